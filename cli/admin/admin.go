@@ -0,0 +1,437 @@
+// Package admin provides ready-made cli commands for common operations on the dynamo,
+// queue, s3 and elector packages.
+//
+// Example:
+//
+//	func main() {
+//	    app := &cli.App{Name: "ops", Summary: "Billing service operations", Commands: admin.Commands()}
+//	    app.Main()
+//	}
+//
+//	// ops dynamo export -table sessions -out sessions.jsonl
+//	// ops queue redrive -from billing-dlq -to billing -max 100
+//	// ops s3 delete-prefix -bucket uploads tmp/2026-10
+//	// ops elector status -table locks
+package admin
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/finch-technologies/go-utils/cli"
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	"github.com/finch-technologies/go-utils/elector"
+	"github.com/finch-technologies/go-utils/queue"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/storage/s3"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Commands returns all admin command groups
+func Commands() []*cli.Command {
+	return []*cli.Command{
+		DynamoCommand(),
+		QueueCommand(),
+		S3Command(),
+		ElectorCommand(),
+	}
+}
+
+// DynamoCommand returns the dynamo export and import commands
+func DynamoCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "dynamo",
+		Summary: "Export and import dynamo tables",
+		Subcommands: []*cli.Command{
+			dynamoExportCommand(),
+			dynamoImportCommand(),
+		},
+	}
+}
+
+// tableFlags holds the flags shared by commands working on a dynamo table
+type tableFlags struct {
+	table  string
+	region string
+}
+
+func (f *tableFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.table, "table", "", "dynamo table name (required)")
+	fs.StringVar(&f.region, "region", os.Getenv("AWS_REGION"), "AWS region")
+}
+
+func (f *tableFlags) open() (*dynamo.DynamoDB, error) {
+	if f.table == "" {
+		return nil, fmt.Errorf("%w: -table is required", cli.ErrUsage)
+	}
+
+	return dynamo.New(dynamo.DbOptions{TableName: f.table, Region: f.region})
+}
+
+func dynamoExportCommand() *cli.Command {
+	var table tableFlags
+	var out string
+
+	return &cli.Command{
+		Name:    "export",
+		Summary: "Write every item in a table to a JSON lines file",
+		Flags: func(fs *flag.FlagSet) {
+			table.register(fs)
+			fs.StringVar(&out, "out", "", "output file (default stdout)")
+		},
+		Run: func(ctx context.Context, c *cli.Context) error {
+			db, err := table.open()
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = c.Stdout
+
+			if out != "" {
+				file, err := os.Create(out)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", out, err)
+				}
+				defer file.Close()
+				w = file
+			}
+
+			count, err := db.Export(ctx, w)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.Stderr, "Exported %d items from %s\n", count, table.table)
+
+			return nil
+		},
+	}
+}
+
+func dynamoImportCommand() *cli.Command {
+	var table tableFlags
+	var in string
+
+	return &cli.Command{
+		Name:    "import",
+		Summary: "Write items from a JSON lines export to a table, replacing existing items",
+		Flags: func(fs *flag.FlagSet) {
+			table.register(fs)
+			fs.StringVar(&in, "in", "", "input file (required)")
+		},
+		Run: func(ctx context.Context, c *cli.Context) error {
+			if in == "" {
+				return fmt.Errorf("%w: -in is required", cli.ErrUsage)
+			}
+
+			db, err := table.open()
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Open(in)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", in, err)
+			}
+			defer file.Close()
+
+			if !c.Confirm(fmt.Sprintf("Import %s into %s, replacing items with the same keys?", in, table.table)) {
+				return cli.ErrAborted
+			}
+
+			count, err := db.Import(ctx, file)
+			if err != nil {
+				return fmt.Errorf("imported %d items before failing: %w", count, err)
+			}
+
+			c.Printf("Imported %d items into %s\n", count, table.table)
+
+			return nil
+		},
+	}
+}
+
+// QueueCommand returns the queue count and redrive commands
+func QueueCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "queue",
+		Summary: "Inspect queues and redrive dead letter queues",
+		Subcommands: []*cli.Command{
+			queueCountCommand(),
+			queueRedriveCommand(),
+		},
+	}
+}
+
+// queueFlags holds the flags shared by queue commands
+type queueFlags struct {
+	driver  string
+	baseUrl string
+	region  string
+}
+
+func (f *queueFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.driver, "driver", string(queue.QueueDriverSQS), "queue driver (sqs or redis)")
+	fs.StringVar(&f.baseUrl, "base-url", os.Getenv("SQS_BASE_URL"), "SQS base URL")
+	fs.StringVar(&f.region, "region", os.Getenv("AWS_REGION"), "AWS region")
+}
+
+func (f *queueFlags) init() error {
+	return queue.Init(queue.QueueConfig{
+		Driver:  queue.QueueDriver(f.driver),
+		BaseUrl: f.baseUrl,
+		Region:  f.region,
+	})
+}
+
+func queueCountCommand() *cli.Command {
+	var flags queueFlags
+
+	return &cli.Command{
+		Name:    "count",
+		Usage:   "[flags] <queue>...",
+		Summary: "Show the approximate number of messages in queues",
+		Flags:   flags.register,
+		Run: func(ctx context.Context, c *cli.Context) error {
+			if _, err := c.Arg(0, "queue"); err != nil {
+				return err
+			}
+
+			if err := flags.init(); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(c.Args))
+
+			for _, name := range c.Args {
+				count, err := queue.Count(ctx, queue.Queue(name))
+				if err != nil {
+					return fmt.Errorf("failed to count messages in %s: %w", name, err)
+				}
+				rows = append(rows, []string{name, strconv.Itoa(count)})
+			}
+
+			c.Table([]string{"QUEUE", "MESSAGES"}, rows)
+
+			return nil
+		},
+	}
+}
+
+func queueRedriveCommand() *cli.Command {
+	var flags queueFlags
+	var from, to string
+	var max, rate int
+
+	return &cli.Command{
+		Name:    "redrive",
+		Summary: "Move messages from one queue to another, e.g. from a dead letter queue",
+		Flags: func(fs *flag.FlagSet) {
+			flags.register(fs)
+			fs.StringVar(&from, "from", "", "source queue (required)")
+			fs.StringVar(&to, "to", "", "target queue (required)")
+			fs.IntVar(&max, "max", 0, "maximum messages to move (default all)")
+			fs.IntVar(&rate, "rate", 10, "maximum messages moved per second")
+		},
+		Run: func(ctx context.Context, c *cli.Context) error {
+			if from == "" || to == "" {
+				return fmt.Errorf("%w: -from and -to are required", cli.ErrUsage)
+			}
+
+			if err := flags.init(); err != nil {
+				return err
+			}
+
+			count, err := queue.Count(ctx, queue.Queue(from))
+			if err != nil {
+				return fmt.Errorf("failed to count messages in %s: %w", from, err)
+			}
+
+			if count == 0 {
+				c.Printf("Queue %s is empty\n", from)
+				return nil
+			}
+
+			if max > 0 && max < count {
+				count = max
+			}
+
+			if !c.Confirm(fmt.Sprintf("Move about %d messages from %s to %s?", count, from, to)) {
+				return cli.ErrAborted
+			}
+
+			moved, err := queue.Redrive(ctx, queue.Queue(from), queue.Queue(to), max, types.RedriveOptions{
+				RatePerSecond: rate,
+			})
+			if err != nil {
+				return fmt.Errorf("moved %d messages before failing: %w", moved, err)
+			}
+
+			c.Printf("Moved %d messages from %s to %s\n", moved, from, to)
+
+			return nil
+		},
+	}
+}
+
+// S3Command returns the s3 prefix cleanup commands
+func S3Command() *cli.Command {
+	return &cli.Command{
+		Name:    "s3",
+		Summary: "Clean up s3 prefixes",
+		Subcommands: []*cli.Command{
+			s3DeletePrefixCommand(),
+			s3CleanupExpiredCommand(),
+		},
+	}
+}
+
+// bucketFlags holds the flags shared by s3 commands
+type bucketFlags struct {
+	bucket    string
+	region    string
+	keyPrefix string
+}
+
+func (f *bucketFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.bucket, "bucket", os.Getenv("S3_BUCKET"), "bucket name (required)")
+	fs.StringVar(&f.region, "region", os.Getenv("S3_REGION"), "AWS region")
+	fs.StringVar(&f.keyPrefix, "key-prefix", "", "key prefix of the client, as used by the service")
+}
+
+func (f *bucketFlags) open() (*s3.Client, error) {
+	if f.bucket == "" {
+		return nil, fmt.Errorf("%w: -bucket is required", cli.ErrUsage)
+	}
+
+	return s3.New(s3.Config{Bucket: f.bucket, Region: f.region, KeyPrefix: f.keyPrefix})
+}
+
+func s3DeletePrefixCommand() *cli.Command {
+	var flags bucketFlags
+
+	return &cli.Command{
+		Name:    "delete-prefix",
+		Usage:   "[flags] <prefix>",
+		Summary: "Delete every object under a prefix",
+		Flags:   flags.register,
+		Run: func(ctx context.Context, c *cli.Context) error {
+			prefix, err := c.Arg(0, "prefix")
+			if err != nil {
+				return err
+			}
+
+			client, err := flags.open()
+			if err != nil {
+				return err
+			}
+
+			if !c.Confirm(fmt.Sprintf("Delete every object under s3://%s/%s?", flags.bucket, prefix)) {
+				return cli.ErrAborted
+			}
+
+			deleted, err := client.DeletePrefix(ctx, prefix)
+			if err != nil {
+				return fmt.Errorf("deleted %d objects before failing: %w", deleted, err)
+			}
+
+			c.Printf("Deleted %d objects\n", deleted)
+
+			return nil
+		},
+	}
+}
+
+func s3CleanupExpiredCommand() *cli.Command {
+	var flags bucketFlags
+
+	return &cli.Command{
+		Name:    "cleanup-expired",
+		Summary: "Delete expiry date prefixes that have passed (see s3.ExpiringKey)",
+		Flags:   flags.register,
+		Run: func(ctx context.Context, c *cli.Context) error {
+			client, err := flags.open()
+			if err != nil {
+				return err
+			}
+
+			if !c.Confirm(fmt.Sprintf("Delete expired prefixes in bucket %s?", flags.bucket)) {
+				return cli.ErrAborted
+			}
+
+			deleted, err := client.DeleteExpiredPrefixes(ctx)
+			if err != nil {
+				return fmt.Errorf("deleted %d objects before failing: %w", deleted, err)
+			}
+
+			c.Printf("Deleted %d expired objects\n", deleted)
+
+			return nil
+		},
+	}
+}
+
+// ElectorCommand returns the elector status command
+func ElectorCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "elector",
+		Summary: "Inspect leader election",
+		Subcommands: []*cli.Command{
+			electorStatusCommand(),
+		},
+	}
+}
+
+func electorStatusCommand() *cli.Command {
+	var table tableFlags
+	var keys string
+
+	return &cli.Command{
+		Name:    "status",
+		Summary: "Show the current leader of election locks",
+		Flags: func(fs *flag.FlagSet) {
+			table.register(fs)
+			fs.StringVar(&keys, "key", "", "comma separated lock key names (default the elector's default key)")
+		},
+		Run: func(ctx context.Context, c *cli.Context) error {
+			if _, err := table.open(); err != nil {
+				return err
+			}
+
+			rows := [][]string{}
+
+			for _, key := range strings.Split(keys, ",") {
+				key = strings.TrimSpace(key)
+
+				status, err := elector.GetStatus(elector.ElectorConfig{TableName: table.table, KeyName: key})
+				if err != nil {
+					return fmt.Errorf("failed to get leader of %s: %w", utils.StringOrDefault(key, "default lock"), err)
+				}
+
+				rows = append(rows, []string{
+					utils.StringOrDefault(key, "(default)"),
+					utils.StringOrDefault(status.Leader, "(none)"),
+					formatTime(status.AcquiredAt),
+					formatTime(status.ExpiresAt),
+				})
+			}
+
+			c.Table([]string{"KEY", "LEADER", "ACQUIRED", "EXPIRES"}, rows)
+
+			return nil
+		},
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
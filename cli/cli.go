@@ -0,0 +1,244 @@
+// Package cli is a small toolkit for admin command line tools: nested subcommands with
+// their own flags, table output and confirmation prompts. See the admin subpackage for
+// ready-made commands built on the other packages in this module.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+)
+
+var (
+	// ErrUsage is returned when a command is called with missing or invalid arguments
+	ErrUsage = errors.New("invalid usage")
+	// ErrAborted is returned by commands when a confirmation prompt is declined
+	ErrAborted = errors.New("aborted")
+)
+
+// Command is a named command. A command either has Subcommands or a Run function.
+type Command struct {
+	Name        string
+	Usage       string // Arguments shown after the command name in help, e.g. "[flags] <queue>"
+	Summary     string // One line description shown in help
+	Flags       func(fs *flag.FlagSet)
+	Run         func(ctx context.Context, c *Context) error
+	Subcommands []*Command
+}
+
+// App is a command line tool made of commands
+type App struct {
+	Name     string
+	Summary  string
+	Commands []*Command
+	Stdin    io.Reader // Defaults to os.Stdin
+	Stdout   io.Writer // Defaults to os.Stdout
+	Stderr   io.Writer // Defaults to os.Stderr
+}
+
+// Context is passed to a running command
+type Context struct {
+	Args   []string // Positional arguments left after flag parsing
+	Stdout io.Writer
+	Stderr io.Writer
+
+	stdin     *bufio.Reader
+	assumeYes bool
+}
+
+// Main runs the app with the process arguments, cancelling the context on SIGINT or
+// SIGTERM, and exits with status 1 if the command fails.
+//
+// Example:
+//
+//	func main() {
+//	    app := &cli.App{Name: "ops", Commands: admin.Commands()}
+//	    app.Main()
+//	}
+func (a *App) Main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := a.Run(ctx, os.Args[1:])
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	fmt.Fprintf(a.stderr(), "error: %v\n", err)
+	stop()
+	os.Exit(1)
+}
+
+// Run finds the command named by args and runs it
+func (a *App) Run(ctx context.Context, args []string) error {
+	root := &Command{Name: a.Name, Summary: a.Summary, Subcommands: a.Commands}
+
+	return a.run(ctx, root, a.Name, args)
+}
+
+func (a *App) run(ctx context.Context, cmd *Command, path string, args []string) error {
+	if len(cmd.Subcommands) > 0 {
+		if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			a.printCommands(cmd, path)
+			if len(args) == 0 {
+				return ErrUsage
+			}
+			return nil
+		}
+
+		for _, sub := range cmd.Subcommands {
+			if sub.Name == args[0] {
+				return a.run(ctx, sub, path+" "+sub.Name, args[1:])
+			}
+		}
+
+		fmt.Fprintf(a.stderr(), "unknown command %q\n\n", args[0])
+		a.printCommands(cmd, path)
+
+		return ErrUsage
+	}
+
+	if cmd.Run == nil {
+		return fmt.Errorf("command %s has nothing to run", path)
+	}
+
+	c := &Context{
+		Stdout: a.stdout(),
+		Stderr: a.stderr(),
+		stdin:  bufio.NewReader(a.stdin()),
+	}
+
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(a.stderr())
+	fs.BoolVar(&c.assumeYes, "y", false, "answer yes to confirmation prompts")
+
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr(), "Usage: %s %s\n", path, commandUsage(cmd))
+		if cmd.Summary != "" {
+			fmt.Fprintf(a.stderr(), "\n%s\n", cmd.Summary)
+		}
+		fmt.Fprintln(a.stderr(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c.Args = fs.Args()
+
+	err := cmd.Run(ctx, c)
+
+	if errors.Is(err, ErrUsage) {
+		fs.Usage()
+	}
+
+	return err
+}
+
+func (a *App) printCommands(cmd *Command, path string) {
+	w := a.stderr()
+
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n", path)
+
+	if cmd.Summary != "" {
+		fmt.Fprintf(w, "\n%s\n", cmd.Summary)
+	}
+
+	fmt.Fprintln(w, "\nCommands:")
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, sub := range cmd.Subcommands {
+		fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Summary)
+	}
+	tw.Flush()
+}
+
+func commandUsage(cmd *Command) string {
+	if cmd.Usage != "" {
+		return cmd.Usage
+	}
+	return "[flags]"
+}
+
+func (a *App) stdin() io.Reader {
+	if a.Stdin != nil {
+		return a.Stdin
+	}
+	return os.Stdin
+}
+
+func (a *App) stdout() io.Writer {
+	if a.Stdout != nil {
+		return a.Stdout
+	}
+	return os.Stdout
+}
+
+func (a *App) stderr() io.Writer {
+	if a.Stderr != nil {
+		return a.Stderr
+	}
+	return os.Stderr
+}
+
+// Printf writes formatted output to Stdout
+func (c *Context) Printf(format string, args ...any) {
+	fmt.Fprintf(c.Stdout, format, args...)
+}
+
+// Arg returns the positional argument at index i, or ErrUsage if it is missing
+func (c *Context) Arg(i int, name string) (string, error) {
+	if i >= len(c.Args) || c.Args[i] == "" {
+		return "", fmt.Errorf("%w: missing %s", ErrUsage, name)
+	}
+	return c.Args[i], nil
+}
+
+// Confirm asks a yes/no question and reports whether it was answered yes. It returns
+// true without asking when the command was run with -y.
+func (c *Context) Confirm(prompt string) bool {
+	if c.assumeYes {
+		return true
+	}
+
+	fmt.Fprintf(c.Stderr, "%s [y/N]: ", prompt)
+
+	answer, _ := c.stdin.ReadString('\n')
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// Table writes rows to Stdout as aligned columns under headers
+func (c *Context) Table(headers []string, rows [][]string) {
+	WriteTable(c.Stdout, headers, rows)
+}
+
+// WriteTable writes rows to w as aligned columns under headers
+func WriteTable(w io.Writer, headers []string, rows [][]string) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	tw.Flush()
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func testApp(stdin string) (*App, *bytes.Buffer, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	var name string
+
+	app := &App{
+		Name:   "ops",
+		Stdin:  strings.NewReader(stdin),
+		Stdout: stdout,
+		Stderr: stderr,
+		Commands: []*Command{
+			{
+				Name: "queue",
+				Subcommands: []*Command{
+					{
+						Name:  "purge",
+						Flags: func(fs *flag.FlagSet) { fs.StringVar(&name, "name", "", "queue name") },
+						Run: func(ctx context.Context, c *Context) error {
+							if name == "" {
+								return ErrUsage
+							}
+							if !c.Confirm("Purge " + name + "?") {
+								return ErrAborted
+							}
+							c.Table([]string{"QUEUE", "PURGED"}, [][]string{{name, "12"}})
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return app, stdout, stderr
+}
+
+func TestApp_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantErr    error
+		wantStdout string
+	}{
+		{
+			name:       "confirmed",
+			args:       []string{"queue", "purge", "-name", "billing"},
+			stdin:      "y\n",
+			wantStdout: "QUEUE    PURGED\nbilling  12\n",
+		},
+		{
+			name:       "assume yes",
+			args:       []string{"queue", "purge", "-y", "-name", "billing"},
+			wantStdout: "QUEUE    PURGED\nbilling  12\n",
+		},
+		{
+			name:    "declined",
+			args:    []string{"queue", "purge", "-name", "billing"},
+			stdin:   "n\n",
+			wantErr: ErrAborted,
+		},
+		{
+			name:    "missing flag",
+			args:    []string{"queue", "purge"},
+			wantErr: ErrUsage,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"queue", "drop"},
+			wantErr: ErrUsage,
+		},
+		{
+			name:    "no command",
+			args:    []string{},
+			wantErr: ErrUsage,
+		},
+		{
+			name: "help",
+			args: []string{"queue", "help"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, stdout, _ := testApp(tt.stdin)
+
+			err := app.Run(context.Background(), tt.args)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestApp_RunHelpListsCommands(t *testing.T) {
+	app, _, stderr := testApp("")
+
+	if err := app.Run(context.Background(), []string{"help"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !strings.Contains(stderr.String(), "queue") {
+		t.Errorf("help output %q does not list commands", stderr.String())
+	}
+}
@@ -0,0 +1,326 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// maxBatchGetItems is the most keys DynamoDB accepts in one BatchGetItem request
+const maxBatchGetItems = 100
+
+// KeyPair identifies an item by partition key and optional sort key
+type KeyPair struct {
+	Key     string
+	SortKey string // Sort key value for tables with composite keys
+}
+
+// BatchItem is an item written by BatchPut
+type BatchItem[T any] struct {
+	Key     string
+	SortKey string        // Sort key value for tables with composite keys
+	Value   T             // Value stored as by Put
+	Ttl     time.Duration // TTL for the item (overrides default table TTL)
+}
+
+// BatchResult is an item returned by BatchGet
+type BatchResult[T any] struct {
+	Key    KeyPair
+	Value  *T         // Item value, nil if the item doesn't exist or has expired
+	Expiry *time.Time // When the item expires, nil if it has no TTL
+}
+
+// BatchPut writes items with BatchWriteItem in chunks of 25, retrying unprocessed items with
+// backoff. Items are stored exactly as Put would store them. When items repeat a key, the
+// last one is written.
+//
+// Example:
+//
+//	err := db.BatchPut(ctx, []BatchItem[any]{
+//	    {Key: "user123", SortKey: "profile", Value: profile},
+//	    {Key: "user456", SortKey: "profile", Value: other, Ttl: time.Hour},
+//	})
+func (d *DynamoDB) BatchPut(ctx context.Context, items []BatchItem[any]) error {
+	requests := make([]types.WriteRequest, 0, len(items))
+	positions := map[KeyPair]int{}
+
+	for _, item := range items {
+		attributes, err := d.buildItem(ctx, item.Key, item.Value, PutOptions{Ttl: item.Ttl, SortKey: item.SortKey})
+		if err != nil {
+			return fmt.Errorf("failed to build item %s: %w", item.Key, err)
+		}
+
+		request := types.WriteRequest{PutRequest: &types.PutRequest{Item: attributes}}
+
+		// BatchWriteItem rejects duplicate keys in a single call
+		key := d.batchKey(item.Key, item.SortKey)
+		if i, ok := positions[key]; ok {
+			requests[i] = request
+			continue
+		}
+
+		positions[key] = len(requests)
+		requests = append(requests, request)
+	}
+
+	defer d.invalidate(keysOf(positions)...)
+
+	return d.batchWriteChunks(ctx, requests)
+}
+
+// BatchDelete deletes items with BatchWriteItem in chunks of 25, retrying unprocessed items
+// with backoff
+func (d *DynamoDB) BatchDelete(ctx context.Context, keys []KeyPair) error {
+	requests := make([]types.WriteRequest, 0, len(keys))
+	seen := map[KeyPair]bool{}
+
+	for _, key := range keys {
+		batchKey := d.batchKey(key.Key, key.SortKey)
+		if seen[batchKey] {
+			continue
+		}
+		seen[batchKey] = true
+
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: d.itemKey(batchKey.Key, batchKey.SortKey)},
+		})
+	}
+
+	defer d.invalidate(keys...)
+
+	return d.batchWriteChunks(ctx, requests)
+}
+
+// BatchPut is the generic form of DynamoDB.BatchPut for the table registered as tableName
+func BatchPut[T any](ctx context.Context, tableName string, items []BatchItem[T]) error {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	values := make([]BatchItem[any], len(items))
+	for i, item := range items {
+		values[i] = BatchItem[any]{Key: item.Key, SortKey: item.SortKey, Value: item.Value, Ttl: item.Ttl}
+	}
+
+	return table.BatchPut(ctx, values)
+}
+
+// BatchDelete deletes keys from the table registered as tableName, see DynamoDB.BatchDelete
+func BatchDelete(ctx context.Context, tableName string, keys []KeyPair) error {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	return table.BatchDelete(ctx, keys)
+}
+
+// BatchGet reads keys from the table registered as tableName with BatchGetItem, in chunks
+// of 100 keys, retrying unprocessed keys with backoff. Results are returned in the order of
+// keys, with a nil Value for items that don't exist or have expired, as Get does.
+//
+// Example:
+//
+//	results, err := BatchGet[Person]("users", []KeyPair{{Key: "user123"}, {Key: "user456"}})
+//	for _, result := range results {
+//	    if result.Value != nil {
+//	        fmt.Println(result.Key.Key, result.Value.Name)
+//	    }
+//	}
+func BatchGet[T any](tableName string, keys []KeyPair) ([]BatchResult[T], error) {
+	return BatchGetContext[T](context.Background(), tableName, keys)
+}
+
+// BatchGetContext is the form of BatchGet that uses ctx for the DynamoDB calls
+func BatchGetContext[T any](ctx context.Context, tableName string, keys []KeyPair) ([]BatchResult[T], error) {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := table.batchGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult[T], len(keys))
+
+	for i, key := range keys {
+		results[i].Key = key
+
+		item, ok := items[table.batchKey(key.Key, key.SortKey)]
+		if !ok {
+			continue
+		}
+
+		expiry, expired := table.itemExpiry(item)
+		results[i].Expiry = expiry
+
+		if expired {
+			table.purgeExpired(item)
+			continue
+		}
+
+		var value T
+
+		if table.valueStoreMode == ValueStoreModeJson {
+			var stored any
+
+			switch member := item[table.valueAttribute].(type) {
+			case *types.AttributeValueMemberS:
+				stored = member.Value
+			case *types.AttributeValueMemberB:
+				stored = member.Value
+			}
+
+			if (stored == nil || stored == "") && !table.isOverflowed(item) {
+				continue
+			}
+
+			batchKey := table.batchKey(key.Key, key.SortKey)
+
+			payload, err := table.decodeValue(ctx, item, batchKey.Key, batchKey.SortKey, stored)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := json.Unmarshal([]byte(payload.(string)), &value); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal item %s: %w", key.Key, err)
+			}
+		} else if err := attributevalue.UnmarshalMap(item, &value); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal item %s: %w", key.Key, err)
+		}
+
+		results[i].Value = &value
+	}
+
+	return results, nil
+}
+
+// batchGet reads keys with BatchGetItem, returning the items found by key
+func (d *DynamoDB) batchGet(ctx context.Context, keys []KeyPair) (map[KeyPair]map[string]types.AttributeValue, error) {
+	var requestKeys []map[string]types.AttributeValue
+	seen := map[KeyPair]bool{}
+
+	// BatchGetItem rejects duplicate keys in a single call
+	for _, key := range keys {
+		batchKey := d.batchKey(key.Key, key.SortKey)
+		if seen[batchKey] {
+			continue
+		}
+		seen[batchKey] = true
+
+		requestKeys = append(requestKeys, d.itemKey(batchKey.Key, batchKey.SortKey))
+	}
+
+	items := make(map[KeyPair]map[string]types.AttributeValue, len(requestKeys))
+
+	for start := 0; start < len(requestKeys); start += maxBatchGetItems {
+		end := min(start+maxBatchGetItems, len(requestKeys))
+
+		pending := map[string]types.KeysAndAttributes{d.tableName: {Keys: requestKeys[start:end]}}
+		backoff := 100 * time.Millisecond
+
+		for attempt := 0; len(pending[d.tableName].Keys) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt > 8 {
+					return nil, fmt.Errorf("failed to read %d items from dynamodb: retries exhausted", len(pending[d.tableName].Keys))
+				}
+
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+
+				backoff *= 2
+			}
+
+			result, err := d.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: pending,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read items from dynamodb: %w", err)
+			}
+
+			for _, item := range result.Responses[d.tableName] {
+				items[d.keyOf(item)] = item
+			}
+
+			pending = result.UnprocessedKeys
+		}
+	}
+
+	return items, nil
+}
+
+// keyOf returns the key of an item
+func (d *DynamoDB) keyOf(item map[string]types.AttributeValue) KeyPair {
+	var key KeyPair
+
+	if member, ok := item[d.partitionKeyAttribute].(*types.AttributeValueMemberS); ok {
+		key.Key = member.Value
+	}
+
+	if d.sortKeyAttribute != "" {
+		if member, ok := item[d.sortKeyAttribute].(*types.AttributeValueMemberS); ok {
+			key.SortKey = member.Value
+		}
+	}
+
+	return key
+}
+
+// keysOf returns the keys of positions
+func keysOf(positions map[KeyPair]int) []KeyPair {
+	keys := make([]KeyPair, 0, len(positions))
+	for key := range positions {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// itemExpiry returns the expiry time of an item and whether it has passed
+func (d *DynamoDB) itemExpiry(item map[string]types.AttributeValue) (*time.Time, bool) {
+	var timestamp int64
+
+	if err := attributevalue.Unmarshal(item[d.ttlAttribute], &timestamp); err != nil || timestamp <= 0 {
+		return nil, false
+	}
+
+	expiry := time.Unix(timestamp, 0)
+
+	return &expiry, time.Now().Unix() > timestamp
+}
+
+// batchKey normalises a key as Put and Delete do, defaulting the sort key to "null" on
+// tables with a sort key
+func (d *DynamoDB) batchKey(key, sortKey string) KeyPair {
+	if d.sortKeyAttribute == "" {
+		return KeyPair{Key: key}
+	}
+	return KeyPair{Key: key, SortKey: utils.StringOrDefault(sortKey, "null")}
+}
+
+// batchWriteChunks writes requests in chunks of the BatchWriteItem limit
+func (d *DynamoDB) batchWriteChunks(ctx context.Context, requests []types.WriteRequest) error {
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		end := min(start+maxBatchWriteItems, len(requests))
+
+		if err := d.batchWrite(ctx, requests[start:end]); err != nil {
+			return fmt.Errorf("failed to write items %d-%d of %d: %w", start, end, len(requests), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package dynamo
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// readCache holds items read by Get, including items found missing. Writes through this
+// process invalidate their keys; writes by other processes show after the TTL.
+type readCache struct {
+	items *utils.LRUCache[KeyPair, map[string]types.AttributeValue]
+
+	// generation changes with every invalidation, so a read that raced a write doesn't cache
+	// what it read from before the write
+	generation atomic.Uint64
+}
+
+func newReadCache(size int, ttl time.Duration) *readCache {
+	return &readCache{
+		items: utils.NewLRUCache[KeyPair, map[string]types.AttributeValue](size, utils.DurationOrDefault(ttl, 5*time.Second)),
+	}
+}
+
+// cachedGetItem reads the item key through the read cache, if the table has one
+func (d *DynamoDB) cachedGetItem(ctx context.Context, key KeyPair, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	if d.cache == nil {
+		return d.getItem(ctx, input)
+	}
+
+	if item, ok := d.cache.items.Get(key); ok {
+		return &dynamodb.GetItemOutput{Item: item}, nil
+	}
+
+	generation := d.cache.generation.Load()
+
+	result, err := d.getItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.cache.generation.Load() == generation {
+		d.cache.items.Set(key, result.Item)
+	}
+
+	return result, nil
+}
+
+// invalidate removes keys written by this process from the read cache
+func (d *DynamoDB) invalidate(keys ...KeyPair) {
+	if d.cache == nil {
+		return
+	}
+
+	d.cache.generation.Add(1)
+
+	for _, key := range keys {
+		d.cache.items.Delete(d.batchKey(key.Key, key.SortKey))
+	}
+}
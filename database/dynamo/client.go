@@ -3,18 +3,23 @@ package dynamo
 import (
 	"context"
 	"fmt"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
-// dynamodbClient holds a singleton instance of the DynamoDB client to avoid
-// creating multiple clients for the same region
-var dynamodbClient *dynamodb.Client
+// dynamodbClients holds one shared DynamoDB client per region and endpoint to avoid
+// creating multiple clients for the same service
+var (
+	dynamodbClientMu sync.Mutex
+	dynamodbClients  = make(map[ClientConfig]*dynamodb.Client)
+)
 
-// GetDynamoClient returns a singleton DynamoDB client for the specified AWS region.
+// GetDynamoClient returns a shared DynamoDB client for the specified AWS region.
 // This function implements lazy initialization and reuses the same client instance
-// for subsequent calls to improve performance and resource utilization.
+// for subsequent calls with the same region to improve performance and resource utilization.
 //
 // Parameters:
 //   - region: The AWS region identifier (e.g., "us-east-1", "af-south-1") where the
@@ -25,16 +30,54 @@ var dynamodbClient *dynamodb.Client
 //   - error: Returns an error if the AWS configuration cannot be loaded or the client
 //     cannot be created
 func GetDynamoClient(region string) (*dynamodb.Client, error) {
-	if dynamodbClient == nil {
-		awsConfig, err := config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(region),
-		)
+	return GetClient(ClientConfig{Region: region})
+}
+
+// GetClient returns a shared DynamoDB client for cfg, creating it on first use. Clients are
+// cached per region and endpoint, so tables in several regions, or on DynamoDB Local or
+// LocalStack next to the regional service, each get their own.
+//
+// Example:
+//
+//	client, err := GetClient(ClientConfig{Region: "af-south-1", Endpoint: "http://localhost:4566"})
+func GetClient(cfg ClientConfig) (*dynamodb.Client, error) {
+	dynamodbClientMu.Lock()
+	defer dynamodbClientMu.Unlock()
+
+	client := dynamodbClients[cfg]
+
+	if client == nil {
+		var err error
+
+		client, err = NewClient(cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+			return nil, err
 		}
 
-		dynamodbClient = dynamodb.NewFromConfig(awsConfig)
+		dynamodbClients[cfg] = client
 	}
 
-	return dynamodbClient, nil
+	return client, nil
+}
+
+// NewClient creates a dedicated DynamoDB client from an explicit config, e.g. to talk to
+// a local DynamoDB endpoint alongside the regional service.
+//
+// Example:
+//
+//	client, err := NewClient(ClientConfig{Region: "af-south-1", Endpoint: "http://localhost:8000"})
+//	db, err := New(DbOptions{TableName: "my-table", Client: client})
+func NewClient(cfg ClientConfig) (*dynamodb.Client, error) {
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion(cfg.Region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return dynamodb.NewFromConfig(awsConfig, func(o *dynamodb.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	}), nil
 }
@@ -0,0 +1,122 @@
+package dynamo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Compression is the codec used to compress large values in json value store mode
+type Compression string
+
+const (
+	// CompressionNone stores values as they are
+	CompressionNone Compression = "none"
+	// CompressionGzip compresses values with gzip
+	CompressionGzip Compression = "gzip"
+)
+
+// defaultCompressionThreshold is the value size above which values are compressed. Smaller
+// values don't gain enough to be worth the CPU, and stay readable in the console.
+const defaultCompressionThreshold = 4 * 1024
+
+// gzipMagic starts every gzip stream, so compressed values are recognized without a marker
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressValue compresses payload when compression is enabled for opts and payload is
+// above the table's threshold. It returns nil when the value should be stored as it is,
+// including when compressing doesn't make it smaller.
+func (d *DynamoDB) compressValue(payload string, opts PutOptions) ([]byte, error) {
+	compression := opts.Compress
+	if compression == "" {
+		compression = d.compression
+	}
+
+	if compression == "" || compression == CompressionNone || len(payload) <= d.compressionThreshold {
+		return nil, nil
+	}
+
+	var buffer bytes.Buffer
+
+	switch compression {
+	case CompressionGzip:
+		writer := gzip.NewWriter(&buffer)
+
+		if _, err := writer.Write([]byte(payload)); err != nil {
+			return nil, fmt.Errorf("failed to compress value: %w", err)
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress value: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported value compression %q", compression)
+	}
+
+	if buffer.Len() >= len(payload) {
+		return nil, nil
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// decompressValue returns the original value of a compressed payload, and other payloads
+// as they are. Values are decompressed whether or not compression is enabled for the table.
+func decompressValue(payload []byte) (string, error) {
+	if !bytes.HasPrefix(payload, gzipMagic) {
+		return string(payload), nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %w", err)
+	}
+	defer reader.Close()
+
+	value, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %w", err)
+	}
+
+	return string(value), nil
+}
+
+// decodeValue turns the value attribute of an item read in json value store mode back into
+// the stored string, loading it from the overflow store and decrypting and decompressing it
+// as needed. Compressed values are stored as binary, or as encrypted strings on tables with
+// encryption.
+func (d *DynamoDB) decodeValue(ctx context.Context, item map[string]types.AttributeValue, key, sortKey string, value any) (any, error) {
+	if d.isOverflowed(item) {
+		data, err := d.loadOverflow(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+
+		value = data
+		if d.encryption != nil {
+			value = string(data)
+		}
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return decompressValue(v)
+	case string:
+		if d.encryption == nil {
+			return v, nil
+		}
+
+		plaintext, err := d.decryptValue(ctx, d.itemAad(key, sortKey), v)
+		if err != nil {
+			return nil, err
+		}
+
+		return decompressValue([]byte(plaintext))
+	default:
+		return value, nil
+	}
+}
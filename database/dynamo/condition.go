@@ -0,0 +1,125 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrConditionFailed is returned when a conditional Put or Update doesn't meet its
+// condition, e.g. because another writer changed the item first
+var ErrConditionFailed = errors.New("condition check failed")
+
+// writeCondition returns the condition expression for a Put or Update with opts, adding its
+// placeholders to names and values. Returns nil if the write is unconditional.
+func (d *DynamoDB) writeCondition(opts PutOptions, names map[string]string, values map[string]types.AttributeValue) (*string, error) {
+	return d.condition(opts, true, names, values)
+}
+
+// condition builds the condition expression for opts. Versioned tables check the item
+// version on every write, and on other operations only when ExpectedVersion is set.
+func (d *DynamoDB) condition(opts PutOptions, write bool, names map[string]string, values map[string]types.AttributeValue) (*string, error) {
+	var conditions []string
+
+	if opts.IfNotExists {
+		names["#cond_pk"] = d.partitionKeyAttribute
+		conditions = append(conditions, "attribute_not_exists(#cond_pk)")
+	}
+
+	if opts.IfExpired {
+		names["#cond_pk"] = d.partitionKeyAttribute
+		names["#cond_ttl"] = d.ttlAttribute
+		values[":cond_now"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)}
+		conditions = append(conditions, "(attribute_not_exists(#cond_pk) OR #cond_ttl < :cond_now)")
+	}
+
+	if !opts.ExpectedExpiry.IsZero() {
+		names["#cond_ttl"] = d.ttlAttribute
+		values[":cond_expiry"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.ExpectedExpiry.Unix(), 10)}
+		conditions = append(conditions, "#cond_ttl = :cond_expiry")
+	}
+
+	if opts.ExpectedValue != nil {
+		value, err := attributevalue.Marshal(opts.ExpectedValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal expected value: %w", err)
+		}
+
+		names["#cond_value"] = d.valueAttribute
+		values[":cond_value"] = value
+		conditions = append(conditions, "#cond_value = :cond_value")
+	}
+
+	if d.versionAttribute != "" && (write || opts.ExpectedVersion > 0) {
+		names["#cond_version"] = d.versionAttribute
+
+		if opts.ExpectedVersion == 0 {
+			conditions = append(conditions, "attribute_not_exists(#cond_version)")
+		} else {
+			values[":cond_version"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.ExpectedVersion, 10)}
+			conditions = append(conditions, "#cond_version = :cond_version")
+		}
+	}
+
+	if opts.Condition != "" {
+		for name, attribute := range opts.ConditionNames {
+			names[name] = attribute
+		}
+
+		for name, value := range opts.ConditionValues {
+			attributeValue, err := attributevalue.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal condition value %s: %w", name, err)
+			}
+			values[name] = attributeValue
+		}
+
+		conditions = append(conditions, "("+opts.Condition+")")
+	}
+
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	expression := strings.Join(conditions, " AND ")
+
+	return &expression, nil
+}
+
+// nextVersion returns the version attribute value written by a versioned Put or Update
+func nextVersion(opts PutOptions) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.ExpectedVersion+1, 10)}
+}
+
+// itemVersion returns the version of an item, 0 if it has none
+func (d *DynamoDB) itemVersion(item map[string]types.AttributeValue) int64 {
+	var version int64
+
+	if d.versionAttribute != "" {
+		_ = attributevalue.Unmarshal(item[d.versionAttribute], &version)
+	}
+
+	return version
+}
+
+// conditionError maps a failed condition check to ErrConditionFailed
+func conditionError(err error) error {
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return fmt.Errorf("%w: %v", ErrConditionFailed, err)
+	}
+	return err
+}
+
+// nilIfEmpty returns nil for empty expression maps, which DynamoDB rejects
+func nilIfEmpty[V any](m map[string]V) map[string]V {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,102 @@
+package dynamo
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestWriteCondition(t *testing.T) {
+	table := &DynamoDB{partitionKeyAttribute: "id", ttlAttribute: "expiration_time"}
+	expiry := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name           string
+		opts           PutOptions
+		expected       string
+		expectedValues map[string]string
+	}{
+		{"unconditional", PutOptions{}, "", nil},
+		{"if not exists", PutOptions{IfNotExists: true}, "attribute_not_exists(#cond_pk)", nil},
+		{"if expired", PutOptions{IfExpired: true}, "(attribute_not_exists(#cond_pk) OR #cond_ttl < :cond_now)", nil},
+		{"expected expiry", PutOptions{ExpectedExpiry: expiry}, "#cond_ttl = :cond_expiry", map[string]string{":cond_expiry": "1700000000"}},
+		{
+			"with a condition",
+			PutOptions{ExpectedExpiry: expiry, Condition: "#owner = :owner", ConditionNames: map[string]string{"#owner": "owner"}, ConditionValues: map[string]any{":owner": "a"}},
+			"#cond_ttl = :cond_expiry AND (#owner = :owner)",
+			map[string]string{":cond_expiry": "1700000000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := map[string]string{}
+			values := map[string]types.AttributeValue{}
+
+			condition, err := table.writeCondition(tt.opts, names, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ""
+			if condition != nil {
+				got = *condition
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+
+			for name, expected := range tt.expectedValues {
+				if value, ok := values[name].(*types.AttributeValueMemberN); !ok || value.Value != expected {
+					t.Errorf("expected %s to be %s, got %v", name, expected, values[name])
+				}
+			}
+		})
+	}
+}
+
+func TestIfExpiredUsesTableAttributes(t *testing.T) {
+	table := &DynamoDB{partitionKeyAttribute: "pk", ttlAttribute: "ttl"}
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	before := time.Now().Unix()
+	table.writeCondition(PutOptions{IfExpired: true}, names, values)
+
+	if names["#cond_pk"] != "pk" || names["#cond_ttl"] != "ttl" {
+		t.Errorf("expected the table's key and TTL attributes, got %v", names)
+	}
+
+	now, ok := values[":cond_now"].(*types.AttributeValueMemberN)
+	if !ok {
+		t.Fatalf("expected the current time, got %v", values[":cond_now"])
+	}
+	if seconds, _ := strconv.ParseInt(now.Value, 10, 64); seconds < before {
+		t.Errorf("expected the current time, got %s", now.Value)
+	}
+}
+
+func TestExpectedValueCondition(t *testing.T) {
+	table := &DynamoDB{partitionKeyAttribute: "id", valueAttribute: "value"}
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	condition, err := table.condition(PutOptions{ExpectedValue: "worker-1"}, false, names, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if condition == nil || *condition != "#cond_value = :cond_value" {
+		t.Fatalf("expected a value condition, got %v", condition)
+	}
+	if names["#cond_value"] != "value" {
+		t.Errorf("expected the table's value attribute, got %v", names)
+	}
+	if value, ok := values[":cond_value"].(*types.AttributeValueMemberS); !ok || value.Value != "worker-1" {
+		t.Errorf("expected the expected value as a string, got %v", values[":cond_value"])
+	}
+}
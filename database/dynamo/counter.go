@@ -0,0 +1,134 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Increment atomically adds delta to a numeric attribute of the item key and returns the
+// new value. Missing items and attributes start at 0, so concurrent increments never lose
+// updates the way read-modify-write with Put does. The TTL of options (or the table's) is
+// only set when the item is created, so a rate limit window doesn't slide with every hit.
+// The conditions of options apply; on versioned tables the version is only checked when
+// ExpectedVersion is set, but is always advanced.
+//
+// Example:
+//
+//	count, err := db.Increment("ratelimit:user123", "hits", 1, dynamo.PutOptions{Ttl: time.Minute})
+//	if count > 100 {
+//	    return ErrRateLimited
+//	}
+func (d *DynamoDB) Increment(key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	return d.IncrementContext(context.Background(), key, attribute, delta, options...)
+}
+
+// IncrementContext works like Increment, using ctx for the DynamoDB call
+func (d *DynamoDB) IncrementContext(ctx context.Context, key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	if attribute == "" || attribute == d.partitionKeyAttribute || attribute == d.sortKeyAttribute {
+		return 0, fmt.Errorf("invalid counter attribute %q", attribute)
+	}
+
+	opts := getSetOptions(options...)
+
+	names := map[string]string{"#counter": attribute}
+	values := map[string]types.AttributeValue{
+		":delta": &types.AttributeValueMemberN{Value: strconv.FormatInt(delta, 10)},
+	}
+
+	add := []string{"#counter :delta"}
+	var set []string
+
+	if ttl := utils.DurationOrDefault(opts.Ttl, d.ttl); ttl > 0 {
+		names["#ttl"] = d.ttlAttribute
+		values[":ttl"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)}
+		set = append(set, "#ttl = if_not_exists(#ttl, :ttl)")
+	}
+
+	condition, err := d.condition(opts, false, names, values)
+	if err != nil {
+		return 0, err
+	}
+
+	if d.versionAttribute != "" {
+		names["#cond_version"] = d.versionAttribute
+		values[":one"] = &types.AttributeValueMemberN{Value: "1"}
+		add = append(add, "#cond_version :one")
+	}
+
+	expression := "ADD " + strings.Join(add, ", ")
+	if len(set) > 0 {
+		expression += " SET " + strings.Join(set, ", ")
+	}
+
+	result, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.tableName),
+		Key:                       d.itemKey(key, d.sortKeyValue(opts.SortKey)),
+		UpdateExpression:          aws.String(expression),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	d.invalidate(KeyPair{Key: key, SortKey: opts.SortKey})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s of %s: %w", attribute, key, conditionError(err))
+	}
+
+	var value int64
+	if err := attributevalue.Unmarshal(result.Attributes[attribute], &value); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal counter %s: %w", attribute, err)
+	}
+
+	return value, nil
+}
+
+// Decrement atomically subtracts delta from a numeric attribute, as Increment adds to it
+func (d *DynamoDB) Decrement(key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	return d.IncrementContext(context.Background(), key, attribute, -delta, options...)
+}
+
+// DecrementContext works like Decrement, using ctx for the DynamoDB call
+func (d *DynamoDB) DecrementContext(ctx context.Context, key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	return d.IncrementContext(ctx, key, attribute, -delta, options...)
+}
+
+// Increment atomically adds delta to an attribute of an item in the table registered as
+// tableName and returns the new value
+//
+// Example:
+//
+//	count, err := dynamo.Increment("rate-limits", "user123", "hits", 1)
+func Increment(tableName, key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	return IncrementContext(context.Background(), tableName, key, attribute, delta, options...)
+}
+
+// IncrementContext works like Increment, using ctx for the DynamoDB call
+func IncrementContext(ctx context.Context, tableName, key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	table, err := getTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	return table.IncrementContext(ctx, key, attribute, delta, options...)
+}
+
+// Decrement atomically subtracts delta from an attribute of an item in the table registered
+// as tableName and returns the new value
+func Decrement(tableName, key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	return IncrementContext(context.Background(), tableName, key, attribute, -delta, options...)
+}
+
+// DecrementContext works like Decrement, using ctx for the DynamoDB call
+func DecrementContext(ctx context.Context, tableName, key, attribute string, delta int64, options ...PutOptions) (int64, error) {
+	return IncrementContext(ctx, tableName, key, attribute, -delta, options...)
+}
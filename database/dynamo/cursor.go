@@ -0,0 +1,72 @@
+package dynamo
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// queryCursor is the state held by a QueryPage cursor
+type queryCursor struct {
+	Key      string         `json:"k"` // Partition key the cursor was issued for
+	StartKey map[string]any `json:"s"` // LastEvaluatedKey of the previous page
+}
+
+// cursorCodec returns the table's cursor codec, or one using CURSOR_SECRET
+func (d *DynamoDB) cursorCodec() (*utils.CursorCodec, error) {
+	if d.cursors != nil {
+		return d.cursors, nil
+	}
+
+	codec, err := utils.NewCursorCodec()
+	if err != nil {
+		return nil, fmt.Errorf("query cursors require DbOptions.Cursors or CURSOR_SECRET: %w", err)
+	}
+
+	return codec, nil
+}
+
+// encodeCursor returns a cursor for lastKey, or an empty string if there are no more pages
+func (d *DynamoDB) encodeCursor(key string, lastKey map[string]types.AttributeValue) (string, error) {
+	if len(lastKey) == 0 {
+		return "", nil
+	}
+
+	codec, err := d.cursorCodec()
+	if err != nil {
+		return "", err
+	}
+
+	var startKey map[string]any
+	if err := attributevalue.UnmarshalMap(lastKey, &startKey); err != nil {
+		return "", fmt.Errorf("failed to unmarshal last evaluated key: %w", err)
+	}
+
+	return codec.Encode(queryCursor{Key: key, StartKey: startKey})
+}
+
+// decodeCursor returns the ExclusiveStartKey held by cursor, checking it was issued for key
+func (d *DynamoDB) decodeCursor(key, cursor string) (map[string]types.AttributeValue, error) {
+	codec, err := d.cursorCodec()
+	if err != nil {
+		return nil, err
+	}
+
+	var state queryCursor
+	if err := codec.Decode(cursor, &state); err != nil {
+		return nil, err
+	}
+
+	if state.Key != key || len(state.StartKey) == 0 {
+		return nil, utils.ErrInvalidCursor
+	}
+
+	startKey, err := attributevalue.MarshalMap(state.StartKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal start key: %w", err)
+	}
+
+	return startKey, nil
+}
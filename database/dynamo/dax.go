@@ -0,0 +1,141 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ReadClient is the part of the DynamoDB API used by Get and Query. The DAX client from
+// github.com/aws/aws-dax-go-v2 implements it.
+type ReadClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// DaxClientFactory creates a DAX client for a cluster endpoint
+type DaxClientFactory func(ctx context.Context, endpoint, region string) (ReadClient, error)
+
+// DaxOptions configures reading through a DAX cluster
+type DaxOptions struct {
+	Endpoint string        // DAX cluster endpoint (default DAX_ENDPOINT)
+	Client   ReadClient    // Existing DAX client, instead of creating one for Endpoint
+	Cooldown time.Duration // How long reads skip DAX after it fails (default 30s)
+}
+
+var daxClientFactory DaxClientFactory
+
+// SetDaxClientFactory sets how DAX clients are created from DaxOptions.Endpoint. It keeps the
+// DAX SDK out of services that don't use it.
+//
+// Example:
+//
+//	dynamo.SetDaxClientFactory(func(ctx context.Context, endpoint, region string) (dynamo.ReadClient, error) {
+//	    cfg := dax.DefaultConfig()
+//	    cfg.HostPorts = []string{endpoint}
+//	    cfg.Region = region
+//	    return dax.New(cfg)
+//	})
+func SetDaxClientFactory(factory DaxClientFactory) {
+	daxClientFactory = factory
+}
+
+// daxReader sends reads to DAX, falling back to DynamoDB while DAX is failing
+type daxReader struct {
+	client   ReadClient
+	cooldown time.Duration
+
+	mu          sync.Mutex
+	failedUntil time.Time
+}
+
+// newDaxReader returns a reader for opts, or nil if DAX can't be used, in which case reads
+// go to DynamoDB
+func newDaxReader(opts *DaxOptions, region string) *daxReader {
+	if opts == nil {
+		return nil
+	}
+
+	client := opts.Client
+
+	if client == nil {
+		endpoint := utils.StringOrDefault(opts.Endpoint, os.Getenv("DAX_ENDPOINT"))
+
+		if endpoint == "" || daxClientFactory == nil {
+			log.Warningf("DAX is configured without a client or endpoint and client factory, reading from DynamoDB")
+			return nil
+		}
+
+		var err error
+		client, err = daxClientFactory(context.Background(), endpoint, region)
+		if err != nil {
+			log.Warningf("Failed to create DAX client for %s, reading from DynamoDB: %v", endpoint, err)
+			return nil
+		}
+	}
+
+	return &daxReader{
+		client:   client,
+		cooldown: utils.DurationOrDefault(opts.Cooldown, 30*time.Second),
+	}
+}
+
+func (r *daxReader) available() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return time.Now().After(r.failedUntil)
+}
+
+func (r *daxReader) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Now().After(r.failedUntil) {
+		log.Warningf("DAX read failed, reading from DynamoDB for %s: %v", r.cooldown, err)
+	}
+
+	r.failedUntil = time.Now().Add(r.cooldown)
+}
+
+// getItem reads an item through DAX when it is available, otherwise from DynamoDB
+func (d *DynamoDB) getItem(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	if d.dax != nil && d.dax.available() {
+		output, err := d.dax.client.GetItem(ctx, input)
+		if err == nil {
+			return output, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to get item through dax: %w", err)
+		}
+
+		d.dax.fail(err)
+	}
+
+	return d.client.GetItem(ctx, input)
+}
+
+// query runs a query through DAX when it is available, otherwise against DynamoDB
+func (d *DynamoDB) query(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	if d.dax != nil && d.dax.available() {
+		output, err := d.dax.client.Query(ctx, input)
+		if err == nil {
+			return output, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to query through dax: %w", err)
+		}
+
+		d.dax.fail(err)
+	}
+
+	return d.client.Query(ctx, input)
+}
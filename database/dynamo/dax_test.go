@@ -0,0 +1,63 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type fakeDax struct {
+	err   error
+	calls int
+}
+
+func (f *fakeDax) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}}}, nil
+}
+
+func (f *fakeDax) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.calls++
+	return &dynamodb.QueryOutput{}, f.err
+}
+
+func TestDaxReader(t *testing.T) {
+	dax := &fakeDax{}
+	d := &DynamoDB{dax: newDaxReader(&DaxOptions{Client: dax, Cooldown: time.Hour}, "af-south-1")}
+
+	output, err := d.getItem(context.Background(), &dynamodb.GetItemInput{})
+	if err != nil || output.Item == nil || dax.calls != 1 {
+		t.Fatalf("getItem() = %v, %v after %d DAX calls", output, err, dax.calls)
+	}
+
+	d.dax.fail(errors.New("connection refused"))
+
+	if d.dax.available() {
+		t.Error("available() = true during cooldown")
+	}
+
+	d.dax.failedUntil = time.Now().Add(-time.Second)
+
+	if !d.dax.available() {
+		t.Error("available() = false after cooldown")
+	}
+}
+
+func TestNewDaxReader_WithoutClient(t *testing.T) {
+	t.Setenv("DAX_ENDPOINT", "")
+
+	if reader := newDaxReader(&DaxOptions{}, "af-south-1"); reader != nil {
+		t.Error("expected reads to go to DynamoDB without a DAX client")
+	}
+
+	if reader := newDaxReader(nil, "af-south-1"); reader != nil {
+		t.Error("expected no DAX reader without options")
+	}
+}
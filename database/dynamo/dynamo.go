@@ -3,6 +3,7 @@ package dynamo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/query"
 	"github.com/finch-technologies/go-utils/log"
 	"github.com/finch-technologies/go-utils/utils"
 )
@@ -27,7 +29,8 @@ var tableMap map[string]*DynamoDB = make(map[string]*DynamoDB)
 //
 // Returns:
 //   - *DynamoDB: Configured DynamoDB instance
-//   - error: Error if table name is missing or client creation fails
+//   - error: Error if table name is missing, client creation fails, the table doesn't
+//     exist and AutoCreateTable isn't set, or TtlCheck finds TTL misconfigured
 //
 // Example:
 //
@@ -45,13 +48,44 @@ func New(options ...DbOptions) (*DynamoDB, error) {
 		return nil, fmt.Errorf("table name is required")
 	}
 
-	client, err := GetDynamoClient(opts.Region)
+	client := opts.Client
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to get dynamodb client: %w", err)
+	if client == nil {
+		var err error
+
+		client, err = GetClient(ClientConfig{Region: opts.Region, Endpoint: opts.Endpoint})
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dynamodb client: %w", err)
+		}
+	}
+
+	if opts.Encryption != nil && opts.ValueStoreMode != ValueStoreModeJson {
+		return nil, fmt.Errorf("encryption is only supported in json value store mode")
+	}
+
+	if opts.Compression != "" && opts.Compression != CompressionNone && opts.ValueStoreMode != ValueStoreModeJson {
+		return nil, fmt.Errorf("compression is only supported in json value store mode")
 	}
 
-	err = ensureTableExists(context.Background(), client, opts.TableName)
+	if opts.Overflow != nil && opts.ValueStoreMode != ValueStoreModeJson {
+		return nil, fmt.Errorf("overflow is only supported in json value store mode")
+	}
+
+	if opts.Overflow != nil && opts.Overflow.Store == nil {
+		return nil, fmt.Errorf("overflow store is required")
+	}
+
+	err := ensureTableExists(context.Background(), client, opts.TableName)
+
+	var resourceNotFound *types.ResourceNotFoundException
+	if errors.As(err, &resourceNotFound) && opts.AutoCreateTable != nil {
+		err = createTable(context.Background(), client, opts)
+	}
+
+	if err == nil && opts.TtlCheck != TtlCheckNone {
+		err = checkTtl(context.Background(), client, opts)
+	}
 
 	if err != nil {
 		return nil, err
@@ -66,6 +100,27 @@ func New(options ...DbOptions) (*DynamoDB, error) {
 		valueStoreMode:        opts.ValueStoreMode,
 		valueAttribute:        opts.ValueAttribute,
 		ttl:                   opts.Ttl,
+		cursors:               opts.Cursors,
+		dax:                   newDaxReader(opts.Dax, opts.Region),
+		versionAttribute:      opts.VersionAttribute,
+		compression:           opts.Compression,
+		compressionThreshold:  utils.IntOrDefault(opts.CompressionThreshold, defaultCompressionThreshold),
+	}
+
+	if opts.Encryption != nil {
+		d.encryption = newTableEncryption(*opts.Encryption)
+	}
+
+	if opts.Overflow != nil {
+		d.overflow = newOverflow(*opts.Overflow, opts.TableName)
+	}
+
+	if opts.DeleteExpiredOnRead {
+		d.purger = newExpiredPurger(d, opts.Metrics)
+	}
+
+	if opts.CacheSize > 0 {
+		d.cache = newReadCache(opts.CacheSize, opts.CacheTTL)
 	}
 
 	tableMap[opts.TableName] = d
@@ -103,18 +158,22 @@ func New(options ...DbOptions) (*DynamoDB, error) {
 //	    Result:  &Person{},
 //	})
 func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, error) {
+	return d.GetContext(context.Background(), key, options...)
+}
 
-	opts := getGetOptions(options...)
+// GetContext works like Get, using ctx for the DynamoDB and KMS calls
+func (d *DynamoDB) GetContext(ctx context.Context, key string, options ...GetOptions) (any, *time.Time, error) {
 
-	keys := map[string]types.AttributeValue{
-		d.partitionKeyAttribute: &types.AttributeValueMemberS{Value: key},
-	}
+	opts := getGetOptions(options...)
 
+	sortKeyValue := ""
 	if d.sortKeyAttribute != "" {
-		keys[d.sortKeyAttribute] = &types.AttributeValueMemberS{Value: utils.StringOrDefault(opts.SortKey, "null")}
+		sortKeyValue = utils.StringOrDefault(opts.SortKey, "null")
 	}
 
-	result, err := d.client.GetItem(context.Background(), &dynamodb.GetItemInput{
+	keys := d.itemKey(key, sortKeyValue)
+
+	result, err := d.cachedGetItem(ctx, KeyPair{Key: key, SortKey: sortKeyValue}, &dynamodb.GetItemInput{
 		TableName: aws.String(d.tableName),
 		Key:       keys,
 	})
@@ -128,6 +187,10 @@ func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, erro
 		return nil, nil, nil
 	}
 
+	if opts.Version != nil {
+		*opts.Version = d.itemVersion(result.Item)
+	}
+
 	//Check expiration time
 	var expirationTimestamp int64
 	err = attributevalue.Unmarshal(result.Item[d.ttlAttribute], &expirationTimestamp)
@@ -141,6 +204,8 @@ func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, erro
 		expirationTime = &unixTime
 
 		if now > expirationTimestamp {
+			d.purgeExpired(result.Item)
+
 			if d.valueStoreMode == ValueStoreModeJson {
 				return "", expirationTime, nil
 			} else {
@@ -157,7 +222,11 @@ func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, erro
 			log.Error("Failed to unmarshal DynamoDB item: ", err)
 			return nil, nil, err
 		}
-		value := resultItem[d.valueAttribute]
+		value, err := d.decodeValue(ctx, result.Item, key, sortKeyValue, resultItem[d.valueAttribute])
+		if err != nil {
+			return nil, nil, err
+		}
+
 		return value, expirationTime, nil
 	} else {
 		err = attributevalue.UnmarshalMap(result.Item, &opts.Result)
@@ -188,11 +257,16 @@ func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, erro
 //   - Efficient DynamoDB Query operation (not table scan)
 //   - Configurable result limits
 //
+// Query returns a single page of up to 1MB of items. Use QueryPage to page through results,
+// or QueryAll to read every page.
+//
 // Sort Key Conditions:
 //   - QueryConditionEquals: Exact match
 //   - QueryConditionBeginsWith: Prefix match
 //   - QueryConditionGreaterThan/LessThan: Comparison operators
 //   - QueryConditionGreaterThanOrEqualTo/LessThanOrEqualTo: Inclusive comparisons
+//   - QueryConditionBetween: Inclusive range from SortKeyValueFrom to SortKeyValueTo
+//   - QueryConditionEndsWith/Contains/NotEquals: Checked against the items read, after Limit
 //
 // Example:
 //
@@ -202,7 +276,7 @@ func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, erro
 //	// Query with sort key condition
 //	sessions, err := db.Query("user123", QueryOptions{
 //	    SortKeyCondition: QueryConditionBeginsWith,
-//	    SortKey:          "session_",
+//	    SortKeyValue:     "session_",
 //	})
 //
 //	// Query with typed result for attribute mode
@@ -210,21 +284,99 @@ func (d *DynamoDB) Get(key string, options ...GetOptions) (any, *time.Time, erro
 //	    Result: &Person{},
 //	})
 func (d *DynamoDB) Query(key string, options ...QueryOptions) ([]QueryResult[any], error) {
+	return d.QueryContext(context.Background(), key, options...)
+}
+
+// QueryContext works like Query, using ctx for the DynamoDB calls
+func (d *DynamoDB) QueryContext(ctx context.Context, key string, options ...QueryOptions) ([]QueryResult[any], error) {
+	items, _, err := d.queryPage(ctx, key, getQueryOptions(options...))
+	return items, err
+}
+
+// QueryPage works like Query but also returns a cursor for the next page, or an empty string
+// when there are no more items. Pass the cursor back as QueryOptions.Cursor to continue. The
+// cursor is encrypted, so it can be handed to API clients without exposing or letting them
+// alter the underlying LastEvaluatedKey, and it is only valid for the same partition key.
+//
+// Example:
+//
+//	items, cursor, err := db.QueryPage("company1", QueryOptions{Limit: 50, Cursor: req.Cursor})
+func (d *DynamoDB) QueryPage(key string, options ...QueryOptions) ([]QueryResult[any], string, error) {
+	return d.QueryPageContext(context.Background(), key, options...)
+}
+
+// QueryPageContext works like QueryPage, using ctx for the DynamoDB and KMS calls
+func (d *DynamoDB) QueryPageContext(ctx context.Context, key string, options ...QueryOptions) ([]QueryResult[any], string, error) {
+	items, lastKey, err := d.queryPage(ctx, key, getQueryOptions(options...))
+	if err != nil {
+		return nil, "", err
+	}
+
+	cursor, err := d.encodeCursor(key, lastKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return items, cursor, nil
+}
+
+// QueryAll works like Query but follows LastEvaluatedKey through every page, stopping once
+// QueryOptions.MaxItems items have been read. Limit sets the page size.
+//
+// Example:
+//
+//	items, err := db.QueryAll(ctx, "company1", QueryOptions{Limit: 100, MaxItems: 1000})
+func (d *DynamoDB) QueryAll(ctx context.Context, key string, options ...QueryOptions) ([]QueryResult[any], error) {
 	opts := getQueryOptions(options...)
+
+	var all []QueryResult[any]
+
+	for {
+		items, lastKey, err := d.queryPage(ctx, key, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, items...)
+
+		if opts.MaxItems > 0 && len(all) >= opts.MaxItems {
+			return all[:opts.MaxItems], nil
+		}
+
+		if len(lastKey) == 0 {
+			return all, nil
+		}
+
+		opts.Cursor = ""
+		opts.ExclusiveStartKey = lastKey
+	}
+}
+
+// queryPage reads one page of a query, returning its items and the key to continue from
+func (d *DynamoDB) queryPage(ctx context.Context, key string, opts QueryOptions) ([]QueryResult[any], map[string]types.AttributeValue, error) {
 	now := time.Now().Unix()
 
+	partitionKeyAttribute, sortKeyAttribute := d.partitionKeyAttribute, d.sortKeyAttribute
+
+	if opts.IndexName != "" {
+		partitionKeyAttribute = utils.StringOrDefault(opts.IndexPartitionKeyAttribute, d.partitionKeyAttribute)
+		sortKeyAttribute = opts.IndexSortKeyAttribute
+	}
+
 	// Build key condition expression
 	keyConditionExpression := "#pk = :pk"
 	expressionAttributeNames := map[string]string{
-		"#pk": d.partitionKeyAttribute,
+		"#pk": partitionKeyAttribute,
 	}
 	expressionAttributeValues := map[string]types.AttributeValue{
 		":pk": &types.AttributeValueMemberS{Value: key},
 	}
 
+	var sortKeyFilter func(sortKey string) bool
+
 	// Add sort key condition if specified
-	if d.sortKeyAttribute != "" && opts.SortKeyCondition != QueryConditionNone {
-		expressionAttributeNames["#sk"] = d.sortKeyAttribute
+	if sortKeyAttribute != "" && opts.SortKeyCondition != QueryConditionNone {
+		expressionAttributeNames["#sk"] = sortKeyAttribute
 
 		switch opts.SortKeyCondition {
 		case QueryConditionEquals:
@@ -245,90 +397,238 @@ func (d *DynamoDB) Query(key string, options ...QueryOptions) ([]QueryResult[any
 		case QueryConditionLessThanOrEqualTo:
 			keyConditionExpression += " AND #sk <= :sk"
 			expressionAttributeValues[":sk"] = &types.AttributeValueMemberS{Value: opts.SortKeyValue}
+		case QueryConditionBetween:
+			if opts.SortKeyValueFrom == "" || opts.SortKeyValueTo == "" || opts.SortKeyValueFrom > opts.SortKeyValueTo {
+				return nil, nil, fmt.Errorf("between condition needs SortKeyValueFrom <= SortKeyValueTo, got %q and %q", opts.SortKeyValueFrom, opts.SortKeyValueTo)
+			}
+			keyConditionExpression += " AND #sk BETWEEN :skFrom AND :skTo"
+			expressionAttributeValues[":skFrom"] = &types.AttributeValueMemberS{Value: opts.SortKeyValueFrom}
+			expressionAttributeValues[":skTo"] = &types.AttributeValueMemberS{Value: opts.SortKeyValueTo}
+		case QueryConditionEndsWith, QueryConditionContains, QueryConditionNotEquals:
+			// Key conditions can't express these and filter expressions can't reference key
+			// attributes, so they are checked against the items read
+			delete(expressionAttributeNames, "#sk")
+			sortKeyFilter = sortKeyMatcher(opts.SortKeyCondition, opts.SortKeyValue)
 		default:
-			return nil, fmt.Errorf("unsupported sort key condition: %s", opts.SortKeyCondition)
+			return nil, nil, fmt.Errorf("unsupported sort key condition: %s", opts.SortKeyCondition)
+		}
+	}
+
+	var filterExpression *string
+	var localFilter query.Expr
+
+	builder := &expressionBuilder{names: expressionAttributeNames, values: expressionAttributeValues}
+
+	if opts.Where != nil {
+		where := opts.Where
+
+		if opts.SortKeyCondition == QueryConditionNone || sortKeyFilter != nil {
+			var keyCondition *query.Condition
+			keyCondition, where = splitWhere(where, sortKeyAttribute)
+
+			if keyCondition != nil {
+				expression, err := builder.build(*keyCondition)
+				if err != nil {
+					return nil, nil, err
+				}
+				keyConditionExpression += " AND " + expression
+			}
+		}
+
+		if d.valueStoreMode == ValueStoreModeJson {
+			// Filter expressions can't see into JSON values, so they are filtered once decoded
+			localFilter = where
+		} else {
+			filter, err := builder.build(where)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if filter != "" {
+				filterExpression = aws.String(filter)
+			}
+		}
+	}
+
+	if len(opts.Filters) > 0 {
+		filter, err := builder.build(filtersExpr(opts.Filters, opts.FilterLogic))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if filterExpression != nil && filter != "" {
+			filter = *filterExpression + " AND " + filter
+		}
+
+		if filter != "" {
+			filterExpression = aws.String(filter)
 		}
 	}
 
 	input := &dynamodb.QueryInput{
 		TableName:                 aws.String(d.tableName),
 		KeyConditionExpression:    aws.String(keyConditionExpression),
+		FilterExpression:          filterExpression,
 		ExpressionAttributeNames:  expressionAttributeNames,
 		ExpressionAttributeValues: expressionAttributeValues,
 	}
 
+	if opts.IndexName != "" {
+		input.IndexName = aws.String(opts.IndexName)
+	}
+
 	if opts.Limit > 0 {
 		input.Limit = aws.Int32(int32(opts.Limit))
 	}
 
-	result, err := d.client.Query(context.Background(), input)
+	if opts.Cursor != "" {
+		startKey, err := d.decodeCursor(key, opts.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.ExclusiveStartKey = startKey
+	} else if len(opts.ExclusiveStartKey) > 0 {
+		input.ExclusiveStartKey = opts.ExclusiveStartKey
+	}
+
+	result, err := d.query(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query dynamodb: %w", err)
+		return nil, nil, fmt.Errorf("failed to query dynamodb: %w", err)
 	}
 
 	var items []QueryResult[any]
 
 	for _, item := range result.Items {
-		// Check expiration time
-		var expirationTime int64
-		err = attributevalue.Unmarshal(item[d.ttlAttribute], &expirationTime)
-		if err == nil && expirationTime > 0 && now > expirationTime {
-			continue // Skip expired items
+		if sortKeyFilter != nil && !sortKeyFilter(attributeString(item[sortKeyAttribute])) {
+			continue
 		}
 
-		expiryTime := time.Unix(expirationTime, 0)
+		if value, ok := d.decodeItem(ctx, item, now, localFilter, opts.Result); ok {
+			items = append(items, value)
+		}
+	}
+
+	if opts.LastEvaluatedKey != nil {
+		*opts.LastEvaluatedKey = result.LastEvaluatedKey
+	}
+
+	return items, result.LastEvaluatedKey, nil
+}
+
+// DecodeItem converts a raw item of the table, e.g. from a stream record, into a result the
+// way Get reads it: in JSON mode the value is loaded from the overflow store, decrypted and
+// decompressed as needed, and in attribute mode it is the item as a map. Unlike Get, expired
+// items are decoded too.
+func (d *DynamoDB) DecodeItem(ctx context.Context, item map[string]types.AttributeValue) (QueryResult[any], error) {
+	result := QueryResult[any]{Key: attributeString(item[d.partitionKeyAttribute])}
+
+	if d.sortKeyAttribute != "" {
+		result.SortKey = attributeString(item[d.sortKeyAttribute])
+	}
+
+	result.Expiry, _ = d.itemExpiry(item)
+
+	var resultItem map[string]any
+	if err := attributevalue.UnmarshalMap(item, &resultItem); err != nil {
+		return QueryResult[any]{}, fmt.Errorf("failed to unmarshal item %s: %w", result.Key, err)
+	}
+
+	if d.valueStoreMode != ValueStoreModeJson {
+		result.Value = resultItem
+		return result, nil
+	}
+
+	value, err := d.decodeValue(ctx, item, result.Key, result.SortKey, resultItem[d.valueAttribute])
+	if err != nil {
+		return QueryResult[any]{}, err
+	}
+
+	result.Value = value
+
+	return result, nil
+}
+
+// decodeItem converts a raw item read by a query or scan into a result. It returns false for
+// items that are expired, don't match localFilter or can't be decoded.
+func (d *DynamoDB) decodeItem(ctx context.Context, item map[string]types.AttributeValue, now int64, localFilter query.Expr, result any) (QueryResult[any], bool) {
+	// Check expiration time
+	var expirationTime int64
+	err := attributevalue.Unmarshal(item[d.ttlAttribute], &expirationTime)
+	if err == nil && expirationTime > 0 && now > expirationTime {
+		d.purgeExpired(item)
+		return QueryResult[any]{}, false // Skip expired items
+	}
+
+	expiryTime := time.Unix(expirationTime, 0)
+
+	key := attributeString(item[d.partitionKeyAttribute])
+
+	sortKey := ""
+	if d.sortKeyAttribute != "" {
+		sortKey = attributeString(item[d.sortKeyAttribute])
+	}
 
-		sortKey := ""
-		if d.sortKeyAttribute != "" {
-			sortKey = item[d.sortKeyAttribute].(*types.AttributeValueMemberS).Value
+	if d.valueStoreMode == ValueStoreModeJson {
+		// Handle JSON value store mode
+		var resultItem map[string]interface{}
+		err = attributevalue.UnmarshalMap(item, &resultItem)
+		if err != nil {
+			log.Error("Failed to unmarshal DynamoDB item: ", err)
+			return QueryResult[any]{}, false
 		}
 
-		if d.valueStoreMode == ValueStoreModeJson {
-			// Handle JSON value store mode
-			var resultItem map[string]interface{}
-			err = attributevalue.UnmarshalMap(item, &resultItem)
+		value, err := d.decodeValue(ctx, item, key, sortKey, resultItem[d.valueAttribute])
+		if err != nil {
+			log.Error("Failed to decode DynamoDB item: ", err)
+			return QueryResult[any]{}, false
+		}
+
+		if localFilter != nil {
+			matched, err := query.Match(localFilter, value)
 			if err != nil {
-				log.Error("Failed to unmarshal DynamoDB item: ", err)
-				continue
+				log.Error("Failed to filter DynamoDB item: ", err)
+				return QueryResult[any]{}, false
 			}
 
-			value := resultItem[d.valueAttribute]
-
-			items = append(items, QueryResult[any]{
-				Value:   value,
-				Expiry:  &expiryTime,
-				SortKey: sortKey,
-			})
-		} else {
-			// Handle attribute value store mode
-			var resultItem interface{}
-			if opts.Result != nil {
-				// Create a new instance of the same type as opts.Result
-				resultType := reflect.TypeOf(opts.Result)
-				if resultType.Kind() == reflect.Ptr {
-					resultItem = reflect.New(resultType.Elem()).Interface()
-				} else {
-					resultItem = reflect.New(resultType).Interface()
-				}
-			} else {
-				resultItem = make(map[string]interface{})
+			if !matched {
+				return QueryResult[any]{}, false
 			}
+		}
 
-			err = attributevalue.UnmarshalMap(item, resultItem)
-			if err != nil {
-				log.Error("Failed to unmarshal DynamoDB item: ", err)
-				continue
-			}
+		return QueryResult[any]{
+			Value:   value,
+			Expiry:  &expiryTime,
+			Key:     key,
+			SortKey: sortKey,
+		}, true
+	}
 
-			items = append(items, QueryResult[any]{
-				Value:   resultItem,
-				Expiry:  &expiryTime,
-				SortKey: sortKey,
-			})
+	// Handle attribute value store mode
+	var resultItem interface{}
+	if result != nil {
+		// Create a new instance of the same type as result
+		resultType := reflect.TypeOf(result)
+		if resultType.Kind() == reflect.Ptr {
+			resultItem = reflect.New(resultType.Elem()).Interface()
+		} else {
+			resultItem = reflect.New(resultType).Interface()
 		}
+	} else {
+		resultItem = make(map[string]interface{})
+	}
+
+	err = attributevalue.UnmarshalMap(item, resultItem)
+	if err != nil {
+		log.Error("Failed to unmarshal DynamoDB item: ", err)
+		return QueryResult[any]{}, false
 	}
 
-	return items, nil
+	return QueryResult[any]{
+		Value:   resultItem,
+		Expiry:  &expiryTime,
+		Key:     key,
+		SortKey: sortKey,
+	}, true
 }
 
 // Update performs partial updates to existing DynamoDB items using the efficient UpdateItem operation.
@@ -373,13 +673,35 @@ func (d *DynamoDB) Query(key string, options ...QueryOptions) ([]QueryResult[any
 //	    Name:  "New Name",
 //	    Email: "new@email.com",
 //	}
-//	err := db.Update("user123", person, SetOptions{
+//	err := db.Update("user123", person, PutOptions{
 //	    SortKey: "profile",
 //	    Ttl:     1 * time.Hour,
 //	})
 func (d *DynamoDB) Update(key string, value any, options ...PutOptions) error {
+	return d.UpdateContext(context.Background(), key, value, options...)
+}
+
+// UpdateContext works like Update, using ctx for the DynamoDB call
+func (d *DynamoDB) UpdateContext(ctx context.Context, key string, value any, options ...PutOptions) error {
 	opts := getSetOptions(options...)
 
+	input, err := d.updateInput(key, value, opts)
+	if err != nil {
+		return err
+	}
+
+	// Execute the update
+	_, err = d.client.UpdateItem(ctx, input)
+	d.invalidate(KeyPair{Key: key, SortKey: opts.SortKey})
+	if err != nil {
+		return fmt.Errorf("failed to update item in dynamodb: %w", conditionError(err))
+	}
+
+	return nil
+}
+
+// updateInput builds the UpdateItem input used by Update and transactions
+func (d *DynamoDB) updateInput(key string, value any, opts PutOptions) (*dynamodb.UpdateItemInput, error) {
 	// Build key for the item to update
 	keys := map[string]types.AttributeValue{
 		d.partitionKeyAttribute: &types.AttributeValueMemberS{Value: key},
@@ -392,7 +714,7 @@ func (d *DynamoDB) Update(key string, value any, options ...PutOptions) error {
 	// Marshal the update value to get attribute values
 	updateValues, err := attributevalue.MarshalMap(value)
 	if err != nil {
-		return fmt.Errorf("failed to marshal update value: %w", err)
+		return nil, fmt.Errorf("failed to marshal update value: %w", err)
 	}
 
 	// Build update expression components
@@ -434,7 +756,17 @@ func (d *DynamoDB) Update(key string, value any, options ...PutOptions) error {
 	}
 
 	if len(updateExpressions) == 0 {
-		return fmt.Errorf("no attributes to update")
+		return nil, fmt.Errorf("no attributes to update")
+	}
+
+	condition, err := d.writeCondition(opts, expressionAttributeNames, expressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.versionAttribute != "" {
+		expressionAttributeValues[":cond_next_version"] = nextVersion(opts)
+		updateExpressions = append(updateExpressions, "#cond_version = :cond_next_version")
 	}
 
 	// Build the complete update expression
@@ -443,23 +775,15 @@ func (d *DynamoDB) Update(key string, value any, options ...PutOptions) error {
 		updateExpression += ", " + updateExpressions[i]
 	}
 
-	// Create the UpdateItem input
-	input := &dynamodb.UpdateItemInput{
+	return &dynamodb.UpdateItemInput{
 		TableName:                 aws.String(d.tableName),
 		Key:                       keys,
 		UpdateExpression:          aws.String(updateExpression),
 		ExpressionAttributeNames:  expressionAttributeNames,
 		ExpressionAttributeValues: expressionAttributeValues,
+		ConditionExpression:       condition,
 		ReturnValues:              types.ReturnValueNone, // Don't return the updated item
-	}
-
-	// Execute the update
-	_, err = d.client.UpdateItem(context.Background(), input)
-	if err != nil {
-		return fmt.Errorf("failed to update item in dynamodb: %w", err)
-	}
-
-	return nil
+	}, nil
 }
 
 // Put stores a complete item in DynamoDB, replacing any existing item with the same key.
@@ -514,9 +838,64 @@ func (d *DynamoDB) Update(key string, value any, options ...PutOptions) error {
 //	    Ttl:     7 * 24 * time.Hour,
 //	})
 func (d *DynamoDB) Put(key string, value any, options ...PutOptions) error {
+	return d.PutContext(context.Background(), key, value, options...)
+}
 
+// PutContext works like Put, using ctx for the DynamoDB and KMS calls
+func (d *DynamoDB) PutContext(ctx context.Context, key string, value any, options ...PutOptions) error {
 	opts := getSetOptions(options...)
 
+	input, err := d.putInput(ctx, key, value, opts)
+	if err != nil {
+		return err
+	}
+
+	if d.overflow != nil {
+		input.ReturnValues = types.ReturnValueAllOld
+	}
+
+	result, err := d.client.PutItem(ctx, input)
+	d.invalidate(KeyPair{Key: key, SortKey: opts.SortKey})
+
+	if err != nil {
+		return fmt.Errorf("failed to write value to dynamodb: %w", conditionError(err))
+	}
+
+	d.removeOverflow(ctx, result.Attributes, input.Item)
+
+	return nil
+}
+
+// putInput builds the PutItem input used by Put and transactions
+func (d *DynamoDB) putInput(ctx context.Context, key string, value any, opts PutOptions) (*dynamodb.PutItemInput, error) {
+	item, err := d.buildItem(ctx, key, value, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	condition, err := d.writeCondition(opts, names, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.versionAttribute != "" {
+		item[d.versionAttribute] = nextVersion(opts)
+	}
+
+	return &dynamodb.PutItemInput{
+		TableName:                 aws.String(d.tableName),
+		Item:                      item,
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  nilIfEmpty(names),
+		ExpressionAttributeValues: nilIfEmpty(values),
+	}, nil
+}
+
+// buildItem builds the item stored by Put for key and value
+func (d *DynamoDB) buildItem(ctx context.Context, key string, value any, opts PutOptions) (map[string]types.AttributeValue, error) {
 	item := map[string]types.AttributeValue{
 		d.partitionKeyAttribute: &types.AttributeValueMemberS{Value: key},
 	}
@@ -536,7 +915,7 @@ func (d *DynamoDB) Put(key string, value any, options ...PutOptions) error {
 		case reflect.Struct, reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array:
 			bytes, err := json.Marshal(value)
 			if err != nil {
-				return fmt.Errorf("failed to marshal payload: %w", err)
+				return nil, fmt.Errorf("failed to marshal payload: %w", err)
 			}
 			payload = string(bytes)
 		case reflect.String:
@@ -548,14 +927,56 @@ func (d *DynamoDB) Put(key string, value any, options ...PutOptions) error {
 		case reflect.Bool:
 			payload = strconv.FormatBool(value.(bool))
 		default:
-			return fmt.Errorf("unsupported type: %v", t)
+			return nil, fmt.Errorf("unsupported type: %v", t)
+		}
+
+		compressed, err := d.compressValue(payload, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if compressed != nil {
+			payload = string(compressed)
+		}
+
+		if d.encryption != nil {
+			if key == d.encryption.opts.KeyItem {
+				return nil, fmt.Errorf("key %s is reserved for data keys", key)
+			}
+
+			sortKeyValue := ""
+			if d.sortKeyAttribute != "" {
+				sortKeyValue = utils.StringOrDefault(opts.SortKey, "null")
+			}
+
+			payload, err = d.encryptValue(ctx, d.itemAad(key, sortKeyValue), payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+			}
 		}
 
-		item[d.valueAttribute] = &types.AttributeValueMemberS{Value: payload}
+		stored := []byte(payload)
+		if compressed != nil && d.encryption == nil {
+			stored = compressed
+		}
+
+		pointer, err := d.overflowValue(ctx, key, d.sortKeyValue(opts.SortKey), stored, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if pointer != nil {
+			item[d.overflow.Attribute] = pointer
+		} else if compressed != nil && d.encryption == nil {
+			// Binary attributes hold compressed values without the overhead of base64
+			item[d.valueAttribute] = &types.AttributeValueMemberB{Value: compressed}
+		} else {
+			item[d.valueAttribute] = &types.AttributeValueMemberS{Value: payload}
+		}
 	} else {
 		payload, err := attributevalue.MarshalMap(value)
 		if err != nil {
-			return fmt.Errorf("failed to marshal payload: %w", err)
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
 		}
 
 		//Merge payload with item
@@ -571,16 +992,7 @@ func (d *DynamoDB) Put(key string, value any, options ...PutOptions) error {
 		item[d.ttlAttribute] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expiryTime, 10)}
 	}
 
-	_, err := d.client.PutItem(context.Background(), &dynamodb.PutItemInput{
-		TableName: aws.String(d.tableName),
-		Item:      item,
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to write value to dynamodb: %w", err)
-	}
-
-	return nil
+	return item, nil
 }
 
 // Delete removes an item from the DynamoDB table by its partition key and optional sort key.
@@ -599,33 +1011,82 @@ func (d *DynamoDB) Put(key string, value any, options ...PutOptions) error {
 // Note: This operation will succeed even if the item doesn't exist (DynamoDB doesn't
 // return an error for deleting non-existent items).
 func (d *DynamoDB) Delete(key string, sortKey ...string) error {
+	return d.DeleteContext(context.Background(), key, sortKey...)
+}
 
+// DeleteContext works like Delete, using ctx for the DynamoDB call
+func (d *DynamoDB) DeleteContext(ctx context.Context, key string, sortKey ...string) error {
 	sk := "null"
 
 	if len(sortKey) > 0 {
 		sk = sortKey[0]
 	}
 
+	return d.DeleteIfContext(ctx, key, PutOptions{SortKey: sk})
+}
+
+// DeleteIf removes an item only if it meets the condition fields of options (IfExpired,
+// ExpectedExpiry, ExpectedValue, Condition and ExpectedVersion), returning an error
+// wrapping ErrConditionFailed otherwise. SortKey selects the item on tables with composite
+// keys.
+//
+// Example:
+//
+//	err := table.DeleteIf("session123", dynamo.PutOptions{ExpectedValue: "worker-1"})
+//	if errors.Is(err, dynamo.ErrConditionFailed) {
+//	    // Another worker holds the session now
+//	}
+func (d *DynamoDB) DeleteIf(key string, options PutOptions) error {
+	return d.DeleteIfContext(context.Background(), key, options)
+}
+
+// DeleteIfContext works like DeleteIf, using ctx for the DynamoDB call
+func (d *DynamoDB) DeleteIfContext(ctx context.Context, key string, options PutOptions) error {
+	sk := utils.StringOrDefault(options.SortKey, "null")
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	condition, err := d.condition(options, false, names, values)
+	if err != nil {
+		return err
+	}
+
 	deleteInput := &dynamodb.DeleteItemInput{
-		TableName: aws.String(d.tableName),
-		Key: map[string]types.AttributeValue{
-			d.partitionKeyAttribute: &types.AttributeValueMemberS{Value: key},
-		},
+		TableName:                 aws.String(d.tableName),
+		Key:                       d.itemKey(key, sk),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  nilIfEmpty(names),
+		ExpressionAttributeValues: nilIfEmpty(values),
 	}
 
-	if d.sortKeyAttribute != "" {
-		deleteInput.Key[d.sortKeyAttribute] = &types.AttributeValueMemberS{Value: sk}
+	if d.overflow != nil {
+		deleteInput.ReturnValues = types.ReturnValueAllOld
 	}
 
-	_, err := d.client.DeleteItem(context.Background(), deleteInput)
+	result, err := d.client.DeleteItem(ctx, deleteInput)
+	d.invalidate(KeyPair{Key: key, SortKey: sk})
 
 	if err != nil {
-		return fmt.Errorf("failed to delete key from dynamodb: %s", err)
+		return fmt.Errorf("failed to delete key from dynamodb: %w", conditionError(err))
 	}
 
+	d.removeOverflow(ctx, result.Attributes, nil)
+
 	return nil
 }
 
+// HealthCheck verifies that the table is reachable with the configured client.
+//
+// Parameters:
+//   - ctx: Context used for the DescribeTable call
+//
+// Returns:
+//   - error: Returns an error if the table cannot be described
+func (d *DynamoDB) HealthCheck(ctx context.Context) error {
+	return ensureTableExists(ctx, d.client, d.tableName)
+}
+
 // Get is a generic utility function that retrieves an item from a DynamoDB table and returns it
 // as a strongly-typed pointer. This function provides a convenient wrapper around the DynamoDB
 // Get operation with automatic type conversion.
@@ -646,6 +1107,11 @@ func (d *DynamoDB) Delete(key string, sortKey ...string) error {
 // The function automatically handles different storage modes (JSON vs native DynamoDB types)
 // and returns nil without error if the requested item doesn't exist in the table.
 func Get[T any](tableName string, key string, sortKey ...string) (*T, *time.Time, error) {
+	return GetContext[T](context.Background(), tableName, key, sortKey...)
+}
+
+// GetContext is the form of Get that uses ctx for the DynamoDB calls
+func GetContext[T any](ctx context.Context, tableName string, key string, sortKey ...string) (*T, *time.Time, error) {
 
 	var value T
 
@@ -664,7 +1130,7 @@ func Get[T any](tableName string, key string, sortKey ...string) (*T, *time.Time
 		opts.SortKey = sortKey[0]
 	}
 
-	result, expiry, err := table.Get(key, opts)
+	result, expiry, err := table.GetContext(ctx, key, opts)
 
 	if err != nil {
 		return nil, nil, err
@@ -708,6 +1174,92 @@ func Get[T any](tableName string, key string, sortKey ...string) (*T, *time.Time
 // The function automatically handles type conversion and returns an empty slice if no items
 // match the query criteria.
 func Query[T any](tableName string, key string, options ...QueryOptions) ([]QueryResult[T], error) {
+	return QueryContext[T](context.Background(), tableName, key, options...)
+}
+
+// QueryContext is the form of Query that uses ctx for the DynamoDB calls
+func QueryContext[T any](ctx context.Context, tableName string, key string, options ...QueryOptions) ([]QueryResult[T], error) {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := getQueryOptions(options...)
+
+	var value T
+
+	opts.Result = value
+
+	items, err := table.QueryContext(ctx, key, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return typedResults[T](table, items), nil
+}
+
+// QuerySortKey is the form of Query for the common case of a single sort key condition. The
+// condition and SortKeyValue of options are replaced by condition and sortKeyValue.
+//
+// Example:
+//
+//	sessions, err := QuerySortKey[Session]("sessions", "user123", QueryConditionBeginsWith, "session_")
+func QuerySortKey[T any](tableName, key string, condition QueryCondition, sortKeyValue string, options ...QueryOptions) ([]QueryResult[T], error) {
+	return QuerySortKeyContext[T](context.Background(), tableName, key, condition, sortKeyValue, options...)
+}
+
+// QuerySortKeyContext is the form of QuerySortKey that uses ctx for the DynamoDB calls
+func QuerySortKeyContext[T any](ctx context.Context, tableName, key string, condition QueryCondition, sortKeyValue string, options ...QueryOptions) ([]QueryResult[T], error) {
+	var opts QueryOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.SortKeyCondition = condition
+	opts.SortKeyValue = sortKeyValue
+
+	return QueryContext[T](ctx, tableName, key, opts)
+}
+
+// QueryPage is the generic form of DynamoDB.QueryPage, returning typed items and a cursor
+// for the next page
+func QueryPage[T any](tableName string, key string, options ...QueryOptions) ([]QueryResult[T], string, error) {
+	return QueryPageContext[T](context.Background(), tableName, key, options...)
+}
+
+// QueryPageContext is the form of QueryPage that uses ctx for the DynamoDB calls
+func QueryPageContext[T any](ctx context.Context, tableName string, key string, options ...QueryOptions) ([]QueryResult[T], string, error) {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts := getQueryOptions(options...)
+
+	var value T
+
+	opts.Result = value
+
+	items, cursor, err := table.QueryPageContext(ctx, key, opts)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return typedResults[T](table, items), cursor, nil
+}
+
+// QueryAll is the generic form of DynamoDB.QueryAll, reading every page of a query up to
+// QueryOptions.MaxItems items
+//
+// Example:
+//
+//	people, err := QueryAll[Person](ctx, "users", "company1", QueryOptions{MaxItems: 500})
+func QueryAll[T any](ctx context.Context, tableName string, key string, options ...QueryOptions) ([]QueryResult[T], error) {
 	table, err := getTable(tableName)
 
 	if err != nil {
@@ -720,12 +1272,17 @@ func Query[T any](tableName string, key string, options ...QueryOptions) ([]Quer
 
 	opts.Result = value
 
-	items, err := table.Query(key, opts)
+	items, err := table.QueryAll(ctx, key, opts)
 
 	if err != nil {
 		return nil, err
 	}
 
+	return typedResults[T](table, items), nil
+}
+
+// typedResults converts query results of table to T
+func typedResults[T any](table *DynamoDB, items []QueryResult[any]) []QueryResult[T] {
 	var result []QueryResult[T]
 
 	for _, item := range items {
@@ -770,11 +1327,12 @@ func Query[T any](tableName string, key string, options ...QueryOptions) ([]Quer
 		result = append(result, QueryResult[T]{
 			Value:   resultValue,
 			Expiry:  item.Expiry,
+			Key:     item.Key,
 			SortKey: item.SortKey,
 		})
 	}
 
-	return result, nil
+	return result
 }
 
 // GetString is a utility function that retrieves a string value from a DynamoDB table.
@@ -790,6 +1348,11 @@ func Query[T any](tableName string, key string, options ...QueryOptions) ([]Quer
 //   - *expirationTime: A pointer to the time the item will expire, or nil if the item doesn't have a TTL
 //   - error: Returns an error if the retrieval fails or the value cannot be converted to string
 func GetString(tableName, key string, sortKey ...string) (string, *time.Time, error) {
+	return GetStringContext(context.Background(), tableName, key, sortKey...)
+}
+
+// GetStringContext is the form of GetString that uses ctx for the DynamoDB calls
+func GetStringContext(ctx context.Context, tableName, key string, sortKey ...string) (string, *time.Time, error) {
 	table, err := getTable(tableName)
 
 	if err != nil {
@@ -802,7 +1365,7 @@ func GetString(tableName, key string, sortKey ...string) (string, *time.Time, er
 		opts.SortKey = sortKey[0]
 	}
 
-	result, expiry, err := table.Get(key, opts)
+	result, expiry, err := table.GetContext(ctx, key, opts)
 
 	if err != nil || result == nil {
 		return "", expiry, err
@@ -826,13 +1389,18 @@ func GetString(tableName, key string, sortKey ...string) (string, *time.Time, er
 //   - *expirationTime: A pointer to the time the item will expire, or nil if the item doesn't have a TTL
 //   - error: Returns an error if retrieval fails or the value cannot be converted to integer
 func GetInt(tableName, key string) (int, *time.Time, error) {
+	return GetIntContext(context.Background(), tableName, key)
+}
+
+// GetIntContext is the form of GetInt that uses ctx for the DynamoDB calls
+func GetIntContext(ctx context.Context, tableName, key string) (int, *time.Time, error) {
 	table, err := getTable(tableName)
 
 	if err != nil {
 		return 0, nil, err
 	}
 
-	result, expiry, err := table.Get(key)
+	result, expiry, err := table.GetContext(ctx, key)
 
 	if err != nil || result == nil {
 		return 0, expiry, err
@@ -862,15 +1430,39 @@ func GetInt(tableName, key string) (int, *time.Time, error) {
 //
 // This function automatically handles the table lookup and delegates to the table's Put method.
 func Put(tableName, key string, value any, options ...PutOptions) error {
+	return PutContext(context.Background(), tableName, key, value, options...)
+}
+
+// PutContext is the form of Put that uses ctx for the DynamoDB calls
+func PutContext(ctx context.Context, tableName, key string, value any, options ...PutOptions) error {
 	table, err := getTable(tableName)
 
 	if err != nil {
 		return err
 	}
 
-	table.Put(key, value, options...)
+	return table.PutContext(ctx, key, value, options...)
+}
 
-	return nil
+// Update sets the attributes of value on an item in the table registered as tableName, as
+// DynamoDB.Update does. The sort key of the item is PutOptions.SortKey.
+//
+// Example:
+//
+//	err := Update("users", "user123", Person{Email: "new@email.com"}, PutOptions{SortKey: "profile"})
+func Update[T any](tableName, key string, value T, options ...PutOptions) error {
+	return UpdateContext(context.Background(), tableName, key, value, options...)
+}
+
+// UpdateContext is the form of Update that uses ctx for the DynamoDB call
+func UpdateContext[T any](ctx context.Context, tableName, key string, value T, options ...PutOptions) error {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	return table.UpdateContext(ctx, key, value, options...)
 }
 
 // Delete is a utility function that removes an item from a DynamoDB table by its key.
@@ -889,11 +1481,33 @@ func Put(tableName, key string, value any, options ...PutOptions) error {
 // for deleting non-existent items). It automatically handles the table lookup and delegates
 // to the table's Delete method.
 func Delete(tableName, key string, sortKey ...string) error {
+	return DeleteContext(context.Background(), tableName, key, sortKey...)
+}
+
+// DeleteContext is the form of Delete that uses ctx for the DynamoDB call
+func DeleteContext(ctx context.Context, tableName, key string, sortKey ...string) error {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	return table.DeleteContext(ctx, key, sortKey...)
+}
+
+// DeleteIf removes an item from the table registered as tableName only if it meets the
+// condition fields of options. See (*DynamoDB).DeleteIf.
+func DeleteIf(tableName, key string, options PutOptions) error {
+	return DeleteIfContext(context.Background(), tableName, key, options)
+}
+
+// DeleteIfContext is the form of DeleteIf that uses ctx for the DynamoDB call
+func DeleteIfContext(ctx context.Context, tableName, key string, options PutOptions) error {
 	table, err := getTable(tableName)
 
 	if err != nil {
 		return err
 	}
 
-	return table.Delete(key, sortKey...)
+	return table.DeleteIfContext(ctx, key, options)
 }
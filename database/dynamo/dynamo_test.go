@@ -2,11 +2,16 @@ package dynamo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/query"
 	"github.com/finch-technologies/go-utils/utils"
 )
 
@@ -1798,3 +1803,909 @@ func TestEdgeCasesAndNilHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestGenericBatchPutDelete(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	var items []BatchItem[Person]
+	var keys []KeyPair
+
+	// More than one BatchWriteItem chunk, with a repeated key
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("test_batch_%d", i)
+		items = append(items, BatchItem[Person]{Key: key, Value: Person{Name: key}, Ttl: time.Minute})
+		keys = append(keys, KeyPair{Key: key})
+	}
+	items = append(items, BatchItem[Person]{Key: "test_batch_0", Value: Person{Name: "last write"}, Ttl: time.Minute})
+
+	if err := BatchPut(ctx, "dynamo.test", items); err != nil {
+		t.Fatalf("Failed to batch put: %v", err)
+	}
+
+	value, _, err := Get[Person]("dynamo.test", "test_batch_0")
+	if err != nil || value == nil || value.Name != "last write" {
+		t.Fatalf("Expected last write, got %v, %v", value, err)
+	}
+
+	if err := BatchDelete(ctx, "dynamo.test", keys); err != nil {
+		t.Fatalf("Failed to batch delete: %v", err)
+	}
+
+	value, _, err = Get[Person]("dynamo.test", "test_batch_29")
+	if err != nil || value != nil {
+		t.Fatalf("Expected %v, got %v, %v", nil, value, err)
+	}
+}
+
+func TestGenericBatchGet(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	var items []BatchItem[Person]
+	var keys []KeyPair
+
+	// More than one BatchGetItem chunk, with a missing key at the end
+	for i := 0; i < 120; i++ {
+		key := fmt.Sprintf("test_batch_get_%d", i)
+		items = append(items, BatchItem[Person]{Key: key, Value: Person{Name: key}, Ttl: time.Minute})
+		keys = append(keys, KeyPair{Key: key})
+	}
+	keys = append(keys, KeyPair{Key: "test_batch_get_missing"})
+
+	if err := BatchPut(ctx, "dynamo.test", items); err != nil {
+		t.Fatalf("Failed to batch put: %v", err)
+	}
+
+	results, err := BatchGet[Person]("dynamo.test", keys)
+	if err != nil {
+		t.Fatalf("Failed to batch get: %v", err)
+	}
+
+	if len(results) != len(keys) {
+		t.Fatalf("Expected %d results, got %d", len(keys), len(results))
+	}
+
+	for i, result := range results[:120] {
+		if result.Value == nil || result.Value.Name != keys[i].Key || result.Expiry == nil {
+			t.Fatalf("Expected %s, got %+v", keys[i].Key, result)
+		}
+	}
+
+	if results[120].Value != nil {
+		t.Fatalf("Expected missing item, got %+v", results[120].Value)
+	}
+
+	BatchDelete(ctx, "dynamo.test", keys)
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	db, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+		VersionAttribute: "version",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	key := "test_optimistic_locking"
+	defer db.Delete(key)
+
+	if err := db.Put(key, Person{Name: "John"}, PutOptions{Ttl: time.Minute}); err != nil {
+		t.Fatalf("Failed to create item: %v", err)
+	}
+
+	if err := db.Put(key, Person{Name: "Jane"}, PutOptions{Ttl: time.Minute}); !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("Expected ErrConditionFailed creating an existing item, got %v", err)
+	}
+
+	var version int64
+	var person Person
+
+	if _, _, err := db.Get(key, GetOptions{Result: &person, Version: &version}); err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if version != 1 {
+		t.Fatalf("Expected version 1, got %d", version)
+	}
+
+	if err := db.Update(key, map[string]any{"email": "john@example.com"}, PutOptions{ExpectedVersion: version}); err != nil {
+		t.Fatalf("Failed to update item: %v", err)
+	}
+
+	// A stale version is rejected
+	if err := db.Update(key, map[string]any{"email": "stale@example.com"}, PutOptions{ExpectedVersion: version}); !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("Expected ErrConditionFailed for a stale version, got %v", err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	defer Delete("dynamo.test", "test_tx_1")
+	defer Delete("dynamo.test", "test_tx_2")
+
+	err = NewTransaction().
+		Put("dynamo.test", "test_tx_1", Person{Name: "John"}, PutOptions{Ttl: time.Minute}).
+		Put("dynamo.test", "test_tx_2", Person{Name: "Jane"}, PutOptions{Ttl: time.Minute, IfNotExists: true}).
+		Commit(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	// The failed condition on the second item rolls back the delete of the first
+	err = NewTransaction().
+		Delete("dynamo.test", "test_tx_1").
+		Put("dynamo.test", "test_tx_2", Person{Name: "Other"}, PutOptions{IfNotExists: true}).
+		Commit(ctx)
+
+	if !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("Expected ErrConditionFailed, got %v", err)
+	}
+
+	person, _, err := Get[Person]("dynamo.test", "test_tx_1")
+	if err != nil || person == nil || person.Name != "John" {
+		t.Fatalf("Expected the first item to remain, got %+v, %v", person, err)
+	}
+}
+
+func TestGenericQueryAll(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	key := "test_query_all"
+
+	var items []BatchItem[Person]
+	var keys []KeyPair
+
+	for i := 0; i < 25; i++ {
+		sortKey := fmt.Sprintf("item_%02d", i)
+		items = append(items, BatchItem[Person]{Key: key, SortKey: sortKey, Value: Person{Name: sortKey}, Ttl: time.Minute})
+		keys = append(keys, KeyPair{Key: key, SortKey: sortKey})
+	}
+
+	if err := BatchPut(ctx, "dynamo.test", items); err != nil {
+		t.Fatalf("Failed to batch put: %v", err)
+	}
+	defer BatchDelete(ctx, "dynamo.test", keys)
+
+	// Pages of 10 items, so QueryAll has to follow LastEvaluatedKey
+	results, err := QueryAll[Person](ctx, "dynamo.test", key, QueryOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to query all: %v", err)
+	}
+
+	if len(results) != 25 {
+		t.Fatalf("Expected 25 items, got %d", len(results))
+	}
+
+	results, err = QueryAll[Person](ctx, "dynamo.test", key, QueryOptions{Limit: 10, MaxItems: 15})
+	if err != nil {
+		t.Fatalf("Failed to query all: %v", err)
+	}
+
+	if len(results) != 15 {
+		t.Fatalf("Expected MaxItems to cap results at 15, got %d", len(results))
+	}
+
+	var lastKey map[string]types.AttributeValue
+
+	page, err := Query[Person]("dynamo.test", key, QueryOptions{Limit: 10, LastEvaluatedKey: &lastKey})
+	if err != nil || len(page) != 10 || lastKey == nil {
+		t.Fatalf("Expected a first page with a LastEvaluatedKey, got %d items, %v", len(page), err)
+	}
+
+	page, err = Query[Person]("dynamo.test", key, QueryOptions{Limit: 10, ExclusiveStartKey: lastKey})
+	if err != nil || len(page) != 10 || page[0].SortKey != "item_10" {
+		t.Fatalf("Expected the second page to start at item_10, got %+v, %v", page, err)
+	}
+}
+
+func TestRotateValues(t *testing.T) {
+	ctx := context.Background()
+
+	table, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	oldKey := SslCipher{SecretKey: "0123456789abcdef0123456789abcdef", IV: "abcdef0123456789"}
+	newKey := SslCipher{SecretKey: "fedcba9876543210fedcba9876543210", IV: "9876543210fedcba"}
+
+	key := "test_rotate_values"
+
+	for _, sortKey := range []string{"a", "b"} {
+		encrypted, err := oldKey.Encrypt(ctx, "secret_"+sortKey)
+		if err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+
+		if err := table.Put(key, encrypted, PutOptions{SortKey: sortKey, Ttl: time.Minute}); err != nil {
+			t.Fatalf("Failed to put item: %v", err)
+		}
+		defer table.Delete(key, sortKey)
+	}
+
+	progress, err := table.RotateValues(ctx, oldKey, newKey, RotateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to dry run rotation: %v", err)
+	}
+
+	if progress.Rotated < 2 {
+		t.Fatalf("Expected the dry run to count both items, got %+v", progress)
+	}
+
+	if value, _, _ := table.Get(key, GetOptions{SortKey: "a"}); value == nil {
+		t.Fatal("Expected the item to still exist")
+	} else if _, err := oldKey.Decrypt(ctx, value.(string)); err != nil {
+		t.Fatalf("Expected the dry run not to write, got %v", err)
+	}
+
+	if _, err := table.RotateValues(ctx, oldKey, newKey); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+
+	for _, sortKey := range []string{"a", "b"} {
+		value, _, err := table.Get(key, GetOptions{SortKey: sortKey})
+		if err != nil {
+			t.Fatalf("Failed to get item: %v", err)
+		}
+
+		plaintext, err := newKey.Decrypt(ctx, value.(string))
+		if err != nil || plaintext != "secret_"+sortKey {
+			t.Fatalf("Expected %s to decrypt with the new key, got %q, %v", sortKey, plaintext, err)
+		}
+	}
+
+	// Running again skips the rotated items instead of failing on them
+	progress, err = table.RotateValues(ctx, oldKey, newKey)
+	if err != nil {
+		t.Fatalf("Failed to rerun rotation: %v", err)
+	}
+
+	if progress.Skipped < 2 {
+		t.Fatalf("Expected rotated items to be skipped, got %+v", progress)
+	}
+}
+
+func TestGenericScan(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	key := "test_scan"
+
+	var items []BatchItem[Person]
+	var keys []KeyPair
+
+	for i := 0; i < 10; i++ {
+		sortKey := fmt.Sprintf("item_%02d", i)
+		items = append(items, BatchItem[Person]{Key: key, SortKey: sortKey, Value: Person{Name: sortKey, Email: key}, Ttl: time.Minute})
+		keys = append(keys, KeyPair{Key: key, SortKey: sortKey})
+	}
+
+	if err := BatchPut(ctx, "dynamo.test", items); err != nil {
+		t.Fatalf("Failed to batch put: %v", err)
+	}
+	defer BatchDelete(ctx, "dynamo.test", keys)
+
+	for _, segments := range []int{1, 4} {
+		results, err := Scan[Person](ctx, "dynamo.test", ScanOptions{
+			Where:    query.Field("email").Eq(key),
+			Segments: segments,
+		})
+		if err != nil {
+			t.Fatalf("Failed to scan with %d segments: %v", segments, err)
+		}
+
+		if len(results) != 10 {
+			t.Fatalf("Expected 10 items with %d segments, got %d", segments, len(results))
+		}
+
+		for _, result := range results {
+			if result.Key != key || result.Value.Name != result.SortKey {
+				t.Fatalf("Unexpected scan result %+v", result)
+			}
+		}
+	}
+
+	results, err := Scan[Person](ctx, "dynamo.test", ScanOptions{Where: query.Field("email").Eq(key), MaxItems: 3})
+	if err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected MaxItems to cap results at 3, got %d", len(results))
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	key := "test_increment"
+	defer Delete("dynamo.test", key, "hits")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := Increment("dynamo.test", key, "count", 2, PutOptions{SortKey: "hits", Ttl: time.Minute}); err != nil {
+				t.Errorf("Failed to increment: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	count, err := Decrement("dynamo.test", key, "count", 5, PutOptions{SortKey: "hits"})
+	if err != nil {
+		t.Fatalf("Failed to decrement: %v", err)
+	}
+
+	if count != 15 {
+		t.Fatalf("Expected concurrent increments to add up to 15 after the decrement, got %d", count)
+	}
+
+	if _, err := Increment("dynamo.test", key, "group_id", 1); err == nil {
+		t.Fatal("Expected incrementing a key attribute to fail")
+	}
+}
+
+func TestAutoCreateTable(t *testing.T) {
+	ctx := context.Background()
+	tableName := fmt.Sprintf("dynamo.autocreate.%d", time.Now().UnixNano())
+
+	if _, err := New(DbOptions{TableName: tableName}); err == nil {
+		t.Fatal("Expected New to fail for a missing table without AutoCreateTable")
+	}
+
+	table, err := New(DbOptions{
+		TableName:        tableName,
+		SortKeyAttribute: "group_id",
+		AutoCreateTable:  &AutoCreateOptions{EnableTtl: true},
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.client.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: &tableName})
+
+	if err := table.WaitForActive(ctx); err != nil {
+		t.Fatalf("Expected the table to be active: %v", err)
+	}
+
+	if err := table.Put("key", "value", PutOptions{SortKey: "sk"}); err != nil {
+		t.Fatalf("Failed to put into the created table: %v", err)
+	}
+
+	value, _, err := table.Get("key", GetOptions{SortKey: "sk"})
+	if err != nil || value != "value" {
+		t.Fatalf("Expected to read the value back, got %v, %v", value, err)
+	}
+}
+
+func TestTtlCheck(t *testing.T) {
+	ctx := context.Background()
+	tableName := fmt.Sprintf("dynamo.ttlcheck.%d", time.Now().UnixNano())
+
+	table, err := New(DbOptions{TableName: tableName, AutoCreateTable: &AutoCreateOptions{}})
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.client.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: &tableName})
+
+	if _, err := New(DbOptions{TableName: tableName, TtlCheck: TtlCheckVerify}); !errors.Is(err, ErrTtlMisconfigured) {
+		t.Fatalf("Expected ErrTtlMisconfigured with ttl disabled, got %v", err)
+	}
+
+	if _, err := New(DbOptions{TableName: tableName, TtlCheck: TtlCheckEnable}); err != nil {
+		t.Fatalf("Failed to enable ttl: %v", err)
+	}
+
+	if _, err := New(DbOptions{TableName: tableName, TtlCheck: TtlCheckVerify}); err != nil {
+		t.Fatalf("Expected ttl to be enabled, got %v", err)
+	}
+
+	if _, err := New(DbOptions{TableName: tableName, TtlAttribute: "expires_at", TtlCheck: TtlCheckEnable}); !errors.Is(err, ErrTtlMisconfigured) {
+		t.Fatalf("Expected ErrTtlMisconfigured with ttl on another attribute, got %v", err)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+
+	table, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+		Compression:      CompressionGzip,
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	large := Person{Name: "John Doe", Email: strings.Repeat("john.doe@example.com ", 1000)}
+
+	tests := []struct {
+		name           string
+		key            string
+		value          Person
+		options        PutOptions
+		wantCompressed bool
+	}{
+		{"large value", "test_compressed", large, PutOptions{}, true},
+		{"small value", "test_uncompressed", Person{Name: "Jane Doe"}, PutOptions{}, false},
+		{"compression disabled for the put", "test_not_compressed", large, PutOptions{Compress: CompressionNone}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Ttl = time.Minute
+
+			if err := table.Put(tt.key, tt.value, tt.options); err != nil {
+				t.Fatalf("Failed to put value: %v", err)
+			}
+			defer table.Delete(tt.key)
+
+			raw, err := table.client.GetItem(ctx, &dynamodb.GetItemInput{
+				TableName: &table.tableName,
+				Key:       table.itemKey(tt.key, "null"),
+			})
+			if err != nil {
+				t.Fatalf("Failed to read raw item: %v", err)
+			}
+
+			if _, compressed := raw.Item[table.valueAttribute].(*types.AttributeValueMemberB); compressed != tt.wantCompressed {
+				t.Errorf("Expected compressed to be %v", tt.wantCompressed)
+			}
+
+			value, _, err := Get[Person]("dynamo.test", tt.key)
+			if err != nil || value == nil || *value != tt.value {
+				t.Fatalf("Expected to read the value back, got %v, %v", value, err)
+			}
+
+			results, err := Query[Person]("dynamo.test", tt.key)
+			if err != nil || len(results) != 1 || results[0].Value != tt.value {
+				t.Fatalf("Expected to query the value back, got %v, %v", results, err)
+			}
+		})
+	}
+}
+
+// memoryStore is an in-memory OverflowStore
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryStore) PutObject(ctx context.Context, key string, data []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryStore) GetObject(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", key)
+	}
+	return data, nil
+}
+
+func (s *memoryStore) DeleteObject(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func TestOverflow(t *testing.T) {
+	store := &memoryStore{objects: map[string][]byte{}}
+
+	table, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+		Overflow:         &OverflowOptions{Store: store, Threshold: 1024},
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	large := Person{Name: "John Doe", Email: strings.Repeat("x", 2048)}
+
+	if err := table.Put("test_overflow", large, PutOptions{Ttl: time.Minute}); err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+
+	if len(store.objects) != 1 {
+		t.Fatalf("Expected the value in the overflow store, got %d objects", len(store.objects))
+	}
+
+	value, _, err := Get[Person]("dynamo.test", "test_overflow")
+	if err != nil || value == nil || *value != large {
+		t.Fatalf("Expected to read the value back, got %v, %v", value, err)
+	}
+
+	results, err := Query[Person]("dynamo.test", "test_overflow")
+	if err != nil || len(results) != 1 || results[0].Value != large {
+		t.Fatalf("Expected to query the value back, got %v, %v", results, err)
+	}
+
+	// Replacing the value with a small one removes the object
+	if err := table.Put("test_overflow", Person{Name: "Jane Doe"}, PutOptions{Ttl: time.Minute}); err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+
+	if len(store.objects) != 0 {
+		t.Errorf("Expected the replaced object to be removed, got %d objects", len(store.objects))
+	}
+
+	if err := table.Put("test_overflow", large, PutOptions{Ttl: time.Minute}); err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+
+	if err := table.Delete("test_overflow"); err != nil {
+		t.Fatalf("Failed to delete value: %v", err)
+	}
+
+	if len(store.objects) != 0 {
+		t.Errorf("Expected the deleted object to be removed, got %d objects", len(store.objects))
+	}
+}
+
+func TestGetClient(t *testing.T) {
+	regional, err := GetClient(ClientConfig{Region: "af-south-1"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   ClientConfig
+		wantSame bool
+	}{
+		{"same region", ClientConfig{Region: "af-south-1"}, true},
+		{"other region", ClientConfig{Region: "eu-west-1"}, false},
+		{"local endpoint", ClientConfig{Region: "af-south-1", Endpoint: "http://localhost:8000"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := GetClient(tt.config)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			if (client == regional) != tt.wantSame {
+				t.Errorf("Expected same client to be %v", tt.wantSame)
+			}
+
+			again, _ := GetClient(tt.config)
+			if again != client {
+				t.Error("Expected the client to be cached")
+			}
+		})
+	}
+}
+
+func TestGenericUpdateAndQuerySortKey(t *testing.T) {
+	_, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		ValueStoreMode:   ValueStoreModeAttributes,
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	for _, sortKey := range []string{"person_1", "person_2", "other_1"} {
+		err := Put("dynamo.test", "test_generic_update", Person{Name: sortKey}, PutOptions{SortKey: sortKey, Ttl: time.Minute})
+		if err != nil {
+			t.Fatalf("Failed to put value: %v", err)
+		}
+	}
+
+	err = Update("dynamo.test", "test_generic_update", Person{Name: "person_1", Email: "updated@example.com"}, PutOptions{SortKey: "person_1"})
+	if err != nil {
+		t.Fatalf("Failed to update value: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		condition QueryCondition
+		value     string
+		want      int
+	}{
+		{"begins with", QueryConditionBeginsWith, "person_", 2},
+		{"equals", QueryConditionEquals, "person_1", 1},
+		{"greater than", QueryConditionGreaterThan, "other_1", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := QuerySortKey[Person]("dynamo.test", "test_generic_update", tt.condition, tt.value)
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+
+			if len(results) != tt.want {
+				t.Errorf("Expected %d results, got %d", tt.want, len(results))
+			}
+		})
+	}
+
+	results, _ := QuerySortKey[Person]("dynamo.test", "test_generic_update", QueryConditionEquals, "person_1")
+	if len(results) != 1 || results[0].Value.Email != "updated@example.com" {
+		t.Errorf("Expected the updated email, got %v", results)
+	}
+}
+
+func TestDeleteExpiredOnRead(t *testing.T) {
+	ctx := context.Background()
+
+	table, err := New(DbOptions{
+		TableName:           "dynamo.test",
+		SortKeyAttribute:    "group_id",
+		DeleteExpiredOnRead: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	if err := table.Put("test_expired_on_read", "value", PutOptions{Ttl: time.Second}); err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	value, _, err := table.Get("test_expired_on_read")
+	if err != nil || value != "" {
+		t.Fatalf("Expected the expired value to be skipped, got %v, %v", value, err)
+	}
+
+	// The delete runs in the background
+	for i := 0; i < 20; i++ {
+		raw, err := table.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: &table.tableName,
+			Key:       table.itemKey("test_expired_on_read", "null"),
+		})
+		if err != nil {
+			t.Fatalf("Failed to read raw item: %v", err)
+		}
+
+		if raw.Item == nil {
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Error("Expected the expired item to be deleted")
+}
+
+func TestReadCache(t *testing.T) {
+	ctx := context.Background()
+
+	table, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+		CacheSize:        100,
+		CacheTTL:         time.Minute,
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	if err := table.Put("test_read_cache", "first"); err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+
+	if value, _, _ := table.Get("test_read_cache"); value != "first" {
+		t.Fatalf("Expected first, got %v", value)
+	}
+
+	// A write that bypasses the table isn't seen until the entry expires
+	update := "SET #value = :value"
+	_, err = table.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &table.tableName,
+		Key:                       table.itemKey("test_read_cache", "null"),
+		UpdateExpression:          &update,
+		ExpressionAttributeNames:  map[string]string{"#value": "value"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":value": &types.AttributeValueMemberS{Value: "bypassed"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update raw item: %v", err)
+	}
+
+	if value, _, _ := table.Get("test_read_cache"); value != "first" {
+		t.Errorf("Expected the cached value, got %v", value)
+	}
+
+	tests := []struct {
+		name  string
+		write func() error
+		want  string
+	}{
+		{"put", func() error { return table.Put("test_read_cache", "second") }, "second"},
+		{"delete", func() error { return table.Delete("test_read_cache") }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); err != nil {
+				t.Fatalf("Failed to write: %v", err)
+			}
+
+			if value, _, _ := table.Get("test_read_cache"); value != tt.want {
+				t.Errorf("Expected %q after %s, got %v", tt.want, tt.name, value)
+			}
+		})
+	}
+}
+
+func TestLegacyDB(t *testing.T) {
+	table, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	db := NewLegacy(table)
+
+	if err := db.Set("test_legacy", "value", time.Hour); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	if value, err := db.GetString("test_legacy"); err != nil || value != "value" {
+		t.Errorf("Expected value, got %q, %v", value, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := db.SetWithSortKey("test_legacy_list", fmt.Sprintf("item#%d", i), fmt.Sprintf("value_%d", i), time.Hour); err != nil {
+			t.Fatalf("Failed to set value with sort key: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int64
+		want   int
+	}{
+		{"all", "item#", 10, 3},
+		{"limited", "item#", 2, 2},
+		{"no match", "other#", 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := db.GetListWithPrefix("test_legacy_list", tt.prefix, tt.limit)
+			if err != nil {
+				t.Fatalf("Failed to get list: %v", err)
+			}
+
+			if len(values) != tt.want {
+				t.Errorf("Expected %d values, got %v", tt.want, values)
+			}
+		})
+	}
+
+	if err := db.Delete("test_legacy"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	if value, _ := db.Get("test_legacy"); value != nil {
+		t.Errorf("Expected nil after delete, got %q", value)
+	}
+}
+
+func TestQuerySortKeyConditions(t *testing.T) {
+	table, err := New(DbOptions{
+		TableName:        "dynamo.test",
+		SortKeyAttribute: "group_id",
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	for _, sortKey := range []string{"2024-01_a", "2024-02_b", "2024-03_a", "2024-04_b"} {
+		if err := table.Put("test_sort_key_conditions", sortKey, PutOptions{SortKey: sortKey}); err != nil {
+			t.Fatalf("Failed to put item: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options QueryOptions
+		want    int
+		wantErr bool
+	}{
+		{"between", QueryOptions{SortKeyCondition: QueryConditionBetween, SortKeyValueFrom: "2024-02", SortKeyValueTo: "2024-03~"}, 2, false},
+		{"between reversed", QueryOptions{SortKeyCondition: QueryConditionBetween, SortKeyValueFrom: "2024-03", SortKeyValueTo: "2024-01"}, 0, true},
+		{"ends with", QueryOptions{SortKeyCondition: QueryConditionEndsWith, SortKeyValue: "_a"}, 2, false},
+		{"contains", QueryOptions{SortKeyCondition: QueryConditionContains, SortKeyValue: "-04"}, 1, false},
+		{"not equals", QueryOptions{SortKeyCondition: QueryConditionNotEquals, SortKeyValue: "2024-01_a"}, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := table.Query("test_sort_key_conditions", tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(results) != tt.want {
+				t.Errorf("Expected %d results, got %d", tt.want, len(results))
+			}
+		})
+	}
+}
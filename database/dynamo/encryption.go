@@ -0,0 +1,546 @@
+package dynamo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/encryption/kms"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// encryptedValuePrefix marks values sealed with a table data key. The full format is
+// "enc:v1:<key version>:<base64 nonce+ciphertext>".
+const encryptedValuePrefix = "enc:v1:"
+
+// KeyWrapper wraps and unwraps table data keys with a master key
+type KeyWrapper interface {
+	GenerateDataKey(ctx context.Context, kmsKeyId string) (plaintext []byte, wrapped []byte, err error)
+	UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error)
+	RewrapDataKey(ctx context.Context, wrapped []byte, kmsKeyId string) ([]byte, error)
+}
+
+// kmsWrapper wraps data keys with AWS KMS
+type kmsWrapper struct{}
+
+func (kmsWrapper) GenerateDataKey(ctx context.Context, kmsKeyId string) ([]byte, []byte, error) {
+	return kms.GenerateDataKey(ctx, kmsKeyId)
+}
+
+func (kmsWrapper) UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return kms.DecryptDataKey(ctx, wrapped)
+}
+
+func (kmsWrapper) RewrapDataKey(ctx context.Context, wrapped []byte, kmsKeyId string) ([]byte, error) {
+	return kms.ReEncrypt(ctx, wrapped, kmsKeyId)
+}
+
+// keyRing is stored (unencrypted) in the table's key item and holds every data key
+// version, wrapped by KMS, so values written with older keys stay readable
+type keyRing struct {
+	Current  int            `json:"current"`
+	KmsKeyId string         `json:"kms_key_id"`
+	Keys     map[int]string `json:"keys"`
+}
+
+type tableEncryption struct {
+	opts EncryptionOptions
+
+	mu     sync.Mutex
+	ring   *keyRing
+	raw    string         // key ring JSON as last read, used for conditional writes
+	loaded time.Time      // when the key ring was last read or written
+	keys   map[int][]byte // unwrapped data keys by version
+}
+
+func newTableEncryption(opts EncryptionOptions) *tableEncryption {
+	opts.KeyItem = utils.StringOrDefault(opts.KeyItem, "__data_keys__")
+	opts.RingTtl = utils.DurationOrDefault(opts.RingTtl, 5*time.Minute)
+
+	if opts.Wrapper == nil {
+		opts.Wrapper = kmsWrapper{}
+	}
+
+	return &tableEncryption{
+		opts: opts,
+		keys: make(map[int][]byte),
+	}
+}
+
+// RotateDataKey adds a new data key version to the table's key ring. New writes use the
+// new key while values written with older keys stay readable; use Reencrypt to move
+// existing items to the new key.
+func (d *DynamoDB) RotateDataKey(ctx context.Context) error {
+	e, err := d.requireEncryption()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ring, raw, err := d.readKeyRing(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ring == nil {
+		return d.createKeyRing(ctx)
+	}
+
+	plaintext, wrapped, err := e.opts.Wrapper.GenerateDataKey(ctx, e.opts.KmsKeyId)
+	if err != nil {
+		return err
+	}
+
+	version := ring.Current + 1
+	ring.Keys[version] = base64.StdEncoding.EncodeToString(wrapped)
+	ring.Current = version
+	ring.KmsKeyId = e.opts.KmsKeyId
+
+	err = d.writeKeyRing(ctx, ring, raw)
+	if err != nil {
+		return err
+	}
+
+	e.keys[version] = plaintext
+
+	return nil
+}
+
+// RewrapDataKeys re-wraps every data key version under a different KMS key, e.g. after the
+// old KMS key was scheduled for deletion. Stored values don't need to be rewritten since
+// the data keys themselves are unchanged.
+func (d *DynamoDB) RewrapDataKeys(ctx context.Context, kmsKeyId string) error {
+	e, err := d.requireEncryption()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ring, raw, err := d.readKeyRing(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ring == nil {
+		return fmt.Errorf("table %s has no data keys to rewrap", d.tableName)
+	}
+
+	for version, encoded := range ring.Keys {
+		wrapped, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid data key version %d: %w", version, err)
+		}
+
+		rewrapped, err := e.opts.Wrapper.RewrapDataKey(ctx, wrapped, kmsKeyId)
+		if err != nil {
+			return fmt.Errorf("failed to rewrap data key version %d: %w", version, err)
+		}
+
+		ring.Keys[version] = base64.StdEncoding.EncodeToString(rewrapped)
+	}
+
+	ring.KmsKeyId = kmsKeyId
+
+	err = d.writeKeyRing(ctx, ring, raw)
+	if err != nil {
+		return err
+	}
+
+	e.opts.KmsKeyId = kmsKeyId
+
+	return nil
+}
+
+// Reencrypt rewrites an item's value with the current data key. Plaintext values written
+// before encryption was enabled are encrypted as well. The item's TTL is left unchanged.
+func (d *DynamoDB) Reencrypt(ctx context.Context, key string, sortKey ...string) error {
+	if _, err := d.requireEncryption(); err != nil {
+		return err
+	}
+
+	sk := ""
+	if d.sortKeyAttribute != "" {
+		sk = "null"
+		if len(sortKey) > 0 {
+			sk = utils.StringOrDefault(sortKey[0], "null")
+		}
+	}
+
+	keys := d.itemKey(key, sk)
+
+	result, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tableName),
+		Key:       keys,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to get item from dynamodb: %w", err)
+	}
+
+	attr, ok := result.Item[d.valueAttribute].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil
+	}
+
+	aad := d.itemAad(key, sk)
+
+	plaintext, err := d.decryptValue(ctx, aad, attr.Value)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := d.encryptValue(ctx, aad, plaintext)
+	if err != nil {
+		return err
+	}
+
+	if sealed == attr.Value {
+		return nil
+	}
+
+	_, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.tableName),
+		Key:                       keys,
+		UpdateExpression:          aws.String("SET #v = :v"),
+		ConditionExpression:       aws.String("#v = :old"),
+		ExpressionAttributeNames:  map[string]string{"#v": d.valueAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":v": &types.AttributeValueMemberS{Value: sealed}, ":old": attr},
+	})
+	d.invalidate(KeyPair{Key: key, SortKey: sk})
+
+	if err != nil {
+		return fmt.Errorf("failed to reencrypt item: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DynamoDB) requireEncryption() (*tableEncryption, error) {
+	if d.encryption == nil {
+		return nil, fmt.Errorf("encryption is not enabled for table %s", d.tableName)
+	}
+	return d.encryption, nil
+}
+
+// encryptValue seals a value with the current data key. The item's key is used as
+// additional data so an encrypted value can't be copied to another item.
+func (d *DynamoDB) encryptValue(ctx context.Context, aad, plaintext string) (string, error) {
+	version, key, err := d.currentDataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return sealValue(key, version, aad, plaintext)
+}
+
+// decryptValue opens a sealed value, returning values without the encryption prefix as-is
+// so tables can be migrated gradually
+func (d *DynamoDB) decryptValue(ctx context.Context, aad, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedValuePrefix) {
+		return value, nil
+	}
+
+	version, err := sealedValueVersion(value)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := d.dataKey(ctx, version)
+	if err != nil {
+		return "", err
+	}
+
+	return openValue(key, aad, value)
+}
+
+func (d *DynamoDB) itemAad(key, sortKey string) string {
+	return d.tableName + "/" + key + "/" + sortKey
+}
+
+func (d *DynamoDB) itemKey(key, sortKey string) map[string]types.AttributeValue {
+	keys := map[string]types.AttributeValue{
+		d.partitionKeyAttribute: &types.AttributeValueMemberS{Value: key},
+	}
+
+	if d.sortKeyAttribute != "" {
+		keys[d.sortKeyAttribute] = &types.AttributeValueMemberS{Value: sortKey}
+	}
+
+	return keys
+}
+
+// currentDataKey returns the data key new values are sealed with. The key ring is re-read
+// once it's older than RingTtl so keys rotated by other instances are picked up.
+func (d *DynamoDB) currentDataKey(ctx context.Context) (int, []byte, error) {
+	e := d.encryption
+
+	e.mu.Lock()
+	ring := e.ring
+	stale := time.Since(e.loaded) > e.opts.RingTtl
+	e.mu.Unlock()
+
+	if ring == nil || stale {
+		if err := d.loadKeyRing(ctx); err != nil {
+			return 0, nil, err
+		}
+
+		e.mu.Lock()
+		ring = e.ring
+		e.mu.Unlock()
+	}
+
+	key, err := d.dataKey(ctx, ring.Current)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return ring.Current, key, nil
+}
+
+// dataKey returns an unwrapped data key version, reloading the key ring if the version
+// was added by another instance since it was last read
+func (d *DynamoDB) dataKey(ctx context.Context, version int) ([]byte, error) {
+	e := d.encryption
+
+	e.mu.Lock()
+	key := e.keys[version]
+	ring := e.ring
+	e.mu.Unlock()
+
+	if key != nil {
+		return key, nil
+	}
+
+	if ring == nil || ring.Keys[version] == "" {
+		if err := d.loadKeyRing(ctx); err != nil {
+			return nil, err
+		}
+
+		e.mu.Lock()
+		ring = e.ring
+		e.mu.Unlock()
+	}
+
+	encoded := ring.Keys[version]
+	if encoded == "" {
+		return nil, fmt.Errorf("data key version %d not found for table %s", version, d.tableName)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key version %d: %w", version, err)
+	}
+
+	key, err = e.opts.Wrapper.UnwrapDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.keys[version] = key
+	e.mu.Unlock()
+
+	return key, nil
+}
+
+// loadKeyRing reads the key ring, creating it with a first data key if the table has none
+func (d *DynamoDB) loadKeyRing(ctx context.Context) error {
+	e := d.encryption
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ring, raw, err := d.readKeyRing(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ring != nil {
+		e.ring, e.raw, e.loaded = ring, raw, time.Now()
+		return nil
+	}
+
+	return d.createKeyRing(ctx)
+}
+
+// createKeyRing must be called with the encryption lock held
+func (d *DynamoDB) createKeyRing(ctx context.Context) error {
+	e := d.encryption
+
+	plaintext, wrapped, err := e.opts.Wrapper.GenerateDataKey(ctx, e.opts.KmsKeyId)
+	if err != nil {
+		return err
+	}
+
+	ring := &keyRing{
+		Current:  1,
+		KmsKeyId: e.opts.KmsKeyId,
+		Keys:     map[int]string{1: base64.StdEncoding.EncodeToString(wrapped)},
+	}
+
+	err = d.writeKeyRing(ctx, ring, "")
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		// Another instance created the key ring first, use theirs
+		ring, raw, err := d.readKeyRing(ctx)
+		if err != nil {
+			return err
+		}
+		if ring == nil {
+			return fmt.Errorf("data keys for table %s changed concurrently", d.tableName)
+		}
+		e.ring, e.raw, e.loaded = ring, raw, time.Now()
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	e.keys[1] = plaintext
+
+	return nil
+}
+
+// readKeyRing must be called with the encryption lock held. It returns nil if the table
+// has no key ring yet.
+func (d *DynamoDB) readKeyRing(ctx context.Context) (*keyRing, string, error) {
+	result, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.tableName),
+		Key:            d.itemKey(d.encryption.opts.KeyItem, "null"),
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read data keys: %w", err)
+	}
+
+	attr, ok := result.Item[d.valueAttribute].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, "", nil
+	}
+
+	var ring keyRing
+	if err := json.Unmarshal([]byte(attr.Value), &ring); err != nil {
+		return nil, "", fmt.Errorf("failed to parse data keys: %w", err)
+	}
+
+	return &ring, attr.Value, nil
+}
+
+// writeKeyRing must be called with the encryption lock held. The write only succeeds if
+// the stored key ring still matches previous, so concurrent rotations can't drop a key.
+func (d *DynamoDB) writeKeyRing(ctx context.Context, ring *keyRing, previous string) error {
+	value, err := json.Marshal(ring)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data keys: %w", err)
+	}
+
+	item := d.itemKey(d.encryption.opts.KeyItem, "null")
+	item[d.valueAttribute] = &types.AttributeValueMemberS{Value: string(value)}
+
+	input := &dynamodb.PutItemInput{
+		TableName:                aws.String(d.tableName),
+		Item:                     item,
+		ExpressionAttributeNames: map[string]string{"#v": d.valueAttribute},
+	}
+
+	if previous == "" {
+		input.ConditionExpression = aws.String("attribute_not_exists(#v)")
+	} else {
+		input.ConditionExpression = aws.String("#v = :previous")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":previous": &types.AttributeValueMemberS{Value: previous},
+		}
+	}
+
+	_, err = d.client.PutItem(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to write data keys: %w", err)
+	}
+
+	d.encryption.ring, d.encryption.raw, d.encryption.loaded = ring, string(value), time.Now()
+
+	return nil
+}
+
+func sealValue(key []byte, version int, aad, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+
+	return encryptedValuePrefix + strconv.Itoa(version) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openValue(key []byte, aad, value string) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedValuePrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func sealedValueVersion(value string) (int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedValuePrefix), ":", 2)
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid encrypted value version: %w", err)
+	}
+
+	return version, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
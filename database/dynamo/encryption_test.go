@@ -0,0 +1,362 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestSealOpenValue(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	sealed, err := sealValue(key, 3, "tokens/user123/null", `{"token":"secret"}`)
+	if err != nil {
+		t.Fatalf("sealValue() error = %v", err)
+	}
+
+	if !strings.HasPrefix(sealed, encryptedValuePrefix+"3:") || strings.Contains(sealed, "secret") {
+		t.Fatalf("sealValue() = %q, want versioned ciphertext", sealed)
+	}
+
+	version, err := sealedValueVersion(sealed)
+	if err != nil || version != 3 {
+		t.Errorf("sealedValueVersion() = %d, %v, want 3", version, err)
+	}
+
+	opened, err := openValue(key, "tokens/user123/null", sealed)
+	if err != nil {
+		t.Fatalf("openValue() error = %v", err)
+	}
+
+	if opened != `{"token":"secret"}` {
+		t.Errorf("openValue() = %q", opened)
+	}
+
+	// A value copied to another item must not decrypt
+	if _, err := openValue(key, "tokens/user456/null", sealed); err == nil {
+		t.Error("openValue() with a different item key succeeded, want error")
+	}
+
+	if _, err := openValue(bytes.Repeat([]byte{8}, 32), "tokens/user123/null", sealed); err == nil {
+		t.Error("openValue() with a different data key succeeded, want error")
+	}
+}
+
+// fakeWrapper "wraps" data keys by prefixing them with the KMS key id
+type fakeWrapper struct{}
+
+func (w *fakeWrapper) GenerateDataKey(ctx context.Context, kmsKeyId string) ([]byte, []byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	return key, append([]byte(kmsKeyId+":"), key...), nil
+}
+
+func (w *fakeWrapper) UnwrapDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	_, key, ok := bytes.Cut(wrapped, []byte(":"))
+	if !ok {
+		return nil, errors.New("invalid wrapped key")
+	}
+	return key, nil
+}
+
+func (w *fakeWrapper) RewrapDataKey(ctx context.Context, wrapped []byte, kmsKeyId string) ([]byte, error) {
+	key, err := w.UnwrapDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(kmsKeyId+":"), key...), nil
+}
+
+// fakeDynamo serves GetItem, PutItem and UpdateItem from memory, supporting the condition
+// expressions used by the key ring and Reencrypt
+type fakeDynamo struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+}
+
+type fakeRequest struct {
+	Key                       map[string]attributeJSON
+	Item                      map[string]attributeJSON
+	ConditionExpression       string
+	UpdateExpression          string
+	ExpressionAttributeNames  map[string]string
+	ExpressionAttributeValues map[string]attributeJSON
+}
+
+func newFakeDynamo(t *testing.T) (*fakeDynamo, *dynamodb.Client) {
+	f := &fakeDynamo{items: make(map[string]map[string]types.AttributeValue)}
+
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "af-south-1",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+
+	return f, client
+}
+
+func (f *fakeDynamo) serve(w http.ResponseWriter, r *http.Request) {
+	var req fakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key, _ := decodeItem(req.Key)
+	item, _ := decodeItem(req.Item)
+	values, _ := decodeItem(req.ExpressionAttributeValues)
+
+	if len(req.Item) > 0 {
+		key = map[string]types.AttributeValue{"id": item["id"]}
+	}
+	id := key["id"].(*types.AttributeValueMemberS).Value
+	stored := f.items[id]
+
+	if !f.conditionMet(stored, req, values) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+		return
+	}
+
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+	case "GetItem":
+		encoded, _ := encodeItem(stored)
+		if stored == nil {
+			encoded = nil
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Item": encoded})
+		return
+	case "PutItem":
+		f.items[id] = item
+	case "UpdateItem":
+		// Only "SET #v = :v" is supported
+		updated := map[string]types.AttributeValue{}
+		for name, value := range stored {
+			updated[name] = value
+		}
+		updated[req.ExpressionAttributeNames["#v"]] = values[":v"]
+		f.items[id] = updated
+	}
+
+	w.Write([]byte(`{}`))
+}
+
+func (f *fakeDynamo) conditionMet(stored map[string]types.AttributeValue, req fakeRequest, values map[string]types.AttributeValue) bool {
+	condition := req.ConditionExpression
+	if condition == "" {
+		return true
+	}
+
+	if name, ok := strings.CutPrefix(condition, "attribute_not_exists("); ok {
+		_, exists := stored[req.ExpressionAttributeNames[strings.TrimSuffix(name, ")")]]
+		return !exists
+	}
+
+	name, value, _ := strings.Cut(condition, " = ")
+	return reflect.DeepEqual(stored[req.ExpressionAttributeNames[name]], values[value])
+}
+
+func (f *fakeDynamo) value(id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	attr, _ := f.items[id]["value"].(*types.AttributeValueMemberS)
+	if attr == nil {
+		return ""
+	}
+	return attr.Value
+}
+
+func (f *fakeDynamo) ring(t *testing.T) keyRing {
+	var ring keyRing
+	if err := json.Unmarshal([]byte(f.value("__data_keys__")), &ring); err != nil {
+		t.Fatalf("failed to parse the stored key ring: %v", err)
+	}
+	return ring
+}
+
+func newEncryptedTable(client *dynamodb.Client, wrapper KeyWrapper) *DynamoDB {
+	return &DynamoDB{
+		client:                client,
+		tableName:             "secrets",
+		partitionKeyAttribute: "id",
+		valueAttribute:        "value",
+		encryption:            newTableEncryption(EncryptionOptions{KmsKeyId: "key-1", Wrapper: wrapper}),
+	}
+}
+
+func TestKeyRing(t *testing.T) {
+	ctx := context.Background()
+	_, client := newFakeDynamo(t)
+	wrapper := &fakeWrapper{}
+
+	first := newEncryptedTable(client, wrapper)
+	second := newEncryptedTable(client, wrapper)
+
+	sealed, err := first.encryptValue(ctx, "a", "secret")
+	if err != nil {
+		t.Fatalf("encryptValue() error = %v", err)
+	}
+	if version, _ := sealedValueVersion(sealed); version != 1 {
+		t.Errorf("first value sealed with version %d, want 1", version)
+	}
+
+	// The second instance uses the key ring created by the first
+	if opened, err := second.decryptValue(ctx, "a", sealed); err != nil || opened != "secret" {
+		t.Fatalf("decryptValue() = %q, %v", opened, err)
+	}
+
+	if err := second.RotateDataKey(ctx); err != nil {
+		t.Fatalf("RotateDataKey() error = %v", err)
+	}
+
+	rotated, err := second.encryptValue(ctx, "b", "rotated")
+	if err != nil {
+		t.Fatalf("encryptValue() error = %v", err)
+	}
+	if version, _ := sealedValueVersion(rotated); version != 2 {
+		t.Errorf("value sealed after rotation with version %d, want 2", version)
+	}
+
+	// The first instance reloads its ring when it sees the unknown version
+	if opened, err := first.decryptValue(ctx, "b", rotated); err != nil || opened != "rotated" {
+		t.Fatalf("decryptValue() with a rotated key = %q, %v", opened, err)
+	}
+
+	sealed, _ = first.encryptValue(ctx, "c", "value")
+	if version, _ := sealedValueVersion(sealed); version != 2 {
+		t.Errorf("value sealed after reload with version %d, want 2", version)
+	}
+
+	if err := first.RotateDataKey(ctx); err != nil {
+		t.Fatalf("RotateDataKey() error = %v", err)
+	}
+
+	// The second instance picks up the rotation once its ring is stale
+	sealed, _ = second.encryptValue(ctx, "d", "value")
+	if version, _ := sealedValueVersion(sealed); version != 2 {
+		t.Errorf("value sealed within the ring TTL with version %d, want 2", version)
+	}
+
+	second.encryption.loaded = time.Now().Add(-time.Hour)
+
+	sealed, _ = second.encryptValue(ctx, "d", "value")
+	if version, _ := sealedValueVersion(sealed); version != 3 {
+		t.Errorf("value sealed after the ring TTL with version %d, want 3", version)
+	}
+}
+
+func TestRewrapDataKeys(t *testing.T) {
+	ctx := context.Background()
+	store, client := newFakeDynamo(t)
+	wrapper := &fakeWrapper{}
+	d := newEncryptedTable(client, wrapper)
+
+	if err := d.RewrapDataKeys(ctx, "key-2"); err == nil {
+		t.Error("RewrapDataKeys() without a key ring succeeded, want error")
+	}
+
+	v1, _ := d.encryptValue(ctx, "a", "one")
+	if err := d.RotateDataKey(ctx); err != nil {
+		t.Fatalf("RotateDataKey() error = %v", err)
+	}
+	v2, _ := d.encryptValue(ctx, "b", "two")
+
+	if err := d.RewrapDataKeys(ctx, "key-2"); err != nil {
+		t.Fatalf("RewrapDataKeys() error = %v", err)
+	}
+
+	ring := store.ring(t)
+	if ring.KmsKeyId != "key-2" || len(ring.Keys) != 2 {
+		t.Fatalf("stored key ring = %+v, want 2 keys wrapped by key-2", ring)
+	}
+	for version, encoded := range ring.Keys {
+		wrapped, _ := base64.StdEncoding.DecodeString(encoded)
+		if !bytes.HasPrefix(wrapped, []byte("key-2:")) {
+			t.Errorf("data key version %d wasn't rewrapped", version)
+		}
+	}
+
+	// Values stay readable with the rewrapped keys
+	fresh := newEncryptedTable(client, wrapper)
+	for aad, sealed := range map[string]string{"a": v1, "b": v2} {
+		if _, err := fresh.decryptValue(ctx, aad, sealed); err != nil {
+			t.Errorf("decryptValue(%q) after rewrap error = %v", aad, err)
+		}
+	}
+
+	if err := d.RotateDataKey(ctx); err != nil {
+		t.Fatalf("RotateDataKey() error = %v", err)
+	}
+	wrapped, _ := base64.StdEncoding.DecodeString(store.ring(t).Keys[3])
+	if !bytes.HasPrefix(wrapped, []byte("key-2:")) {
+		t.Error("data key rotated after rewrap wasn't wrapped by the new KMS key")
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx := context.Background()
+	store, client := newFakeDynamo(t)
+	d := newEncryptedTable(client, &fakeWrapper{})
+
+	old, err := d.encryptValue(ctx, d.itemAad("sealed", ""), "sealed value")
+	if err != nil {
+		t.Fatalf("encryptValue() error = %v", err)
+	}
+
+	store.items["sealed"] = map[string]types.AttributeValue{
+		"id":    &types.AttributeValueMemberS{Value: "sealed"},
+		"value": &types.AttributeValueMemberS{Value: old},
+	}
+	store.items["plain"] = map[string]types.AttributeValue{
+		"id":    &types.AttributeValueMemberS{Value: "plain"},
+		"value": &types.AttributeValueMemberS{Value: "plain value"},
+	}
+
+	if err := d.RotateDataKey(ctx); err != nil {
+		t.Fatalf("RotateDataKey() error = %v", err)
+	}
+
+	for key, want := range map[string]string{"sealed": "sealed value", "plain": "plain value"} {
+		if err := d.Reencrypt(ctx, key); err != nil {
+			t.Fatalf("Reencrypt(%q) error = %v", key, err)
+		}
+
+		value := store.value(key)
+		if version, _ := sealedValueVersion(value); !strings.HasPrefix(value, encryptedValuePrefix) || version != 2 {
+			t.Errorf("Reencrypt(%q) stored %q, want a value sealed with version 2", key, value)
+		}
+
+		if opened, err := d.decryptValue(ctx, d.itemAad(key, ""), value); err != nil || opened != want {
+			t.Errorf("reencrypted %q = %q, %v, want %q", key, opened, err, want)
+		}
+	}
+
+	if err := d.Reencrypt(ctx, "missing"); err != nil {
+		t.Errorf("Reencrypt() of a missing item error = %v", err)
+	}
+}
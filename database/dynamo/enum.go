@@ -0,0 +1,28 @@
+package dynamo
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// UnmarshalEnum decodes a string attribute into target, validating it against e. Enum types
+// stored in attribute mode call it from UnmarshalDynamoDBAttributeValue; they are marshalled
+// as plain strings without any extra methods.
+//
+// Example:
+//
+//	func (c *Color) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+//	    return dynamo.UnmarshalEnum(Colors, av, c)
+//	}
+func UnmarshalEnum[T ~string](e *utils.Enum[T], av types.AttributeValue, target *T) error {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberS:
+		return e.DecodeText([]byte(v.Value), target)
+	default:
+		return fmt.Errorf("%w: expected a string attribute, got %T", utils.ErrInvalidEnum, av)
+	}
+}
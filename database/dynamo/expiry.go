@@ -0,0 +1,108 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/metrics"
+)
+
+// MetricExpiredPurged counts expired items deleted on read by DeleteExpiredOnRead, by table
+// and status: deleted, skipped (renewed or already gone) or failed
+const MetricExpiredPurged = "dynamo_expired_purged_total"
+
+// maxPurges bounds the deletes of expired items in flight per table; expired items found
+// while it is reached are left for DynamoDB's TTL
+const maxPurges = 16
+
+// expiredPurger deletes expired items found by reads in the background
+type expiredPurger struct {
+	table    *DynamoDB
+	metrics  metrics.Collector
+	slots    chan struct{}
+	inFlight sync.Map // map[KeyPair]struct{}
+}
+
+func newExpiredPurger(table *DynamoDB, collector metrics.Collector) *expiredPurger {
+	if collector != nil {
+		err := collector.RegisterCustomMetrics(metrics.CustomMetric{
+			Name: MetricExpiredPurged, Description: "Expired dynamo items deleted on read", Type: metrics.Counter, Labels: []string{"table", "status"},
+		})
+		if err != nil {
+			log.Warningf("Failed to register expiry metrics for %s: %v", table.tableName, err)
+		}
+	}
+
+	return &expiredPurger{
+		table:   table,
+		metrics: collector,
+		slots:   make(chan struct{}, maxPurges),
+	}
+}
+
+// purgeExpired deletes an expired item read from the table in the background, if
+// DeleteExpiredOnRead is set. The delete only applies while the item still has the expiry
+// it was read with, so an item renewed in the meantime is kept.
+func (d *DynamoDB) purgeExpired(item map[string]types.AttributeValue) {
+	p := d.purger
+	if p == nil || item[d.ttlAttribute] == nil {
+		return
+	}
+
+	key := d.keyOf(item)
+
+	if _, busy := p.inFlight.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		p.inFlight.Delete(key)
+		return
+	}
+
+	go func() {
+		defer func() {
+			<-p.slots
+			p.inFlight.Delete(key)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		status := "deleted"
+
+		result, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:                 aws.String(d.tableName),
+			Key:                       d.itemKey(key.Key, key.SortKey),
+			ConditionExpression:       aws.String("#ttl = :ttl"),
+			ExpressionAttributeNames:  map[string]string{"#ttl": d.ttlAttribute},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":ttl": item[d.ttlAttribute]},
+			ReturnValues:              types.ReturnValueAllOld,
+		})
+		d.invalidate(key)
+
+		var conditionFailed *types.ConditionalCheckFailedException
+
+		switch {
+		case errors.As(err, &conditionFailed):
+			status = "skipped"
+		case err != nil:
+			status = "failed"
+			log.Warningf("Failed to delete expired item %s from %s: %v", key.Key, d.tableName, err)
+		default:
+			d.removeOverflow(ctx, result.Attributes, nil)
+		}
+
+		if p.metrics != nil {
+			p.metrics.IncrementCounter(ctx, MetricExpiredPurged, map[string]string{"table": d.tableName, "status": status}, 1)
+		}
+	}()
+}
@@ -0,0 +1,269 @@
+package dynamo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchWriteItems is the most items DynamoDB accepts in one BatchWriteItem request
+const maxBatchWriteItems = 25
+
+// Export writes every item in the table to w as one JSON object per line and returns the
+// number of items written. Items are written in DynamoDB JSON, the format of the AWS CLI,
+// e.g. {"id":{"S":"user123"},"count":{"N":"7"}}, so numbers keep their precision and binary
+// values stay binary. Items are exported as stored, so encrypted values stay encrypted and
+// can only be read back in a table with the same name and data keys.
+//
+// Example:
+//
+//	file, _ := os.Create("sessions.jsonl")
+//	count, err := db.Export(ctx, file)
+func (d *DynamoDB) Export(ctx context.Context, w io.Writer) (int, error) {
+	encoder := json.NewEncoder(w)
+	count := 0
+
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName: aws.String(d.tableName),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return count, fmt.Errorf("failed to scan dynamodb table %s: %w", d.tableName, err)
+		}
+
+		for _, item := range page.Items {
+			value, err := encodeItem(item)
+			if err != nil {
+				return count, err
+			}
+
+			if err := encoder.Encode(value); err != nil {
+				return count, fmt.Errorf("failed to write item: %w", err)
+			}
+
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Import writes items read from r, in the format produced by Export, to the table and
+// returns the number of items written. Existing items with the same keys are replaced.
+func (d *DynamoDB) Import(ctx context.Context, r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	batch := make([]types.WriteRequest, 0, maxBatchWriteItems)
+	count := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := d.batchWrite(ctx, batch); err != nil {
+			return err
+		}
+
+		count += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var value map[string]attributeJSON
+		if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+			return count, fmt.Errorf("failed to parse item on line %d: %w", line, err)
+		}
+
+		item, err := decodeItem(value)
+		if err != nil {
+			return count, fmt.Errorf("failed to decode item on line %d: %w", line, err)
+		}
+
+		batch = append(batch, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+
+		if len(batch) == maxBatchWriteItems {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("failed to read items: %w", err)
+	}
+
+	return count, flush()
+}
+
+// batchWrite writes requests with BatchWriteItem, retrying unprocessed items with backoff
+func (d *DynamoDB) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	pending := map[string][]types.WriteRequest{d.tableName: requests}
+	backoff := 100 * time.Millisecond
+
+	for attempt := 0; len(pending[d.tableName]) > 0; attempt++ {
+		if attempt > 0 {
+			if attempt > 8 {
+				return fmt.Errorf("failed to write %d items to dynamodb: retries exhausted", len(pending[d.tableName]))
+			}
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			backoff *= 2
+		}
+
+		result, err := d.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write items to dynamodb: %w", err)
+		}
+
+		pending = result.UnprocessedItems
+	}
+
+	return nil
+}
+
+// attributeJSON is an attribute value in DynamoDB JSON, with exactly one field set
+type attributeJSON struct {
+	S    *string                  `json:"S"`
+	N    *string                  `json:"N"`
+	B    []byte                   `json:"B"`
+	BOOL *bool                    `json:"BOOL"`
+	NULL *bool                    `json:"NULL"`
+	SS   []string                 `json:"SS"`
+	NS   []string                 `json:"NS"`
+	BS   [][]byte                 `json:"BS"`
+	L    []attributeJSON          `json:"L"`
+	M    map[string]attributeJSON `json:"M"`
+}
+
+// encodeItem returns an item in DynamoDB JSON
+func encodeItem(item map[string]types.AttributeValue) (map[string]any, error) {
+	encoded := make(map[string]any, len(item))
+
+	for name, value := range item {
+		attribute, err := encodeAttribute(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode attribute %s: %w", name, err)
+		}
+		encoded[name] = attribute
+	}
+
+	return encoded, nil
+}
+
+func encodeAttribute(value types.AttributeValue) (map[string]any, error) {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]any{"S": v.Value}, nil
+	case *types.AttributeValueMemberN:
+		return map[string]any{"N": v.Value}, nil
+	case *types.AttributeValueMemberB:
+		return map[string]any{"B": v.Value}, nil
+	case *types.AttributeValueMemberBOOL:
+		return map[string]any{"BOOL": v.Value}, nil
+	case *types.AttributeValueMemberNULL:
+		return map[string]any{"NULL": v.Value}, nil
+	case *types.AttributeValueMemberSS:
+		return map[string]any{"SS": v.Value}, nil
+	case *types.AttributeValueMemberNS:
+		return map[string]any{"NS": v.Value}, nil
+	case *types.AttributeValueMemberBS:
+		return map[string]any{"BS": v.Value}, nil
+	case *types.AttributeValueMemberL:
+		list := make([]map[string]any, len(v.Value))
+		for i, element := range v.Value {
+			attribute, err := encodeAttribute(element)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = attribute
+		}
+		return map[string]any{"L": list}, nil
+	case *types.AttributeValueMemberM:
+		attributes, err := encodeItem(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"M": attributes}, nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute value %T", value)
+	}
+}
+
+// decodeItem returns the item of its DynamoDB JSON
+func decodeItem(item map[string]attributeJSON) (map[string]types.AttributeValue, error) {
+	decoded := make(map[string]types.AttributeValue, len(item))
+
+	for name, value := range item {
+		attribute, err := decodeAttribute(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode attribute %s: %w", name, err)
+		}
+		decoded[name] = attribute
+	}
+
+	return decoded, nil
+}
+
+func decodeAttribute(value attributeJSON) (types.AttributeValue, error) {
+	switch {
+	case value.S != nil:
+		return &types.AttributeValueMemberS{Value: *value.S}, nil
+	case value.N != nil:
+		return &types.AttributeValueMemberN{Value: *value.N}, nil
+	case value.B != nil:
+		return &types.AttributeValueMemberB{Value: value.B}, nil
+	case value.BOOL != nil:
+		return &types.AttributeValueMemberBOOL{Value: *value.BOOL}, nil
+	case value.NULL != nil:
+		return &types.AttributeValueMemberNULL{Value: *value.NULL}, nil
+	case value.SS != nil:
+		return &types.AttributeValueMemberSS{Value: value.SS}, nil
+	case value.NS != nil:
+		return &types.AttributeValueMemberNS{Value: value.NS}, nil
+	case value.BS != nil:
+		return &types.AttributeValueMemberBS{Value: value.BS}, nil
+	case value.L != nil:
+		list := make([]types.AttributeValue, len(value.L))
+		for i, element := range value.L {
+			attribute, err := decodeAttribute(element)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = attribute
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case value.M != nil:
+		attributes, err := decodeItem(value.M)
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: attributes}, nil
+	default:
+		return nil, errors.New("attribute value without a type")
+	}
+}
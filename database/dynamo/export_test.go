@@ -0,0 +1,98 @@
+package dynamo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestExportFormat(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(`{"name":"large value"}`))
+	writer.Close()
+
+	item := map[string]types.AttributeValue{
+		"id":      &types.AttributeValueMemberS{Value: "user123"},
+		"big":     &types.AttributeValueMemberN{Value: "12345678901234567891"},
+		"decimal": &types.AttributeValueMemberN{Value: "0.1000000000000000055511151231257827"},
+		"value":   &types.AttributeValueMemberB{Value: compressed.Bytes()},
+		"empty":   &types.AttributeValueMemberB{Value: []byte{}},
+		"active":  &types.AttributeValueMemberBOOL{Value: false},
+		"none":    &types.AttributeValueMemberNULL{Value: true},
+		"tags":    &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"scores":  &types.AttributeValueMemberNS{Value: []string{"1", "9007199254740993"}},
+		"keys":    &types.AttributeValueMemberBS{Value: [][]byte{{0, 1}, {2}}},
+		"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "x"},
+			&types.AttributeValueMemberN{Value: "9007199254740993"},
+		}},
+		"emptyList": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+		"map": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"nested": &types.AttributeValueMemberB{Value: []byte{0xff}},
+		}},
+	}
+
+	encoded, err := encodeItem(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed map[string]attributeJSON
+	if err := json.Unmarshal(line, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := decodeItem(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, item) {
+		t.Errorf("expected the item to round trip\nwant %#v\ngot  %#v", item, decoded)
+	}
+}
+
+func TestImportFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    map[string]types.AttributeValue
+		wantErr bool
+	}{
+		{
+			"aws cli format",
+			`{"id":{"S":"a"},"n":{"N":"1"}}`,
+			map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}, "n": &types.AttributeValueMemberN{Value: "1"}},
+			false,
+		},
+		{"untyped value", `{"id":{"S":"a"},"n":{}}`, nil, true},
+		{"untyped nested value", `{"id":{"S":"a"},"l":{"L":[{}]}}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parsed map[string]attributeJSON
+			if err := json.Unmarshal([]byte(tt.line), &parsed); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := decodeItem(parsed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeItem() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/naming"
 	"github.com/finch-technologies/go-utils/utils"
 )
 
@@ -83,6 +84,7 @@ func getGetOptions(options ...GetOptions) GetOptions {
 func getOptions(options ...DbOptions) DbOptions {
 	defaultOpts := DbOptions{
 		Region:                utils.StringOrDefault(os.Getenv("AWS_REGION"), "af-south-1"),
+		Endpoint:              os.Getenv("DYNAMODB_ENDPOINT"),
 		PartitionKeyAttribute: "id",
 		TtlAttribute:          "expiration_time",
 		SortKeyAttribute:      "",
@@ -98,6 +100,10 @@ func getOptions(options ...DbOptions) DbOptions {
 		utils.MergeObjects(&opts, defaultOpts)
 	}
 
+	if opts.TableName == "" && opts.Resource != "" {
+		opts.TableName = naming.Table(opts.Resource)
+	}
+
 	return opts
 }
 
@@ -149,7 +155,7 @@ func getSetOptions(options ...PutOptions) PutOptions {
 	return PutOptions{}
 }
 
-// ensureTableExists creates the DynamoDB table if it doesn't exist
+// ensureTableExists returns an error if the DynamoDB table doesn't exist
 func ensureTableExists(ctx context.Context, client *dynamodb.Client, tableName string) error {
 	// Check if table exists
 	_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
@@ -0,0 +1,37 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/finch-technologies/go-utils/naming"
+)
+
+func TestGetOptionsResolvesResource(t *testing.T) {
+	naming.Configure(naming.Options{Project: "shrike", Environment: "staging"})
+	defer naming.Configure(naming.Options{})
+
+	tests := []struct {
+		name     string
+		options  []DbOptions
+		expected string
+	}{
+		{"resource", []DbOptions{{Resource: "x"}}, naming.Table("x")},
+		{"table name wins", []DbOptions{{TableName: "sessions", Resource: "x"}}, "sessions"},
+		{"table name only", []DbOptions{{TableName: "sessions"}}, "sessions"},
+		{"no options", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := getOptions(tt.options...)
+
+			if opts.TableName != tt.expected {
+				t.Errorf("expected table %q, got %q", tt.expected, opts.TableName)
+			}
+		})
+	}
+
+	if naming.Table("x") != "shrike.staging.x" {
+		t.Errorf("expected shrike.staging.x, got %s", naming.Table("x"))
+	}
+}
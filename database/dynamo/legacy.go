@@ -0,0 +1,104 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LegacyDB exposes a table through the method set of the old SDK v1 database interface,
+// which redis.RedisDB also implements, so code written against that interface can move to
+// this package without changes. The table must use the JSON value store mode and, for
+// SetWithSortKey and GetListWithPrefix, have a sort key.
+//
+// Example:
+//
+//	table, err := dynamo.New(dynamo.DbOptions{TableName: "sessions", SortKeyAttribute: "sk"})
+//	db := dynamo.NewLegacy(table)
+//	err = db.SetWithSortKey("user123", "session#1", session, time.Hour)
+type LegacyDB struct {
+	table *DynamoDB
+}
+
+// NewLegacy wraps table in the legacy interface
+func NewLegacy(table *DynamoDB) *LegacyDB {
+	return &LegacyDB{table: table}
+}
+
+// Table returns the wrapped table, for callers moving to its methods
+func (l *LegacyDB) Table() *DynamoDB {
+	return l.table
+}
+
+// GetString returns the value of key, or an empty string if it doesn't exist or has expired
+func (l *LegacyDB) GetString(key string) (string, error) {
+	value, err := l.get(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get value from dynamo: %w", err)
+	}
+	return value, nil
+}
+
+// Get returns the value of key as bytes, or nil if it doesn't exist or has expired
+func (l *LegacyDB) Get(key string) ([]byte, error) {
+	value, err := l.get(key)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+func (l *LegacyDB) get(key string) (string, error) {
+	value, _, err := l.table.GetContext(context.Background(), key)
+	if err != nil || value == nil {
+		return "", err
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected value type %T for %s", value, key)
+	}
+
+	return str, nil
+}
+
+// Set writes value as key, expiring after expiration unless it is 0
+func (l *LegacyDB) Set(key string, value any, expiration time.Duration) error {
+	return l.table.Put(key, value, PutOptions{Ttl: expiration})
+}
+
+// SetWithSortKey writes value under the partition key pk and sort key sk, expiring after
+// expiration unless it is 0
+func (l *LegacyDB) SetWithSortKey(pk string, sk string, value any, expiration time.Duration) error {
+	return l.table.Put(pk, value, PutOptions{SortKey: sk, Ttl: expiration})
+}
+
+// Delete removes key
+func (l *LegacyDB) Delete(key string) error {
+	return l.table.Delete(key)
+}
+
+// GetListWithPrefix returns up to limit unexpired values with the partition key id whose
+// sort key starts with skPrefix, ordered by sort key
+func (l *LegacyDB) GetListWithPrefix(id string, skPrefix string, limit int64) ([]string, error) {
+	opts := QueryOptions{MaxItems: int(limit)}
+
+	if skPrefix != "" {
+		opts.SortKeyCondition = QueryConditionBeginsWith
+		opts.SortKeyValue = skPrefix
+	}
+
+	items, err := l.table.QueryAll(context.Background(), id, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list from dynamo: %w", err)
+	}
+
+	var result []string
+	for _, item := range items {
+		if value, ok := item.Value.(string); ok {
+			result = append(result, value)
+		}
+	}
+
+	return result, nil
+}
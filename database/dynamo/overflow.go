@@ -0,0 +1,102 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// defaultOverflowThreshold leaves room under the 400KB item limit for the keys and other
+// attributes of the item
+const defaultOverflowThreshold = 350 * 1024
+
+// OverflowStore stores values too large for a DynamoDB item. The Client of storage/s3
+// implements it.
+type OverflowStore interface {
+	PutObject(ctx context.Context, key string, data []byte, expiresAt time.Time) error
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// OverflowOptions configures large item mode: values above Threshold, after compression and
+// encryption, are stored in Store and the item only keeps the object key in Attribute.
+// Reads resolve the object transparently. Put and Delete remove the object of the value
+// they replace; objects replaced by batch and transactional writes are left behind, so
+// give the bucket a lifecycle rule for the prefix. Objects of items with a TTL are tagged
+// to expire with the item.
+type OverflowOptions struct {
+	Store     OverflowStore // Where oversized values are stored, e.g. an s3.Client (required)
+	Threshold int           // Stored value size in bytes above which values overflow (default 350KB)
+	KeyPrefix string        // Object key prefix (default "dynamo/<table name>")
+	Attribute string        // Attribute holding the object key (default "value_ref")
+}
+
+func newOverflow(opts OverflowOptions, tableName string) *OverflowOptions {
+	opts.Threshold = utils.IntOrDefault(opts.Threshold, defaultOverflowThreshold)
+	opts.KeyPrefix = utils.StringOrDefault(opts.KeyPrefix, "dynamo/"+tableName)
+	opts.Attribute = utils.StringOrDefault(opts.Attribute, "value_ref")
+
+	return &opts
+}
+
+// overflowValue stores value in the overflow store when it is above the threshold and
+// returns the attribute pointing at it, or nil if the value fits in the item
+func (d *DynamoDB) overflowValue(ctx context.Context, key, sortKey string, value []byte, opts PutOptions) (types.AttributeValue, error) {
+	if d.overflow == nil || len(value) <= d.overflow.Threshold {
+		return nil, nil
+	}
+
+	// Objects are addressed by content, so a value is never overwritten while a reader
+	// holding the previous pointer fetches it
+	objectKey := utils.ContentKey(d.overflow.KeyPrefix+"/"+utils.Hash(key+"/"+sortKey, 22), value, "")
+
+	var expiresAt time.Time
+	if ttl := utils.DurationOrDefault(opts.Ttl, d.ttl); ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if err := d.overflow.Store.PutObject(ctx, objectKey, value, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to store oversized value of %s: %w", key, err)
+	}
+
+	return &types.AttributeValueMemberS{Value: objectKey}, nil
+}
+
+// isOverflowed reports whether the value of item is in the overflow store
+func (d *DynamoDB) isOverflowed(item map[string]types.AttributeValue) bool {
+	return d.overflow != nil && attributeString(item[d.overflow.Attribute]) != ""
+}
+
+// loadOverflow reads the value of an overflowed item from the overflow store
+func (d *DynamoDB) loadOverflow(ctx context.Context, item map[string]types.AttributeValue) ([]byte, error) {
+	objectKey := attributeString(item[d.overflow.Attribute])
+
+	data, err := d.overflow.Store.GetObject(ctx, objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load oversized value %s: %w", objectKey, err)
+	}
+
+	return data, nil
+}
+
+// removeOverflow deletes the object of a replaced or deleted item unless the new item still
+// points at it. Failures only leave an orphaned object behind, so they are logged.
+func (d *DynamoDB) removeOverflow(ctx context.Context, old, current map[string]types.AttributeValue) {
+	if !d.isOverflowed(old) {
+		return
+	}
+
+	objectKey := attributeString(old[d.overflow.Attribute])
+
+	if current != nil && attributeString(current[d.overflow.Attribute]) == objectKey {
+		return
+	}
+
+	if err := d.overflow.Store.DeleteObject(ctx, objectKey); err != nil {
+		log.Warningf("Failed to delete oversized value %s: %v", objectKey, err)
+	}
+}
@@ -0,0 +1,347 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/encryption/kms"
+	"github.com/finch-technologies/go-utils/encryption/ssl"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/metrics"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// MetricRotatedValues counts items handled by RotateValues, by table and status
+// (rotated, skipped, conflict or failed)
+const MetricRotatedValues = "dynamo_rotated_values_total"
+
+// ValueCipher encrypts and decrypts values that applications store already encrypted
+type ValueCipher interface {
+	Encrypt(ctx context.Context, plaintext string) (string, error)
+	Decrypt(ctx context.Context, ciphertext string) (string, error)
+}
+
+// SslCipher encrypts values with the ssl package. Empty fields default to the
+// SSL_SECRET_KEY and SSL_IV env variables.
+type SslCipher struct {
+	SecretKey string
+	IV        string
+}
+
+func (c SslCipher) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	return ssl.Encrypt(plaintext, ssl.EncryptOptions{SecretKey: c.SecretKey, IV: c.IV})
+}
+
+func (c SslCipher) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	return ssl.Decrypt(ciphertext, ssl.DecryptOptions{SecretKey: c.SecretKey, IV: c.IV})
+}
+
+// KmsCipher encrypts values with the kms package. Decryption uses whichever key the value
+// was encrypted with, so KeyId only matters for encryption.
+type KmsCipher struct {
+	KeyId  string // Defaults to KMS_KEY_ID
+	Region string // Defaults to AWS_REGION
+}
+
+func (c KmsCipher) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	return kms.Encrypt(ctx, plaintext, kms.EncryptOptions{KeyId: c.KeyId, Region: c.Region})
+}
+
+func (c KmsCipher) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	return kms.Decrypt(ctx, ciphertext, c.Region)
+}
+
+// RotateOptions configures RotateValues
+type RotateOptions struct {
+	Attributes       []string                      // String attributes holding encrypted values (default the value attribute)
+	Concurrency      int                           // Items rotated in parallel (default 4)
+	DryRun           bool                          // Decrypt and re-encrypt without writing, to check the keys first
+	ProgressInterval time.Duration                 // How often progress is reported (default 5s)
+	OnProgress       func(progress RotateProgress) // Progress callback, defaults to logging
+	Metrics          metrics.Collector             // Counts items by status as MetricRotatedValues (optional)
+}
+
+// RotateProgress counts the items handled by RotateValues
+type RotateProgress struct {
+	Scanned   int           // Items read from the table
+	Rotated   int           // Items re-encrypted with the new key (or that would be, in a dry run)
+	Skipped   int           // Items already encrypted with the new key, or without values to rotate
+	Conflicts int           // Items changed since they were read; run the rotation again to pick them up
+	Failed    int           // Items whose values couldn't be decrypted with either key, or not written
+	Elapsed   time.Duration // Time since the rotation started
+}
+
+type rotateStatus string
+
+const (
+	rotateStatusRotated  rotateStatus = "rotated"
+	rotateStatusSkipped  rotateStatus = "skipped"
+	rotateStatusConflict rotateStatus = "conflict"
+	rotateStatusFailed   rotateStatus = "failed"
+)
+
+func getRotateOptions(options ...RotateOptions) RotateOptions {
+	opts := RotateOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Concurrency = utils.IntOrDefault(opts.Concurrency, 4)
+	opts.ProgressInterval = utils.DurationOrDefault(opts.ProgressInterval, 5*time.Second)
+
+	if opts.OnProgress == nil {
+		opts.OnProgress = func(progress RotateProgress) {
+			log.Infof("Rotating values: scanned=%d rotated=%d skipped=%d conflicts=%d failed=%d elapsed=%s",
+				progress.Scanned, progress.Rotated, progress.Skipped, progress.Conflicts, progress.Failed, progress.Elapsed)
+		}
+	}
+
+	return opts
+}
+
+// RotateValues scans the table and re-encrypts values encrypted with from using to. Each
+// item is written back only if its values haven't changed since the scan, so the rotation
+// can run while the table is in use, and items already encrypted with to are skipped, so an
+// interrupted rotation can simply be run again. Values that don't decrypt to valid UTF-8
+// are treated as not encrypted with the key, since decrypting with the wrong ssl key
+// usually succeeds with garbage.
+//
+// Tables with EncryptionOptions rotate their data keys with RotateDataKey and Reencrypt
+// instead.
+//
+// Example:
+//
+//	progress, err := db.RotateValues(ctx,
+//	    dynamo.SslCipher{SecretKey: oldKey, IV: oldIV},
+//	    dynamo.KmsCipher{KeyId: "alias/credentials"},
+//	    dynamo.RotateOptions{Attributes: []string{"password"}, DryRun: true},
+//	)
+func (d *DynamoDB) RotateValues(ctx context.Context, from, to ValueCipher, options ...RotateOptions) (RotateProgress, error) {
+	opts := getRotateOptions(options...)
+	progress := RotateProgress{}
+
+	if d.encryption != nil {
+		return progress, fmt.Errorf("table %s uses envelope encryption, use RotateDataKey and Reencrypt", d.tableName)
+	}
+
+	if len(opts.Attributes) == 0 {
+		opts.Attributes = []string{d.valueAttribute}
+	}
+
+	if opts.Metrics != nil {
+		err := opts.Metrics.RegisterCustomMetrics(metrics.CustomMetric{
+			Name: MetricRotatedValues, Description: "Items handled by dynamo value rotation", Type: metrics.Counter, Labels: []string{"table", "status"},
+		})
+		if err != nil {
+			log.Warningf("Failed to register rotation metrics for %s: %v", d.tableName, err)
+		}
+	}
+
+	start := time.Now()
+	lastReport := start
+
+	var mu sync.Mutex
+
+	report := func() {
+		mu.Lock()
+		progress.Elapsed = time.Since(start)
+		current := progress
+		mu.Unlock()
+
+		opts.OnProgress(current)
+	}
+
+	items := make(chan map[string]types.AttributeValue)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for item := range items {
+				status := d.rotateItem(ctx, item, from, to, opts)
+
+				mu.Lock()
+				switch status {
+				case rotateStatusRotated:
+					progress.Rotated++
+				case rotateStatusSkipped:
+					progress.Skipped++
+				case rotateStatusConflict:
+					progress.Conflicts++
+				default:
+					progress.Failed++
+				}
+				mu.Unlock()
+
+				if opts.Metrics != nil {
+					opts.Metrics.IncrementCounter(ctx, MetricRotatedValues, map[string]string{"table": d.tableName, "status": string(status)}, 1)
+				}
+			}
+		}()
+	}
+
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName: aws.String(d.tableName),
+	})
+
+	var err error
+
+scan:
+	for paginator.HasMorePages() {
+		var page *dynamodb.ScanOutput
+
+		page, err = paginator.NextPage(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed to scan dynamodb table %s: %w", d.tableName, err)
+			break
+		}
+
+		for _, item := range page.Items {
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				err = ctx.Err()
+				break scan
+			}
+
+			mu.Lock()
+			progress.Scanned++
+			mu.Unlock()
+		}
+
+		if time.Since(lastReport) >= opts.ProgressInterval {
+			lastReport = time.Now()
+			report()
+		}
+	}
+
+	close(items)
+	wg.Wait()
+
+	report()
+
+	return progress, err
+}
+
+// rotateItem re-encrypts the rotated attributes of one item
+func (d *DynamoDB) rotateItem(ctx context.Context, item map[string]types.AttributeValue, from, to ValueCipher, opts RotateOptions) rotateStatus {
+	key := d.itemKey(attributeString(item[d.partitionKeyAttribute]), attributeString(item[d.sortKeyAttribute]))
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	var sets, conditions []string
+
+	for i, attribute := range opts.Attributes {
+		current, ok := item[attribute].(*types.AttributeValueMemberS)
+		if !ok || current.Value == "" {
+			continue
+		}
+
+		// Skip values already rotated, e.g. by an earlier run that was interrupted
+		if _, err := decryptString(ctx, to, current.Value); err == nil {
+			continue
+		}
+
+		plaintext, err := decryptString(ctx, from, current.Value)
+		if err != nil {
+			log.Errorf("Failed to decrypt %s of item %v in %s: %v", attribute, key, d.tableName, err)
+			return rotateStatusFailed
+		}
+
+		rotated, err := to.Encrypt(ctx, plaintext)
+		if err != nil {
+			log.Errorf("Failed to encrypt %s of item %v in %s: %v", attribute, key, d.tableName, err)
+			return rotateStatusFailed
+		}
+
+		// Check the new value opens before replacing the only copy of the old one
+		if check, err := to.Decrypt(ctx, rotated); err != nil || check != plaintext {
+			log.Errorf("Re-encrypted %s of item %v in %s doesn't decrypt with the new key", attribute, key, d.tableName)
+			return rotateStatusFailed
+		}
+
+		name := fmt.Sprintf("#r%d", i)
+		names[name] = attribute
+		values[fmt.Sprintf(":new%d", i)] = &types.AttributeValueMemberS{Value: rotated}
+		values[fmt.Sprintf(":old%d", i)] = current
+
+		sets = append(sets, fmt.Sprintf("%s = :new%d", name, i))
+		conditions = append(conditions, fmt.Sprintf("%s = :old%d", name, i))
+	}
+
+	if len(sets) == 0 {
+		return rotateStatusSkipped
+	}
+
+	if opts.DryRun {
+		return rotateStatusRotated
+	}
+
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.tableName),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ")),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	d.invalidate(d.keyOf(item))
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return rotateStatusConflict
+	}
+
+	if err != nil {
+		log.Errorf("Failed to write rotated item %v in %s: %v", key, d.tableName, err)
+		return rotateStatusFailed
+	}
+
+	return rotateStatusRotated
+}
+
+// decryptString decrypts value, rejecting results that aren't valid UTF-8
+func decryptString(ctx context.Context, cipher ValueCipher, value string) (string, error) {
+	plaintext, err := cipher.Decrypt(ctx, value)
+	if err != nil {
+		return "", err
+	}
+
+	if !utf8.ValidString(plaintext) {
+		return "", fmt.Errorf("decrypted value is not valid UTF-8")
+	}
+
+	return plaintext, nil
+}
+
+// attributeString returns the value of a string attribute, or an empty string
+func attributeString(value types.AttributeValue) string {
+	if s, ok := value.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+// RotateValues re-encrypts the values of the table registered as tableName, as
+// DynamoDB.RotateValues does
+func RotateValues(ctx context.Context, tableName string, from, to ValueCipher, options ...RotateOptions) (RotateProgress, error) {
+	table, err := getTable(tableName)
+	if err != nil {
+		return RotateProgress{}, err
+	}
+
+	return table.RotateValues(ctx, from, to, options...)
+}
@@ -0,0 +1,61 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// prefixCipher "encrypts" values by prefixing them. With lenient set it also "decrypts"
+// values it didn't encrypt, like a CBC key that happens to produce valid padding.
+type prefixCipher struct {
+	prefix  string
+	lenient bool
+}
+
+func (c prefixCipher) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	return c.prefix + plaintext, nil
+}
+
+func (c prefixCipher) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	if plaintext, ok := strings.CutPrefix(ciphertext, c.prefix); ok {
+		return plaintext, nil
+	}
+	if c.lenient {
+		return ciphertext, nil
+	}
+	return "", errors.New("wrong key")
+}
+
+func TestRotateItem(t *testing.T) {
+	d := &DynamoDB{tableName: "secrets", partitionKeyAttribute: "id", valueAttribute: "value"}
+	from := prefixCipher{prefix: "old:", lenient: true}
+	to := prefixCipher{prefix: "new:"}
+
+	tests := []struct {
+		name  string
+		value string
+		want  rotateStatus
+	}{
+		{"old value", "old:secret", rotateStatusRotated},
+		{"rotated value the old key also opens", "new:secret", rotateStatusSkipped},
+		{"empty value", "", rotateStatusSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := map[string]types.AttributeValue{
+				"id":    &types.AttributeValueMemberS{Value: "a"},
+				"value": &types.AttributeValueMemberS{Value: tt.value},
+			}
+
+			got := d.rotateItem(context.Background(), item, from, to, RotateOptions{Attributes: []string{"value"}, DryRun: true})
+			if got != tt.want {
+				t.Errorf("rotateItem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
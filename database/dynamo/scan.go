@@ -0,0 +1,240 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/query"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// errScanDone stops a scan once MaxItems have been collected
+var errScanDone = errors.New("scan done")
+
+// ScanOptions configures a scan of a whole table
+type ScanOptions struct {
+	Result         any        // Pointer to struct where the results will be unmarshaled (attribute mode)
+	Where          query.Expr // Filters items, as a filter expression or in JSON value store mode on the decoded values
+	Segments       int        // Segments scanned in parallel (default 1)
+	Limit          int        // Items read per request (0 = DynamoDB's 1MB pages)
+	MaxItems       int        // Most items Scan returns (0 = all)
+	ConsistentRead bool       // Read items written just before the scan started
+}
+
+func getScanOptions(options ...ScanOptions) ScanOptions {
+	opts := ScanOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Segments = utils.IntOrDefault(opts.Segments, 1)
+
+	return opts
+}
+
+// Scan reads every item in the table, skipping expired items. Segments splits the table
+// into parts read in parallel, which speeds up scans of large tables at the cost of read
+// capacity; items are then returned in no particular order.
+//
+// Example:
+//
+//	items, err := db.Scan(ctx, dynamo.ScanOptions{
+//	    Where:    query.Field("status").Eq("pending"),
+//	    Segments: 4,
+//	})
+func (d *DynamoDB) Scan(ctx context.Context, options ...ScanOptions) ([]QueryResult[any], error) {
+	opts := getScanOptions(options...)
+
+	var mu sync.Mutex
+	var all []QueryResult[any]
+
+	err := d.ScanEach(ctx, func(item QueryResult[any]) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if opts.MaxItems > 0 && len(all) >= opts.MaxItems {
+			return errScanDone
+		}
+
+		all = append(all, item)
+
+		return nil
+	}, opts)
+
+	if err != nil && !errors.Is(err, errScanDone) {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// ScanEach calls handle for every item in the table, without holding the whole table in
+// memory. With more than one segment handle is called concurrently. The scan stops at the
+// first error handle returns, and that error is returned.
+//
+// Example:
+//
+//	err := db.ScanEach(ctx, func(item dynamo.QueryResult[any]) error {
+//	    return migrate(ctx, item.Key, item.SortKey, item.Value)
+//	}, dynamo.ScanOptions{Segments: 8})
+func (d *DynamoDB) ScanEach(ctx context.Context, handle func(item QueryResult[any]) error, options ...ScanOptions) error {
+	opts := getScanOptions(options...)
+
+	expressionAttributeNames := map[string]string{}
+	expressionAttributeValues := map[string]types.AttributeValue{}
+
+	var filterExpression *string
+	var localFilter query.Expr
+
+	if opts.Where != nil {
+		if d.valueStoreMode == ValueStoreModeJson {
+			// Filter expressions can't see into JSON values, so they are filtered once decoded
+			localFilter = opts.Where
+		} else {
+			builder := &expressionBuilder{names: expressionAttributeNames, values: expressionAttributeValues}
+
+			filter, err := builder.build(opts.Where)
+			if err != nil {
+				return err
+			}
+
+			if filter != "" {
+				filterExpression = aws.String(filter)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for segment := 0; segment < opts.Segments; segment++ {
+		input := &dynamodb.ScanInput{
+			TableName:                 aws.String(d.tableName),
+			FilterExpression:          filterExpression,
+			ExpressionAttributeNames:  nilIfEmpty(expressionAttributeNames),
+			ExpressionAttributeValues: nilIfEmpty(expressionAttributeValues),
+			ConsistentRead:            aws.Bool(opts.ConsistentRead),
+		}
+
+		if opts.Segments > 1 {
+			input.Segment = aws.Int32(int32(segment))
+			input.TotalSegments = aws.Int32(int32(opts.Segments))
+		}
+
+		if opts.Limit > 0 {
+			input.Limit = aws.Int32(int32(opts.Limit))
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := d.scanSegment(ctx, input, localFilter, opts.Result, handle); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// scanSegment reads the pages of one scan segment
+func (d *DynamoDB) scanSegment(ctx context.Context, input *dynamodb.ScanInput, localFilter query.Expr, result any, handle func(item QueryResult[any]) error) error {
+	paginator := dynamodb.NewScanPaginator(d.client, input)
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to scan dynamodb table %s: %w", d.tableName, err)
+		}
+
+		now := time.Now().Unix()
+
+		for _, item := range page.Items {
+			if d.encryption != nil && attributeString(item[d.partitionKeyAttribute]) == d.encryption.opts.KeyItem {
+				continue
+			}
+
+			value, ok := d.decodeItem(ctx, item, now, localFilter, result)
+			if !ok {
+				continue
+			}
+
+			if err := handle(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Scan reads every item of the table registered as tableName as T
+//
+// Example:
+//
+//	users, err := dynamo.Scan[User](ctx, "users", dynamo.ScanOptions{Segments: 4})
+func Scan[T any](ctx context.Context, tableName string, options ...ScanOptions) ([]QueryResult[T], error) {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := getScanOptions(options...)
+
+	var value T
+
+	opts.Result = value
+
+	items, err := table.Scan(ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return typedResults[T](table, items), nil
+}
+
+// ScanEach calls handle with every item of the table registered as tableName as T
+func ScanEach[T any](ctx context.Context, tableName string, handle func(item QueryResult[T]) error, options ...ScanOptions) error {
+	table, err := getTable(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	opts := getScanOptions(options...)
+
+	var value T
+
+	opts.Result = value
+
+	return table.ScanEach(ctx, func(item QueryResult[any]) error {
+		typed := typedResults[T](table, []QueryResult[any]{item})
+		if len(typed) == 0 {
+			return nil
+		}
+		return handle(typed[0])
+	}, opts)
+}
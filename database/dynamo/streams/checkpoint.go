@@ -0,0 +1,78 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+)
+
+// checkpointTtl outlives the 24 hour retention of stream records, after which a shard's
+// checkpoint is of no use
+const checkpointTtl = 48 * time.Hour
+
+// Checkpointer stores the sequence number of the last record processed in each shard, so a
+// restarted consumer continues where it stopped
+type Checkpointer interface {
+	// Load returns the checkpoint of a shard, or an empty string if there is none
+	Load(ctx context.Context, shardId string) (string, error)
+	// Save stores the checkpoint of a shard
+	Save(ctx context.Context, shardId, sequenceNumber string) error
+}
+
+// MemoryCheckpointer keeps checkpoints in memory, for consumers that only need to resume
+// within a process
+type MemoryCheckpointer struct {
+	checkpoints sync.Map // map[string]string
+}
+
+func (m *MemoryCheckpointer) Load(ctx context.Context, shardId string) (string, error) {
+	if value, ok := m.checkpoints.Load(shardId); ok {
+		return value.(string), nil
+	}
+	return "", nil
+}
+
+func (m *MemoryCheckpointer) Save(ctx context.Context, shardId, sequenceNumber string) error {
+	m.checkpoints.Store(shardId, sequenceNumber)
+	return nil
+}
+
+// TableCheckpointer stores checkpoints in a dynamo table in JSON value store mode, under
+// "<Consumer>#<shard id>". Checkpoints expire two days after their last update.
+//
+// Example:
+//
+//	checkpoints, err := dynamo.New(dynamo.DbOptions{TableName: "stream-checkpoints"})
+//	consumer, err := streams.NewConsumer(handle, streams.Options{
+//	    TableName:    "orders",
+//	    Checkpointer: &streams.TableCheckpointer{Table: checkpoints, Consumer: "billing"},
+//	})
+type TableCheckpointer struct {
+	Table    *dynamo.DynamoDB
+	Consumer string // Name of the consumer, so several consumers of a stream can share the table
+}
+
+func (t *TableCheckpointer) key(shardId string) string {
+	return t.Consumer + "#" + shardId
+}
+
+func (t *TableCheckpointer) Load(ctx context.Context, shardId string) (string, error) {
+	value, _, err := t.Table.GetContext(ctx, t.key(shardId))
+	if err != nil {
+		return "", fmt.Errorf("failed to load checkpoint of %s: %w", shardId, err)
+	}
+
+	sequenceNumber, _ := value.(string)
+
+	return sequenceNumber, nil
+}
+
+func (t *TableCheckpointer) Save(ctx context.Context, shardId, sequenceNumber string) error {
+	if err := t.Table.PutContext(ctx, t.key(shardId), sequenceNumber, dynamo.PutOptions{Ttl: checkpointTtl}); err != nil {
+		return fmt.Errorf("failed to save checkpoint of %s: %w", shardId, err)
+	}
+	return nil
+}
@@ -0,0 +1,445 @@
+// Package streams consumes the DynamoDB Stream of a table, delivering its writes to a
+// handler as typed events and checkpointing its position in each shard.
+package streams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ttlPrincipal is the principal of removes made by DynamoDB's TTL
+const ttlPrincipal = "dynamodb.amazonaws.com"
+
+// Consumer reads a table's stream and passes its records to a handler. Shards are read
+// concurrently, each after its parent, so the writes to an item are handled in order.
+// Delivery is at least once: events handled after the last checkpoint are delivered again
+// after a restart.
+type Consumer[T any] struct {
+	opts    Options
+	handler Handler[T]
+	client  *dynamodbstreams.Client
+
+	mu       sync.Mutex
+	started  map[string]bool // Shards being read or read to the end
+	finished map[string]bool // Shards read to the end
+}
+
+func getOptions(options ...Options) Options {
+	defaultOpts := Options{
+		Region:          utils.StringOrDefault(os.Getenv("AWS_REGION"), "af-south-1"),
+		Endpoint:        os.Getenv("DYNAMODB_ENDPOINT"),
+		StartPosition:   StartTrimHorizon,
+		BatchSize:       1000,
+		PollInterval:    time.Second,
+		RefreshInterval: 30 * time.Second,
+	}
+
+	opts := defaultOpts
+
+	if len(options) > 0 {
+		opts = options[0]
+		utils.MergeObjects(&opts, defaultOpts)
+	}
+
+	opts.BatchSize = min(opts.BatchSize, 1000)
+
+	if opts.Checkpointer == nil {
+		opts.Checkpointer = &MemoryCheckpointer{}
+	}
+
+	return opts
+}
+
+// NewConsumer creates a consumer passing the records of a table's stream to handler. The
+// stream must be enabled on the table; use the NEW_AND_OLD_IMAGES view type to get both
+// Old and New.
+//
+// Example:
+//
+//	consumer, err := streams.NewConsumer(func(ctx context.Context, event streams.Event[Order]) error {
+//	    if event.Type == streams.EventInsert {
+//	        return notify(ctx, *event.New)
+//	    }
+//	    return nil
+//	}, streams.Options{TableName: "orders", Table: ordersTable})
+//
+//	err = consumer.Run(ctx)
+func NewConsumer[T any](handler Handler[T], options ...Options) (*Consumer[T], error) {
+	opts := getOptions(options...)
+
+	if opts.TableName == "" && opts.StreamArn == "" {
+		return nil, errors.New("a table name or stream ARN is required")
+	}
+
+	if !StartPositions.Valid(opts.StartPosition) {
+		return nil, fmt.Errorf("invalid start position %q, expected one of %v", opts.StartPosition, StartPositions.Strings())
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(opts.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := dynamodbstreams.NewFromConfig(awsConfig, func(o *dynamodbstreams.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})
+
+	return &Consumer[T]{
+		opts:     opts,
+		handler:  handler,
+		client:   client,
+		started:  make(map[string]bool),
+		finished: make(map[string]bool),
+	}, nil
+}
+
+// Run reads the stream until ctx is cancelled, returning nil, or until the handler or a
+// stream read fails, returning the error
+func (c *Consumer[T]) Run(ctx context.Context) error {
+	if c.opts.StreamArn == "" {
+		arn, err := c.streamArn(ctx)
+		if err != nil {
+			return err
+		}
+		c.opts.StreamArn = arn
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+
+	initial := true
+
+	for {
+		shards, err := c.listShards(ctx)
+
+		switch {
+		case err != nil && initial:
+			return err
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Warningf("Failed to refresh the shards of %s: %v", c.opts.StreamArn, err)
+			}
+		default:
+			for _, shard := range c.ready(shards) {
+				wg.Add(1)
+
+				go func(shard streamtypes.Shard, initial bool) {
+					defer wg.Done()
+
+					if err := c.readShard(ctx, shard, initial); err != nil && ctx.Err() == nil {
+						cancel(err)
+					}
+				}(shard, initial)
+			}
+		}
+
+		initial = false
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+
+			if err := context.Cause(ctx); !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+
+			return nil
+		case <-time.After(c.opts.RefreshInterval):
+		}
+	}
+}
+
+// streamArn returns the latest stream of the table
+func (c *Consumer[T]) streamArn(ctx context.Context) (string, error) {
+	client, err := dynamo.GetClient(dynamo.ClientConfig{Region: c.opts.Region, Endpoint: c.opts.Endpoint})
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(c.opts.TableName)})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table %s: %w", c.opts.TableName, err)
+	}
+
+	if result.Table.LatestStreamArn == nil {
+		return "", fmt.Errorf("table %s has no stream enabled", c.opts.TableName)
+	}
+
+	return aws.ToString(result.Table.LatestStreamArn), nil
+}
+
+// listShards returns every shard of the stream
+func (c *Consumer[T]) listShards(ctx context.Context) ([]streamtypes.Shard, error) {
+	var shards []streamtypes.Shard
+	var startShardId *string
+
+	for {
+		result, err := c.client.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn:             aws.String(c.opts.StreamArn),
+			ExclusiveStartShardId: startShardId,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stream %s: %w", c.opts.StreamArn, err)
+		}
+
+		shards = append(shards, result.StreamDescription.Shards...)
+
+		startShardId = result.StreamDescription.LastEvaluatedShardId
+		if startShardId == nil {
+			return shards, nil
+		}
+	}
+}
+
+// ready marks and returns the shards that can be read: those not started whose parent has
+// been read to the end or has left the stream
+func (c *Consumer[T]) ready(shards []streamtypes.Shard) []streamtypes.Shard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listed := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		listed[aws.ToString(shard.ShardId)] = true
+	}
+
+	var ready []streamtypes.Shard
+
+	for _, shard := range shards {
+		shardId := aws.ToString(shard.ShardId)
+		parent := aws.ToString(shard.ParentShardId)
+
+		if c.started[shardId] {
+			continue
+		}
+
+		if parent != "" && listed[parent] && !c.finished[parent] {
+			continue
+		}
+
+		c.started[shardId] = true
+		ready = append(ready, shard)
+	}
+
+	// Shards leave the stream 24 hours after they close
+	for shardId := range c.finished {
+		if !listed[shardId] {
+			delete(c.finished, shardId)
+			delete(c.started, shardId)
+		}
+	}
+
+	return ready
+}
+
+// readShard handles the records of a shard until it is closed and read to the end
+func (c *Consumer[T]) readShard(ctx context.Context, shard streamtypes.Shard, initial bool) error {
+	shardId := aws.ToString(shard.ShardId)
+
+	iterator, err := c.iterator(ctx, shardId, initial)
+	if err != nil {
+		return err
+	}
+
+	for iterator != nil {
+		result, err := c.client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: iterator,
+			Limit:         aws.Int32(int32(c.opts.BatchSize)),
+		})
+
+		var expired *streamtypes.ExpiredIteratorException
+		if errors.As(err, &expired) {
+			if iterator, err = c.iterator(ctx, shardId, initial); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read shard %s: %w", shardId, err)
+		}
+
+		for _, record := range result.Records {
+			event := c.event(ctx, shardId, record)
+
+			if err := c.handler(ctx, event); err != nil {
+				return fmt.Errorf("failed to handle %s of %s: %w", event.SequenceNumber, shardId, err)
+			}
+		}
+
+		if len(result.Records) > 0 {
+			last := aws.ToString(result.Records[len(result.Records)-1].Dynamodb.SequenceNumber)
+
+			// A lost checkpoint only causes events to be delivered again
+			if err := c.opts.Checkpointer.Save(ctx, shardId, last); err != nil {
+				log.Warningf("Failed to checkpoint shard %s: %v", shardId, err)
+			}
+		}
+
+		iterator = result.NextShardIterator
+
+		if len(result.Records) == 0 && iterator != nil {
+			utils.Sleep(ctx, c.opts.PollInterval)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+
+	c.mu.Lock()
+	c.finished[shardId] = true
+	c.mu.Unlock()
+
+	return nil
+}
+
+// iterator returns an iterator after the checkpoint of a shard, or at the start position if
+// it has none. StartLatest only applies to the shards open when Run starts; shards that
+// open later are read from the start so no writes are missed.
+func (c *Consumer[T]) iterator(ctx context.Context, shardId string, initial bool) (*string, error) {
+	checkpoint, err := c.opts.Checkpointer.Load(ctx, shardId)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(c.opts.StreamArn),
+		ShardId:           aws.String(shardId),
+		ShardIteratorType: streamtypes.ShardIteratorTypeTrimHorizon,
+	}
+
+	switch {
+	case checkpoint != "":
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(checkpoint)
+	case initial && c.opts.StartPosition == StartLatest:
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeLatest
+	}
+
+	result, err := c.client.GetShardIterator(ctx, input)
+
+	var trimmed *streamtypes.TrimmedDataAccessException
+	if errors.As(err, &trimmed) {
+		log.Warningf("Checkpoint of shard %s is older than the stream, reading from the oldest record", shardId)
+
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeTrimHorizon
+		input.SequenceNumber = nil
+
+		result, err = c.client.GetShardIterator(ctx, input)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iterator of shard %s: %w", shardId, err)
+	}
+
+	return result.ShardIterator, nil
+}
+
+// event converts a stream record into an event
+func (c *Consumer[T]) event(ctx context.Context, shardId string, record streamtypes.Record) Event[T] {
+	event := Event[T]{
+		Type:    EventType(record.EventName),
+		ShardId: shardId,
+	}
+
+	if record.UserIdentity != nil {
+		event.Expired = aws.ToString(record.UserIdentity.PrincipalId) == ttlPrincipal
+	}
+
+	data := record.Dynamodb
+	if data == nil {
+		return event
+	}
+
+	event.SequenceNumber = aws.ToString(data.SequenceNumber)
+	event.Time = aws.ToTime(data.ApproximateCreationDateTime)
+
+	event.Keys, _ = attributevalue.FromDynamoDBStreamsMap(data.Keys)
+
+	if c.opts.Table != nil && event.Keys != nil {
+		if keys, err := c.opts.Table.DecodeItem(ctx, event.Keys); err == nil {
+			event.Key, event.SortKey = keys.Key, keys.SortKey
+		}
+	}
+
+	event.Old = c.image(ctx, data.OldImage, event)
+	event.New = c.image(ctx, data.NewImage, event)
+
+	return event
+}
+
+// image decodes an item image into T, returning nil if there is none or it can't be decoded
+func (c *Consumer[T]) image(ctx context.Context, image map[string]streamtypes.AttributeValue, event Event[T]) *T {
+	if len(image) == 0 {
+		return nil
+	}
+
+	item, err := attributevalue.FromDynamoDBStreamsMap(image)
+	if err == nil {
+		var value *T
+		if value, err = c.decode(ctx, item); err == nil {
+			return value
+		}
+	}
+
+	log.Warningf("Failed to decode %s image of %s in %s: %v", event.Type, event.SequenceNumber, event.ShardId, err)
+
+	return nil
+}
+
+func (c *Consumer[T]) decode(ctx context.Context, item map[string]types.AttributeValue) (*T, error) {
+	var value T
+
+	if c.opts.Table == nil {
+		if err := attributevalue.UnmarshalMap(item, &value); err != nil {
+			return nil, err
+		}
+		return &value, nil
+	}
+
+	result, err := c.opts.Table.DecodeItem(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	str, ok := result.Value.(string)
+	if !ok {
+		// Attribute mode tables decode to the item itself
+		if err := attributevalue.UnmarshalMap(item, &value); err != nil {
+			return nil, err
+		}
+		return &value, nil
+	}
+
+	if target := reflect.ValueOf(&value).Elem(); target.Kind() == reflect.String {
+		target.SetString(str)
+		return &value, nil
+	}
+
+	if err := json.Unmarshal([]byte(str), &value); err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
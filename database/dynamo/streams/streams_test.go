@@ -0,0 +1,108 @@
+package streams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+type order struct {
+	Id     string `dynamodbav:"id"`
+	Status string `dynamodbav:"status"`
+}
+
+func shard(id, parent string) streamtypes.Shard {
+	s := streamtypes.Shard{ShardId: aws.String(id)}
+	if parent != "" {
+		s.ParentShardId = aws.String(parent)
+	}
+	return s
+}
+
+func shardIds(shards []streamtypes.Shard) []string {
+	var ids []string
+	for _, s := range shards {
+		ids = append(ids, aws.ToString(s.ShardId))
+	}
+	return ids
+}
+
+func TestReady(t *testing.T) {
+	c := &Consumer[order]{started: map[string]bool{}, finished: map[string]bool{}}
+
+	shards := []streamtypes.Shard{
+		shard("a", ""),
+		shard("b", "a"),
+		shard("c", "trimmed"),
+	}
+
+	tests := []struct {
+		name   string
+		before func()
+		want   []string
+	}{
+		{"roots and orphans", func() {}, []string{"a", "c"}},
+		{"parent still open", func() {}, nil},
+		{"parent finished", func() { c.finished["a"] = true }, []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.before()
+
+			got := shardIds(c.ready(shards))
+			if len(got) != len(tt.want) {
+				t.Fatalf("ready() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ready() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// Finished shards that left the stream are forgotten
+	c.ready([]streamtypes.Shard{shard("b", "a")})
+	if c.finished["a"] || c.started["a"] {
+		t.Error("expected the trimmed shard to be forgotten")
+	}
+}
+
+func TestEvent(t *testing.T) {
+	c := &Consumer[order]{}
+
+	record := streamtypes.Record{
+		EventName:    streamtypes.OperationTypeRemove,
+		UserIdentity: &streamtypes.Identity{PrincipalId: aws.String(ttlPrincipal), Type: aws.String("Service")},
+		Dynamodb: &streamtypes.StreamRecord{
+			SequenceNumber: aws.String("100"),
+			Keys:           map[string]streamtypes.AttributeValue{"id": &streamtypes.AttributeValueMemberS{Value: "order1"}},
+			OldImage: map[string]streamtypes.AttributeValue{
+				"id":     &streamtypes.AttributeValueMemberS{Value: "order1"},
+				"status": &streamtypes.AttributeValueMemberS{Value: "pending"},
+			},
+		},
+	}
+
+	event := c.event(context.Background(), "shard1", record)
+
+	if event.Type != EventRemove || !event.Expired || event.SequenceNumber != "100" || event.ShardId != "shard1" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	if event.Old == nil || event.Old.Status != "pending" {
+		t.Errorf("Old = %+v, want the pending order", event.Old)
+	}
+
+	if event.New != nil {
+		t.Errorf("New = %+v, want nil for a remove", event.New)
+	}
+
+	if key, ok := event.Keys["id"].(*types.AttributeValueMemberS); !ok || key.Value != "order1" {
+		t.Errorf("Keys = %v, want id order1", event.Keys)
+	}
+}
@@ -0,0 +1,82 @@
+package streams
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// EventType is the kind of write a stream record describes
+type EventType string
+
+const (
+	// EventInsert is a new item
+	EventInsert EventType = "INSERT"
+	// EventModify is a change to an existing item
+	EventModify EventType = "MODIFY"
+	// EventRemove is a deleted item, including items deleted by TTL
+	EventRemove EventType = "REMOVE"
+)
+
+// StartPosition is where reading starts in shards without a checkpoint
+type StartPosition string
+
+const (
+	// StartTrimHorizon starts at the oldest record still in the stream, up to 24 hours old
+	StartTrimHorizon StartPosition = "trim_horizon"
+	// StartLatest starts after the newest record, so only new writes are seen
+	StartLatest StartPosition = "latest"
+)
+
+// StartPositions are the valid start positions
+var StartPositions = utils.NewEnum(StartTrimHorizon, StartLatest)
+
+func (p *StartPosition) UnmarshalJSON(data []byte) error {
+	return StartPositions.DecodeJSON(data, p)
+}
+
+func (p *StartPosition) UnmarshalText(text []byte) error {
+	return StartPositions.DecodeText(text, p)
+}
+
+// Event is a write to the table. Old and New hold the item images the stream view type
+// includes, decoded into T; they are nil when the stream doesn't include them or when the
+// image can't be decoded, which is logged.
+type Event[T any] struct {
+	Type           EventType
+	Key            string                          // Partition key of the item, when Options.Table is set
+	SortKey        string                          // Sort key of the item, when Options.Table is set
+	Keys           map[string]types.AttributeValue // Raw key attributes of the item
+	Old            *T                              // The item before the write (MODIFY and REMOVE)
+	New            *T                              // The item after the write (INSERT and MODIFY)
+	Expired        bool                            // The item was deleted by DynamoDB's TTL
+	SequenceNumber string
+	Time           time.Time // Approximate time of the write
+	ShardId        string
+}
+
+// Handler processes an event. Returning an error stops the consumer without checkpointing
+// the event, so it is delivered again on the next run.
+type Handler[T any] func(ctx context.Context, event Event[T]) error
+
+// Options configures a Consumer
+type Options struct {
+	TableName string // Table whose stream is consumed (required unless StreamArn is set)
+	StreamArn string // Stream to consume, instead of the latest stream of TableName
+	Region    string // AWS region (default AWS_REGION, or af-south-1)
+	Endpoint  string // Custom endpoint URL, e.g. for LocalStack (default DYNAMODB_ENDPOINT)
+
+	// Table decodes item images the way its Get does, including encrypted, compressed and
+	// overflowed values in JSON mode. Without it, images are unmarshaled into T as
+	// attributes.
+	Table *dynamo.DynamoDB
+
+	Checkpointer    Checkpointer  // Stores the position in each shard (default in memory, so restarts begin at StartPosition)
+	StartPosition   StartPosition // Where shards without a checkpoint start (default trim_horizon)
+	BatchSize       int           // Records per GetRecords call, up to 1000 (default 1000)
+	PollInterval    time.Duration // Wait after an empty read of an open shard (default 1s)
+	RefreshInterval time.Duration // How often the shard list is refreshed to find new shards (default 30s)
+}
@@ -0,0 +1,174 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrTtlMisconfigured is returned by New when TtlCheck finds TTL disabled or enabled on an
+// attribute other than TtlAttribute, in which case items would never expire
+var ErrTtlMisconfigured = errors.New("table ttl is misconfigured")
+
+// AutoCreateOptions configures the creation of a missing table by New. The key schema is
+// taken from the PartitionKeyAttribute and SortKeyAttribute of DbOptions, both strings.
+type AutoCreateOptions struct {
+	BillingMode   types.BillingMode // Billing mode (default on-demand)
+	ReadCapacity  int               // Read capacity units in provisioned mode (default 5)
+	WriteCapacity int               // Write capacity units in provisioned mode (default 5)
+	EnableTtl     bool              // Enable expiry on the TtlAttribute
+	Timeout       time.Duration     // How long to wait for the table to become active (default 2m)
+}
+
+// createTable creates a table for opts and waits until it is active. A table created
+// concurrently by another process is waited for instead.
+func createTable(ctx context.Context, client *dynamodb.Client, opts DbOptions) error {
+	create := *opts.AutoCreateTable
+	create.Timeout = utils.DurationOrDefault(create.Timeout, 2*time.Minute)
+
+	if create.BillingMode == "" {
+		create.BillingMode = types.BillingModePayPerRequest
+	}
+
+	input := &dynamodb.CreateTableInput{
+		TableName:   aws.String(opts.TableName),
+		BillingMode: create.BillingMode,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(opts.PartitionKeyAttribute), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(opts.PartitionKeyAttribute), KeyType: types.KeyTypeHash},
+		},
+	}
+
+	if opts.SortKeyAttribute != "" {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: aws.String(opts.SortKeyAttribute), AttributeType: types.ScalarAttributeTypeS,
+		})
+		input.KeySchema = append(input.KeySchema, types.KeySchemaElement{
+			AttributeName: aws.String(opts.SortKeyAttribute), KeyType: types.KeyTypeRange,
+		})
+	}
+
+	if create.BillingMode == types.BillingModeProvisioned {
+		input.ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(int64(utils.IntOrDefault(create.ReadCapacity, 5))),
+			WriteCapacityUnits: aws.Int64(int64(utils.IntOrDefault(create.WriteCapacity, 5))),
+		}
+	}
+
+	log.Infof("Creating dynamodb table %s", opts.TableName)
+
+	_, err := client.CreateTable(ctx, input)
+
+	var inUse *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUse) {
+		return fmt.Errorf("failed to create table %s: %w", opts.TableName, err)
+	}
+
+	if err := waitForActive(ctx, client, opts.TableName, create.Timeout); err != nil {
+		return err
+	}
+
+	if !create.EnableTtl {
+		return nil
+	}
+
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(opts.TableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(opts.TtlAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+
+	// Already enabled when another process created the table first
+	var apiErr smithy.APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException") {
+		return fmt.Errorf("failed to enable ttl on table %s: %w", opts.TableName, err)
+	}
+
+	return nil
+}
+
+// checkTtl verifies that TTL is enabled on the TtlAttribute of opts, enabling it when the
+// check is TtlCheckEnable and it is disabled
+func checkTtl(ctx context.Context, client *dynamodb.Client, opts DbOptions) error {
+	result, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(opts.TableName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe ttl of table %s: %w", opts.TableName, err)
+	}
+
+	status := types.TimeToLiveStatusDisabled
+	attribute := ""
+
+	if description := result.TimeToLiveDescription; description != nil {
+		status = description.TimeToLiveStatus
+		attribute = aws.ToString(description.AttributeName)
+	}
+
+	switch status {
+	case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+		if attribute != opts.TtlAttribute {
+			return fmt.Errorf("%w: table %s expires items on %q, not %q", ErrTtlMisconfigured, opts.TableName, attribute, opts.TtlAttribute)
+		}
+		return nil
+	case types.TimeToLiveStatusDisabling:
+		return fmt.Errorf("%w: ttl of table %s is being disabled", ErrTtlMisconfigured, opts.TableName)
+	}
+
+	if opts.TtlCheck != TtlCheckEnable {
+		return fmt.Errorf("%w: ttl of table %s is disabled", ErrTtlMisconfigured, opts.TableName)
+	}
+
+	log.Infof("Enabling ttl on %s of dynamodb table %s", opts.TtlAttribute, opts.TableName)
+
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(opts.TableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(opts.TtlAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable ttl on table %s: %w", opts.TableName, err)
+	}
+
+	return nil
+}
+
+// WaitForActive waits until the table exists and is active, e.g. after it was created or
+// restored by another process, or timeout passes (default 2m)
+//
+// Example:
+//
+//	err := db.WaitForActive(ctx, time.Minute)
+func (d *DynamoDB) WaitForActive(ctx context.Context, timeout ...time.Duration) error {
+	wait := 2 * time.Minute
+	if len(timeout) > 0 {
+		wait = utils.DurationOrDefault(timeout[0], wait)
+	}
+
+	return waitForActive(ctx, d.client, d.tableName, wait)
+}
+
+func waitForActive(ctx context.Context, client *dynamodb.Client, tableName string, timeout time.Duration) error {
+	waiter := dynamodb.NewTableExistsWaiter(client)
+
+	err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, timeout)
+	if err != nil {
+		return fmt.Errorf("failed waiting for table %s to become active: %w", tableName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// maxTransactionItems is the most operations DynamoDB accepts in one TransactWriteItems call
+const maxTransactionItems = 100
+
+// TransactionOptions configures a transaction
+type TransactionOptions struct {
+	// Token makes retries of the same transaction idempotent for 10 minutes (optional)
+	Token string
+}
+
+// Transaction collects writes to one or more registered tables and commits them atomically
+// with TransactWriteItems: either all of them are applied or none are. The tables must be
+// in the same account and region.
+type Transaction struct {
+	opts   TransactionOptions
+	client *dynamodb.Client
+	items  []types.TransactWriteItem
+	writes []transactionWrite
+	err    error
+}
+
+// transactionWrite is an item written by a transaction, invalidated in its table's read
+// cache on commit
+type transactionWrite struct {
+	table *DynamoDB
+	key   KeyPair
+}
+
+// NewTransaction starts an empty transaction. Operations are validated as they are added and
+// the first error is returned by Commit.
+//
+// Example:
+//
+//	tx := dynamo.NewTransaction()
+//	tx.Update("accounts", "acc1", Balance{Amount: 50}, dynamo.PutOptions{ExpectedVersion: 3})
+//	tx.Put("ledger", "acc1", entry, dynamo.PutOptions{SortKey: entry.ID, IfNotExists: true})
+//	err := tx.Commit(ctx)
+func NewTransaction(options ...TransactionOptions) *Transaction {
+	var opts TransactionOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	return &Transaction{opts: opts}
+}
+
+// Put adds a Put of value to the table registered as tableName, with the conditions of options
+func (tx *Transaction) Put(tableName, key string, value any, options ...PutOptions) *Transaction {
+	table := tx.table(tableName)
+	if table == nil {
+		return tx
+	}
+
+	input, err := table.putInput(context.Background(), key, value, getSetOptions(options...))
+	if err != nil {
+		tx.err = fmt.Errorf("failed to build put of %s to %s: %w", key, tableName, err)
+		return tx
+	}
+
+	tx.writes = append(tx.writes, transactionWrite{table, KeyPair{Key: key, SortKey: getSetOptions(options...).SortKey}})
+
+	return tx.add(types.TransactWriteItem{Put: &types.Put{
+		TableName:                 input.TableName,
+		Item:                      input.Item,
+		ConditionExpression:       input.ConditionExpression,
+		ExpressionAttributeNames:  input.ExpressionAttributeNames,
+		ExpressionAttributeValues: input.ExpressionAttributeValues,
+	}})
+}
+
+// Update adds a partial update of the item key in the table registered as tableName, as
+// DynamoDB.Update does
+func (tx *Transaction) Update(tableName, key string, value any, options ...PutOptions) *Transaction {
+	table := tx.table(tableName)
+	if table == nil {
+		return tx
+	}
+
+	input, err := table.updateInput(key, value, getSetOptions(options...))
+	if err != nil {
+		tx.err = fmt.Errorf("failed to build update of %s in %s: %w", key, tableName, err)
+		return tx
+	}
+
+	tx.writes = append(tx.writes, transactionWrite{table, KeyPair{Key: key, SortKey: getSetOptions(options...).SortKey}})
+
+	return tx.add(types.TransactWriteItem{Update: &types.Update{
+		TableName:                 input.TableName,
+		Key:                       input.Key,
+		UpdateExpression:          input.UpdateExpression,
+		ConditionExpression:       input.ConditionExpression,
+		ExpressionAttributeNames:  input.ExpressionAttributeNames,
+		ExpressionAttributeValues: input.ExpressionAttributeValues,
+	}})
+}
+
+// Delete adds a delete of the item key from the table registered as tableName. The SortKey and
+// condition fields of options apply; on versioned tables the version is only checked when
+// ExpectedVersion is set.
+func (tx *Transaction) Delete(tableName, key string, options ...PutOptions) *Transaction {
+	table := tx.table(tableName)
+	if table == nil {
+		return tx
+	}
+
+	opts := getSetOptions(options...)
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	condition, err := table.condition(opts, false, names, values)
+	if err != nil {
+		tx.err = fmt.Errorf("failed to build delete of %s from %s: %w", key, tableName, err)
+		return tx
+	}
+
+	tx.writes = append(tx.writes, transactionWrite{table, KeyPair{Key: key, SortKey: opts.SortKey}})
+
+	return tx.add(types.TransactWriteItem{Delete: &types.Delete{
+		TableName:                 aws.String(table.tableName),
+		Key:                       table.itemKey(key, table.sortKeyValue(opts.SortKey)),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  nilIfEmpty(names),
+		ExpressionAttributeValues: nilIfEmpty(values),
+	}})
+}
+
+// Check adds a condition on the item key in the table registered as tableName without writing
+// it, failing the transaction if the condition of options isn't met
+//
+// Example:
+//
+//	tx.Check("users", "user123", dynamo.PutOptions{
+//	    Condition:       "#status = :active",
+//	    ConditionNames:  map[string]string{"#status": "status"},
+//	    ConditionValues: map[string]any{":active": "active"},
+//	})
+func (tx *Transaction) Check(tableName, key string, options PutOptions) *Transaction {
+	table := tx.table(tableName)
+	if table == nil {
+		return tx
+	}
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	condition, err := table.condition(options, false, names, values)
+	if err == nil && condition == nil {
+		err = errors.New("no condition to check")
+	}
+	if err != nil {
+		tx.err = fmt.Errorf("failed to build check of %s in %s: %w", key, tableName, err)
+		return tx
+	}
+
+	return tx.add(types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+		TableName:                 aws.String(table.tableName),
+		Key:                       table.itemKey(key, table.sortKeyValue(options.SortKey)),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  nilIfEmpty(names),
+		ExpressionAttributeValues: nilIfEmpty(values),
+	}})
+}
+
+// Len returns the number of operations in the transaction
+func (tx *Transaction) Len() int {
+	return len(tx.items)
+}
+
+// Commit applies all operations atomically. It returns an error wrapping ErrConditionFailed
+// when a condition of any operation wasn't met, in which case nothing was written.
+func (tx *Transaction) Commit(ctx context.Context) error {
+	if tx.err != nil {
+		return tx.err
+	}
+
+	if len(tx.items) == 0 {
+		return nil
+	}
+
+	if len(tx.items) > maxTransactionItems {
+		return fmt.Errorf("transaction has %d operations, the limit is %d", len(tx.items), maxTransactionItems)
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: tx.items,
+	}
+
+	if tx.opts.Token != "" {
+		input.ClientRequestToken = aws.String(tx.opts.Token)
+	}
+
+	_, err := tx.client.TransactWriteItems(ctx, input)
+
+	for _, write := range tx.writes {
+		write.table.invalidate(write.key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", transactionError(err))
+	}
+
+	return nil
+}
+
+// table returns the table registered as tableName, recording an error if it doesn't exist
+func (tx *Transaction) table(tableName string) *DynamoDB {
+	if tx.err != nil {
+		return nil
+	}
+
+	table, err := getTable(tableName)
+	if err != nil {
+		tx.err = err
+		return nil
+	}
+
+	if tx.client == nil {
+		tx.client = table.client
+	}
+
+	return table
+}
+
+func (tx *Transaction) add(item types.TransactWriteItem) *Transaction {
+	tx.items = append(tx.items, item)
+	return tx
+}
+
+// sortKeyValue returns the stored sort key value, defaulting to "null" as Put does
+func (d *DynamoDB) sortKeyValue(sortKey string) string {
+	if d.sortKeyAttribute == "" {
+		return ""
+	}
+	return utils.StringOrDefault(sortKey, "null")
+}
+
+// transactionError maps a transaction cancelled by a failed condition to ErrConditionFailed,
+// naming the operations that failed
+func transactionError(err error) error {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return err
+	}
+
+	var failed []int
+	for i, reason := range cancelled.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			failed = append(failed, i)
+		}
+	}
+
+	if len(failed) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("%w: operations %v: %v", ErrConditionFailed, failed, err)
+}
@@ -4,6 +4,10 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/query"
+	"github.com/finch-technologies/go-utils/metrics"
+	"github.com/finch-technologies/go-utils/utils"
 )
 
 // ValueStoreMode defines how values are stored in DynamoDB tables
@@ -16,41 +20,129 @@ const (
 	ValueStoreModeAttributes ValueStoreMode = "attributes"
 )
 
+// ValueStoreModes are the valid value store modes
+var ValueStoreModes = utils.NewEnum(ValueStoreModeJson, ValueStoreModeAttributes)
+
+func (m *ValueStoreMode) UnmarshalJSON(data []byte) error {
+	return ValueStoreModes.DecodeJSON(data, m)
+}
+
+func (m *ValueStoreMode) UnmarshalText(text []byte) error {
+	return ValueStoreModes.DecodeText(text, m)
+}
+
+// TtlCheck defines how New checks the TTL configuration of a table
+type TtlCheck string
+
+const (
+	// TtlCheckNone doesn't check the TTL configuration
+	TtlCheckNone TtlCheck = ""
+	// TtlCheckVerify fails New unless TTL is enabled on the TtlAttribute
+	TtlCheckVerify TtlCheck = "verify"
+	// TtlCheckEnable enables TTL on the TtlAttribute if it is disabled, and fails New if it
+	// is enabled on another attribute
+	TtlCheckEnable TtlCheck = "enable"
+)
+
+// TtlChecks are the valid TTL checks
+var TtlChecks = utils.NewEnum(TtlCheckNone, TtlCheckVerify, TtlCheckEnable)
+
+func (c *TtlCheck) UnmarshalJSON(data []byte) error {
+	return TtlChecks.DecodeJSON(data, c)
+}
+
+func (c *TtlCheck) UnmarshalText(text []byte) error {
+	return TtlChecks.DecodeText(text, c)
+}
+
 // DynamoDB represents a configured DynamoDB table connection with all necessary
 // settings for performing operations on a specific table
 type DynamoDB struct {
-	client                *dynamodb.Client // AWS DynamoDB client instance
-	tableName             string           // Name of the DynamoDB table
-	partitionKeyAttribute string           // Name of the partition key attribute
-	ttlAttribute          string           // Name of the TTL (Time To Live) attribute
-	sortKeyAttribute      string           // Name of the sort key attribute (optional)
-	valueStoreMode        ValueStoreMode   // How values are stored (JSON vs attributes)
-	valueAttribute        string           // Name of the attribute that stores the value
-	ttl                   time.Duration    // Default TTL for items
+	client                *dynamodb.Client   // AWS DynamoDB client instance
+	tableName             string             // Name of the DynamoDB table
+	partitionKeyAttribute string             // Name of the partition key attribute
+	ttlAttribute          string             // Name of the TTL (Time To Live) attribute
+	sortKeyAttribute      string             // Name of the sort key attribute (optional)
+	valueStoreMode        ValueStoreMode     // How values are stored (JSON vs attributes)
+	valueAttribute        string             // Name of the attribute that stores the value
+	ttl                   time.Duration      // Default TTL for items
+	encryption            *tableEncryption   // Envelope encryption of the value attribute (optional)
+	cursors               *utils.CursorCodec // Encrypts QueryPage cursors (optional)
+	dax                   *daxReader         // Serves Get and Query reads through DAX (optional)
+	versionAttribute      string             // Attribute holding the item version for optimistic locking (optional)
+	compression           Compression        // Default compression of large values (optional)
+	compressionThreshold  int                // Value size above which values are compressed
+	overflow              *OverflowOptions   // Stores oversized values outside the table (optional)
+	purger                *expiredPurger     // Deletes expired items found by reads (optional)
+	cache                 *readCache         // Caches items read by Get (optional)
 }
 
 // DbOptions contains configuration options for creating a new DynamoDB connection
 type DbOptions struct {
-	Region                string         // AWS region for the DynamoDB service
-	TableName             string         // Name of the DynamoDB table
-	PartitionKeyAttribute string         // Name of the partition key attribute
-	TtlAttribute          string         // Name of the TTL attribute for automatic item expiration
-	SortKeyAttribute      string         // Name of the sort key attribute (optional)
-	ValueStoreMode        ValueStoreMode // Storage mode for values (JSON or attributes)
-	ValueAttribute        string         // Name of the attribute that stores the value
-	Ttl                   time.Duration  // Default TTL for items
+	Region                string             // AWS region for the DynamoDB service
+	Endpoint              string             // Custom endpoint URL, e.g. for DynamoDB Local or LocalStack (default DYNAMODB_ENDPOINT)
+	TableName             string             // Name of the DynamoDB table
+	Resource              string             // Logical table name resolved with naming.Table when TableName is empty
+	PartitionKeyAttribute string             // Name of the partition key attribute
+	TtlAttribute          string             // Name of the TTL attribute for automatic item expiration
+	SortKeyAttribute      string             // Name of the sort key attribute (optional)
+	ValueStoreMode        ValueStoreMode     // Storage mode for values (JSON or attributes)
+	ValueAttribute        string             // Name of the attribute that stores the value
+	Ttl                   time.Duration      // Default TTL for items
+	Client                *dynamodb.Client   // Existing client to use instead of the shared client for Region
+	Encryption            *EncryptionOptions // Encrypt the value attribute with per-table data keys (JSON mode only)
+	Cursors               *utils.CursorCodec // Encrypts QueryPage cursors (default a codec using CURSOR_SECRET)
+	Dax                   *DaxOptions        // Serve Get and Query reads through DAX, falling back to DynamoDB (optional)
+	VersionAttribute      string             // Attribute holding an item version; Put and Update then require PutOptions.ExpectedVersion (optional)
+	AutoCreateTable       *AutoCreateOptions // Create the table if it doesn't exist, e.g. for tests and new environments (optional)
+	TtlCheck              TtlCheck           // Verify or enable TTL on the TtlAttribute (default no check)
+	Compression           Compression        // Compress large values, e.g. CompressionGzip (json mode only, default none)
+	CompressionThreshold  int                // Value size in bytes above which values are compressed (default 4KB)
+	Overflow              *OverflowOptions   // Store values too large for an item in S3, keeping a pointer in the item (json mode only, optional)
+	DeleteExpiredOnRead   bool               // Delete expired items found by reads in the background instead of waiting for DynamoDB's TTL
+	Metrics               metrics.Collector  // Counts expired items deleted on read as MetricExpiredPurged (optional)
+	CacheSize             int                // Cache up to this many items read by Get in memory, invalidated by this process's writes (default no cache)
+	CacheTTL              time.Duration      // How long items stay cached, bounding how stale writes by other processes can be (default 5s)
+}
+
+// EncryptionOptions configures envelope encryption of a table's value attribute. Values are
+// encrypted with AES-GCM using a per-table data key, which is itself wrapped by KMS and
+// stored in the table under KeyItem.
+type EncryptionOptions struct {
+	KmsKeyId string        // KMS key used to wrap the table's data keys (default KMS_KEY_ID)
+	KeyItem  string        // Partition key of the item holding the wrapped data keys (default "__data_keys__")
+	Wrapper  KeyWrapper    // Wraps and unwraps data keys (default AWS KMS)
+	RingTtl  time.Duration // How long the key ring is cached before it's re-read to pick up rotations by other instances (default 5m)
+}
+
+// ClientConfig contains the settings for creating a dedicated DynamoDB client
+type ClientConfig struct {
+	Region   string // AWS region for the DynamoDB service
+	Endpoint string // Custom endpoint URL, e.g. for DynamoDB Local (optional)
 }
 
 // GetOptions contains options for DynamoDB Get operations
 type GetOptions struct {
 	SortKey string // Sort key value for tables with composite keys
 	Result  any    // Pointer to struct where the result will be unmarshaled
+	Version *int64 // Receives the item's version on tables with a VersionAttribute (optional)
 }
 
 // PutOptions contains options for DynamoDB Put and Update operations
 type PutOptions struct {
 	Ttl     time.Duration // TTL for the item (overrides default table TTL)
 	SortKey string        // Sort key value for tables with composite keys
+
+	IfNotExists     bool              // Only write if no item with the key exists
+	IfExpired       bool              // Only write if no item with the key exists or its TTL has passed, e.g. to take over an expired lock DynamoDB hasn't deleted yet
+	ExpectedExpiry  time.Time         // Expiry the item must still have, as returned by Get, e.g. to renew a lock only while nobody took it over
+	ExpectedValue   any               // Value the item must still hold, e.g. to release a claim only while it is yours. Compared as stored, so not for compressed or encrypted values.
+	Condition       string            // Condition expression the existing item must meet, e.g. "#owner = :owner"
+	ConditionNames  map[string]string // Expression attribute names used by Condition
+	ConditionValues map[string]any    // Expression attribute values used by Condition
+	ExpectedVersion int64             // Version the item must have on tables with a VersionAttribute, 0 for a new item
+
+	Compress Compression // Compression of this value, overriding the table's DbOptions.Compression (optional)
 }
 
 // QueryCondition defines the types of conditions that can be applied to sort keys in DynamoDB queries
@@ -61,13 +153,16 @@ const (
 	QueryConditionNone QueryCondition = "none"
 	// QueryConditionBeginsWith checks if the sort key begins with a specific value
 	QueryConditionBeginsWith QueryCondition = "beginsWith"
-	// QueryConditionEndsWith checks if the sort key ends with a specific value
+	// QueryConditionEndsWith checks if the sort key ends with a specific value. DynamoDB can't
+	// query on it, so it is checked against the items read, after Limit.
 	QueryConditionEndsWith QueryCondition = "endsWith"
-	// QueryConditionContains checks if the sort key contains a specific value
+	// QueryConditionContains checks if the sort key contains a specific value. DynamoDB can't
+	// query on it, so it is checked against the items read, after Limit.
 	QueryConditionContains QueryCondition = "contains"
 	// QueryConditionEquals checks if the sort key equals a specific value
 	QueryConditionEquals QueryCondition = "equals"
-	// QueryConditionNotEquals checks if the sort key does not equal a specific value
+	// QueryConditionNotEquals checks if the sort key does not equal a specific value. DynamoDB
+	// can't query on it, so it is checked against the items read, after Limit.
 	QueryConditionNotEquals QueryCondition = "notEquals"
 	// QueryConditionGreaterThan checks if the sort key is greater than a specific value
 	QueryConditionGreaterThan QueryCondition = "greaterThan"
@@ -77,19 +172,75 @@ const (
 	QueryConditionGreaterThanOrEqualTo QueryCondition = "greaterThanOrEqualTo"
 	// QueryConditionLessThanOrEqualTo checks if the sort key is less than or equal to a specific value
 	QueryConditionLessThanOrEqualTo QueryCondition = "lessThanOrEqualTo"
+	// QueryConditionBetween checks if the sort key is between SortKeyValueFrom and
+	// SortKeyValueTo, inclusive
+	QueryConditionBetween QueryCondition = "between"
+)
+
+// QueryConditions are the valid query conditions
+var QueryConditions = utils.NewEnum(
+	QueryConditionNone,
+	QueryConditionBeginsWith,
+	QueryConditionEndsWith,
+	QueryConditionContains,
+	QueryConditionEquals,
+	QueryConditionNotEquals,
+	QueryConditionGreaterThan,
+	QueryConditionLessThan,
+	QueryConditionGreaterThanOrEqualTo,
+	QueryConditionLessThanOrEqualTo,
+	QueryConditionBetween,
 )
 
+func (c *QueryCondition) UnmarshalJSON(data []byte) error {
+	return QueryConditions.DecodeJSON(data, c)
+}
+
+func (c *QueryCondition) UnmarshalText(text []byte) error {
+	return QueryConditions.DecodeText(text, c)
+}
+
 // QueryOptions contains options for DynamoDB Query operations
 type QueryOptions struct {
 	Result                any            // Pointer to struct where the results will be unmarshaled
 	SortKeyValue          string         // Sort key value to apply the condition against
+	SortKeyValueFrom      string         // Lower bound of QueryConditionBetween, inclusive
+	SortKeyValueTo        string         // Upper bound of QueryConditionBetween, inclusive
 	PartitionKeyCondition QueryCondition // Condition to apply to the partition key (usually equals)
 	SortKeyCondition      QueryCondition // Condition to apply to the sort key
 	Limit                 int            // Maximum number of items to return (0 = no limit)
+	Cursor                string         // Cursor returned by QueryPage to continue from
+	MaxItems              int            // Most items QueryAll returns (0 = all)
+
+	// ExclusiveStartKey continues a query after this key, for callers that keep the raw
+	// LastEvaluatedKey instead of a cursor. Cursor takes precedence.
+	ExclusiveStartKey map[string]types.AttributeValue
+	// LastEvaluatedKey receives the key to continue from, nil after the last page (optional)
+	LastEvaluatedKey *map[string]types.AttributeValue
+
+	// IndexName queries a global or local secondary index instead of the table. The key
+	// passed to Query is then the index's partition key value, and SortKeyCondition and Where
+	// apply to the index's sort key. Results still carry the table's keys.
+	IndexName                  string
+	IndexPartitionKeyAttribute string // Partition key of the index (default the table's, as for local indexes)
+	IndexSortKeyAttribute      string // Sort key of the index (optional)
+
+	// Where filters items. A condition on the sort key becomes the key condition when
+	// SortKeyCondition isn't set; the rest is a filter expression, or in JSON value store mode
+	// applies to the decoded values. Filters run after Limit, so pages may be short.
+	Where query.Expr
+
+	// Filters are conditions on item attributes, combined with FilterLogic (default and), that
+	// are sent as a filter expression in both value store modes. In JSON mode they see the
+	// item's own attributes, not the fields of its value; use Where for those. Combined with
+	// Where using and.
+	Filters     []Filter
+	FilterLogic query.Logic
 }
 
 type QueryResult[T interface{}] struct {
 	Value   T
 	Expiry  *time.Time
+	Key     string // Partition key of the item
 	SortKey string
 }
@@ -0,0 +1,238 @@
+package dynamo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/query"
+)
+
+// expressionBuilder translates queries into DynamoDB expressions, collecting their
+// attribute name and value placeholders
+type expressionBuilder struct {
+	names   map[string]string
+	values  map[string]types.AttributeValue
+	counter int
+}
+
+// Filter is a condition on an item attribute for QueryOptions.Filters. Nested attributes are
+// separated with dots, e.g. "address.city".
+//
+// Example:
+//
+//	items, err := db.Query("user123", dynamo.QueryOptions{
+//	    Filters: []dynamo.Filter{{Attribute: "status", Operator: query.OpEq, Value: "active"}},
+//	})
+type Filter struct {
+	Attribute string
+	Operator  query.Op
+	Value     any
+}
+
+// filtersExpr combines filters into a query expression, with and unless logic is or
+func filtersExpr(filters []Filter, logic query.Logic) query.Expr {
+	exprs := make([]query.Expr, len(filters))
+
+	for i, filter := range filters {
+		exprs[i] = query.Condition{Field: filter.Attribute, Op: filter.Operator, Value: filter.Value}
+	}
+
+	if logic == query.LogicOr {
+		return query.Or(exprs...)
+	}
+
+	return query.And(exprs...)
+}
+
+// splitWhere moves a condition on the sort key out of where so it can be used as a key
+// condition, which reads fewer items than a filter. It returns the remaining expression.
+func splitWhere(where query.Expr, sortKeyAttribute string) (*query.Condition, query.Expr) {
+	if sortKeyAttribute == "" {
+		return nil, where
+	}
+
+	switch e := where.(type) {
+	case query.Condition:
+		if isKeyCondition(e, sortKeyAttribute) {
+			return &e, nil
+		}
+	case query.Group:
+		if e.Logic != query.LogicAnd {
+			return nil, where
+		}
+
+		for i, sub := range e.Exprs {
+			if condition, ok := sub.(query.Condition); ok && isKeyCondition(condition, sortKeyAttribute) {
+				rest := append(append([]query.Expr{}, e.Exprs[:i]...), e.Exprs[i+1:]...)
+				return &condition, query.And(rest...)
+			}
+		}
+	}
+
+	return nil, where
+}
+
+// isKeyCondition reports whether DynamoDB accepts a condition in a key condition expression
+func isKeyCondition(c query.Condition, sortKeyAttribute string) bool {
+	if c.Field != sortKeyAttribute {
+		return false
+	}
+
+	switch c.Op {
+	case query.OpEq, query.OpLt, query.OpLe, query.OpGt, query.OpGe, query.OpBeginsWith, query.OpBetween:
+		return true
+	default:
+		return false
+	}
+}
+
+// build returns the expression for expr, or an empty string if it matches everything
+func (b *expressionBuilder) build(expr query.Expr) (string, error) {
+	switch e := expr.(type) {
+	case nil:
+		return "", nil
+	case query.Condition:
+		return b.condition(e)
+	case query.Group:
+		var parts []string
+
+		for _, sub := range e.Exprs {
+			part, err := b.build(sub)
+			if err != nil {
+				return "", err
+			}
+
+			if part == "" {
+				if e.Logic == query.LogicOr {
+					// One branch matches everything, so the whole group does
+					return "", nil
+				}
+				continue
+			}
+
+			parts = append(parts, part)
+		}
+
+		if len(parts) == 0 {
+			if e.Logic == query.LogicOr {
+				return "", fmt.Errorf("%w: empty or", query.ErrUnsupported)
+			}
+			return "", nil
+		}
+
+		if len(parts) == 1 {
+			return parts[0], nil
+		}
+
+		separator := " AND "
+		if e.Logic == query.LogicOr {
+			separator = " OR "
+		}
+
+		return "(" + strings.Join(parts, separator) + ")", nil
+	default:
+		return "", fmt.Errorf("%w: expression %T", query.ErrUnsupported, expr)
+	}
+}
+
+func (b *expressionBuilder) condition(c query.Condition) (string, error) {
+	path := b.name(c.Field)
+
+	switch c.Op {
+	case query.OpExists:
+		return fmt.Sprintf("attribute_exists(%s)", path), nil
+	case query.OpNotExists:
+		return fmt.Sprintf("attribute_not_exists(%s)", path), nil
+	case query.OpBetween:
+		bounds, ok := c.Value.([2]any)
+		if !ok {
+			return "", fmt.Errorf("%w: between on %s needs [2]any bounds", query.ErrUnsupported, c.Field)
+		}
+
+		low, err := b.value(bounds[0])
+		if err != nil {
+			return "", err
+		}
+
+		high, err := b.value(bounds[1])
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s BETWEEN %s AND %s", path, low, high), nil
+	case query.OpIn:
+		values, ok := c.Value.([]any)
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("%w: in on %s needs values", query.ErrUnsupported, c.Field)
+		}
+
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			placeholder, err := b.value(value)
+			if err != nil {
+				return "", err
+			}
+			placeholders[i] = placeholder
+		}
+
+		return fmt.Sprintf("%s IN (%s)", path, strings.Join(placeholders, ", ")), nil
+	}
+
+	value, err := b.value(c.Value)
+	if err != nil {
+		return "", err
+	}
+
+	switch c.Op {
+	case query.OpEq, query.OpNe, query.OpLt, query.OpLe, query.OpGt, query.OpGe:
+		return fmt.Sprintf("%s %s %s", path, c.Op, value), nil
+	case query.OpBeginsWith, query.OpContains:
+		return fmt.Sprintf("%s(%s, %s)", c.Op, path, value), nil
+	default:
+		return "", fmt.Errorf("%w: operator %q", query.ErrUnsupported, c.Op)
+	}
+}
+
+// name returns the placeholder path for a dotted field
+func (b *expressionBuilder) name(field string) string {
+	parts := strings.Split(field, ".")
+
+	for i, part := range parts {
+		placeholder := fmt.Sprintf("#w%d", b.counter)
+		b.counter++
+
+		b.names[placeholder] = part
+		parts[i] = placeholder
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// value returns the placeholder for a value
+func (b *expressionBuilder) value(value any) (string, error) {
+	attributeValue, err := attributevalue.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal query value: %w", err)
+	}
+
+	placeholder := fmt.Sprintf(":w%d", b.counter)
+	b.counter++
+
+	b.values[placeholder] = attributeValue
+
+	return placeholder, nil
+}
+
+// sortKeyMatcher returns a check of the sort key conditions DynamoDB can't query on
+func sortKeyMatcher(condition QueryCondition, value string) func(sortKey string) bool {
+	switch condition {
+	case QueryConditionEndsWith:
+		return func(sortKey string) bool { return strings.HasSuffix(sortKey, value) }
+	case QueryConditionContains:
+		return func(sortKey string) bool { return strings.Contains(sortKey, value) }
+	default:
+		return func(sortKey string) bool { return sortKey != value }
+	}
+}
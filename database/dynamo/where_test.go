@@ -0,0 +1,125 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/finch-technologies/go-utils/database/query"
+)
+
+func TestExpressionBuilder(t *testing.T) {
+	tests := []struct {
+		name string
+		expr query.Expr
+		want string
+	}{
+		{name: "nil", expr: nil, want: ""},
+		{name: "eq", expr: query.Field("status").Eq("active"), want: "#w0 = :w1"},
+		{name: "nested", expr: query.Field("address.city").Eq("Durban"), want: "#w0.#w1 = :w2"},
+		{name: "begins with", expr: query.Field("name").BeginsWith("Th"), want: "begins_with(#w0, :w1)"},
+		{name: "between", expr: query.Field("age").Between(18, 65), want: "#w0 BETWEEN :w1 AND :w2"},
+		{name: "in", expr: query.Field("status").In("a", "b"), want: "#w0 IN (:w1, :w2)"},
+		{name: "exists", expr: query.Field("email").Exists(), want: "attribute_exists(#w0)"},
+		{
+			name: "group",
+			expr: query.And(query.Field("age").Gt(18), query.Or(query.Field("status").Eq("a"), query.Field("status").Eq("b"))),
+			want: "(#w0 > :w1 AND (#w2 = :w3 OR #w4 = :w5))",
+		},
+		{name: "empty and", expr: query.And(), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := &expressionBuilder{names: map[string]string{}, values: map[string]types.AttributeValue{}}
+
+			got, err := builder.build(tt.expr)
+			if err != nil {
+				t.Fatalf("build() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("build() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitWhere(t *testing.T) {
+	key, rest := splitWhere(query.And(query.Field("status").Eq("active"), query.Field("group_id").BeginsWith("2024")), "group_id")
+	if key == nil || key.Field != "group_id" {
+		t.Fatalf("expected the sort key condition to be split out, got %+v", key)
+	}
+
+	if group, ok := rest.(query.Group); !ok || len(group.Exprs) != 1 {
+		t.Errorf("expected the status condition to remain, got %+v", rest)
+	}
+
+	// Sort key conditions under an or can't be key conditions
+	if key, _ := splitWhere(query.Or(query.Field("group_id").Eq("a"), query.Field("group_id").Eq("b")), "group_id"); key != nil {
+		t.Errorf("expected no key condition, got %+v", key)
+	}
+
+	// Conditions on the table's sort key aren't key conditions of an index with another one
+	if key, _ := splitWhere(query.Field("group_id").Eq("a"), "created_at"); key != nil {
+		t.Errorf("expected no key condition on an index, got %+v", key)
+	}
+}
+
+func TestFiltersExpr(t *testing.T) {
+	filters := []Filter{
+		{Attribute: "status", Operator: query.OpEq, Value: "active"},
+		{Attribute: "age", Operator: query.OpGe, Value: 18},
+	}
+
+	tests := []struct {
+		name  string
+		logic query.Logic
+		want  string
+	}{
+		{name: "default and", want: "(#w0 = :w1 AND #w2 >= :w3)"},
+		{name: "or", logic: query.LogicOr, want: "(#w0 = :w1 OR #w2 >= :w3)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := &expressionBuilder{names: map[string]string{}, values: map[string]types.AttributeValue{}}
+
+			got, err := builder.build(filtersExpr(filters, tt.logic))
+			if err != nil {
+				t.Fatalf("build() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("build() = %q, want %q", got, tt.want)
+			}
+
+			if builder.names["#w0"] != "status" || builder.names["#w2"] != "age" {
+				t.Errorf("unexpected names %v", builder.names)
+			}
+		})
+	}
+}
+
+func TestSortKeyMatcher(t *testing.T) {
+	tests := []struct {
+		condition QueryCondition
+		value     string
+		sortKey   string
+		want      bool
+	}{
+		{QueryConditionEndsWith, "_2024", "order_2024", true},
+		{QueryConditionEndsWith, "_2024", "order_2025", false},
+		{QueryConditionContains, "der", "order_2024", true},
+		{QueryConditionContains, "xyz", "order_2024", false},
+		{QueryConditionNotEquals, "order_1", "order_2", true},
+		{QueryConditionNotEquals, "order_1", "order_1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.condition)+"/"+tt.sortKey, func(t *testing.T) {
+			if got := sortKeyMatcher(tt.condition, tt.value)(tt.sortKey); got != tt.want {
+				t.Errorf("sortKeyMatcher(%s, %q)(%q) = %v, want %v", tt.condition, tt.value, tt.sortKey, got, tt.want)
+			}
+		})
+	}
+}
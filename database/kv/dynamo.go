@@ -0,0 +1,108 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+)
+
+// dynamoStore stores items in a DynamoDB table in JSON value store mode
+type dynamoStore struct {
+	table *dynamo.DynamoDB
+}
+
+func newDynamoStore(opts StoreOptions) (Store, error) {
+	if opts.Dynamo.ValueStoreMode != "" && opts.Dynamo.ValueStoreMode != dynamo.ValueStoreModeJson {
+		return nil, fmt.Errorf("kv requires the %s value store mode, got %s", dynamo.ValueStoreModeJson, opts.Dynamo.ValueStoreMode)
+	}
+
+	opts.Dynamo.ValueStoreMode = dynamo.ValueStoreModeJson
+
+	table, err := dynamo.New(opts.Dynamo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamo store: %w", err)
+	}
+
+	return NewDynamoStore(table), nil
+}
+
+// NewDynamoStore wraps a table in JSON value store mode as a Store
+func NewDynamoStore(table *dynamo.DynamoDB) Store {
+	return &dynamoStore{table: table}
+}
+
+func (s *dynamoStore) Get(ctx context.Context, key string, options ...GetOptions) (string, *time.Time, error) {
+	opts := getGetOptions(options...)
+
+	value, expiry, err := s.table.GetContext(ctx, key, dynamo.GetOptions{SortKey: opts.SortKey})
+	if err != nil || value == nil {
+		return "", nil, err
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected value type %T for %s", value, key)
+	}
+
+	if str == "" {
+		return "", nil, nil
+	}
+
+	return str, expiry, nil
+}
+
+func (s *dynamoStore) Put(ctx context.Context, key string, value any, options ...PutOptions) error {
+	opts := getPutOptions(options...)
+
+	payload, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	return s.table.PutContext(ctx, key, payload, dynamo.PutOptions{SortKey: opts.SortKey, Ttl: opts.Ttl})
+}
+
+func (s *dynamoStore) Query(ctx context.Context, key string, options ...QueryOptions) ([]Item[string], error) {
+	opts := getQueryOptions(options...)
+
+	queryOpts := dynamo.QueryOptions{MaxItems: opts.Limit}
+
+	if opts.SortKeyPrefix != "" {
+		queryOpts.SortKeyCondition = dynamo.QueryConditionBeginsWith
+		queryOpts.SortKeyValue = opts.SortKeyPrefix
+	}
+
+	results, err := s.table.QueryAll(ctx, key, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item[string], 0, len(results))
+
+	for _, result := range results {
+		value, ok := result.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value type %T for %s/%s", result.Value, result.Key, result.SortKey)
+		}
+
+		expiry := result.Expiry
+		if expiry != nil && expiry.Unix() <= 0 {
+			expiry = nil
+		}
+
+		items = append(items, Item[string]{Value: value, Expiry: expiry, Key: result.Key, SortKey: result.SortKey})
+	}
+
+	return items, nil
+}
+
+func (s *dynamoStore) Delete(ctx context.Context, key string, sortKey ...string) error {
+	return s.table.DeleteContext(ctx, key, sortKey...)
+}
+
+// Close is a no-op since dynamo clients are shared
+func (s *dynamoStore) Close() error {
+	return nil
+}
@@ -0,0 +1,201 @@
+// Package kv provides a key-value store with sort keys and expiry over interchangeable
+// backends, so the same code can run against DynamoDB in production, redis where it is
+// cheaper, and memory in tests.
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Store is a key-value store. Items are addressed by a key and an optional sort key, and
+// values are stored as strings: Put stores strings as they are and encodes other values as
+// JSON. Use the generic Get, Put and Query functions to work with typed values.
+type Store interface {
+	// Get returns the value of an item and its expiry, or an empty string if the item
+	// doesn't exist or has expired
+	Get(ctx context.Context, key string, options ...GetOptions) (string, *time.Time, error)
+	// Put writes an item, replacing any existing item with the same keys
+	Put(ctx context.Context, key string, value any, options ...PutOptions) error
+	// Query returns the unexpired items with key, ordered by sort key
+	Query(ctx context.Context, key string, options ...QueryOptions) ([]Item[string], error)
+	// Delete removes an item. Deleting a missing item isn't an error.
+	Delete(ctx context.Context, key string, sortKey ...string) error
+	// Close releases the connections the store created
+	Close() error
+}
+
+// New creates a store on the backend selected by options, or by the KV_BACKEND environment
+// variable, defaulting to dynamo.
+//
+// Example:
+//
+//	store, err := kv.New(kv.StoreOptions{
+//	    Dynamo: dynamo.DbOptions{TableName: "sessions", SortKeyAttribute: "device"},
+//	    Redis:  redis.DbOptions{Db: 2},
+//	    Prefix: "sessions:",
+//	})
+//	err = store.Put(ctx, "user123", session, kv.PutOptions{SortKey: "phone", Ttl: time.Hour})
+func New(options ...StoreOptions) (Store, error) {
+	opts := getOptions(options...)
+
+	switch opts.Backend {
+	case BackendDynamo:
+		return newDynamoStore(opts)
+	case BackendRedis:
+		return newRedisStore(opts)
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported kv backend %q, expected one of %v", opts.Backend, Backends.Strings())
+	}
+}
+
+func getOptions(options ...StoreOptions) StoreOptions {
+	var opts StoreOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Backend == "" {
+		opts.Backend = BackendDynamo
+
+		if backend := os.Getenv("KV_BACKEND"); backend != "" {
+			opts.Backend = Backend(backend)
+		}
+	}
+
+	return opts
+}
+
+func getGetOptions(options ...GetOptions) GetOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return GetOptions{}
+}
+
+func getPutOptions(options ...PutOptions) PutOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return PutOptions{}
+}
+
+func getQueryOptions(options ...QueryOptions) QueryOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return QueryOptions{}
+}
+
+// Get returns the value of an item decoded into T, or nil if the item doesn't exist or has
+// expired. String values are returned as stored.
+//
+// Example:
+//
+//	session, expiry, err := kv.Get[Session](ctx, store, "user123", kv.GetOptions{SortKey: "phone"})
+func Get[T any](ctx context.Context, store Store, key string, options ...GetOptions) (*T, *time.Time, error) {
+	value, expiry, err := store.Get(ctx, key, options...)
+	if err != nil || value == "" {
+		return nil, expiry, err
+	}
+
+	result, err := decode[T](value)
+	if err != nil {
+		return nil, expiry, fmt.Errorf("failed to decode value of %s: %w", key, err)
+	}
+
+	return result, expiry, nil
+}
+
+// Put writes value as the item key. It is Store.Put with the value type checked at compile
+// time.
+func Put[T any](ctx context.Context, store Store, key string, value T, options ...PutOptions) error {
+	return store.Put(ctx, key, value, options...)
+}
+
+// Query returns the unexpired items with key decoded into T, ordered by sort key
+//
+// Example:
+//
+//	sessions, err := kv.Query[Session](ctx, store, "user123", kv.QueryOptions{SortKeyPrefix: "web_"})
+func Query[T any](ctx context.Context, store Store, key string, options ...QueryOptions) ([]Item[T], error) {
+	items, err := store.Query(ctx, key, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Item[T], 0, len(items))
+
+	for _, item := range items {
+		value, err := decode[T](item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value of %s/%s: %w", item.Key, item.SortKey, err)
+		}
+
+		results = append(results, Item[T]{Value: *value, Expiry: item.Expiry, Key: item.Key, SortKey: item.SortKey})
+	}
+
+	return results, nil
+}
+
+// encode converts a value to its stored form: strings as they are, numbers and booleans
+// formatted, and everything else as JSON
+func encode(value any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	return string(bytes), nil
+}
+
+// decode reverses encode. Strings are returned as stored since they aren't encoded as JSON.
+func decode[T any](value string) (*T, error) {
+	var result T
+
+	if target := reflect.ValueOf(&result).Elem(); target.Kind() == reflect.String {
+		target.SetString(value)
+		return &result, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// expiryOf returns the expiry of an item written now with ttl, nil for no ttl
+func expiryOf(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	expiry := time.Now().Add(ttl)
+	return &expiry
+}
@@ -0,0 +1,105 @@
+package kv
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type session struct {
+	Device string `json:"device"`
+	Active bool   `json:"active"`
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if err := Put(ctx, store, "user1", session{Device: "phone", Active: true}, PutOptions{SortKey: "web_1"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := Put(ctx, store, "user1", session{Device: "laptop"}, PutOptions{SortKey: "web_2", Ttl: time.Hour}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := Put(ctx, store, "user1", session{Device: "tablet"}, PutOptions{SortKey: "app_1"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := Put(ctx, store, "user1", session{Device: "old"}, PutOptions{SortKey: "web_0", Ttl: time.Nanosecond}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put(ctx, "name", "plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	value, expiry, err := Get[session](ctx, store, "user1", GetOptions{SortKey: "web_2"})
+	if err != nil || value == nil || value.Device != "laptop" {
+		t.Fatalf("Get() = %v, %v, want laptop", value, err)
+	}
+	if expiry == nil || time.Until(*expiry) < 59*time.Minute {
+		t.Errorf("Get() expiry = %v, want about an hour from now", expiry)
+	}
+
+	if name, _, _ := Get[string](ctx, store, "name"); name == nil || *name != "plain" {
+		t.Errorf("Get[string]() = %v, want plain", name)
+	}
+
+	if value, _, _ := Get[session](ctx, store, "user1", GetOptions{SortKey: "web_0"}); value != nil {
+		t.Errorf("Get() of an expired item = %v, want nil", value)
+	}
+
+	tests := []struct {
+		name    string
+		options QueryOptions
+		want    []string
+	}{
+		{"all", QueryOptions{}, []string{"tablet", "phone", "laptop"}},
+		{"prefix", QueryOptions{SortKeyPrefix: "web_"}, []string{"phone", "laptop"}},
+		{"limit", QueryOptions{SortKeyPrefix: "web_", Limit: 1}, []string{"phone"}},
+		{"no match", QueryOptions{SortKeyPrefix: "tv_"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := Query[session](ctx, store, "user1", tt.options)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			if len(items) != len(tt.want) {
+				t.Fatalf("Query() returned %d items, want %d", len(items), len(tt.want))
+			}
+
+			for i, item := range items {
+				if item.Value.Device != tt.want[i] || item.Key != "user1" {
+					t.Errorf("Query()[%d] = %+v, want %s", i, item, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if err := store.Delete(ctx, "user1", "web_1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if value, _, _ := store.Get(ctx, "user1", GetOptions{SortKey: "web_1"}); value != "" {
+		t.Errorf("Get() after Delete = %q, want empty", value)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	t.Setenv("KV_BACKEND", "memory")
+
+	store, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, ok := store.(*MemoryStore); !ok {
+		t.Errorf("New() = %T, want the KV_BACKEND backend", store)
+	}
+
+	if _, err := New(StoreOptions{Backend: "cassandra"}); err == nil {
+		t.Error("New() with an unknown backend should fail")
+	}
+}
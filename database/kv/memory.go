@@ -0,0 +1,109 @@
+package kv
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryKey struct {
+	key     string
+	sortKey string
+}
+
+// MemoryStore keeps items in process memory. Expired items are skipped by reads and
+// replaced by writes.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items map[memoryKey]Item[string]
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[memoryKey]Item[string])}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string, options ...GetOptions) (string, *time.Time, error) {
+	opts := getGetOptions(options...)
+
+	s.mu.RLock()
+	item, ok := s.items[memoryKey{key, opts.SortKey}]
+	s.mu.RUnlock()
+
+	if !ok || expired(item) {
+		return "", nil, nil
+	}
+
+	return item.Value, item.Expiry, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, value any, options ...PutOptions) error {
+	opts := getPutOptions(options...)
+
+	payload, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[memoryKey{key, opts.SortKey}] = Item[string]{
+		Value:   payload,
+		Expiry:  expiryOf(opts.Ttl),
+		Key:     key,
+		SortKey: opts.SortKey,
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Query(ctx context.Context, key string, options ...QueryOptions) ([]Item[string], error) {
+	opts := getQueryOptions(options...)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []Item[string]
+
+	for k, item := range s.items {
+		if k.key == key && strings.HasPrefix(k.sortKey, opts.SortKeyPrefix) && !expired(item) {
+			items = append(items, item)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b Item[string]) int {
+		return strings.Compare(a.SortKey, b.SortKey)
+	})
+
+	if opts.Limit > 0 && len(items) > opts.Limit {
+		items = items[:opts.Limit]
+	}
+
+	return items, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string, sortKey ...string) error {
+	sk := ""
+	if len(sortKey) > 0 {
+		sk = sortKey[0]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, memoryKey{key, sk})
+
+	return nil
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func expired(item Item[string]) bool {
+	return item.Expiry != nil && time.Now().After(*item.Expiry)
+}
@@ -0,0 +1,197 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// sortKeySeparator joins the key and sort key of an item into its redis key
+const sortKeySeparator = "#"
+
+// redisStore stores each item as a redis key made of the prefix, the key and the sort key,
+// expiring with the item
+type redisStore struct {
+	db     *redis.RedisDB
+	prefix string
+}
+
+func newRedisStore(opts StoreOptions) (Store, error) {
+	db, err := redis.New(opts.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redis store: %w", err)
+	}
+
+	return NewRedisStore(db, opts.Prefix), nil
+}
+
+// NewRedisStore wraps a redis database as a Store, namespacing its keys with prefix
+func NewRedisStore(db *redis.RedisDB, prefix string) Store {
+	return &redisStore{db: db, prefix: prefix}
+}
+
+func (s *redisStore) redisKey(key, sortKey string) string {
+	if sortKey == "" {
+		return s.prefix + key
+	}
+	return s.prefix + key + sortKeySeparator + sortKey
+}
+
+func (s *redisStore) Get(ctx context.Context, key string, options ...GetOptions) (string, *time.Time, error) {
+	opts := getGetOptions(options...)
+
+	values, err := s.read(ctx, []string{s.redisKey(key, opts.SortKey)})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(values) == 0 {
+		return "", nil, nil
+	}
+
+	return values[0].Value, values[0].Expiry, nil
+}
+
+func (s *redisStore) Put(ctx context.Context, key string, value any, options ...PutOptions) error {
+	opts := getPutOptions(options...)
+
+	payload, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Client().Set(ctx, s.redisKey(key, opts.SortKey), payload, opts.Ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write value to redis: %w", err)
+	}
+
+	return nil
+}
+
+func (s *redisStore) Query(ctx context.Context, key string, options ...QueryOptions) ([]Item[string], error) {
+	opts := getQueryOptions(options...)
+
+	base := s.redisKey(key, "") + sortKeySeparator
+	pattern := escapeGlob(base+opts.SortKeyPrefix) + "*"
+
+	var keys []string
+
+	iter := s.db.Client().Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan keys from redis: %w", err)
+	}
+
+	// Like dynamo, where such items have a default sort key, items without a sort key are
+	// part of the query
+	if opts.SortKeyPrefix == "" {
+		keys = append(keys, s.redisKey(key, ""))
+	}
+
+	// SCAN can return a key more than once
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	items, err := s.read(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		if items[i].Key != s.redisKey(key, "") {
+			items[i].SortKey = strings.TrimPrefix(items[i].Key, base)
+		}
+		items[i].Key = key
+	}
+
+	if opts.Limit > 0 && len(items) > opts.Limit {
+		items = items[:opts.Limit]
+	}
+
+	return items, nil
+}
+
+// read returns the values and expiries of the keys that exist, in order. Items carry their
+// redis key until the caller sets the item keys.
+func (s *redisStore) read(ctx context.Context, keys []string) ([]Item[string], error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := s.db.Client().Pipeline()
+
+	gets := make([]*goredis.StringCmd, len(keys))
+	ttls := make([]*goredis.DurationCmd, len(keys))
+
+	for i, key := range keys {
+		gets[i] = pipe.Get(ctx, key)
+		ttls[i] = pipe.PTTL(ctx, key)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("failed to get values from redis: %w", err)
+	}
+
+	items := make([]Item[string], 0, len(keys))
+
+	for i, key := range keys {
+		value, err := gets[i].Result()
+		if errors.Is(err, goredis.Nil) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get value from redis: %w", err)
+		}
+
+		item := Item[string]{Value: value, Key: key}
+
+		// PTTL is negative for keys without an expiry
+		if ttl := ttls[i].Val(); ttl > 0 {
+			item.Expiry = expiryOf(ttl)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *redisStore) Delete(ctx context.Context, key string, sortKey ...string) error {
+	sk := ""
+	if len(sortKey) > 0 {
+		sk = sortKey[0]
+	}
+
+	if err := s.db.Client().Del(ctx, s.redisKey(key, sk)).Err(); err != nil {
+		return fmt.Errorf("failed to delete key from redis: %w", err)
+	}
+
+	return nil
+}
+
+func (s *redisStore) Close() error {
+	return s.db.Close()
+}
+
+// escapeGlob escapes the characters redis treats as wildcards in SCAN patterns
+func escapeGlob(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
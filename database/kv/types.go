@@ -0,0 +1,65 @@
+package kv
+
+import (
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	"github.com/finch-technologies/go-utils/database/redis"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Backend selects the store New connects to
+type Backend string
+
+const (
+	// BackendDynamo stores items in a DynamoDB table in JSON value store mode
+	BackendDynamo Backend = "dynamo"
+	// BackendRedis stores items as redis keys
+	BackendRedis Backend = "redis"
+	// BackendMemory keeps items in process memory, for tests and local runs
+	BackendMemory Backend = "memory"
+)
+
+// Backends are the valid backends
+var Backends = utils.NewEnum(BackendDynamo, BackendRedis, BackendMemory)
+
+func (b *Backend) UnmarshalJSON(data []byte) error {
+	return Backends.DecodeJSON(data, b)
+}
+
+func (b *Backend) UnmarshalText(text []byte) error {
+	return Backends.DecodeText(text, b)
+}
+
+// StoreOptions selects and configures the backend of a Store
+type StoreOptions struct {
+	Backend Backend          // Backend to use (default KV_BACKEND, or dynamo)
+	Dynamo  dynamo.DbOptions // Table options for the dynamo backend; the value store mode is always json
+	Redis   redis.DbOptions  // Client options for the redis backend
+	Prefix  string           // Prefix of the keys of the redis backend, e.g. "sessions:" (optional)
+}
+
+// GetOptions contains options for Get
+type GetOptions struct {
+	SortKey string // Sort key of the item (optional)
+}
+
+// PutOptions contains options for Put
+type PutOptions struct {
+	SortKey string        // Sort key of the item (optional)
+	Ttl     time.Duration // How long the item lives (default the backend's default, or forever)
+}
+
+// QueryOptions contains options for Query
+type QueryOptions struct {
+	SortKeyPrefix string // Only return items whose sort key starts with this prefix (optional)
+	Limit         int    // Maximum number of items to return (0 = no limit)
+}
+
+// Item is an item returned by Query
+type Item[T any] struct {
+	Value   T
+	Expiry  *time.Time // When the item expires, nil if it doesn't
+	Key     string
+	SortKey string
+}
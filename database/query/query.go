@@ -0,0 +1,411 @@
+// Package query is a small backend-neutral query language. Business code builds a query
+// once, e.g. query.And(query.Field("status").Eq("active"), query.Field("age").Gt(18)), and
+// each database package translates it: dynamo into key condition and filter expressions,
+// redis and in-memory stores by filtering decoded values with Match.
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrUnsupported is returned when a backend can't evaluate part of a query
+var ErrUnsupported = errors.New("unsupported query")
+
+// Op is a comparison operator
+type Op string
+
+const (
+	OpEq         Op = "="
+	OpNe         Op = "<>"
+	OpLt         Op = "<"
+	OpLe         Op = "<="
+	OpGt         Op = ">"
+	OpGe         Op = ">="
+	OpBeginsWith Op = "begins_with"
+	OpContains   Op = "contains"   // Substring of a string, or element of a list
+	OpBetween    Op = "between"    // Value is a [2]any of the inclusive bounds
+	OpIn         Op = "in"         // Value is a []any of allowed values
+	OpExists     Op = "exists"     // Value is ignored
+	OpNotExists  Op = "not_exists" // Value is ignored
+)
+
+// Expr is a query expression, either a Condition or a Group
+type Expr interface {
+	expr()
+}
+
+// Condition compares a field with a value. Nested fields are separated with dots, e.g.
+// "address.city".
+type Condition struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// Logic combines the expressions of a Group
+type Logic string
+
+const (
+	LogicAnd Logic = "and"
+	LogicOr  Logic = "or"
+)
+
+// Group combines expressions with and/or
+type Group struct {
+	Logic Logic
+	Exprs []Expr
+}
+
+func (Condition) expr() {}
+func (Group) expr()     {}
+
+// Field starts a condition on a field
+//
+// Example:
+//
+//	q := query.Or(query.Field("status").Eq("active"), query.Field("trial_ends").Gt(now))
+type Field string
+
+// Eq matches when the field equals value
+func (f Field) Eq(value any) Condition {
+	return Condition{Field: string(f), Op: OpEq, Value: value}
+}
+
+// Ne matches when the field doesn't equal value
+func (f Field) Ne(value any) Condition {
+	return Condition{Field: string(f), Op: OpNe, Value: value}
+}
+
+// Lt matches when the field is less than value
+func (f Field) Lt(value any) Condition {
+	return Condition{Field: string(f), Op: OpLt, Value: value}
+}
+
+// Le matches when the field is less than or equal to value
+func (f Field) Le(value any) Condition {
+	return Condition{Field: string(f), Op: OpLe, Value: value}
+}
+
+// Gt matches when the field is greater than value
+func (f Field) Gt(value any) Condition {
+	return Condition{Field: string(f), Op: OpGt, Value: value}
+}
+
+// Ge matches when the field is greater than or equal to value
+func (f Field) Ge(value any) Condition {
+	return Condition{Field: string(f), Op: OpGe, Value: value}
+}
+
+// BeginsWith matches when the field is a string starting with prefix
+func (f Field) BeginsWith(prefix string) Condition {
+	return Condition{Field: string(f), Op: OpBeginsWith, Value: prefix}
+}
+
+// Contains matches when the field is a string containing value, or a list with value in it
+func (f Field) Contains(value any) Condition {
+	return Condition{Field: string(f), Op: OpContains, Value: value}
+}
+
+// Between matches when the field is between low and high, inclusive
+func (f Field) Between(low, high any) Condition {
+	return Condition{Field: string(f), Op: OpBetween, Value: [2]any{low, high}}
+}
+
+// In matches when the field equals one of values
+func (f Field) In(values ...any) Condition {
+	return Condition{Field: string(f), Op: OpIn, Value: values}
+}
+
+// Exists matches when the field is set
+func (f Field) Exists() Condition {
+	return Condition{Field: string(f), Op: OpExists}
+}
+
+// NotExists matches when the field isn't set
+func (f Field) NotExists() Condition {
+	return Condition{Field: string(f), Op: OpNotExists}
+}
+
+// And matches when all exprs match
+func And(exprs ...Expr) Group {
+	return Group{Logic: LogicAnd, Exprs: exprs}
+}
+
+// Or matches when any of exprs matches
+func Or(exprs ...Expr) Group {
+	return Group{Logic: LogicOr, Exprs: exprs}
+}
+
+// Match reports whether value matches expr. Value is a struct, a map or the JSON encoding of
+// one; fields are compared by their JSON names. A nil expr matches everything.
+//
+// Example:
+//
+//	ok, err := query.Match(q, user)
+func Match(expr Expr, value any) (bool, error) {
+	if expr == nil {
+		return true, nil
+	}
+
+	item, err := document(value)
+	if err != nil {
+		return false, err
+	}
+
+	return match(expr, item)
+}
+
+// Filter returns the values matching expr
+func Filter[T any](expr Expr, values []T) ([]T, error) {
+	var matched []T
+
+	for _, value := range values {
+		ok, err := Match(expr, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			matched = append(matched, value)
+		}
+	}
+
+	return matched, nil
+}
+
+func match(expr Expr, item map[string]any) (bool, error) {
+	switch e := expr.(type) {
+	case Condition:
+		return matchCondition(e, item)
+	case Group:
+		for _, sub := range e.Exprs {
+			ok, err := match(sub, item)
+			if err != nil {
+				return false, err
+			}
+
+			if e.Logic == LogicOr && ok {
+				return true, nil
+			}
+
+			if e.Logic != LogicOr && !ok {
+				return false, nil
+			}
+		}
+
+		// An empty and matches everything, an empty or nothing
+		return e.Logic != LogicOr, nil
+	default:
+		return false, fmt.Errorf("%w: expression %T", ErrUnsupported, expr)
+	}
+}
+
+func matchCondition(c Condition, item map[string]any) (bool, error) {
+	actual, found := lookup(item, c.Field)
+
+	switch c.Op {
+	case OpExists:
+		return found, nil
+	case OpNotExists:
+		return !found, nil
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	switch c.Op {
+	case OpEq, OpNe, OpLt, OpLe, OpGt, OpGe:
+		expected, err := normalize(c.Value)
+		if err != nil {
+			return false, err
+		}
+
+		if c.Op == OpEq {
+			return reflect.DeepEqual(actual, expected), nil
+		}
+
+		if c.Op == OpNe {
+			return !reflect.DeepEqual(actual, expected), nil
+		}
+
+		cmp, ok := compare(actual, expected)
+		if !ok {
+			return false, nil
+		}
+
+		switch c.Op {
+		case OpLt:
+			return cmp < 0, nil
+		case OpLe:
+			return cmp <= 0, nil
+		case OpGt:
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case OpBeginsWith:
+		s, ok := actual.(string)
+		prefix, _ := c.Value.(string)
+		return ok && strings.HasPrefix(s, prefix), nil
+	case OpContains:
+		expected, err := normalize(c.Value)
+		if err != nil {
+			return false, err
+		}
+
+		switch a := actual.(type) {
+		case string:
+			s, ok := expected.(string)
+			return ok && strings.Contains(a, s), nil
+		case []any:
+			for _, element := range a {
+				if reflect.DeepEqual(element, expected) {
+					return true, nil
+				}
+			}
+		}
+
+		return false, nil
+	case OpBetween:
+		bounds, ok := c.Value.([2]any)
+		if !ok {
+			return false, fmt.Errorf("%w: between on %s needs [2]any bounds", ErrUnsupported, c.Field)
+		}
+
+		low, err := normalize(bounds[0])
+		if err != nil {
+			return false, err
+		}
+
+		high, err := normalize(bounds[1])
+		if err != nil {
+			return false, err
+		}
+
+		lowCmp, lowOk := compare(actual, low)
+		highCmp, highOk := compare(actual, high)
+
+		return lowOk && highOk && lowCmp >= 0 && highCmp <= 0, nil
+	case OpIn:
+		values, ok := c.Value.([]any)
+		if !ok {
+			return false, fmt.Errorf("%w: in on %s needs []any values", ErrUnsupported, c.Field)
+		}
+
+		for _, value := range values {
+			expected, err := normalize(value)
+			if err != nil {
+				return false, err
+			}
+
+			if reflect.DeepEqual(actual, expected) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: operator %q", ErrUnsupported, c.Op)
+	}
+}
+
+// lookup returns the value of a dotted field path
+func lookup(item map[string]any, field string) (any, bool) {
+	var current any = item
+
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// compare orders two normalized numbers or strings
+func compare(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case a < b:
+			return -1, true
+		case a > b:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(a, b), true
+	default:
+		return 0, false
+	}
+}
+
+// document converts a value into its JSON object form
+func document(value any) (map[string]any, error) {
+	var data []byte
+
+	switch v := value.(type) {
+	case map[string]any:
+		return v, nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		data, err = json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+	}
+
+	var item map[string]any
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return item, nil
+}
+
+// normalize converts a condition value to the form JSON decoding gives, so 5 equals 5.0 and
+// times compare as their JSON strings
+func normalize(value any) (any, error) {
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return value, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query value: %w", err)
+	}
+
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query value: %w", err)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,95 @@
+package query
+
+import (
+	"errors"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type user struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Age     int      `json:"age"`
+	Tags    []string `json:"tags"`
+	Address address  `json:"address"`
+	Manager *string  `json:"manager,omitempty"`
+}
+
+func TestMatch(t *testing.T) {
+	u := user{Name: "Thandi", Status: "active", Age: 34, Tags: []string{"admin", "beta"}, Address: address{City: "Cape Town"}}
+
+	tests := []struct {
+		name string
+		expr Expr
+		want bool
+	}{
+		{name: "nil", expr: nil, want: true},
+		{name: "eq", expr: Field("status").Eq("active"), want: true},
+		{name: "eq int", expr: Field("age").Eq(34), want: true},
+		{name: "ne", expr: Field("status").Ne("active"), want: false},
+		{name: "gt", expr: Field("age").Gt(30), want: true},
+		{name: "le", expr: Field("age").Le(33), want: false},
+		{name: "string order", expr: Field("name").Lt("Z"), want: true},
+		{name: "begins with", expr: Field("name").BeginsWith("Tha"), want: true},
+		{name: "contains string", expr: Field("name").Contains("and"), want: true},
+		{name: "contains list", expr: Field("tags").Contains("beta"), want: true},
+		{name: "between", expr: Field("age").Between(18, 34), want: true},
+		{name: "in", expr: Field("status").In("pending", "active"), want: true},
+		{name: "not in", expr: Field("status").In("pending", "disabled"), want: false},
+		{name: "nested", expr: Field("address.city").Eq("Cape Town"), want: true},
+		{name: "exists", expr: Field("manager").Exists(), want: false},
+		{name: "not exists", expr: Field("manager").NotExists(), want: true},
+		{name: "missing field", expr: Field("missing").Eq("x"), want: false},
+		{name: "and", expr: And(Field("status").Eq("active"), Field("age").Lt(30)), want: false},
+		{name: "or", expr: Or(Field("status").Eq("disabled"), Field("age").Ge(34)), want: true},
+		{name: "empty and", expr: And(), want: true},
+		{name: "empty or", expr: Or(), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.expr, u)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch_JSON(t *testing.T) {
+	got, err := Match(Field("status").Eq("active"), `{"status":"active"}`)
+	if err != nil || !got {
+		t.Errorf("Match() = %v, %v, want true", got, err)
+	}
+
+	if _, err := Match(Field("status").Eq("active"), "not json"); err == nil {
+		t.Error("expected an error for a value that isn't a JSON object")
+	}
+}
+
+func TestMatch_Unsupported(t *testing.T) {
+	_, err := Match(Condition{Field: "status", Op: "like", Value: "a%"}, map[string]any{"status": "active"})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Match() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	users := []user{{Name: "a", Age: 10}, {Name: "b", Age: 20}, {Name: "c", Age: 30}}
+
+	got, err := Filter(Field("age").Ge(20), users)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0].Name != "b" || got[1].Name != "c" {
+		t.Errorf("Filter() = %+v", got)
+	}
+}
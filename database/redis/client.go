@@ -1,33 +1,94 @@
 package redis
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var redisClientMap map[int]*redis.Client = make(map[int]*redis.Client)
+// ClientConfig holds the connection settings for a redis client
+type ClientConfig struct {
+	Host     string
+	Port     string
+	Password string
+	Db       int
+	TLS      bool
+}
+
+var (
+	redisClientMu  sync.Mutex
+	redisClientMap map[int]*redis.Client = make(map[int]*redis.Client)
+)
 
+// ConfigFromEnv returns a client config for db using REDIS_HOST, REDIS_PORT,
+// REDIS_PASSWORD and REDIS_SCHEME
+func ConfigFromEnv(db int) ClientConfig {
+	return ClientConfig{
+		Host:     os.Getenv("REDIS_HOST"),
+		Port:     os.Getenv("REDIS_PORT"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		Db:       db,
+		TLS:      os.Getenv("REDIS_SCHEME") == "tls",
+	}
+}
+
+// NewClient creates a new redis client from an explicit config. The caller owns the
+// client and is responsible for closing it.
+func NewClient(cfg ClientConfig) *redis.Client {
+	options := &redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.Db,
+	}
+
+	if cfg.TLS {
+		options.TLSConfig = &tls.Config{}
+	}
+
+	return redis.NewClient(options)
+}
+
+// GetRedisClient returns a shared client for db configured from the environment
 func GetRedisClient(db int) *redis.Client {
+	redisClientMu.Lock()
+	defer redisClientMu.Unlock()
 
 	redisClient := redisClientMap[db]
 
 	if redisClient == nil {
-		options := &redis.Options{
-			Addr:     fmt.Sprintf("%s:%s", os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT")),
-			Password: os.Getenv("REDIS_PASSWORD"),
-			DB:       db,
-		}
-
-		if os.Getenv("REDIS_SCHEME") == "tls" {
-			options.TLSConfig = &tls.Config{}
-		}
-
-		redisClient = redis.NewClient(options)
+		redisClient = NewClient(ConfigFromEnv(db))
 		redisClientMap[db] = redisClient
 	}
 
 	return redisClient
 }
+
+// HealthCheck pings the redis server
+func HealthCheck(ctx context.Context, client *redis.Client) error {
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis health check failed: %w", err)
+	}
+	return nil
+}
+
+// CloseClients closes every shared client returned by GetRedisClient
+func CloseClients() error {
+	redisClientMu.Lock()
+	defer redisClientMu.Unlock()
+
+	var errs []error
+
+	for db, client := range redisClientMap {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close redis client for db %d: %w", db, err))
+		}
+		delete(redisClientMap, db)
+	}
+
+	return errors.Join(errs...)
+}
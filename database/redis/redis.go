@@ -8,13 +8,15 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/finch-technologies/go-utils/database/query"
 	"github.com/finch-technologies/go-utils/log"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisDB struct {
-	rdb *redis.Client
+	rdb   *redis.Client
+	owned bool // whether the client was created for this instance and should be closed with it
 }
 
 func getOptions(options ...DbOptions) DbOptions {
@@ -30,11 +32,43 @@ func New(options ...DbOptions) (*RedisDB, error) {
 
 	opts := getOptions(options...)
 
+	if opts.Client != nil {
+		return &RedisDB{
+			rdb: opts.Client,
+		}, nil
+	}
+
+	if opts.Config != nil {
+		return &RedisDB{
+			rdb:   NewClient(*opts.Config),
+			owned: true,
+		}, nil
+	}
+
 	return &RedisDB{
 		rdb: GetRedisClient(opts.Db),
 	}, nil
 }
 
+// Client returns the underlying redis client
+func (r *RedisDB) Client() *redis.Client {
+	return r.rdb
+}
+
+// HealthCheck pings the redis server
+func (r *RedisDB) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, r.rdb)
+}
+
+// Close closes the client if it was created from DbOptions.Config. Shared and injected
+// clients are left open since other users may still rely on them.
+func (r *RedisDB) Close() error {
+	if !r.owned {
+		return nil
+	}
+	return r.rdb.Close()
+}
+
 func (r *RedisDB) GetString(key string) (string, error) {
 	val, err := r.rdb.Get(context.Background(), key).Result()
 
@@ -142,3 +176,53 @@ func (r *RedisDB) GetListWithPrefix(id string, skPrefix string, limit int64) ([]
 
 	return result, nil
 }
+
+// FindWithPrefix works like GetListWithPrefix but only returns JSON values matching where,
+// filtering before the limit is applied. Values that aren't JSON objects never match.
+//
+// Example:
+//
+//	values, err := db.FindWithPrefix("company1", "user#", query.Field("status").Eq("active"), 50)
+func (r *RedisDB) FindWithPrefix(id string, skPrefix string, where query.Expr, limit int64) ([]string, error) {
+	ctx := context.Background()
+
+	keys, err := r.rdb.Keys(ctx, id+"*"+skPrefix+"*").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys from redis: %s", err)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := r.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values from redis: %s", err)
+	}
+
+	var result []string
+	for _, v := range values {
+		vStr, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		matched, err := query.Match(where, vStr)
+		if err != nil {
+			if errors.Is(err, query.ErrUnsupported) {
+				return nil, err
+			}
+			continue
+		}
+
+		if matched {
+			result = append(result, vStr)
+		}
+
+		if limit > 0 && int64(len(result)) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
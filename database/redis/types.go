@@ -1,5 +1,9 @@
 package redis
 
+import "github.com/redis/go-redis/v9"
+
 type DbOptions struct {
-	Db int
+	Db     int
+	Client *redis.Client // Use an existing client instead of the shared client for Db
+	Config *ClientConfig // Create a dedicated client from this config instead of the environment
 }
@@ -0,0 +1,288 @@
+// Package discovery resolves internal service endpoints from env, Cloud Map or a dynamo
+// table, caches them and orders them by health so callers fail over to a working instance.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrNoEndpoints is returned when no source knows about a service
+var ErrNoEndpoints = errors.New("no endpoints found for service")
+
+// Endpoint is a single instance of a service
+type Endpoint struct {
+	URL      string `json:"url"`      // Base URL of the instance, e.g. "http://10.0.1.12:8080"
+	Priority int    `json:"priority"` // Lower values are preferred when several instances are healthy
+	Zone     string `json:"zone"`     // Availability zone or region of the instance (optional)
+}
+
+// Source looks up the endpoints of a service
+type Source interface {
+	Resolve(ctx context.Context, service string) ([]Endpoint, error)
+}
+
+// HealthCheckFunc reports whether an endpoint is able to serve requests
+type HealthCheckFunc func(ctx context.Context, endpoint Endpoint) error
+
+// Options configures a resolver
+type Options struct {
+	Sources        []Source        // Sources tried in order, the first to return endpoints wins (default env)
+	CacheTtl       time.Duration   // How long resolved endpoints are cached (default 1m)
+	HealthPath     string          // Path requested by the default health check, which expects a 2xx or 3xx status (default "/health")
+	HealthTimeout  time.Duration   // Timeout for a single health check (default 2s)
+	HealthInterval time.Duration   // How long a health result is trusted (default 15s)
+	FailureBackoff time.Duration   // How long an endpoint is demoted after MarkFailed (default 30s)
+	HealthCheck    HealthCheckFunc // Custom health check, replaces the HTTP check on HealthPath
+}
+
+type endpointState struct {
+	healthy     bool
+	checkedAt   time.Time
+	failedUntil time.Time
+}
+
+type serviceEntry struct {
+	endpoints []Endpoint
+	expires   time.Time
+}
+
+// Resolver resolves and health-checks service endpoints
+type Resolver struct {
+	opts Options
+
+	mu       sync.Mutex
+	services map[string]*serviceEntry
+	states   map[string]*endpointState
+}
+
+func getOptions(options ...Options) Options {
+	opts := Options{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if len(opts.Sources) == 0 {
+		opts.Sources = []Source{EnvSource{}}
+	}
+
+	opts.CacheTtl = utils.DurationOrDefault(opts.CacheTtl, time.Minute)
+	opts.HealthPath = utils.StringOrDefault(opts.HealthPath, "/health")
+	opts.HealthTimeout = utils.DurationOrDefault(opts.HealthTimeout, 2*time.Second)
+	opts.HealthInterval = utils.DurationOrDefault(opts.HealthInterval, 15*time.Second)
+	opts.FailureBackoff = utils.DurationOrDefault(opts.FailureBackoff, 30*time.Second)
+
+	return opts
+}
+
+// New creates a resolver
+//
+// Example:
+//
+//	resolver := discovery.New(discovery.Options{
+//	    Sources: []discovery.Source{discovery.EnvSource{}, discovery.DynamoSource{TableName: "services"}},
+//	})
+//	endpoint, err := resolver.Endpoint(ctx, "billing")
+func New(options ...Options) *Resolver {
+	r := &Resolver{
+		opts:     getOptions(options...),
+		services: make(map[string]*serviceEntry),
+		states:   make(map[string]*endpointState),
+	}
+
+	if r.opts.HealthCheck == nil {
+		r.opts.HealthCheck = r.httpHealthCheck
+	}
+
+	return r
+}
+
+// Endpoints returns all known endpoints of a service in failover order: healthy endpoints
+// first, ordered by priority, followed by unhealthy ones as a last resort
+func (r *Resolver) Endpoints(ctx context.Context, service string) ([]Endpoint, error) {
+	endpoints, err := r.resolve(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	r.checkHealth(ctx, endpoints)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	ordered := make([]Endpoint, len(endpoints))
+	copy(ordered, endpoints)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		hi, hj := r.isHealthy(ordered[i], now), r.isHealthy(ordered[j], now)
+		if hi != hj {
+			return hi
+		}
+		return ordered[i].Priority < ordered[j].Priority
+	})
+
+	return ordered, nil
+}
+
+// Endpoint returns the preferred endpoint of a service
+func (r *Resolver) Endpoint(ctx context.Context, service string) (Endpoint, error) {
+	endpoints, err := r.Endpoints(ctx, service)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	return endpoints[0], nil
+}
+
+// MarkFailed demotes an endpoint after a failed request so it is tried last until the
+// failure backoff passes
+func (r *Resolver) MarkFailed(endpoint Endpoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.state(endpoint)
+	state.healthy = false
+	state.failedUntil = time.Now().Add(r.opts.FailureBackoff)
+}
+
+// Invalidate drops the cached endpoints of a service so the next call resolves it again
+func (r *Resolver) Invalidate(service string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.services, service)
+}
+
+// resolve returns the cached endpoints of a service, refreshing them from the sources when
+// the cache expired. Stale endpoints are kept if every source fails.
+func (r *Resolver) resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	r.mu.Lock()
+	entry := r.services[service]
+	r.mu.Unlock()
+
+	if entry != nil && time.Now().Before(entry.expires) {
+		return entry.endpoints, nil
+	}
+
+	var errs []error
+
+	for _, source := range r.opts.Sources {
+		endpoints, err := source.Resolve(ctx, service)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if len(endpoints) == 0 {
+			continue
+		}
+
+		r.mu.Lock()
+		r.services[service] = &serviceEntry{
+			endpoints: endpoints,
+			expires:   time.Now().Add(r.opts.CacheTtl),
+		}
+		r.mu.Unlock()
+
+		return endpoints, nil
+	}
+
+	if entry != nil {
+		log.Warningf("Failed to refresh endpoints for service %s, using cached endpoints: %v", service, errors.Join(errs...))
+		return entry.endpoints, nil
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to resolve service %s: %w", service, errors.Join(errs...))
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoEndpoints, service)
+}
+
+// checkHealth runs the health check concurrently on every endpoint whose last result is
+// older than the health interval
+func (r *Resolver) checkHealth(ctx context.Context, endpoints []Endpoint) {
+	now := time.Now()
+
+	var stale []Endpoint
+
+	r.mu.Lock()
+	for _, endpoint := range endpoints {
+		state := r.state(endpoint)
+		if now.Sub(state.checkedAt) >= r.opts.HealthInterval {
+			stale = append(stale, endpoint)
+		}
+	}
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, endpoint := range stale {
+		wg.Add(1)
+
+		go func(endpoint Endpoint) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.opts.HealthTimeout)
+			defer cancel()
+
+			err := r.opts.HealthCheck(checkCtx, endpoint)
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			state := r.state(endpoint)
+			state.healthy = err == nil
+			state.checkedAt = time.Now()
+		}(endpoint)
+	}
+
+	wg.Wait()
+}
+
+func (r *Resolver) isHealthy(endpoint Endpoint, now time.Time) bool {
+	state := r.state(endpoint)
+	return state.healthy && now.After(state.failedUntil)
+}
+
+// state must be called with r.mu held
+func (r *Resolver) state(endpoint Endpoint) *endpointState {
+	state := r.states[endpoint.URL]
+
+	if state == nil {
+		state = &endpointState{}
+		r.states[endpoint.URL] = state
+	}
+
+	return state
+}
+
+func (r *Resolver) httpHealthCheck(ctx context.Context, endpoint Endpoint) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint.URL, "/")+r.opts.HealthPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
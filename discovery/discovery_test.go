@@ -0,0 +1,150 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingSource struct{}
+
+func (failingSource) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return nil, errors.New("source unavailable")
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("SERVICE_BILLING_API_URL", "http://a:8080, http://b:8080")
+
+	endpoints, err := EnvSource{}.Resolve(context.Background(), "billing-api")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	if len(endpoints) != 2 || endpoints[0].URL != "http://a:8080" || endpoints[1].Priority != 1 {
+		t.Errorf("Resolve() = %+v", endpoints)
+	}
+}
+
+func TestResolver_FailoverOrdering(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	resolver := New(Options{
+		Sources: []Source{
+			failingSource{},
+			StaticSource{"billing": {
+				{URL: unhealthy.URL, Priority: 0},
+				{URL: healthy.URL, Priority: 1},
+			}},
+		},
+	})
+
+	endpoint, err := resolver.Endpoint(context.Background(), "billing")
+	if err != nil {
+		t.Fatalf("Endpoint() error = %v", err)
+	}
+
+	if endpoint.URL != healthy.URL {
+		t.Errorf("Endpoint() = %s, want healthy endpoint %s", endpoint.URL, healthy.URL)
+	}
+
+	resolver.MarkFailed(endpoint)
+
+	endpoints, err := resolver.Endpoints(context.Background(), "billing")
+	if err != nil {
+		t.Fatalf("Endpoints() error = %v", err)
+	}
+
+	if len(endpoints) != 2 || endpoints[0].URL != unhealthy.URL {
+		t.Errorf("Endpoints() after MarkFailed = %+v, want priority order", endpoints)
+	}
+}
+
+func TestResolver_CachesEndpoints(t *testing.T) {
+	calls := 0
+	source := sourceFunc(func(ctx context.Context, service string) ([]Endpoint, error) {
+		calls++
+		return []Endpoint{{URL: "http://a"}}, nil
+	})
+
+	resolver := New(Options{
+		Sources:     []Source{source},
+		CacheTtl:    time.Minute,
+		HealthCheck: func(ctx context.Context, endpoint Endpoint) error { return nil },
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.Endpoint(context.Background(), "billing"); err != nil {
+			t.Fatalf("Endpoint() error = %v", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("source called %d times, want 1", calls)
+	}
+
+	resolver.Invalidate("billing")
+
+	if _, err := resolver.Endpoint(context.Background(), "billing"); err != nil {
+		t.Fatalf("Endpoint() error = %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("source called %d times after Invalidate, want 2", calls)
+	}
+}
+
+func TestResolver_NoEndpoints(t *testing.T) {
+	resolver := New(Options{Sources: []Source{StaticSource{}}})
+
+	_, err := resolver.Endpoint(context.Background(), "missing")
+	if !errors.Is(err, ErrNoEndpoints) {
+		t.Errorf("Endpoint() error = %v, want ErrNoEndpoints", err)
+	}
+}
+
+type sourceFunc func(ctx context.Context, service string) ([]Endpoint, error)
+
+func (f sourceFunc) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return f(ctx, service)
+}
+
+func TestHttpHealthCheck(t *testing.T) {
+	tests := []struct {
+		status  int
+		healthy bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusNoContent, true},
+		{http.StatusNotModified, true},
+		{http.StatusNotFound, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			resolver := New(Options{Sources: []Source{StaticSource{}}})
+
+			err := resolver.httpHealthCheck(context.Background(), Endpoint{URL: server.URL})
+			if (err == nil) != tt.healthy {
+				t.Errorf("httpHealthCheck() error = %v, want healthy %v", err, tt.healthy)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+)
+
+// EnvSource resolves services from environment variables named <Prefix><SERVICE>_URL, e.g.
+// SERVICE_BILLING_URL="http://billing-a:8080,http://billing-b:8080". Endpoints are given
+// priorities in the order they are listed.
+type EnvSource struct {
+	Prefix string // Variable name prefix (default "SERVICE_")
+}
+
+func (s EnvSource) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "SERVICE_"
+	}
+
+	name := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(service)) + "_URL"
+
+	var endpoints []Endpoint
+
+	for _, url := range strings.Split(os.Getenv(name), ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+
+		endpoints = append(endpoints, Endpoint{URL: url, Priority: len(endpoints)})
+	}
+
+	return endpoints, nil
+}
+
+// DynamoSource resolves services from a dynamo table keyed by service name, where each
+// item holds the list of endpoints. The table must have been registered with dynamo.New.
+type DynamoSource struct {
+	TableName string
+}
+
+func (s DynamoSource) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	endpoints, _, err := dynamo.GetContext[[]Endpoint](ctx, s.TableName, service)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoints == nil {
+		return nil, nil
+	}
+
+	return *endpoints, nil
+}
+
+// CloudMapClient discovers the instances of a Cloud Map service. It is satisfied by a thin
+// adapter around servicediscovery.Client.DiscoverInstances, which keeps the AWS Cloud Map
+// SDK out of this module for callers that don't use it.
+type CloudMapClient interface {
+	DiscoverInstances(ctx context.Context, namespace, service string) ([]Endpoint, error)
+}
+
+// CloudMapSource resolves services registered in an AWS Cloud Map namespace
+type CloudMapSource struct {
+	Namespace string
+	Client    CloudMapClient
+}
+
+func (s CloudMapSource) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return s.Client.DiscoverInstances(ctx, s.Namespace, service)
+}
+
+// StaticSource resolves services from a fixed map, mostly useful for local development
+// and tests
+type StaticSource map[string][]Endpoint
+
+func (s StaticSource) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return s[service], nil
+}
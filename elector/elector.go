@@ -7,7 +7,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/finch-technologies/go-utils/database/dynamo"
 	"github.com/finch-technologies/go-utils/log"
 	"github.com/finch-technologies/go-utils/utils"
 
@@ -24,7 +23,10 @@ var (
 	rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 )
 
-var elector *Elector
+var (
+	elector   *Elector
+	electorMu sync.RWMutex // Guards elector for callers that may run before or alongside Start
+)
 
 // ElectorConfig holds configuration for the leader elector
 type ElectorConfig struct {
@@ -34,6 +36,24 @@ type ElectorConfig struct {
 	CheckInterval time.Duration
 	LeaseTimeout  time.Duration
 	KeyName       string
+	// ClockSkewTolerance is the maximum clock difference assumed between instances
+	// when validating the leader lease
+	ClockSkewTolerance time.Duration
+	// EventChannel is the pubsub channel leadership changes are published on.
+	// Leave empty to disable events.
+	EventChannel string
+	// OnLeadershipChange is called in its own goroutine whenever this instance's
+	// leadership status changes
+	OnLeadershipChange func(event LeadershipEvent)
+	// RandSource seeds the initial delay jitter. Set it to make election timing reproducible in tests.
+	RandSource rand.Source
+}
+
+// ResignOptions configures a voluntary leadership resignation
+type ResignOptions struct {
+	// Cooldown prevents this instance from re-acquiring leadership for the given
+	// duration after resigning. Zero means no cooldown.
+	Cooldown time.Duration
 }
 
 // Elector handles leader election using a distributed lock
@@ -48,17 +68,22 @@ type Elector struct {
 	mu             sync.RWMutex
 	tableName      string
 	isLeader       bool
+	term           int64
+	acquiredAt     time.Time
+	cooldownUntil  time.Time // Set by Resign, no leadership attempts are made before it
+	lease          *LeaseManager
 }
 
 // getDefaultConfig returns the default configuration
 func getDefaultConfig() ElectorConfig {
 	return ElectorConfig{
-		TableName:     "default",
-		MinDelay:      defaultMinDelay,
-		MaxDelay:      defaultMaxDelay,
-		CheckInterval: defaultInterval,
-		LeaseTimeout:  defaultLeaseTimeout,
-		KeyName:       defaultKeyName,
+		TableName:          "default",
+		MinDelay:           defaultMinDelay,
+		MaxDelay:           defaultMaxDelay,
+		CheckInterval:      defaultInterval,
+		LeaseTimeout:       defaultLeaseTimeout,
+		KeyName:            defaultKeyName,
+		ClockSkewTolerance: defaultClockSkewTolerance,
 	}
 }
 
@@ -77,11 +102,19 @@ func Start(opts ...ElectorConfig) error {
 	// Generate a unique instance ID
 	instanceID := uuid.New().String()
 
+	r := rng
+	if cfg.RandSource != nil {
+		r = rand.New(cfg.RandSource)
+	}
+
 	// Generate initial delay
-	initialDelay := generateInitialDelay(cfg.MinDelay, cfg.MaxDelay)
+	initialDelay := generateInitialDelay(r, cfg.MinDelay, cfg.MaxDelay)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	electorMu.Lock()
+	defer electorMu.Unlock()
+
 	elector = &Elector{
 		isLeader:     false,
 		instanceID:   instanceID,
@@ -89,6 +122,13 @@ func Start(opts ...ElectorConfig) error {
 		initialDelay: initialDelay,
 		ctx:          ctx,
 		cancel:       cancel,
+		lease: NewLeaseManager(LeaseConfig{
+			TableName:          cfg.TableName,
+			LeaseTimeout:       cfg.LeaseTimeout,
+			OwnerID:            instanceID,
+			ClockSkewTolerance: cfg.ClockSkewTolerance,
+			RandSource:         cfg.RandSource,
+		}),
 	}
 
 	initialTimer := time.NewTimer(initialDelay)
@@ -178,6 +218,12 @@ func attemptLeadership() (bool, error) {
 	default:
 	}
 
+	// Don't compete for leadership right after resigning
+	if inCooldown() {
+		log.Debug("Instance recently resigned, skipping leadership attempt")
+		return false, nil
+	}
+
 	// Check if there's already a leader
 	leader, err := getLeader()
 	if err != nil {
@@ -190,20 +236,7 @@ func attemptLeadership() (bool, error) {
 	}
 
 	// Try to set ourselves as the leader
-	err = dynamo.Put(elector.config.TableName, elector.config.KeyName, elector.instanceID, dynamo.PutOptions{
-		Ttl: elector.config.LeaseTimeout,
-	})
-	if err != nil {
-		return false, fmt.Errorf("failed to set leadership: %w", err)
-	}
-
-	// Verify that we actually became the leader
-	currentLeader, err := getLeader()
-	if err != nil {
-		return false, fmt.Errorf("failed to verify leadership: %w", err)
-	}
-
-	return currentLeader == elector.instanceID, nil
+	return elector.lease.Acquire(elector.config.KeyName)
 }
 
 // renewLeadership renews the leadership lease if still the leader
@@ -215,25 +248,8 @@ func renewLeadership() (bool, error) {
 	default:
 	}
 
-	// Check if we're still the leader
-	leader, err := getLeader()
-	if err != nil {
-		return false, fmt.Errorf("failed to get leader: %w", err)
-	}
-
-	if leader != elector.instanceID {
-		return false, nil
-	}
-
-	// Renew our lease
-	err = dynamo.Put(elector.config.TableName, elector.config.KeyName, elector.instanceID, dynamo.PutOptions{
-		Ttl: elector.config.LeaseTimeout,
-	})
-	if err != nil {
-		return false, fmt.Errorf("failed to renew lease: %w", err)
-	}
-
-	return true, nil
+	// Renew our lease if we're still the leader
+	return elector.lease.Renew(elector.config.KeyName)
 }
 
 // revokeLeadership releases leadership
@@ -257,7 +273,7 @@ func revokeLeadership() error {
 	}
 
 	// Delete the leader lock
-	err = dynamo.Delete(elector.config.TableName, elector.config.KeyName)
+	err = elector.lease.Release(elector.config.KeyName)
 
 	if err != nil {
 		return fmt.Errorf("failed to revoke leadership: %w", err)
@@ -288,7 +304,7 @@ func getLeader() (string, error) {
 	// }
 
 	// Query the database directly (no cache)
-	result, _, err := dynamo.GetString(elector.config.TableName, elector.config.KeyName)
+	result, err := elector.lease.GetOwner(elector.config.KeyName)
 
 	if err != nil {
 		// Check if context was cancelled during the operation
@@ -297,7 +313,7 @@ func getLeader() (string, error) {
 			return "", elector.ctx.Err()
 		default:
 		}
-		return "", fmt.Errorf("failed to get leader from store: %w", err)
+		return "", err
 	}
 
 	return result, nil
@@ -311,12 +327,16 @@ func setLeader(isLeader bool) {
 	oldStatus := elector.isLeader
 	elector.isLeader = isLeader
 
-	// Log status changes
+	// Log and publish status changes
 	if oldStatus != isLeader {
 		if isLeader {
-			log.Debugf("Instance %s became leader", elector.instanceID)
+			elector.term = elector.lease.Term(elector.config.KeyName)
+			elector.acquiredAt = time.Now().UTC()
+			log.Debugf("Instance %s became leader for term %d", elector.instanceID, elector.term)
+			notifyLeadershipChange(LeadershipAcquired, elector.term, elector.acquiredAt)
 		} else {
 			log.Debugf("Instance %s lost leadership", elector.instanceID)
+			notifyLeadershipChange(LeadershipLost, elector.term, elector.acquiredAt)
 		}
 	}
 }
@@ -344,24 +364,124 @@ func Stop() {
 	log.Info("Leader elector stopped")
 }
 
-func IsLeader() bool {
+// Resign proactively hands off leadership by deleting the lock instead of waiting
+// for the lease to expire, so another instance can take over straight away (e.g.
+// before shutting down during a deploy). The elector keeps running; with a cooldown
+// set, this instance will not attempt to re-acquire leadership until it passes.
+func Resign(ctx context.Context, options ...ResignOptions) error {
+	var opts ResignOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if opts.Cooldown > 0 {
+		// Start the cooldown before releasing the lock so the next election cycle can't win it back
+		elector.mu.Lock()
+		elector.cooldownUntil = time.Now().Add(opts.Cooldown)
+		elector.mu.Unlock()
+	}
+
+	if !IsLeader() {
+		return nil
+	}
+
+	log.Infof("Resigning leadership for instance: %s", elector.instanceID)
+
+	if err := revokeLeadership(); err != nil {
+		return fmt.Errorf("failed to resign leadership: %w", err)
+	}
+
+	return nil
+}
+
+// inCooldown checks whether this instance resigned within its cooldown
+func inCooldown() bool {
 	elector.mu.RLock()
 	defer elector.mu.RUnlock()
-	return elector.isLeader
+	return time.Now().Before(elector.cooldownUntil)
+}
+
+// IsLeader reports whether this instance is the leader. It is false before Start is called.
+func IsLeader() bool {
+	e := currentElector()
+	if e == nil {
+		return false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// currentElector returns the elector set up by Start, or nil before Start is called
+func currentElector() *Elector {
+	electorMu.RLock()
+	defer electorMu.RUnlock()
+	return elector
 }
 
 // Generate random initial delay between min and max duration
-func generateInitialDelay(minDelay, maxDelay time.Duration) time.Duration {
+func generateInitialDelay(r *rand.Rand, minDelay, maxDelay time.Duration) time.Duration {
 	minMs := minDelay.Milliseconds()
 	maxMs := maxDelay.Milliseconds()
 	delayRange := maxMs - minMs
 	if delayRange <= 0 {
 		return minDelay
 	}
-	return minDelay + time.Duration(rng.Int63n(delayRange))*time.Millisecond
+	return minDelay + time.Duration(r.Int63n(delayRange))*time.Millisecond
 }
 
-// GetInstanceID returns the unique instance ID
+// GetInstanceID returns the unique instance ID, or an empty string before Start is called
 func GetInstanceID() string {
-	return elector.instanceID
+	e := currentElector()
+	if e == nil {
+		return ""
+	}
+	return e.instanceID
+}
+
+// Status describes the current holder of a leader lock
+type Status struct {
+	Leader     string    // Instance ID of the leader, empty if there is none
+	AcquiredAt time.Time // Zero for leases written before timestamps were stored
+	ExpiresAt  time.Time
+}
+
+// GetStatus reads the current leader of the lock described by cfg without taking part in
+// the election, e.g. for admin tooling
+func GetStatus(cfg ...ElectorConfig) (*Status, error) {
+	defaultConfig := getDefaultConfig()
+
+	c := defaultConfig
+
+	if len(cfg) > 0 {
+		c = cfg[0]
+		utils.MergeObjects(&c, defaultConfig)
+	}
+
+	lease := NewLeaseManager(LeaseConfig{
+		TableName:          c.TableName,
+		LeaseTimeout:       c.LeaseTimeout,
+		ClockSkewTolerance: c.ClockSkewTolerance,
+	})
+
+	record, err := lease.readLease(c.KeyName)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil || lease.expiredForOthers(record) {
+		return &Status{}, nil
+	}
+
+	return &Status{
+		Leader:     record.Owner,
+		AcquiredAt: record.AcquiredAt,
+		ExpiresAt:  record.ExpiresAt,
+	}, nil
 }
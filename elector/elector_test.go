@@ -0,0 +1,147 @@
+package elector
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/finch-technologies/go-utils/pubsub"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// useElector makes a stopped elector with in-memory leases the package elector for a test
+func useElector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	setElector(t, &Elector{
+		instanceID: "a",
+		config:     getDefaultConfig(),
+		ctx:        ctx,
+		cancel:     cancel,
+		lease:      newTestLeaseManager(newMemoryStore(), "a"),
+	})
+	t.Cleanup(cancel)
+}
+
+// setElector makes e the package elector for a test
+func setElector(t *testing.T, e *Elector) {
+	electorMu.Lock()
+	previous := elector
+	elector = e
+	electorMu.Unlock()
+
+	t.Cleanup(func() {
+		electorMu.Lock()
+		elector = previous
+		electorMu.Unlock()
+	})
+}
+
+func TestResignCooldown(t *testing.T) {
+	useElector(t)
+
+	if inCooldown() {
+		t.Fatal("expected no cooldown before resigning")
+	}
+
+	if err := Resign(context.Background(), ResignOptions{Cooldown: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if !inCooldown() {
+		t.Error("expected a cooldown after resigning")
+	}
+	if acquired, err := attemptLeadership(); err != nil || acquired {
+		t.Errorf("expected no leadership attempt during the cooldown, got %v %v", acquired, err)
+	}
+
+	elector.cooldownUntil = time.Now().Add(-time.Second)
+	if inCooldown() {
+		t.Error("expected the cooldown to pass")
+	}
+}
+
+func TestResignWithoutCooldown(t *testing.T) {
+	useElector(t)
+
+	if err := Resign(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if inCooldown() {
+		t.Error("expected no cooldown")
+	}
+}
+
+func TestLeadershipChangeHook(t *testing.T) {
+	useElector(t)
+
+	events := make(chan LeadershipEvent, 2)
+	elector.config.OnLeadershipChange = func(event LeadershipEvent) {
+		events <- event
+	}
+
+	if acquired, _ := elector.lease.Acquire(elector.config.KeyName); !acquired {
+		t.Fatal("expected to acquire the leader lease")
+	}
+
+	setLeader(true)
+	setLeader(true)
+	setLeader(false)
+
+	acquired := <-events
+	lost := <-events
+	if acquired.Type == LeadershipLost {
+		acquired, lost = lost, acquired
+	}
+
+	if acquired.Type != LeadershipAcquired || lost.Type != LeadershipLost {
+		t.Fatalf("expected an acquired and a lost event, got %+v and %+v", acquired, lost)
+	}
+	if acquired.InstanceID != "a" || acquired.AcquiredAt.IsZero() || !lost.AcquiredAt.Equal(acquired.AcquiredAt) {
+		t.Errorf("expected both events to carry the leadership's start, got %+v and %+v", acquired, lost)
+	}
+	if acquired.Term != 1 || lost.Term != 1 {
+		t.Errorf("expected both events to carry the lease term, got %d and %d", acquired.Term, lost.Term)
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("expected no event without a change, got %+v", event)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestLeadershipEventPublished(t *testing.T) {
+	useElector(t)
+
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	if _, err := pubsub.Init(pubsub.MessageBrokerOptions{RedisClient: client}); err != nil {
+		t.Fatal(err)
+	}
+
+	subscription := client.Subscribe(context.Background(), "leadership")
+	t.Cleanup(func() { subscription.Close() })
+	if _, err := subscription.Receive(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	elector.config.EventChannel = "leadership"
+	elector.lease.Acquire(elector.config.KeyName)
+	setLeader(true)
+
+	select {
+	case message := <-subscription.Channel():
+		var event LeadershipEvent
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != LeadershipAcquired || event.InstanceID != "a" || event.Term != 1 {
+			t.Errorf("expected an acquired event for term 1, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the leadership change to be published")
+	}
+}
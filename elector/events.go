@@ -0,0 +1,69 @@
+package elector
+
+import (
+	"context"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/pubsub"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// LeadershipEventType describes a leadership change
+type LeadershipEventType string
+
+const (
+	// LeadershipAcquired is published when an instance becomes leader
+	LeadershipAcquired LeadershipEventType = "acquired"
+	// LeadershipLost is published when an instance stops being leader
+	LeadershipLost LeadershipEventType = "lost"
+)
+
+// LeadershipEvent is published on the configured event channel and passed to the
+// OnLeadershipChange hook whenever this instance's leadership status changes
+type LeadershipEvent struct {
+	Type       LeadershipEventType `json:"type"`
+	InstanceID string              `json:"instance_id"`
+	Term       int64               `json:"term"`        // Leader lease term, incremented every time leadership changes hands
+	AcquiredAt time.Time           `json:"acquired_at"` // Time this instance became leader
+	Timestamp  time.Time           `json:"timestamp"`   // Time the change was observed
+}
+
+// notifyLeadershipChange publishes a leadership event and calls the leadership hook
+// without blocking the election cycle
+func notifyLeadershipChange(eventType LeadershipEventType, term int64, acquiredAt time.Time) {
+	channel := elector.config.EventChannel
+	hook := elector.config.OnLeadershipChange
+
+	if channel == "" && hook == nil {
+		return
+	}
+
+	event := LeadershipEvent{
+		Type:       eventType,
+		InstanceID: elector.instanceID,
+		Term:       term,
+		AcquiredAt: acquiredAt,
+		Timestamp:  time.Now().UTC(),
+	}
+
+	go utils.Try(func() {
+		if hook != nil {
+			hook(event)
+		}
+
+		if channel == "" {
+			return
+		}
+
+		broker, err := pubsub.GetBroker()
+		if err != nil {
+			log.Errorf("Failed to get message broker for leadership event: %v", err)
+			return
+		}
+
+		if err := broker.Publish(context.Background(), channel, event); err != nil {
+			log.Errorf("Failed to publish leadership event: %v", err)
+		}
+	}, log.FromContext(context.Background()))
+}
@@ -0,0 +1,453 @@
+package elector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+
+	"github.com/google/uuid"
+)
+
+var (
+	defaultRenewInterval      = 30 * time.Second
+	defaultClockSkewTolerance = 5 * time.Second
+)
+
+// LeaseConfig holds configuration for a lease manager
+type LeaseConfig struct {
+	TableName     string
+	LeaseTimeout  time.Duration
+	RenewInterval time.Duration
+	KeyPrefix     string
+	OwnerID       string
+	// ClockSkewTolerance is the maximum clock difference assumed between instances.
+	// Other instances treat a lease as live until this long after its stored expiry,
+	// while the owner treats it as lost this long before.
+	ClockSkewTolerance time.Duration
+	// RandSource seeds the order AcquireAny tries resources in. Set it to make shard spreading reproducible in tests.
+	// Sources aren't safe for concurrent use, so don't share one between managers.
+	RandSource rand.Source
+}
+
+// leaseRecord is the JSON value stored in a lease lock
+type leaseRecord struct {
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Term       int64     `json:"term"` // Incremented every time the lease changes hands
+
+	ttl time.Time // Expiry of the stored item, which conditional writes compare against
+}
+
+// LeaseManager holds TTL leases on multiple named resources (e.g. one per shard)
+// using the same dynamo locking as the leader elector
+type LeaseManager struct {
+	ownerID     string
+	config      LeaseConfig
+	store       leaseStore
+	ctx         context.Context
+	cancel      context.CancelFunc
+	renewTicker *time.Ticker
+	mu          sync.RWMutex
+	held        map[string]int64 // Term of each held lease
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	rngMu       sync.Mutex // *rand.Rand isn't safe for concurrent use
+	rng         *rand.Rand
+}
+
+// getDefaultLeaseConfig returns the default lease configuration
+func getDefaultLeaseConfig() LeaseConfig {
+	return LeaseConfig{
+		TableName:          "default",
+		LeaseTimeout:       defaultLeaseTimeout,
+		RenewInterval:      defaultRenewInterval,
+		ClockSkewTolerance: defaultClockSkewTolerance,
+	}
+}
+
+// NewLeaseManager creates a lease manager. Leases are not renewed until Start is called.
+func NewLeaseManager(opts ...LeaseConfig) *LeaseManager {
+	defaultConfig := getDefaultLeaseConfig()
+
+	cfg := defaultConfig
+
+	if len(opts) > 0 {
+		cfg = opts[0]
+		utils.MergeObjects(&cfg, defaultConfig)
+	}
+
+	if cfg.OwnerID == "" {
+		cfg.OwnerID = uuid.New().String()
+	}
+
+	source := cfg.RandSource
+	if source == nil {
+		source = rand.NewSource(time.Now().UnixNano())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &LeaseManager{
+		ownerID: cfg.OwnerID,
+		config:  cfg,
+		store:   dynamoStore{tableName: cfg.TableName},
+		ctx:     ctx,
+		cancel:  cancel,
+		held:    make(map[string]int64),
+		rng:     rand.New(source),
+	}
+}
+
+// Start begins periodically renewing all held leases
+func (m *LeaseManager) Start() {
+	m.startOnce.Do(func() {
+		m.renewTicker = time.NewTicker(m.config.RenewInterval)
+
+		go func() {
+			defer m.renewTicker.Stop()
+
+			for {
+				select {
+				case <-m.ctx.Done():
+					return
+				case <-m.renewTicker.C:
+					m.renewAll()
+				}
+			}
+		}()
+	})
+}
+
+// Stop stops renewing leases and releases every lease that is still held
+func (m *LeaseManager) Stop() {
+	m.stopOnce.Do(func() {
+		m.cancel()
+
+		for _, resource := range m.Held() {
+			if err := m.Release(resource); err != nil {
+				log.Debugf("Failed to release lease %s during shutdown: %v", resource, err)
+			}
+		}
+	})
+}
+
+// OwnerID returns the owner ID written into every lease held by this manager
+func (m *LeaseManager) OwnerID() string {
+	return m.ownerID
+}
+
+// Acquire tries to take the lease on a resource. Returns true if the lease is held
+// by this manager afterwards.
+func (m *LeaseManager) Acquire(resource string) (bool, error) {
+	select {
+	case <-m.ctx.Done():
+		return false, context.Canceled
+	default:
+	}
+
+	if m.IsHeld(resource) {
+		return m.Renew(resource)
+	}
+
+	term, err := m.acquireLock(resource)
+	if err != nil {
+		return false, err
+	}
+
+	if term > 0 {
+		m.setHeld(resource, term)
+	}
+
+	return term > 0, nil
+}
+
+// acquireLock writes the lease if it is free and returns its term, or 0 if another instance
+// holds it. The write is conditional on the lease read still being stored, so of several
+// instances racing for it only one succeeds.
+func (m *LeaseManager) acquireLock(resource string) (int64, error) {
+	record, err := m.readLease(resource)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check current owner: %w", err)
+	}
+
+	if record != nil && record.Owner != m.ownerID && !m.expiredForOthers(record) {
+		return 0, nil
+	}
+
+	opts := dynamo.PutOptions{IfExpired: true}
+	if record != nil && !record.ttl.IsZero() {
+		opts = dynamo.PutOptions{ExpectedExpiry: record.ttl}
+	}
+
+	term := int64(1)
+	if record != nil {
+		term = record.Term + 1
+	}
+
+	err = m.writeLease(resource, time.Now(), term, opts)
+	if errors.Is(err, dynamo.ErrConditionFailed) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to set lease: %w", err)
+	}
+
+	return term, nil
+}
+
+// AcquireAny tries to hold up to n leases from the given resources. Resources are
+// attempted in random order so that instances spread across shards instead of racing
+// for the same ones. Returns all of the given resources held after the attempt.
+func (m *LeaseManager) AcquireAny(resources []string, n int) ([]string, error) {
+	var held []string
+	var candidates []string
+
+	for _, resource := range resources {
+		if m.IsHeld(resource) {
+			held = append(held, resource)
+		} else {
+			candidates = append(candidates, resource)
+		}
+	}
+
+	m.rngMu.Lock()
+	m.rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	m.rngMu.Unlock()
+
+	var lastErr error
+
+	for _, resource := range candidates {
+		if len(held) >= n {
+			break
+		}
+
+		acquired, err := m.Acquire(resource)
+		if err != nil {
+			log.Debugf("Failed to acquire lease %s: %v", resource, err)
+			lastErr = err
+			continue
+		}
+
+		if acquired {
+			held = append(held, resource)
+		}
+	}
+
+	if len(held) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return held, nil
+}
+
+// Renew extends the lease on a resource if it is still held by this manager
+func (m *LeaseManager) Renew(resource string) (bool, error) {
+	select {
+	case <-m.ctx.Done():
+		return false, context.Canceled
+	default:
+	}
+
+	record, err := m.readLease(resource)
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease owner: %w", err)
+	}
+
+	// Treat the lease as lost if it may already look expired to an instance whose clock is ahead of ours
+	if record == nil || record.Owner != m.ownerID || m.expiredForOwner(record) {
+		m.setHeld(resource, 0)
+		return false, nil
+	}
+
+	// Only extend the lease we read, in case another instance took it over since
+	err = m.writeLease(resource, record.AcquiredAt, record.Term, dynamo.PutOptions{ExpectedExpiry: record.ttl})
+	if errors.Is(err, dynamo.ErrConditionFailed) {
+		m.setHeld(resource, 0)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+
+	return true, nil
+}
+
+// Release frees the lease on a resource if it is held by this manager. The lease is
+// stored as expired rather than deleted, so the next holder continues from its term.
+func (m *LeaseManager) Release(resource string) error {
+	if !m.IsHeld(resource) {
+		return nil
+	}
+
+	record, err := m.readLease(resource)
+	if err != nil {
+		return fmt.Errorf("failed to verify lease owner before release: %w", err)
+	}
+
+	if record == nil || record.Owner != m.ownerID {
+		m.setHeld(resource, 0)
+		return nil
+	}
+
+	// Only free the lease we read, in case another instance took it over since
+	err = m.store.put(m.key(resource), leaseRecord{
+		Owner:      record.Owner,
+		AcquiredAt: record.AcquiredAt,
+		ExpiresAt:  time.Unix(0, 0).UTC(),
+		Term:       record.Term,
+	}, dynamo.PutOptions{
+		Ttl:            m.config.LeaseTimeout + m.config.ClockSkewTolerance,
+		ExpectedExpiry: record.ttl,
+	})
+	if err != nil && !errors.Is(err, dynamo.ErrConditionFailed) {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+
+	m.setHeld(resource, 0)
+
+	return nil
+}
+
+// GetOwner retrieves the current owner of a resource lease, or an empty string if it is free
+func (m *LeaseManager) GetOwner(resource string) (string, error) {
+	record, err := m.readLease(resource)
+
+	if err != nil {
+		return "", err
+	}
+
+	if record == nil || m.expiredForOthers(record) {
+		return "", nil
+	}
+
+	return record.Owner, nil
+}
+
+// readLease reads the lease record of a resource, or nil if there is none. Plain owner
+// values written before leases were stored as JSON are returned without timestamps and
+// rely on the dynamo TTL alone.
+func (m *LeaseManager) readLease(resource string) (*leaseRecord, error) {
+	result, expiry, err := m.store.get(m.key(resource))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease from store: %w", err)
+	}
+
+	if result == "" {
+		return nil, nil
+	}
+
+	var record leaseRecord
+	if err := json.Unmarshal([]byte(result), &record); err != nil || record.Owner == "" {
+		record = leaseRecord{Owner: result}
+	}
+
+	if expiry != nil {
+		record.ttl = *expiry
+	}
+
+	return &record, nil
+}
+
+// writeLease stores a lease record owned by this manager with a fresh expiry, if the
+// conditions in opts are met
+func (m *LeaseManager) writeLease(resource string, acquiredAt time.Time, term int64, opts dynamo.PutOptions) error {
+	now := time.Now()
+
+	if acquiredAt.IsZero() {
+		acquiredAt = now
+	}
+
+	// Keep the item around past its stored expiry so other instances validate it from the timestamps
+	opts.Ttl = m.config.LeaseTimeout + m.config.ClockSkewTolerance
+
+	return m.store.put(m.key(resource), leaseRecord{
+		Owner:      m.ownerID,
+		AcquiredAt: acquiredAt.UTC(),
+		ExpiresAt:  now.Add(m.config.LeaseTimeout).UTC(),
+		Term:       term,
+	}, opts)
+}
+
+// expiredForOthers reports whether another instance may take over the lease
+func (m *LeaseManager) expiredForOthers(record *leaseRecord) bool {
+	if record.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().After(record.ExpiresAt.Add(m.config.ClockSkewTolerance))
+}
+
+// expiredForOwner reports whether the owner should stop relying on the lease
+func (m *LeaseManager) expiredForOwner(record *leaseRecord) bool {
+	if record.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().After(record.ExpiresAt.Add(-m.config.ClockSkewTolerance))
+}
+
+// IsHeld reports whether this manager currently holds the lease on a resource
+func (m *LeaseManager) IsHeld(resource string) bool {
+	return m.Term(resource) > 0
+}
+
+// Term returns the term of a lease held by this manager, or 0 if it isn't held. Terms
+// increase every time a lease changes hands, so a holder can fence off writes made by
+// the holders before it.
+func (m *LeaseManager) Term(resource string) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.held[resource]
+}
+
+// Held returns all resources this manager currently holds leases on
+func (m *LeaseManager) Held() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	resources := make([]string, 0, len(m.held))
+	for resource := range m.held {
+		resources = append(resources, resource)
+	}
+
+	return resources
+}
+
+// renewAll renews every held lease, dropping the ones that were lost
+func (m *LeaseManager) renewAll() {
+	for _, resource := range m.Held() {
+		success, err := m.Renew(resource)
+		if err != nil {
+			log.Errorf("Failed to renew lease %s: %v", resource, err)
+			m.setHeld(resource, 0)
+		} else if !success {
+			log.Infof("Lost lease %s", resource)
+		}
+	}
+}
+
+// setHeld records the term of a held lease, or that it is no longer held if term is 0
+func (m *LeaseManager) setHeld(resource string, term int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if term > 0 {
+		m.held[resource] = term
+	} else {
+		delete(m.held, resource)
+	}
+}
+
+func (m *LeaseManager) key(resource string) string {
+	return m.config.KeyPrefix + resource
+}
@@ -0,0 +1,356 @@
+package elector
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+)
+
+type memoryItem struct {
+	value string
+	ttl   time.Time
+}
+
+// memoryStore keeps leases in memory, expiring and conditioning writes to the second like dynamo
+type memoryStore struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{items: make(map[string]memoryItem)}
+}
+
+func (s *memoryStore) get(key string) (string, *time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if !ok || time.Now().Unix() > item.ttl.Unix() {
+		return "", nil, nil
+	}
+
+	return item.value, &item.ttl, nil
+}
+
+func (s *memoryStore) put(key string, value any, opts dynamo.PutOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item, exists := s.items[key]
+
+	if opts.IfExpired && exists && item.ttl.Unix() >= now.Unix() {
+		return fmt.Errorf("failed to write value: %w", dynamo.ErrConditionFailed)
+	}
+	if !opts.ExpectedExpiry.IsZero() && (!exists || item.ttl.Unix() != opts.ExpectedExpiry.Unix()) {
+		return fmt.Errorf("failed to write value: %w", dynamo.ErrConditionFailed)
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.items[key] = memoryItem{value: string(body), ttl: time.Unix(now.Add(opts.Ttl).Unix(), 0)}
+
+	return nil
+}
+
+// expire moves the stored expiry of a lease into the past, leaving its TTL as it is
+func (s *memoryStore) expire(key string, ago time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.items[key]
+
+	var record leaseRecord
+	json.Unmarshal([]byte(item.value), &record)
+	record.ExpiresAt = time.Now().Add(-ago)
+
+	body, _ := json.Marshal(record)
+	item.value = string(body)
+	s.items[key] = item
+}
+
+func newTestLeaseManager(store leaseStore, owner string) *LeaseManager {
+	m := NewLeaseManager(LeaseConfig{
+		TableName:          "leases",
+		LeaseTimeout:       30 * time.Second,
+		ClockSkewTolerance: 5 * time.Second,
+		OwnerID:            owner,
+		RandSource:         rand.NewSource(1),
+	})
+	m.store = store
+	return m
+}
+
+func TestAcquire(t *testing.T) {
+	store := newMemoryStore()
+	a := newTestLeaseManager(store, "a")
+	b := newTestLeaseManager(store, "b")
+
+	if acquired, err := a.Acquire("shard-1"); err != nil || !acquired {
+		t.Fatalf("expected a to acquire the free lease, got %v %v", acquired, err)
+	}
+	if acquired, err := b.Acquire("shard-1"); err != nil || acquired {
+		t.Fatalf("expected b not to acquire a's lease, got %v %v", acquired, err)
+	}
+	if acquired, err := a.Acquire("shard-1"); err != nil || !acquired {
+		t.Fatalf("expected a to keep its lease, got %v %v", acquired, err)
+	}
+
+	if owner, _ := b.GetOwner("shard-1"); owner != "a" {
+		t.Errorf("expected a to own the lease, got %q", owner)
+	}
+
+	if err := a.Release("shard-1"); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, err := b.Acquire("shard-1"); err != nil || !acquired {
+		t.Fatalf("expected b to acquire the released lease, got %v %v", acquired, err)
+	}
+}
+
+func TestAcquireRace(t *testing.T) {
+	store := newMemoryStore()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var winners []string
+
+	for i := 0; i < 20; i++ {
+		m := newTestLeaseManager(store, fmt.Sprintf("instance-%d", i))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			acquired, err := m.Acquire("shard-1")
+			if err != nil {
+				t.Error(err)
+			}
+			if acquired {
+				mu.Lock()
+				winners = append(winners, m.OwnerID())
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(winners) != 1 {
+		t.Errorf("expected exactly one instance to acquire the lease, got %v", winners)
+	}
+}
+
+func TestAcquireExpiredLease(t *testing.T) {
+	store := newMemoryStore()
+	a := newTestLeaseManager(store, "a")
+	b := newTestLeaseManager(store, "b")
+
+	if acquired, _ := a.Acquire("shard-1"); !acquired {
+		t.Fatal("expected a to acquire the lease")
+	}
+
+	// Expired, but not by more than the clock skew tolerance
+	store.expire("shard-1", 2*time.Second)
+	if acquired, _ := b.Acquire("shard-1"); acquired {
+		t.Fatal("expected b to wait out the clock skew tolerance")
+	}
+
+	store.expire("shard-1", 10*time.Second)
+	if acquired, err := b.Acquire("shard-1"); err != nil || !acquired {
+		t.Fatalf("expected b to take over the expired lease, got %v %v", acquired, err)
+	}
+
+	if renewed, err := a.Renew("shard-1"); err != nil || renewed {
+		t.Errorf("expected a to lose the lease, got %v %v", renewed, err)
+	}
+	if a.IsHeld("shard-1") {
+		t.Error("expected a to stop holding the lease")
+	}
+}
+
+// takeoverStore lets another instance take a lease over right before the next write
+type takeoverStore struct {
+	*memoryStore
+	takeover func()
+}
+
+func (s *takeoverStore) put(key string, value any, opts dynamo.PutOptions) error {
+	if s.takeover != nil {
+		s.takeover()
+		s.takeover = nil
+	}
+	return s.memoryStore.put(key, value, opts)
+}
+
+func TestRenewAfterTakeover(t *testing.T) {
+	store := &takeoverStore{memoryStore: newMemoryStore()}
+	a := newTestLeaseManager(store, "a")
+
+	if acquired, _ := a.Acquire("shard-1"); !acquired {
+		t.Fatal("expected a to acquire the lease")
+	}
+
+	store.takeover = func() {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		body, _ := json.Marshal(leaseRecord{Owner: "b", ExpiresAt: time.Now().Add(time.Minute)})
+		store.items["shard-1"] = memoryItem{value: string(body), ttl: time.Now().Add(time.Hour)}
+	}
+
+	if renewed, err := a.Renew("shard-1"); err != nil || renewed {
+		t.Fatalf("expected a to lose the lease taken over while renewing, got %v %v", renewed, err)
+	}
+	if a.IsHeld("shard-1") {
+		t.Error("expected a to stop holding the lease")
+	}
+	if owner, _ := a.GetOwner("shard-1"); owner != "b" {
+		t.Errorf("expected b to keep the lease, got %q", owner)
+	}
+}
+
+func TestAcquireAny(t *testing.T) {
+	store := newMemoryStore()
+	a := newTestLeaseManager(store, "a")
+	b := newTestLeaseManager(store, "b")
+
+	shards := []string{"shard-1", "shard-2", "shard-3", "shard-4"}
+
+	heldByA, err := a.AcquireAny(shards, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heldByA) != 2 {
+		t.Fatalf("expected a to hold 2 shards, got %v", heldByA)
+	}
+
+	heldByB, err := b.AcquireAny(shards, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heldByB) != 2 {
+		t.Fatalf("expected b to hold the 2 free shards, got %v", heldByB)
+	}
+
+	all := append(append([]string{}, heldByA...), heldByB...)
+	sort.Strings(all)
+	for i, shard := range shards {
+		if all[i] != shard {
+			t.Fatalf("expected the shards to be split between a and b, got %v and %v", heldByA, heldByB)
+		}
+	}
+
+	again, err := a.AcquireAny(shards, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(again)
+	sort.Strings(heldByA)
+	if len(again) != 2 || again[0] != heldByA[0] || again[1] != heldByA[1] {
+		t.Errorf("expected a to keep %v, got %v", heldByA, again)
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	m := newTestLeaseManager(newMemoryStore(), "a")
+	now := time.Now()
+
+	tests := []struct {
+		name             string
+		expiresAt        time.Time
+		expiredForOthers bool
+		expiredForOwner  bool
+	}{
+		{"without expiry", time.Time{}, false, false},
+		{"live", now.Add(time.Minute), false, false},
+		{"within tolerance of expiring", now.Add(2 * time.Second), false, true},
+		{"expired within tolerance", now.Add(-2 * time.Second), false, true},
+		{"expired past tolerance", now.Add(-10 * time.Second), true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &leaseRecord{Owner: "a", ExpiresAt: tt.expiresAt}
+
+			if got := m.expiredForOthers(record); got != tt.expiredForOthers {
+				t.Errorf("expected expiredForOthers %v, got %v", tt.expiredForOthers, got)
+			}
+			if got := m.expiredForOwner(record); got != tt.expiredForOwner {
+				t.Errorf("expected expiredForOwner %v, got %v", tt.expiredForOwner, got)
+			}
+		})
+	}
+}
+
+func TestAcquireAnyConcurrently(t *testing.T) {
+	store := newMemoryStore()
+	shards := []string{"shard-1", "shard-2", "shard-3", "shard-4"}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		m := NewLeaseManager(LeaseConfig{TableName: "leases", OwnerID: fmt.Sprintf("instance-%d", i)})
+		m.store = store
+
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if _, err := m.AcquireAny(shards, 2); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestLeaseTerms(t *testing.T) {
+	store := newMemoryStore()
+	a := newTestLeaseManager(store, "a")
+	b := newTestLeaseManager(store, "b")
+
+	a.Acquire("shard-1")
+	if term := a.Term("shard-1"); term != 1 {
+		t.Fatalf("expected the first term to be 1, got %d", term)
+	}
+
+	if renewed, _ := a.Renew("shard-1"); !renewed || a.Term("shard-1") != 1 {
+		t.Errorf("expected renewing to keep term 1, got %d", a.Term("shard-1"))
+	}
+
+	// Released leases keep their term for the next holder
+	if err := a.Release("shard-1"); err != nil {
+		t.Fatal(err)
+	}
+	if a.Term("shard-1") != 0 {
+		t.Error("expected no term for a released lease")
+	}
+	if owner, _ := b.GetOwner("shard-1"); owner != "" {
+		t.Errorf("expected the released lease to be free, got owner %q", owner)
+	}
+
+	b.Acquire("shard-1")
+	if term := b.Term("shard-1"); term != 2 {
+		t.Errorf("expected the term after a release to be 2, got %d", term)
+	}
+
+	store.expire("shard-1", 10*time.Second)
+	a.Acquire("shard-1")
+	if term := a.Term("shard-1"); term != 3 {
+		t.Errorf("expected the term after a takeover to be 3, got %d", term)
+	}
+}
@@ -0,0 +1,111 @@
+package elector
+
+import (
+	"context"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/metrics"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Metrics reported by jobs started with RunIfLeader when RunOptions.Metrics is set
+const (
+	MetricJobRunsTotal   = "elector_job_runs_total"           // Counter by job and status
+	MetricJobRunDuration = "elector_job_run_duration_seconds" // Histogram by job and status
+)
+
+// RunOptions configures a job started with RunIfLeader
+type RunOptions struct {
+	Timeout time.Duration     // Deadline of each run (default the interval)
+	Metrics metrics.Collector // Counts and times runs by status: success, failure or panic (optional)
+}
+
+// RunIfLeader runs fn every interval while this instance is the leader, until ctx is
+// cancelled or stop is called. Runs on other instances are skipped, a panic in fn is
+// recovered and logged with its stack so it doesn't take the service down, and runs never
+// overlap: a run that takes longer than interval delays the next one. Stop waits for a
+// run in progress to finish.
+//
+// Example:
+//
+//	elector.Start()
+//	stop := elector.RunIfLeader(ctx, "expire-sessions", 5*time.Minute, expireSessions,
+//	    elector.RunOptions{Metrics: collector})
+//	defer stop()
+func RunIfLeader(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error, options ...RunOptions) (stop func()) {
+	var opts RunOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	interval = utils.DurationOrDefault(interval, defaultInterval)
+	opts.Timeout = utils.DurationOrDefault(opts.Timeout, interval)
+
+	if opts.Metrics != nil {
+		err := opts.Metrics.RegisterCustomMetrics(
+			metrics.CustomMetric{Name: MetricJobRunsTotal, Description: "Leader job runs", Type: metrics.Counter, Labels: []string{"job", "status"}},
+			metrics.CustomMetric{Name: MetricJobRunDuration, Description: "Time taken by a leader job run", Type: metrics.Histogram, Labels: []string{"job", "status"}},
+		)
+		if err != nil {
+			log.Warningf("Failed to register leader job metrics for %s: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !IsLeader() {
+					continue
+				}
+
+				runJob(ctx, name, fn, opts)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// runJob runs fn once, recovering panics and reporting the outcome
+func runJob(ctx context.Context, name string, fn func(ctx context.Context) error, opts RunOptions) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	status := "success"
+
+	utils.TryCatch(func() {
+		if err := fn(ctx); err != nil {
+			status = "failure"
+			log.Errorf("Leader job %s failed: %v", name, err)
+		}
+	}, func(e error, stackTrace string) {
+		status = "panic"
+		log.ErrorStack(stackTrace, "Leader job %s panicked: %v", name, e)
+	})
+
+	log.Debugf("Leader job %s finished with %s in %s", name, status, time.Since(start))
+
+	if opts.Metrics != nil {
+		labels := map[string]string{"job": name, "status": status}
+
+		opts.Metrics.IncrementCounter(ctx, MetricJobRunsTotal, labels, 1)
+		opts.Metrics.ObserveHistogram(ctx, MetricJobRunDuration, labels, time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,80 @@
+package elector
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunIfLeaderSkipsFollowers(t *testing.T) {
+	tests := []struct {
+		name    string
+		elector *Elector
+	}{
+		{"before Start", nil},
+		{"follower", &Elector{instanceID: "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setElector(t, tt.elector)
+
+			var runs atomic.Int32
+			stop := RunIfLeader(context.Background(), "job", 5*time.Millisecond, func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			})
+
+			time.Sleep(30 * time.Millisecond)
+			stop()
+
+			if got := runs.Load(); got != 0 {
+				t.Errorf("expected no runs, got %d", got)
+			}
+		})
+	}
+}
+
+func TestRunIfLeaderRecoversPanics(t *testing.T) {
+	setElector(t, &Elector{instanceID: "a", isLeader: true})
+
+	var runs atomic.Int32
+	stop := RunIfLeader(context.Background(), "job", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		panic("boom")
+	})
+
+	time.Sleep(40 * time.Millisecond)
+	stop()
+
+	if got := runs.Load(); got < 2 {
+		t.Errorf("expected runs to continue after a panic, got %d runs", got)
+	}
+}
+
+func TestRunIfLeaderDoesNotOverlap(t *testing.T) {
+	setElector(t, &Elector{instanceID: "a", isLeader: true})
+
+	var running, overlaps, runs atomic.Int32
+	stop := RunIfLeader(context.Background(), "job", time.Millisecond, func(ctx context.Context) error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		defer running.Add(-1)
+
+		runs.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	if running.Load() != 0 {
+		t.Error("expected stop to wait for the run in progress")
+	}
+	if runs.Load() < 2 || overlaps.Load() != 0 {
+		t.Errorf("expected consecutive runs without overlap, got %d runs and %d overlaps", runs.Load(), overlaps.Load())
+	}
+}
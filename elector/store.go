@@ -0,0 +1,27 @@
+package elector
+
+import (
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+)
+
+// leaseStore is where leases are kept. Puts honour the IfExpired and ExpectedExpiry options
+// and fail with dynamo.ErrConditionFailed when another instance got there first.
+type leaseStore interface {
+	get(key string) (string, *time.Time, error)
+	put(key string, value any, opts dynamo.PutOptions) error
+}
+
+// dynamoStore keeps leases in a dynamo table
+type dynamoStore struct {
+	tableName string
+}
+
+func (s dynamoStore) get(key string) (string, *time.Time, error) {
+	return dynamo.GetString(s.tableName, key)
+}
+
+func (s dynamoStore) put(key string, value any, opts dynamo.PutOptions) error {
+	return dynamo.Put(s.tableName, key, value, opts)
+}
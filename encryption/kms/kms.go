@@ -8,7 +8,9 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/finch-technologies/go-utils/utils"
 )
 
 var kmsClient *kms.Client
@@ -28,12 +30,23 @@ func getKmsClient(ctx context.Context, awsRegion string) (*kms.Client, error) {
 	return kms.NewFromConfig(cfg), nil
 }
 
-func Encrypt(ctx context.Context, plaintext string) (string, error) {
+type EncryptOptions struct {
+	KeyId  string // KMS key to encrypt with (default KMS_KEY_ID)
+	Region string // AWS region of the key (default AWS_REGION)
+}
+
+func Encrypt(ctx context.Context, plaintext string, options ...EncryptOptions) (string, error) {
+
+	var opts EncryptOptions
 
-	kmsKeyId := os.Getenv("KMS_KEY_ID")
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	kmsKeyId := utils.StringOrDefault(opts.KeyId, os.Getenv("KMS_KEY_ID"))
 
 	// Create KMS client
-	client, err := getKmsClient(ctx, os.Getenv("AWS_REGION"))
+	client, err := getKmsClient(ctx, utils.StringOrDefault(opts.Region, os.Getenv("AWS_REGION")))
 
 	if err != nil {
 		return "", fmt.Errorf("failed to create KMS client: %w", err)
@@ -90,3 +103,68 @@ func Decrypt(ctx context.Context, ciphertext string, awsRegion string) (string,
 
 	return string(resp.Plaintext), nil
 }
+
+// GenerateDataKey creates a new AES-256 data key under the given KMS key (KMS_KEY_ID if
+// empty), returning the plaintext key for local encryption and the wrapped copy to store
+func GenerateDataKey(ctx context.Context, kmsKeyId string) (plaintext []byte, wrapped []byte, err error) {
+	if kmsKeyId == "" {
+		kmsKeyId = os.Getenv("KMS_KEY_ID")
+	}
+
+	client, err := getKmsClient(ctx, os.Getenv("AWS_REGION"))
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create KMS client: %w", err)
+	}
+
+	resp, err := client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(kmsKeyId),
+		KeySpec: types.DataKeySpecAes256,
+	})
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+// DecryptDataKey unwraps a data key created by GenerateDataKey
+func DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	client, err := getKmsClient(ctx, os.Getenv("AWS_REGION"))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KMS client: %w", err)
+	}
+
+	resp, err := client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: wrapped,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+
+	return resp.Plaintext, nil
+}
+
+// ReEncrypt re-wraps ciphertext under a different KMS key without exposing the plaintext,
+// e.g. to move data keys to a new KMS key during rotation
+func ReEncrypt(ctx context.Context, wrapped []byte, kmsKeyId string) ([]byte, error) {
+	client, err := getKmsClient(ctx, os.Getenv("AWS_REGION"))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KMS client: %w", err)
+	}
+
+	resp, err := client.ReEncrypt(ctx, &kms.ReEncryptInput{
+		CiphertextBlob:   wrapped,
+		DestinationKeyId: aws.String(kmsKeyId),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encrypt data: %w", err)
+	}
+
+	return resp.CiphertextBlob, nil
+}
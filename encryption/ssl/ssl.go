@@ -7,25 +7,29 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
+
+	"github.com/finch-technologies/go-utils/utils"
 )
 
-func Encrypt(plaintext string) (string, error) {
+type EncryptOptions struct {
+	SecretKey string
+	IV        string
+}
+
+func Encrypt(plaintext string, options ...EncryptOptions) (string, error) {
 
 	sslKey := os.Getenv("SSL_SECRET_KEY")
 	iv := os.Getenv("SSL_IV")
 
-	var plainTextBlock []byte
-	length := len(plaintext)
-
-	if length%aes.BlockSize != 0 {
-		extendBlock := aes.BlockSize - (length % aes.BlockSize)
-		plainTextBlock = make([]byte, length+extendBlock)
-		copy(plainTextBlock[length:], bytes.Repeat([]byte{uint8(extendBlock)}, extendBlock))
-	} else {
-		plainTextBlock = make([]byte, length)
+	if len(options) > 0 {
+		sslKey = utils.StringOrDefault(options[0].SecretKey, sslKey)
+		iv = utils.StringOrDefault(options[0].IV, iv)
 	}
 
-	copy(plainTextBlock, plaintext)
+	// PKCS#7 always pads, adding a full block when the plaintext is already aligned
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plainTextBlock := append([]byte(plaintext), bytes.Repeat([]byte{uint8(padding)}, padding)...)
+
 	block, err := aes.NewCipher([]byte(sslKey))
 
 	if err != nil {
@@ -86,11 +90,37 @@ func Decrypt(encrypted string, options ...DecryptOptions) (string, error) {
 		return "", fmt.Errorf("block size cant be zero")
 	}
 
+	if len(ciphertext) == 0 {
+		return "", fmt.Errorf("ciphertext is empty")
+	}
+
 	mode := cipher.NewCBCDecrypter(block, []byte(iv))
 	mode.CryptBlocks(ciphertext, ciphertext)
-	ciphertext = PKCS5UnPadding(ciphertext)
 
-	return string(ciphertext), nil
+	// Values decrypted with the wrong key end in random bytes rather than valid padding
+	plaintext, err := unpad(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// unpad removes PKCS#7 padding, checking every padding byte
+func unpad(src []byte) ([]byte, error) {
+	padding := int(src[len(src)-1])
+
+	if padding < 1 || padding > aes.BlockSize || padding > len(src) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+
+	for _, b := range src[len(src)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+
+	return src[:len(src)-padding], nil
 }
 
 // PKCS5UnPadding  pads a certain blob of data with necessary data to be used in AES block cipher
@@ -0,0 +1,64 @@
+package ssl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	opts := EncryptOptions{SecretKey: strings.Repeat("k", 32), IV: strings.Repeat("i", 16)}
+
+	for _, plaintext := range []string{"", "a", "exactly 16 bytes", strings.Repeat("x", 31), strings.Repeat("y", 32)} {
+		encrypted, err := Encrypt(plaintext, opts)
+		if err != nil {
+			t.Fatalf("Encrypt(%q) error = %v", plaintext, err)
+		}
+
+		decrypted, err := Decrypt(encrypted, DecryptOptions(opts))
+		if err != nil {
+			t.Fatalf("Decrypt() of %q error = %v", plaintext, err)
+		}
+
+		if decrypted != plaintext {
+			t.Errorf("Decrypt() = %q, want %q", decrypted, plaintext)
+		}
+	}
+
+	encrypted, _ := Encrypt("a secret value", opts)
+	if _, err := Decrypt(encrypted, DecryptOptions{SecretKey: strings.Repeat("w", 32), IV: opts.IV}); err == nil {
+		t.Error("Decrypt() with the wrong key succeeded, want error")
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	block := func(tail ...byte) []byte {
+		return append([]byte(strings.Repeat("a", 16-len(tail))), tail...)
+	}
+
+	tests := []struct {
+		name    string
+		src     []byte
+		want    string
+		wantErr bool
+	}{
+		{"one byte", block(1), strings.Repeat("a", 15), false},
+		{"three bytes", block(3, 3, 3), strings.Repeat("a", 13), false},
+		{"full block", append(block(), []byte(strings.Repeat("\x10", 16))...), strings.Repeat("a", 16), false},
+		{"zero", block(0), "", true},
+		{"larger than a block", block(17), "", true},
+		{"unequal bytes", block(1, 2, 3), "", true},
+		{"printable last byte", block('a'), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unpad(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unpad() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("unpad() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package token
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type session struct {
+	UserID string `json:"u"`
+	Role   string `json:"r"`
+}
+
+func TestCodec(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+
+	codec, err := NewCodec(Options{Secrets: [][]byte{secret}, Ttl: time.Hour, Purpose: "session"})
+	if err != nil {
+		t.Fatalf("NewCodec() error = %v", err)
+	}
+
+	otherSecret, _ := NewCodec(Options{Secrets: [][]byte{[]byte("fedcba9876543210")}})
+	otherPurpose, _ := NewCodec(Options{Secrets: [][]byte{secret}, Purpose: "reset"})
+	rotated, _ := NewCodec(Options{Secrets: [][]byte{[]byte("fedcba9876543210"), secret}, Purpose: "session"})
+
+	expired, _ := NewCodec(Options{Secrets: [][]byte{secret}, Ttl: time.Hour, Purpose: "session"})
+	expired.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	token, err := codec.Encode(session{UserID: "user123", Role: "admin"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tampered := []byte(token)
+	tampered[len(tampered)/2] ^= 1
+
+	tests := []struct {
+		name    string
+		codec   *Codec
+		token   string
+		wantErr error
+	}{
+		{name: "valid", codec: codec, token: token},
+		{name: "rotated secret", codec: rotated, token: token},
+		{name: "tampered", codec: codec, token: string(tampered), wantErr: ErrInvalidToken},
+		{name: "other secret", codec: otherSecret, token: token, wantErr: ErrInvalidToken},
+		{name: "other purpose", codec: otherPurpose, token: token, wantErr: ErrInvalidToken},
+		{name: "expired", codec: expired, token: token, wantErr: ErrTokenExpired},
+		{name: "garbage", codec: codec, token: "not-a-token", wantErr: ErrInvalidToken},
+		{name: "empty", codec: codec, token: "", wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got session
+			err := tt.codec.Decode(tt.token, &got)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && (got.UserID != "user123" || got.Role != "admin") {
+				t.Errorf("Decode() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestCodec_Expiry(t *testing.T) {
+	codec, err := NewCodec(Options{Secrets: [][]byte{[]byte("0123456789abcdef")}})
+	if err != nil {
+		t.Fatalf("NewCodec() error = %v", err)
+	}
+
+	token, _ := codec.Encode("value")
+	if expiry, err := codec.Expiry(token); err != nil || !expiry.IsZero() {
+		t.Errorf("Expiry() = %v, %v, want no expiry", expiry, err)
+	}
+
+	token, _ = codec.Encode("value", time.Minute)
+	if expiry, err := codec.Expiry(token); err != nil || time.Until(expiry) > time.Minute || time.Until(expiry) < 58*time.Second {
+		t.Errorf("Expiry() = %v, %v, want in a minute", expiry, err)
+	}
+}
+
+func TestCodec_Cookie(t *testing.T) {
+	codec, err := NewCodec(Options{Secrets: [][]byte{[]byte("0123456789abcdef")}, Ttl: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCodec() error = %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := codec.SetCookie(recorder, "session", session{UserID: "user123"}); err != nil {
+		t.Fatalf("SetCookie() error = %v", err)
+	}
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || !cookies[0].Secure || cookies[0].MaxAge != 3600 {
+		t.Fatalf("unexpected cookies %+v", cookies)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(cookies[0])
+
+	var got session
+	if err := codec.ReadCookie(request, "session", &got); err != nil || got.UserID != "user123" {
+		t.Errorf("ReadCookie() = %+v, %v", got, err)
+	}
+
+	if err := codec.SetCookie(recorder, "large", make([]byte, maxCookieSize)); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("SetCookie() error = %v, want ErrTokenTooLarge", err)
+	}
+}
+
+func TestNewCodec_Secrets(t *testing.T) {
+	t.Setenv("TOKEN_SECRET", "")
+	if _, err := NewCodec(); err == nil {
+		t.Error("expected an error without secrets")
+	}
+
+	t.Setenv("TOKEN_SECRET", "0123456789abcdef, fedcba9876543210")
+	codec, err := NewCodec()
+	if err != nil || len(codec.keys) != 2 {
+		t.Errorf("NewCodec() = %v, %v, want two keys from TOKEN_SECRET", codec, err)
+	}
+
+	if _, err := NewCodec(Options{Secrets: [][]byte{[]byte("short")}}); err == nil {
+		t.Error("expected an error for a short secret")
+	}
+}
@@ -0,0 +1,210 @@
+// Package events is a registry of typed message schemas shared by the queue and pubsub
+// packages. Event types are registered once with a name and version, and messages carry
+// an envelope naming their type so consumers decode them into the right Go type, migrating
+// payloads written by older producers.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrUnknownType is returned when decoding an event whose type is not registered
+	ErrUnknownType = errors.New("unknown event type")
+	// ErrNotEvent is returned when decoding a payload that is not an event envelope
+	ErrNotEvent = errors.New("payload is not an event")
+)
+
+// Migration upgrades an event payload from one version to the next
+type Migration func(data json.RawMessage) (json.RawMessage, error)
+
+// TypeOptions configures a registered event type
+type TypeOptions struct {
+	Version int // Current schema version (default 1)
+	// Migrations keyed by the version they upgrade from, e.g. Migrations[1] turns a version 1
+	// payload into version 2. Missing steps pass the payload through unchanged, which is
+	// enough for additive changes.
+	Migrations map[int]Migration
+}
+
+// Envelope is the wire format of an event
+type Envelope struct {
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type eventType struct {
+	name       string
+	goType     reflect.Type
+	version    int
+	migrations map[int]Migration
+}
+
+var (
+	mu       sync.RWMutex
+	byName   = map[string]*eventType{}
+	byGoType = map[reflect.Type]*eventType{}
+)
+
+// Register registers T as the event type name. Register each type once at startup in
+// every service that produces or consumes it.
+//
+// Example:
+//
+//	type OrderCreated struct {
+//	    OrderID string `json:"order_id"`
+//	    Total   int64  `json:"total"`
+//	}
+//
+//	events.Register[OrderCreated]("order.created", events.TypeOptions{
+//	    Version: 2,
+//	    Migrations: map[int]events.Migration{
+//	        1: renameAmountToTotal,
+//	    },
+//	})
+func Register[T any](name string, options ...TypeOptions) error {
+	opts := TypeOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if name == "" {
+		return fmt.Errorf("event type name is required")
+	}
+
+	if opts.Version <= 0 {
+		opts.Version = 1
+	}
+
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if existing, ok := byName[name]; ok && existing.goType != goType {
+		return fmt.Errorf("event type %s is already registered for %s", name, existing.goType)
+	}
+
+	if existing, ok := byGoType[goType]; ok && existing.name != name {
+		return fmt.Errorf("%s is already registered as event type %s", goType, existing.name)
+	}
+
+	t := &eventType{
+		name:       name,
+		goType:     goType,
+		version:    opts.Version,
+		migrations: opts.Migrations,
+	}
+
+	byName[name] = t
+	byGoType[goType] = t
+
+	return nil
+}
+
+// Encode wraps a registered event (or a pointer to one) in an envelope
+func Encode(event any) ([]byte, error) {
+	goType := reflect.TypeOf(event)
+	if goType != nil && goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	mu.RLock()
+	t, ok := byGoType[goType]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %v is not registered", ErrUnknownType, goType)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event %s: %w", t.name, err)
+	}
+
+	return json.Marshal(Envelope{Type: t.name, Version: t.version, Data: data})
+}
+
+// Decode decodes an event envelope into a value of its registered Go type, migrating
+// payloads from older versions. Payloads from newer versions are decoded as they are,
+// ignoring fields this service doesn't know yet.
+//
+// Example:
+//
+//	event, err := events.Decode(payload)
+//	switch e := event.(type) {
+//	case OrderCreated:
+//	    handleOrder(e)
+//	}
+func Decode(payload []byte) (any, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil || envelope.Type == "" {
+		return nil, ErrNotEvent
+	}
+
+	mu.RLock()
+	t, ok := byName[envelope.Type]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, envelope.Type)
+	}
+
+	data := envelope.Data
+
+	for version := envelope.Version; version < t.version; version++ {
+		migrate, ok := t.migrations[version]
+		if !ok {
+			continue
+		}
+
+		var err error
+		data, err = migrate(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate event %s from version %d: %w", t.name, version, err)
+		}
+	}
+
+	value := reflect.New(t.goType)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event %s: %w", t.name, err)
+	}
+
+	return value.Elem().Interface(), nil
+}
+
+// DecodeAs decodes an event that is expected to be of type T
+func DecodeAs[T any](payload []byte) (T, error) {
+	var zero T
+
+	event, err := Decode(payload)
+	if err != nil {
+		return zero, err
+	}
+
+	value, ok := event.(T)
+	if !ok {
+		return zero, fmt.Errorf("expected event of type %T, got %T", zero, event)
+	}
+
+	return value, nil
+}
+
+// Parse returns a parse function for queue.Dequeue that decodes events of type T
+func Parse[T any]() func(body string) (T, error) {
+	return func(body string) (T, error) {
+		return DecodeAs[T]([]byte(body))
+	}
+}
+
+// ParseAny decodes an event of any registered type, for queue.Dequeue on queues carrying
+// several event types
+func ParseAny(body string) (any, error) {
+	return Decode([]byte(body))
+}
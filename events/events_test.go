@@ -0,0 +1,137 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type orderCreated struct {
+	OrderID string `json:"order_id"`
+	Total   int64  `json:"total"`
+}
+
+type orderCancelled struct {
+	OrderID string `json:"order_id"`
+}
+
+func init() {
+	// Version 1 called the total "amount"
+	err := Register[orderCreated]("order.created", TypeOptions{
+		Version: 2,
+		Migrations: map[int]Migration{
+			1: func(data json.RawMessage) (json.RawMessage, error) {
+				var v map[string]any
+				if err := json.Unmarshal(data, &v); err != nil {
+					return nil, err
+				}
+				v["total"] = v["amount"]
+				delete(v, "amount")
+				return json.Marshal(v)
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if err := Register[orderCancelled]("order.cancelled"); err != nil {
+		panic(err)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	payload, err := Encode(&orderCreated{OrderID: "o-1", Total: 250})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	event, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	created, ok := event.(orderCreated)
+	if !ok || created.OrderID != "o-1" || created.Total != 250 {
+		t.Errorf("Decode() = %#v", event)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "migrates older version",
+			payload: `{"type":"order.created","version":1,"data":{"order_id":"o-1","amount":99}}`,
+			want:    orderCreated{OrderID: "o-1", Total: 99},
+		},
+		{
+			name:    "newer version ignores unknown fields",
+			payload: `{"type":"order.created","version":3,"data":{"order_id":"o-1","total":5,"currency":"ZAR"}}`,
+			want:    orderCreated{OrderID: "o-1", Total: 5},
+		},
+		{
+			name:    "other type",
+			payload: `{"type":"order.cancelled","version":1,"data":{"order_id":"o-2"}}`,
+			want:    orderCancelled{OrderID: "o-2"},
+		},
+		{
+			name:    "unknown type",
+			payload: `{"type":"order.shipped","version":1,"data":{}}`,
+			wantErr: ErrUnknownType,
+		},
+		{
+			name:    "not an envelope",
+			payload: `{"order_id":"o-1"}`,
+			wantErr: ErrNotEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := Decode([]byte(tt.payload))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && event != tt.want {
+				t.Errorf("Decode() = %#v, want %#v", event, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister_Conflicts(t *testing.T) {
+	if err := Register[orderCancelled]("order.created"); err == nil {
+		t.Error("expected error registering a second type under an existing name")
+	}
+
+	if err := Register[orderCreated]("order.placed"); err == nil {
+		t.Error("expected error registering a type under a second name")
+	}
+
+	if err := Register[orderCreated]("order.created", TypeOptions{Version: 2}); err != nil {
+		t.Errorf("re-registering the same type should succeed, got %v", err)
+	}
+}
+
+func TestDecodeAs(t *testing.T) {
+	payload, err := Encode(orderCancelled{OrderID: "o-3"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if _, err := DecodeAs[orderCreated](payload); err == nil {
+		t.Error("expected error decoding as the wrong type")
+	}
+
+	cancelled, err := Parse[orderCancelled]()(string(payload))
+	if err != nil || cancelled.OrderID != "o-3" {
+		t.Errorf("Parse() = %+v, %v", cancelled, err)
+	}
+}
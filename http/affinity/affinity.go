@@ -0,0 +1,283 @@
+// Package affinity maps session keys to assigned proxies so sticky proxy sessions survive
+// process restarts and are shared between workers.
+package affinity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	dbredis "github.com/finch-technologies/go-utils/database/redis"
+	"github.com/finch-technologies/go-utils/http"
+	"github.com/finch-technologies/go-utils/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type AffinityDriver string
+
+const (
+	AffinityDriverRedis  AffinityDriver = "redis"
+	AffinityDriverDynamo AffinityDriver = "dynamo"
+)
+
+// Options configures a session map
+type Options struct {
+	Driver    AffinityDriver // Storage backend (default redis)
+	RedisDb   int            // Redis database for the redis driver
+	TableName string         // Table for the dynamo driver (default "default")
+	KeyPrefix string         // Prefix for session keys (default "proxy_session_")
+	Ttl       time.Duration  // How long an assignment lives without a refresh (default 30m)
+}
+
+// store persists session assignments. Values are compared as raw JSON so only the
+// worker holding the same assignment can refresh or release it.
+type store interface {
+	setIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	get(ctx context.Context, key string) (string, error)
+	refreshIfEqual(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	deleteIfEqual(ctx context.Context, key, value string) error
+}
+
+// SessionMap assigns proxies to session keys with a TTL
+type SessionMap struct {
+	opts  Options
+	store store
+}
+
+func getOptions(options ...Options) Options {
+	opts := Options{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Driver == "" {
+		opts.Driver = AffinityDriverRedis
+	}
+
+	opts.TableName = utils.StringOrDefault(opts.TableName, "default")
+	opts.KeyPrefix = utils.StringOrDefault(opts.KeyPrefix, "proxy_session_")
+	opts.Ttl = utils.DurationOrDefault(opts.Ttl, 30*time.Minute)
+
+	return opts
+}
+
+// New creates a session map using the configured driver
+//
+// Example:
+//
+//	sessions, err := affinity.New(affinity.Options{Ttl: time.Hour})
+//	proxy, _, err := sessions.Claim(ctx, userID, candidate)
+//	resp, err := http.Fetch[Result](ctx, url, "GET", nil, http.FetchOptions{Proxy: &proxy})
+func New(options ...Options) (*SessionMap, error) {
+	opts := getOptions(options...)
+
+	var s store
+
+	switch opts.Driver {
+	case AffinityDriverRedis:
+		s = &redisStore{rdb: dbredis.GetRedisClient(opts.RedisDb)}
+	case AffinityDriverDynamo:
+		s = &dynamoStore{table: dynamoTableName(opts.TableName)}
+	default:
+		return nil, fmt.Errorf("unsupported affinity driver: %s", opts.Driver)
+	}
+
+	return &SessionMap{
+		opts:  opts,
+		store: s,
+	}, nil
+}
+
+// Claim assigns proxy to a session unless it already has an assignment. It returns the
+// proxy the session is assigned to afterwards and whether this call made the assignment.
+func (m *SessionMap) Claim(ctx context.Context, session string, proxy http.Proxy) (http.Proxy, bool, error) {
+	value, err := json.Marshal(proxy)
+	if err != nil {
+		return http.Proxy{}, false, fmt.Errorf("failed to marshal proxy: %w", err)
+	}
+
+	claimed, err := m.store.setIfAbsent(ctx, m.key(session), string(value), m.opts.Ttl)
+	if err != nil {
+		return http.Proxy{}, false, fmt.Errorf("failed to claim session: %w", err)
+	}
+
+	if claimed {
+		return proxy, true, nil
+	}
+
+	assigned, err := m.Get(ctx, session)
+	if err != nil {
+		return http.Proxy{}, false, err
+	}
+
+	if assigned == nil {
+		// The assignment expired between the claim and the read, try once more
+		claimed, err = m.store.setIfAbsent(ctx, m.key(session), string(value), m.opts.Ttl)
+		if err != nil {
+			return http.Proxy{}, false, fmt.Errorf("failed to claim session: %w", err)
+		}
+		if !claimed {
+			return http.Proxy{}, false, fmt.Errorf("failed to claim session %s: assignment changed concurrently", session)
+		}
+		return proxy, true, nil
+	}
+
+	return *assigned, false, nil
+}
+
+// Get returns the proxy assigned to a session, or nil if there is none
+func (m *SessionMap) Get(ctx context.Context, session string) (*http.Proxy, error) {
+	value, err := m.store.get(ctx, m.key(session))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if value == "" {
+		return nil, nil
+	}
+
+	proxy, err := utils.ParseJson[http.Proxy](value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session proxy: %w", err)
+	}
+
+	return &proxy, nil
+}
+
+// Refresh extends a session's TTL if it is still assigned to proxy. Returns false if the
+// assignment expired or was replaced.
+func (m *SessionMap) Refresh(ctx context.Context, session string, proxy http.Proxy) (bool, error) {
+	value, err := json.Marshal(proxy)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal proxy: %w", err)
+	}
+
+	refreshed, err := m.store.refreshIfEqual(ctx, m.key(session), string(value), m.opts.Ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	return refreshed, nil
+}
+
+// Release removes a session's assignment if it is still assigned to proxy, e.g. after the
+// proxy was banned
+func (m *SessionMap) Release(ctx context.Context, session string, proxy http.Proxy) error {
+	value, err := json.Marshal(proxy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal proxy: %w", err)
+	}
+
+	err = m.store.deleteIfEqual(ctx, m.key(session), string(value))
+	if err != nil {
+		return fmt.Errorf("failed to release session: %w", err)
+	}
+
+	return nil
+}
+
+func (m *SessionMap) key(session string) string {
+	return m.opts.KeyPrefix + session
+}
+
+var (
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+func (s *redisStore) setIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *redisStore) get(ctx context.Context, key string) (string, error) {
+	value, err := s.rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return value, err
+}
+
+func (s *redisStore) refreshIfEqual(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	result, err := refreshScript.Run(ctx, s.rdb, []string{key}, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (s *redisStore) deleteIfEqual(ctx context.Context, key, value string) error {
+	return releaseScript.Run(ctx, s.rdb, []string{key}, value).Err()
+}
+
+// dynamoStore claims, refreshes and releases sessions with conditional writes, so of
+// several workers racing for a session only one succeeds
+type dynamoStore struct {
+	table dynamoTable
+}
+
+// dynamoTable is the part of the dynamo package the dynamo store uses
+type dynamoTable interface {
+	put(ctx context.Context, key, value string, opts dynamo.PutOptions) error
+	get(ctx context.Context, key string) (string, error)
+	deleteIf(ctx context.Context, key string, opts dynamo.PutOptions) error
+}
+
+func (s *dynamoStore) setIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return conditionMet(s.table.put(ctx, key, value, dynamo.PutOptions{Ttl: ttl, IfExpired: true}))
+}
+
+func (s *dynamoStore) get(ctx context.Context, key string) (string, error) {
+	return s.table.get(ctx, key)
+}
+
+func (s *dynamoStore) refreshIfEqual(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return conditionMet(s.table.put(ctx, key, value, dynamo.PutOptions{Ttl: ttl, ExpectedValue: value}))
+}
+
+func (s *dynamoStore) deleteIfEqual(ctx context.Context, key, value string) error {
+	_, err := conditionMet(s.table.deleteIf(ctx, key, dynamo.PutOptions{ExpectedValue: value}))
+	return err
+}
+
+// conditionMet reports whether a conditional write succeeded, treating a failed condition
+// as a result rather than an error
+func conditionMet(err error) (bool, error) {
+	if errors.Is(err, dynamo.ErrConditionFailed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// dynamoTableName is a dynamoTable over a table registered with the dynamo package
+type dynamoTableName string
+
+func (t dynamoTableName) put(ctx context.Context, key, value string, opts dynamo.PutOptions) error {
+	return dynamo.PutContext(ctx, string(t), key, value, opts)
+}
+
+func (t dynamoTableName) get(ctx context.Context, key string) (string, error) {
+	value, _, err := dynamo.GetStringContext(ctx, string(t), key)
+	return value, err
+}
+
+func (t dynamoTableName) deleteIf(ctx context.Context, key string, opts dynamo.PutOptions) error {
+	return dynamo.DeleteIfContext(ctx, string(t), key, opts)
+}
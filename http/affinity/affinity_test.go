@@ -0,0 +1,202 @@
+package affinity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/finch-technologies/go-utils/database/dynamo"
+	"github.com/finch-technologies/go-utils/http"
+	"github.com/redis/go-redis/v9"
+)
+
+type fakeItem struct {
+	value   string
+	expires time.Time
+}
+
+// fakeTable applies the conditions of dynamo writes to items kept in memory
+type fakeTable struct {
+	mu    sync.Mutex
+	items map[string]fakeItem
+}
+
+func (t *fakeTable) live(key string) (fakeItem, bool) {
+	item, ok := t.items[key]
+	return item, ok && time.Now().Before(item.expires)
+}
+
+func (t *fakeTable) met(key string, opts dynamo.PutOptions) bool {
+	item, exists := t.items[key]
+
+	if opts.IfExpired && exists && time.Now().Before(item.expires) {
+		return false
+	}
+	if opts.ExpectedValue != nil && (!exists || item.value != opts.ExpectedValue) {
+		return false
+	}
+	return true
+}
+
+func (t *fakeTable) put(ctx context.Context, key, value string, opts dynamo.PutOptions) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.met(key, opts) {
+		return fmt.Errorf("failed to write value to dynamodb: %w", dynamo.ErrConditionFailed)
+	}
+
+	t.items[key] = fakeItem{value: value, expires: time.Now().Add(opts.Ttl)}
+	return nil
+}
+
+func (t *fakeTable) get(ctx context.Context, key string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	item, ok := t.live(key)
+	if !ok {
+		return "", nil
+	}
+	return item.value, nil
+}
+
+func (t *fakeTable) deleteIf(ctx context.Context, key string, opts dynamo.PutOptions) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.met(key, opts) {
+		return fmt.Errorf("failed to delete key from dynamodb: %w", dynamo.ErrConditionFailed)
+	}
+
+	delete(t.items, key)
+	return nil
+}
+
+// testStores returns a session map for each store
+func testStores(t *testing.T) map[string]*SessionMap {
+	server := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]*SessionMap{
+		"redis":  {opts: getOptions(), store: &redisStore{rdb: client}},
+		"dynamo": {opts: getOptions(), store: &dynamoStore{table: &fakeTable{items: map[string]fakeItem{}}}},
+	}
+}
+
+var (
+	proxyA = http.Proxy{Host: "10.0.0.1", Port: "8080"}
+	proxyB = http.Proxy{Host: "10.0.0.2", Port: "8080"}
+)
+
+func TestClaim(t *testing.T) {
+	for name, sessions := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			proxy, claimed, err := sessions.Claim(ctx, "user-1", proxyA)
+			if err != nil || !claimed || proxy != proxyA {
+				t.Fatalf("expected to claim the free session, got %v %v %v", proxy, claimed, err)
+			}
+
+			proxy, claimed, err = sessions.Claim(ctx, "user-1", proxyB)
+			if err != nil || claimed || proxy != proxyA {
+				t.Fatalf("expected the existing assignment, got %v %v %v", proxy, claimed, err)
+			}
+
+			assigned, err := sessions.Get(ctx, "user-1")
+			if err != nil || assigned == nil || *assigned != proxyA {
+				t.Errorf("expected %v to be assigned, got %v %v", proxyA, assigned, err)
+			}
+
+			if assigned, _ := sessions.Get(ctx, "user-2"); assigned != nil {
+				t.Errorf("expected no assignment, got %v", assigned)
+			}
+		})
+	}
+}
+
+func TestRefreshAndRelease(t *testing.T) {
+	for name, sessions := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, claimed, _ := sessions.Claim(ctx, "user-1", proxyA); !claimed {
+				t.Fatal("expected to claim the session")
+			}
+
+			if refreshed, err := sessions.Refresh(ctx, "user-1", proxyB); err != nil || refreshed {
+				t.Errorf("expected another proxy not to refresh the session, got %v %v", refreshed, err)
+			}
+			if refreshed, err := sessions.Refresh(ctx, "user-1", proxyA); err != nil || !refreshed {
+				t.Errorf("expected the assigned proxy to refresh the session, got %v %v", refreshed, err)
+			}
+			if refreshed, err := sessions.Refresh(ctx, "user-2", proxyA); err != nil || refreshed {
+				t.Errorf("expected a missing session not to be refreshed, got %v %v", refreshed, err)
+			}
+
+			if err := sessions.Release(ctx, "user-1", proxyB); err != nil {
+				t.Fatal(err)
+			}
+			if assigned, _ := sessions.Get(ctx, "user-1"); assigned == nil {
+				t.Fatal("expected another proxy's release to leave the assignment")
+			}
+
+			if err := sessions.Release(ctx, "user-1", proxyA); err != nil {
+				t.Fatal(err)
+			}
+			if assigned, _ := sessions.Get(ctx, "user-1"); assigned != nil {
+				t.Fatalf("expected the release to remove the assignment, got %v", assigned)
+			}
+
+			if proxy, claimed, err := sessions.Claim(ctx, "user-1", proxyB); err != nil || !claimed || proxy != proxyB {
+				t.Errorf("expected to claim the released session, got %v %v %v", proxy, claimed, err)
+			}
+		})
+	}
+}
+
+func TestClaimConcurrently(t *testing.T) {
+	for name, sessions := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			var winners []http.Proxy
+
+			for i := 0; i < 20; i++ {
+				proxy := http.Proxy{Host: fmt.Sprintf("10.0.1.%d", i), Port: "8080"}
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					assigned, claimed, err := sessions.Claim(context.Background(), "user-1", proxy)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if claimed {
+						mu.Lock()
+						winners = append(winners, assigned)
+						mu.Unlock()
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			if len(winners) != 1 {
+				t.Fatalf("expected exactly one worker to claim the session, got %v", winners)
+			}
+
+			if assigned, _ := sessions.Get(context.Background(), "user-1"); assigned == nil || *assigned != winners[0] {
+				t.Errorf("expected the winner %v to be assigned, got %v", winners[0], assigned)
+			}
+		})
+	}
+}
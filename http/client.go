@@ -2,9 +2,11 @@ package http
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -12,7 +14,10 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/finch-technologies/go-utils/discovery"
 )
 
 // Response represents the response from an HTTP request with optional proxy information
@@ -33,6 +38,7 @@ type RequestOptions struct {
 	Timeout   time.Duration
 	TLSConfig *tls.Config
 	CookieJar *cookiejar.Jar
+	Service   string // Internal service to resolve through the client's resolver, URL is then a path
 }
 
 // Client is a custom HTTP client that can extract proxy information
@@ -40,8 +46,19 @@ type Client struct {
 	timeout   time.Duration
 	tlsConfig *tls.Config
 	cookieJar *cookiejar.Jar
+	resolver  *discovery.Resolver
+	recorder  *Recorder
+	hedger    *hedger
+
+	mu        sync.Mutex
+	closed    bool
+	inFlight  sync.WaitGroup
+	transport *http.Transport // Shared by direct requests so connections are reused and can be closed
 }
 
+// ErrClientClosed is returned for requests made after Shutdown or Close
+var ErrClientClosed = errors.New("http client is shut down")
+
 // NewClient creates a new custom HTTP client
 func NewClient(timeout time.Duration, tlsConfig *tls.Config) *Client {
 	if tlsConfig == nil {
@@ -53,6 +70,7 @@ func NewClient(timeout time.Duration, tlsConfig *tls.Config) *Client {
 	return &Client{
 		timeout:   timeout,
 		tlsConfig: tlsConfig,
+		recorder:  defaultRecorder.Load(),
 	}
 }
 
@@ -68,11 +86,50 @@ func NewClientWithCookieJar(timeout time.Duration, tlsConfig *tls.Config, cookie
 		timeout:   timeout,
 		tlsConfig: tlsConfig,
 		cookieJar: cookieJar,
+		recorder:  defaultRecorder.Load(),
 	}
 }
 
+// WithResolver sets the resolver used for requests that name an internal Service
+//
+// Example:
+//
+//	client := NewClient(10*time.Second, nil).WithResolver(discovery.New())
+//	resp, err := client.Do(ctx, RequestOptions{Method: "GET", Service: "billing", URL: "/invoices/123"})
+func (c *Client) WithResolver(resolver *discovery.Resolver) *Client {
+	c.resolver = resolver
+	return c
+}
+
 // Do performs an HTTP request and returns the response with optional proxy IP
 func (c *Client) Do(ctx context.Context, opts RequestOptions) (*Response, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClientClosed
+	}
+	c.inFlight.Add(1)
+	c.mu.Unlock()
+
+	defer c.inFlight.Done()
+
+	send := c.do
+	if c.hedger != nil {
+		send = c.hedged
+	}
+
+	if c.recorder != nil {
+		return c.recorder.do(ctx, opts, send)
+	}
+
+	return send(ctx, opts)
+}
+
+func (c *Client) do(ctx context.Context, opts RequestOptions) (*Response, error) {
+	if opts.Service != "" {
+		return c.doServiceRequest(ctx, opts)
+	}
+
 	if opts.ProxyURL == "" {
 		// No proxy - use standard HTTP client
 		return c.doDirectRequest(ctx, opts)
@@ -82,6 +139,144 @@ func (c *Client) Do(ctx context.Context, opts RequestOptions) (*Response, error)
 	return c.doProxyRequest(ctx, opts)
 }
 
+// Shutdown stops the client accepting new requests, waits for in-flight requests to finish
+// until ctx is done and closes idle connections. It returns ctx.Err() if requests were still
+// running at the deadline.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	err := client.Shutdown(ctx)
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(done)
+	}()
+
+	var err error
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
+	c.mu.Unlock()
+
+	return err
+}
+
+// Close shuts the client down, waiting for all in-flight requests to finish
+func (c *Client) Close() error {
+	return c.Shutdown(context.Background())
+}
+
+// directTransport returns the transport shared by direct requests
+func (c *Client) directTransport() *http.Transport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.transport == nil {
+		c.transport = &http.Transport{
+			TLSClientConfig:     c.tlsConfig,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			DisableKeepAlives:   false,
+		}
+	}
+
+	return c.transport
+}
+
+// doServiceRequest resolves the service's endpoints and tries them in failover order.
+// Idempotent requests move on to the next endpoint on any error or 5xx response; other
+// requests only when the connection failed before the request was sent.
+func (c *Client) doServiceRequest(ctx context.Context, opts RequestOptions) (*Response, error) {
+	if c.resolver == nil {
+		return nil, fmt.Errorf("no resolver configured for service %s", opts.Service)
+	}
+
+	endpoints, err := c.resolver.Endpoints(ctx, opts.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body has to be replayable to retry against another endpoint
+	var body []byte
+	if opts.Body != nil {
+		body, err = io.ReadAll(opts.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	service := opts.Service
+	path := opts.URL
+
+	var lastResp *Response
+	var lastErr error
+
+	for _, endpoint := range endpoints {
+		opts.Service = ""
+		opts.URL = strings.TrimRight(endpoint.URL, "/") + "/" + strings.TrimLeft(path, "/")
+		opts.Body = nil
+		if body != nil {
+			opts.Body = bytes.NewReader(body)
+		}
+
+		resp, err := c.do(ctx, opts)
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		c.resolver.MarkFailed(endpoint)
+
+		if !idempotent(opts.Method) && !notSent(err) {
+			return resp, err
+		}
+
+		lastResp, lastErr = resp, err
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("all endpoints of service %s failed: %w", service, lastErr)
+	}
+
+	return lastResp, nil
+}
+
+// idempotent reports whether a request can safely be repeated against another endpoint
+// after the first one may already have processed it
+func idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// notSent reports whether a request failed while connecting, before any of it was sent
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
 // doDirectRequest performs a request without proxy
 func (c *Client) doDirectRequest(ctx context.Context, opts RequestOptions) (*Response, error) {
 	// Use cookie jar from opts first, then from client
@@ -93,14 +288,8 @@ func (c *Client) doDirectRequest(ctx context.Context, opts RequestOptions) (*Res
 	}
 
 	client := &http.Client{
-		Timeout: c.timeout,
-		Transport: &http.Transport{
-			TLSClientConfig:     c.tlsConfig,
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-			DisableKeepAlives:   false,
-		},
+		Timeout:   c.timeout,
+		Transport: c.directTransport(),
 	}
 
 	// Only set the cookie jar if it's not nil
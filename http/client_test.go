@@ -3,11 +3,15 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/finch-technologies/go-utils/discovery"
 )
 
 func TestClient_DoDirectRequest(t *testing.T) {
@@ -106,3 +110,150 @@ func TestBasicAuth(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, result)
 	}
 }
+
+func TestClient_ServiceFailover(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/invoices/1" {
+			t.Errorf("Expected path /invoices/1, got %s", r.URL.Path)
+		}
+		w.Write([]byte("invoice"))
+	}))
+	defer working.Close()
+
+	resolver := discovery.New(discovery.Options{
+		Sources: []discovery.Source{discovery.StaticSource{"billing": {
+			{URL: failing.URL, Priority: 0},
+			{URL: working.URL, Priority: 1},
+		}}},
+		HealthCheck: func(ctx context.Context, endpoint discovery.Endpoint) error { return nil },
+	})
+
+	client := NewClient(5*time.Second, nil).WithResolver(resolver)
+
+	resp, err := client.Do(context.Background(), RequestOptions{Method: "GET", Service: "billing", URL: "/invoices/1"})
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	if string(resp.Body) != "invoice" {
+		t.Errorf("Expected 'invoice', got %s", string(resp.Body))
+	}
+}
+
+func TestClient_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, nil)
+	ctx := context.Background()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Do(ctx, RequestOptions{Method: "GET", URL: server.URL})
+		result <- err
+	}()
+
+	<-started
+
+	// The deadline passes while the request is still in flight
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if err := client.Shutdown(shortCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+
+	if _, err := client.Do(ctx, RequestOptions{Method: "GET", URL: server.URL}); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("Do() after shutdown error = %v, want ErrClientClosed", err)
+	}
+
+	close(release)
+
+	if err := <-result; err != nil {
+		t.Fatalf("in-flight request error = %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestClient_ServiceFailoverMethods(t *testing.T) {
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		method       string
+		first        string // "5xx" or "refused"
+		wantFailover bool
+	}{
+		{"GET on 5xx", "GET", "5xx", true},
+		{"PUT on 5xx", "PUT", "5xx", true},
+		{"DELETE on 5xx", "DELETE", "5xx", true},
+		{"POST on 5xx", "POST", "5xx", false},
+		{"PATCH on 5xx", "PATCH", "5xx", false},
+		{"POST on connection refused", "POST", "refused", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var firstCalls, secondCalls atomic.Int32
+
+			failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				firstCalls.Add(1)
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer failing.Close()
+
+			working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				secondCalls.Add(1)
+				w.Write([]byte("ok"))
+			}))
+			defer working.Close()
+
+			first := failing.URL
+			if tt.first == "refused" {
+				first = closed.URL
+			}
+
+			resolver := discovery.New(discovery.Options{
+				Sources: []discovery.Source{discovery.StaticSource{"billing": {
+					{URL: first, Priority: 0},
+					{URL: working.URL, Priority: 1},
+				}}},
+				HealthCheck: func(ctx context.Context, endpoint discovery.Endpoint) error { return nil },
+			})
+
+			client := NewClient(5*time.Second, nil).WithResolver(resolver)
+
+			resp, err := client.Do(context.Background(), RequestOptions{Method: tt.method, Service: "billing", URL: "/invoices", Body: strings.NewReader("{}")})
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			if tt.wantFailover {
+				if resp.StatusCode != http.StatusOK || secondCalls.Load() != 1 {
+					t.Errorf("Expected the request to fail over, got status %d", resp.StatusCode)
+				}
+				return
+			}
+
+			if resp.StatusCode != http.StatusBadGateway || firstCalls.Load() != 1 || secondCalls.Load() != 0 {
+				t.Errorf("Expected the 5xx response without failover, got status %d after %d retries", resp.StatusCode, secondCalls.Load())
+			}
+		})
+	}
+}
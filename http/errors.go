@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// ErrorDecoder turns the body of an unsuccessful response into a typed error. Returning nil
+// falls back to the generic status code error.
+type ErrorDecoder func(status int, body []byte) error
+
+var (
+	errorDecodersMu sync.RWMutex
+	errorDecoders   = map[string]ErrorDecoder{}
+)
+
+// JSONError returns an ErrorDecoder unmarshaling error bodies into E. Bodies that aren't
+// valid JSON fall back to the generic status code error.
+//
+// Example:
+//
+//	type APIError struct {
+//	    Code    string `json:"code"`
+//	    Message string `json:"message"`
+//	}
+//
+//	func (e *APIError) Error() string { return e.Code + ": " + e.Message }
+//
+//	http.RegisterErrorDecoder("api.example.com", http.JSONError[*APIError]())
+//
+//	_, err := http.Fetch[Order](ctx, "https://api.example.com/orders/1", "GET", nil)
+//	var apiErr *APIError
+//	if errors.As(err, &apiErr) && apiErr.Code == "not_found" { ... }
+func JSONError[E error]() ErrorDecoder {
+	return func(status int, body []byte) error {
+		var e E
+		if err := json.Unmarshal(body, &e); err != nil {
+			return nil
+		}
+
+		// A "null" body leaves a pointer E nil
+		if v := reflect.ValueOf(e); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+			return nil
+		}
+
+		return e
+	}
+}
+
+// RegisterErrorDecoder sets the decoder for unsuccessful responses from host, used by
+// Fetch, FetchRaw and FetchWithMeta when FetchOptions.ErrorDecoder is not set
+func RegisterErrorDecoder(host string, decoder ErrorDecoder) {
+	errorDecodersMu.Lock()
+	defer errorDecodersMu.Unlock()
+
+	if decoder == nil {
+		delete(errorDecoders, host)
+		return
+	}
+
+	errorDecoders[host] = decoder
+}
+
+// statusError returns the error for an unsuccessful response, wrapping the typed error
+// from the request's decoder when there is one
+func statusError(uri string, status int, body []byte, decoder ErrorDecoder) error {
+	if decoder == nil {
+		if u, err := url.Parse(uri); err == nil {
+			errorDecodersMu.RLock()
+			decoder = errorDecoders[u.Host]
+			errorDecodersMu.RUnlock()
+		}
+	}
+
+	if decoder != nil {
+		if err := decoder(status, body); err != nil {
+			return fmt.Errorf("http request was unsuccessful with status code: %d. request url: %s: %w", status, uri, err)
+		}
+	}
+
+	return fmt.Errorf("http request was unsuccessful with status code: %d. request url: %s", status, uri)
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type testAPIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *testAPIError) Error() string { return e.Code + ": " + e.Message }
+
+func TestStatusErrorDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		switch r.URL.Path {
+		case "/json":
+			w.Write([]byte(`{"code":"not_found","message":"order 1 not found"}`))
+		case "/null":
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`<html>not found</html>`))
+		}
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name     string
+		path     string
+		register bool
+		opts     FetchOptions
+		wantCode string
+	}{
+		{name: "option decoder", path: "/json", opts: FetchOptions{ErrorDecoder: JSONError[*testAPIError]()}, wantCode: "not_found"},
+		{name: "registered decoder", path: "/json", register: true, wantCode: "not_found"},
+		{name: "no decoder", path: "/json"},
+		{name: "html body", path: "/html", register: true},
+		{name: "null body", path: "/null", register: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.register {
+				RegisterErrorDecoder(u.Host, JSONError[*testAPIError]())
+				defer RegisterErrorDecoder(u.Host, nil)
+			}
+
+			_, err := Fetch[map[string]string](context.Background(), server.URL+tt.path, "GET", nil, tt.opts)
+			if err == nil {
+				t.Fatal("Fetch() expected error")
+			}
+
+			var apiErr *testAPIError
+			if got := errors.As(err, &apiErr); got != (tt.wantCode != "") {
+				t.Fatalf("errors.As() = %v for %v", got, err)
+			}
+
+			if tt.wantCode != "" && apiErr.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", apiErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+// Package fieldmask implements partial responses: a client asks for ?fields=id,name,address.city
+// and the handler returns only those fields of the document. Masks apply to structs (by
+// their JSON field names), maps, slices and raw JSON, so the pruned value encodes to the
+// same JSON the full value would, minus the fields that weren't asked for.
+package fieldmask
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidMask is returned when parsing a malformed mask
+var ErrInvalidMask = errors.New("invalid field mask")
+
+// Mask is a parsed field mask. Each key selects a field; an empty sub-mask selects the whole
+// field and a non-empty one only its masked sub-fields. An empty Mask selects everything.
+type Mask map[string]Mask
+
+// Parse parses a comma separated list of fields. Nested fields are separated with dots or
+// grouped in parentheses, so "id,address.city,address.zip" and "id,address(city,zip)" are
+// the same mask. Selecting a field and one of its sub-fields selects the whole field.
+//
+// Example:
+//
+//	mask, err := fieldmask.Parse("id,name,address(city,zip)")
+//	pruned, err := mask.Apply(user)
+//	json.NewEncoder(w).Encode(pruned)
+func Parse(s string) (Mask, error) {
+	mask := Mask{}
+
+	if strings.TrimSpace(s) == "" {
+		return mask, nil
+	}
+
+	p := &parser{input: s}
+
+	if err := p.list(mask); err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidMask, p.input[p.pos], p.pos)
+	}
+
+	return mask, nil
+}
+
+// FromRequest parses the fields query parameter of r
+func FromRequest(r *http.Request) (Mask, error) {
+	return Parse(strings.Join(r.URL.Query()["fields"], ","))
+}
+
+// String returns the mask in dotted form, with fields sorted
+func (m Mask) String() string {
+	var paths []string
+	m.paths("", &paths)
+	sort.Strings(paths)
+	return strings.Join(paths, ",")
+}
+
+func (m Mask) paths(prefix string, paths *[]string) {
+	for name, sub := range m {
+		if len(sub) == 0 {
+			*paths = append(*paths, prefix+name)
+			continue
+		}
+		sub.paths(prefix+name+".", paths)
+	}
+}
+
+// add selects the whole field at path
+func (m Mask) add(path []string) {
+	parent := m.node(path[:len(path)-1])
+	parent[path[len(path)-1]] = nil
+}
+
+// node returns the sub-mask at path, creating it if needed. If the whole field at path or
+// one of its parents is already selected, it returns a detached mask so sub-fields added to
+// it don't narrow the selection.
+func (m Mask) node(path []string) Mask {
+	for _, name := range path {
+		sub, exists := m[name]
+
+		if exists && len(sub) == 0 {
+			return Mask{}
+		}
+
+		if sub == nil {
+			sub = Mask{}
+			m[name] = sub
+		}
+
+		m = sub
+	}
+
+	return m
+}
+
+// Apply returns the masked fields of value. Structs and maps become maps holding only the
+// selected fields, the mask applies to every element of slices and arrays, and raw JSON
+// ([]byte, json.RawMessage or a string) is decoded first. Fields that don't exist are
+// ignored, so clients can't break a response by asking for too much.
+func (m Mask) Apply(value any) (any, error) {
+	if len(m) == 0 {
+		return value, nil
+	}
+
+	switch v := value.(type) {
+	case json.RawMessage:
+		return m.applyJSON(v)
+	case []byte:
+		return m.applyJSON(v)
+	case string:
+		return m.applyJSON([]byte(v))
+	}
+
+	return m.apply(reflect.ValueOf(value))
+}
+
+func (m Mask) applyJSON(data []byte) (any, error) {
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return m.apply(reflect.ValueOf(decoded))
+}
+
+func (m Mask) apply(v reflect.Value) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if len(m) == 0 {
+		// The whole value is selected
+		return v.Interface(), nil
+	}
+
+	if v.Type().Implements(marshalerType) || reflect.PointerTo(v.Type()).Implements(marshalerType) {
+		// Types with their own JSON encoding are masked by that encoding
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		return m.applyJSON(data)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		result := map[string]any{}
+
+		for _, field := range structFields(v.Type()) {
+			sub, ok := m[field.name]
+			if !ok {
+				continue
+			}
+
+			fieldValue, ok := fieldByIndex(v, field.index)
+			if !ok || !fieldValue.CanInterface() || (field.omitEmpty && isEmpty(fieldValue)) {
+				continue
+			}
+
+			masked, err := sub.apply(fieldValue)
+			if err != nil {
+				return nil, err
+			}
+
+			result[field.name] = masked
+		}
+
+		return result, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface(), nil
+		}
+
+		result := map[string]any{}
+
+		for name, sub := range m {
+			element := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !element.IsValid() {
+				continue
+			}
+
+			masked, err := sub.apply(element)
+			if err != nil {
+				return nil, err
+			}
+
+			result[name] = masked
+		}
+
+		return result, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices encode as base64 strings, which have no fields
+			return v.Interface(), nil
+		}
+
+		result := make([]any, v.Len())
+
+		for i := range result {
+			masked, err := m.apply(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = masked
+		}
+
+		return result, nil
+	default:
+		// Scalars have no fields to mask
+		return v.Interface(), nil
+	}
+}
+
+var marshalerType = reflect.TypeFor[json.Marshaler]()
+
+// field is a struct field as encoding/json sees it
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// fieldCache holds the fields of each struct type masked so far
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// structFields returns the JSON fields of t, including those promoted from embedded structs
+func structFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	fields := collectFields(t, nil)
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]field)
+}
+
+func collectFields(t reflect.Type, index []int) []field {
+	var fields []field
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, collectFields(embedded, fieldIndex)...)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, field{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+
+	return fields
+}
+
+// fieldByIndex returns the field at index, or false if it is behind a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// isEmpty reports whether omitempty drops v
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// parser is a recursive descent parser of masks
+type parser struct {
+	input string
+	pos   int
+}
+
+// list parses fields separated by commas into mask
+func (p *parser) list(mask Mask) error {
+	for {
+		if err := p.path(mask); err != nil {
+			return err
+		}
+
+		if p.pos >= len(p.input) || p.input[p.pos] != ',' {
+			return nil
+		}
+
+		p.pos++
+	}
+}
+
+// path parses a dotted path, optionally followed by a parenthesized list of sub-fields
+func (p *parser) path(mask Mask) error {
+	var path []string
+
+	for {
+		name := p.name()
+		if name == "" {
+			return fmt.Errorf("%w: expected a field name at %d", ErrInvalidMask, p.pos)
+		}
+
+		path = append(path, name)
+
+		if p.pos >= len(p.input) || p.input[p.pos] != '.' {
+			break
+		}
+
+		p.pos++
+	}
+
+	if p.pos >= len(p.input) || p.input[p.pos] != '(' {
+		mask.add(path)
+		return nil
+	}
+
+	p.pos++
+
+	sub := mask.node(path)
+	if err := p.list(sub); err != nil {
+		return err
+	}
+
+	if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+		return fmt.Errorf("%w: missing ) at %d", ErrInvalidMask, p.pos)
+	}
+
+	p.pos++
+
+	return nil
+}
+
+// name parses a field name, trimming surrounding spaces
+func (p *parser) name() string {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+
+	start := p.pos
+
+	for p.pos < len(p.input) && !strings.ContainsRune(",.() ", rune(p.input[p.pos])) {
+		p.pos++
+	}
+
+	name := p.input[start:p.pos]
+
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+
+	return name
+}
@@ -0,0 +1,132 @@
+package fieldmask
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type testBase struct {
+	ID string `json:"id"`
+}
+
+type testUser struct {
+	testBase
+	Name      string        `json:"name"`
+	Email     string        `json:"email,omitempty"`
+	Password  string        `json:"-"`
+	Address   *testAddress  `json:"address"`
+	Previous  []testAddress `json:"previous"`
+	CreatedAt time.Time     `json:"created_at"`
+	Tags      map[string]string
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "id,name", want: "id,name"},
+		{input: " id , address.city ", want: "address.city,id"},
+		{input: "address(city,zip),id", want: "address.city,address.zip,id"},
+		{input: "a(b(c,d),e)", want: "a.b.c,a.b.d,a.e"},
+		{input: "address.city,address", want: "address"},
+		{input: "address,address(city)", want: "address"},
+		{input: "id,", wantErr: true},
+		{input: "address(city", wantErr: true},
+		{input: "address)", wantErr: true},
+		{input: "a..b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			mask, err := Parse(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMask) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInvalidMask", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got := mask.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask_Apply(t *testing.T) {
+	user := testUser{
+		testBase:  testBase{ID: "u1"},
+		Name:      "Thandi",
+		Password:  "secret",
+		Address:   &testAddress{City: "Durban", Zip: "4001"},
+		Previous:  []testAddress{{City: "Cape Town"}, {City: "Johannesburg", Zip: "2000"}},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Tags:      map[string]string{"plan": "pro", "region": "za"},
+	}
+
+	raw, _ := json.Marshal(user)
+
+	tests := []struct {
+		name  string
+		mask  string
+		value any
+		want  string
+	}{
+		{name: "empty mask", mask: "", value: testAddress{City: "Durban"}, want: `{"city":"Durban"}`},
+		{name: "top level", mask: "id,name", value: user, want: `{"id":"u1","name":"Thandi"}`},
+		{name: "nested", mask: "address.city", value: user, want: `{"address":{"city":"Durban"}}`},
+		{name: "slice elements", mask: "previous(zip)", value: user, want: `{"previous":[{},{"zip":"2000"}]}`},
+		{name: "omitempty", mask: "email,name", value: user, want: `{"name":"Thandi"}`},
+		{name: "ignored field", mask: "password,missing,id", value: user, want: `{"id":"u1"}`},
+		{name: "marshaler", mask: "created_at", value: user, want: `{"created_at":"2024-01-02T03:04:05Z"}`},
+		{name: "map", mask: "Tags.plan", value: user, want: `{"Tags":{"plan":"pro"}}`},
+		{name: "raw json", mask: "id,address.city", value: raw, want: `{"address":{"city":"Durban"},"id":"u1"}`},
+		{name: "slice of structs", mask: "city", value: []testAddress{{City: "A", Zip: "1"}}, want: `[{"city":"A"}]`},
+		{name: "nil pointer", mask: "address.city", value: testUser{}, want: `{"address":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask, err := Parse(tt.mask)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.mask, err)
+			}
+
+			pruned, err := mask.Apply(tt.value)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			got, _ := json.Marshal(pruned)
+			if string(got) != tt.want {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/users/u1?fields=id,name&fields=address.city", nil)
+
+	mask, err := FromRequest(r)
+	if err != nil {
+		t.Fatalf("FromRequest() error = %v", err)
+	}
+
+	if got := mask.String(); got != "address.city,id,name" {
+		t.Errorf("FromRequest() = %q", got)
+	}
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// HedgeOptions configures hedged requests
+type HedgeOptions struct {
+	Delay      time.Duration // Fixed delay before the hedge is sent, instead of the host's latency percentile
+	Percentile float64       // Latency percentile of the host used as the delay (default 0.99)
+	MinDelay   time.Duration // Lower bound of the delay (default 50ms)
+	MaxDelay   time.Duration // Upper bound of the delay, and the delay until enough latencies are sampled (default 5s)
+	Samples    int           // Latencies kept per host (default 500)
+}
+
+// minHedgeSamples is how many latencies a host needs before its percentile is used
+const minHedgeSamples = 20
+
+// hedger tracks recent latencies per host to pick hedge delays
+type hedger struct {
+	opts HedgeOptions
+
+	mu        sync.Mutex
+	latencies map[string]*latencyWindow
+}
+
+// latencyWindow is a ring buffer of recent latencies
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// WithHedging enables hedged requests. An idempotent GET or HEAD without a body that hasn't
+// completed after the host's P99 latency is sent a second time; the first successful
+// response is used and the other request is cancelled. Other requests are never hedged.
+//
+// Example:
+//
+//	client := NewClient(30*time.Second, nil).WithHedging(HedgeOptions{MaxDelay: 2 * time.Second})
+func (c *Client) WithHedging(options ...HedgeOptions) *Client {
+	var opts HedgeOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Percentile <= 0 || opts.Percentile >= 1 {
+		opts.Percentile = 0.99
+	}
+	opts.MinDelay = utils.DurationOrDefault(opts.MinDelay, 50*time.Millisecond)
+	opts.MaxDelay = utils.DurationOrDefault(opts.MaxDelay, 5*time.Second)
+	opts.Samples = utils.IntOrDefault(opts.Samples, 500)
+
+	c.hedger = &hedger{
+		opts:      opts,
+		latencies: map[string]*latencyWindow{},
+	}
+
+	return c
+}
+
+// hedgeable reports whether a request is safe to send twice
+func hedgeable(opts RequestOptions) bool {
+	switch strings.ToUpper(opts.Method) {
+	case "", http.MethodGet, http.MethodHead:
+		return opts.Body == nil
+	default:
+		return false
+	}
+}
+
+// hedgeKey returns the host latencies are tracked for
+func hedgeKey(opts RequestOptions) string {
+	if opts.Service != "" {
+		return "service:" + opts.Service
+	}
+
+	if u, err := url.Parse(opts.URL); err == nil {
+		return u.Host
+	}
+
+	return opts.URL
+}
+
+// delay returns how long to wait for a request to host before hedging it
+func (h *hedger) delay(host string) time.Duration {
+	if h.opts.Delay > 0 {
+		return h.opts.Delay
+	}
+
+	h.mu.Lock()
+	window := h.latencies[host]
+	var samples []time.Duration
+	if window != nil {
+		samples = slices.Clone(window.samples)
+	}
+	h.mu.Unlock()
+
+	if len(samples) < minHedgeSamples {
+		return h.opts.MaxDelay
+	}
+
+	slices.Sort(samples)
+	delay := samples[int(float64(len(samples)-1)*h.opts.Percentile)]
+
+	return min(max(delay, h.opts.MinDelay), h.opts.MaxDelay)
+}
+
+// observe records the latency of a successful request to host
+func (h *hedger) observe(host string, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	window := h.latencies[host]
+	if window == nil {
+		window = &latencyWindow{}
+		h.latencies[host] = window
+	}
+
+	if len(window.samples) < h.opts.Samples {
+		window.samples = append(window.samples, latency)
+		return
+	}
+
+	window.samples[window.next] = latency
+	window.next = (window.next + 1) % len(window.samples)
+}
+
+type hedgeResult struct {
+	resp *Response
+	err  error
+}
+
+// succeeded reports whether a response can be used instead of waiting for the other request
+func (r hedgeResult) succeeded() bool {
+	return r.err == nil && r.resp.StatusCode < 500
+}
+
+// hedged sends a request, sending it again when the first attempt is slow, and returns the
+// first successful response. If the first attempt fails before the delay, its result is
+// returned without hedging.
+func (c *Client) hedged(ctx context.Context, opts RequestOptions) (*Response, error) {
+	if !hedgeable(opts) {
+		return c.do(ctx, opts)
+	}
+
+	host := hedgeKey(opts)
+	results := make(chan hedgeResult, 2)
+
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	send := func() {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			start := time.Now()
+			resp, err := c.do(attemptCtx, opts)
+
+			result := hedgeResult{resp: resp, err: err}
+			if result.succeeded() {
+				c.hedger.observe(host, time.Since(start))
+			}
+
+			results <- result
+		}()
+	}
+
+	send()
+
+	timer := time.NewTimer(c.hedger.delay(host))
+	defer timer.Stop()
+
+	pending := 1
+	hedgeSent := false
+	var failed *hedgeResult
+
+	for {
+		select {
+		case result := <-results:
+			pending--
+
+			if result.succeeded() {
+				return result.resp, nil
+			}
+
+			if failed == nil {
+				failed = &result
+			}
+
+			if pending == 0 {
+				return failed.resp, failed.err
+			}
+		case <-timer.C:
+			if !hedgeSent {
+				hedgeSent = true
+				pending++
+				send()
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Hedging(t *testing.T) {
+	var calls atomic.Int32
+
+	// The first request stalls until it is cancelled, the hedge is answered immediately
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("hedged"))
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, nil).WithHedging(HedgeOptions{Delay: 20 * time.Millisecond})
+
+	start := time.Now()
+	resp, err := client.Do(context.Background(), RequestOptions{Method: "GET", URL: server.URL})
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	if string(resp.Body) != "hedged" {
+		t.Errorf("Expected the hedged response, got %q", resp.Body)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the hedge to answer quickly, took %s", elapsed)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 requests, got %d", calls.Load())
+	}
+}
+
+func TestClient_HedgingSkipsNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, nil).WithHedging(HedgeOptions{Delay: 10 * time.Millisecond})
+
+	tests := []struct {
+		name string
+		opts RequestOptions
+	}{
+		{name: "post", opts: RequestOptions{Method: "POST", URL: server.URL}},
+		{name: "get with body", opts: RequestOptions{Method: "GET", URL: server.URL, Body: strings.NewReader("{}")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+
+			if _, err := client.Do(context.Background(), tt.opts); err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			if calls.Load() != 1 {
+				t.Errorf("Expected 1 request, got %d", calls.Load())
+			}
+		})
+	}
+}
+
+func TestHedger_Delay(t *testing.T) {
+	h := NewClient(time.Second, nil).WithHedging(HedgeOptions{MinDelay: 5 * time.Millisecond, MaxDelay: time.Second}).hedger
+
+	if delay := h.delay("example.com"); delay != time.Second {
+		t.Errorf("Expected MaxDelay without samples, got %s", delay)
+	}
+
+	for i := 1; i <= 100; i++ {
+		h.observe("example.com", time.Duration(i)*time.Millisecond)
+	}
+
+	if delay := h.delay("example.com"); delay != 99*time.Millisecond {
+		t.Errorf("Expected the P99 latency, got %s", delay)
+	}
+
+	if delay := h.delay("other.com"); delay != time.Second {
+		t.Errorf("Expected latencies to be tracked per host, got %s", delay)
+	}
+}
@@ -23,6 +23,10 @@ type FetchOptions struct {
 	Headers        *http.Header
 	Cookies        *[]http.Cookie
 	Proxy          *Proxy
+	ProxyPool      ProxyPool     // Pool to take a proxy from per request, used when Proxy is nil
+	ProxySelector  ProxySelector // Picks the proxy per request, used when Proxy is nil; takes precedence over ProxyPool
+	ProxyResult    *ProxyResult  // Receives the proxy used and the X-Proxy-IP it reported (optional)
+	ErrorDecoder   ErrorDecoder  // Decodes unsuccessful response bodies into typed errors, overriding RegisterErrorDecoder
 	ShowCurl       bool
 	RawBody        bool
 	CookieJar      *cookiejar.Jar
@@ -61,6 +65,51 @@ func Fetch[T interface{}](ctx context.Context, url, method string, payload inter
 	return JsonBody[T](ctx, resp)
 }
 
+// ResponseMeta describes the response behind a FetchWithMeta result
+type ResponseMeta struct {
+	Status   int           // HTTP status code, 0 if no response was received
+	Headers  http.Header   // Response headers, e.g. rate limit headers and ETags
+	Duration time.Duration // Time taken by the request, including proxy selection
+	ProxyIP  string        // X-Proxy-IP reported by the proxy, if any
+}
+
+// FetchWithMeta is Fetch that also returns the response status, headers and timing. The
+// meta is filled in for unsuccessful responses too, so callers can inspect a 429's
+// Retry-After alongside the error.
+//
+// Example:
+//
+//	page, meta, err := http.FetchWithMeta[Page](ctx, url, "GET", nil)
+//	remaining := meta.Headers.Get("X-RateLimit-Remaining")
+func FetchWithMeta[T interface{}](ctx context.Context, url, method string, payload interface{}, options ...FetchOptions) (T, ResponseMeta, error) {
+	var jsonResp T
+	var meta ResponseMeta
+
+	opts := getOpts(options)
+	opts.ReturnResponse = true
+
+	if opts.ProxyResult == nil {
+		opts.ProxyResult = &ProxyResult{}
+	}
+
+	start := time.Now()
+	resp, err := FetchRaw(ctx, url, method, payload, opts)
+	meta.Duration = time.Since(start)
+	meta.ProxyIP = opts.ProxyResult.ProxyIP
+
+	if resp != nil {
+		meta.Status = resp.StatusCode
+		meta.Headers = resp.Header
+	}
+
+	if err != nil {
+		return jsonResp, meta, err
+	}
+
+	jsonResp, err = JsonBody[T](ctx, resp)
+	return jsonResp, meta, err
+}
+
 func BodyBytes(ctx context.Context, response *http.Response) ([]byte, error) {
 
 	bodyBytes, err := io.ReadAll(response.Body)
@@ -114,7 +163,11 @@ func FetchRaw(ctx context.Context, uri, method string, payload interface{}, opti
 	opts := getOpts(options)
 
 	// Build proxy URL if proxy options exist
-	proxyURL := getProxyUrl(opts.Proxy)
+	proxy, err := selectProxy(ctx, uri, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select proxy: %w", err)
+	}
+	proxyURL := getProxyUrl(proxy)
 
 	// Convert headers to map[string]string
 	var headers map[string]string
@@ -177,7 +230,6 @@ func FetchRaw(ctx context.Context, uri, method string, payload interface{}, opti
 	}
 
 	var resp *HttpxResponse
-	var err error
 	timeout := utils.DurationOrDefault(opts.Timeout, 30*time.Second)
 
 	// Use our custom Request function with CookieJar support
@@ -187,6 +239,17 @@ func FetchRaw(ctx context.Context, uri, method string, payload interface{}, opti
 		resp, err = Request(ctx, method, uri, body, headers, proxyURL, timeout)
 	}
 
+	if opts.Proxy == nil && opts.ProxySelector == nil && opts.ProxyPool != nil {
+		opts.ProxyPool.Release(proxy, err)
+	}
+
+	if opts.ProxyResult != nil {
+		*opts.ProxyResult = ProxyResult{Proxy: proxy}
+		if resp != nil {
+			opts.ProxyResult.ProxyIP = resp.ProxyIP
+		}
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("http request failed with error: %s", err)
 	}
@@ -203,10 +266,16 @@ func FetchRaw(ctx context.Context, uri, method string, payload interface{}, opti
 		ContentLength: int64(len(resp.Body)),
 	}
 
+	// Surface the proxy's X-Proxy-IP, which arrives on the CONNECT response rather than the
+	// response itself
+	if resp.ProxyIP != "" && httpResp.Header != nil && httpResp.Header.Get("X-Proxy-IP") == "" {
+		httpResp.Header.Set("X-Proxy-IP", resp.ProxyIP)
+	}
+
 	if opts.ReturnResponse && resp.StatusCode >= 300 {
-		return httpResp, fmt.Errorf("http request was unsuccessful with status code: %d. request url: %s", resp.StatusCode, uri)
+		return httpResp, statusError(uri, resp.StatusCode, resp.Body, opts.ErrorDecoder)
 	} else if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("http request was unsuccessful with status code: %d. request url: %s", resp.StatusCode, uri)
+		return nil, statusError(uri, resp.StatusCode, resp.Body, opts.ErrorDecoder)
 	}
 
 	if opts.ShowCurl {
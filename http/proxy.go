@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoProxyAvailable is returned by a pool when every proxy is cooling down after failures
+var ErrNoProxyAvailable = errors.New("no proxy available")
+
+// ProxyPool hands out a proxy per request. Release is called with the request's error (nil
+// on success) so pools can rotate away from failing proxies.
+type ProxyPool interface {
+	Get(ctx context.Context) (*Proxy, error)
+	Release(proxy *Proxy, err error)
+}
+
+// ProxySelector picks the proxy for a request, e.g. from a session affinity map. Returning
+// nil sends the request without a proxy.
+type ProxySelector func(ctx context.Context, url string) (*Proxy, error)
+
+// ProxyResult receives the proxy a request was sent through
+type ProxyResult struct {
+	Proxy   *Proxy // Proxy used for the request, nil if none
+	ProxyIP string // X-Proxy-IP header from the proxy's CONNECT response, if it sent one
+}
+
+// RoundRobinPool is a ProxyPool that rotates through a fixed list of proxies, skipping
+// proxies that failed within the cooldown period
+type RoundRobinPool struct {
+	Cooldown time.Duration // How long a failed proxy is skipped (default 30s)
+
+	mu          sync.Mutex
+	proxies     []Proxy
+	next        int
+	failedUntil map[int]time.Time
+}
+
+// NewRoundRobinPool creates a pool rotating through proxies
+//
+// Example:
+//
+//	pool := http.NewRoundRobinPool(proxies...)
+//	result, err := http.Fetch[Page](ctx, url, "GET", nil, http.FetchOptions{ProxyPool: pool})
+func NewRoundRobinPool(proxies ...Proxy) *RoundRobinPool {
+	return &RoundRobinPool{
+		Cooldown:    30 * time.Second,
+		proxies:     proxies,
+		failedUntil: map[int]time.Time{},
+	}
+}
+
+// Get returns the next proxy that is not cooling down
+func (p *RoundRobinPool) Get(ctx context.Context) (*Proxy, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	for range p.proxies {
+		i := p.next
+		p.next = (p.next + 1) % len(p.proxies)
+
+		if now.Before(p.failedUntil[i]) {
+			continue
+		}
+
+		proxy := p.proxies[i]
+		return &proxy, nil
+	}
+
+	return nil, ErrNoProxyAvailable
+}
+
+// Release puts a proxy that failed into cooldown
+func (p *RoundRobinPool) Release(proxy *Proxy, err error) {
+	if proxy == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, candidate := range p.proxies {
+		if candidate.Host != proxy.Host || candidate.Port != proxy.Port || candidate.Username != proxy.Username {
+			continue
+		}
+
+		if err != nil {
+			p.failedUntil[i] = time.Now().Add(p.Cooldown)
+		} else {
+			delete(p.failedUntil, i)
+		}
+	}
+}
+
+// selectProxy returns the proxy for a request, in order of precedence the static Proxy,
+// the ProxySelector and the ProxyPool
+func selectProxy(ctx context.Context, uri string, opts FetchOptions) (*Proxy, error) {
+	switch {
+	case opts.Proxy != nil:
+		return opts.Proxy, nil
+	case opts.ProxySelector != nil:
+		return opts.ProxySelector(ctx, uri)
+	case opts.ProxyPool != nil:
+		return opts.ProxyPool.Get(ctx)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRoundRobinPool(t *testing.T) {
+	pool := NewRoundRobinPool(
+		Proxy{Host: "a", Port: "1"},
+		Proxy{Host: "b", Port: "1"},
+	)
+	ctx := context.Background()
+
+	first, _ := pool.Get(ctx)
+	second, _ := pool.Get(ctx)
+	if first.Host != "a" || second.Host != "b" {
+		t.Fatalf("Get() = %s, %s, want a, b", first.Host, second.Host)
+	}
+
+	pool.Release(first, errors.New("connection refused"))
+
+	for i := 0; i < 3; i++ {
+		proxy, err := pool.Get(ctx)
+		if err != nil || proxy.Host != "b" {
+			t.Fatalf("Get() = %v, %v, want b while a is cooling down", proxy, err)
+		}
+	}
+
+	pool.Release(second, errors.New("connection refused"))
+
+	if _, err := pool.Get(ctx); !errors.Is(err, ErrNoProxyAvailable) {
+		t.Fatalf("Get() error = %v, want ErrNoProxyAvailable", err)
+	}
+
+	pool.Release(first, nil)
+
+	if proxy, err := pool.Get(ctx); err != nil || proxy.Host != "a" {
+		t.Fatalf("Get() = %v, %v, want a after success", proxy, err)
+	}
+}
+
+func TestFetchRaw_ProxySelection(t *testing.T) {
+	// A plain HTTP proxy receives the absolute target URL and answers directly
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"via":"proxy"}`))
+	}))
+	defer proxyServer.Close()
+
+	u, _ := url.Parse(proxyServer.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	proxy := Proxy{Host: host, Port: port}
+
+	tests := []struct {
+		name string
+		opts FetchOptions
+	}{
+		{name: "selector", opts: FetchOptions{ProxySelector: func(ctx context.Context, uri string) (*Proxy, error) {
+			return &proxy, nil
+		}}},
+		{name: "pool", opts: FetchOptions{ProxyPool: NewRoundRobinPool(proxy)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result ProxyResult
+			tt.opts.ProxyResult = &result
+			tt.opts.Timeout = 5 * time.Second
+
+			body, err := Fetch[map[string]string](context.Background(), "http://example.invalid/", "GET", nil, tt.opts)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			if body["via"] != "proxy" {
+				t.Errorf("Fetch() = %v, want response from proxy", body)
+			}
+
+			if result.Proxy == nil || result.Proxy.Port != port {
+				t.Errorf("ProxyResult.Proxy = %+v, want %+v", result.Proxy, proxy)
+			}
+		})
+	}
+}
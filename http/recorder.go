@@ -0,0 +1,251 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/finch-technologies/go-utils/storage/filesystem"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrNoRecording is returned in replay mode for a request that has no recorded response
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// RecordMode controls whether a Recorder hits the network
+type RecordMode string
+
+const (
+	RecordModeReplay         RecordMode = "replay"           // Only serve recorded responses, fail on unknown requests
+	RecordModeRecord         RecordMode = "record"           // Always send requests and overwrite recordings
+	RecordModeReplayOrRecord RecordMode = "replay_or_record" // Serve recordings, sending and recording unknown requests
+)
+
+// RecorderOptions configures a Recorder
+type RecorderOptions struct {
+	Mode         RecordMode               // Default from HTTP_RECORD_MODE, else RecordModeReplay
+	Storage      *filesystem.LocalStorage // Where recordings are kept (default testdata/http)
+	MatchHeaders []string                 // Request headers included in the fingerprint, e.g. Authorization for per-user responses
+}
+
+// Recorder records responses of a Client and replays them by request fingerprint, so
+// integration tests run deterministically without hitting live sites. The fingerprint
+// covers the method, URL, body and MatchHeaders.
+type Recorder struct {
+	mode         RecordMode
+	storage      *filesystem.LocalStorage
+	matchHeaders []string
+}
+
+// recording is the stored form of a request and its response
+type recording struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for non UTF-8 bodies
+	ProxyIP      string      `json:"proxy_ip,omitempty"`
+}
+
+var defaultRecorder atomic.Pointer[Recorder]
+
+// NewRecorder creates a recorder
+//
+// Example:
+//
+//	rec, err := http.NewRecorder(http.RecorderOptions{Mode: http.RecordModeReplayOrRecord})
+//	client := http.NewClient(10*time.Second, nil).WithRecorder(rec)
+func NewRecorder(options ...RecorderOptions) (*Recorder, error) {
+	var opts RecorderOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	mode := RecordMode(utils.StringOrDefault(string(opts.Mode), utils.StringOrDefault(os.Getenv("HTTP_RECORD_MODE"), string(RecordModeReplay))))
+
+	switch mode {
+	case RecordModeReplay, RecordModeRecord, RecordModeReplayOrRecord:
+	default:
+		return nil, fmt.Errorf("invalid record mode %q", mode)
+	}
+
+	storage := opts.Storage
+	if storage == nil {
+		var err error
+		storage, err = filesystem.Init(filesystem.LocalStorageOptions{BasePath: "testdata/http"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialise recording storage: %w", err)
+		}
+	}
+
+	return &Recorder{
+		mode:         mode,
+		storage:      storage,
+		matchHeaders: opts.MatchHeaders,
+	}, nil
+}
+
+// UseRecorder makes every client created afterwards, including those behind Fetch and
+// Request, use rec. It returns a function restoring the previous recorder.
+//
+// Example:
+//
+//	func TestScraper(t *testing.T) {
+//	    rec, _ := http.NewRecorder()
+//	    defer http.UseRecorder(rec)()
+//	    ...
+//	}
+func UseRecorder(rec *Recorder) (restore func()) {
+	previous := defaultRecorder.Swap(rec)
+	return func() {
+		defaultRecorder.Store(previous)
+	}
+}
+
+// WithRecorder sets the recorder used for the client's requests
+func (c *Client) WithRecorder(rec *Recorder) *Client {
+	c.recorder = rec
+	return c
+}
+
+// do serves a request from its recording, or sends it with send and records the response
+func (r *Recorder) do(ctx context.Context, opts RequestOptions, send func(ctx context.Context, opts RequestOptions) (*Response, error)) (*Response, error) {
+	var body []byte
+	if opts.Body != nil {
+		var err error
+		body, err = io.ReadAll(opts.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		opts.Body = bytes.NewReader(body)
+	}
+
+	target := opts.URL
+	if opts.Service != "" {
+		target = "service://" + opts.Service + "/" + strings.TrimLeft(opts.URL, "/")
+	}
+
+	path := r.path(opts.Method, target, opts.Headers, body)
+
+	if r.mode != RecordModeRecord {
+		if r.storage.FileExists(path) {
+			return r.load(ctx, path)
+		}
+
+		if r.mode == RecordModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoRecording, opts.Method, target)
+		}
+	}
+
+	resp, err := send(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.save(ctx, path, opts.Method, target, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// path returns the storage path of a request's recording, grouped by host
+func (r *Recorder) path(method, target string, headers map[string]string, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", strings.ToUpper(method), target)
+
+	names := make([]string, 0, len(r.matchHeaders))
+	for _, name := range r.matchHeaders {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for key, value := range headers {
+			if http.CanonicalHeaderKey(key) == name {
+				fmt.Fprintf(hash, "%s: %s\n", name, value)
+			}
+		}
+	}
+
+	hash.Write(body)
+
+	host := "unknown"
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	return fmt.Sprintf("%s/%s-%s.json", unsafePathChars.ReplaceAllString(host, "_"), strings.ToLower(method), hex.EncodeToString(hash.Sum(nil))[:32])
+}
+
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9.-]`)
+
+func (r *Recorder) load(ctx context.Context, path string) (*Response, error) {
+	data, err := r.storage.Read(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recording %s: %w", path, err)
+	}
+
+	body := []byte(rec.Body)
+	if rec.BodyEncoding == "base64" {
+		body, err = base64.StdEncoding.DecodeString(rec.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recording %s: %w", path, err)
+		}
+	}
+
+	return &Response{
+		StatusCode: rec.StatusCode,
+		Headers:    rec.Headers,
+		Body:       body,
+		ProxyIP:    rec.ProxyIP,
+	}, nil
+}
+
+func (r *Recorder) save(ctx context.Context, path, method, target string, resp *Response) error {
+	rec := recording{
+		Method:     method,
+		URL:        target,
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Headers,
+		Body:       string(resp.Body),
+		ProxyIP:    resp.ProxyIP,
+	}
+
+	if !utf8.Valid(resp.Body) {
+		rec.Body = base64.StdEncoding.EncodeToString(resp.Body)
+		rec.BodyEncoding = "base64"
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	if _, err := r.storage.Write(ctx, data, path); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return nil
+}
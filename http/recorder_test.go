@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/finch-technologies/go-utils/storage/filesystem"
+)
+
+func TestRecorder(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"name":"` + r.URL.Query().Get("name") + `"}`))
+	}))
+
+	storage := &filesystem.LocalStorage{BasePath: t.TempDir()}
+	ctx := context.Background()
+
+	recorder, err := NewRecorder(RecorderOptions{Mode: RecordModeReplayOrRecord, Storage: storage})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+
+	restore := UseRecorder(recorder)
+	defer restore()
+
+	first, err := Fetch[map[string]string](ctx, server.URL+"/people", "GET", map[string]string{"name": "ada"})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// Recorded responses are served without the live server
+	server.Close()
+
+	replayer, err := NewRecorder(RecorderOptions{Mode: RecordModeReplay, Storage: storage})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+
+	client := NewClient(5*time.Second, nil).WithRecorder(replayer)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "recorded", url: server.URL + "/people?name=ada"},
+		{name: "not recorded", url: server.URL + "/people?name=bob", wantErr: ErrNoRecording},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Do(ctx, RequestOptions{Method: "GET", URL: tt.url})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if string(resp.Body) != `{"name":"ada"}` || resp.Headers.Get("ETag") != `"v1"` {
+				t.Errorf("Do() = %d %v %s", resp.StatusCode, resp.Headers, resp.Body)
+			}
+		})
+	}
+
+	if hits != 1 || first["name"] != "ada" {
+		t.Errorf("hits = %d, first = %v, want a single live request", hits, first)
+	}
+}
@@ -156,3 +156,44 @@ func TestRequestWithNilBody(t *testing.T) {
 		t.Errorf("Expected empty ProxyIP, got %s", resp.ProxyIP)
 	}
 }
+
+func TestFetchWithMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		if r.URL.Path == "/limited" {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "success", path: "/items", wantStatus: http.StatusOK},
+		{name: "rate limited", path: "/limited", wantStatus: http.StatusTooManyRequests, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, meta, err := FetchWithMeta[map[string]string](context.Background(), server.URL+tt.path, "GET", nil)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchWithMeta() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if meta.Status != tt.wantStatus || meta.Headers.Get("X-RateLimit-Remaining") != "41" || meta.Duration <= 0 {
+				t.Errorf("FetchWithMeta() meta = %+v", meta)
+			}
+
+			if !tt.wantErr && body["id"] != "42" {
+				t.Errorf("FetchWithMeta() body = %v", body)
+			}
+		})
+	}
+}
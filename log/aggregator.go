@@ -0,0 +1,208 @@
+package log
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexPattern    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{16,}\b`)
+	numberPattern = regexp.MustCompile(`\d+`)
+	quotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+)
+
+// AggregatorOptions configures an ErrorAggregator
+type AggregatorOptions struct {
+	Window          time.Duration   // How long occurrences are counted before a summary is logged
+	MaxFingerprints int             // Maximum distinct errors tracked per window, further errors are logged directly
+	Logger          LoggerInterface // Logger used for output, defaults to the package logger
+}
+
+// ErrorAggregator fingerprints errors by their normalized message and top stack frame.
+// The first occurrence of an error in a window is logged with its stack trace; repeats
+// are only counted and reported in a single summary when the window is flushed.
+type ErrorAggregator struct {
+	opts    AggregatorOptions
+	mu      sync.Mutex
+	entries map[string]*errorEntry
+}
+
+type errorEntry struct {
+	message     string
+	frame       string
+	sampleStack string
+	count       int
+	firstSeen   time.Time
+	lastSeen    time.Time
+}
+
+func getAggregatorOptions(options ...AggregatorOptions) AggregatorOptions {
+	opts := AggregatorOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+
+	if opts.MaxFingerprints <= 0 {
+		opts.MaxFingerprints = 1000
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = logger
+	}
+
+	return opts
+}
+
+// NewErrorAggregator creates an ErrorAggregator. Call Start to flush summaries periodically.
+func NewErrorAggregator(options ...AggregatorOptions) *ErrorAggregator {
+	return &ErrorAggregator{
+		opts:    getAggregatorOptions(options...),
+		entries: make(map[string]*errorEntry),
+	}
+}
+
+// Start flushes summaries every window until ctx is cancelled, then flushes once more
+func (a *ErrorAggregator) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(a.opts.Window)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				a.Flush()
+				return
+			case <-ticker.C:
+				a.Flush()
+			}
+		}
+	}()
+}
+
+// Error records an error occurrence
+func (a *ErrorAggregator) Error(err error) {
+	if err == nil {
+		return
+	}
+	a.record(err.Error())
+}
+
+// Errorf records a formatted error message occurrence
+func (a *ErrorAggregator) Errorf(s string, v ...any) {
+	a.record(fmt.Sprintf(s, v...))
+}
+
+func (a *ErrorAggregator) record(message string) {
+	frame := callerFrame(3)
+	fingerprint := Fingerprint(message, frame)
+	now := time.Now()
+
+	a.mu.Lock()
+
+	entry, exists := a.entries[fingerprint]
+
+	if exists {
+		entry.count++
+		entry.lastSeen = now
+		a.mu.Unlock()
+		return
+	}
+
+	if len(a.entries) >= a.opts.MaxFingerprints {
+		a.mu.Unlock()
+		a.opts.Logger.Error(message)
+		return
+	}
+
+	stack := string(debug.Stack())
+
+	a.entries[fingerprint] = &errorEntry{
+		message:     message,
+		frame:       frame,
+		sampleStack: stack,
+		count:       1,
+		firstSeen:   now,
+		lastSeen:    now,
+	}
+
+	a.mu.Unlock()
+
+	a.opts.Logger.ErrorStack(stack, "%s", message)
+}
+
+// Flush logs a summary for every error that occurred more than once in the current window and resets the counts
+func (a *ErrorAggregator) Flush() {
+	a.mu.Lock()
+	entries := a.entries
+	a.entries = make(map[string]*errorEntry)
+	a.mu.Unlock()
+
+	fingerprints := make([]string, 0, len(entries))
+	for fingerprint := range entries {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	for _, fingerprint := range fingerprints {
+		entry := entries[fingerprint]
+
+		if entry.count < 2 {
+			continue
+		}
+
+		a.opts.Logger.ErrorFields("Aggregated error: "+entry.message, map[string]any{
+			"fingerprint":  fingerprint,
+			"count":        entry.count,
+			"first_seen":   entry.firstSeen,
+			"last_seen":    entry.lastSeen,
+			"frame":        entry.frame,
+			"sample_stack": entry.sampleStack,
+		})
+	}
+}
+
+// Fingerprint returns a stable identifier for an error message and stack frame. Variable
+// parts of the message such as numbers, IDs and quoted values are normalized away so
+// that repeats of the same failure share a fingerprint.
+func Fingerprint(message, frame string) string {
+	hash := sha1.Sum([]byte(NormalizeErrorMessage(message) + "|" + frame))
+	return hex.EncodeToString(hash[:8])
+}
+
+// NormalizeErrorMessage replaces variable parts of an error message with placeholders
+func NormalizeErrorMessage(message string) string {
+	message = quotedPattern.ReplaceAllString(message, "<str>")
+	message = uuidPattern.ReplaceAllString(message, "<uuid>")
+	message = hexPattern.ReplaceAllString(message, "<hex>")
+	message = numberPattern.ReplaceAllString(message, "<n>")
+	return message
+}
+
+// callerFrame returns "function file:line" of the caller skip frames up the stack
+func callerFrame(skip int) string {
+	pc, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+
+	name := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+	}
+
+	return fmt.Sprintf("%s %s:%d", name, file, line)
+}
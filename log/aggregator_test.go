@@ -0,0 +1,112 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// recordingLogger records the output of an ErrorAggregator
+type recordingLogger struct {
+	LoggerInterface
+	stacks    []string
+	errors    []string
+	summaries []map[string]any
+}
+
+func (l *recordingLogger) ErrorStack(stack, s string, v ...any) {
+	l.stacks = append(l.stacks, fmt.Sprintf(s, v...))
+}
+
+func (l *recordingLogger) Error(v ...any) {
+	l.errors = append(l.errors, fmt.Sprint(v...))
+}
+
+func (l *recordingLogger) ErrorFields(msg string, fields map[string]any) {
+	fields["message"] = msg
+	l.summaries = append(l.summaries, fields)
+}
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"failed to get user 42", "failed to get user <n>"},
+		{"user 9b2f7c1e-3d4a-4b5c-8d9e-0f1a2b3c4d5e not found", "user <uuid> not found"},
+		{"bad pointer 0xc000123abc", "bad pointer <hex>"},
+		{"hash deadbeefdeadbeef mismatch", "hash <hex> mismatch"},
+		{`key "orders/7" missing`, "key <str> missing"},
+		{"key 'a' and 'b'", "key <str> and <str>"},
+		{"no variable parts", "no variable parts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := NormalizeErrorMessage(tt.message); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  [2]string // message and frame
+		equal bool
+	}{
+		{"same message", [2]string{"timeout", "f a.go:1"}, [2]string{"timeout", "f a.go:1"}, true},
+		{"different numbers", [2]string{"user 1 failed", "f a.go:1"}, [2]string{"user 2 failed", "f a.go:1"}, true},
+		{"different messages", [2]string{"timeout", "f a.go:1"}, [2]string{"refused", "f a.go:1"}, false},
+		{"different frames", [2]string{"timeout", "f a.go:1"}, [2]string{"timeout", "g b.go:2"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := Fingerprint(tt.a[0], tt.a[1]), Fingerprint(tt.b[0], tt.b[1])
+
+			if (a == b) != tt.equal {
+				t.Errorf("expected equal %v, got %s and %s", tt.equal, a, b)
+			}
+			if len(a) != 16 {
+				t.Errorf("expected 16 hex characters, got %q", a)
+			}
+		})
+	}
+}
+
+func TestErrorAggregatorFlush(t *testing.T) {
+	logger := &recordingLogger{}
+	aggregator := NewErrorAggregator(AggregatorOptions{Logger: logger, MaxFingerprints: 2})
+
+	for i := 0; i < 3; i++ {
+		aggregator.Errorf("user %d failed", i)
+	}
+	aggregator.Error(errors.New("only once"))
+	aggregator.Error(nil)
+	aggregator.Error(errors.New("over the limit"))
+
+	if len(logger.stacks) != 2 {
+		t.Fatalf("expected the first occurrences to be logged with stacks, got %v", logger.stacks)
+	}
+	if len(logger.errors) != 1 || logger.errors[0] != "over the limit" {
+		t.Fatalf("expected errors past MaxFingerprints to be logged directly, got %v", logger.errors)
+	}
+
+	aggregator.Flush()
+
+	if len(logger.summaries) != 1 {
+		t.Fatalf("expected one summary for the repeated error, got %v", logger.summaries)
+	}
+	if summary := logger.summaries[0]; summary["count"] != 3 || summary["message"] != "Aggregated error: user 0 failed" {
+		t.Errorf("unexpected summary %v", summary)
+	}
+
+	logger.summaries = nil
+	aggregator.Flush()
+
+	if len(logger.summaries) != 0 {
+		t.Errorf("expected Flush to reset the counts, got %v", logger.summaries)
+	}
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/finch-technologies/go-utils/log/zero"
+)
+
+// LevelState describes the current base level and module level overrides
+type LevelState struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// SetLevel changes the base log level at runtime (debug, info, warn, error, ...)
+func SetLevel(level string) error {
+	return zero.SetLevel(level)
+}
+
+// GetLevel returns the current base log level
+func GetLevel() string {
+	return zero.GetLevel()
+}
+
+// SetModuleLevel overrides the log level for a single module, e.g. debug only for "dynamo"
+func SetModuleLevel(module, level string) error {
+	return zero.SetModuleLevel(module, level)
+}
+
+// ClearModuleLevel removes a module level override
+func ClearModuleLevel(module string) {
+	zero.ClearModuleLevel(module)
+}
+
+// Module returns a logger tagged with a module name whose level follows any override set with SetModuleLevel
+func Module(name string) LoggerInterface {
+	Init()
+
+	return zero.New(context.Background(), nil).WithModule(name)
+}
+
+// GetLevels returns the current base level and module level overrides
+func GetLevels() LevelState {
+	return LevelState{
+		Level:   zero.GetLevel(),
+		Modules: zero.GetModuleLevels(),
+	}
+}
+
+// LevelHandler returns an HTTP handler to inspect and change log levels without a restart.
+//
+// GET returns the current LevelState as JSON. POST or PUT changes a level using the
+// "level" and optional "module" query parameters, e.g.
+//
+//	POST /log/level?level=debug&module=dynamo
+//
+// An empty level together with a module clears that module's override.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			level := r.URL.Query().Get("level")
+			module := r.URL.Query().Get("module")
+
+			var err error
+
+			if module == "" {
+				err = SetLevel(level)
+			} else if level == "" {
+				ClearModuleLevel(module)
+			} else {
+				err = SetModuleLevel(module, level)
+			}
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			Infof("Log levels changed: level=%s module=%s", level, module)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GetLevels())
+	})
+}
+
+// WatchSignals reloads LOG_LEVEL and LOG_MODULE_LEVELS from the environment (and .env file)
+// whenever the process receives SIGHUP, until ctx is cancelled.
+func WatchSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := zero.ReloadLevels(); err != nil {
+					Errorf("Failed to reload log levels: %v", err)
+					continue
+				}
+				Infof("Log levels reloaded: %+v", GetLevels())
+			}
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	t.Cleanup(func() {
+		SetLevel("info")
+		ClearModuleLevel("dynamo")
+	})
+
+	handler := LevelHandler()
+
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+		expectedLevel  string
+		expectedModule string // Level of the dynamo module, empty when it has no override
+	}{
+		{"get", http.MethodGet, "", http.StatusOK, "info", ""},
+		{"set base level", http.MethodPost, "?level=warn", http.StatusOK, "warn", ""},
+		{"set module level", http.MethodPut, "?level=debug&module=dynamo", http.StatusOK, "warn", "debug"},
+		{"clear module level", http.MethodPost, "?module=dynamo", http.StatusOK, "warn", ""},
+		{"unknown level", http.MethodPost, "?level=loud", http.StatusBadRequest, "", ""},
+		{"unsupported method", http.MethodDelete, "", http.StatusMethodNotAllowed, "", ""},
+	}
+
+	SetLevel("info")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/log/level"+tt.query, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var state LevelState
+			if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if state.Level != tt.expectedLevel {
+				t.Errorf("expected level %q, got %q", tt.expectedLevel, state.Level)
+			}
+			if state.Modules["dynamo"] != tt.expectedModule {
+				t.Errorf("expected dynamo level %q, got %q", tt.expectedModule, state.Modules["dynamo"])
+			}
+		})
+	}
+}
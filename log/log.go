@@ -2,6 +2,8 @@ package log
 
 import (
 	"context"
+
+	"github.com/finch-technologies/go-utils/log/zero"
 )
 
 var logger = New(context.Background(), nil)
@@ -93,3 +95,14 @@ func Fatalf(s string, v ...any) {
 func GetContext() context.Context {
 	return logger.GetContext()
 }
+
+// RedactFields registers field names whose values are masked in all log output,
+// including structured fields and key=value pairs in messages
+func RedactFields(names ...string) {
+	zero.RedactFields(names...)
+}
+
+// RedactPatterns registers regular expressions whose matches are masked in all log output
+func RedactPatterns(patterns ...string) error {
+	return zero.RedactPatterns(patterns...)
+}
@@ -0,0 +1,193 @@
+package zero
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+)
+
+var (
+	levelOnce    sync.Once
+	levelMu      sync.RWMutex
+	baseLevel    = zerolog.InfoLevel
+	moduleLevels = make(map[string]zerolog.Level)
+
+	// levelGeneration counts level changes, so loggers know when to reapply their level
+	levelGeneration atomic.Uint64
+)
+
+// initLevel reads LOG_LEVEL and LOG_MODULE_LEVELS the first time a logger is created.
+// Later changes are made through SetLevel / SetModuleLevel or ReloadLevels.
+func initLevel() {
+	levelOnce.Do(func() {
+		godotenv.Load()
+		if err := loadLevelsFromEnv(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid log level configuration: %v\n", err)
+		}
+	})
+}
+
+// ReloadLevels re-reads LOG_LEVEL and LOG_MODULE_LEVELS (including any .env file) and applies them.
+// LOG_MODULE_LEVELS is a comma separated list of module=level pairs, e.g. "dynamo=debug,s3=warn".
+func ReloadLevels() error {
+	godotenv.Overload()
+	return loadLevelsFromEnv()
+}
+
+func loadLevelsFromEnv() error {
+	level := zerolog.InfoLevel
+
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		parsed, err := parseLevel(value)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+
+	modules := make(map[string]zerolog.Level)
+
+	for _, pair := range strings.Split(os.Getenv("LOG_MODULE_LEVELS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		module, value, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("invalid module level %q, expected module=level", pair)
+		}
+
+		parsed, err := parseLevel(value)
+		if err != nil {
+			return err
+		}
+		modules[strings.TrimSpace(module)] = parsed
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	baseLevel = level
+	moduleLevels = modules
+	applyGlobalLevel()
+
+	return nil
+}
+
+// SetLevel changes the log level of all loggers without a module override
+func SetLevel(level string) error {
+	initLevel()
+
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	baseLevel = parsed
+	applyGlobalLevel()
+
+	return nil
+}
+
+// GetLevel returns the current base log level
+func GetLevel() string {
+	initLevel()
+
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+
+	return baseLevel.String()
+}
+
+// SetModuleLevel overrides the log level for loggers created with WithModule
+func SetModuleLevel(module, level string) error {
+	initLevel()
+
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	moduleLevels[module] = parsed
+	applyGlobalLevel()
+
+	return nil
+}
+
+// ClearModuleLevel removes a module level override so the module follows the base level again
+func ClearModuleLevel(module string) {
+	initLevel()
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	delete(moduleLevels, module)
+	applyGlobalLevel()
+}
+
+// GetModuleLevels returns a copy of the current module level overrides
+func GetModuleLevels() map[string]string {
+	initLevel()
+
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+
+	levels := make(map[string]string, len(moduleLevels))
+	for module, level := range moduleLevels {
+		levels[module] = level.String()
+	}
+
+	return levels
+}
+
+// applyGlobalLevel lowers the zerolog global level to the most verbose configured level
+// so module overrides can get through; each ZeroLogger applies its own effective level. Must hold levelMu.
+func applyGlobalLevel() {
+	lowest := baseLevel
+	for _, level := range moduleLevels {
+		if level < lowest {
+			lowest = level
+		}
+	}
+	zerolog.SetGlobalLevel(lowest)
+	levelGeneration.Add(1)
+}
+
+// effectiveLevel returns the level that applies to a module
+func effectiveLevel(module string) zerolog.Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+
+	if level, ok := moduleLevels[module]; ok && module != "" {
+		return level
+	}
+
+	return baseLevel
+}
+
+func parseLevel(level string) (zerolog.Level, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+
+	if level == "warning" {
+		level = "warn"
+	}
+
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil || level == "" {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+
+	return parsed, nil
+}
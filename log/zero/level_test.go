@@ -0,0 +1,170 @@
+package zero
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// resetLevels restores the default levels after a test
+func resetLevels(t *testing.T) {
+	t.Cleanup(func() {
+		levelMu.Lock()
+		defer levelMu.Unlock()
+
+		baseLevel = zerolog.InfoLevel
+		moduleLevels = make(map[string]zerolog.Level)
+		applyGlobalLevel()
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level    string
+		expected zerolog.Level
+		wantErr  bool
+	}{
+		{"debug", zerolog.DebugLevel, false},
+		{"INFO", zerolog.InfoLevel, false},
+		{" warn ", zerolog.WarnLevel, false},
+		{"warning", zerolog.WarnLevel, false},
+		{"error", zerolog.ErrorLevel, false},
+		{"trace", zerolog.TraceLevel, false},
+		{"", zerolog.NoLevel, true},
+		{"verbose", zerolog.NoLevel, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := parseLevel(tt.level)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if level != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestLoadLevelsFromEnv(t *testing.T) {
+	tests := []struct {
+		name            string
+		level           string
+		moduleLevels    string
+		expectedLevel   zerolog.Level
+		expectedModules map[string]zerolog.Level
+		wantErr         bool
+	}{
+		{"defaults", "", "", zerolog.InfoLevel, map[string]zerolog.Level{}, false},
+		{"base level", "error", "", zerolog.ErrorLevel, map[string]zerolog.Level{}, false},
+		{"modules", "warn", "dynamo=debug, s3 = error,", zerolog.WarnLevel, map[string]zerolog.Level{"dynamo": zerolog.DebugLevel, "s3": zerolog.ErrorLevel}, false},
+		{"missing level", "", "dynamo", zerolog.InfoLevel, nil, true},
+		{"unknown module level", "", "dynamo=loud", zerolog.InfoLevel, nil, true},
+		{"unknown base level", "loud", "", zerolog.InfoLevel, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetLevels(t)
+			t.Setenv("LOG_LEVEL", tt.level)
+			t.Setenv("LOG_MODULE_LEVELS", tt.moduleLevels)
+
+			err := loadLevelsFromEnv()
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if baseLevel != tt.expectedLevel {
+				t.Errorf("expected level %v, got %v", tt.expectedLevel, baseLevel)
+			}
+			if !reflect.DeepEqual(moduleLevels, tt.expectedModules) {
+				t.Errorf("expected modules %v, got %v", tt.expectedModules, moduleLevels)
+			}
+		})
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	resetLevels(t)
+
+	var buf bytes.Buffer
+	base := zerolog.New(&buf)
+	root := &ZeroLogger{logger: &base, context: context.Background()}
+	dynamo := root.WithModule("dynamo")
+
+	logged := func(z *ZeroLogger, msg string) bool {
+		buf.Reset()
+		z.Debug(msg)
+		return strings.Contains(buf.String(), msg)
+	}
+
+	if err := SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+	if logged(root, "root debug") || logged(dynamo, "dynamo debug") {
+		t.Fatal("expected debug to be filtered at info")
+	}
+
+	if err := SetModuleLevel("dynamo", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !logged(dynamo, "dynamo override") {
+		t.Error("expected the module override to let debug through")
+	}
+	if logged(root, "root still info") {
+		t.Error("expected the base level to stay at info")
+	}
+	if levels := GetModuleLevels(); levels["dynamo"] != "debug" {
+		t.Errorf("expected dynamo=debug, got %v", levels)
+	}
+
+	ClearModuleLevel("dynamo")
+	if logged(dynamo, "dynamo cleared") {
+		t.Error("expected the module to follow the base level after clearing")
+	}
+
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !logged(root, "root debug") || !logged(dynamo, "dynamo debug") {
+		t.Error("expected debug at the debug base level")
+	}
+
+	if err := SetModuleLevel("dynamo", "nope"); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+}
+
+func TestLoggerCachesLevel(t *testing.T) {
+	resetLevels(t)
+
+	base := zerolog.New(&bytes.Buffer{})
+	z := (&ZeroLogger{logger: &base, context: context.Background()}).WithModule("cache")
+
+	first := z.log()
+	if second := z.log(); second != first {
+		t.Error("expected the leveled logger to be reused while levels are unchanged")
+	}
+
+	if err := SetModuleLevel("cache", "error"); err != nil {
+		t.Fatal(err)
+	}
+
+	third := z.log()
+	if third == first {
+		t.Error("expected the leveled logger to be rebuilt after a level change")
+	}
+	if third.GetLevel() != zerolog.ErrorLevel {
+		t.Errorf("expected error level, got %v", third.GetLevel())
+	}
+}
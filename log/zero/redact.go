@@ -0,0 +1,253 @@
+package zero
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces sensitive values in log output
+const RedactedValue = "[REDACTED]"
+
+var (
+	redactMu sync.RWMutex
+
+	// redactedFields holds lower case field name endings whose values are always masked
+	redactedFields = []string{
+		"password",
+		"passwd",
+		"secret",
+		"token",
+		"authorization",
+		"api_key",
+		"apikey",
+		"access_key",
+		"secret_key",
+		"private_key",
+		"cookie",
+		"id_number",
+		"idnumber",
+	}
+
+	// redactPatterns are applied to log messages and string field values
+	redactPatterns []*regexp.Regexp
+)
+
+// RedactFields registers additional field names whose values are masked. Matching is case
+// insensitive and also applies to fields that end with the name (e.g. "user_password"), but
+// not to those that only start with it (e.g. "token_count").
+// Names are also masked in key=value and key: value pairs inside log messages.
+func RedactFields(names ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !containsString(redactedFields, name) {
+			redactedFields = append(redactedFields, name)
+		}
+	}
+}
+
+// RedactPatterns registers regular expressions whose matches are masked in log messages
+// and string field values, e.g. `\b\d{13}\b` for national ID numbers.
+func RedactPatterns(patterns ...string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	redactMu.Lock()
+	defer redactMu.Unlock()
+
+	redactPatterns = append(redactPatterns, compiled...)
+
+	return nil
+}
+
+// isSensitiveField reports whether values of the field should be masked
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+
+	for _, field := range redactedFields {
+		if strings.HasSuffix(name, field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactMessage masks registered patterns and sensitive key=value pairs in a log message
+func redactMessage(msg string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+
+	for _, re := range redactPatterns {
+		msg = re.ReplaceAllString(msg, RedactedValue)
+	}
+
+	lower := strings.ToLower(msg)
+
+	for _, field := range redactedFields {
+		if !strings.Contains(lower, field) {
+			continue
+		}
+		msg = redactKeyValues(msg, keyValuePattern(field))
+	}
+
+	return msg
+}
+
+// redactField masks a structured field value if its name is sensitive or it contains registered patterns
+func redactField(name string, value any) any {
+	if isSensitiveField(name) {
+		return RedactedValue
+	}
+
+	switch v := value.(type) {
+	case string:
+		return redactMessage(v)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, inner := range v {
+			redacted[key] = redactField(key, inner)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, inner := range v {
+			if isSensitiveField(key) {
+				redacted[key] = RedactedValue
+			} else {
+				redacted[key] = redactMessage(inner)
+			}
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, inner := range v {
+			redacted[i] = redactField("", inner)
+		}
+		return redacted
+	case error:
+		return redactMessage(v.Error())
+	default:
+		return redactValue(value)
+	}
+}
+
+// redactValue masks sensitive fields nested in structs, pointers, slices and other maps.
+// These are converted to their JSON form, which is how they are logged anyway, so field
+// names follow their json tags.
+func redactValue(value any) any {
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return value
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		// Don't risk logging a value that can't be inspected
+		return RedactedValue
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return RedactedValue
+	}
+
+	return redactField("", decoded)
+}
+
+var (
+	keyValueMu       sync.Mutex
+	keyValuePatterns = make(map[string]*regexp.Regexp)
+)
+
+// keyValuePattern matches `field=value`, `field: value` and `"field": "value"` for keys ending
+// in a sensitive field name. Quoted values are matched up to the closing quote, and Bearer
+// and Basic schemes are matched with their credential. Groups are the key and separator,
+// the separator character, a quoted value and an unquoted value.
+func keyValuePattern(field string) *regexp.Regexp {
+	keyValueMu.Lock()
+	defer keyValueMu.Unlock()
+
+	re, ok := keyValuePatterns[field]
+	if !ok {
+		re = regexp.MustCompile(`(?i)([\w-]*` + regexp.QuoteMeta(field) + `"?\s*([:=])\s*)(?:"((?:[^"\\]|\\.)*)"|((?:bearer|basic)\s+[^\s",&;}]+|[^\s",&;}]+))`)
+		keyValuePatterns[field] = re
+	}
+
+	return re
+}
+
+// proseWord matches an unquoted value that reads as a word of a sentence
+var proseWord = regexp.MustCompile(`^[a-z]+$`)
+
+// redactKeyValues masks the values of the sensitive field pairs in msg. Colon pairs whose
+// value is a lower case word followed by more words are prose rather than a credential, as
+// in "failed to refresh token: connection refused", and are left alone.
+func redactKeyValues(msg string, re *regexp.Regexp) string {
+	matches := re.FindAllStringSubmatchIndex(msg, -1)
+	if len(matches) == 0 {
+		return msg
+	}
+
+	var b strings.Builder
+	last := 0
+
+	for _, m := range matches {
+		prefix := msg[m[2]:m[3]]
+
+		if m[6] >= 0 {
+			b.WriteString(msg[last:m[2]])
+			b.WriteString(prefix + `"` + RedactedValue + `"`)
+			last = m[1]
+			continue
+		}
+
+		value := msg[m[8]:m[9]]
+		rest := strings.TrimLeft(msg[m[1]:], " \t")
+		if msg[m[4]:m[5]] == ":" && proseWord.MatchString(value) && len(rest) < len(msg[m[1]:]) && rest != "" && isLetter(rest[0]) {
+			continue
+		}
+
+		b.WriteString(msg[last:m[2]])
+		b.WriteString(prefix + RedactedValue)
+		last = m[1]
+	}
+
+	b.WriteString(msg[last:])
+
+	return b.String()
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package zero
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRedactMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		expected string
+	}{
+		{"key=value", "login password=hunter2 user=bob", "login password=[REDACTED] user=bob"},
+		{"colon pair", "password: hunter2", "password: [REDACTED]"},
+		{"colon pair before more pairs", "password: hunter2, user: bob", "password: [REDACTED], user: bob"},
+		{"colon pair with a credential followed by words", "token: abc123 sent to bob", "token: [REDACTED] sent to bob"},
+		{"bearer token", "Authorization: Bearer abc123secret", "Authorization: [REDACTED]"},
+		{"basic credentials", "authorization=Basic dXNlcjpwYXNz done", "authorization=[REDACTED] done"},
+		{"quoted json value", `{"token": "abc def"}`, `{"token": "[REDACTED]"}`},
+		{"compact json", `{"password":"a b","user":"bob"}`, `{"password":"[REDACTED]","user":"bob"}`},
+		{"escaped quote", `{"secret": "a\"b c"}`, `{"secret": "[REDACTED]"}`},
+		{"key ending in field", "access_token=abc refresh", "access_token=[REDACTED] refresh"},
+		{"camel case key", "accessToken: xyz", "accessToken: [REDACTED]"},
+		{"key starting with field", "token_count=5", "token_count=5"},
+		{"wrapped error", "Failed to refresh token: connection refused", "Failed to refresh token: connection refused"},
+		{"wrapped error with a code", "Failed to refresh token: 401 unauthorized", "Failed to refresh token: [REDACTED] unauthorized"},
+		{"no sensitive fields", "user=bob", "user=bob"},
+		{"several pairs", "password=a secret=b", "password=[REDACTED] secret=[REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactMessage(tt.msg); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsSensitiveField(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"password", true},
+		{"user_password", true},
+		{"Authorization", true},
+		{"accessToken", true},
+		{"client_secret", true},
+		{"secret_key", true},
+		{"token_count", false},
+		{"password_length", false},
+		{"user", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSensitiveField(tt.name); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+type credentials struct {
+	Username string
+	Password string
+	APIKey   string `json:"api_key"`
+	Attempts int
+}
+
+type session struct {
+	Token  string   `json:"token"`
+	Scopes []string `json:"scopes"`
+}
+
+func TestRedactField(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		value    any
+		expected any
+	}{
+		{"sensitive name", "password", "hunter2", RedactedValue},
+		{"pairs in a string", "message", "token=abc", "token=[REDACTED]"},
+		{"map", "headers", map[string]string{"Authorization": "Bearer x", "Accept": "json"}, map[string]string{"Authorization": RedactedValue, "Accept": "json"}},
+		{"nested map", "body", map[string]any{"token": "x", "count": 2}, map[string]any{"token": RedactedValue, "count": 2}},
+		{"other values", "count", 3, 3},
+		{"struct", "user", credentials{Username: "jo", Password: "x", APIKey: "y", Attempts: 2}, map[string]any{"Username": "jo", "Password": RedactedValue, "api_key": RedactedValue, "Attempts": json.Number("2")}},
+		{"pointer", "user", &credentials{Username: "jo", Password: "x"}, map[string]any{"Username": "jo", "Password": RedactedValue, "api_key": RedactedValue, "Attempts": json.Number("0")}},
+		{"nested struct", "request", map[string]any{"auth": &session{Token: "x", Scopes: []string{"read"}}}, map[string]any{"auth": map[string]any{"token": RedactedValue, "scopes": []any{"read"}}}},
+		{"slice of structs", "sessions", []session{{Token: "x"}}, []any{map[string]any{"token": RedactedValue, "scopes": nil}}},
+		{"typed map", "limits", map[string]int{"secret": 1, "max": 2}, map[string]any{"secret": RedactedValue, "max": json.Number("2")}},
+		{"nil pointer", "user", (*credentials)(nil), (*credentials)(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactField(tt.field, tt.value); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -6,10 +6,10 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/finch-technologies/go-utils/env"
-	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -17,15 +17,18 @@ import (
 type ZeroLogger struct {
 	logger  *zerolog.Logger
 	context context.Context
+	module  string
+	leveled atomic.Pointer[leveledLogger] // logger with the module's level applied, rebuilt when levels change
 }
 
-func New(ctx context.Context, ctxFields any) *ZeroLogger {
-	godotenv.Load()
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+// leveledLogger is a logger with the effective level of a level generation applied
+type leveledLogger struct {
+	generation uint64
+	logger     zerolog.Logger
+}
 
-	if os.Getenv("LOG_LEVEL") == "debug" {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	}
+func New(ctx context.Context, ctxFields any) *ZeroLogger {
+	initLevel()
 
 	var loggerCtx zerolog.Context
 
@@ -41,7 +44,7 @@ func New(ctx context.Context, ctxFields any) *ZeroLogger {
 	if ctxFields != nil {
 		for key, value := range getKeyValues(ctxFields) {
 			if value != "" {
-				loggerCtx = loggerCtx.Str(key, value)
+				loggerCtx = loggerCtx.Str(key, redactField(key, value).(string))
 			}
 		}
 	}
@@ -64,6 +67,36 @@ func FromContext(ctx context.Context) *ZeroLogger {
 	}
 }
 
+// WithModule returns a copy of the logger tagged with a module name. Its level can be
+// overridden independently of the base level using SetModuleLevel.
+func (z *ZeroLogger) WithModule(module string) *ZeroLogger {
+	logger := z.logger.With().Str("module", module).Logger()
+
+	return &ZeroLogger{
+		logger:  &logger,
+		context: logger.WithContext(z.context),
+		module:  module,
+	}
+}
+
+// log returns the underlying logger with the current effective level of its module applied.
+// The leveled logger is cached until the levels change.
+func (z *ZeroLogger) log() *zerolog.Logger {
+	generation := levelGeneration.Load()
+
+	if cached := z.leveled.Load(); cached != nil && cached.generation == generation {
+		return &cached.logger
+	}
+
+	leveled := &leveledLogger{
+		generation: generation,
+		logger:     z.logger.Level(effectiveLevel(z.module)),
+	}
+	z.leveled.Store(leveled)
+
+	return &leveled.logger
+}
+
 // withTrace adds trace_id and span_id to a zerolog event when the logger was
 // created with a context that holds an active OTEL span. No-op otherwise.
 func (z *ZeroLogger) withTrace(event *zerolog.Event) *zerolog.Event {
@@ -107,110 +140,110 @@ func (z *ZeroLogger) GetContext() context.Context {
 }
 
 func (z *ZeroLogger) Debug(v ...any) {
-	z.withTrace(z.logger.Debug()).Msg(fmt.Sprint(v...))
+	z.withTrace(z.log().Debug()).Msg(redactMessage(fmt.Sprint(v...)))
 }
 
 func (z *ZeroLogger) Debugf(s string, v ...any) {
-	z.withTrace(z.logger.Debug()).Msg(fmt.Sprintf(s, v...))
+	z.withTrace(z.log().Debug()).Msg(redactMessage(fmt.Sprintf(s, v...)))
 }
 
 func (z *ZeroLogger) Info(v ...any) {
-	z.withTrace(z.logger.Info()).Msg(fmt.Sprint(v...))
+	z.withTrace(z.log().Info()).Msg(redactMessage(fmt.Sprint(v...)))
 }
 
 func (z *ZeroLogger) Infof(s string, v ...any) {
-	z.withTrace(z.logger.Info()).Msg(fmt.Sprintf(s, v...))
+	z.withTrace(z.log().Info()).Msg(redactMessage(fmt.Sprintf(s, v...)))
 }
 
 func (z *ZeroLogger) Warning(v ...any) {
-	z.withTrace(z.logger.Warn()).Msg(fmt.Sprint(v...))
+	z.withTrace(z.log().Warn()).Msg(redactMessage(fmt.Sprint(v...)))
 }
 
 func (z *ZeroLogger) Warningf(s string, v ...any) {
-	z.withTrace(z.logger.Warn()).Msg(fmt.Sprintf(s, v...))
+	z.withTrace(z.log().Warn()).Msg(redactMessage(fmt.Sprintf(s, v...)))
 }
 
 func (z *ZeroLogger) Error(v ...any) {
-	z.withTrace(z.logger.Error()).Stack().Msg(fmt.Sprint(v...))
+	z.withTrace(z.log().Error()).Stack().Msg(redactMessage(fmt.Sprint(v...)))
 }
 
 func (z *ZeroLogger) Errorf(s string, v ...any) {
-	z.withTrace(z.logger.Error()).Stack().Msg(fmt.Sprintf(s, v...))
+	z.withTrace(z.log().Error()).Stack().Msg(redactMessage(fmt.Sprintf(s, v...)))
 }
 
 func (z *ZeroLogger) ErrorStack(stack, s string, v ...any) {
-	z.withTrace(z.logger.Error()).Stack().Msg(fmt.Sprintf(s, v...) + "\n\n" + stack)
+	z.withTrace(z.log().Error()).Stack().Msg(redactMessage(fmt.Sprintf(s, v...) + "\n\n" + stack))
 }
 
 func (z *ZeroLogger) InfoEvent(eventType string, data string) {
-	z.withTrace(z.logger.Info()).Str("event", eventType).Msg(data)
+	z.withTrace(z.log().Info()).Str("event", eventType).Msg(redactMessage(data))
 }
 
 func (z *ZeroLogger) ErrorEvent(eventType string, data string) {
-	z.withTrace(z.logger.Error()).Str("event", eventType).Msg(data)
+	z.withTrace(z.log().Error()).Str("event", eventType).Msg(redactMessage(data))
 }
 
 func (z *ZeroLogger) ErrorEventWithResources(eventType string, screenshot, text, data string) {
-	le := z.withTrace(z.logger.Error()).Str("event", eventType)
+	le := z.withTrace(z.log().Error()).Str("event", eventType)
 	if screenshot != "" {
-		le = le.Str("screenshotUrl", screenshot)
+		le = le.Str("screenshotUrl", redactMessage(screenshot))
 	}
 	if text != "" {
-		le = le.Str("textUrl", text)
+		le = le.Str("textUrl", redactMessage(text))
 	}
-	le.Msg(data)
+	le.Msg(redactMessage(data))
 }
 
 func (z *ZeroLogger) InfoFile(fileLocation string, data string) {
-	z.withTrace(z.logger.Info()).Str("file", fileLocation).Msg(data)
+	z.withTrace(z.log().Info()).Str("file", fileLocation).Msg(redactMessage(data))
 }
 
 func (z *ZeroLogger) ErrorFile(fileLocation string, data string) {
-	z.withTrace(z.logger.Error()).Str("file", fileLocation).Msg(data)
+	z.withTrace(z.log().Error()).Str("file", fileLocation).Msg(redactMessage(data))
 }
 
 // DebugFields logs a debug level message with structured fields
 func (z *ZeroLogger) DebugFields(msg string, fields map[string]any) {
-	event := z.withTrace(z.logger.Debug())
+	event := z.withTrace(z.log().Debug())
 	for k, v := range fields {
-		event = event.Interface(k, v)
+		event = event.Interface(k, redactField(k, v))
 	}
-	event.Msg(msg)
+	event.Msg(redactMessage(msg))
 }
 
 // InfoFields logs an info level message with structured fields
 func (z *ZeroLogger) InfoFields(msg string, fields map[string]interface{}) {
-	event := z.withTrace(z.logger.Info())
+	event := z.withTrace(z.log().Info())
 	for k, v := range fields {
-		event = event.Interface(k, v)
+		event = event.Interface(k, redactField(k, v))
 	}
-	event.Msg(msg)
+	event.Msg(redactMessage(msg))
 }
 
 // WarningFields logs a warning level message with structured fields
 func (z *ZeroLogger) WarningFields(msg string, fields map[string]interface{}) {
-	event := z.withTrace(z.logger.Warn())
+	event := z.withTrace(z.log().Warn())
 	for k, v := range fields {
-		event = event.Interface(k, v)
+		event = event.Interface(k, redactField(k, v))
 	}
-	event.Msg(msg)
+	event.Msg(redactMessage(msg))
 }
 
 // ErrorFields logs an error level message with structured fields
 func (z *ZeroLogger) ErrorFields(msg string, fields map[string]interface{}) {
-	event := z.withTrace(z.logger.Error())
+	event := z.withTrace(z.log().Error())
 	for k, v := range fields {
-		event = event.Interface(k, v)
+		event = event.Interface(k, redactField(k, v))
 	}
-	event.Msg(msg)
+	event.Msg(redactMessage(msg))
 }
 
 // Fatal logs a fatal level message and then calls os.Exit(1).
 func (z *ZeroLogger) Fatal(v ...any) {
-	z.withTrace(z.logger.Fatal()).Msg(fmt.Sprint(v...))
+	z.withTrace(z.log().Fatal()).Msg(redactMessage(fmt.Sprint(v...)))
 }
 
 // Fatalf logs a formatted fatal level message and then calls os.Exit(1).
 func (z *ZeroLogger) Fatalf(s string, v ...any) {
-	z.withTrace(z.logger.Fatal()).Msg(fmt.Sprintf(s, v...))
+	z.withTrace(z.log().Fatal()).Msg(redactMessage(fmt.Sprintf(s, v...)))
 }
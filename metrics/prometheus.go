@@ -2,79 +2,202 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 
+	"github.com/finch-technologies/go-utils/log"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// ErrMetricTypeMismatch is returned when registering a metric name already used by a metric
+// of another type
+var ErrMetricTypeMismatch = errors.New("metric is registered with a different type")
+
+// CollectorOptions configures a PrometheusCollector
+type CollectorOptions struct {
+	// AutoRegister registers unknown metrics on first use, taking the label names from the
+	// labels of that call. Without it, unknown metrics are dropped with a warning.
+	AutoRegister bool
+}
+
 type PrometheusCollector struct {
-	registry   *prometheus.Registry
-	counters   map[string]*prometheus.CounterVec
-	gauges     map[string]*prometheus.GaugeVec
-	histograms map[string]*prometheus.HistogramVec
-	summaries  map[string]*prometheus.SummaryVec
-	mu         sync.Mutex
-	namespace  string
+	registry     *prometheus.Registry
+	counters     map[string]*prometheus.CounterVec
+	gauges       map[string]*prometheus.GaugeVec
+	histograms   map[string]*prometheus.HistogramVec
+	summaries    map[string]*prometheus.SummaryVec
+	types        map[string]MetricType
+	mu           sync.RWMutex
+	namespace    string
+	autoRegister bool
+	warned       sync.Map
 }
 
-func NewPrometheusCollector(namespace string) *PrometheusCollector {
+func NewPrometheusCollector(namespace string, options ...CollectorOptions) *PrometheusCollector {
+	var opts CollectorOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
 	return &PrometheusCollector{
-		registry:   prometheus.NewRegistry(),
-		counters:   make(map[string]*prometheus.CounterVec),
-		gauges:     make(map[string]*prometheus.GaugeVec),
-		histograms: make(map[string]*prometheus.HistogramVec),
-		summaries:  make(map[string]*prometheus.SummaryVec),
-		namespace:  namespace,
+		registry:     prometheus.NewRegistry(),
+		counters:     make(map[string]*prometheus.CounterVec),
+		gauges:       make(map[string]*prometheus.GaugeVec),
+		histograms:   make(map[string]*prometheus.HistogramVec),
+		summaries:    make(map[string]*prometheus.SummaryVec),
+		types:        make(map[string]MetricType),
+		namespace:    namespace,
+		autoRegister: opts.AutoRegister,
 	}
 }
 
 func (p *PrometheusCollector) IncrementCounter(ctx context.Context, name string, labels map[string]string, value float64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	counter, ok := resolve(p, p.counters, name, Counter, labels)
+	if !ok {
+		return
+	}
 
-	counter, exists := p.counters[name]
-	if !exists {
+	metric, err := counter.GetMetricWith(labels)
+	if err != nil {
+		p.warnOnce(name, "Invalid labels for metric %s: %v", name, err)
 		return
 	}
 
-	counter.With(labels).Add(value)
+	metric.Add(value)
 }
 
 func (p *PrometheusCollector) SetGauge(ctx context.Context, name string, labels map[string]string, value float64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	gauge, ok := resolve(p, p.gauges, name, Gauge, labels)
+	if !ok {
+		return
+	}
 
-	gauge, exists := p.gauges[name]
-	if !exists {
+	metric, err := gauge.GetMetricWith(labels)
+	if err != nil {
+		p.warnOnce(name, "Invalid labels for metric %s: %v", name, err)
 		return
 	}
 
-	gauge.With(labels).Set(value)
+	metric.Set(value)
 }
 
 func (p *PrometheusCollector) ObserveHistogram(ctx context.Context, name string, labels map[string]string, value float64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	histogram, ok := resolve(p, p.histograms, name, Histogram, labels)
+	if !ok {
+		return
+	}
 
-	histogram, exists := p.histograms[name]
-	if !exists {
+	metric, err := histogram.GetMetricWith(labels)
+	if err != nil {
+		p.warnOnce(name, "Invalid labels for metric %s: %v", name, err)
 		return
 	}
 
-	histogram.With(labels).Observe(value)
+	metric.Observe(value)
 }
 
 func (p *PrometheusCollector) ObserveSummary(ctx context.Context, name string, labels map[string]string, value float64) {
+	summary, ok := resolve(p, p.summaries, name, Summary, labels)
+	if !ok {
+		return
+	}
+
+	metric, err := summary.GetMetricWith(labels)
+	if err != nil {
+		p.warnOnce(name, "Invalid labels for metric %s: %v", name, err)
+		return
+	}
+
+	metric.Observe(value)
+}
+
+// GetOrRegisterCounter returns the counter registered as name, registering it if needed
+//
+// Example:
+//
+//	requests, err := collector.GetOrRegisterCounter("requests_total", "Requests served", "route", "status")
+//	requests.WithLabelValues("/orders", "200").Inc()
+func (p *PrometheusCollector) GetOrRegisterCounter(name, description string, labels ...string) (*prometheus.CounterVec, error) {
+	return getOrRegister(p, p.counters, CustomMetric{Name: name, Description: description, Type: Counter, Labels: labels})
+}
+
+// GetOrRegisterGauge returns the gauge registered as name, registering it if needed
+func (p *PrometheusCollector) GetOrRegisterGauge(name, description string, labels ...string) (*prometheus.GaugeVec, error) {
+	return getOrRegister(p, p.gauges, CustomMetric{Name: name, Description: description, Type: Gauge, Labels: labels})
+}
+
+// GetOrRegisterHistogram returns the histogram registered as name, registering it if needed
+func (p *PrometheusCollector) GetOrRegisterHistogram(name, description string, labels ...string) (*prometheus.HistogramVec, error) {
+	return getOrRegister(p, p.histograms, CustomMetric{Name: name, Description: description, Type: Histogram, Labels: labels})
+}
+
+// GetOrRegisterSummary returns the summary registered as name, registering it if needed
+func (p *PrometheusCollector) GetOrRegisterSummary(name, description string, labels ...string) (*prometheus.SummaryVec, error) {
+	return getOrRegister(p, p.summaries, CustomMetric{Name: name, Description: description, Type: Summary, Labels: labels})
+}
+
+// resolve returns the metric vector registered as name, auto-registering it with the
+// names of labels when enabled. Missing metrics are reported once rather than dropped
+// silently.
+func resolve[V any](p *PrometheusCollector, vecs map[string]V, name string, metricType MetricType, labels map[string]string) (V, bool) {
+	p.mu.RLock()
+	vec, ok := vecs[name]
+	p.mu.RUnlock()
+
+	if ok {
+		return vec, true
+	}
+
+	if !p.autoRegister {
+		p.warnOnce(name, "Metric %s is not registered, dropping value", name)
+		return vec, false
+	}
+
+	labelNames := make([]string, 0, len(labels))
+	for label := range labels {
+		labelNames = append(labelNames, label)
+	}
+	sort.Strings(labelNames)
+
+	vec, err := getOrRegister(p, vecs, CustomMetric{Name: name, Description: name, Type: metricType, Labels: labelNames})
+	if err != nil {
+		p.warnOnce(name, "Failed to auto-register metric %s: %v", name, err)
+		return vec, false
+	}
+
+	return vec, true
+}
+
+func getOrRegister[V any](p *PrometheusCollector, vecs map[string]V, metric CustomMetric) (V, error) {
+	p.mu.RLock()
+	vec, ok := vecs[metric.Name]
+	p.mu.RUnlock()
+
+	if ok {
+		return vec, nil
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	summary, exists := p.summaries[name]
-	if !exists {
-		return
+	if err := p.register(metric); err != nil {
+		return vec, err
 	}
 
-	summary.With(labels).Observe(value)
+	return vecs[metric.Name], nil
+}
+
+// warnOnce logs a warning the first time a metric has a problem, so a misconfigured
+// metric on a hot path doesn't flood the logs
+func (p *PrometheusCollector) warnOnce(name, format string, args ...any) {
+	if _, warned := p.warned.LoadOrStore(name, true); !warned {
+		log.Warningf(format, args...)
+	}
 }
 
 func (p *PrometheusCollector) RegisterCustomMetrics(metrics ...CustomMetric) error {
@@ -82,82 +205,101 @@ func (p *PrometheusCollector) RegisterCustomMetrics(metrics ...CustomMetric) err
 	defer p.mu.Unlock()
 
 	for _, metric := range metrics {
-		switch metric.Type {
-		case Counter:
-			counter := prometheus.NewCounterVec(
-				prometheus.CounterOpts{
-					Namespace: p.namespace,
-					Name:      metric.Name,
-					Help:      metric.Description,
-				},
-				metric.Labels,
-			)
-			if err := p.registry.Register(counter); err != nil {
-				return err
-			}
-			p.counters[metric.Name] = counter
-
-		case Gauge:
-			gauge := prometheus.NewGaugeVec(
-				prometheus.GaugeOpts{
-					Namespace: p.namespace,
-					Name:      metric.Name,
-					Help:      metric.Description,
-				},
-				metric.Labels,
-			)
-			if err := p.registry.Register(gauge); err != nil {
-				return err
-			}
-			p.gauges[metric.Name] = gauge
-
-		case Histogram:
-			// Use custom buckets to reduce data volume
-			var buckets []float64
-			if metric.Name == "proxy_response_time_seconds" {
-				// Custom buckets for response times (in seconds)
-				buckets = []float64{0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 30.0}
-			} else if metric.Name == "health_check_duration_seconds" {
-				// Custom buckets for health check duration
-				buckets = []float64{1.0, 5.0, 10.0, 30.0, 60.0, 120.0}
-			} else {
-				// Default buckets for other histograms
-				buckets = prometheus.DefBuckets
-			}
-
-			histogram := prometheus.NewHistogramVec(
-				prometheus.HistogramOpts{
-					Namespace: p.namespace,
-					Name:      metric.Name,
-					Help:      metric.Description,
-					Buckets:   buckets,
-				},
-				metric.Labels,
-			)
-			if err := p.registry.Register(histogram); err != nil {
-				return err
-			}
-			p.histograms[metric.Name] = histogram
-
-		case Summary:
-			summary := prometheus.NewSummaryVec(
-				prometheus.SummaryOpts{
-					Namespace: p.namespace,
-					Name:      metric.Name,
-					Help:      metric.Description,
-				},
-				metric.Labels,
-			)
-			if err := p.registry.Register(summary); err != nil {
-				return err
-			}
-			p.summaries[metric.Name] = summary
+		if err := p.register(metric); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// register creates and registers metric, doing nothing if it is already registered with
+// the same type. Callers must hold p.mu.
+func (p *PrometheusCollector) register(metric CustomMetric) error {
+	if existing, ok := p.types[metric.Name]; ok {
+		if existing != metric.Type {
+			return fmt.Errorf("%w: %s", ErrMetricTypeMismatch, metric.Name)
+		}
+		return nil
+	}
+
+	switch metric.Type {
+	case Counter:
+		counter := prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: p.namespace,
+				Name:      metric.Name,
+				Help:      metric.Description,
+			},
+			metric.Labels,
+		)
+		if err := p.registry.Register(counter); err != nil {
+			return err
+		}
+		p.counters[metric.Name] = counter
+
+	case Gauge:
+		gauge := prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: p.namespace,
+				Name:      metric.Name,
+				Help:      metric.Description,
+			},
+			metric.Labels,
+		)
+		if err := p.registry.Register(gauge); err != nil {
+			return err
+		}
+		p.gauges[metric.Name] = gauge
+
+	case Histogram:
+		// Use custom buckets to reduce data volume
+		var buckets []float64
+		if metric.Name == "proxy_response_time_seconds" {
+			// Custom buckets for response times (in seconds)
+			buckets = []float64{0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 30.0}
+		} else if metric.Name == "health_check_duration_seconds" {
+			// Custom buckets for health check duration
+			buckets = []float64{1.0, 5.0, 10.0, 30.0, 60.0, 120.0}
+		} else {
+			// Default buckets for other histograms
+			buckets = prometheus.DefBuckets
+		}
+
+		histogram := prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: p.namespace,
+				Name:      metric.Name,
+				Help:      metric.Description,
+				Buckets:   buckets,
+			},
+			metric.Labels,
+		)
+		if err := p.registry.Register(histogram); err != nil {
+			return err
+		}
+		p.histograms[metric.Name] = histogram
+
+	case Summary:
+		summary := prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace: p.namespace,
+				Name:      metric.Name,
+				Help:      metric.Description,
+			},
+			metric.Labels,
+		)
+		if err := p.registry.Register(summary); err != nil {
+			return err
+		}
+		p.summaries[metric.Name] = summary
+	}
+
+	p.types[metric.Name] = metric.Type
+
+	return nil
+}
+
 func (p *PrometheusCollector) GetMetricsHandler() interface{} {
 	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{
 		DisableCompression: true, // Disable gzip compression to avoid garbled output
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPrometheusCollector_AutoRegister(t *testing.T) {
+	ctx := context.Background()
+	labels := map[string]string{"queue": "orders"}
+
+	tests := []struct {
+		name         string
+		autoRegister bool
+		want         float64
+	}{
+		{name: "auto register", autoRegister: true, want: 20},
+		{name: "unregistered dropped", autoRegister: false, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPrometheusCollector("test", CollectorOptions{AutoRegister: tt.autoRegister})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p.IncrementCounter(ctx, "messages_total", labels, 1)
+				}()
+			}
+			wg.Wait()
+
+			// Mismatched labels are dropped instead of panicking
+			p.IncrementCounter(ctx, "messages_total", map[string]string{"other": "x"}, 1)
+
+			counter, err := p.GetOrRegisterCounter("messages_total", "Messages", "queue")
+			if err != nil {
+				t.Fatalf("GetOrRegisterCounter() error = %v", err)
+			}
+
+			if got := testutil.ToFloat64(counter.With(labels)); got != tt.want {
+				t.Errorf("counter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusCollector_GetOrRegister(t *testing.T) {
+	p := NewPrometheusCollector("test")
+
+	first, err := p.GetOrRegisterGauge("in_flight", "In flight requests")
+	if err != nil {
+		t.Fatalf("GetOrRegisterGauge() error = %v", err)
+	}
+
+	second, err := p.GetOrRegisterGauge("in_flight", "In flight requests")
+	if err != nil || first != second {
+		t.Fatalf("GetOrRegisterGauge() = %p, %v, want the existing gauge %p", second, err, first)
+	}
+
+	if err := p.RegisterCustomMetrics(CustomMetric{Name: "in_flight", Type: Gauge}); err != nil {
+		t.Errorf("RegisterCustomMetrics() of an existing metric error = %v", err)
+	}
+
+	if _, err := p.GetOrRegisterCounter("in_flight", "In flight requests"); !errors.Is(err, ErrMetricTypeMismatch) {
+		t.Errorf("GetOrRegisterCounter() error = %v, want ErrMetricTypeMismatch", err)
+	}
+}
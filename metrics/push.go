@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// PushOptions configures pushing to a Prometheus Pushgateway
+type PushOptions struct {
+	URL      string            // Pushgateway URL (default PUSHGATEWAY_URL)
+	Job      string            // Job label (default the collector's namespace)
+	Grouping map[string]string // Additional grouping labels, e.g. the instance or shard
+}
+
+// EMFOptions configures snapshots in CloudWatch Embedded Metric Format
+type EMFOptions struct {
+	Namespace string // CloudWatch namespace (default the collector's namespace)
+}
+
+// Push pushes the current value of every metric to a Pushgateway, replacing the metrics
+// previously pushed for the same job and grouping. Use it at the end of batch jobs and
+// cron binaries that exit before they can be scraped.
+//
+// Example:
+//
+//	defer collector.Push(context.Background(), metrics.PushOptions{Job: "export"})
+func (p *PrometheusCollector) Push(ctx context.Context, options ...PushOptions) error {
+	var opts PushOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	url := utils.StringOrDefault(opts.URL, os.Getenv("PUSHGATEWAY_URL"))
+	if url == "" {
+		return errors.New("pushgateway url is required")
+	}
+
+	pusher := push.New(url, utils.StringOrDefault(opts.Job, p.namespace)).Gatherer(p.registry)
+
+	for name, value := range opts.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+
+	if err := pusher.PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+
+	return nil
+}
+
+// WriteEMF writes a snapshot of every metric to w in CloudWatch Embedded Metric Format, one
+// JSON line per series. Written to stdout from Lambda, ECS or any host running the
+// CloudWatch agent, the lines become CloudWatch metrics without a scraper. Counters are
+// written as cumulative totals; histograms and summaries as their _count and _sum.
+func (p *PrometheusCollector) WriteEMF(w io.Writer, options ...EMFOptions) error {
+	var opts EMFOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	namespace := utils.StringOrDefault(opts.Namespace, p.namespace)
+
+	families, err := p.registry.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	timestamp := time.Now().UnixMilli()
+	encoder := json.NewEncoder(w)
+
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for name, value := range sampleValues(family, metric) {
+				if err := encoder.Encode(emfRecord(namespace, timestamp, name, value, metric.GetLabel())); err != nil {
+					return fmt.Errorf("failed to write metric %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// StartExporter runs export every interval until ctx is cancelled or stop is called, and
+// once more on stop so the final values of a short-lived job are not lost. Failed exports
+// are logged and retried on the next tick.
+//
+// Example:
+//
+//	stop := metrics.StartExporter(ctx, time.Minute, func(ctx context.Context) error {
+//	    return collector.WriteEMF(os.Stdout)
+//	})
+//	defer stop()
+func StartExporter(ctx context.Context, interval time.Duration, export func(ctx context.Context) error) (stop func() error) {
+	interval = utils.DurationOrDefault(interval, time.Minute)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := export(ctx); err != nil {
+					log.Warningf("Failed to export metrics: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		cancel()
+		<-done
+
+		return export(context.WithoutCancel(ctx))
+	}
+}
+
+// sampleValues returns the values to report for a series, keyed by metric name
+func sampleValues(family *dto.MetricFamily, metric *dto.Metric) map[string]float64 {
+	name := family.GetName()
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return map[string]float64{name: metric.GetCounter().GetValue()}
+	case dto.MetricType_GAUGE:
+		return map[string]float64{name: metric.GetGauge().GetValue()}
+	case dto.MetricType_HISTOGRAM:
+		return map[string]float64{
+			name + "_count": float64(metric.GetHistogram().GetSampleCount()),
+			name + "_sum":   metric.GetHistogram().GetSampleSum(),
+		}
+	case dto.MetricType_SUMMARY:
+		return map[string]float64{
+			name + "_count": float64(metric.GetSummary().GetSampleCount()),
+			name + "_sum":   metric.GetSummary().GetSampleSum(),
+		}
+	case dto.MetricType_UNTYPED:
+		return map[string]float64{name: metric.GetUntyped().GetValue()}
+	default:
+		return nil
+	}
+}
+
+// emfRecord builds an Embedded Metric Format record for a single value, using the series'
+// labels as dimensions
+func emfRecord(namespace string, timestamp int64, name string, value float64, labels []*dto.LabelPair) map[string]any {
+	dimensions := make([]string, 0, len(labels))
+	record := map[string]any{name: value}
+
+	for _, label := range labels {
+		dimensions = append(dimensions, label.GetName())
+		record[label.GetName()] = label.GetValue()
+	}
+	sort.Strings(dimensions)
+
+	record["_aws"] = map[string]any{
+		"Timestamp": timestamp,
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  namespace,
+			"Dimensions": [][]string{dimensions},
+			"Metrics":    []map[string]string{{"Name": name}},
+		}},
+	}
+
+	return record
+}
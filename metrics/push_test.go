@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPrometheusCollector_Push(t *testing.T) {
+	var gotPath, gotMethod string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	p := NewPrometheusCollector("batch", CollectorOptions{AutoRegister: true})
+	p.IncrementCounter(context.Background(), "rows_exported_total", nil, 42)
+
+	err := p.Push(context.Background(), PushOptions{URL: server.URL, Job: "export", Grouping: map[string]string{"shard": "3"}})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPut || gotPath != "/metrics/job/export/shard/3" {
+		t.Errorf("Push() sent %s %s", gotMethod, gotPath)
+	}
+}
+
+func TestPrometheusCollector_WriteEMF(t *testing.T) {
+	ctx := context.Background()
+
+	p := NewPrometheusCollector("batch", CollectorOptions{AutoRegister: true})
+	p.IncrementCounter(ctx, "rows_exported_total", map[string]string{"table": "orders"}, 42)
+	p.ObserveHistogram(ctx, "export_seconds", nil, 1.5)
+
+	var buf bytes.Buffer
+	if err := p.WriteEMF(&buf, EMFOptions{Namespace: "Exports"}); err != nil {
+		t.Fatalf("WriteEMF() error = %v", err)
+	}
+
+	values := map[string]float64{}
+	decoder := json.NewDecoder(&buf)
+
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("invalid EMF line: %v", err)
+		}
+
+		aws := record["_aws"].(map[string]any)
+		directive := aws["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		if directive["Namespace"] != "Exports" {
+			t.Errorf("Namespace = %v", directive["Namespace"])
+		}
+
+		name := directive["Metrics"].([]any)[0].(map[string]any)["Name"].(string)
+		values[name] = record[name].(float64)
+
+		if name == "batch_rows_exported_total" && record["table"] != "orders" {
+			t.Errorf("dimension table = %v, want orders", record["table"])
+		}
+	}
+
+	want := map[string]float64{
+		"batch_rows_exported_total":  42,
+		"batch_export_seconds_count": 1,
+		"batch_export_seconds_sum":   1.5,
+	}
+
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %v, want %v", name, values[name], value)
+		}
+	}
+}
+
+func TestStartExporter(t *testing.T) {
+	exports := make(chan struct{}, 10)
+
+	stop := StartExporter(context.Background(), 10*time.Millisecond, func(ctx context.Context) error {
+		exports <- struct{}{}
+		return nil
+	})
+
+	<-exports
+
+	if err := stop(); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	// stop exports once more after the last tick
+	if len(exports) == 0 {
+		t.Error("expected a final export on stop")
+	}
+}
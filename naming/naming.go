@@ -0,0 +1,107 @@
+// Package naming builds environment-specific names for tables, buckets and queues, so
+// every service derives "shrike.staging.sessions" the same way instead of assembling names
+// by hand.
+package naming
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/finch-technologies/go-utils/env"
+)
+
+// Options configures resource naming
+type Options struct {
+	Project     string // Project prefix (default PROJECT_NAME)
+	Environment string // Environment (default ENVIRONMENT, then STAGE, then "local")
+}
+
+var (
+	mu     sync.RWMutex
+	config *Options
+)
+
+// Configure overrides the project and environment detected from the environment
+func Configure(options Options) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	config = &options
+}
+
+// Project returns the configured project prefix
+func Project() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if config != nil && config.Project != "" {
+		return config.Project
+	}
+
+	return env.GetOrDefault("PROJECT_NAME", "")
+}
+
+// Environment returns the configured environment
+func Environment() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if config != nil && config.Environment != "" {
+		return config.Environment
+	}
+
+	return env.GetOrDefault("ENVIRONMENT", env.GetOrDefault("STAGE", string(env.Local)))
+}
+
+// Resource returns the environment-specific name of a resource, with empty parts left out
+//
+// Example:
+//
+//	naming.Resource("sessions") // "shrike.staging.sessions"
+func Resource(name string) string {
+	return join(".", Project(), Environment(), name)
+}
+
+// Table returns the environment-specific name of a DynamoDB table
+func Table(name string) string {
+	return Resource(name)
+}
+
+// Bucket returns the environment-specific name of an S3 bucket. Buckets are lower case
+// and joined with dashes, since dots break virtual-hosted style TLS.
+//
+// Example:
+//
+//	naming.Bucket("Exports") // "shrike-staging-exports"
+func Bucket(name string) string {
+	return strings.ToLower(join("-", Project(), Environment(), name))
+}
+
+// Queue returns the environment-specific name of an SQS queue, joined with dashes since
+// queue names can't contain dots. A ".fifo" suffix is kept.
+//
+// Example:
+//
+//	naming.Queue("orders.fifo") // "shrike-staging-orders.fifo"
+func Queue(name string) string {
+	base, fifo := strings.CutSuffix(name, ".fifo")
+
+	name = join("-", Project(), Environment(), base)
+	if fifo {
+		name += ".fifo"
+	}
+
+	return name
+}
+
+func join(separator string, parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	return strings.Join(nonEmpty, separator)
+}
@@ -0,0 +1,46 @@
+package naming
+
+import "testing"
+
+func TestNames(t *testing.T) {
+	t.Setenv("PROJECT_NAME", "shrike")
+	t.Setenv("ENVIRONMENT", "")
+	t.Setenv("STAGE", "staging")
+
+	tests := []struct {
+		name     string
+		fn       func(string) string
+		resource string
+		want     string
+	}{
+		{name: "resource", fn: Resource, resource: "sessions", want: "shrike.staging.sessions"},
+		{name: "table", fn: Table, resource: "sessions", want: "shrike.staging.sessions"},
+		{name: "bucket", fn: Bucket, resource: "Exports", want: "shrike-staging-exports"},
+		{name: "queue", fn: Queue, resource: "orders", want: "shrike-staging-orders"},
+		{name: "fifo queue", fn: Queue, resource: "orders.fifo", want: "shrike-staging-orders.fifo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.resource); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Setenv("PROJECT_NAME", "")
+	t.Setenv("ENVIRONMENT", "prod")
+
+	if got := Resource("sessions"); got != "prod.sessions" {
+		t.Errorf("Resource() = %s, want prod.sessions", got)
+	}
+
+	Configure(Options{Project: "shrike", Environment: "dev"})
+	defer Configure(Options{})
+
+	if got := Resource("sessions"); got != "shrike.dev.sessions" {
+		t.Errorf("Resource() = %s, want shrike.dev.sessions", got)
+	}
+}
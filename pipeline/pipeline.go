@@ -0,0 +1,260 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// StageOptions configures a pipeline stage
+type StageOptions struct {
+	Name        string // Stage name used in errors and logs
+	Concurrency int    // Number of workers processing items in parallel (default 1)
+	Buffer      int    // Size of the stage's output channel buffer (default 0)
+}
+
+// Pipeline wires stages together with typed channels. The first error returned by any
+// stage cancels the pipeline context; every stage then stops processing and drains its
+// input so upstream goroutines never block, and Wait returns that error.
+//
+// Example:
+//
+//	p, ctx := pipeline.New(ctx)
+//	urls := pipeline.FromSlice(p, []string{"https://a", "https://b"})
+//	pages := pipeline.Stage(p, urls, scrape, pipeline.StageOptions{Name: "scrape", Concurrency: 4})
+//	parsed := pipeline.Stage(p, pages, parse)
+//	pipeline.Sink(p, parsed, store)
+//	err := p.Wait()
+type Pipeline struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+	stopped atomic.Bool // Set by Cancel
+}
+
+// New creates a pipeline bound to ctx. The returned context is cancelled when the
+// pipeline fails or ctx is cancelled.
+func New(ctx context.Context) (*Pipeline, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Pipeline{
+		ctx:    ctx,
+		cancel: cancel,
+	}, ctx
+}
+
+// Context returns the pipeline context
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Cancel stops the pipeline without an error. Errors stages return after Cancel, e.g.
+// the cancelled context, are ignored.
+func (p *Pipeline) Cancel() {
+	p.errOnce.Do(func() {
+		p.stopped.Store(true)
+		p.cancel()
+	})
+}
+
+// Wait blocks until every stage has finished and returns the first error, if any.
+// A pipeline stopped by cancelling its parent context returns the context error, one
+// stopped by Cancel returns nil.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+
+	err := p.ctx.Err()
+	p.cancel()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	if p.stopped.Load() {
+		return nil
+	}
+
+	return err
+}
+
+// fail records the first error and cancels the pipeline
+func (p *Pipeline) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+func getOptions(options ...StageOptions) StageOptions {
+	opts := StageOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	if opts.Buffer < 0 {
+		opts.Buffer = 0
+	}
+
+	return opts
+}
+
+// Source starts a stage that produces items by calling emit. emit returns false once the
+// pipeline has been cancelled, after which the generator should return.
+func Source[T any](p *Pipeline, generate func(ctx context.Context, emit func(T) bool) error, options ...StageOptions) <-chan T {
+	opts := getOptions(options...)
+	out := make(chan T, opts.Buffer)
+
+	emit := func(item T) bool {
+		select {
+		case <-p.ctx.Done():
+			return false
+		case out <- item:
+			return true
+		}
+	}
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+
+		_, err := utils.TryReturn(func() (any, error) {
+			return nil, generate(p.ctx, emit)
+		})
+
+		if err != nil {
+			p.fail(stageError(opts.Name, err))
+		}
+	}()
+
+	return out
+}
+
+// FromSlice starts a source stage that emits every item of a slice
+func FromSlice[T any](p *Pipeline, items []T, options ...StageOptions) <-chan T {
+	return Source(p, func(ctx context.Context, emit func(T) bool) error {
+		for _, item := range items {
+			if !emit(item) {
+				return nil
+			}
+		}
+		return nil
+	}, options...)
+}
+
+// Stage starts a stage that transforms every input item with fn using the configured
+// number of workers. Output order is not preserved when Concurrency is above 1.
+func Stage[In, Out any](p *Pipeline, in <-chan In, fn func(ctx context.Context, item In) (Out, error), options ...StageOptions) <-chan Out {
+	return FilterStage(p, in, func(ctx context.Context, item In) (Out, bool, error) {
+		result, err := fn(ctx, item)
+		return result, true, err
+	}, options...)
+}
+
+// FilterStage is like Stage but fn can drop an item by returning false
+func FilterStage[In, Out any](p *Pipeline, in <-chan In, fn func(ctx context.Context, item In) (Out, bool, error), options ...StageOptions) <-chan Out {
+	opts := getOptions(options...)
+	out := make(chan Out, opts.Buffer)
+
+	var workers sync.WaitGroup
+
+	for i := 0; i < opts.Concurrency; i++ {
+		workers.Add(1)
+		p.wg.Add(1)
+
+		go func() {
+			defer p.wg.Done()
+			defer workers.Done()
+
+			for item := range in {
+				if p.ctx.Err() != nil {
+					// Keep draining so upstream stages can finish
+					continue
+				}
+
+				result, keep, err := runItem(func() (Out, bool, error) {
+					return fn(p.ctx, item)
+				})
+
+				if err != nil {
+					p.fail(stageError(opts.Name, err))
+					continue
+				}
+
+				if !keep {
+					continue
+				}
+
+				select {
+				case <-p.ctx.Done():
+				case out <- result:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		workers.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Sink starts a final stage that consumes every item with fn
+func Sink[T any](p *Pipeline, in <-chan T, fn func(ctx context.Context, item T) error, options ...StageOptions) {
+	drained := FilterStage(p, in, func(ctx context.Context, item T) (struct{}, bool, error) {
+		return struct{}{}, false, fn(ctx, item)
+	}, options...)
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		for range drained {
+		}
+	}()
+}
+
+// Collect consumes a channel into a slice. Call it before Wait on the final stage's output.
+func Collect[T any](in <-chan T) []T {
+	var items []T
+
+	for item := range in {
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// runItem calls fn and converts a panic into an error
+func runItem[Out any](fn func() (Out, bool, error)) (result Out, keep bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Pipeline stage panicked: %v", r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn()
+}
+
+func stageError(name string, err error) error {
+	if name == "" {
+		return err
+	}
+	return fmt.Errorf("stage %s: %w", name, err)
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	p, _ := New(context.Background())
+
+	numbers := FromSlice(p, []int{1, 2, 3, 4, 5})
+
+	doubled := Stage(p, numbers, func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	}, StageOptions{Concurrency: 3, Buffer: 2})
+
+	strs := FilterStage(p, doubled, func(ctx context.Context, n int) (string, bool, error) {
+		return strconv.Itoa(n), n > 4, nil
+	})
+
+	result := Collect(strs)
+
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	sort.Strings(result)
+
+	expected := []string{"10", "6", "8"}
+	if len(result) != len(expected) {
+		t.Fatalf("result = %v, want %v", result, expected)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("result = %v, want %v", result, expected)
+		}
+	}
+}
+
+func TestPipelineErrorCancelsAndDrains(t *testing.T) {
+	p, ctx := New(context.Background())
+
+	var produced atomic.Int32
+
+	source := Source(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil
+			}
+			produced.Add(1)
+		}
+	})
+
+	failing := errors.New("boom")
+
+	Sink(p, source, func(ctx context.Context, n int) error {
+		if n == 10 {
+			return failing
+		}
+		return nil
+	}, StageOptions{Name: "sink", Concurrency: 2})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, failing) {
+			t.Errorf("Wait() error = %v, want %v", err, failing)
+		}
+		if err.Error() != "stage sink: boom" {
+			t.Errorf("Wait() error = %q, want stage name prefix", err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline did not stop after stage error")
+	}
+
+	if ctx.Err() == nil {
+		t.Error("pipeline context should be cancelled after an error")
+	}
+}
+
+func TestPipelinePanic(t *testing.T) {
+	p, _ := New(context.Background())
+
+	Sink(p, FromSlice(p, []int{1, 2, 3}), func(ctx context.Context, n int) error {
+		if n == 2 {
+			panic("unexpected")
+		}
+		return nil
+	})
+
+	if err := p.Wait(); err == nil {
+		t.Error("Wait() should return an error when a stage panics")
+	}
+}
+
+func TestPipelineParentCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	p, _ := New(parent)
+
+	source := Source(p, func(ctx context.Context, emit func(int) bool) error {
+		for emit(1) {
+		}
+		return nil
+	})
+
+	Sink(p, source, func(ctx context.Context, n int) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	p, _ := New(context.Background())
+
+	source := Source(p, func(ctx context.Context, emit func(int) bool) error {
+		for emit(1) {
+		}
+		return ctx.Err()
+	})
+
+	Sink(p, source, func(ctx context.Context, n int) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	p.Cancel()
+
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() error = %v, want nil after Cancel", err)
+	}
+}
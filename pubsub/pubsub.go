@@ -2,8 +2,16 @@ package pubsub
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
 
+	"github.com/finch-technologies/go-utils/events"
+	"github.com/finch-technologies/go-utils/log"
 	"github.com/finch-technologies/go-utils/pubsub/redis"
+	"github.com/finch-technologies/go-utils/pubsub/sns"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 type IMessageBroker interface {
@@ -11,18 +19,30 @@ type IMessageBroker interface {
 	Subscribe(ctx context.Context, channel string, callback func(channel string, payload string)) func() error
 }
 
+// IAckSubscriber is implemented by brokers that redeliver messages whose callback returns an
+// error, such as the SNS broker
+type IAckSubscriber interface {
+	SubscribeAck(ctx context.Context, channel string, callback func(channel string, payload string) error) (func() error, error)
+}
+
 type MessageBrokerOptions struct {
-	Db     int
-	Driver MessageBrokerDriver
+	Db          int
+	Driver      MessageBrokerDriver
+	RedisClient *goredis.Client // Use an existing redis client instead of the shared client for Db
+	SNS         sns.Config      // Topics and subscription queue of the SNS driver
 }
 
 type MessageBrokerDriver string
 
 const (
 	MessageBrokerDriverRedis MessageBrokerDriver = "redis"
+	MessageBrokerDriverSNS   MessageBrokerDriver = "sns"
 )
 
-var msgBroker IMessageBroker
+var (
+	brokerMu  sync.Mutex
+	msgBroker IMessageBroker
+)
 
 func getOptions(options ...MessageBrokerOptions) MessageBrokerOptions {
 	if len(options) > 0 {
@@ -34,26 +54,118 @@ func getOptions(options ...MessageBrokerOptions) MessageBrokerOptions {
 	}
 }
 
+// NewBroker creates a broker for the driver of options, without setting it up for the
+// package functions
+func NewBroker(options ...MessageBrokerOptions) (IMessageBroker, error) {
+	opts := getOptions(options...)
+
+	switch opts.Driver {
+	case MessageBrokerDriverRedis, "":
+		return newRedisBroker(opts), nil //pubsub db
+	case MessageBrokerDriverSNS:
+		broker, err := sns.New(opts.SNS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sns broker: %w", err)
+		}
+		return broker, nil
+	default:
+		return nil, fmt.Errorf("unsupported message broker driver: %s", opts.Driver)
+	}
+}
+
+// Init sets up the broker used by the package functions, replacing any broker set up before.
+// A replaced broker is closed, ending its subscriptions. Without options it uses redis db 3.
+//
+// Example:
+//
+//	_, err := pubsub.Init(pubsub.MessageBrokerOptions{
+//	    Driver: pubsub.MessageBrokerDriverSNS,
+//	    SNS:    sns.Config{TopicArnPrefix: topicArnPrefix, QueueUrl: queueUrl},
+//	})
+//	err = pubsub.Publish(ctx, "orders", order)
 func Init(options ...MessageBrokerOptions) (IMessageBroker, error) {
+	broker, err := NewBroker(options...)
+	if err != nil {
+		return nil, err
+	}
 
-	opts := getOptions(options...)
+	brokerMu.Lock()
+	previous := msgBroker
+	msgBroker = broker
+	brokerMu.Unlock()
 
-	if msgBroker == nil {
-		switch opts.Driver {
-		case MessageBrokerDriverRedis:
-			msgBroker = redis.New(opts.Db) //pubsub db
-		default:
-			msgBroker = redis.New(opts.Db)
+	if closer, ok := previous.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Errorf("Failed to close the replaced message broker: %v", err)
 		}
 	}
 
-	return msgBroker, nil
+	return broker, nil
 }
 
+// GetBroker returns the broker set up by Init, setting up the default broker if there is none
 func GetBroker() (IMessageBroker, error) {
+	brokerMu.Lock()
+	defer brokerMu.Unlock()
+
 	if msgBroker == nil {
-		return Init()
+		broker, err := NewBroker()
+		if err != nil {
+			return nil, err
+		}
+		msgBroker = broker
 	}
 
 	return msgBroker, nil
 }
+
+func newRedisBroker(opts MessageBrokerOptions) *redis.RedisMessageBroker {
+	if opts.RedisClient != nil {
+		return redis.NewWithClient(opts.RedisClient)
+	}
+	return redis.New(opts.Db)
+}
+
+// PublishEvent publishes an event registered with the events package, wrapped in an
+// envelope naming its type and version
+func PublishEvent(ctx context.Context, channel string, event any) error {
+	broker, err := GetBroker()
+	if err != nil {
+		return err
+	}
+
+	body, err := events.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	return broker.Publish(ctx, channel, json.RawMessage(body))
+}
+
+// SubscribeEvents subscribes to events registered with the events package. The callback
+// receives values of the registered types; payloads that can't be decoded are logged and
+// skipped. Call the returned function to unsubscribe.
+//
+// Example:
+//
+//	unsubscribe := pubsub.SubscribeEvents(ctx, "orders", func(channel string, event any) {
+//	    if created, ok := event.(OrderCreated); ok {
+//	        handleOrder(created)
+//	    }
+//	})
+func SubscribeEvents(ctx context.Context, channel string, callback func(channel string, event any)) (func() error, error) {
+	broker, err := GetBroker()
+	if err != nil {
+		return nil, err
+	}
+
+	return broker.Subscribe(ctx, channel, func(channel string, payload string) {
+		event, err := events.Decode([]byte(payload))
+		if err != nil {
+			log.Errorf("Failed to decode event on %s: %v", channel, err)
+			return
+		}
+
+		callback(channel, event)
+	}), nil
+}
@@ -0,0 +1,68 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+)
+
+// closingBroker records whether it was closed
+type closingBroker struct {
+	fakeBroker
+	closed bool
+}
+
+func (b *closingBroker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestGetBrokerSetsUpOnce(t *testing.T) {
+	useBroker(t, nil)
+
+	var wg sync.WaitGroup
+	brokers := make([]IMessageBroker, 10)
+
+	for i := range brokers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			brokers[i], _ = GetBroker()
+		}()
+	}
+	wg.Wait()
+
+	for _, broker := range brokers {
+		if broker == nil || broker != brokers[0] {
+			t.Fatalf("expected every caller to get the same broker, got %v", brokers)
+		}
+	}
+}
+
+func TestInitClosesReplacedBroker(t *testing.T) {
+	previous := &closingBroker{fakeBroker: *newFakeBroker()}
+	useBroker(t, previous)
+
+	broker, err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !previous.closed {
+		t.Error("expected the replaced broker to be closed")
+	}
+	if current, _ := GetBroker(); current != broker {
+		t.Error("expected Init's broker to replace the previous one")
+	}
+}
+
+func TestInitRejectsUnknownDriver(t *testing.T) {
+	previous := &closingBroker{fakeBroker: *newFakeBroker()}
+	useBroker(t, previous)
+
+	if _, err := Init(MessageBrokerOptions{Driver: "kafka"}); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+	if previous.closed {
+		t.Error("expected the broker to be kept when Init fails")
+	}
+}
@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync"
 
 	database "github.com/finch-technologies/go-utils/database/redis"
 	"github.com/finch-technologies/go-utils/log"
@@ -10,12 +12,20 @@ import (
 )
 
 type RedisMessageBroker struct {
-	rdb *redis.Client
+	rdb           *redis.Client
+	mu            sync.Mutex
+	subscriptions map[*redis.PubSub]struct{}
 }
 
 func New(db int) *RedisMessageBroker {
+	return NewWithClient(database.GetRedisClient(db))
+}
+
+// NewWithClient creates a broker backed by an existing redis client
+func NewWithClient(client *redis.Client) *RedisMessageBroker {
 	return &RedisMessageBroker{
-		rdb: database.GetRedisClient(db),
+		rdb:           client,
+		subscriptions: make(map[*redis.PubSub]struct{}),
 	}
 }
 
@@ -39,7 +49,7 @@ func (msgBroker *RedisMessageBroker) Subscribe(ctx context.Context, channel stri
 	_, err := pubsub.Receive(ctx)
 
 	if err != nil {
-		log.Error("Failed to subscribe", err)
+		log.Errorf("Failed to subscribe to %s: %v", channel, err)
 		return pubsub.Close
 	}
 
@@ -48,7 +58,40 @@ func (msgBroker *RedisMessageBroker) Subscribe(ctx context.Context, channel stri
 
 	go listen(ch, callback)
 
-	return pubsub.Close
+	msgBroker.mu.Lock()
+	msgBroker.subscriptions[pubsub] = struct{}{}
+	msgBroker.mu.Unlock()
+
+	return func() error {
+		msgBroker.mu.Lock()
+		_, open := msgBroker.subscriptions[pubsub]
+		delete(msgBroker.subscriptions, pubsub)
+		msgBroker.mu.Unlock()
+
+		if !open {
+			return nil
+		}
+		return pubsub.Close()
+	}
+}
+
+// Close ends the broker's subscriptions. The redis client is left open since it is shared
+// or owned by the caller.
+func (msgBroker *RedisMessageBroker) Close() error {
+	msgBroker.mu.Lock()
+	subscriptions := msgBroker.subscriptions
+	msgBroker.subscriptions = make(map[*redis.PubSub]struct{})
+	msgBroker.mu.Unlock()
+
+	var errs []error
+
+	for pubsub := range subscriptions {
+		if err := pubsub.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func listen(channel <-chan *redis.Message, callback func(channel string, payload string)) {
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestBroker(t *testing.T) *RedisMessageBroker {
+	server := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewWithClient(client)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	broker := newTestBroker(t)
+	received := make(chan [2]string, 1)
+
+	unsubscribe := broker.Subscribe(context.Background(), "orders.*", func(channel string, payload string) {
+		received <- [2]string{channel, payload}
+	})
+	defer unsubscribe()
+
+	if err := broker.Publish(context.Background(), "orders.created", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-received:
+		if message != [2]string{"orders.*", `{"id":1}`} {
+			t.Errorf("unexpected message %v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message wasn't received")
+	}
+}
+
+func TestClose(t *testing.T) {
+	broker := newTestBroker(t)
+
+	unsubscribe := broker.Subscribe(context.Background(), "orders", func(string, string) {})
+
+	if err := broker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(broker.subscriptions) != 0 {
+		t.Errorf("expected the subscriptions to end, got %d", len(broker.subscriptions))
+	}
+	if err := unsubscribe(); err != nil {
+		t.Errorf("expected unsubscribing after Close to succeed, got %v", err)
+	}
+}
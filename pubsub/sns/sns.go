@@ -0,0 +1,166 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	awssns "github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ChannelAttribute is the message attribute naming the channel a message was published to
+const ChannelAttribute = "channel"
+
+// Config configures an SNS broker. Channels are published to the topics named after them,
+// and subscriptions receive from an SQS queue subscribed to those topics, one queue per
+// instance so every instance gets every message.
+type Config struct {
+	Region         string // AWS region (default AWS_REGION, else af-south-1)
+	TopicArnPrefix string // Prefix of the topic ARNs, e.g. "arn:aws:sns:af-south-1:123456789012:shrike-"
+	QueueUrl       string // URL of the SQS queue subscribed to the topics, required to Subscribe
+	Endpoint       string // SNS endpoint, e.g. for localstack (default the regional endpoint)
+	SQSEndpoint    string // SQS endpoint, e.g. for localstack (default the regional endpoint)
+
+	AccessKeyId     string // Static credentials (default the AWS credential chain)
+	SecretAccessKey string
+
+	WaitTimeSeconds int           // Long polling wait of the subscription queue (default 20)
+	PollErrorDelay  time.Duration // Wait after a failed poll before polling again (default 1s)
+}
+
+// publisher is the part of the SNS client the broker uses
+type publisher interface {
+	Publish(ctx context.Context, params *awssns.PublishInput, optFns ...func(*awssns.Options)) (*awssns.PublishOutput, error)
+}
+
+// receiver is the part of the SQS client the subscription poller uses
+type receiver interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+// SNSMessageBroker publishes to SNS topics and receives through an SQS queue
+type SNSMessageBroker struct {
+	config      Config
+	snsClient   publisher
+	sqsClient   receiver
+	mu          sync.Mutex
+	subscribers map[int]subscriber
+	nextId      int
+	stopPolling context.CancelFunc
+}
+
+// New creates an SNS broker
+//
+// Example:
+//
+//	broker, err := sns.New(sns.Config{
+//	    TopicArnPrefix: "arn:aws:sns:af-south-1:123456789012:shrike-",
+//	    QueueUrl:       "https://sqs.af-south-1.amazonaws.com/123456789012/shrike-api-1",
+//	})
+//	err = broker.Publish(ctx, "orders", order) // to the topic shrike-orders
+func New(config Config) (*SNSMessageBroker, error) {
+	config.Region = utils.StringOrDefault(config.Region, utils.StringOrDefault(os.Getenv("AWS_REGION"), "af-south-1"))
+
+	if config.TopicArnPrefix == "" {
+		return nil, fmt.Errorf("sns topic arn prefix is required")
+	}
+
+	loadOptions := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(config.Region)}
+
+	if config.AccessKeyId != "" {
+		loadOptions = append(loadOptions, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(config.AccessKeyId, config.SecretAccessKey, ""),
+		))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS SDK config: %w", err)
+	}
+
+	snsClient := awssns.NewFromConfig(awsCfg, func(o *awssns.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+		}
+	})
+
+	sqsClient := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if config.SQSEndpoint != "" {
+			o.BaseEndpoint = aws.String(config.SQSEndpoint)
+		}
+	})
+
+	return newBroker(config, snsClient, sqsClient), nil
+}
+
+// newBroker creates a broker from its clients, applying the config defaults
+func newBroker(config Config, snsClient publisher, sqsClient receiver) *SNSMessageBroker {
+	config.WaitTimeSeconds = utils.IntOrDefault(config.WaitTimeSeconds, 20)
+	config.PollErrorDelay = utils.DurationOrDefault(config.PollErrorDelay, time.Second)
+
+	return &SNSMessageBroker{
+		config:      config,
+		snsClient:   snsClient,
+		sqsClient:   sqsClient,
+		subscribers: make(map[int]subscriber),
+	}
+}
+
+// invalidTopicChars are the characters SNS doesn't allow in topic names
+var invalidTopicChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// TopicArn returns the ARN of the topic of a channel: the prefix followed by the channel,
+// with characters topic names can't have replaced by dashes. Channels that are ARNs are
+// used as they are.
+func (b *SNSMessageBroker) TopicArn(channel string) string {
+	if strings.HasPrefix(channel, "arn:") {
+		return channel
+	}
+	return b.config.TopicArnPrefix + invalidTopicChars.ReplaceAllString(channel, "-")
+}
+
+// Publish sends payload as JSON to the topic of channel, naming the channel in the channel
+// message attribute
+func (b *SNSMessageBroker) Publish(ctx context.Context, channel string, payload any) error {
+	message, err := encodePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.snsClient.Publish(ctx, &awssns.PublishInput{
+		TopicArn: aws.String(b.TopicArn(channel)),
+		Message:  aws.String(message),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			ChannelAttribute: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(channel),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+
+	return nil
+}
+
+// encodePayload returns the JSON of a payload, as published by the redis broker
+func encodePayload(payload any) (string, error) {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return string(bytes), nil
+}
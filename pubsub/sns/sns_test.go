@@ -0,0 +1,304 @@
+package sns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssns "github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const testPrefix = "arn:aws:sns:af-south-1:123456789012:shrike-"
+
+// fakeSNS records published messages
+type fakeSNS struct {
+	published []*awssns.PublishInput
+}
+
+func (f *fakeSNS) Publish(ctx context.Context, params *awssns.PublishInput, optFns ...func(*awssns.Options)) (*awssns.PublishOutput, error) {
+	f.published = append(f.published, params)
+	return &awssns.PublishOutput{}, nil
+}
+
+// fakeSQS returns its messages on the first receive, then blocks until the poller stops
+type fakeSQS struct {
+	mu       sync.Mutex
+	messages []sqstypes.Message
+	deleted  []string
+	received chan struct{}
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	messages := f.messages
+	f.messages = nil
+	f.mu.Unlock()
+
+	if len(messages) == 0 {
+		close(f.received)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func rawMessage(id, channel, body string) sqstypes.Message {
+	return sqstypes.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String(id),
+		Body:          aws.String(body),
+		MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+			ChannelAttribute: {DataType: aws.String("String"), StringValue: aws.String(channel)},
+		},
+	}
+}
+
+func TestTopicArn(t *testing.T) {
+	b := newBroker(Config{TopicArnPrefix: testPrefix}, nil, nil)
+
+	tests := []struct {
+		channel  string
+		expected string
+	}{
+		{"orders", testPrefix + "orders"},
+		{"orders.created", testPrefix + "orders-created"},
+		{"tenant/1:events", testPrefix + "tenant-1-events"},
+		{"snake_case-name", testPrefix + "snake_case-name"},
+		{"arn:aws:sns:eu-west-1:1:other", "arn:aws:sns:eu-west-1:1:other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			if got := b.TopicArn(tt.channel); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTopicChannel(t *testing.T) {
+	b := newBroker(Config{TopicArnPrefix: testPrefix}, nil, nil)
+
+	tests := []struct {
+		topicArn string
+		expected string
+	}{
+		{testPrefix + "orders", "orders"},
+		{testPrefix + "orders-created", "orders-created"},
+		{"arn:aws:sns:eu-west-1:1:other", "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.topicArn, func(t *testing.T) {
+			if got := b.topicChannel(tt.topicArn); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	b := newBroker(Config{TopicArnPrefix: testPrefix}, nil, nil)
+
+	tests := []struct {
+		name            string
+		message         sqstypes.Message
+		expectedChannel string
+		expectedPayload string
+	}{
+		{
+			"notification with channel",
+			sqstypes.Message{Body: aws.String(`{"Type":"Notification","TopicArn":"` + testPrefix + `orders-created","Message":"{\"id\":1}","MessageAttributes":{"channel":{"Type":"String","Value":"orders.created"}}}`)},
+			"orders.created", `{"id":1}`,
+		},
+		{
+			"notification from another publisher",
+			sqstypes.Message{Body: aws.String(`{"Type":"Notification","TopicArn":"` + testPrefix + `users","Message":"hi"}`)},
+			"users", "hi",
+		},
+		{
+			"raw delivery",
+			rawMessage("1", "orders.updated", `{"id":2}`),
+			"orders.updated", `{"id":2}`,
+		},
+		{
+			"raw json that isn't a notification",
+			rawMessage("1", "orders", `{"Type":"Order"}`),
+			"orders", `{"Type":"Order"}`,
+		},
+		{
+			"no channel",
+			sqstypes.Message{Body: aws.String("raw")},
+			"", "raw",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel, payload := b.unwrap(tt.message)
+
+			if channel != tt.expectedChannel || payload != tt.expectedPayload {
+				t.Errorf("expected %q %q, got %q %q", tt.expectedChannel, tt.expectedPayload, channel, payload)
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	publisher := &fakeSNS{}
+	b := newBroker(Config{TopicArnPrefix: testPrefix}, publisher, nil)
+
+	if err := b.Publish(context.Background(), "orders.created", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(publisher.published) != 1 {
+		t.Fatalf("expected one message, got %d", len(publisher.published))
+	}
+
+	input := publisher.published[0]
+	if aws.ToString(input.TopicArn) != testPrefix+"orders-created" || aws.ToString(input.Message) != `{"id":1}` {
+		t.Errorf("unexpected input %s %s", aws.ToString(input.TopicArn), aws.ToString(input.Message))
+	}
+	if channel := aws.ToString(input.MessageAttributes[ChannelAttribute].StringValue); channel != "orders.created" {
+		t.Errorf("expected the channel attribute, got %q", channel)
+	}
+}
+
+func TestSubscribeRequiresQueueUrl(t *testing.T) {
+	b := newBroker(Config{TopicArnPrefix: testPrefix}, nil, &fakeSQS{})
+
+	if _, err := b.SubscribeAck(context.Background(), "orders", func(string, string) error { return nil }); err == nil {
+		t.Error("expected an error without a queue url")
+	}
+
+	b.Subscribe(context.Background(), "orders", func(string, string) {})
+	if len(b.subscribers) != 0 {
+		t.Error("expected no subscription without a queue url")
+	}
+}
+
+func TestPollAcknowledges(t *testing.T) {
+	receiver := &fakeSQS{
+		received: make(chan struct{}),
+		messages: []sqstypes.Message{
+			rawMessage("ok", "orders.created", "1"),
+			rawMessage("failed", "orders.failed", "2"),
+			rawMessage("panicked", "orders.panicked", "3"),
+			rawMessage("unmatched", "users", "4"),
+			{MessageId: aws.String("unknown"), ReceiptHandle: aws.String("unknown"), Body: aws.String("5")},
+		},
+	}
+	b := newBroker(Config{TopicArnPrefix: testPrefix, QueueUrl: "queue"}, nil, receiver)
+
+	var mu sync.Mutex
+	calls := []string{}
+
+	unsubscribe, err := b.SubscribeAck(context.Background(), "orders.*", func(channel string, payload string) error {
+		mu.Lock()
+		calls = append(calls, channel+"|"+payload)
+		mu.Unlock()
+
+		switch payload {
+		case "2":
+			return errors.New("failed")
+		case "3":
+			panic("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-receiver.received:
+	case <-time.After(time.Second):
+		t.Fatal("messages weren't received")
+	}
+
+	unsubscribe()
+
+	if len(calls) != 3 {
+		t.Errorf("expected the three matching messages to be handled, got %v", calls)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	expected := []string{"ok", "unmatched", "unknown"}
+	if len(receiver.deleted) != len(expected) {
+		t.Fatalf("expected %v to be deleted, got %v", expected, receiver.deleted)
+	}
+	for i, id := range expected {
+		if receiver.deleted[i] != id {
+			t.Errorf("expected %v to be deleted, got %v", expected, receiver.deleted)
+		}
+	}
+}
+
+func TestSubscribeEndsWithContext(t *testing.T) {
+	receiver := &fakeSQS{received: make(chan struct{})}
+	b := newBroker(Config{TopicArnPrefix: testPrefix, QueueUrl: "queue"}, nil, receiver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b.Subscribe(ctx, "orders", func(string, string) {})
+
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		b.mu.Lock()
+		stopped := len(b.subscribers) == 0 && b.stopPolling == nil
+		b.mu.Unlock()
+
+		if stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the subscription to end with its context")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := b.SubscribeAck(ctx, "orders", func(string, string) error { return nil }); err == nil {
+		t.Error("expected an error subscribing with a cancelled context")
+	}
+}
+
+func TestClose(t *testing.T) {
+	receiver := &fakeSQS{received: make(chan struct{})}
+	b := newBroker(Config{TopicArnPrefix: testPrefix, QueueUrl: "queue"}, nil, receiver)
+
+	unsubscribe := b.Subscribe(context.Background(), "orders", func(string, string) {})
+	b.Subscribe(context.Background(), "users", func(string, string) {})
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b.mu.Lock()
+	if len(b.subscribers) != 0 || b.stopPolling != nil {
+		t.Errorf("expected the subscriptions and poller to stop, got %d subscriptions", len(b.subscribers))
+	}
+	b.mu.Unlock()
+
+	if err := unsubscribe(); err != nil {
+		t.Errorf("expected unsubscribing after Close to succeed, got %v", err)
+	}
+}
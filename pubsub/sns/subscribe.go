@@ -0,0 +1,259 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// maxReceived is the most messages SQS returns per receive
+const maxReceived = 10
+
+type subscriber struct {
+	pattern     string
+	callback    func(channel string, payload string) error
+	unsubscribe func() error
+}
+
+// notification is an SNS message delivered to SQS without raw message delivery
+type notification struct {
+	Type              string `json:"Type"`
+	TopicArn          string `json:"TopicArn"`
+	Message           string `json:"Message"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// Subscribe calls callback with the payload of every message published to a channel
+// matching the pattern channel, which can include * as with redis. As with redis the
+// callback receives the pattern as its channel. Messages are deleted from the subscription
+// queue once the callback returned; a callback that panics leaves the message to be
+// redelivered. The subscription ends when ctx is done or the returned function is called.
+// Subscribing fails, with the error logged, if the broker has no subscription queue; use
+// SubscribeAck to get the error instead.
+func (b *SNSMessageBroker) Subscribe(ctx context.Context, channel string, callback func(channel string, payload string)) func() error {
+	unsubscribe, err := b.SubscribeAck(ctx, channel, func(channel string, payload string) error {
+		callback(channel, payload)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to subscribe to %s: %v", channel, err)
+		return func() error { return nil }
+	}
+
+	return unsubscribe
+}
+
+// SubscribeAck is Subscribe with a callback that acknowledges messages. A message is deleted
+// from the subscription queue once the callbacks of every matching subscription returned
+// nil. Otherwise it stays in the queue and is delivered again, to every matching
+// subscription, when its visibility timeout expires, until the queue's redrive policy moves
+// it to a dead letter queue.
+func (b *SNSMessageBroker) SubscribeAck(ctx context.Context, channel string, callback func(channel string, payload string) error) (func() error, error) {
+	if b.config.QueueUrl == "" {
+		return nil, fmt.Errorf("sns subscription queue url is required to subscribe")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	b.mu.Lock()
+
+	id := b.nextId
+	b.nextId++
+
+	unsubscribe := func() error {
+		once.Do(func() {
+			close(done)
+			b.unsubscribe(id)
+		})
+		return nil
+	}
+
+	b.subscribers[id] = subscriber{pattern: channel, callback: callback, unsubscribe: unsubscribe}
+
+	if b.stopPolling == nil {
+		pollCtx, cancel := context.WithCancel(context.Background())
+		b.stopPolling = cancel
+		go b.poll(pollCtx)
+	}
+
+	b.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+		case <-done:
+		}
+	}()
+
+	return unsubscribe, nil
+}
+
+// unsubscribe removes a subscription, stopping the poller after the last one
+func (b *SNSMessageBroker) unsubscribe(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers, id)
+
+	if len(b.subscribers) == 0 && b.stopPolling != nil {
+		b.stopPolling()
+		b.stopPolling = nil
+	}
+}
+
+// Close ends the broker's subscriptions and stops polling the subscription queue. Messages
+// being handled are left to finish.
+func (b *SNSMessageBroker) Close() error {
+	b.mu.Lock()
+	subscribers := make([]subscriber, 0, len(b.subscribers))
+	for _, sub := range b.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.unsubscribe()
+	}
+
+	return nil
+}
+
+// poll receives from the subscription queue until ctx is cancelled. Messages received after
+// ctx is cancelled are left for redelivery.
+func (b *SNSMessageBroker) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		resp, err := b.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(b.config.QueueUrl),
+			MaxNumberOfMessages:   maxReceived,
+			WaitTimeSeconds:       int32(b.config.WaitTimeSeconds),
+			MessageAttributeNames: []string{"All"},
+		})
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Errorf("Failed to receive from %s: %v", b.config.QueueUrl, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.config.PollErrorDelay):
+			}
+			continue
+		}
+
+		for _, message := range resp.Messages {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !b.dispatch(message) {
+				continue
+			}
+
+			_, err := b.sqsClient.DeleteMessage(context.WithoutCancel(ctx), &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(b.config.QueueUrl),
+				ReceiptHandle: message.ReceiptHandle,
+			})
+			if err != nil {
+				log.Errorf("Failed to delete message %s: %v", aws.ToString(message.MessageId), err)
+			}
+		}
+	}
+}
+
+// dispatch calls the callbacks of the subscriptions matching a message's channel, reporting
+// whether the message can be deleted. Messages without a channel or matching subscription
+// are deleted, as nothing will ever handle them.
+func (b *SNSMessageBroker) dispatch(message sqstypes.Message) bool {
+	id := aws.ToString(message.MessageId)
+
+	channel, payload := b.unwrap(message)
+	if channel == "" {
+		log.Warningf("Skipping message %s without a channel", id)
+		return true
+	}
+
+	b.mu.Lock()
+	matched := []subscriber{}
+	for _, sub := range b.subscribers {
+		if ok, _ := path.Match(sub.pattern, channel); ok {
+			matched = append(matched, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	handled := true
+
+	for _, sub := range matched {
+		if err := call(sub, payload); err != nil {
+			log.Errorf("Failed to handle message %s on %s: %v", id, channel, err)
+			handled = false
+		}
+	}
+
+	return handled
+}
+
+// call runs a subscription's callback, returning a panic as an error
+func call(sub subscriber, payload string) (err error) {
+	utils.TryCatch(func() {
+		err = sub.callback(sub.pattern, payload)
+	}, func(e error, stackTrace string) {
+		log.ErrorStack(stackTrace, "Panic handling message on %s: %v", sub.pattern, e)
+		err = e
+	})
+
+	return err
+}
+
+// unwrap returns the channel and payload of a message. Without raw message delivery the
+// body is an SNS notification carrying the payload and attributes; with it the body is the
+// payload and the attributes are SQS message attributes. Messages that weren't published by
+// this package are named after their topic where it is known.
+func (b *SNSMessageBroker) unwrap(message sqstypes.Message) (string, string) {
+	body := aws.ToString(message.Body)
+
+	var n notification
+	if strings.HasPrefix(body, "{") && json.Unmarshal([]byte(body), &n) == nil && n.Type == "Notification" && n.TopicArn != "" {
+		if attribute, ok := n.MessageAttributes[ChannelAttribute]; ok && attribute.Value != "" {
+			return attribute.Value, n.Message
+		}
+		return b.topicChannel(n.TopicArn), n.Message
+	}
+
+	if attribute, ok := message.MessageAttributes[ChannelAttribute]; ok {
+		return aws.ToString(attribute.StringValue), body
+	}
+
+	return "", body
+}
+
+// topicChannel returns the channel of a topic ARN: its name without the prefix
+func (b *SNSMessageBroker) topicChannel(topicArn string) string {
+	if name, ok := strings.CutPrefix(topicArn, b.config.TopicArnPrefix); ok {
+		return name
+	}
+	return topicArn[strings.LastIndex(topicArn, ":")+1:]
+}
@@ -0,0 +1,198 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+	"github.com/google/uuid"
+)
+
+// instanceId identifies this process as the source of the messages it publishes
+var instanceId = uuid.New().String()
+
+// InstanceId returns the ID stamped as the source of the messages this process publishes
+func InstanceId() string {
+	return instanceId
+}
+
+// Metadata describes a message published with Publish
+type Metadata struct {
+	Id        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"` // InstanceId of the publisher
+}
+
+// Envelope is the payload Publish sends: the message with its metadata
+type Envelope[T any] struct {
+	Metadata
+	Payload T `json:"payload"`
+}
+
+type SubscribeOptions struct {
+	MaxAttempts int                             // Handler calls per message, including the first (default 3)
+	Backoff     func(attempt int) time.Duration // Delay before retrying, given the attempts so far (default 100ms doubling)
+}
+
+type metadataKey struct{}
+
+// MessageMetadata returns the metadata of the message a Subscribe handler was called with.
+// Messages that weren't published with Publish have none.
+func MessageMetadata(ctx context.Context) (Metadata, bool) {
+	metadata, ok := ctx.Value(metadataKey{}).(Metadata)
+	return metadata, ok
+}
+
+func getSubscribeOptions(options ...SubscribeOptions) SubscribeOptions {
+	opts := SubscribeOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.MaxAttempts = utils.IntOrDefault(opts.MaxAttempts, 3)
+
+	if opts.Backoff == nil {
+		opts.Backoff = func(attempt int) time.Duration {
+			return 100 * time.Millisecond << min(attempt-1, 10)
+		}
+	}
+
+	return opts
+}
+
+// Publish publishes msg to channel in an envelope carrying a message ID, the time it was
+// published and the instance that published it
+//
+// Example:
+//
+//	err := pubsub.Publish(ctx, "orders", OrderCreated{Id: order.Id})
+func Publish[T any](ctx context.Context, channel string, msg T) error {
+	broker, err := GetBroker()
+	if err != nil {
+		return err
+	}
+
+	envelope := Envelope[T]{
+		Metadata: Metadata{
+			Id:        uuid.New().String(),
+			Timestamp: time.Now().UTC(),
+			Source:    instanceId,
+		},
+		Payload: msg,
+	}
+
+	if err := broker.Publish(ctx, channel, envelope); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+
+	return nil
+}
+
+// Subscribe calls handler with the messages published to channel, decoded as T. A handler
+// that returns an error or panics is called again after the backoff, up to MaxAttempts
+// times. Retries hold up later messages of the subscription. Payloads that aren't envelopes
+// are decoded as T directly, and payloads that can't be decoded are logged and skipped.
+// Call the returned function to unsubscribe.
+//
+// What happens to a message that still fails depends on the broker. Brokers that implement
+// IAckSubscriber, such as SNS, deliver it again later, so handling is at least once. Redis
+// pubsub can't redeliver, so the message is logged and dropped.
+//
+// Example:
+//
+//	unsubscribe, err := pubsub.Subscribe(ctx, "orders", func(ctx context.Context, order OrderCreated) error {
+//	    return fulfil(ctx, order)
+//	})
+func Subscribe[T any](ctx context.Context, channel string, handler func(ctx context.Context, msg T) error, options ...SubscribeOptions) (func() error, error) {
+	broker, err := GetBroker()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := getSubscribeOptions(options...)
+
+	if acker, ok := broker.(IAckSubscriber); ok {
+		return acker.SubscribeAck(ctx, channel, func(channel string, payload string) error {
+			return deliver(ctx, channel, payload, handler, opts)
+		})
+	}
+
+	return broker.Subscribe(ctx, channel, func(channel string, payload string) {
+		deliver(ctx, channel, payload, handler, opts)
+	}), nil
+}
+
+// deliver decodes a payload and calls handler until it succeeds or runs out of attempts,
+// returning the last error. Payloads that can't be decoded never will be, so they are
+// logged and reported as delivered.
+func deliver[T any](ctx context.Context, channel string, payload string, handler func(ctx context.Context, msg T) error, opts SubscribeOptions) error {
+	envelope, err := decodeEnvelope[T](payload)
+	if err != nil {
+		log.Errorf("Failed to decode message on %s: %v", channel, err)
+		return nil
+	}
+
+	if envelope.Id != "" {
+		ctx = context.WithValue(ctx, metadataKey{}, envelope.Metadata)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := handle(ctx, channel, envelope, handler)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= opts.MaxAttempts {
+			log.Errorf("Failed to handle message %s on %s after %d attempts: %v", envelope.Id, channel, attempt, err)
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Errorf("Failed to handle message %s on %s: %v", envelope.Id, channel, err)
+			return err
+		case <-time.After(opts.Backoff(attempt)):
+		}
+	}
+}
+
+// handle calls handler once, returning a panic as an error
+func handle[T any](ctx context.Context, channel string, envelope Envelope[T], handler func(ctx context.Context, msg T) error) (err error) {
+	utils.TryCatch(func() {
+		err = handler(ctx, envelope.Payload)
+	}, func(e error, stackTrace string) {
+		log.ErrorStack(stackTrace, "Panic handling message %s on %s: %v", envelope.Id, channel, e)
+		err = e
+	})
+
+	return err
+}
+
+// decodeEnvelope returns the envelope of a payload. Payloads that aren't envelopes are
+// decoded as the message itself, without metadata.
+func decodeEnvelope[T any](payload string) (Envelope[T], error) {
+	var raw struct {
+		Metadata
+		Payload json.RawMessage `json:"payload"`
+	}
+
+	var envelope Envelope[T]
+
+	if err := json.Unmarshal([]byte(payload), &raw); err == nil && raw.Id != "" && raw.Payload != nil {
+		envelope.Metadata = raw.Metadata
+		if err := json.Unmarshal(raw.Payload, &envelope.Payload); err != nil {
+			return envelope, fmt.Errorf("failed to decode payload of message %s: %w", raw.Id, err)
+		}
+		return envelope, nil
+	}
+
+	if err := json.Unmarshal([]byte(payload), &envelope.Payload); err != nil {
+		return envelope, fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	return envelope, nil
+}
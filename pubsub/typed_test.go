@@ -0,0 +1,220 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type order struct {
+	Id int `json:"id"`
+}
+
+// fakeBroker delivers published messages to its subscriptions right away
+type fakeBroker struct {
+	callbacks map[string]func(channel string, payload string)
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{callbacks: make(map[string]func(channel string, payload string))}
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, channel string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if callback, ok := b.callbacks[channel]; ok {
+		callback(channel, string(body))
+	}
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(ctx context.Context, channel string, callback func(channel string, payload string)) func() error {
+	b.callbacks[channel] = callback
+	return func() error { return nil }
+}
+
+// fakeAckBroker records whether each delivered message was acknowledged
+type fakeAckBroker struct {
+	fakeBroker
+	acks []error
+}
+
+func (b *fakeAckBroker) SubscribeAck(ctx context.Context, channel string, callback func(channel string, payload string) error) (func() error, error) {
+	b.callbacks[channel] = func(channel string, payload string) {
+		b.acks = append(b.acks, callback(channel, payload))
+	}
+	return func() error { return nil }, nil
+}
+
+// useBroker makes broker the package broker for a test
+func useBroker(t *testing.T, broker IMessageBroker) {
+	brokerMu.Lock()
+	previous := msgBroker
+	msgBroker = broker
+	brokerMu.Unlock()
+
+	t.Cleanup(func() {
+		brokerMu.Lock()
+		msgBroker = previous
+		brokerMu.Unlock()
+	})
+}
+
+var noBackoff = SubscribeOptions{Backoff: func(int) time.Duration { return 0 }}
+
+func TestDecodeEnvelope(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		expectedId   string
+		expectedBody order
+		wantErr      bool
+	}{
+		{"envelope", `{"id":"m1","timestamp":"2024-01-02T03:04:05Z","source":"i1","payload":{"id":7}}`, "m1", order{Id: 7}, false},
+		{"plain payload", `{"id":9}`, "", order{Id: 9}, false},
+		{"envelope without payload", `{"id":"m1"}`, "", order{}, true},
+		{"envelope with a bad payload", `{"id":"m1","payload":"seven"}`, "m1", order{}, true},
+		{"not json", `nope`, "", order{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, err := decodeEnvelope[order](tt.payload)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if envelope.Id != tt.expectedId || envelope.Payload != tt.expectedBody {
+				t.Errorf("expected %q %v, got %q %v", tt.expectedId, tt.expectedBody, envelope.Id, envelope.Payload)
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int  // Attempts that fail before the handler succeeds
+		panics        bool // Failing attempts panic instead of returning an error
+		maxAttempts   int
+		expectedCalls int
+		wantErr       bool
+	}{
+		{"succeeds", 0, false, 3, 1, false},
+		{"retries errors", 2, false, 3, 3, false},
+		{"retries panics", 2, true, 3, 3, false},
+		{"runs out of attempts", 5, false, 3, 3, true},
+		{"runs out of attempts panicking", 5, true, 2, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := func(ctx context.Context, msg order) error {
+				calls++
+				if calls <= tt.failures {
+					if tt.panics {
+						panic("boom")
+					}
+					return errors.New("failed")
+				}
+				return nil
+			}
+
+			opts := getSubscribeOptions(SubscribeOptions{MaxAttempts: tt.maxAttempts, Backoff: noBackoff.Backoff})
+			err := deliver(context.Background(), "orders", `{"id":1}`, handler, opts)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestDeliverStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+
+	err := deliver(ctx, "orders", `{"id":1}`, func(ctx context.Context, msg order) error {
+		calls++
+		cancel()
+		return errors.New("failed")
+	}, getSubscribeOptions(SubscribeOptions{Backoff: func(int) time.Duration { return time.Hour }}))
+
+	if err == nil || calls != 1 {
+		t.Errorf("expected one failed call, got %d calls and %v", calls, err)
+	}
+}
+
+func TestDeliverSkipsUndecodable(t *testing.T) {
+	calls := 0
+
+	err := deliver(context.Background(), "orders", `nope`, func(ctx context.Context, msg order) error {
+		calls++
+		return nil
+	}, getSubscribeOptions())
+
+	if err != nil || calls != 0 {
+		t.Errorf("expected the payload to be skipped, got %d calls and %v", calls, err)
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	broker := newFakeBroker()
+	useBroker(t, broker)
+
+	var received order
+	var metadata Metadata
+
+	_, err := Subscribe(context.Background(), "orders", func(ctx context.Context, msg order) error {
+		received = msg
+		metadata, _ = MessageMetadata(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Publish(context.Background(), "orders", order{Id: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Id != 7 {
+		t.Errorf("expected order 7, got %v", received)
+	}
+	if metadata.Id == "" || metadata.Source != InstanceId() || metadata.Timestamp.IsZero() {
+		t.Errorf("expected the envelope's metadata, got %+v", metadata)
+	}
+}
+
+func TestSubscribeAcknowledges(t *testing.T) {
+	broker := &fakeAckBroker{fakeBroker: *newFakeBroker()}
+	useBroker(t, broker)
+
+	_, err := Subscribe(context.Background(), "orders", func(ctx context.Context, msg order) error {
+		if msg.Id == 2 {
+			return errors.New("failed")
+		}
+		return nil
+	}, SubscribeOptions{MaxAttempts: 2, Backoff: noBackoff.Backoff})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Publish(context.Background(), "orders", order{Id: 1})
+	Publish(context.Background(), "orders", order{Id: 2})
+
+	if len(broker.acks) != 2 || broker.acks[0] != nil || broker.acks[1] == nil {
+		t.Errorf("expected the failed message to be rejected for redelivery, got %v", broker.acks)
+	}
+}
@@ -0,0 +1,467 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/finch-technologies/go-utils/events"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/queue/redis"
+	"github.com/finch-technologies/go-utils/queue/sqs"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Client sends and receives messages through one queue driver. The package functions use
+// the client set up by Init; create more with NewClient to use several brokers, or several
+// AWS accounts, from one process.
+type Client struct {
+	driver               IMessageQueue
+	compression          Compression
+	compressionThreshold int
+}
+
+// NewClient creates a client for the driver of config
+//
+// Example:
+//
+//	billing, err := queue.NewClient(queue.QueueConfig{
+//	    Driver:  queue.QueueDriverSQS,
+//	    Region:  "eu-west-1",
+//	    BaseUrl: billingQueueUrl,
+//	})
+//	err = queue.Typed[Invoice](billing).Enqueue(ctx, InvoicesQueue, invoice)
+func NewClient(config QueueConfig) (*Client, error) {
+	client := &Client{
+		compression:          config.Compression,
+		compressionThreshold: utils.IntOrDefault(config.CompressionThreshold, defaultCompressionThreshold),
+	}
+
+	redisDb := 4
+
+	if config.RedisDb != nil {
+		redisDb = *config.RedisDb
+	}
+
+	if config.Region == "" {
+		config.Region = utils.StringOrDefault(os.Getenv("AWS_REGION"), "af-south-1")
+	}
+
+	switch config.Driver {
+	case QueueDriverRedis:
+		if config.RedisClient != nil {
+			client.driver = redis.NewWithClient(config.RedisClient)
+		} else {
+			client.driver = redis.New(redisDb) //queue db
+		}
+	case QueueDriverSQS:
+		if config.BaseUrl == "" {
+			return nil, fmt.Errorf("sqs base url is required")
+		}
+		driver, err := sqs.New(sqs.SQSConfig{
+			Region:     config.Region,
+			SQSBaseUrl: config.BaseUrl,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sqs queue: %s", err)
+		}
+		client.driver = driver
+	default:
+		return nil, fmt.Errorf("no valid queue driver specified")
+	}
+
+	return client, nil
+}
+
+// NewClientWithDriver creates a client for a driver created by the caller, e.g. a driver of
+// another package implementing IMessageQueue
+func NewClientWithDriver(driver IMessageQueue, options ...QueueConfig) *Client {
+	client := &Client{driver: driver, compressionThreshold: defaultCompressionThreshold}
+
+	if len(options) > 0 {
+		client.compression = options[0].Compression
+		client.compressionThreshold = utils.IntOrDefault(options[0].CompressionThreshold, defaultCompressionThreshold)
+	}
+
+	return client
+}
+
+// Driver returns the queue driver of the client
+func (c *Client) Driver() IMessageQueue {
+	if c == nil {
+		return nil
+	}
+	return c.driver
+}
+
+// check fails if the client has no driver, e.g. the default client before Init
+func (c *Client) check() error {
+	if c == nil || c.driver == nil {
+		return fmt.Errorf("no queue driver found")
+	}
+	return nil
+}
+
+func (c *Client) Count(ctx context.Context, queue Queue) (int, error) {
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+
+	return c.driver.Count(ctx, string(queue))
+}
+
+func (c *Client) Delete(ctx context.Context, queue Queue, id string) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	return c.driver.Delete(ctx, string(queue), id)
+}
+
+// ChangeVisibility hides a received message for d from now, see the package function
+func (c *Client) ChangeVisibility(ctx context.Context, queue Queue, receiptHandle string, d time.Duration) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	changer, ok := c.driver.(IVisibilityChanger)
+	if !ok {
+		return fmt.Errorf("queue driver does not support visibility timeouts")
+	}
+
+	return changer.ChangeVisibility(ctx, string(queue), receiptHandle, d)
+}
+
+// Redrive moves up to max messages (all if max <= 0) from one queue to another, see the
+// package function
+func (c *Client) Redrive(ctx context.Context, fromQueue, toQueue Queue, max int, options ...types.RedriveOptions) (int, error) {
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+
+	redriver, ok := c.driver.(IRedriver)
+	if !ok {
+		return 0, fmt.Errorf("queue driver does not support redrive")
+	}
+
+	return redriver.Redrive(ctx, string(fromQueue), string(toQueue), max, options...)
+}
+
+// Purge deletes every message in a queue
+func (c *Client) Purge(ctx context.Context, queue Queue) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	return c.driver.Purge(ctx, string(queue))
+}
+
+// EnqueueEvent sends an event registered with the events package, see the package function
+func (c *Client) EnqueueEvent(ctx context.Context, queue Queue, event any, options ...types.EnqueueOptions) error {
+	body, err := events.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	return Typed[json.RawMessage](c).Enqueue(ctx, queue, json.RawMessage(body), options...)
+}
+
+// DequeueEvents dequeues events registered with the events package, see the package function
+func (c *Client) DequeueEvents(ctx context.Context, queue Queue, options ...types.DequeueOptions) ([]types.QueueMessage[any], error) {
+	opts := types.GenericDequeueOptions[any]{
+		WaitTimeSeconds: 20,
+		BatchSize:       1,
+		DeleteMessage:   true,
+		ParseFunc:       events.ParseAny,
+	}
+
+	if len(options) > 0 {
+		opts.WaitTimeSeconds = options[0].WaitTimeSeconds
+		opts.BatchSize = options[0].BatchSize
+		opts.DeleteMessage = options[0].DeleteMessage
+		opts.VisibilityTimeout = options[0].VisibilityTimeout
+	}
+
+	return Typed[any](c).Dequeue(ctx, queue, opts)
+}
+
+// TypedClient is the generic API of a client for messages with payloads of type T. Go
+// methods can't have type parameters, so the generic package functions are methods here.
+type TypedClient[T any] struct {
+	client *Client
+}
+
+// Typed returns the generic API of client for payloads of type T
+//
+// Example:
+//
+//	orders := queue.Typed[Order](client)
+//	err := orders.Enqueue(ctx, OrdersQueue, order)
+//	messages, err := orders.Dequeue(ctx, OrdersQueue)
+func Typed[T any](client *Client) TypedClient[T] {
+	return TypedClient[T]{client: client}
+}
+
+// Enqueue sends a message with payload encoded as JSON
+func (t TypedClient[T]) Enqueue(ctx context.Context, queue Queue, payload T, options ...types.EnqueueOptions) error {
+	c := t.client
+
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload to json: %s", err)
+	}
+
+	enqueueOptions := types.EnqueueOptions{}
+
+	if len(options) > 0 {
+		enqueueOptions = options[0]
+	}
+
+	enqueueOptions.Attributes = InjectTrace(ctx, enqueueOptions.Attributes)
+
+	if err := stampDeadLetterPolicy(enqueueOptions); err != nil {
+		return err
+	}
+
+	body, err := c.compressBody(string(jsonBytes))
+	if err != nil {
+		return err
+	}
+
+	return c.driver.Enqueue(ctx, string(queue), body, enqueueOptions)
+}
+
+// EnqueueBatch sends several messages with the same options, see the package function
+func (t TypedClient[T]) EnqueueBatch(ctx context.Context, queue Queue, payloads []T, options ...types.EnqueueOptions) error {
+	c := t.client
+
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	bodies := make([]string, len(payloads))
+
+	for i, payload := range payloads {
+		jsonBytes, err := json.Marshal(payload)
+
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload to json: %s", err)
+		}
+
+		bodies[i], err = c.compressBody(string(jsonBytes))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	enqueueOptions := types.EnqueueOptions{}
+
+	if len(options) > 0 {
+		enqueueOptions = options[0]
+	}
+
+	enqueueOptions.Attributes = InjectTrace(ctx, enqueueOptions.Attributes)
+
+	if err := stampDeadLetterPolicy(enqueueOptions); err != nil {
+		return err
+	}
+
+	return c.driver.EnqueueBatch(ctx, string(queue), bodies, enqueueOptions)
+}
+
+// NewEnqueueBatcher returns a batcher that enqueues messages in batches, see the package
+// function
+func (t TypedClient[T]) NewEnqueueBatcher(queue Queue, options ...utils.BatcherOptions[T]) *utils.Batcher[T] {
+	opts := utils.BatcherOptions[T]{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.MaxSize = utils.IntOrDefault(opts.MaxSize, 10)
+
+	return utils.NewBatcher(func(ctx context.Context, batch []T) error {
+		return t.EnqueueBatch(ctx, queue, batch)
+	}, opts)
+}
+
+// Dequeue receives messages and decodes their payloads, see the package function
+func (t TypedClient[T]) Dequeue(ctx context.Context, queue Queue, options ...types.GenericDequeueOptions[T]) ([]types.QueueMessage[T], error) {
+	c := t.client
+
+	var messages []types.QueueMessage[T]
+
+	if err := c.check(); err != nil {
+		return messages, err
+	}
+
+	dequeueOptions := types.DequeueOptions{
+		WaitTimeSeconds: 20,
+		BatchSize:       1,
+		DeleteMessage:   true,
+	}
+
+	var parse func(body string) (T, error)
+
+	if len(options) > 0 {
+		dequeueOptions.WaitTimeSeconds = options[0].WaitTimeSeconds
+		dequeueOptions.BatchSize = utils.IntOrDefault(options[0].BatchSize, 1)
+		dequeueOptions.DeleteMessage = options[0].DeleteMessage
+		dequeueOptions.VisibilityTimeout = options[0].VisibilityTimeout
+		parse = options[0].ParseFunc
+	}
+
+	if parse == nil {
+		parse = func(body string) (T, error) {
+			var payload T
+			if err := json.Unmarshal([]byte(body), &payload); err != nil {
+				return payload, fmt.Errorf("failed to unmarshal payload from json: %w", err)
+			}
+			return payload, nil
+		}
+	}
+
+	dequeuedMessages, err := c.driver.Dequeue(ctx, string(queue), dequeueOptions)
+
+	if err != nil {
+		return messages, fmt.Errorf("failed to dequeue item from queue: %s", err)
+	}
+
+	dequeueErr := &types.DequeueError{}
+
+	for _, dequeuedMessage := range dequeuedMessages {
+		if c.deadLetterExpired(ctx, queue, dequeuedMessage, dequeueOptions.DeleteMessage) {
+			continue
+		}
+
+		payload, err := decodePayload(dequeuedMessage.Body, parse)
+
+		if err != nil {
+			dequeueErr.Failed = append(dequeueErr.Failed, types.DecodeError{
+				MessageId:     dequeuedMessage.MessageId,
+				ReceiptHandle: dequeuedMessage.ReceiptHandle,
+				Body:          dequeuedMessage.Body,
+				Err:           err,
+			})
+			continue
+		}
+
+		messages = append(messages, types.QueueMessage[T]{
+			MessageId:               dequeuedMessage.MessageId,
+			ReceiptHandle:           dequeuedMessage.ReceiptHandle,
+			Payload:                 payload,
+			ReceivedAt:              dequeuedMessage.ReceivedAt,
+			ApproximateReceiveCount: dequeuedMessage.ApproximateReceiveCount,
+			Attributes:              dequeuedMessage.Attributes,
+		})
+	}
+
+	if len(dequeueErr.Failed) > 0 {
+		return messages, dequeueErr
+	}
+
+	return messages, nil
+}
+
+// decodePayload decompresses a message body and parses its payload
+func decodePayload[T interface{}](body string, parse func(body string) (T, error)) (T, error) {
+	body, err := decompressBody(body)
+
+	if err != nil {
+		var payload T
+		return payload, err
+	}
+
+	return parse(body)
+}
+
+// Drain consumes messages with handler until the queue is empty, see the package function
+func (t TypedClient[T]) Drain(ctx context.Context, queue Queue, handler func(ctx context.Context, message types.QueueMessage[T]) error, options ...types.DrainOptions) (types.DrainProgress, error) {
+	c := t.client
+	opts := getDrainOptions(options)
+	progress := types.DrainProgress{}
+
+	if err := c.check(); err != nil {
+		return progress, err
+	}
+
+	start := time.Now()
+	lastReport := start
+
+	report := func() {
+		remaining, err := c.Count(ctx, queue)
+		if err == nil {
+			progress.Remaining = remaining
+		}
+		progress.Elapsed = time.Since(start)
+		opts.OnProgress(progress)
+	}
+
+	for {
+		if ctx.Err() != nil {
+			report()
+			return progress, ctx.Err()
+		}
+
+		messages, err := t.Dequeue(ctx, queue, types.GenericDequeueOptions[T]{
+			WaitTimeSeconds: opts.WaitTimeSeconds,
+			BatchSize:       opts.BatchSize,
+			DeleteMessage:   false,
+		})
+
+		var dequeueErr *types.DequeueError
+		if errors.As(err, &dequeueErr) {
+			for _, failed := range dequeueErr.Failed {
+				log.Errorf("Failed to decode message %s while draining %s: %v", failed.MessageId, queue, failed.Err)
+			}
+			progress.Failed += len(dequeueErr.Failed)
+		} else if err != nil {
+			report()
+			return progress, err
+		}
+
+		if len(messages) == 0 && dequeueErr == nil {
+			break
+		}
+
+		for _, message := range messages {
+			if err := handler(MessageContext(ctx, message), message); err != nil {
+				log.Errorf("Failed to handle message %s while draining %s: %v", message.MessageId, queue, err)
+				progress.Failed++
+				continue
+			}
+
+			if err := c.Delete(ctx, queue, message.ReceiptHandle); err != nil {
+				log.Errorf("Failed to delete message %s while draining %s: %v", message.MessageId, queue, err)
+			}
+
+			progress.Processed++
+		}
+
+		if time.Since(lastReport) >= opts.ProgressInterval {
+			lastReport = time.Now()
+			report()
+		}
+	}
+
+	report()
+
+	return progress, nil
+}
+
+// NewConsumer creates a consumer of queue on this client, see the package function
+func (t TypedClient[T]) NewConsumer(queue Queue, handler MessageHandler[T], options ...ConsumerOptions) *Consumer[T] {
+	consumer := NewConsumer(queue, handler, options...)
+	consumer.client = t.client
+	return consumer
+}
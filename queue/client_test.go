@@ -0,0 +1,383 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// fakeMessage is a message of a fakeQueue, hidden until visibleAt
+type fakeMessage struct {
+	types.DequeuedMessage
+	visibleAt time.Time
+}
+
+// fakeQueue is an in-memory driver that hides received messages until they are deleted or
+// their visibility timeout expires, as SQS does
+type fakeQueue struct {
+	mu       sync.Mutex
+	queues   map[string][]*fakeMessage
+	nextId   int
+	received int
+}
+
+func newFakeQueue() *fakeQueue {
+	return &fakeQueue{queues: make(map[string][]*fakeMessage)}
+}
+
+func (q *fakeQueue) Count(ctx context.Context, queue string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+	for _, message := range q.queues[queue] {
+		if !message.visibleAt.After(time.Now()) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *fakeQueue) Enqueue(ctx context.Context, queue string, payload string, options ...types.EnqueueOptions) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	opts := types.EnqueueOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	q.nextId++
+	id := fmt.Sprintf("m%d", q.nextId)
+
+	q.queues[queue] = append(q.queues[queue], &fakeMessage{
+		DequeuedMessage: types.DequeuedMessage{MessageId: id, ReceiptHandle: id, Body: payload, Attributes: opts.Attributes},
+		visibleAt:       time.Now().Add(opts.Delay),
+	})
+	return nil
+}
+
+func (q *fakeQueue) EnqueueBatch(ctx context.Context, queue string, payloads []string, options ...types.EnqueueOptions) error {
+	for _, payload := range payloads {
+		q.Enqueue(ctx, queue, payload, options...)
+	}
+	return nil
+}
+
+func (q *fakeQueue) Dequeue(ctx context.Context, queue string, options ...types.DequeueOptions) ([]types.DequeuedMessage, error) {
+	opts := options[0]
+	messages := q.receive(queue, opts)
+
+	// Long poll briefly, so consumers don't spin on an empty queue
+	if len(messages) == 0 && opts.WaitTimeSeconds > 0 {
+		utils.Sleep(ctx, time.Millisecond)
+	}
+
+	return messages, nil
+}
+
+func (q *fakeQueue) receive(queue string, opts types.DequeueOptions) []types.DequeuedMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	messages := []types.DequeuedMessage{}
+	kept := []*fakeMessage{}
+
+	for _, message := range q.queues[queue] {
+		if len(messages) == opts.BatchSize || message.visibleAt.After(time.Now()) {
+			kept = append(kept, message)
+			continue
+		}
+
+		message.ApproximateReceiveCount++
+		message.ReceivedAt = time.Now()
+		message.visibleAt = time.Now().Add(utils.DurationOrDefault(opts.VisibilityTimeout, time.Minute))
+		messages = append(messages, message.DequeuedMessage)
+		q.received++
+
+		if !opts.DeleteMessage {
+			kept = append(kept, message)
+		}
+	}
+
+	q.queues[queue] = kept
+
+	return messages
+}
+
+func (q *fakeQueue) Delete(ctx context.Context, queue string, receiptHandle string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, message := range q.queues[queue] {
+		if message.ReceiptHandle == receiptHandle {
+			q.queues[queue] = append(q.queues[queue][:i], q.queues[queue][i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQueue) Purge(ctx context.Context, queue string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.queues, queue)
+	return nil
+}
+
+func (q *fakeQueue) ChangeVisibility(ctx context.Context, queue string, receiptHandle string, d time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, message := range q.queues[queue] {
+		if message.ReceiptHandle == receiptHandle {
+			message.visibleAt = time.Now().Add(d)
+			return nil
+		}
+	}
+	return fmt.Errorf("message is no longer in flight")
+}
+
+// messages returns the messages of a queue, visible or not
+func (q *fakeQueue) messages(queue string) []fakeMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]fakeMessage, len(q.queues[queue]))
+	for i, message := range q.queues[queue] {
+		result[i] = *message
+	}
+	return result
+}
+
+// basicQueue hides the optional interfaces of a fakeQueue
+type basicQueue struct {
+	IMessageQueue
+}
+
+type order struct {
+	Id int `json:"id"`
+}
+
+func TestClientWithoutDriver(t *testing.T) {
+	ctx := context.Background()
+
+	var client *Client
+
+	if _, err := client.Count(ctx, "orders"); err == nil {
+		t.Error("expected Count to fail without a driver")
+	}
+	if err := Typed[order](client).Enqueue(ctx, "orders", order{}); err == nil {
+		t.Error("expected Enqueue to fail without a driver")
+	}
+	if _, err := Typed[order](client).Dequeue(ctx, "orders"); err == nil {
+		t.Error("expected Dequeue to fail without a driver")
+	}
+	if _, err := NewClient(QueueConfig{Driver: QueueDriverSQS}); err == nil {
+		t.Error("expected the sqs driver to require a base url")
+	}
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := WithTenantId(WithCorrelationId(context.Background(), "c1"), "t1")
+
+	attributes := map[string]string{"source": "checkout"}
+
+	if err := orders.Enqueue(ctx, "orders", order{Id: 1}, types.EnqueueOptions{Attributes: attributes}); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.EnqueueBatch(ctx, "orders", []order{{Id: 2}, {Id: 3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(attributes) != 1 {
+		t.Errorf("expected the caller's attributes to be left alone, got %v", attributes)
+	}
+
+	messages, err := orders.Dequeue(ctx, "orders", types.GenericDequeueOptions[order]{BatchSize: 10, DeleteMessage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 3 || messages[0].Payload.Id != 1 || messages[2].Payload.Id != 3 {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	first := messages[0].Attributes
+	if first["source"] != "checkout" || first[CorrelationIdAttribute] != "c1" || first[TenantIdAttribute] != "t1" {
+		t.Errorf("expected the attributes and trace to be sent, got %v", first)
+	}
+
+	if count, _ := NewClientWithDriver(driver).Count(ctx, "orders"); count != 0 {
+		t.Errorf("expected the messages to be deleted, got %d", count)
+	}
+}
+
+func TestDequeueDefaults(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := context.Background()
+
+	orders.EnqueueBatch(ctx, "orders", []order{{Id: 1}, {Id: 2}})
+
+	messages, err := orders.Dequeue(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 1 || len(driver.messages("orders")) != 1 {
+		t.Errorf("expected one message to be received and deleted, got %+v", messages)
+	}
+}
+
+func TestDequeueDecodeErrors(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := context.Background()
+
+	orders.Enqueue(ctx, "orders", order{Id: 1})
+	driver.Enqueue(ctx, "orders", "not json")
+	orders.Enqueue(ctx, "orders", order{Id: 2})
+
+	messages, err := orders.Dequeue(ctx, "orders", types.GenericDequeueOptions[order]{BatchSize: 10})
+
+	var dequeueErr *types.DequeueError
+	if !errors.As(err, &dequeueErr) {
+		t.Fatalf("expected a DequeueError, got %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Errorf("expected the messages that decoded, got %+v", messages)
+	}
+	if len(dequeueErr.Failed) != 1 || dequeueErr.Failed[0].Body != "not json" || dequeueErr.Failed[0].ReceiptHandle == "" {
+		t.Errorf("unexpected failures %+v", dequeueErr.Failed)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	driver := newFakeQueue()
+	client := NewClientWithDriver(driver, QueueConfig{Compression: CompressionGzip, CompressionThreshold: 100})
+	ctx := context.Background()
+
+	large := strings.Repeat("a", 1000)
+
+	if err := Typed[string](client).Enqueue(ctx, "notes", large); err != nil {
+		t.Fatal(err)
+	}
+	Typed[string](client).Enqueue(ctx, "notes", "small")
+
+	stored := driver.messages("notes")
+	if !strings.HasPrefix(stored[0].Body, compressedBodyPrefix) || stored[1].Body != `"small"` {
+		t.Errorf("expected only the large body to be compressed, got %.40s and %s", stored[0].Body, stored[1].Body)
+	}
+
+	// Consumers without compression configured still decompress
+	messages, err := Typed[string](NewClientWithDriver(driver)).Dequeue(ctx, "notes", types.GenericDequeueOptions[string]{BatchSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 2 || messages[0].Payload != large || messages[1].Payload != "small" {
+		t.Errorf("expected the bodies back, got %d messages", len(messages))
+	}
+}
+
+func TestOptionalDriverFeatures(t *testing.T) {
+	client := NewClientWithDriver(basicQueue{newFakeQueue()})
+	ctx := context.Background()
+
+	if err := client.ChangeVisibility(ctx, "orders", "m1", time.Minute); err == nil {
+		t.Error("expected ChangeVisibility to fail on a driver without visibility timeouts")
+	}
+	if _, err := client.Redrive(ctx, "orders-dlq", "orders", 0); err == nil {
+		t.Error("expected Redrive to fail on a driver without redrive")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := context.Background()
+
+	orders.EnqueueBatch(ctx, "orders", []order{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 5}})
+	driver.Enqueue(ctx, "orders", "not json")
+
+	reports := []types.DrainProgress{}
+	handled := []int{}
+
+	progress, err := orders.Drain(ctx, "orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+		handled = append(handled, message.Payload.Id)
+		if message.Payload.Id == 3 {
+			return errors.New("failed")
+		}
+		return nil
+	}, types.DrainOptions{
+		BatchSize:  2,
+		OnProgress: func(progress types.DrainProgress) { reports = append(reports, progress) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(handled) != 5 {
+		t.Errorf("expected every message to be handled once, got %v", handled)
+	}
+	if progress.Processed != 4 || progress.Failed != 2 {
+		t.Errorf("expected 4 processed and 2 failed, got %+v", progress)
+	}
+	if len(reports) == 0 || reports[len(reports)-1].Processed != 4 {
+		t.Errorf("expected a final progress report, got %+v", reports)
+	}
+
+	left := driver.messages("orders")
+	if len(left) != 2 {
+		t.Errorf("expected the failed messages to be left for redelivery, got %+v", left)
+	}
+}
+
+func TestDrainStopsWithContext(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	orders.EnqueueBatch(ctx, "orders", []order{{Id: 1}, {Id: 2}})
+
+	progress, err := orders.Drain(ctx, "orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+		cancel()
+		return nil
+	}, types.DrainOptions{BatchSize: 1, OnProgress: func(types.DrainProgress) {}})
+
+	if !errors.Is(err, context.Canceled) || progress.Processed != 1 {
+		t.Errorf("expected Drain to stop after the first message, got %+v %v", progress, err)
+	}
+}
+
+func TestPackageDequeueWithoutOptions(t *testing.T) {
+	driver := newFakeQueue()
+
+	previous := defaultClient
+	defaultClient = NewClientWithDriver(driver)
+	t.Cleanup(func() { defaultClient = previous })
+
+	ctx := context.Background()
+	Enqueue(ctx, "orders", order{Id: 1})
+
+	messages, err := Dequeue[order](ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 1 || messages[0].Payload.Id != 1 {
+		t.Errorf("expected the message, got %+v", messages)
+	}
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Compression is the codec used for large message bodies
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+)
+
+// defaultCompressionThreshold is the body size above which bodies are compressed, well
+// below the 256KB SQS limit
+const defaultCompressionThreshold = 64 * 1024
+
+// compressedBodyPrefix starts every compressed body, so consumers can detect them without
+// decoding every message
+const compressedBodyPrefix = `{"$encoding":`
+
+// compressedBody is the envelope of a compressed message. It is JSON so the body stays
+// valid for every driver.
+type compressedBody struct {
+	Encoding Compression `json:"$encoding"`
+	Data     []byte      `json:"data"`
+}
+
+// compressBody compresses body if compression is enabled and body is above the threshold.
+// Bodies that don't shrink are sent as they are.
+func (c *Client) compressBody(body string) (string, error) {
+	if c.compression == CompressionNone || len(body) <= c.compressionThreshold {
+		return body, nil
+	}
+
+	var buffer bytes.Buffer
+
+	switch c.compression {
+	case CompressionGzip:
+		writer := gzip.NewWriter(&buffer)
+
+		if _, err := writer.Write([]byte(body)); err != nil {
+			return "", fmt.Errorf("failed to compress message body: %w", err)
+		}
+
+		if err := writer.Close(); err != nil {
+			return "", fmt.Errorf("failed to compress message body: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("unsupported message compression %q", c.compression)
+	}
+
+	envelope, err := json.Marshal(compressedBody{Encoding: c.compression, Data: buffer.Bytes()})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal compressed message body: %w", err)
+	}
+
+	if len(envelope) >= len(body) {
+		return body, nil
+	}
+
+	return string(envelope), nil
+}
+
+// decompressBody returns the original body of a compressed message, and other bodies as
+// they are. Consumers decompress whether or not compression is enabled for them.
+func decompressBody(body string) (string, error) {
+	if !strings.HasPrefix(body, compressedBodyPrefix) {
+		return body, nil
+	}
+
+	var envelope compressedBody
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return "", fmt.Errorf("failed to unmarshal compressed message body: %w", err)
+	}
+
+	switch envelope.Encoding {
+	case CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(envelope.Data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress message body: %w", err)
+		}
+		defer reader.Close()
+
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress message body: %w", err)
+		}
+
+		return string(decompressed), nil
+	default:
+		return "", fmt.Errorf("unsupported message compression %q", envelope.Encoding)
+	}
+}
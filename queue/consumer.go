@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ConsumerOptions configures a Consumer
+type ConsumerOptions struct {
+	Concurrency       int           // Messages handled at once (default 1)
+	BatchSize         int           // Most messages received per poll (default Concurrency, at most 10)
+	WaitTimeSeconds   int           // Long polling wait of a poll (default 20)
+	VisibilityTimeout time.Duration // How long received messages stay hidden (default the queue's setting)
+
+	// MaxReceiveCount is the number of attempts after which a failing message is moved to
+	// DeadLetterQueue, or deleted without one (default the dead letter policy the message was
+	// enqueued with, else retried until the queue's own redrive policy moves it)
+	MaxReceiveCount int
+	DeadLetterQueue Queue
+
+	// Backoff is the delay before a failed message is retried, given the attempts so far. It
+	// is applied by changing the message's visibility, or as the Delay of messages
+	// re-enqueued with Requeue (default the queue's visibility timeout).
+	Backoff func(attempt int) time.Duration
+
+	// Requeue re-enqueues failed messages and deletes the originals instead of leaving them
+	// for redelivery, which puts retries behind the messages already waiting. Attempts are
+	// counted in the RetryCountAttribute attribute.
+	Requeue bool
+
+	PollErrorDelay time.Duration // Wait after a failed poll before polling again (default 1s)
+}
+
+// Consumer long-polls a queue and hands messages to a pool of workers. A message is deleted
+// once its handler succeeds; failed messages are retried, re-enqueued or dead-lettered as
+// configured by ConsumerOptions.
+type Consumer[T any] struct {
+	client  *Client // nil for the client set up by Init
+	queue   Queue
+	handler MessageHandler[T]
+	opts    ConsumerOptions
+}
+
+// NewConsumer creates a consumer of queue on the client set up by Init. Wrap handler with
+// Instrument to report metrics.
+//
+// Example:
+//
+//	consumer := queue.NewConsumer(OrdersQueue, handleOrder, queue.ConsumerOptions{
+//	    Concurrency:     8,
+//	    MaxReceiveCount: 5,
+//	    DeadLetterQueue: OrdersDLQ,
+//	})
+//	err := consumer.Start(ctx)
+func NewConsumer[T any](queue Queue, handler MessageHandler[T], options ...ConsumerOptions) *Consumer[T] {
+	opts := ConsumerOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Concurrency = utils.IntOrDefault(opts.Concurrency, 1)
+	opts.BatchSize = min(utils.IntOrDefault(opts.BatchSize, opts.Concurrency), 10)
+	opts.WaitTimeSeconds = utils.IntOrDefault(opts.WaitTimeSeconds, 20)
+	opts.PollErrorDelay = utils.DurationOrDefault(opts.PollErrorDelay, time.Second)
+
+	return &Consumer[T]{queue: queue, handler: handler, opts: opts}
+}
+
+// Start consumes messages until ctx is cancelled, then waits for the handlers in flight to
+// return. Handlers get ctx with the message's trace context restored, so they see the
+// cancellation too. Messages are only received while a worker is free to handle them.
+func (c *Consumer[T]) Start(ctx context.Context) error {
+	client := c.client
+	if client == nil {
+		client = defaultClient
+	}
+
+	if err := client.check(); err != nil {
+		return err
+	}
+
+	slots := make(chan struct{}, c.opts.Concurrency)
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+
+	for {
+		// Wait for a free worker, then take as many more as are free up to the batch size
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		acquired := 1
+	fill:
+		for acquired < c.opts.BatchSize {
+			select {
+			case slots <- struct{}{}:
+				acquired++
+			default:
+				break fill
+			}
+		}
+
+		messages, err := Typed[T](client).Dequeue(ctx, c.queue, types.GenericDequeueOptions[T]{
+			WaitTimeSeconds:   c.opts.WaitTimeSeconds,
+			BatchSize:         acquired,
+			DeleteMessage:     false,
+			VisibilityTimeout: c.opts.VisibilityTimeout,
+		})
+
+		// Release the slots no message arrived for
+		for range acquired - len(messages) {
+			<-slots
+		}
+
+		var dequeueErr *types.DequeueError
+		if errors.As(err, &dequeueErr) {
+			// Malformed messages aren't handled, so drivers that redeliver retry them until
+			// their dead letter policy moves them
+			for _, failed := range dequeueErr.Failed {
+				log.Errorf("Failed to decode message %s from %s: %v", failed.MessageId, c.queue, failed.Err)
+			}
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Errorf("Failed to receive messages from %s: %v", c.queue, err)
+			utils.Sleep(ctx, c.opts.PollErrorDelay)
+			continue
+		}
+
+		for _, message := range messages {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				c.handle(ctx, client, message)
+			}()
+		}
+	}
+}
+
+// handle runs the handler for a message and acknowledges, retries or dead-letters it
+func (c *Consumer[T]) handle(ctx context.Context, client *Client, message types.QueueMessage[T]) {
+	_, err := utils.TryReturn(func() (struct{}, error) {
+		return struct{}{}, c.handler(MessageContext(ctx, message), message)
+	})
+
+	// Settle the message even if the consumer is shutting down
+	ctx = context.WithoutCancel(ctx)
+
+	if err == nil {
+		if err := client.Delete(ctx, c.queue, message.ReceiptHandle); err != nil {
+			log.Errorf("Failed to delete message %s from %s: %v", message.MessageId, c.queue, err)
+		}
+		return
+	}
+
+	attempt := attempts(message.ApproximateReceiveCount, message.Attributes)
+
+	log.Errorf("Failed to handle message %s from %s on attempt %d: %v", message.MessageId, c.queue, attempt, err)
+
+	deadLetterQueue, maxReceiveCount := c.deadLetterPolicy(message)
+
+	switch {
+	case maxReceiveCount > 0 && attempt >= maxReceiveCount:
+		c.deadLetter(ctx, client, message, deadLetterQueue, attempt)
+	case c.opts.Requeue:
+		opts := types.EnqueueOptions{
+			Attributes: map[string]string{RetryCountAttribute: strconv.Itoa(attempt)},
+		}
+		if c.opts.Backoff != nil {
+			opts.Delay = c.opts.Backoff(attempt)
+		}
+
+		err := Typed[T](client).Requeue(ctx, c.queue, c.queue, message, opts)
+		if err != nil {
+			log.Errorf("Failed to re-enqueue message %s to %s: %v", message.MessageId, c.queue, err)
+		}
+	case c.opts.Backoff != nil:
+		if err := client.ChangeVisibility(ctx, c.queue, message.ReceiptHandle, c.opts.Backoff(attempt)); err != nil {
+			log.Errorf("Failed to delay retry of message %s from %s: %v", message.MessageId, c.queue, err)
+		}
+	}
+}
+
+// deadLetterPolicy returns the dead letter queue and maximum receive count of the consumer,
+// falling back to the policy the message was enqueued with
+func (c *Consumer[T]) deadLetterPolicy(message types.QueueMessage[T]) (Queue, int) {
+	deadLetterQueue, maxReceiveCount := deadLetterPolicy(message.Attributes)
+
+	if c.opts.DeadLetterQueue != "" {
+		deadLetterQueue = c.opts.DeadLetterQueue
+	}
+	if c.opts.MaxReceiveCount > 0 {
+		maxReceiveCount = c.opts.MaxReceiveCount
+	}
+
+	// A consumer of the dead letter queue itself leaves failed messages there
+	if deadLetterQueue == c.queue {
+		return "", 0
+	}
+
+	return deadLetterQueue, maxReceiveCount
+}
+
+// deadLetter moves a message that failed too often to the dead letter queue, or drops it
+// if there is none
+func (c *Consumer[T]) deadLetter(ctx context.Context, client *Client, message types.QueueMessage[T], deadLetterQueue Queue, attempt int) {
+	if deadLetterQueue != "" {
+		if err := Typed[T](client).Requeue(ctx, c.queue, deadLetterQueue, message); err != nil {
+			log.Errorf("Failed to move message %s from %s to %s: %v", message.MessageId, c.queue, deadLetterQueue, err)
+		}
+		return
+	}
+
+	log.Warningf("Dropping message %s from %s after %d attempts", message.MessageId, c.queue, attempt)
+
+	if err := client.Delete(ctx, c.queue, message.ReceiptHandle); err != nil {
+		log.Errorf("Failed to delete message %s from %s: %v", message.MessageId, c.queue, err)
+	}
+}
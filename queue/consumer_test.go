@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/finch-technologies/go-utils/queue/types"
+)
+
+// runConsumer starts consumer and stops it once done reports true
+func runConsumer[T any](t *testing.T, consumer *Consumer[T], done func() bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+
+	go func() { stopped <- consumer.Start(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			cancel()
+			<-stopped
+			t.Fatal("consumer didn't finish in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConsumerDeletesHandledMessages(t *testing.T) {
+	driver := newFakeQueue()
+	client := NewClientWithDriver(driver)
+	Typed[order](client).EnqueueBatch(context.Background(), "orders", []order{{Id: 1}, {Id: 2}, {Id: 3}})
+
+	var handled atomic.Int32
+	var tenant atomic.Value
+
+	consumer := Typed[order](client).NewConsumer("orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+		tenant.Store(TenantId(ctx))
+		handled.Add(1)
+		return nil
+	}, ConsumerOptions{WaitTimeSeconds: 1})
+
+	runConsumer(t, consumer, func() bool { return len(driver.messages("orders")) == 0 })
+
+	if handled.Load() != 3 {
+		t.Errorf("expected 3 messages to be handled, got %d", handled.Load())
+	}
+	if tenant.Load() != "" {
+		t.Errorf("expected no tenant, got %v", tenant.Load())
+	}
+}
+
+func TestConsumerConcurrency(t *testing.T) {
+	driver := newFakeQueue()
+	client := NewClientWithDriver(driver)
+	Typed[order](client).EnqueueBatch(context.Background(), "orders", []order{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 5}, {Id: 6}})
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+
+	consumer := Typed[order](client).NewConsumer("orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}, ConsumerOptions{Concurrency: 2, WaitTimeSeconds: 1})
+
+	runConsumer(t, consumer, func() bool { return len(driver.messages("orders")) == 0 })
+
+	if peak != 2 {
+		t.Errorf("expected at most and at least 2 handlers at once, got %d", peak)
+	}
+}
+
+func TestConsumerRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  ConsumerOptions
+		expected func(t *testing.T, driver *fakeQueue)
+	}{
+		{
+			"backoff delays the retry",
+			ConsumerOptions{Backoff: func(attempt int) time.Duration { return time.Millisecond }, MaxReceiveCount: 3, DeadLetterQueue: "orders-dlq"},
+			func(t *testing.T, driver *fakeQueue) {
+				if dead := driver.messages("orders-dlq"); len(dead) != 1 || dead[0].Attributes[RetryCountAttribute] != "" {
+					t.Errorf("expected the message to be dead-lettered after 3 receives, got %+v", dead)
+				}
+			},
+		},
+		{
+			"requeue counts retries",
+			ConsumerOptions{Requeue: true, MaxReceiveCount: 3, DeadLetterQueue: "orders-dlq"},
+			func(t *testing.T, driver *fakeQueue) {
+				if dead := driver.messages("orders-dlq"); len(dead) != 1 {
+					t.Errorf("expected the message to be dead-lettered after 3 attempts, got %+v", dead)
+				}
+			},
+		},
+		{
+			"without a dead letter queue",
+			ConsumerOptions{Requeue: true, MaxReceiveCount: 2},
+			func(t *testing.T, driver *fakeQueue) {
+				if len(driver.messages("orders-dlq")) != 0 {
+					t.Error("expected the message to be dropped")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := newFakeQueue()
+			client := NewClientWithDriver(driver)
+			Typed[order](client).Enqueue(context.Background(), "orders", order{Id: 1})
+
+			var attempts atomic.Int32
+
+			tt.options.WaitTimeSeconds = 1
+			consumer := Typed[order](client).NewConsumer("orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+				attempts.Add(1)
+				return errors.New("failed")
+			}, tt.options)
+
+			runConsumer(t, consumer, func() bool { return len(driver.messages("orders")) == 0 })
+
+			if int(attempts.Load()) != tt.options.MaxReceiveCount {
+				t.Errorf("expected %d attempts, got %d", tt.options.MaxReceiveCount, attempts.Load())
+			}
+			tt.expected(t, driver)
+		})
+	}
+}
+
+func TestConsumerRecoversPanics(t *testing.T) {
+	driver := newFakeQueue()
+	client := NewClientWithDriver(driver)
+	Typed[order](client).Enqueue(context.Background(), "orders", order{Id: 1})
+
+	consumer := Typed[order](client).NewConsumer("orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+		panic("boom")
+	}, ConsumerOptions{WaitTimeSeconds: 1, MaxReceiveCount: 1, DeadLetterQueue: "orders-dlq"})
+
+	runConsumer(t, consumer, func() bool { return len(driver.messages("orders-dlq")) == 1 })
+}
+
+func TestConsumerWithoutDriver(t *testing.T) {
+	consumer := Typed[order](nil).NewConsumer("orders", func(ctx context.Context, message types.QueueMessage[order]) error {
+		return nil
+	})
+
+	if err := consumer.Start(context.Background()); err == nil {
+		t.Error("expected an error without a driver")
+	}
+}
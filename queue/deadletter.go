@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/queue/types"
+)
+
+// Message attributes recording the dead letter policy of a message, see types.EnqueueOptions
+const (
+	DeadLetterQueueAttribute = "dead_letter_queue"
+	MaxReceiveCountAttribute = "max_receive_count"
+)
+
+// RetryCountAttribute is the message attribute counting how often a consumer re-enqueued a
+// failed message
+const RetryCountAttribute = "retry_count"
+
+// stampDeadLetterPolicy records the dead letter policy of opts in its attributes, which
+// must be a map owned by the caller
+func stampDeadLetterPolicy(opts types.EnqueueOptions) error {
+	if opts.MaxReceiveCount <= 0 {
+		return nil
+	}
+
+	if opts.DeadLetterQueue == "" {
+		return fmt.Errorf("a dead letter queue is required with MaxReceiveCount")
+	}
+
+	opts.Attributes[DeadLetterQueueAttribute] = opts.DeadLetterQueue
+	opts.Attributes[MaxReceiveCountAttribute] = strconv.Itoa(opts.MaxReceiveCount)
+
+	return nil
+}
+
+// deadLetterPolicy returns the dead letter queue and maximum receive count recorded in the
+// attributes of a message, or 0 if it has none
+func deadLetterPolicy(attributes map[string]string) (Queue, int) {
+	max, _ := strconv.Atoi(attributes[MaxReceiveCountAttribute])
+	return Queue(attributes[DeadLetterQueueAttribute]), max
+}
+
+// attempts is the number of times a message was received, counting this one: its receive
+// count, or the re-enqueues recorded by a consumer plus one for drivers that don't count
+func attempts(receiveCount int, attributes map[string]string) int {
+	retries, _ := strconv.Atoi(attributes[RetryCountAttribute])
+	return max(receiveCount, retries+1)
+}
+
+// deadLetterExpired moves a received message that exceeded the maximum receive count of
+// its dead letter policy to its dead letter queue. It reports whether the message was
+// moved; a message that couldn't be moved is returned as usual.
+func (c *Client) deadLetterExpired(ctx context.Context, queue Queue, message types.DequeuedMessage, deleted bool) bool {
+	deadLetterQueue, maxReceiveCount := deadLetterPolicy(message.Attributes)
+
+	// Messages keep their policy in the dead letter queue, so they can be requeued with it
+	if maxReceiveCount <= 0 || deadLetterQueue == queue || attempts(message.ApproximateReceiveCount, message.Attributes) <= maxReceiveCount {
+		return false
+	}
+
+	err := c.driver.Enqueue(ctx, string(deadLetterQueue), message.Body, types.EnqueueOptions{Attributes: message.Attributes})
+	if err != nil {
+		log.Errorf("Failed to move message %s from %s to %s: %v", message.MessageId, queue, deadLetterQueue, err)
+		return false
+	}
+
+	log.Warningf("Moved message %s from %s to %s after %d receives", message.MessageId, queue, deadLetterQueue, maxReceiveCount)
+
+	if !deleted {
+		if err := c.driver.Delete(ctx, string(queue), message.ReceiptHandle); err != nil {
+			log.Errorf("Failed to delete message %s from %s after moving it: %v", message.MessageId, queue, err)
+		}
+	}
+
+	return true
+}
+
+// Requeue sends a received message to a queue and deletes it from the queue it was received
+// from, e.g. to redrive single messages of a dead letter queue after inspecting them, where
+// Redrive moves them all. The message keeps its attributes and dead letter policy, but its
+// receive count starts over.
+//
+// Example:
+//
+//	messages, err := queue.Dequeue[Order](ctx, OrdersDLQ, types.GenericDequeueOptions[Order]{BatchSize: 10})
+//	for _, message := range messages {
+//	    if message.Payload.Retryable() {
+//	        err = queue.Requeue(ctx, OrdersDLQ, OrdersQueue, message)
+//	    }
+//	}
+func Requeue[T interface{}](ctx context.Context, from, to Queue, message types.QueueMessage[T], options ...types.EnqueueOptions) error {
+	return Typed[T](defaultClient).Requeue(ctx, from, to, message, options...)
+}
+
+// Requeue sends a received message to a queue and deletes it from the queue it was received
+// from, see the package function
+func (t TypedClient[T]) Requeue(ctx context.Context, from, to Queue, message types.QueueMessage[T], options ...types.EnqueueOptions) error {
+	opts := types.EnqueueOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	attributes := make(map[string]string, len(message.Attributes)+len(opts.Attributes))
+
+	for key, value := range message.Attributes {
+		if key != RetryCountAttribute {
+			attributes[key] = value
+		}
+	}
+
+	for key, value := range opts.Attributes {
+		attributes[key] = value
+	}
+
+	opts.Attributes = attributes
+
+	if err := t.Enqueue(ctx, to, message.Payload, opts); err != nil {
+		return fmt.Errorf("failed to requeue message %s to %s: %w", message.MessageId, to, err)
+	}
+
+	if err := t.client.Delete(ctx, from, message.ReceiptHandle); err != nil {
+		return fmt.Errorf("failed to delete message %s from %s after requeueing it: %w", message.MessageId, from, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finch-technologies/go-utils/queue/types"
+)
+
+func TestAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		receiveCount int
+		attributes   map[string]string
+		expected     int
+	}{
+		{"first receive", 1, nil, 1},
+		{"driver counts receives", 3, nil, 3},
+		{"consumer counts retries", 1, map[string]string{RetryCountAttribute: "2"}, 3},
+		{"higher count wins", 4, map[string]string{RetryCountAttribute: "1"}, 4},
+		{"bad retry count", 1, map[string]string{RetryCountAttribute: "x"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attempts(tt.receiveCount, tt.attributes); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEnqueueDeadLetterPolicy(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := context.Background()
+
+	if err := orders.Enqueue(ctx, "orders", order{Id: 1}, types.EnqueueOptions{MaxReceiveCount: 2}); err == nil {
+		t.Error("expected an error without a dead letter queue")
+	}
+
+	if err := orders.Enqueue(ctx, "orders", order{Id: 1}, types.EnqueueOptions{MaxReceiveCount: 2, DeadLetterQueue: "orders-dlq"}); err != nil {
+		t.Fatal(err)
+	}
+
+	queue, max := deadLetterPolicy(driver.messages("orders")[0].Attributes)
+	if queue != "orders-dlq" || max != 2 {
+		t.Errorf("expected the policy in the attributes, got %s %d", queue, max)
+	}
+}
+
+func TestDequeueMovesExpiredMessages(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := context.Background()
+
+	orders.Enqueue(ctx, "orders", order{Id: 1}, types.EnqueueOptions{MaxReceiveCount: 2, DeadLetterQueue: "orders-dlq"})
+
+	for receive := 1; receive <= 2; receive++ {
+		messages, _ := orders.Dequeue(ctx, "orders", types.GenericDequeueOptions[order]{})
+		if len(messages) != 1 {
+			t.Fatalf("expected receive %d to return the message, got %+v", receive, messages)
+		}
+		driver.ChangeVisibility(ctx, "orders", messages[0].ReceiptHandle, 0)
+	}
+
+	messages, _ := orders.Dequeue(ctx, "orders", types.GenericDequeueOptions[order]{})
+	if len(messages) != 0 {
+		t.Errorf("expected the third receive to be dead-lettered, got %+v", messages)
+	}
+	if len(driver.messages("orders")) != 0 || len(driver.messages("orders-dlq")) != 1 {
+		t.Error("expected the message to move to the dead letter queue")
+	}
+
+	// Messages keep their policy in the dead letter queue without moving again
+	dead, _ := orders.Dequeue(ctx, "orders-dlq", types.GenericDequeueOptions[order]{})
+	driver.ChangeVisibility(ctx, "orders-dlq", dead[0].ReceiptHandle, 0)
+	orders.Dequeue(ctx, "orders-dlq", types.GenericDequeueOptions[order]{})
+	driver.ChangeVisibility(ctx, "orders-dlq", dead[0].ReceiptHandle, 0)
+
+	if again, _ := orders.Dequeue(ctx, "orders-dlq", types.GenericDequeueOptions[order]{}); len(again) != 1 {
+		t.Errorf("expected the dead letter queue to keep returning the message, got %+v", again)
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	driver := newFakeQueue()
+	orders := Typed[order](NewClientWithDriver(driver))
+	ctx := context.Background()
+
+	orders.Enqueue(ctx, "orders-dlq", order{Id: 1}, types.EnqueueOptions{
+		Attributes: map[string]string{RetryCountAttribute: "4", TenantIdAttribute: "t1"},
+	})
+
+	messages, _ := orders.Dequeue(ctx, "orders-dlq", types.GenericDequeueOptions[order]{})
+
+	if err := orders.Requeue(ctx, "orders-dlq", "orders", messages[0], types.EnqueueOptions{Attributes: map[string]string{"reason": "fixed"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if left := driver.messages("orders-dlq"); len(left) != 0 {
+		t.Errorf("expected the message to be deleted from the dead letter queue, got %+v", left)
+	}
+
+	requeued := driver.messages("orders")
+	if len(requeued) != 1 {
+		t.Fatalf("expected the message to be requeued, got %+v", requeued)
+	}
+
+	attributes := requeued[0].Attributes
+	if attributes[TenantIdAttribute] != "t1" || attributes["reason"] != "fixed" || attributes[RetryCountAttribute] != "" {
+		t.Errorf("expected the attributes without the retry count, got %v", attributes)
+	}
+}
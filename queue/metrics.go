@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/metrics"
+	"github.com/finch-technologies/go-utils/queue/types"
+)
+
+// Metrics reported by handlers wrapped with Instrument
+const (
+	MetricMessagesTotal   = "queue_messages_total"           // Counter by queue and status
+	MetricMessageDuration = "queue_message_duration_seconds" // Histogram by queue and status
+	MetricReceiveCount    = "queue_message_receive_count"    // Gauge of the last message's receive count by queue
+)
+
+// MessageHandler handles a single dequeued message
+type MessageHandler[T any] func(ctx context.Context, message types.QueueMessage[T]) error
+
+// Instrument wraps handler so every message it handles is reported to collector: a
+// success/failure counter, a duration histogram and a gauge of the message's receive count,
+// which climbs when messages keep failing. All consumers wrapped with it report the same
+// metric names, labelled by queue.
+//
+// Example:
+//
+//	handler := queue.Instrument(collector, OrdersQueue, handleOrder)
+//	progress, err := queue.Drain(ctx, OrdersQueue, handler)
+func Instrument[T any](collector metrics.Collector, queue Queue, handler MessageHandler[T]) MessageHandler[T] {
+	err := collector.RegisterCustomMetrics(
+		metrics.CustomMetric{Name: MetricMessagesTotal, Description: "Queue messages handled", Type: metrics.Counter, Labels: []string{"queue", "status"}},
+		metrics.CustomMetric{Name: MetricMessageDuration, Description: "Time taken to handle a queue message", Type: metrics.Histogram, Labels: []string{"queue", "status"}},
+		metrics.CustomMetric{Name: MetricReceiveCount, Description: "Receive count of the last queue message handled", Type: metrics.Gauge, Labels: []string{"queue"}},
+	)
+	if err != nil {
+		log.Warningf("Failed to register queue metrics for %s: %v", queue, err)
+	}
+
+	return func(ctx context.Context, message types.QueueMessage[T]) error {
+		start := time.Now()
+
+		err := handler(ctx, message)
+
+		status := "success"
+		if err != nil {
+			status = "failure"
+		}
+
+		labels := map[string]string{"queue": string(queue), "status": status}
+
+		collector.IncrementCounter(ctx, MetricMessagesTotal, labels, 1)
+		collector.ObserveHistogram(ctx, MetricMessageDuration, labels, time.Since(start).Seconds())
+		collector.SetGauge(ctx, MetricReceiveCount, map[string]string{"queue": string(queue)}, float64(message.ApproximateReceiveCount))
+
+		return err
+	}
+}
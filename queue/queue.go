@@ -2,23 +2,34 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
+	"time"
 
-	"github.com/finch-technologies/go-utils/queue/redis"
-	"github.com/finch-technologies/go-utils/queue/sqs"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/naming"
 	"github.com/finch-technologies/go-utils/queue/types"
 	"github.com/finch-technologies/go-utils/utils"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 type Queue string
 
+// Named returns the environment-specific queue for a logical name, see naming.Queue
+//
+// Example:
+//
+//	var OrdersQueue = queue.Named("orders") // "shrike-staging-orders"
+func Named(name string) Queue {
+	return Queue(naming.Queue(name))
+}
+
 type IMessageQueue interface {
 	Count(ctx context.Context, queue string) (int, error)
 	Enqueue(ctx context.Context, queue string, payload string, options ...types.EnqueueOptions) error
+	EnqueueBatch(ctx context.Context, queue string, payloads []string, options ...types.EnqueueOptions) error
 	Dequeue(ctx context.Context, queue string, options ...types.DequeueOptions) ([]types.DequeuedMessage, error)
 	Delete(ctx context.Context, queue string, message string) error
+	Purge(ctx context.Context, queue string) error
 }
 
 type QueueDriver string
@@ -28,137 +39,199 @@ const (
 	QueueDriverSQS   QueueDriver = "sqs"
 )
 
+// QueueDrivers are the supported queue drivers
+var QueueDrivers = utils.NewEnum(QueueDriverRedis, QueueDriverSQS)
+
+func (d *QueueDriver) UnmarshalJSON(data []byte) error {
+	return QueueDrivers.DecodeJSON(data, d)
+}
+
+func (d *QueueDriver) UnmarshalText(text []byte) error {
+	return QueueDrivers.DecodeText(text, d)
+}
+
 type QueueConfig struct {
-	Driver  QueueDriver
-	RedisDb *int
-	Region  string
-	BaseUrl string
+	Driver      QueueDriver
+	RedisDb     *int
+	RedisClient *goredis.Client // Use an existing redis client instead of the shared client for RedisDb
+	Region      string
+	BaseUrl     string
+
+	// Compression compresses message bodies larger than CompressionThreshold bytes (default
+	// 64KB). Consumers decompress automatically, so upgrade them before enabling it.
+	Compression          Compression
+	CompressionThreshold int
 }
 
-var mq IMessageQueue
-var err error
+var defaultClient *Client
 
+// Init sets up the client used by the package functions
 func Init(config ...QueueConfig) error {
 
-	redisDb := 4
-
 	if len(config) == 0 {
 		return fmt.Errorf("no queue config provided")
 	}
 
-	if config[0].RedisDb != nil {
-		redisDb = *config[0].RedisDb
-	}
-
-	if config[0].Region == "" {
-		config[0].Region = utils.StringOrDefault(os.Getenv("AWS_REGION"), "af-south-1")
+	client, err := NewClient(config[0])
+	if err != nil {
+		return err
 	}
 
-	switch config[0].Driver {
-	case QueueDriverRedis:
-		mq = redis.New(redisDb) //queue db
-	case QueueDriverSQS:
-		if config[0].BaseUrl == "" {
-			return fmt.Errorf("sqs base url is required")
-		}
-		mq, err = sqs.New(sqs.SQSConfig{
-			Region:     config[0].Region,
-			SQSBaseUrl: config[0].BaseUrl,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create sqs queue: %s", err)
-		}
-	default:
-		return fmt.Errorf("no valid queue driver specified")
-	}
+	defaultClient = client
 
 	return nil
 }
 
-func Count(ctx context.Context, queue Queue) (int, error) {
-
-	if mq == nil {
-		return 0, fmt.Errorf("no queue driver found")
-	}
+// Default returns the client set up by Init, or nil before Init
+func Default() *Client {
+	return defaultClient
+}
 
-	return mq.Count(ctx, string(queue))
+func Count(ctx context.Context, queue Queue) (int, error) {
+	return defaultClient.Count(ctx, queue)
 }
 
 func Enqueue[T interface{}](ctx context.Context, queue Queue, payload T, options ...types.EnqueueOptions) error {
+	return Typed[T](defaultClient).Enqueue(ctx, queue, payload, options...)
+}
 
-	if mq == nil {
-		return fmt.Errorf("no queue driver found")
-	}
+// EnqueueBatch sends several messages with the same options through the driver's batch API.
+// With the sqs driver, messages that weren't enqueued are reported by their index in
+// payloads in a *types.BatchError.
+//
+// Example:
+//
+//	err := queue.EnqueueBatch(ctx, OrdersQueue, orders)
+//	var batchErr *types.BatchError
+//	if errors.As(err, &batchErr) {
+//	    for _, i := range batchErr.FailedIndexes() {
+//	        log.Errorf("Order %s wasn't enqueued", orders[i].Id)
+//	    }
+//	}
+func EnqueueBatch[T interface{}](ctx context.Context, queue Queue, payloads []T, options ...types.EnqueueOptions) error {
+	return Typed[T](defaultClient).EnqueueBatch(ctx, queue, payloads, options...)
+}
 
-	jsonBytes, err := json.Marshal(payload)
+// NewEnqueueBatcher returns a batcher that collects messages and enqueues them in batches,
+// 10 per batch by default to match the SQS batch limit
+//
+// Example:
+//
+//	batcher := queue.NewEnqueueBatcher[Job]("jobs")
+//	defer batcher.Close(ctx)
+//	err := batcher.Add(ctx, job)
+func NewEnqueueBatcher[T interface{}](queue Queue, options ...utils.BatcherOptions[T]) *utils.Batcher[T] {
+	return Typed[T](defaultClient).NewEnqueueBatcher(queue, options...)
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload to json: %s", err)
-	}
+// Dequeue receives messages and decodes their JSON payloads into T, or with ParseFunc if
+// set. Without options it waits up to 20 seconds for a single message and deletes it once
+// received. Messages that can't be decoded don't fail the others: they are reported in a
+// *types.DequeueError next to the messages that decoded.
+//
+// Example:
+//
+//	messages, err := queue.Dequeue[Order](ctx, OrdersQueue, types.GenericDequeueOptions[Order]{BatchSize: 10})
+//	var dequeueErr *types.DequeueError
+//	if errors.As(err, &dequeueErr) {
+//	    for _, failed := range dequeueErr.Failed {
+//	        log.Errorf("Skipping malformed order %s: %v", failed.MessageId, failed.Err)
+//	    }
+//	} else if err != nil {
+//	    return err
+//	}
+func Dequeue[T interface{}](ctx context.Context, queue Queue, options ...types.GenericDequeueOptions[T]) ([]types.QueueMessage[T], error) {
+	return Typed[T](defaultClient).Dequeue(ctx, queue, options...)
+}
 
-	return mq.Enqueue(ctx, string(queue), string(jsonBytes))
+// EnqueueEvent sends an event registered with the events package, wrapped in an envelope
+// naming its type and version
+func EnqueueEvent(ctx context.Context, queue Queue, event any, options ...types.EnqueueOptions) error {
+	return defaultClient.EnqueueEvent(ctx, queue, event, options...)
 }
 
-func Dequeue[T interface{}](ctx context.Context, queue Queue, options ...types.GenericDequeueOptions[T]) ([]types.QueueMessage[T], error) {
+// DequeueEvents dequeues events registered with the events package. Payloads are values of
+// their registered types, so a queue can carry several event types.
+//
+// Example:
+//
+//	messages, err := queue.DequeueEvents(ctx, "orders")
+//	for _, message := range messages {
+//	    switch event := message.Payload.(type) {
+//	    case OrderCreated:
+//	        handleOrder(event)
+//	    }
+//	}
+func DequeueEvents(ctx context.Context, queue Queue, options ...types.DequeueOptions) ([]types.QueueMessage[any], error) {
+	return defaultClient.DequeueEvents(ctx, queue, options...)
+}
 
-	var messages []types.QueueMessage[T]
+func Delete(ctx context.Context, queue Queue, id string) error {
+	return defaultClient.Delete(ctx, queue, id)
+}
 
-	if mq == nil {
-		return messages, fmt.Errorf("no queue driver found")
-	}
+// IVisibilityChanger is implemented by queue drivers that hide received messages until they
+// are deleted
+type IVisibilityChanger interface {
+	ChangeVisibility(ctx context.Context, queue string, receiptHandle string, d time.Duration) error
+}
 
-	dequeueOptions := types.DequeueOptions{
-		WaitTimeSeconds: 20,
-		BatchSize:       1,
-		DeleteMessage:   true,
-	}
+// ChangeVisibility hides a received message for d from now, so a consumer that needs longer
+// than the visibility timeout can extend its lease instead of having the message redelivered
+// mid-processing. A d of 0 makes the message visible again right away. Messages dequeued
+// with DeleteMessage are already gone, so their visibility can't be changed.
+//
+// Example:
+//
+//	messages, err := queue.Dequeue[Export](ctx, ExportsQueue, types.GenericDequeueOptions[Export]{
+//	    BatchSize:         1,
+//	    VisibilityTimeout: time.Minute,
+//	})
+//	// ... every 30s while the export runs
+//	err = queue.ChangeVisibility(ctx, ExportsQueue, messages[0].ReceiptHandle, time.Minute)
+func ChangeVisibility(ctx context.Context, queue Queue, receiptHandle string, d time.Duration) error {
+	return defaultClient.ChangeVisibility(ctx, queue, receiptHandle, d)
+}
 
-	if len(options) > 0 {
-		dequeueOptions.WaitTimeSeconds = options[0].WaitTimeSeconds
-		dequeueOptions.BatchSize = options[0].BatchSize
-		dequeueOptions.DeleteMessage = options[0].DeleteMessage
-	}
+// IRedriver is implemented by queue drivers that can move messages between queues
+type IRedriver interface {
+	Redrive(ctx context.Context, fromQueue, toQueue string, max int, options ...types.RedriveOptions) (int, error)
+}
 
-	dequeuedMessages, err := mq.Dequeue(ctx, string(queue), dequeueOptions)
+// Redrive moves up to max messages (all if max <= 0) from one queue to another, e.g. from a
+// dead letter queue back to the main queue. Returns the number of messages moved.
+func Redrive(ctx context.Context, fromQueue, toQueue Queue, max int, options ...types.RedriveOptions) (int, error) {
+	return defaultClient.Redrive(ctx, fromQueue, toQueue, max, options...)
+}
 
-	if err != nil {
-		return messages, fmt.Errorf("failed to dequeue item from queue: %s", err)
-	}
+// Purge deletes every message in a queue
+func Purge(ctx context.Context, queue Queue) error {
+	return defaultClient.Purge(ctx, queue)
+}
 
-	if len(dequeuedMessages) == 0 {
-		return messages, nil
-	}
+func getDrainOptions(options []types.DrainOptions) types.DrainOptions {
+	opts := types.DrainOptions{}
 
-	for _, dequeuedMessage := range dequeuedMessages {
-		var payload T
+	if len(options) > 0 {
+		opts = options[0]
+	}
 
-		if options[0].ParseFunc != nil {
-			payload, err = options[0].ParseFunc(dequeuedMessage.Body)
-		} else {
-			err = json.Unmarshal([]byte(dequeuedMessage.Body), &payload)
-		}
+	opts.BatchSize = utils.IntOrDefault(opts.BatchSize, 10)
+	opts.WaitTimeSeconds = utils.IntOrDefault(opts.WaitTimeSeconds, 2)
+	opts.ProgressInterval = utils.DurationOrDefault(opts.ProgressInterval, 5*time.Second)
 
-		if err != nil {
-			return messages, fmt.Errorf("failed to unmarshal payload from json: %s", err)
+	if opts.OnProgress == nil {
+		opts.OnProgress = func(progress types.DrainProgress) {
+			log.Infof("Draining queue: processed=%d failed=%d remaining=%d elapsed=%s", progress.Processed, progress.Failed, progress.Remaining, progress.Elapsed)
 		}
-
-		messages = append(messages, types.QueueMessage[T]{
-			MessageId:               dequeuedMessage.MessageId,
-			ReceiptHandle:           dequeuedMessage.ReceiptHandle,
-			Payload:                 payload,
-			ReceivedAt:              dequeuedMessage.ReceivedAt,
-			ApproximateReceiveCount: dequeuedMessage.ApproximateReceiveCount,
-		})
 	}
 
-	return messages, nil
+	return opts
 }
 
-func Delete(ctx context.Context, queue Queue, id string) error {
-
-	if mq == nil {
-		return fmt.Errorf("no queue driver found")
-	}
-
-	return mq.Delete(ctx, string(queue), id)
+// Drain consumes messages with handler until the queue is empty, reporting progress
+// periodically and once more at the end. Messages are deleted only after the handler
+// succeeds; failed messages are counted and left for redelivery.
+func Drain[T interface{}](ctx context.Context, queue Queue, handler func(ctx context.Context, message types.QueueMessage[T]) error, options ...types.DrainOptions) (types.DrainProgress, error) {
+	return Typed[T](defaultClient).Drain(ctx, queue, handler, options...)
 }
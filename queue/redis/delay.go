@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxPromoted is the most due messages moved to a queue per Dequeue
+const maxPromoted = 100
+
+// delayedKey is the sorted set holding the delayed messages of a queue, scored by the unix
+// milliseconds they are due at
+func delayedKey(queue string) string {
+	return queue + ":delayed"
+}
+
+// promoteScript moves the due members of a delayed set to the queue, oldest first. Members
+// are a UUID and a colon before the list item, so equal items can be delayed together.
+var promoteScript = redis.NewScript(`
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(items) do
+	redis.call('ZREM', KEYS[1], member)
+	redis.call('LPUSH', KEYS[2], string.sub(member, 38))
+end
+return #items
+`)
+
+// delay adds list items to the delayed set of queue, due after d
+func (msgQueue *RedisMessageQueue) delay(ctx context.Context, queue string, d time.Duration, items ...string) error {
+	due := float64(time.Now().Add(d).UnixMilli())
+
+	members := make([]redis.Z, len(items))
+	for i, item := range items {
+		members[i] = redis.Z{Score: due, Member: uuid.New().String() + ":" + item}
+	}
+
+	if err := msgQueue.rdb.ZAdd(ctx, delayedKey(queue), members...).Err(); err != nil {
+		return fmt.Errorf("failed to delay message: %w", err)
+	}
+	return nil
+}
+
+// promoteDue moves the delayed messages of queue that are due onto the queue
+func (msgQueue *RedisMessageQueue) promoteDue(ctx context.Context, queue string) error {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	err := promoteScript.Run(ctx, msgQueue.rdb, []string{delayedKey(queue), queue}, now, maxPromoted).Err()
+	if err != nil {
+		return fmt.Errorf("failed to move delayed messages to the queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/google/uuid"
+)
+
+// List items are JSON envelopes carrying the ID and attributes of a message next to its
+// body, as redis lists have nowhere else to keep them. The ID also keeps equal messages
+// apart while they are in flight. Version 1 is
+//
+//	{"$v":1,"$attributes":{"tenant_id":"t1"},"id":"<uuid>","body":"<payload>"}
+//
+// Items are read as
+//   - envelopes when they start with {"$v":
+//   - version 0 envelopes, without an ID, when they start with {"$attributes":
+//   - plain bodies otherwise, as pushed by producers from before envelopes
+//
+// Consumers from before envelopes read envelopes as their bodies, so upgrade consumers
+// before producers. Envelopes of a newer version than envelopeVersion keep the fields they
+// share with it; a format that can't be read that way needs a new prefix.
+
+// envelopeVersion is the version of the envelopes this driver pushes
+const envelopeVersion = 1
+
+// envelopePrefixes start the items that are envelopes
+var envelopePrefixes = []string{`{"$v":`, `{"$attributes":`}
+
+type envelope struct {
+	Version    int               `json:"$v,omitempty"`
+	Attributes map[string]string `json:"$attributes"`
+	Id         string            `json:"id"`
+	Body       string            `json:"body"`
+}
+
+// encodeItem returns the list item of a message body
+func encodeItem(body string, options ...types.EnqueueOptions) (string, error) {
+	message := envelope{Version: envelopeVersion, Id: uuid.New().String(), Body: body}
+
+	if len(options) > 0 {
+		message.Attributes = options[0].Attributes
+	}
+
+	item, err := json.Marshal(message)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	return string(item), nil
+}
+
+// decodeItem returns the message of a list item. Items that aren't envelopes are bodies
+// without an ID or attributes.
+func decodeItem(item string) envelope {
+	if !isEnvelope(item) {
+		return envelope{Body: item}
+	}
+
+	var message envelope
+	if err := json.Unmarshal([]byte(item), &message); err != nil {
+		return envelope{Body: item}
+	}
+
+	return message
+}
+
+// isEnvelope reports whether a list item starts like an envelope
+func isEnvelope(item string) bool {
+	for _, prefix := range envelopePrefixes {
+		if strings.HasPrefix(item, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/finch-technologies/go-utils/queue/types"
+)
+
+func TestEncodeItem(t *testing.T) {
+	item, err := encodeItem(`{"id":1}`, types.EnqueueOptions{Attributes: map[string]string{"tenant_id": "t1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(item, `{"$v":1,"$attributes":{"tenant_id":"t1"},"id":"`) {
+		t.Errorf("unexpected item %s", item)
+	}
+
+	message := decodeItem(item)
+	if message.Version != envelopeVersion || message.Id == "" || message.Body != `{"id":1}` || message.Attributes["tenant_id"] != "t1" {
+		t.Errorf("expected the item to decode to its message, got %+v", message)
+	}
+
+	other, _ := encodeItem(`{"id":1}`)
+	if decodeItem(other).Id == message.Id {
+		t.Error("expected equal messages to get different IDs")
+	}
+}
+
+func TestDecodeItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     string
+		expected envelope
+	}{
+		{
+			"envelope",
+			`{"$v":1,"$attributes":{"a":"1"},"id":"m1","body":"hello"}`,
+			envelope{Version: 1, Attributes: map[string]string{"a": "1"}, Id: "m1", Body: "hello"},
+		},
+		{
+			"envelope without attributes",
+			`{"$v":1,"$attributes":null,"id":"m1","body":"hello"}`,
+			envelope{Version: 1, Id: "m1", Body: "hello"},
+		},
+		{
+			"newer envelope",
+			`{"$v":2,"$attributes":null,"id":"m1","body":"hello","priority":3}`,
+			envelope{Version: 2, Id: "m1", Body: "hello"},
+		},
+		{
+			"version 0 envelope",
+			`{"$attributes":{"a":"1"},"body":"hello"}`,
+			envelope{Attributes: map[string]string{"a": "1"}, Body: "hello"},
+		},
+		{
+			"legacy json body",
+			`{"orderId":7}`,
+			envelope{Body: `{"orderId":7}`},
+		},
+		{
+			"legacy text body",
+			`hello`,
+			envelope{Body: `hello`},
+		},
+		{
+			"broken envelope",
+			`{"$v":1,"id":`,
+			envelope{Body: `{"$v":1,"id":`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeItem(tt.item); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -3,54 +3,125 @@ package redis
 import (
 	"context"
 	"fmt"
-	"time"
 
 	database "github.com/finch-technologies/go-utils/database/redis"
 	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
 	"github.com/google/uuid"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisMessageQueue struct {
-	rdb *redis.Client
+	rdb        *redis.Client
+	consumerId string // Names the processing list of the messages this instance has in flight
 }
 
 func New(db int) *RedisMessageQueue {
+	return NewWithClient(database.GetRedisClient(db))
+}
+
+// NewWithClient creates a queue backed by an existing redis client
+func NewWithClient(client *redis.Client) *RedisMessageQueue {
 	return &RedisMessageQueue{
-		rdb: database.GetRedisClient(db),
+		rdb:        client,
+		consumerId: uuid.New().String(),
 	}
 }
 
+// Count returns the number of messages waiting in the queue. As with SQS's
+// ApproximateNumberOfMessages, delayed messages and those leased to a consumer aren't
+// counted, so a queue with messages in flight can count 0 and still have messages return.
 func (msgQueue *RedisMessageQueue) Count(ctx context.Context, queue string) (int, error) {
-	count := msgQueue.rdb.LLen(ctx, queue).Val()
+	count, err := msgQueue.rdb.LLen(ctx, queue).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count the queue: %w", err)
+	}
 	return int(count), nil
 }
 
+// Enqueue pushes a message onto the queue. Messages with a Delay wait in a sorted set until
+// a Dequeue finds them due.
 func (msgQueue *RedisMessageQueue) Enqueue(ctx context.Context, queue string, payload string, options ...types.EnqueueOptions) error {
-	err := msgQueue.rdb.LPush(ctx, queue, payload).Err()
+	item, err := encodeItem(payload, options...)
+	if err != nil {
+		return err
+	}
+
+	if len(options) > 0 && options[0].Delay > 0 {
+		return msgQueue.delay(ctx, queue, options[0].Delay, item)
+	}
+
+	err = msgQueue.rdb.LPush(ctx, queue, item).Err()
+	if err != nil {
+		return fmt.Errorf("failed to push to the queue: %s", err)
+	}
+	return nil
+}
+
+// EnqueueBatch pushes several messages with a single LPUSH, keeping their order
+func (msgQueue *RedisMessageQueue) EnqueueBatch(ctx context.Context, queue string, payloads []string, options ...types.EnqueueOptions) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	items := make([]string, len(payloads))
+	for i, payload := range payloads {
+		item, err := encodeItem(payload, options...)
+		if err != nil {
+			return err
+		}
+		items[i] = item
+	}
+
+	if len(options) > 0 && options[0].Delay > 0 {
+		return msgQueue.delay(ctx, queue, options[0].Delay, items...)
+	}
+
+	values := make([]any, len(items))
+	for i, item := range items {
+		values[i] = item
+	}
+
+	err := msgQueue.rdb.LPush(ctx, queue, values...).Err()
 	if err != nil {
 		return fmt.Errorf("failed to push to the queue: %s", err)
 	}
 	return nil
 }
 
+// Dequeue pops messages off the queue. With DeleteMessage they are removed right away.
+// Otherwise they move to the processing list of this consumer, leased for the visibility
+// timeout (default 30s), and return to the queue unless they are deleted before it expires.
 func (msgQueue *RedisMessageQueue) Dequeue(ctx context.Context, queue string, options ...types.DequeueOptions) ([]types.DequeuedMessage, error) {
+	opts := types.DequeueOptions{BatchSize: 1, DeleteMessage: true}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	batchSize := utils.IntOrDefault(opts.BatchSize, 1)
+
+	if err := msgQueue.promoteDue(ctx, queue); err != nil {
+		return nil, err
+	}
+
+	if _, err := msgQueue.Reap(ctx, queue); err != nil {
+		return nil, err
+	}
+
+	if !opts.DeleteMessage {
+		return msgQueue.lease(ctx, queue, batchSize, utils.DurationOrDefault(opts.VisibilityTimeout, defaultVisibilityTimeout))
+	}
+
 	// TODO: Implement batch dequeue
 	items := []types.DequeuedMessage{}
 
-	for i := 0; i < options[0].BatchSize; i++ {
+	for i := 0; i < batchSize; i++ {
 		itemStr, err := msgQueue.rdb.RPop(ctx, queue).Result()
 
-		item := types.DequeuedMessage{
-			MessageId:     uuid.New().String(),
-			ReceiptHandle: uuid.New().String(),
-			Body:          itemStr,
-			ReceivedAt:    time.Now(),
-		}
-
 		if err == nil {
-			items = append(items, item)
+			items = append(items, dequeuedMessage(itemStr, uuid.New().String(), 1))
 		} else if err == redis.Nil {
 			break
 		} else {
@@ -61,7 +132,48 @@ func (msgQueue *RedisMessageQueue) Dequeue(ctx context.Context, queue string, op
 	return items, nil
 }
 
-func (msgQueue *RedisMessageQueue) Delete(ctx context.Context, queue string, id string) error {
-	// Redis does not support deleting a specific message from a queue since dequeue always removes the last item
+// Delete acknowledges a leased message, removing it from the processing list. Messages that
+// were deleted when they were dequeued are already gone, and a message whose lease expired
+// is left to its next receive.
+func (msgQueue *RedisMessageQueue) Delete(ctx context.Context, queue string, receiptHandle string) error {
+	consumerId, item, ok := parseReceiptHandle(receiptHandle)
+	if !ok {
+		return nil
+	}
+
+	keys := []string{processingKey(queue, consumerId), leasesKey(queue)}
+
+	deleted, err := ackScript.Run(ctx, msgQueue.rdb, keys, receiptHandle, item).Int()
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	if id := decodeItem(item).Id; id != "" && deleted > 0 {
+		if err := msgQueue.rdb.HDel(ctx, receivesKey(queue), id).Err(); err != nil {
+			return fmt.Errorf("failed to delete message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Purge deletes every message of a queue, including delayed messages and those in flight
+func (msgQueue *RedisMessageQueue) Purge(ctx context.Context, queue string) error {
+	leases, err := msgQueue.rdb.ZRange(ctx, leasesKey(queue), 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to purge the queue: %s", err)
+	}
+
+	keys := []string{queue, delayedKey(queue), leasesKey(queue), receivesKey(queue)}
+	for _, lease := range leases {
+		if consumerId, _, ok := parseReceiptHandle(lease); ok {
+			keys = append(keys, processingKey(queue, consumerId))
+		}
+	}
+
+	err = msgQueue.rdb.Del(ctx, keys...).Err()
+	if err != nil {
+		return fmt.Errorf("failed to purge the queue: %s", err)
+	}
 	return nil
 }
@@ -0,0 +1,282 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/redis/go-redis/v9"
+)
+
+const testQueue = "orders"
+
+func newTestQueue(t *testing.T) (*RedisMessageQueue, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewWithClient(client), server
+}
+
+func bodies(messages []types.DequeuedMessage) []string {
+	result := make([]string, len(messages))
+	for i, message := range messages {
+		result[i] = message.Body
+	}
+	return result
+}
+
+func expectBodies(t *testing.T, messages []types.DequeuedMessage, expected ...string) {
+	t.Helper()
+
+	got := bodies(messages)
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	q.Enqueue(ctx, testQueue, "a", types.EnqueueOptions{Attributes: map[string]string{"tenant_id": "t1"}})
+	q.EnqueueBatch(ctx, testQueue, []string{"b", "c"})
+
+	if count, err := q.Count(ctx, testQueue); err != nil || count != 3 {
+		t.Fatalf("expected 3 messages, got %d %v", count, err)
+	}
+
+	messages, err := q.Dequeue(ctx, testQueue, types.DequeueOptions{BatchSize: 10, DeleteMessage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, messages, "a", "b", "c")
+
+	if messages[0].Attributes["tenant_id"] != "t1" || messages[0].ApproximateReceiveCount != 1 {
+		t.Errorf("unexpected message %+v", messages[0])
+	}
+	if count, _ := q.Count(ctx, testQueue); count != 0 {
+		t.Errorf("expected the messages to be deleted, got %d left", count)
+	}
+}
+
+func TestDequeueWithoutOptions(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	q.EnqueueBatch(ctx, testQueue, []string{"a", "b"})
+
+	messages, err := q.Dequeue(ctx, testQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, messages, "a")
+}
+
+func TestDequeueLegacyItems(t *testing.T) {
+	q, server := newTestQueue(t)
+
+	server.Lpush(testQueue, `{"orderId":7}`)
+
+	messages, err := q.Dequeue(context.Background(), testQueue, types.DequeueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, messages, `{"orderId":7}`)
+
+	if messages[0].MessageId == "" {
+		t.Error("expected legacy items to get a message ID")
+	}
+	if err := q.Delete(context.Background(), testQueue, messages[0].ReceiptHandle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaseAndDelete(t *testing.T) {
+	q, server := newTestQueue(t)
+	ctx := context.Background()
+
+	q.EnqueueBatch(ctx, testQueue, []string{"a", "b"})
+
+	messages, err := q.Dequeue(ctx, testQueue, types.DequeueOptions{BatchSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, messages, "a", "b")
+
+	processing := processingKey(testQueue, q.consumerId)
+	if items, _ := server.List(processing); len(items) != 2 {
+		t.Fatalf("expected the messages to be processing, got %v", items)
+	}
+	if count, _ := q.Count(ctx, testQueue); count != 0 {
+		t.Errorf("expected leased messages not to be counted, got %d", count)
+	}
+
+	if err := q.Delete(ctx, testQueue, messages[0].ReceiptHandle); err != nil {
+		t.Fatal(err)
+	}
+
+	if items, _ := server.List(processing); len(items) != 1 {
+		t.Errorf("expected one message left processing, got %v", items)
+	}
+	if leases, _ := server.ZMembers(leasesKey(testQueue)); len(leases) != 1 || leases[0] != messages[1].ReceiptHandle {
+		t.Errorf("expected the lease of the other message to remain, got %v", leases)
+	}
+	if server.HGet(receivesKey(testQueue), messages[0].MessageId) != "" {
+		t.Error("expected the receive count of the deleted message to be removed")
+	}
+}
+
+func TestReapExpiredLeases(t *testing.T) {
+	q, server := newTestQueue(t)
+	ctx := context.Background()
+
+	q.EnqueueBatch(ctx, testQueue, []string{"a", "b"})
+
+	first, err := q.Dequeue(ctx, testQueue, types.DequeueOptions{VisibilityTimeout: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, first, "a")
+	time.Sleep(5 * time.Millisecond)
+
+	reaped, err := q.Reap(ctx, testQueue)
+	if err != nil || reaped != 1 {
+		t.Fatalf("expected one message to be reaped, got %d %v", reaped, err)
+	}
+
+	if items, _ := server.List(processingKey(testQueue, q.consumerId)); len(items) != 0 {
+		t.Errorf("expected the processing list to be empty, got %v", items)
+	}
+
+	// The reaped message returns to the front of the queue, keeping its ID
+	second, err := q.Dequeue(ctx, testQueue, types.DequeueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, second, "a")
+
+	if second[0].MessageId != first[0].MessageId || second[0].ApproximateReceiveCount != 2 {
+		t.Errorf("expected the second receive of %s, got %+v", first[0].MessageId, second[0])
+	}
+
+	// Deleting with the expired receipt handle leaves the new lease alone
+	q.Delete(ctx, testQueue, first[0].ReceiptHandle)
+
+	if items, _ := server.List(processingKey(testQueue, q.consumerId)); len(items) != 1 {
+		t.Errorf("expected the message to stay processing, got %v", items)
+	}
+}
+
+func TestReapOtherConsumers(t *testing.T) {
+	q, server := newTestQueue(t)
+	other := NewWithClient(q.rdb)
+	ctx := context.Background()
+
+	q.Enqueue(ctx, testQueue, "a")
+
+	if _, err := other.Dequeue(ctx, testQueue, types.DequeueOptions{VisibilityTimeout: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	messages, err := q.Dequeue(ctx, testQueue, types.DequeueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectBodies(t, messages, "a")
+
+	if items, _ := server.List(processingKey(testQueue, other.consumerId)); len(items) != 0 {
+		t.Errorf("expected the crashed consumer's message to be reaped, got %v", items)
+	}
+}
+
+func TestChangeVisibility(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	q.Enqueue(ctx, testQueue, "a")
+
+	messages, _ := q.Dequeue(ctx, testQueue, types.DequeueOptions{})
+
+	if err := q.ChangeVisibility(ctx, testQueue, messages[0].ReceiptHandle, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := q.Dequeue(ctx, testQueue, types.DequeueOptions{})
+	expectBodies(t, again, "a")
+
+	if err := q.ChangeVisibility(ctx, testQueue, messages[0].ReceiptHandle, time.Minute); err == nil {
+		t.Error("expected an error extending a lease that was reaped")
+	}
+	if err := q.ChangeVisibility(ctx, testQueue, "not-a-lease", time.Minute); err == nil {
+		t.Error("expected an error for a message without a lease")
+	}
+}
+
+func TestDelay(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	q.EnqueueBatch(ctx, testQueue, []string{"a", "a"}, types.EnqueueOptions{Delay: 20 * time.Millisecond})
+
+	messages, _ := q.Dequeue(ctx, testQueue, types.DequeueOptions{BatchSize: 10, DeleteMessage: true})
+	if len(messages) != 0 {
+		t.Fatalf("expected delayed messages to wait, got %v", bodies(messages))
+	}
+	if count, _ := q.Count(ctx, testQueue); count != 0 {
+		t.Errorf("expected delayed messages not to be counted, got %d", count)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	messages, _ = q.Dequeue(ctx, testQueue, types.DequeueOptions{BatchSize: 10, DeleteMessage: true})
+	expectBodies(t, messages, "a", "a")
+}
+
+func TestPurge(t *testing.T) {
+	q, server := newTestQueue(t)
+	ctx := context.Background()
+
+	q.EnqueueBatch(ctx, testQueue, []string{"a", "b"})
+	q.Enqueue(ctx, testQueue, "c", types.EnqueueOptions{Delay: time.Minute})
+	q.Dequeue(ctx, testQueue, types.DequeueOptions{})
+
+	if err := q.Purge(ctx, testQueue); err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("expected every key of the queue to be deleted, got %v", keys)
+	}
+}
+
+func TestRedrive(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	q.EnqueueBatch(ctx, "orders-dlq", []string{"a", "b", "c"})
+
+	moved, err := q.Redrive(ctx, "orders-dlq", testQueue, 2, types.RedriveOptions{RatePerSecond: -1})
+	if err != nil || moved != 2 {
+		t.Fatalf("expected 2 messages to be moved, got %d %v", moved, err)
+	}
+
+	messages, _ := q.Dequeue(ctx, testQueue, types.DequeueOptions{BatchSize: 10, DeleteMessage: true})
+	expectBodies(t, messages, "a", "b")
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redrive moves up to max messages (all messages if max <= 0) from one list to another,
+// e.g. from a dead letter queue back to its main queue. Each message is moved with a single
+// LMOVE, oldest first, so a failure part way through never loses or reorders messages.
+// Returns the number of messages moved.
+func (msgQueue *RedisMessageQueue) Redrive(ctx context.Context, fromQueue, toQueue string, max int, options ...types.RedriveOptions) (int, error) {
+	opts := types.RedriveOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.RatePerSecond = utils.IntOrDefault(opts.RatePerSecond, 10)
+
+	var limiter *time.Ticker
+	if opts.RatePerSecond > 0 {
+		limiter = time.NewTicker(time.Second / time.Duration(opts.RatePerSecond))
+		defer limiter.Stop()
+	}
+
+	moved := 0
+
+	for max <= 0 || moved < max {
+		if limiter != nil {
+			select {
+			case <-ctx.Done():
+				return moved, ctx.Err()
+			case <-limiter.C:
+			}
+		} else if ctx.Err() != nil {
+			return moved, ctx.Err()
+		}
+
+		// Messages are pushed on the left and popped on the right, so the oldest message of
+		// the source becomes the newest of the target
+		err := msgQueue.rdb.LMove(ctx, fromQueue, toQueue, "RIGHT", "LEFT").Err()
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return moved, fmt.Errorf("failed to move message from %s to %s: %w", fromQueue, toQueue, err)
+		}
+
+		moved++
+	}
+
+	log.Debugf("Redrive %s -> %s: moved %d messages", fromQueue, toQueue, moved)
+
+	return moved, nil
+}
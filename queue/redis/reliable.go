@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultVisibilityTimeout is how long a leased message stays with its consumer, as with SQS
+const defaultVisibilityTimeout = 30 * time.Second
+
+// maxReaped is the most expired messages returned to a queue per Dequeue
+const maxReaped = 100
+
+// A message that is dequeued without being deleted moves from the queue to the processing
+// list of its consumer, and is leased in a sorted set of the queue scored by the unix
+// milliseconds its lease expires at. Lease members are the consumer ID, a lease ID and the
+// list item, separated by colons, and double as receipt handles. The lease ID is new for
+// every receive, so the receipt handle of an expired lease can't delete the message once it
+// is received again. Deleting a message removes it from both; the reaper returns messages
+// whose lease expired to the front of the queue.
+
+// processingKey is the list holding the messages consumerId is processing
+func processingKey(queue, consumerId string) string {
+	return queue + ":processing:" + consumerId
+}
+
+// leasesKey is the sorted set holding the leases of the messages in flight
+func leasesKey(queue string) string {
+	return queue + ":leases"
+}
+
+// receivesKey is the hash counting how often each message in flight was received, by ID
+func receivesKey(queue string) string {
+	return queue + ":receives"
+}
+
+// leaseScript moves up to ARGV[3] messages to the processing list and leases them, with
+// ARGV[2] the consumer and lease IDs
+var leaseScript = redis.NewScript(`
+local items = {}
+for i = 1, tonumber(ARGV[3]) do
+	local item = redis.call('LMOVE', KEYS[1], KEYS[2], 'RIGHT', 'LEFT')
+	if not item then break end
+	redis.call('ZADD', KEYS[3], ARGV[1], ARGV[2] .. ':' .. item)
+	items[#items + 1] = item
+end
+return items
+`)
+
+// ackScript removes a leased message from the leases and its processing list, unless its
+// lease is gone because it was reaped or already deleted
+var ackScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then return 0 end
+return redis.call('LREM', KEYS[1], 1, ARGV[2])
+`)
+
+// extendScript moves the expiry of a lease that still exists
+var extendScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then return 0 end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+// reapScript returns the messages whose lease expired from their processing lists to the
+// front of the queue. The processing lists are only known from the leases, so this doesn't
+// work with redis cluster.
+var reapScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(members) do
+	local consumer = string.sub(member, 1, 36)
+	local item = string.sub(member, 75)
+	redis.call('ZREM', KEYS[2], member)
+	if redis.call('LREM', ARGV[3] .. consumer, 1, item) > 0 then
+		redis.call('RPUSH', KEYS[1], item)
+	end
+end
+return #members
+`)
+
+// parseReceiptHandle returns the consumer ID and list item of a leased message's receipt
+// handle. Messages that were deleted when they were dequeued have no lease.
+func parseReceiptHandle(receiptHandle string) (string, string, bool) {
+	if len(receiptHandle) < 74 || receiptHandle[36] != ':' || receiptHandle[73] != ':' {
+		return "", "", false
+	}
+	return receiptHandle[:36], receiptHandle[74:], true
+}
+
+// dequeuedMessage returns the message of a list item
+func dequeuedMessage(item, receiptHandle string, receiveCount int) types.DequeuedMessage {
+	message := decodeItem(item)
+
+	return types.DequeuedMessage{
+		MessageId:               utils.StringOrDefault(message.Id, uuid.New().String()),
+		ReceiptHandle:           receiptHandle,
+		Body:                    message.Body,
+		ReceivedAt:              time.Now(),
+		ApproximateReceiveCount: receiveCount,
+		Attributes:              message.Attributes,
+	}
+}
+
+// lease moves up to batchSize messages to the processing list of this consumer, leased for
+// visibilityTimeout
+func (msgQueue *RedisMessageQueue) lease(ctx context.Context, queue string, batchSize int, visibilityTimeout time.Duration) ([]types.DequeuedMessage, error) {
+	deadline := time.Now().Add(visibilityTimeout).UnixMilli()
+	keys := []string{queue, processingKey(queue, msgQueue.consumerId), leasesKey(queue)}
+	leasePrefix := msgQueue.consumerId + ":" + uuid.New().String()
+
+	items, err := leaseScript.Run(ctx, msgQueue.rdb, keys, deadline, leasePrefix, batchSize).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get item from queue: %w", err)
+	}
+
+	ids := make([]string, len(items))
+	counts := make([]*redis.IntCmd, len(items))
+
+	pipe := msgQueue.rdb.Pipeline()
+	for i, item := range items {
+		if ids[i] = decodeItem(item).Id; ids[i] != "" {
+			counts[i] = pipe.HIncrBy(ctx, receivesKey(queue), ids[i], 1)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to count message receives: %w", err)
+	}
+
+	messages := make([]types.DequeuedMessage, len(items))
+	for i, item := range items {
+		receiveCount := 1
+		if counts[i] != nil {
+			receiveCount = int(counts[i].Val())
+		}
+
+		messages[i] = dequeuedMessage(item, leasePrefix+":"+item, receiveCount)
+	}
+
+	return messages, nil
+}
+
+// Reap returns the messages of a queue whose lease expired before they were deleted to the
+// front of the queue, e.g. those of a consumer that crashed. Dequeue reaps before leasing
+// messages, so this is only needed for queues nobody dequeues from. Returns the number of
+// messages returned.
+func (msgQueue *RedisMessageQueue) Reap(ctx context.Context, queue string) (int, error) {
+	now := time.Now().UnixMilli()
+
+	reaped, err := reapScript.Run(ctx, msgQueue.rdb, []string{queue, leasesKey(queue)}, now, maxReaped, queue+":processing:").Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to return expired messages to the queue: %w", err)
+	}
+
+	return reaped, nil
+}
+
+// ChangeVisibility moves the expiry of a leased message's lease to d from now. A d of 0
+// returns the message to the queue on the next Dequeue.
+func (msgQueue *RedisMessageQueue) ChangeVisibility(ctx context.Context, queue string, receiptHandle string, d time.Duration) error {
+	if _, _, ok := parseReceiptHandle(receiptHandle); !ok {
+		return fmt.Errorf("message was deleted when it was dequeued")
+	}
+
+	deadline := strconv.FormatInt(time.Now().Add(d).UnixMilli(), 10)
+
+	extended, err := extendScript.Run(ctx, msgQueue.rdb, []string{leasesKey(queue)}, receiptHandle, deadline).Int()
+	if err != nil {
+		return fmt.Errorf("failed to change message visibility: %w", err)
+	}
+	if extended == 0 {
+		return fmt.Errorf("message is no longer in flight")
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+func getRedriveOptions(options []types.RedriveOptions) types.RedriveOptions {
+	opts := types.RedriveOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.RatePerSecond = utils.IntOrDefault(opts.RatePerSecond, 10)
+	opts.WaitTimeSeconds = utils.IntOrDefault(opts.WaitTimeSeconds, 2)
+
+	return opts
+}
+
+// Redrive moves up to max messages (all messages if max <= 0) from one queue to another,
+// e.g. from a dead letter queue back to its main queue. Message attributes and FIFO message
+// groups are preserved. A message is only deleted from the source queue after it was sent
+// to the target, so a failure part way through never loses messages. Returns the number of
+// messages moved.
+func (q *SQSMessageQueue) Redrive(ctx context.Context, fromQueue, toQueue string, max int, options ...types.RedriveOptions) (int, error) {
+	opts := getRedriveOptions(options)
+	fromUrl := q.getQueueURL(fromQueue)
+	toUrl := q.getQueueURL(toQueue)
+
+	var limiter *time.Ticker
+	if opts.RatePerSecond > 0 {
+		limiter = time.NewTicker(time.Second / time.Duration(opts.RatePerSecond))
+		defer limiter.Stop()
+	}
+
+	moved := 0
+
+	for max <= 0 || moved < max {
+		if ctx.Err() != nil {
+			return moved, ctx.Err()
+		}
+
+		batchSize := 10
+		if max > 0 && max-moved < batchSize {
+			batchSize = max - moved
+		}
+
+		resp, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(fromUrl),
+			MaxNumberOfMessages: int32(batchSize),
+			WaitTimeSeconds:     int32(opts.WaitTimeSeconds),
+			MessageAttributeNames: []string{
+				string(sqstypes.QueueAttributeNameAll),
+			},
+			AttributeNames: []sqstypes.QueueAttributeName{
+				sqstypes.QueueAttributeNameAll,
+			},
+		})
+
+		if err != nil {
+			return moved, fmt.Errorf("failed to receive message: %w", err)
+		}
+
+		if len(resp.Messages) == 0 {
+			break
+		}
+
+		for _, message := range resp.Messages {
+			if limiter != nil {
+				select {
+				case <-ctx.Done():
+					return moved, ctx.Err()
+				case <-limiter.C:
+				}
+			}
+
+			input := &sqs.SendMessageInput{
+				QueueUrl:          aws.String(toUrl),
+				MessageBody:       message.Body,
+				MessageAttributes: message.MessageAttributes,
+			}
+
+			if groupId, ok := message.Attributes[string(sqstypes.MessageSystemAttributeNameMessageGroupId)]; ok {
+				input.MessageGroupId = aws.String(groupId)
+				// The original deduplication ID may still be inside the target queue's
+				// deduplication window, so use the message ID instead
+				input.MessageDeduplicationId = message.MessageId
+			}
+
+			_, err = q.client.SendMessage(ctx, input)
+			if err != nil {
+				return moved, fmt.Errorf("failed to send message %s to %s: %w", aws.ToString(message.MessageId), toQueue, err)
+			}
+
+			_, err = q.client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(fromUrl),
+				ReceiptHandle: message.ReceiptHandle,
+			})
+			if err != nil {
+				return moved, fmt.Errorf("failed to delete message %s from %s after moving it: %w", aws.ToString(message.MessageId), fromQueue, err)
+			}
+
+			moved++
+		}
+
+		log.Debugf("Redrive %s -> %s: moved %d messages", fromQueue, toQueue, moved)
+	}
+
+	return moved, nil
+}
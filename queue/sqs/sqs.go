@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,6 +16,9 @@ import (
 	"github.com/finch-technologies/go-utils/queue/types"
 )
 
+// maxBatchEntries is the most messages SQS accepts in a single batch call
+const maxBatchEntries = 10
+
 // SQSMessageQueue is a concrete implementation of IMessageQueue using AWS SQS.
 type SQSMessageQueue struct {
 	client *sqs.Client
@@ -68,17 +73,28 @@ func (q *SQSMessageQueue) Enqueue(ctx context.Context, queueName string, payload
 
 	opts := getEnqueueOptions(options)
 
+	delay, err := delaySeconds(opts.Delay)
+	if err != nil {
+		return err
+	}
+
 	sqsInput := &sqs.SendMessageInput{
 		QueueUrl:       aws.String(url),
 		MessageBody:    aws.String(payload),
 		MessageGroupId: aws.String(opts.MessageGroupId),
+		DelaySeconds:   delay,
 	}
 
 	if opts.DeduplicationId != "" {
 		sqsInput.MessageDeduplicationId = aws.String(opts.DeduplicationId)
 	}
 
-	_, err := q.client.SendMessage(ctx, sqsInput)
+	sqsInput.MessageAttributes, err = messageAttributes(opts.Attributes)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.SendMessage(ctx, sqsInput)
 
 	if err != nil {
 		return fmt.Errorf("failed to enqueue message: %w", err)
@@ -86,13 +102,228 @@ func (q *SQSMessageQueue) Enqueue(ctx context.Context, queueName string, payload
 	return nil
 }
 
-func getEnqueueOptions(options []types.EnqueueOptions) types.EnqueueOptions {
-	if len(options) == 0 {
-		return types.EnqueueOptions{
-			MessageGroupId: "default",
+// EnqueueBatch sends messages to the specified queue using SendMessageBatch, in calls of at
+// most 10 messages and 256KB. Every message gets the same options; a deduplication ID is
+// suffixed with the message's index in payloads. Messages that weren't enqueued are
+// reported by their index in payloads in a *types.BatchError. A failed request stops the
+// batch, and so does any failure on a FIFO queue, where the messages share a group and
+// sending the rest would break its order.
+//
+// Example:
+//
+//	err := mq.EnqueueBatch(ctx, "orders.fifo", bodies, types.EnqueueOptions{MessageGroupId: customerId})
+//	var batchErr *types.BatchError
+//	if errors.As(err, &batchErr) {
+//	    retry(batchErr.FailedIndexes())
+//	}
+func (q *SQSMessageQueue) EnqueueBatch(ctx context.Context, queueName string, payloads []string, options ...types.EnqueueOptions) error {
+	url := q.getQueueURL(queueName)
+
+	opts := getEnqueueOptions(options)
+	fifo := strings.HasSuffix(queueName, ".fifo")
+
+	attributes, err := messageAttributes(opts.Attributes)
+	if err != nil {
+		return err
+	}
+
+	delay, err := delaySeconds(opts.Delay)
+	if err != nil {
+		return err
+	}
+
+	batchErr := &types.BatchError{Total: len(payloads)}
+
+	// notSent reports the messages from index on as skipped
+	notSent := func(from int) {
+		for i := from; i < len(payloads); i++ {
+			batchErr.Failed = append(batchErr.Failed, types.BatchEntryError{Index: i, Code: "NotSent", Message: "not sent after an earlier failure"})
 		}
 	}
-	return options[0]
+
+	for _, chunk := range batchChunks(payloads, attributesSize(opts.Attributes)) {
+		if len(batchErr.Failed) > 0 && fifo {
+			notSent(chunk.start)
+			break
+		}
+
+		if chunk.tooLong {
+			batchErr.Failed = append(batchErr.Failed, types.BatchEntryError{
+				Index:       chunk.start,
+				Code:        "MessageTooLong",
+				Message:     fmt.Sprintf("message is larger than %d bytes", maxBatchBytes),
+				SenderFault: true,
+			})
+			continue
+		}
+
+		entries := make([]sqstypes.SendMessageBatchRequestEntry, 0, chunk.end-chunk.start)
+		for i := chunk.start; i < chunk.end; i++ {
+			entry := sqstypes.SendMessageBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				MessageBody:       aws.String(payloads[i]),
+				MessageGroupId:    aws.String(opts.MessageGroupId),
+				MessageAttributes: attributes,
+				DelaySeconds:      delay,
+			}
+
+			if opts.DeduplicationId != "" {
+				entry.MessageDeduplicationId = aws.String(fmt.Sprintf("%s-%d", opts.DeduplicationId, i))
+			}
+
+			entries = append(entries, entry)
+		}
+
+		resp, err := q.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(url),
+			Entries:  entries,
+		})
+
+		if err != nil {
+			batchErr.Err = fmt.Errorf("failed to enqueue message batch: %w", err)
+			notSent(chunk.start)
+			break
+		}
+
+		batchErr.Failed = append(batchErr.Failed, failedEntries(resp.Failed)...)
+	}
+
+	if len(batchErr.Failed) > 0 || batchErr.Err != nil {
+		return batchErr
+	}
+
+	return nil
+}
+
+// maxBatchBytes is the most a SendMessageBatch call may carry, counting message bodies and
+// attributes
+const maxBatchBytes = 256 * 1024
+
+// batchChunk is a range of payloads sent in one SendMessageBatch call, or a single payload
+// too large to send at all
+type batchChunk struct {
+	start, end int
+	tooLong    bool
+}
+
+// batchChunks splits payloads into calls of at most maxBatchEntries messages and
+// maxBatchBytes, keeping their order. Every message carries attributesSize bytes of
+// attributes.
+func batchChunks(payloads []string, attributesSize int) []batchChunk {
+	var chunks []batchChunk
+	start, size := 0, 0
+
+	for i, payload := range payloads {
+		messageSize := len(payload) + attributesSize
+
+		if messageSize > maxBatchBytes {
+			if i > start {
+				chunks = append(chunks, batchChunk{start: start, end: i})
+			}
+			chunks = append(chunks, batchChunk{start: i, end: i + 1, tooLong: true})
+			start, size = i+1, 0
+			continue
+		}
+
+		if i-start == maxBatchEntries || size+messageSize > maxBatchBytes {
+			chunks = append(chunks, batchChunk{start: start, end: i})
+			start, size = i, 0
+		}
+
+		size += messageSize
+	}
+
+	if start < len(payloads) {
+		chunks = append(chunks, batchChunk{start: start, end: len(payloads)})
+	}
+
+	return chunks
+}
+
+// failedEntries converts the failed entries of a SendMessageBatch response, whose IDs are
+// the indexes of the messages in the batch
+func failedEntries(failed []sqstypes.BatchResultErrorEntry) []types.BatchEntryError {
+	errs := make([]types.BatchEntryError, 0, len(failed))
+
+	for _, entry := range failed {
+		index, _ := strconv.Atoi(aws.ToString(entry.Id))
+
+		errs = append(errs, types.BatchEntryError{
+			Index:       index,
+			Code:        aws.ToString(entry.Code),
+			Message:     aws.ToString(entry.Message),
+			SenderFault: entry.SenderFault,
+		})
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Index < errs[j].Index
+	})
+
+	return errs
+}
+
+// maxMessageAttributes is the most message attributes SQS accepts on a message
+const maxMessageAttributes = 10
+
+// messageAttributes converts string attributes to SQS message attributes. Empty values are
+// left out, as SQS rejects them.
+func messageAttributes(attributes map[string]string) (map[string]sqstypes.MessageAttributeValue, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]sqstypes.MessageAttributeValue, len(attributes))
+	for key, value := range attributes {
+		if value == "" {
+			continue
+		}
+		values[key] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	if len(values) > maxMessageAttributes {
+		return nil, fmt.Errorf("message has %d attributes, SQS accepts at most %d", len(values), maxMessageAttributes)
+	}
+
+	return values, nil
+}
+
+// attributesSize is the size SQS counts for string attributes: names, types and values
+func attributesSize(attributes map[string]string) int {
+	size := 0
+	for key, value := range attributes {
+		size += len(key) + len("String") + len(value)
+	}
+	return size
+}
+
+// maxDelay is the longest SQS delays a message
+const maxDelay = 15 * time.Minute
+
+// delaySeconds converts a message delay to the SQS DelaySeconds
+func delaySeconds(d time.Duration) (int32, error) {
+	if d < 0 || d > maxDelay {
+		return 0, fmt.Errorf("message delay %s is outside 0 to %s", d, maxDelay)
+	}
+
+	return int32(d.Seconds()), nil
+}
+
+func getEnqueueOptions(options []types.EnqueueOptions) types.EnqueueOptions {
+	opts := types.EnqueueOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.MessageGroupId == "" {
+		opts.MessageGroupId = "default"
+	}
+
+	return opts
 }
 
 func getDequeueOptions(options []types.DequeueOptions) types.DequeueOptions {
@@ -123,6 +354,10 @@ func (q *SQSMessageQueue) Dequeue(ctx context.Context, queueName string, options
 		},
 	}
 
+	if opts.VisibilityTimeout > 0 {
+		input.VisibilityTimeout = int32(opts.VisibilityTimeout.Seconds())
+	}
+
 	// CRITICAL: Use background context for AWS call to prevent message loss during shutdown.
 	// If the parent context is cancelled mid-request, AWS may have already dequeued messages
 	// but they would be lost until visibility timeout expires. Let the AWS call complete.
@@ -148,12 +383,23 @@ func (q *SQSMessageQueue) Dequeue(ctx context.Context, queueName string, options
 			}
 		}
 
+		var attributes map[string]string
+		if len(message.MessageAttributes) > 0 {
+			attributes = make(map[string]string, len(message.MessageAttributes))
+			for key, value := range message.MessageAttributes {
+				if value.StringValue != nil {
+					attributes[key] = *value.StringValue
+				}
+			}
+		}
+
 		messages[i] = types.DequeuedMessage{
 			MessageId:               *message.MessageId,
 			ReceiptHandle:           *message.ReceiptHandle,
 			Body:                    *message.Body,
 			ReceivedAt:              time.Now(),
 			ApproximateReceiveCount: approximateReceiveCount,
+			Attributes:              attributes,
 		}
 	}
 
@@ -173,6 +419,33 @@ func (q *SQSMessageQueue) Dequeue(ctx context.Context, queueName string, options
 	return messages, nil
 }
 
+// maxVisibilityTimeout is the longest SQS hides a received message
+const maxVisibilityTimeout = 12 * time.Hour
+
+// ChangeVisibility hides a received message for d from now, e.g. to extend the lease of a
+// message that takes long to process, or makes it visible again right away when d is 0.
+// SQS allows at most 12 hours from when the message was first received.
+//
+// Example:
+//
+//	err := mq.ChangeVisibility(ctx, "exports", message.ReceiptHandle, 5*time.Minute)
+func (q *SQSMessageQueue) ChangeVisibility(ctx context.Context, queueName string, receiptHandle string, d time.Duration) error {
+	if d < 0 || d > maxVisibilityTimeout {
+		return fmt.Errorf("visibility timeout %s is outside 0 to %s", d, maxVisibilityTimeout)
+	}
+
+	_, err := q.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(q.getQueueURL(queueName)),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: int32(d.Seconds()),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to change message visibility: %w", err)
+	}
+	return nil
+}
+
 // Delete deletes a message from the specified queue.
 func (q *SQSMessageQueue) Delete(ctx context.Context, queueName string, id string) error {
 	url := q.getQueueURL(queueName)
@@ -182,3 +455,17 @@ func (q *SQSMessageQueue) Delete(ctx context.Context, queueName string, id strin
 	})
 	return err
 }
+
+// Purge deletes all messages in the specified queue. SQS only allows one purge per queue
+// every 60 seconds and may take up to 60 seconds to complete.
+func (q *SQSMessageQueue) Purge(ctx context.Context, queueName string) error {
+	url := q.getQueueURL(queueName)
+	_, err := q.client.PurgeQueue(ctx, &sqs.PurgeQueueInput{
+		QueueUrl: aws.String(url),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to purge queue: %w", err)
+	}
+	return nil
+}
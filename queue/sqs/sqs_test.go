@@ -0,0 +1,87 @@
+package sqs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/finch-technologies/go-utils/queue/types"
+)
+
+func repeat(n int, payload string) []string {
+	payloads := make([]string, n)
+	for i := range payloads {
+		payloads[i] = payload
+	}
+	return payloads
+}
+
+func TestBatchChunks(t *testing.T) {
+	large := strings.Repeat("x", 100*1024)
+	tooLong := strings.Repeat("x", maxBatchBytes+1)
+
+	tests := []struct {
+		name           string
+		payloads       []string
+		attributesSize int
+		expected       []batchChunk
+	}{
+		{"empty", nil, 0, nil},
+		{"single", []string{"a"}, 0, []batchChunk{{start: 0, end: 1}}},
+		{"ten", repeat(10, "a"), 0, []batchChunk{{start: 0, end: 10}}},
+		{"split by count", repeat(23, "a"), 0, []batchChunk{{start: 0, end: 10}, {start: 10, end: 20}, {start: 20, end: 23}}},
+		{"split by size", repeat(5, large), 0, []batchChunk{{start: 0, end: 2}, {start: 2, end: 4}, {start: 4, end: 5}}},
+		{"attributes count toward size", repeat(3, large), 30 * 1024, []batchChunk{{start: 0, end: 1}, {start: 1, end: 2}, {start: 2, end: 3}}},
+		{"too long alone", []string{tooLong}, 0, []batchChunk{{start: 0, end: 1, tooLong: true}}},
+		{"too long in between", []string{"a", "b", tooLong, "c"}, 0, []batchChunk{{start: 0, end: 2}, {start: 2, end: 3, tooLong: true}, {start: 3, end: 4}}},
+		{"too long first", []string{tooLong, "a"}, 0, []batchChunk{{start: 0, end: 1, tooLong: true}, {start: 1, end: 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := batchChunks(tt.payloads, tt.attributesSize)
+
+			if !reflect.DeepEqual(chunks, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, chunks)
+			}
+		})
+	}
+}
+
+func TestFailedEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failed   []sqstypes.BatchResultErrorEntry
+		expected []types.BatchEntryError
+	}{
+		{"none", nil, []types.BatchEntryError{}},
+		{
+			"sorted by index",
+			[]sqstypes.BatchResultErrorEntry{
+				{Id: aws.String("7"), Code: aws.String("InternalError"), Message: aws.String("try again")},
+				{Id: aws.String("2"), Code: aws.String("InvalidParameterValue"), Message: aws.String("bad"), SenderFault: true},
+			},
+			[]types.BatchEntryError{
+				{Index: 2, Code: "InvalidParameterValue", Message: "bad", SenderFault: true},
+				{Index: 7, Code: "InternalError", Message: "try again"},
+			},
+		},
+		{
+			"missing fields",
+			[]sqstypes.BatchResultErrorEntry{{Id: aws.String("0")}},
+			[]types.BatchEntryError{{Index: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := failedEntries(tt.failed)
+
+			if !reflect.DeepEqual(errs, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/queue/types"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CorrelationIdAttribute is the message attribute carrying the correlation ID
+const CorrelationIdAttribute = "correlation_id"
+
+// TenantIdAttribute is the message attribute carrying the tenant ID
+const TenantIdAttribute = "tenant_id"
+
+type correlationIdKey struct{}
+
+type tenantIdKey struct{}
+
+// traceFields are the logger fields added to a message context
+type traceFields struct {
+	CorrelationId string
+	TenantId      string
+}
+
+// WithCorrelationId returns a context carrying a correlation ID that is stamped onto every
+// message enqueued with it
+func WithCorrelationId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIdKey{}, id)
+}
+
+// CorrelationId returns the correlation ID carried by ctx, or an empty string
+func CorrelationId(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIdKey{}).(string)
+	return id
+}
+
+// WithTenantId returns a context carrying the tenant a flow runs for, which is stamped onto
+// every message enqueued with it so consumers act for the same tenant
+//
+// Example:
+//
+//	ctx = queue.WithTenantId(ctx, account.TenantId)
+//	err := queue.Enqueue(ctx, InvoicesQueue, invoice)
+//	// ... in the consumer, after queue.MessageContext
+//	tenantId := queue.TenantId(ctx)
+func WithTenantId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIdKey{}, id)
+}
+
+// TenantId returns the tenant ID carried by ctx, or an empty string
+func TenantId(ctx context.Context) string {
+	id, _ := ctx.Value(tenantIdKey{}).(string)
+	return id
+}
+
+// InjectTrace stamps the correlation ID, tenant ID and active trace context of ctx into
+// message attributes. A new correlation ID is generated if ctx has none, so every
+// asynchronous flow can be followed across services. Returns a new map; attributes is not
+// modified.
+func InjectTrace(ctx context.Context, attributes map[string]string) map[string]string {
+	stamped := make(map[string]string, len(attributes)+4)
+
+	for key, value := range attributes {
+		stamped[key] = value
+	}
+
+	if _, ok := stamped[CorrelationIdAttribute]; !ok {
+		id := CorrelationId(ctx)
+		if id == "" {
+			id = uuid.New().String()
+		}
+		stamped[CorrelationIdAttribute] = id
+	}
+
+	if _, ok := stamped[TenantIdAttribute]; !ok {
+		if tenantId := TenantId(ctx); tenantId != "" {
+			stamped[TenantIdAttribute] = tenantId
+		}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(stamped))
+
+	return stamped
+}
+
+// ExtractTrace restores the correlation ID, tenant ID and trace context from message
+// attributes into ctx. The returned context also carries a logger tagged with both IDs,
+// which includes trace_id and span_id when a trace was propagated, so log.FromContext(ctx)
+// in the consumer continues the producer's trace.
+func ExtractTrace(ctx context.Context, attributes map[string]string) context.Context {
+	if len(attributes) == 0 {
+		return ctx
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(attributes))
+
+	id := attributes[CorrelationIdAttribute]
+	if id != "" {
+		ctx = WithCorrelationId(ctx, id)
+	}
+
+	tenantId := attributes[TenantIdAttribute]
+	if tenantId != "" {
+		ctx = WithTenantId(ctx, tenantId)
+	}
+
+	if id == "" && tenantId == "" && !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	return log.New(ctx, traceFields{CorrelationId: id, TenantId: tenantId}).GetContext()
+}
+
+// MessageContext returns ctx with the trace context of a dequeued message restored
+//
+// Example:
+//
+//	for _, message := range messages {
+//		ctx := queue.MessageContext(ctx, message)
+//		log.FromContext(ctx).Info("Processing message")
+//	}
+func MessageContext[T any](ctx context.Context, message types.QueueMessage[T]) context.Context {
+	return ExtractTrace(ctx, message.Attributes)
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectTrace(t *testing.T) {
+	ctx := WithTenantId(WithCorrelationId(context.Background(), "c1"), "t1")
+
+	attributes := map[string]string{"source": "checkout"}
+	stamped := InjectTrace(ctx, attributes)
+
+	if stamped["source"] != "checkout" || stamped[CorrelationIdAttribute] != "c1" || stamped[TenantIdAttribute] != "t1" {
+		t.Errorf("unexpected attributes %v", stamped)
+	}
+	if len(attributes) != 1 {
+		t.Errorf("expected the attributes to be left alone, got %v", attributes)
+	}
+
+	// Attributes set by the caller win over the context
+	stamped = InjectTrace(ctx, map[string]string{TenantIdAttribute: "t2"})
+	if stamped[TenantIdAttribute] != "t2" {
+		t.Errorf("expected the caller's tenant, got %v", stamped)
+	}
+
+	// A flow without a correlation ID gets a new one, and no tenant
+	stamped = InjectTrace(context.Background(), nil)
+	if stamped[CorrelationIdAttribute] == "" {
+		t.Error("expected a new correlation ID")
+	}
+	if _, ok := stamped[TenantIdAttribute]; ok {
+		t.Errorf("expected no tenant, got %v", stamped)
+	}
+}
+
+func TestExtractTrace(t *testing.T) {
+	ctx := ExtractTrace(context.Background(), map[string]string{CorrelationIdAttribute: "c1", TenantIdAttribute: "t1"})
+
+	if CorrelationId(ctx) != "c1" || TenantId(ctx) != "t1" {
+		t.Errorf("expected the IDs to be restored, got %q %q", CorrelationId(ctx), TenantId(ctx))
+	}
+
+	if ctx := ExtractTrace(context.Background(), nil); CorrelationId(ctx) != "" || TenantId(ctx) != "" {
+		t.Error("expected no IDs without attributes")
+	}
+}
+
+func TestTraceRoundTrip(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	stamped := InjectTrace(ctx, nil)
+	if stamped["traceparent"] == "" {
+		t.Fatalf("expected the trace context to be stamped, got %v", stamped)
+	}
+
+	restored := trace.SpanContextFromContext(ExtractTrace(context.Background(), stamped))
+	if restored.TraceID() != traceId || restored.SpanID() != spanId {
+		t.Errorf("expected the producer's span, got %s %s", restored.TraceID(), restored.SpanID())
+	}
+}
@@ -1,24 +1,35 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type EnqueueOptions struct {
 	MessageGroupId  string
 	DeduplicationId string
-	Attributes      map[string]string
+	Attributes      map[string]string // String metadata kept out of the payload, e.g. tenant IDs (at most 10 with sqs, counting those InjectTrace adds)
+	Delay           time.Duration     // How long the message stays hidden before it can be received (at most 15 minutes with sqs)
+
+	// MaxReceiveCount is the number of receives after which Dequeue moves the message to
+	// DeadLetterQueue instead of returning it (default 0, never)
+	MaxReceiveCount int
+	DeadLetterQueue string
 }
 
 type DequeueOptions struct {
-	WaitTimeSeconds int
-	BatchSize       int
-	DeleteMessage   bool
+	WaitTimeSeconds   int
+	BatchSize         int
+	DeleteMessage     bool
+	VisibilityTimeout time.Duration // How long received messages stay hidden from other consumers (default the queue's setting)
 }
 
 type GenericDequeueOptions[T any] struct {
-	WaitTimeSeconds int
-	BatchSize       int
-	DeleteMessage   bool
-	ParseFunc       func(body string) (T, error)
+	WaitTimeSeconds   int
+	BatchSize         int
+	DeleteMessage     bool
+	VisibilityTimeout time.Duration // How long received messages stay hidden from other consumers (default the queue's setting)
+	ParseFunc         func(body string) (T, error)
 }
 
 type DequeuedMessage struct {
@@ -27,6 +38,7 @@ type DequeuedMessage struct {
 	Body                    string
 	ReceivedAt              time.Time
 	ApproximateReceiveCount int
+	Attributes              map[string]string
 }
 
 type QueueMessage[T any] struct {
@@ -35,4 +47,89 @@ type QueueMessage[T any] struct {
 	Payload                 T
 	ReceivedAt              time.Time
 	ApproximateReceiveCount int
+	Attributes              map[string]string
+}
+
+type RedriveOptions struct {
+	RatePerSecond   int // Maximum messages moved per second (default 10, negative for unlimited)
+	WaitTimeSeconds int // Long polling wait before the source queue is considered empty (default 2)
+}
+
+type DrainOptions struct {
+	BatchSize        int                          // Messages dequeued per call (default 10)
+	WaitTimeSeconds  int                          // Long polling wait before the queue is considered empty (default 2)
+	ProgressInterval time.Duration                // How often progress is reported (default 5s)
+	OnProgress       func(progress DrainProgress) // Progress callback, defaults to logging
+}
+
+type DrainProgress struct {
+	Processed int           // Messages handled successfully
+	Failed    int           // Messages the handler returned an error for
+	Remaining int           // Approximate number of messages left in the queue
+	Elapsed   time.Duration // Time since the drain started
+}
+
+// BatchEntryError is a message of a batch that wasn't enqueued
+type BatchEntryError struct {
+	Index       int    // Index of the message in the batch
+	Code        string // e.g. MessageTooLong, or NotSent for messages skipped after an earlier failure
+	Message     string
+	SenderFault bool // The message itself was rejected, so sending it again won't help
+}
+
+// BatchError reports the messages of a batch that weren't enqueued. The other messages
+// were enqueued.
+type BatchError struct {
+	Total  int               // Messages in the batch
+	Failed []BatchEntryError // Messages that weren't enqueued, in batch order
+	Err    error             // Error of the request that failed, if the batch stopped on one
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Failed) == 0 {
+		return fmt.Sprintf("failed to enqueue batch of %d messages: %v", e.Total, e.Err)
+	}
+
+	first := e.Failed[0]
+	return fmt.Sprintf("failed to enqueue %d of %d messages, first at index %d: %s %s", len(e.Failed), e.Total, first.Index, first.Code, first.Message)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// FailedIndexes returns the indexes of the messages that weren't enqueued, e.g. to retry them
+func (e *BatchError) FailedIndexes() []int {
+	indexes := make([]int, len(e.Failed))
+	for i, failed := range e.Failed {
+		indexes[i] = failed.Index
+	}
+	return indexes
+}
+
+// DecodeError is a received message whose body couldn't be decoded
+type DecodeError struct {
+	MessageId     string
+	ReceiptHandle string // To delete the message, or leave it for redelivery and its dead letter policy
+	Body          string
+	Err           error
+}
+
+// DequeueError reports the received messages that couldn't be decoded. The other messages
+// were returned.
+type DequeueError struct {
+	Failed []DecodeError
+}
+
+func (e *DequeueError) Error() string {
+	first := e.Failed[0]
+	return fmt.Sprintf("failed to decode %d received messages, first %s: %v", len(e.Failed), first.MessageId, first.Err)
+}
+
+func (e *DequeueError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, failed := range e.Failed {
+		errs[i] = failed.Err
+	}
+	return errs
 }
@@ -0,0 +1,233 @@
+// Package singleton ensures only one instance of a command runs at a time, either on a
+// single host using a file lock or across a fleet using a dynamo lease.
+package singleton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/finch-technologies/go-utils/elector"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrAlreadyRunning is returned when another instance holds the lock
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// Lock is a held singleton lock
+type Lock interface {
+	// Lost is closed when the lock is lost while held (only possible for distributed locks)
+	Lost() <-chan struct{}
+	// Release releases the lock
+	Release() error
+}
+
+// Options configures a singleton guard
+type Options struct {
+	Name        string              // Lock name, defaults to the executable name
+	LockDir     string              // Directory for file locks, defaults to os.TempDir()
+	Distributed bool                // Lock across hosts with a dynamo lease instead of a local file lock
+	Lease       elector.LeaseConfig // Lease configuration for distributed locks
+}
+
+func getOptions(options ...Options) Options {
+	opts := Options{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Name == "" {
+		opts.Name = filepath.Base(os.Args[0])
+	}
+
+	if opts.LockDir == "" {
+		opts.LockDir = os.TempDir()
+	}
+
+	return opts
+}
+
+// Acquire takes the singleton lock described by options, returning ErrAlreadyRunning if
+// another instance holds it
+func Acquire(options ...Options) (Lock, error) {
+	opts := getOptions(options...)
+
+	if opts.Distributed {
+		return LockDistributed(opts.Name, opts.Lease)
+	}
+
+	return LockFile(filepath.Join(opts.LockDir, sanitize(opts.Name)+".lock"))
+}
+
+// Run calls fn only if no other instance holds the lock, and releases the lock when fn
+// returns. The context passed to fn is cancelled if a distributed lock is lost.
+//
+// Example:
+//
+//	err := singleton.Run(ctx, cleanup, singleton.Options{Name: "nightly-cleanup", Distributed: true})
+//	if errors.Is(err, singleton.ErrAlreadyRunning) {
+//		return
+//	}
+func Run(ctx context.Context, fn func(ctx context.Context) error, options ...Options) error {
+	lock, err := Acquire(options...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Errorf("Failed to release singleton lock: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-lock.Lost():
+			log.Warning("Singleton lock lost, cancelling run")
+			cancel()
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// FileLock is an exclusive utils.FileLock on a local file
+type FileLock struct {
+	lock *utils.FileLock
+	lost chan struct{}
+}
+
+// LockFile takes an exclusive lock on path without waiting for it. The lock is released
+// by the operating system if the process exits without calling Release.
+func LockFile(path string) (*FileLock, error) {
+	lock, err := utils.LockFile(context.Background(), path, utils.FileLockOptions{NoWait: true})
+	if errors.Is(err, utils.ErrLockHeld) {
+		return nil, ErrAlreadyRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the holder to help with debugging stuck locks
+	if file := lock.File(); file.Truncate(0) == nil {
+		fmt.Fprintf(file, "%d\n", os.Getpid())
+	}
+
+	return &FileLock{
+		lock: lock,
+		lost: make(chan struct{}),
+	}, nil
+}
+
+// Lost never fires for file locks
+func (l *FileLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release unlocks and closes the lock file
+func (l *FileLock) Release() error {
+	return l.lock.Unlock()
+}
+
+// DistributedLock is a dynamo lease held for the lifetime of a run and renewed in the background
+type DistributedLock struct {
+	name    string
+	manager *elector.LeaseManager
+	lost    chan struct{}
+	done    chan struct{}
+}
+
+// LockDistributed takes a dynamo lease named name using the same locking as the leader
+// elector. The lease is renewed until Release is called.
+func LockDistributed(name string, config ...elector.LeaseConfig) (*DistributedLock, error) {
+	cfg := elector.LeaseConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "singleton_"
+	}
+
+	manager := elector.NewLeaseManager(cfg)
+
+	acquired, err := manager.Acquire(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire singleton lease: %w", err)
+	}
+
+	if !acquired {
+		return nil, ErrAlreadyRunning
+	}
+
+	manager.Start()
+
+	lock := &DistributedLock{
+		name:    name,
+		manager: manager,
+		lost:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go lock.watch()
+
+	return lock, nil
+}
+
+// watch closes the lost channel once the lease is no longer held
+func (l *DistributedLock) watch() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			if !l.manager.IsHeld(l.name) {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// Lost is closed when the lease could not be renewed or was taken over
+func (l *DistributedLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release stops renewing and deletes the lease
+func (l *DistributedLock) Release() error {
+	select {
+	case <-l.done:
+		return nil
+	default:
+		close(l.done)
+	}
+
+	err := l.manager.Release(l.name)
+	l.manager.Stop()
+
+	return err
+}
+
+// sanitize makes a lock name safe to use as a file name
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
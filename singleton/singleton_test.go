@@ -0,0 +1,59 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.lock")
+
+	lock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() error = %v", err)
+	}
+
+	if _, err := LockFile(path); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second LockFile() error = %v, want %v", err, ErrAlreadyRunning)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	lock, err = LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() after release error = %v", err)
+	}
+	lock.Release()
+}
+
+func TestRun(t *testing.T) {
+	opts := Options{Name: "maintenance job", LockDir: t.TempDir()}
+
+	ran := false
+
+	err := Run(context.Background(), func(ctx context.Context) error {
+		ran = true
+
+		nested := Run(ctx, func(ctx context.Context) error {
+			t.Error("nested Run() should not call fn while the lock is held")
+			return nil
+		}, opts)
+
+		if !errors.Is(nested, ErrAlreadyRunning) {
+			t.Errorf("nested Run() error = %v, want %v", nested, ErrAlreadyRunning)
+		}
+
+		return nil
+	}, opts)
+
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !ran {
+		t.Error("Run() did not call fn")
+	}
+}
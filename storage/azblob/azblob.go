@@ -0,0 +1,386 @@
+// Package azblob stores files as block blobs in an Azure Storage container, talking to the
+// Blob service REST API with the account's shared key.
+package azblob
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Config configures a Blob storage client
+type Config struct {
+	Account   string        // Storage account name (default AZURE_STORAGE_ACCOUNT)
+	Key       string        // Base64 shared key of the account (default AZURE_STORAGE_KEY)
+	Container string        // Container holding the blobs
+	KeyPrefix string        // Prefix of every blob name, like s3.Config.KeyPrefix (optional)
+	Endpoint  string        // Blob service endpoint (default https://<account>.blob.core.windows.net)
+	Timeout   time.Duration // Limit per request, including reading a download's body (default none)
+}
+
+// UploadOptions configures Upload
+type UploadOptions struct {
+	ContentType string // Content type of the blob (default inferred from the key's extension)
+}
+
+// FileInfo describes a stored blob
+type FileInfo struct {
+	Name         string // Blob name relative to the key prefix
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// ResponseError is the error of a request the Blob service rejected. Errors for missing
+// blobs match fs.ErrNotExist.
+type ResponseError struct {
+	StatusCode int
+	Code       string // x-ms-error-code, e.g. BlobNotFound
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("blob service returned %d %s", e.StatusCode, e.Code)
+}
+
+func (e *ResponseError) Is(target error) bool {
+	return target == fs.ErrNotExist && e.StatusCode == http.StatusNotFound
+}
+
+// Client reads and writes the blobs of one container
+type Client struct {
+	Account   string
+	Container string
+	KeyPrefix string
+
+	endpoint   string
+	key        []byte
+	httpClient *http.Client
+}
+
+// New creates a Blob storage client
+//
+// Example:
+//
+//	client, err := azblob.New(azblob.Config{Container: "documents"})
+//	err = client.Upload(ctx, data, "reports/2024.pdf")
+//	url, err := client.GeneratePresignedURL(ctx, "reports/2024.pdf", 30)
+func New(config ...Config) (*Client, error) {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	cfg.Account = utils.StringOrDefault(cfg.Account, os.Getenv("AZURE_STORAGE_ACCOUNT"))
+	cfg.Key = utils.StringOrDefault(cfg.Key, os.Getenv("AZURE_STORAGE_KEY"))
+
+	if cfg.Account == "" || cfg.Key == "" {
+		return nil, errors.New("a storage account and key are required")
+	}
+	if cfg.Container == "" {
+		return nil, errors.New("container is required")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode storage account key: %w", err)
+	}
+
+	return &Client{
+		Account:    cfg.Account,
+		Container:  cfg.Container,
+		KeyPrefix:  cfg.KeyPrefix,
+		endpoint:   strings.TrimSuffix(utils.StringOrDefault(cfg.Endpoint, "https://"+cfg.Account+".blob.core.windows.net"), "/"),
+		key:        key,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Upload stores data as a block blob under key, replacing any existing blob
+func (c *Client) Upload(ctx context.Context, data []byte, key string, options ...UploadOptions) error {
+	var opts UploadOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, c.blobURL(key), bytes.NewReader(data), map[string]string{
+		"x-ms-blob-type": "BlockBlob",
+		"Content-Type":   utils.StringOrDefault(opts.ContentType, utils.GetContentTypeFromURL(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to blob storage: %w", key, err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Download returns the content of key
+func (c *Client) Download(ctx context.Context, key string) ([]byte, error) {
+	r, err := c.Reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from blob storage: %w", key, err)
+	}
+
+	return data, nil
+}
+
+// Reader returns the content of key as a stream. Close the reader when done.
+func (c *Client) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.blobURL(key), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from blob storage: %w", key, err)
+	}
+
+	return resp.Body, nil
+}
+
+// Writer returns a writer whose content is uploaded to key when it is closed. The content
+// is held in memory until then.
+func (c *Client) Writer(ctx context.Context, key string, options ...UploadOptions) (io.WriteCloser, error) {
+	return &blobWriter{ctx: ctx, client: c, key: key, options: options}, nil
+}
+
+// FileExists checks if a blob exists
+func (c *Client) FileExists(ctx context.Context, key string) (bool, error) {
+	_, err := c.GetFileInfo(ctx, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetFileInfo returns the size, content type and modification time of a blob
+func (c *Client) GetFileInfo(ctx context.Context, key string) (*FileInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, c.blobURL(key), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties of %s from blob storage: %w", key, err)
+	}
+	resp.Body.Close()
+
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &FileInfo{
+		Name:         key,
+		Size:         resp.ContentLength,
+		ContentType:  resp.Header.Get("Content-Type"),
+		LastModified: lastModified,
+	}, nil
+}
+
+// DeleteFile deletes a blob
+func (c *Client) DeleteFile(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, c.blobURL(key), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from blob storage: %w", key, err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// List returns the blobs whose names start with prefix
+func (c *Client) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	var files []FileInfo
+	marker := ""
+
+	for {
+		query := url.Values{
+			"restype": {"container"},
+			"comp":    {"list"},
+			"prefix":  {c.fullKey(prefix)},
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, c.endpoint+"/"+c.Container+"?"+query.Encode(), nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs under %s: %w", prefix, err)
+		}
+
+		var page listBlobsResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode blob listing: %w", err)
+		}
+
+		for _, blob := range page.Blobs {
+			lastModified, _ := http.ParseTime(blob.Properties.LastModified)
+
+			files = append(files, FileInfo{
+				Name:         c.relativeKey(blob.Name),
+				Size:         blob.Properties.ContentLength,
+				ContentType:  blob.Properties.ContentType,
+				LastModified: lastModified,
+			})
+		}
+
+		if page.NextMarker == "" {
+			return files, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+// Copy copies the blob under src to dst within the container, waiting for the copy to
+// complete
+func (c *Client) Copy(ctx context.Context, src, dst string) error {
+	resp, err := c.do(ctx, http.MethodPut, c.blobURL(dst), nil, map[string]string{
+		"x-ms-copy-source": c.blobURL(src),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s in blob storage: %w", src, dst, err)
+	}
+	resp.Body.Close()
+
+	status := resp.Header.Get("x-ms-copy-status")
+
+	for status == "pending" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+
+		resp, err := c.do(ctx, http.MethodHead, c.blobURL(dst), nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get copy status of %s: %w", dst, err)
+		}
+		resp.Body.Close()
+
+		status = resp.Header.Get("x-ms-copy-status")
+	}
+
+	if status != "success" {
+		return fmt.Errorf("copy of %s to %s ended with status %q", src, dst, status)
+	}
+
+	return nil
+}
+
+// Move copies the blob under src to dst as Copy does, then deletes src
+func (c *Client) Move(ctx context.Context, src, dst string) error {
+	if err := c.Copy(ctx, src, dst); err != nil {
+		return err
+	}
+
+	return c.DeleteFile(ctx, src)
+}
+
+// GeneratePresignedURL returns a URL that can read key without credentials for the given
+// number of minutes, signed with a service SAS
+func (c *Client) GeneratePresignedURL(_ context.Context, key string, expirationMinutes int) (string, error) {
+	expiry := time.Now().Add(time.Duration(expirationMinutes) * time.Minute)
+
+	return c.blobURL(key) + "?" + c.sasToken(c.fullKey(key), expiry), nil
+}
+
+// do sends a signed request, returning a *ResponseError for error statuses
+func (c *Client) do(ctx context.Context, method, rawURL string, body *bytes.Reader, headers map[string]string) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	c.sign(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, &ResponseError{StatusCode: resp.StatusCode, Code: resp.Header.Get("x-ms-error-code")}
+	}
+
+	return resp, nil
+}
+
+// blobURL is the URL of the blob stored for key
+func (c *Client) blobURL(key string) string {
+	segments := strings.Split(c.fullKey(key), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return c.endpoint + "/" + c.Container + "/" + strings.Join(segments, "/")
+}
+
+func (c *Client) fullKey(key string) string {
+	prefix := strings.Trim(c.KeyPrefix, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (c *Client) relativeKey(fullKey string) string {
+	prefix := strings.Trim(c.KeyPrefix, "/")
+	if prefix == "" {
+		return fullKey
+	}
+	return strings.TrimPrefix(fullKey, prefix+"/")
+}
+
+// listBlobsResult is a page of the List Blobs response
+type listBlobsResult struct {
+	Blobs []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			LastModified  string `xml:"Last-Modified"`
+			ContentLength int64  `xml:"Content-Length"`
+			ContentType   string `xml:"Content-Type"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// blobWriter buffers a blob until it is closed
+type blobWriter struct {
+	ctx     context.Context
+	client  *Client
+	key     string
+	options []UploadOptions
+	buf     bytes.Buffer
+}
+
+func (w *blobWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *blobWriter) Close() error {
+	return w.client.Upload(w.ctx, w.buf.Bytes(), w.key, w.options...)
+}
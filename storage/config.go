@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/finch-technologies/go-utils/storage/azblob"
+	"github.com/finch-technologies/go-utils/storage/filesystem"
+	"github.com/finch-technologies/go-utils/storage/gcs"
+	"github.com/finch-technologies/go-utils/storage/memory"
+	"github.com/finch-technologies/go-utils/storage/s3"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// Type selects the backend New creates
+type Type string
+
+const (
+	// TypeS3 stores files in an S3 bucket
+	TypeS3 Type = "s3"
+	// TypeGCS stores files in a Cloud Storage bucket
+	TypeGCS Type = "gcs"
+	// TypeAzure stores files in an Azure Blob storage container
+	TypeAzure Type = "azure"
+	// TypeLocal stores files on the local filesystem
+	TypeLocal Type = "local"
+	// TypeMemory keeps files in process memory, for tests and local runs
+	TypeMemory Type = "memory"
+)
+
+// Types are the valid storage types
+var Types = utils.NewEnum(TypeS3, TypeGCS, TypeAzure, TypeLocal, TypeMemory)
+
+func (t *Type) UnmarshalJSON(data []byte) error {
+	return Types.DecodeJSON(data, t)
+}
+
+func (t *Type) UnmarshalText(text []byte) error {
+	return Types.DecodeText(text, t)
+}
+
+// StorageConfig selects and configures the backend of a Storage
+type StorageConfig struct {
+	Type  Type                           // Backend to use (default STORAGE_TYPE, or local)
+	S3    s3.Config                      // Client config for the s3 backend
+	GCS   gcs.Config                     // Client config for the gcs backend
+	Azure azblob.Config                  // Client config for the azure backend
+	Local filesystem.LocalStorageOptions // Options for the local backend (default base path ./.storage)
+}
+
+var (
+	shared   Storage
+	sharedMu sync.Mutex
+)
+
+// New creates a Storage for the backend selected by config
+//
+// Example:
+//
+//	store, err := storage.New(storage.StorageConfig{Type: storage.TypeS3, S3: s3.Config{Bucket: "documents"}})
+func New(config ...StorageConfig) (Storage, error) {
+	cfg := getConfig(config...)
+
+	switch cfg.Type {
+	case TypeS3:
+		client, err := s3.New(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3(client), nil
+	case TypeGCS:
+		client, err := gcs.New(cfg.GCS)
+		if err != nil {
+			return nil, err
+		}
+		return NewGCS(client), nil
+	case TypeAzure:
+		client, err := azblob.New(cfg.Azure)
+		if err != nil {
+			return nil, err
+		}
+		return NewAzure(client), nil
+	case TypeLocal:
+		var options []filesystem.LocalStorageOptions
+		if cfg.Local.BasePath != "" {
+			options = append(options, cfg.Local)
+		}
+
+		local, err := filesystem.Init(options...)
+		if err != nil {
+			return nil, err
+		}
+		local.Locking = cfg.Local.Locking
+		local.LockTimeout = cfg.Local.LockTimeout
+		local.Checksums = cfg.Local.Checksums
+
+		return NewLocal(local), nil
+	case TypeMemory:
+		return NewMemory(memory.New()), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type %q, expected one of %v", cfg.Type, Types.Strings())
+	}
+}
+
+// Init creates the shared Storage returned by GetStorage. Once it exists, Init returns it
+// unchanged.
+//
+// Example:
+//
+//	// In tests
+//	storage.Init(storage.StorageConfig{Type: storage.TypeMemory})
+func Init(config ...StorageConfig) (Storage, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if shared == nil {
+		store, err := New(config...)
+		if err != nil {
+			return nil, err
+		}
+		shared = store
+	}
+
+	return shared, nil
+}
+
+// GetStorage returns the shared Storage, creating it from the environment if Init wasn't
+// called
+func GetStorage() (Storage, error) {
+	return Init()
+}
+
+func getConfig(config ...StorageConfig) StorageConfig {
+	var cfg StorageConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.Type == "" {
+		cfg.Type = Type(utils.StringOrDefault(os.Getenv("STORAGE_TYPE"), string(TypeLocal)))
+	}
+
+	return cfg
+}
@@ -0,0 +1,99 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrChecksumMismatch is returned when a write doesn't match WriteOptions.ChecksumSHA256 or
+// a read doesn't match the checksum stored with the file
+var ErrChecksumMismatch = utils.ErrChecksumMismatch
+
+// checksumSuffix marks the files holding the SHA-256 of a stored file
+const checksumSuffix = ".sha256"
+
+// isChecksumFile reports whether name is the checksum file of a stored file
+func isChecksumFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, checksumSuffix)
+}
+
+// checksumPath is the hidden file next to filePath holding its hex SHA-256
+func checksumPath(filePath string) string {
+	return filepath.Join(filepath.Dir(filePath), "."+filepath.Base(filePath)+checksumSuffix)
+}
+
+// checkChecksum fails with ErrChecksumMismatch if want is set and isn't the hex of sum
+func checkChecksum(path string, sum []byte, want string) error {
+	if want != "" && !strings.EqualFold(want, hex.EncodeToString(sum)) {
+		return fmt.Errorf("content of %q doesn't match the expected checksum %s: %w", path, want, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// readChecksum returns the stored SHA-256 of filePath, or nil if it has none
+func readChecksum(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(checksumPath(filePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum of %q: %w", filePath, err)
+	}
+
+	sum, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum of %q: %w", filePath, err)
+	}
+
+	return sum, nil
+}
+
+// verifyChecksum fails with ErrChecksumMismatch if sum isn't the stored checksum of filePath
+func verifyChecksum(path, filePath string, sum []byte) error {
+	want, err := readChecksum(filePath)
+	if err != nil || want == nil {
+		return err
+	}
+
+	if !bytes.Equal(sum, want) {
+		return fmt.Errorf("content of %q doesn't match its checksum: %w", path, ErrChecksumMismatch)
+	}
+
+	return nil
+}
+
+// saveChecksum stores sum as the checksum of filePath, or removes a checksum left by an
+// earlier write if store is false, so it can't fail reads of the new content
+func saveChecksum(filePath string, sum []byte, store bool, opts WriteOptions) error {
+	if !store {
+		if err := os.Remove(checksumPath(filePath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove checksum of %q: %w", filePath, err)
+		}
+		return nil
+	}
+
+	tmp, err := createTemp(checksumPath(filePath))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.WriteString(hex.EncodeToString(sum) + "\n"); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checksum of %q: %w", filePath, err)
+	}
+
+	return commitTemp(tmp, checksumPath(filePath), WriteOptions{Mode: opts.Mode, Sync: opts.Sync})
+}
+
+// storesChecksum reports whether a write with opts keeps a checksum of the file
+func (s *LocalStorage) storesChecksum(opts WriteOptions) bool {
+	return s.Checksums || opts.ChecksumSHA256 != ""
+}
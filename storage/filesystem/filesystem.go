@@ -2,12 +2,20 @@ package filesystem
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
 )
 
 type DeleteOptions struct {
@@ -15,12 +23,46 @@ type DeleteOptions struct {
 	DeleteDir bool
 }
 
+type WriteOptions struct {
+	Deduplicate   bool        // Store under a SHA-256 content path and reuse an existing file with the same content
+	ContentPrefix string      // Path prefix for deduplicated writes (default "content")
+	Mode          os.FileMode // Permissions of the file (default 0644)
+	NoOverwrite   bool        // Fail with an error matching os.ErrExist if the file exists
+	Sync          bool        // Flush the file and its directory to disk before returning, so it survives a crash
+
+	// ChecksumSHA256 is the hex SHA-256 the content must have; the write fails with
+	// ErrChecksumMismatch otherwise. It is kept with the file like LocalStorage.Checksums does.
+	ChecksumSHA256 string
+}
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Path        string // Path of the file in the form it was asked for, with forward slashes
+	Size        int64
+	ModTime     time.Time
+	ContentType string // Inferred from the extension
+}
+
 type LocalStorageOptions struct {
-	BasePath string
+	BasePath    string
+	Locking     bool          // Guard reads, writes and deletes with inter-process file locks
+	LockTimeout time.Duration // How long to wait for a lock (default 30s)
+	Checksums   bool          // Keep the SHA-256 of every written file to verify reads against
 }
 
+// LocalStorage stores files under BasePath. With Locking set, processes sharing BasePath
+// take an advisory lock per file, so a reader never sees a half written file and
+// concurrent writers don't interleave. Lock files are kept under BasePath/.locks.
+//
+// With Checksums set, the SHA-256 of a file is kept next to it in a hidden .<name>.sha256
+// file, and reads of files that have one fail with ErrChecksumMismatch if the content no
+// longer matches. The checksum is replaced after the file, so use Locking as well when
+// files are read while they are rewritten.
 type LocalStorage struct {
-	BasePath string
+	BasePath    string
+	Locking     bool
+	LockTimeout time.Duration
+	Checksums   bool
 }
 
 func Init(options ...LocalStorageOptions) (*LocalStorage, error) {
@@ -28,18 +70,37 @@ func Init(options ...LocalStorageOptions) (*LocalStorage, error) {
 
 	basePath := wd + "/.storage"
 
+	storage := &LocalStorage{}
+
 	if len(options) > 0 {
 		basePath = options[0].BasePath
+		storage.Locking = options[0].Locking
+		storage.LockTimeout = options[0].LockTimeout
+		storage.Checksums = options[0].Checksums
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &LocalStorage{BasePath: basePath}, nil
+	storage.BasePath = basePath
+
+	return storage, nil
 }
 
 func (s *LocalStorage) Read(ctx context.Context, path string) ([]byte, error) {
+	if s.Locking {
+		lock, err := s.lock(ctx, path, true)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Unlock()
+	}
+
+	return s.read(path)
+}
+
+func (s *LocalStorage) read(path string) ([]byte, error) {
 	sourceFile, err := os.Open(s.getPath(path))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open source file %q, %v", path, err)
@@ -47,30 +108,116 @@ func (s *LocalStorage) Read(ctx context.Context, path string) ([]byte, error) {
 	defer func(sourceFile *os.File) {
 		err := sourceFile.Close()
 		if err != nil {
-			log.Error("failed to write file %q: %v", path, err)
+			log.Errorf("failed to close file %q: %v", path, err)
 		}
 	}(sourceFile)
 
-	return io.ReadAll(sourceFile)
+	data, err := io.ReadAll(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if err := verifyChecksum(path, s.getPath(path), sum[:]); err != nil {
+		return nil, err
+	}
+
+	return data, nil
 }
 
-func (s *LocalStorage) Write(ctx context.Context, file []byte, path string) (string, error) {
+// Write stores file at path. The content goes to a temporary file that is renamed into
+// place, so readers see either the old or the new file, never a partial one. With
+// Deduplicate set, the file is stored under a content-addressed path instead, skipping the
+// write if a file with the same content exists, and that path is returned.
+//
+// Example:
+//
+//	_, err := storage.Write(ctx, state, "state/ledger.json", filesystem.WriteOptions{Sync: true, Mode: 0600})
+func (s *LocalStorage) Write(ctx context.Context, file []byte, path string, options ...WriteOptions) (string, error) {
+	var opts WriteOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	result := ""
+
+	if opts.Deduplicate {
+		path = utils.ContentKey(utils.StringOrDefault(opts.ContentPrefix, "content"), file, path)
+		result = path
+
+		if s.FileExists(path) {
+			return result, nil
+		}
+	}
+
+	if s.Locking {
+		lock, err := s.lock(ctx, path, false)
+		if err != nil {
+			return "", err
+		}
+		defer lock.Unlock()
+	}
+
+	return result, s.write(file, path, opts)
+}
+
+func (s *LocalStorage) write(file []byte, path string, opts WriteOptions) error {
 	filePath := s.getPath(path)
 
-	// split dir and file name based on the last "/"
-	dir := filePath[:strings.LastIndex(filePath, "/")]
+	sum := sha256.Sum256(file)
+	if err := checkChecksum(path, sum[:], opts.ChecksumSHA256); err != nil {
+		return err
+	}
+
+	tmp, err := createTemp(filePath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(file); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write file %q: %w", filePath, err)
+	}
+
+	if err := commitTemp(tmp, filePath, opts); err != nil {
+		return err
+	}
+
+	return saveChecksum(filePath, sum[:], s.storesChecksum(opts), opts)
+}
 
-	// Create the directory if it doesn't exist
-	err := os.MkdirAll(dir, 0755)
+// Update replaces the file at path with the result of fn, holding an exclusive lock from
+// the read to the write so updates from other processes aren't lost. fn gets nil if the
+// file doesn't exist. Update always locks, even without Locking set.
+//
+// Example:
+//
+//	err := storage.Update(ctx, "state/counters.json", func(current []byte) ([]byte, error) {
+//	    counters := map[string]int{}
+//	    json.Unmarshal(current, &counters)
+//	    counters["runs"]++
+//	    return json.Marshal(counters)
+//	})
+func (s *LocalStorage) Update(ctx context.Context, path string, fn func(current []byte) ([]byte, error)) error {
+	lock, err := s.lock(ctx, path, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to write directory %q: %v", s.BasePath, err)
+		return err
 	}
+	defer lock.Unlock()
 
-	err = os.WriteFile(filePath, file, 0644)
+	current, err := os.ReadFile(s.getPath(path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+
+	updated, err := fn(current)
 	if err != nil {
-		return "", fmt.Errorf("failed to write file %q: %v", filePath, err)
+		return err
 	}
-	return "", nil
+
+	return s.write(updated, path, WriteOptions{})
 }
 
 // DeleteFile removes a file from storage
@@ -95,6 +242,14 @@ func (s *LocalStorage) Delete(path string, options ...DeleteOptions) error {
 	// split dir and file name based on the last "/"
 	dir := filePath[:strings.LastIndex(filePath, "/")]
 
+	if s.Locking && !opts.Recursive {
+		lock, err := s.lock(context.Background(), path, false)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+	}
+
 	if opts.Recursive {
 		if err := os.RemoveAll(filePath); err != nil {
 			return fmt.Errorf("failed to delete directory %s: %w", filePath, err)
@@ -105,6 +260,10 @@ func (s *LocalStorage) Delete(path string, options ...DeleteOptions) error {
 		}
 	}
 
+	if err := saveChecksum(filePath, nil, false, WriteOptions{}); err != nil {
+		return err
+	}
+
 	// delete the directory if there are no files in it
 	if opts.DeleteDir {
 		//Check if the directory is empty
@@ -137,6 +296,98 @@ func (s *LocalStorage) GetFileSize(path string) (int64, error) {
 	return info.Size(), nil
 }
 
+// GetFileInfo returns the size, modification time and content type of a file
+func (s *LocalStorage) GetFileInfo(path string) (*FileInfo, error) {
+	info, err := os.Stat(s.getPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info for %s: %w", s.getPath(path), err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", s.getPath(path))
+	}
+
+	return fileInfo(path, info), nil
+}
+
+// List returns the files whose paths start with prefix, like a key prefix in S3: "reports/"
+// lists a directory recursively and "reports/2024" also matches "reports/2024-01.csv".
+// Lock files, checksum files and the temporary files of running writes are left out.
+//
+// Example:
+//
+//	files, err := storage.List("exports/")
+//	for _, file := range files {
+//	    fmt.Println(file.Path, file.Size) // exports/2024/01.csv 5120
+//	}
+func (s *LocalStorage) List(prefix string) ([]FileInfo, error) {
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i+1]
+	}
+
+	root := s.getPath(dir)
+	if dir == "" {
+		root = utils.StringOrDefault(s.BasePath, ".")
+	}
+
+	var files []FileInfo
+
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if filePath == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		path := dir + filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			// Descend only into directories that can hold matching files
+			inPrefix := strings.HasPrefix(path+"/", prefix) || strings.HasPrefix(prefix, path+"/")
+			if filePath != root && (entry.Name() == lockDir || !inPrefix) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasPrefix(path, prefix) || isTempFile(entry.Name()) || isChecksumFile(entry.Name()) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, *fileInfo(path, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files under %q: %w", prefix, err)
+	}
+
+	return files, nil
+}
+
+func fileInfo(path string, info fs.FileInfo) *FileInfo {
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &FileInfo{
+		Path:        path,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		ContentType: contentType,
+	}
+}
+
 func (s *LocalStorage) getPath(path string) string {
 	if s.BasePath == "" {
 		return path
@@ -152,3 +403,32 @@ func (s *LocalStorage) getPath(path string) string {
 
 	return basePath + "/" + path
 }
+
+// lockDir is where lock files are kept, out of the way of listings and watches
+const lockDir = ".locks"
+
+// lock takes the inter-process lock of the file at path
+func (s *LocalStorage) lock(ctx context.Context, path string, shared bool) (*utils.FileLock, error) {
+	filePath, err := filepath.Abs(s.getPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %q: %w", path, err)
+	}
+
+	// Lock files are named by a hash of the absolute path, so every process sharing the
+	// storage agrees on them however they refer to the file
+	lockPath := filepath.Join(s.lockRoot(), strconv.FormatUint(utils.HashFNV(filePath), 16)+".lock")
+
+	lock, err := utils.LockFile(ctx, lockPath, utils.FileLockOptions{
+		Shared:  shared,
+		Timeout: utils.DurationOrDefault(s.LockTimeout, 30*time.Second),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %q: %w", path, err)
+	}
+
+	return lock, nil
+}
+
+func (s *LocalStorage) lockRoot() string {
+	return filepath.Join(utils.StringOrDefault(s.BasePath, "."), lockDir)
+}
@@ -2,10 +2,17 @@ package filesystem
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/finch-technologies/go-utils/utils"
 )
 
 func TestInit(t *testing.T) {
@@ -518,3 +525,452 @@ func TestWrite(t *testing.T) {
 		t.Errorf("failed to delete file: %v", err)
 	}
 }
+
+func TestWriteDeduplicate(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := &LocalStorage{BasePath: tempDir}
+	ctx := context.Background()
+
+	content := []byte("statement content")
+
+	first, err := storage.Write(ctx, content, "statements/jan.pdf", WriteOptions{Deduplicate: true})
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if !strings.HasPrefix(first, "content/") || filepath.Ext(first) != ".pdf" {
+		t.Errorf("expected content-addressed .pdf path, got %s", first)
+	}
+
+	second, err := storage.Write(ctx, content, "statements/feb.pdf", WriteOptions{Deduplicate: true})
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if second != first {
+		t.Errorf("expected duplicate content to return %s, got %s", first, second)
+	}
+
+	other, err := storage.Write(ctx, []byte("other content"), "statements/mar.pdf", WriteOptions{Deduplicate: true})
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if other == first {
+		t.Error("expected different content to get a different path")
+	}
+
+	readContent, err := storage.Read(ctx, first)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if string(readContent) != string(content) {
+		t.Error("file content mismatch")
+	}
+}
+
+func TestLocking(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx := context.Background()
+
+	// Separate instances stand in for separate processes sharing the directory
+	writer := &LocalStorage{BasePath: tempDir, Locking: true}
+	reader := &LocalStorage{BasePath: tempDir, Locking: true, LockTimeout: 100 * time.Millisecond}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := writer.Update(ctx, "state/counter.txt", func(current []byte) ([]byte, error) {
+				count, _ := strconv.Atoi(string(current))
+				return []byte(strconv.Itoa(count + 1)), nil
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	content, err := reader.Read(ctx, "state/counter.txt")
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if string(content) != "20" {
+		t.Errorf("expected 20 updates, got %s", content)
+	}
+
+	err = writer.Update(ctx, "state/counter.txt", func(current []byte) ([]byte, error) {
+		if _, err := reader.Read(ctx, "state/counter.txt"); !errors.Is(err, utils.ErrLockTimeout) {
+			t.Errorf("expected read during update to time out, got %v", err)
+		}
+		return current, nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	files, err := os.ReadDir(filepath.Join(tempDir, "state"))
+	if err != nil || len(files) != 1 {
+		t.Errorf("expected lock files to stay out of the data directory, got %v", files)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tempDir := t.TempDir()
+	storage := &LocalStorage{BasePath: tempDir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := storage.Watch(ctx, "incoming", WatchOptions{
+		Patterns: []string{"*.csv"},
+		Debounce: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+
+	// Several writes to one file and a file that doesn't match the pattern
+	for i := 0; i < 3; i++ {
+		if _, err := storage.Write(ctx, []byte(strings.Repeat("x", i+1)), "incoming/batch/report.csv"); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	if _, err := storage.Write(ctx, []byte("ignored"), "incoming/report.tmp"); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Path != "incoming/batch/report.csv" || event.Op != WatchCreate {
+			t.Errorf("unexpected event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("expected a single debounced event, got %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := storage.Delete("incoming/batch/report.csv"); err != nil {
+		t.Fatalf("failed to delete file: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Path != "incoming/batch/report.csv" || event.Op != WatchRemove {
+			t.Errorf("unexpected event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for remove event")
+	}
+
+	cancel()
+
+	for range events {
+	}
+}
+
+func TestList(t *testing.T) {
+	storage := &LocalStorage{BasePath: t.TempDir(), Locking: true}
+	ctx := context.Background()
+
+	for _, path := range []string{"reports/2024-01.csv", "reports/2024/02.csv", "reports/2023/12.csv", "other.txt"} {
+		if _, err := storage.Write(ctx, []byte(path), path); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"other.txt", "reports/2023/12.csv", "reports/2024/02.csv", "reports/2024-01.csv"}},
+		{"reports/", []string{"reports/2023/12.csv", "reports/2024/02.csv", "reports/2024-01.csv"}},
+		{"reports/2024", []string{"reports/2024/02.csv", "reports/2024-01.csv"}},
+		{"reports/2024/", []string{"reports/2024/02.csv"}},
+		{"missing/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			files, err := storage.List(tt.prefix)
+			if err != nil {
+				t.Fatalf("List(%q) error = %v", tt.prefix, err)
+			}
+
+			var got []string
+			for _, file := range files {
+				got = append(got, file.Path)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFileInfo(t *testing.T) {
+	storage := &LocalStorage{BasePath: t.TempDir()}
+
+	if _, err := storage.Write(context.Background(), []byte(`{"a":1}`), "data/a.json"); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	info, err := storage.GetFileInfo("data/a.json")
+	if err != nil {
+		t.Fatalf("GetFileInfo() error = %v", err)
+	}
+
+	if info.Path != "data/a.json" || info.Size != 7 || info.ContentType != "application/json" || info.ModTime.IsZero() {
+		t.Errorf("GetFileInfo() = %+v", info)
+	}
+
+	if _, err := storage.GetFileInfo("data"); err == nil {
+		t.Error("expected an error for a directory")
+	}
+
+	if _, err := storage.GetFileInfo("data/missing.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GetFileInfo() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestReaderWriter(t *testing.T) {
+	storage := &LocalStorage{BasePath: t.TempDir(), Locking: true}
+	ctx := context.Background()
+
+	w, err := storage.Writer(ctx, "stream/out.txt")
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+
+	if _, err := w.Write([]byte("hello ")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// Nothing is visible until the writer is closed
+	if storage.FileExists("stream/out.txt") {
+		t.Error("expected the file not to exist before Close")
+	}
+	if files, _ := storage.List("stream/"); len(files) != 0 {
+		t.Errorf("expected the temporary file not to be listed, got %v", files)
+	}
+
+	if _, err := w.Write([]byte("world")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	r, err := storage.Reader(ctx, "stream/out.txt")
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "hello world" {
+		t.Errorf("read %q, %v, want %q", data, err, "hello world")
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(storage.BasePath, "stream"))
+	if len(entries) != 1 {
+		t.Errorf("expected only the written file in the directory, got %d entries", len(entries))
+	}
+
+	if _, err := storage.Reader(ctx, "stream/missing.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Reader() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestCopyMove(t *testing.T) {
+	for _, locking := range []bool{false, true} {
+		t.Run("locking="+strconv.FormatBool(locking), func(t *testing.T) {
+			storage := &LocalStorage{BasePath: t.TempDir(), Locking: locking}
+			ctx := context.Background()
+
+			if _, err := storage.Write(ctx, []byte("content"), "src/file.txt"); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+
+			if err := storage.Copy(ctx, "src/file.txt", "copies/deep/file.txt"); err != nil {
+				t.Fatalf("Copy() error = %v", err)
+			}
+
+			if err := storage.Move(ctx, "src/file.txt", "moved/file.txt"); err != nil {
+				t.Fatalf("Move() error = %v", err)
+			}
+
+			tests := []struct {
+				path       string
+				wantExists bool
+			}{
+				{"src/file.txt", false},
+				{"copies/deep/file.txt", true},
+				{"moved/file.txt", true},
+			}
+
+			for _, tt := range tests {
+				if exists := storage.FileExists(tt.path); exists != tt.wantExists {
+					t.Errorf("FileExists(%s) = %v, want %v", tt.path, exists, tt.wantExists)
+					continue
+				}
+
+				if tt.wantExists {
+					if data, err := storage.Read(ctx, tt.path); err != nil || string(data) != "content" {
+						t.Errorf("Read(%s) = %q, %v", tt.path, data, err)
+					}
+				}
+			}
+
+			if err := storage.Copy(ctx, "missing.txt", "copy.txt"); err == nil {
+				t.Error("expected an error copying a missing file")
+			}
+		})
+	}
+}
+
+func TestWriteOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  WriteOptions
+		existing bool
+		wantErr  error
+		wantData string
+		wantMode os.FileMode
+	}{
+		{"defaults", WriteOptions{}, false, nil, "new", 0644},
+		{"overwrite", WriteOptions{}, true, nil, "new", 0644},
+		{"mode and sync", WriteOptions{Mode: 0600, Sync: true}, true, nil, "new", 0600},
+		{"no overwrite of missing file", WriteOptions{NoOverwrite: true}, false, nil, "new", 0644},
+		{"no overwrite of existing file", WriteOptions{NoOverwrite: true}, true, os.ErrExist, "old", 0644},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &LocalStorage{BasePath: t.TempDir()}
+			ctx := context.Background()
+
+			if tt.existing {
+				if _, err := storage.Write(ctx, []byte("old"), "dir/file.txt"); err != nil {
+					t.Fatalf("failed to write existing file: %v", err)
+				}
+			}
+
+			_, err := storage.Write(ctx, []byte("new"), "dir/file.txt", tt.options)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Write() error = %v, want %v", err, tt.wantErr)
+			}
+
+			data, err := storage.Read(ctx, "dir/file.txt")
+			if err != nil || string(data) != tt.wantData {
+				t.Errorf("Read() = %q, %v, want %q", data, err, tt.wantData)
+			}
+
+			info, err := os.Stat(storage.getPath("dir/file.txt"))
+			if err != nil || info.Mode().Perm() != tt.wantMode {
+				t.Errorf("mode = %v, %v, want %v", info.Mode().Perm(), err, tt.wantMode)
+			}
+
+			// No temporary files are left behind, even after a failed write
+			entries, _ := os.ReadDir(filepath.Join(storage.BasePath, "dir"))
+			if len(entries) != 1 {
+				t.Errorf("expected only the file in the directory, got %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	storage := &LocalStorage{BasePath: t.TempDir(), Checksums: true}
+	ctx := context.Background()
+	content := []byte("archived document")
+
+	_, err := storage.Write(ctx, content, "docs/a.txt", WriteOptions{ChecksumSHA256: utils.ChecksumSHA256([]byte("other"))})
+	if !errors.Is(err, ErrChecksumMismatch) || storage.FileExists("docs/a.txt") {
+		t.Fatalf("Write() with a wrong checksum error = %v, want ErrChecksumMismatch and no file", err)
+	}
+
+	if _, err := storage.Write(ctx, content, "docs/a.txt"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	w, _ := storage.Writer(ctx, "docs/b.txt", WriteOptions{ChecksumSHA256: utils.ChecksumSHA256(content)})
+	io.WriteString(w, string(content))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Writer.Close() error = %v", err)
+	}
+
+	if err := storage.Copy(ctx, "docs/b.txt", "docs/c.txt"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if err := storage.Move(ctx, "docs/c.txt", "docs/d.txt"); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	files, _ := storage.List("docs/")
+	if len(files) != 3 {
+		t.Errorf("List() = %v, want the three files without their checksums", files)
+	}
+
+	// Corrupt every file behind the storage's back
+	for _, path := range []string{"docs/a.txt", "docs/b.txt", "docs/d.txt"} {
+		if err := os.WriteFile(storage.getPath(path), []byte("archived d0cument"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		read func() error
+	}{
+		{"read", func() error { _, err := storage.Read(ctx, "docs/a.txt"); return err }},
+		{"reader", func() error {
+			r, err := storage.Reader(ctx, "docs/b.txt")
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			_, err = io.ReadAll(r)
+			return err
+		}},
+		{"copy", func() error { return storage.Copy(ctx, "docs/d.txt", "docs/e.txt") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.read(); !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("error = %v, want ErrChecksumMismatch", err)
+			}
+		})
+	}
+
+	// Rewriting a file without checksums drops its stale checksum
+	storage.Checksums = false
+	if _, err := storage.Write(ctx, []byte("rewritten"), "docs/a.txt"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if data, err := storage.Read(ctx, "docs/a.txt"); err != nil || string(data) != "rewritten" {
+		t.Errorf("Read() = %q, %v after rewrite", data, err)
+	}
+
+	if err := storage.Delete("docs/d.txt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(checksumPath(storage.getPath("docs/d.txt"))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected Delete to remove the checksum, got %v", err)
+	}
+}
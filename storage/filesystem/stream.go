@@ -0,0 +1,367 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// tempSuffix marks the temporary files of writes in progress
+const tempSuffix = ".tmp"
+
+// isTempFile reports whether name is the temporary file of a write in progress
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, tempSuffix)
+}
+
+// Reader opens the file at path for streaming reads. With Locking set, a shared lock is
+// held until the reader is closed. If the file has a checksum that the content doesn't
+// match, the final read fails with ErrChecksumMismatch instead of io.EOF.
+//
+// Example:
+//
+//	r, err := storage.Reader(ctx, "exports/2024.csv")
+//	defer r.Close()
+//	_, err = io.Copy(w, r)
+func (s *LocalStorage) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	var lock *utils.FileLock
+
+	if s.Locking {
+		var err error
+		if lock, err = s.lock(ctx, path, true); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(s.getPath(path))
+	if err != nil {
+		if lock != nil {
+			lock.Unlock()
+		}
+		return nil, fmt.Errorf("failed to open source file %q: %w", path, err)
+	}
+
+	reader := &lockedReader{File: file, lock: lock}
+
+	sum, err := readChecksum(s.getPath(path))
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if sum != nil {
+		return utils.NewChecksumReader(reader, sha256.New(), sum), nil
+	}
+
+	return reader, nil
+}
+
+// Writer opens a writer for the file at path. Data goes to a temporary file that replaces
+// the file when the writer is closed, so readers never see a partial file. With Locking
+// set, an exclusive lock is held until the writer is closed. Deduplicate isn't supported.
+// With ChecksumSHA256 set, Close fails with ErrChecksumMismatch and discards the file if the
+// content doesn't match.
+//
+// Example:
+//
+//	w, err := storage.Writer(ctx, "exports/2024.csv")
+//	_, err = io.Copy(w, rows)
+//	err = w.Close()
+func (s *LocalStorage) Writer(ctx context.Context, path string, options ...WriteOptions) (io.WriteCloser, error) {
+	var opts WriteOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Deduplicate {
+		return nil, errors.New("deduplicated writes aren't supported by Writer")
+	}
+
+	var lock *utils.FileLock
+
+	if s.Locking {
+		var err error
+		if lock, err = s.lock(ctx, path, false); err != nil {
+			return nil, err
+		}
+	}
+
+	filePath := s.getPath(path)
+
+	tmp, err := createTemp(filePath)
+	if err != nil {
+		if lock != nil {
+			lock.Unlock()
+		}
+		return nil, err
+	}
+
+	return &fileWriter{file: tmp, path: filePath, opts: opts, lock: lock, hash: sha256.New(), storeChecksum: s.storesChecksum(opts)}, nil
+}
+
+// createTemp creates the temporary file a write of filePath goes through, next to it so the
+// final rename stays on one filesystem
+func createTemp(filePath string) (*os.File, error) {
+	dir := filepath.Dir(filePath)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to write directory %q: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filePath)+".*"+tempSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %q: %w", filePath, err)
+	}
+
+	return tmp, nil
+}
+
+// lockedReader releases the read lock of a file when it is closed
+type lockedReader struct {
+	*os.File
+	lock *utils.FileLock
+}
+
+func (r *lockedReader) Close() error {
+	err := r.File.Close()
+	if r.lock != nil {
+		r.lock.Unlock()
+	}
+	return err
+}
+
+// fileWriter writes to a temporary file that is moved to path on Close
+type fileWriter struct {
+	file          *os.File
+	path          string
+	opts          WriteOptions
+	lock          *utils.FileLock
+	hash          hash.Hash // SHA-256 of the content written so far
+	storeChecksum bool
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	return n, err
+}
+
+func (w *fileWriter) Close() error {
+	if w.lock != nil {
+		defer w.lock.Unlock()
+	}
+
+	sum := w.hash.Sum(nil)
+
+	if err := checkChecksum(w.path, sum, w.opts.ChecksumSHA256); err != nil {
+		w.file.Close()
+		os.Remove(w.file.Name())
+		return err
+	}
+
+	if err := commitTemp(w.file, w.path, w.opts); err != nil {
+		return err
+	}
+
+	return saveChecksum(w.path, sum, w.storeChecksum, w.opts)
+}
+
+// commitTemp moves a fully written temporary file into place at filePath and removes it.
+// Without NoOverwrite it is renamed over any existing file; with it, it is hard linked, which
+// fails if filePath exists.
+func commitTemp(tmp *os.File, filePath string, opts WriteOptions) error {
+	defer os.Remove(tmp.Name())
+
+	if opts.Sync {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to sync file %q: %w", filePath, err)
+		}
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", filePath, err)
+	}
+
+	mode := opts.Mode
+	if mode == 0 {
+		mode = 0644
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", filePath, err)
+	}
+
+	if opts.NoOverwrite {
+		if err := os.Link(tmp.Name(), filePath); err != nil {
+			return fmt.Errorf("failed to write file %q: %w", filePath, err)
+		}
+	} else if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", filePath, err)
+	}
+
+	if opts.Sync {
+		// The rename itself is only durable once the directory is synced
+		dir, err := os.Open(filepath.Dir(filePath))
+		if err != nil {
+			return fmt.Errorf("failed to sync directory of %q: %w", filePath, err)
+		}
+		defer dir.Close()
+
+		if err := dir.Sync(); err != nil {
+			return fmt.Errorf("failed to sync directory of %q: %w", filePath, err)
+		}
+	}
+
+	return nil
+}
+
+// Copy copies the file at src to dst, replacing dst atomically like Writer does
+//
+// Example:
+//
+//	err := storage.Copy(ctx, "uploads/report.pdf", "reports/2024/report.pdf")
+func (s *LocalStorage) Copy(ctx context.Context, src, dst string) error {
+	if s.Locking {
+		unlock, err := s.lockPair(ctx, src, dst)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	return s.copy(src, dst)
+}
+
+// Move moves the file at src to dst, replacing dst. Within one filesystem this is a rename;
+// across filesystems the file is copied and src deleted.
+//
+// Example:
+//
+//	err := storage.Move(ctx, "incoming/batch.csv", "processed/batch.csv")
+func (s *LocalStorage) Move(ctx context.Context, src, dst string) error {
+	if s.Locking {
+		unlock, err := s.lockPair(ctx, src, dst)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	dstPath := s.getPath(dst)
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return fmt.Errorf("failed to write directory %q: %w", filepath.Dir(dstPath), err)
+	}
+
+	err := os.Rename(s.getPath(src), dstPath)
+	if err == nil {
+		return moveChecksum(s.getPath(src), dstPath)
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("failed to move %q to %q: %w", src, dst, err)
+	}
+
+	if err := s.copy(src, dst); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.getPath(src)); err != nil {
+		return fmt.Errorf("failed to delete %q after copying it: %w", src, err)
+	}
+
+	return saveChecksum(s.getPath(src), nil, false, WriteOptions{})
+}
+
+// moveChecksum moves the checksum of a renamed file along with it
+func moveChecksum(srcPath, dstPath string) error {
+	err := os.Rename(checksumPath(srcPath), checksumPath(dstPath))
+	if errors.Is(err, os.ErrNotExist) {
+		// The file has no checksum, so dst mustn't keep the one of a file it replaced
+		return saveChecksum(dstPath, nil, false, WriteOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move checksum of %q: %w", srcPath, err)
+	}
+
+	return nil
+}
+
+// copy copies src to dst, verifying src against its checksum and keeping one for dst if
+// src has one or Checksums is set
+func (s *LocalStorage) copy(src, dst string) error {
+	source, err := os.Open(s.getPath(src))
+	if err != nil {
+		return fmt.Errorf("failed to open source file %q: %w", src, err)
+	}
+	defer source.Close()
+
+	want, err := readChecksum(s.getPath(src))
+	if err != nil {
+		return err
+	}
+
+	tmp, err := createTemp(s.getPath(dst))
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+
+	if _, err := io.Copy(tmp, io.TeeReader(source, h)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to copy %q to %q: %w", src, dst, err)
+	}
+
+	sum := h.Sum(nil)
+
+	if want != nil && !bytes.Equal(sum, want) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("content of %q doesn't match its checksum: %w", src, ErrChecksumMismatch)
+	}
+
+	if err := commitTemp(tmp, s.getPath(dst), WriteOptions{}); err != nil {
+		return err
+	}
+
+	return saveChecksum(s.getPath(dst), sum, want != nil || s.Checksums, WriteOptions{})
+}
+
+// lockPair takes the exclusive locks of two files in a fixed order, so processes working on
+// the same pair in opposite directions can't deadlock
+func (s *LocalStorage) lockPair(ctx context.Context, a, b string) (func(), error) {
+	if s.getPath(a) == s.getPath(b) {
+		return nil, fmt.Errorf("%q and %q are the same file", a, b)
+	}
+	if b < a {
+		a, b = b, a
+	}
+
+	first, err := s.lock(ctx, a, false)
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := s.lock(ctx, b, false)
+	if err != nil {
+		first.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		second.Unlock()
+		first.Unlock()
+	}, nil
+}
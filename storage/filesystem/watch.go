@@ -0,0 +1,309 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+	"github.com/fsnotify/fsnotify"
+)
+
+type WatchOp string
+
+const (
+	WatchCreate WatchOp = "create"
+	WatchWrite  WatchOp = "write"
+	WatchRemove WatchOp = "remove"
+)
+
+// WatchEvent is a change to a file under a watched prefix
+type WatchEvent struct {
+	Path string // Path of the file, relative to BasePath like the other LocalStorage methods
+	Op   WatchOp
+}
+
+type WatchOptions struct {
+	// Glob patterns a file must match, e.g. "*.csv". Patterns without a "/" are matched
+	// against the file name, others against the path relative to the watched prefix.
+	// Matches every file when empty.
+	Patterns   []string
+	Debounce   time.Duration // Quiet period before a file's events are emitted (default 200ms)
+	BufferSize int           // Size of the event channel (default 100)
+}
+
+// Watch emits an event when a file under prefix is created, written or removed, until ctx
+// is done. Subdirectories are watched as well, including ones created later. Events for the
+// same file within the debounce period are merged into one, so a file written in several
+// chunks is reported once.
+//
+// Example:
+//
+//	events, err := storage.Watch(ctx, "incoming", filesystem.WatchOptions{Patterns: []string{"*.csv"}})
+//	for event := range events {
+//	    if event.Op != filesystem.WatchRemove {
+//	        process(event.Path)
+//	    }
+//	}
+func (s *LocalStorage) Watch(ctx context.Context, prefix string, options ...WatchOptions) (<-chan WatchEvent, error) {
+	var opts WatchOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Debounce = utils.DurationOrDefault(opts.Debounce, 200*time.Millisecond)
+	opts.BufferSize = utils.IntOrDefault(opts.BufferSize, 100)
+
+	for _, pattern := range opts.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid watch pattern %q: %w", pattern, err)
+		}
+	}
+
+	root := strings.TrimSuffix(s.getPath(prefix), "/")
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create watch directory %q: %w", root, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	w := &fileWatcher{
+		storage: s,
+		root:    root,
+		opts:    opts,
+		watcher: watcher,
+		events:  make(chan WatchEvent, opts.BufferSize),
+		pending: map[string]*pendingEvent{},
+	}
+
+	if err := w.addDir(root, false); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go w.run(ctx)
+
+	return w.events, nil
+}
+
+type pendingEvent struct {
+	op  WatchOp
+	due time.Time
+}
+
+type fileWatcher struct {
+	storage *LocalStorage
+	root    string
+	opts    WatchOptions
+	watcher *fsnotify.Watcher
+	events  chan WatchEvent
+	pending map[string]*pendingEvent
+}
+
+func (w *fileWatcher) run(ctx context.Context) {
+	defer close(w.events)
+	defer w.watcher.Close()
+
+	timer := time.NewTimer(w.opts.Debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+			w.resetTimer(timer)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("File watcher error under %s: %v", w.root, err)
+
+		case <-timer.C:
+			if !w.emitDue(ctx) {
+				return
+			}
+			w.resetTimer(timer)
+		}
+	}
+}
+
+func (w *fileWatcher) handle(event fsnotify.Event) {
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Stat(event.Name)
+		if err != nil {
+			return
+		}
+
+		if info.IsDir() {
+			// Files can land in a new directory before it is watched, so report what is
+			// already there
+			if err := w.addDir(event.Name, true); err != nil {
+				log.Errorf("Failed to watch directory %s: %v", event.Name, err)
+			}
+			return
+		}
+
+		w.queue(event.Name, WatchCreate)
+
+	case event.Has(fsnotify.Write):
+		w.queue(event.Name, WatchWrite)
+
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		w.queue(event.Name, WatchRemove)
+	}
+}
+
+// addDir watches dir and its subdirectories, optionally queueing create events for the
+// files found in them
+func (w *fileWatcher) addDir(dir string, reportFiles bool) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && w.isLockDir(path) {
+			return filepath.SkipDir
+		}
+
+		if !entry.IsDir() {
+			if reportFiles {
+				w.queue(path, WatchCreate)
+			}
+			return nil
+		}
+
+		if err := w.watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch directory %q: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+// queue records an event for a file, merging it with any pending event for the same file
+func (w *fileWatcher) queue(path string, op WatchOp) {
+	// Writes land through temporary files renamed into place, reported as a create
+	if w.isLockDir(filepath.Dir(path)) || isTempFile(filepath.Base(path)) || isChecksumFile(filepath.Base(path)) || !w.matches(path) {
+		return
+	}
+
+	due := time.Now().Add(w.opts.Debounce)
+
+	if existing, ok := w.pending[path]; ok {
+		// A file created and then written within the debounce period is still new
+		if !(existing.op == WatchCreate && op == WatchWrite) {
+			existing.op = op
+		}
+		existing.due = due
+		return
+	}
+
+	w.pending[path] = &pendingEvent{op: op, due: due}
+}
+
+// isLockDir reports whether dir holds the storage's lock files
+func (w *fileWatcher) isLockDir(dir string) bool {
+	lockRoot, err := filepath.Abs(w.storage.lockRoot())
+	if err != nil {
+		return false
+	}
+
+	dir, err = filepath.Abs(dir)
+	return err == nil && dir == lockRoot
+}
+
+func (w *fileWatcher) matches(path string) bool {
+	if len(w.opts.Patterns) == 0 {
+		return true
+	}
+
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range w.opts.Patterns {
+		target := rel
+		if !strings.Contains(pattern, "/") {
+			target = filepath.Base(path)
+		}
+
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// emitDue sends every pending event whose debounce period has passed. It returns false if
+// ctx is done before they could be sent.
+func (w *fileWatcher) emitDue(ctx context.Context) bool {
+	now := time.Now()
+
+	for path, pending := range w.pending {
+		if pending.due.After(now) {
+			continue
+		}
+
+		select {
+		case w.events <- WatchEvent{Path: w.storagePath(path), Op: pending.op}:
+		case <-ctx.Done():
+			return false
+		}
+
+		delete(w.pending, path)
+	}
+
+	return true
+}
+
+// resetTimer schedules the timer for the earliest pending event
+func (w *fileWatcher) resetTimer(timer *time.Timer) {
+	var next time.Time
+
+	for _, pending := range w.pending {
+		if next.IsZero() || pending.due.Before(next) {
+			next = pending.due
+		}
+	}
+
+	timer.Stop()
+
+	if !next.IsZero() {
+		timer.Reset(time.Until(next))
+	}
+}
+
+// storagePath converts an absolute path back to one relative to BasePath
+func (w *fileWatcher) storagePath(path string) string {
+	base := strings.TrimSuffix(w.storage.BasePath, "/")
+	if base == "" {
+		return path
+	}
+
+	rel, err := filepath.Rel(base, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,73 @@
+// Package gcs stores files in Google Cloud Storage through its XML API, which is compatible
+// with S3. Requests are signed with an HMAC key of a service account, so the client is an
+// s3.Client pointed at Cloud Storage and has the same methods.
+package gcs
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/finch-technologies/go-utils/storage/s3"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// DefaultEndpoint is the XML API endpoint of Cloud Storage
+const DefaultEndpoint = "https://storage.googleapis.com"
+
+// Config configures a Cloud Storage client
+type Config struct {
+	Bucket    string
+	KeyPrefix string
+	AccessId  string // HMAC key access ID (default GCS_HMAC_ACCESS_ID)
+	Secret    string // HMAC key secret (default GCS_HMAC_SECRET)
+	Endpoint  string // XML API endpoint (default DefaultEndpoint)
+
+	MaxRetries int           // Retries of a failed request (default 2, -1 disables retries)
+	Timeout    time.Duration // Limit per request attempt (default none)
+}
+
+// Client is an s3.Client talking to Cloud Storage. Upload, Download, FileExists,
+// DeleteFile, GeneratePresignedURL, List, Copy and the streaming methods work as they do
+// on S3. The XML API has no batch delete or object tags, so DeleteFiles, DeletePrefix and
+// UploadOptions.ExpiresAt aren't supported; use bucket lifecycle rules to expire objects.
+type Client struct {
+	*s3.Client
+}
+
+// New creates a Cloud Storage client
+//
+// Example:
+//
+//	client, err := gcs.New(gcs.Config{Bucket: "documents", AccessId: id, Secret: secret})
+//	_, err = client.Upload(ctx, data, "reports/2024.pdf")
+//	url, err := client.GeneratePresignedURL(ctx, "reports/2024.pdf", 30)
+func New(config ...Config) (*Client, error) {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	cfg.AccessId = utils.StringOrDefault(cfg.AccessId, os.Getenv("GCS_HMAC_ACCESS_ID"))
+	cfg.Secret = utils.StringOrDefault(cfg.Secret, os.Getenv("GCS_HMAC_SECRET"))
+
+	if cfg.AccessId == "" || cfg.Secret == "" {
+		return nil, errors.New("an HMAC access ID and secret are required")
+	}
+
+	client, err := s3.New(s3.Config{
+		Bucket:          cfg.Bucket,
+		KeyPrefix:       cfg.KeyPrefix,
+		Region:          "auto",
+		Endpoint:        utils.StringOrDefault(cfg.Endpoint, DefaultEndpoint),
+		AccessKeyId:     cfg.AccessId,
+		SecretAccessKey: cfg.Secret,
+		MaxRetries:      cfg.MaxRetries,
+		Timeout:         cfg.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{Client: client}, nil
+}
@@ -0,0 +1,214 @@
+// Package memory keeps files in process memory. It stands in for the S3 and filesystem
+// backends in tests and local runs that shouldn't need a writable disk or AWS credentials.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Path        string
+	Size        int64
+	ModTime     time.Time
+	ContentType string // Inferred from the extension
+}
+
+type file struct {
+	data    []byte
+	modTime time.Time
+}
+
+// Storage is a thread-safe map of paths to files. Missing files fail with errors matching
+// fs.ErrNotExist, as with the filesystem backend.
+type Storage struct {
+	mu    sync.RWMutex
+	files map[string]file
+}
+
+// New returns an empty Storage
+//
+// Example:
+//
+//	files := memory.New()
+//	err := files.Write(ctx, []byte("a,b"), "exports/a.csv")
+func New() *Storage {
+	return &Storage{files: map[string]file{}}
+}
+
+// Write stores a copy of data at path, replacing any existing file
+func (s *Storage) Write(_ context.Context, data []byte, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[path] = file{data: bytes.Clone(data), modTime: time.Now()}
+
+	return nil
+}
+
+// Read returns a copy of the content of the file at path
+func (s *Storage) Read(_ context.Context, path string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.files[path]
+	if !ok {
+		return nil, notExist(path)
+	}
+
+	return bytes.Clone(f.data), nil
+}
+
+// FileExists reports whether a file is stored at path
+func (s *Storage) FileExists(path string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.files[path]
+	return ok
+}
+
+// Delete removes the file at path
+func (s *Storage) Delete(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[path]; !ok {
+		return notExist(path)
+	}
+
+	delete(s.files, path)
+
+	return nil
+}
+
+// List returns the files whose paths start with prefix, sorted by path
+func (s *Storage) List(prefix string) []FileInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var files []FileInfo
+	for path, f := range s.files {
+		if strings.HasPrefix(path, prefix) {
+			files = append(files, fileInfo(path, f))
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files
+}
+
+// GetFileInfo returns the size, modification time and content type of the file at path
+func (s *Storage) GetFileInfo(path string) (*FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.files[path]
+	if !ok {
+		return nil, notExist(path)
+	}
+
+	info := fileInfo(path, f)
+	return &info, nil
+}
+
+// Reader returns a reader over the content the file at path has when Reader is called
+func (s *Storage) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	data, err := s.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Writer returns a writer whose content is stored at path when it is closed
+func (s *Storage) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	return &writer{ctx: ctx, storage: s, path: path}, nil
+}
+
+// Copy copies the file at src to dst
+func (s *Storage) Copy(_ context.Context, src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[src]
+	if !ok {
+		return notExist(src)
+	}
+
+	s.files[dst] = file{data: f.data, modTime: time.Now()}
+
+	return nil
+}
+
+// Move moves the file at src to dst
+func (s *Storage) Move(_ context.Context, src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[src]
+	if !ok {
+		return notExist(src)
+	}
+
+	delete(s.files, src)
+	s.files[dst] = f
+
+	return nil
+}
+
+// Clear removes every file
+func (s *Storage) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files = map[string]file{}
+}
+
+// writer buffers a file until it is closed
+type writer struct {
+	ctx     context.Context
+	storage *Storage
+	path    string
+	buf     bytes.Buffer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *writer) Close() error {
+	return w.storage.Write(w.ctx, w.buf.Bytes(), w.path)
+}
+
+func fileInfo(path string, f file) FileInfo {
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return FileInfo{
+		Path:        path,
+		Size:        int64(len(f.data)),
+		ModTime:     f.modTime,
+		ContentType: contentType,
+	}
+}
+
+func notExist(path string) error {
+	return fmt.Errorf("file %q: %w", path, fs.ErrNotExist)
+}
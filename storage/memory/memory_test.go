@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	data := []byte("a,b")
+	if err := s.Write(ctx, data, "exports/a.csv"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// The stored file doesn't share memory with the caller's slice
+	data[0] = 'x'
+	if got, _ := s.Read(ctx, "exports/a.csv"); string(got) != "a,b" {
+		t.Errorf("Read() = %q, want %q", got, "a,b")
+	}
+
+	w, _ := s.Writer(ctx, "exports/b.json")
+	io.WriteString(w, `{"b":1}`)
+	if s.FileExists("exports/b.json") {
+		t.Error("expected the file not to exist before Close")
+	}
+	w.Close()
+
+	if err := s.Copy(ctx, "exports/a.csv", "archive/a.csv"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if err := s.Move(ctx, "archive/a.csv", "archive/moved.csv"); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"archive/moved.csv", "exports/a.csv", "exports/b.json"}},
+		{"exports/", []string{"exports/a.csv", "exports/b.json"}},
+		{"missing/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("list "+tt.prefix, func(t *testing.T) {
+			files := s.List(tt.prefix)
+			if len(files) != len(tt.want) {
+				t.Fatalf("List(%q) = %v, want %v", tt.prefix, files, tt.want)
+			}
+			for i, file := range files {
+				if file.Path != tt.want[i] {
+					t.Errorf("List(%q)[%d] = %s, want %s", tt.prefix, i, file.Path, tt.want[i])
+				}
+			}
+		})
+	}
+
+	info, err := s.GetFileInfo("exports/b.json")
+	if err != nil || info.Size != 7 || info.ContentType != "application/json" || info.ModTime.IsZero() {
+		t.Errorf("GetFileInfo() = %+v, %v", info, err)
+	}
+
+	r, _ := s.Reader(ctx, "exports/b.json")
+	if got, _ := io.ReadAll(r); string(got) != `{"b":1}` {
+		t.Errorf("Reader() read %q", got)
+	}
+
+	if err := s.Delete("exports/a.csv"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	for _, missing := range []func() error{
+		func() error { _, err := s.Read(ctx, "exports/a.csv"); return err },
+		func() error { return s.Delete("exports/a.csv") },
+		func() error { return s.Move(ctx, "exports/a.csv", "x") },
+		func() error { _, err := s.GetFileInfo("archive/a.csv"); return err },
+	} {
+		if err := missing(); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("error = %v, want fs.ErrNotExist", err)
+		}
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := "file-" + strconv.Itoa(i%5)
+			s.Write(ctx, []byte(path), path)
+			s.Read(ctx, path)
+			s.List("")
+		}()
+	}
+	wg.Wait()
+
+	if files := s.List(""); len(files) != 5 {
+		t.Errorf("List() returned %d files, want 5", len(files))
+	}
+}
@@ -0,0 +1,152 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrObjectArchived is returned when downloading an object in an archive storage class that
+// hasn't been restored. Use errors.As with *ArchivedError for the restore state.
+var ErrObjectArchived = errors.New("object is archived")
+
+// ArchivedError describes an archived object that can't be downloaded yet
+type ArchivedError struct {
+	Key               string
+	StorageClass      string
+	RestoreInProgress bool          // A restore request is running
+	RetryAfter        time.Duration // Estimated time until a running restore completes, 0 if none is running
+}
+
+func (e *ArchivedError) Error() string {
+	if e.RestoreInProgress {
+		return fmt.Sprintf("object %s is archived in %s, restore in progress, retry after %s", e.Key, e.StorageClass, e.RetryAfter)
+	}
+	return fmt.Sprintf("object %s is archived in %s and must be restored", e.Key, e.StorageClass)
+}
+
+func (e *ArchivedError) Unwrap() error {
+	return ErrObjectArchived
+}
+
+// DownloadOptions configures Download
+type DownloadOptions struct {
+	Restore        bool           // Start a restore when the object is archived
+	RestoreOptions RestoreOptions // Options for restores started by Download
+}
+
+// RestoreOptions configures restoring an archived object
+type RestoreOptions struct {
+	Tier s3types.Tier // Retrieval tier (default Standard)
+	Days int          // Days the restored copy stays available (default 1)
+}
+
+// restoreTimes are the documented upper bounds of retrieval times per storage class and tier
+var restoreTimes = map[s3types.StorageClass]map[s3types.Tier]time.Duration{
+	s3types.StorageClassGlacier: {
+		s3types.TierExpedited: 5 * time.Minute,
+		s3types.TierStandard:  5 * time.Hour,
+		s3types.TierBulk:      12 * time.Hour,
+	},
+	s3types.StorageClassDeepArchive: {
+		s3types.TierStandard: 12 * time.Hour,
+		s3types.TierBulk:     48 * time.Hour,
+	},
+}
+
+var restoreHeader = regexp.MustCompile(`ongoing-request="(true|false)"(?:,\s*expiry-date="([^"]+)")?`)
+
+// isArchived reports whether objects in storageClass need restoring before they can be read
+func isArchived(storageClass string) bool {
+	switch s3types.StorageClass(storageClass) {
+	case s3types.StorageClassGlacier, s3types.StorageClassDeepArchive:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRestore parses the x-amz-restore header of a HeadObject response
+func parseRestore(header string) (inProgress bool, restoredUntil *time.Time) {
+	match := restoreHeader.FindStringSubmatch(header)
+	if match == nil {
+		return false, nil
+	}
+
+	if match[2] != "" {
+		if expiry, err := time.Parse(http.TimeFormat, match[2]); err == nil {
+			restoredUntil = &expiry
+		}
+	}
+
+	return match[1] == "true", restoredUntil
+}
+
+// RestoreObject starts restoring an archived object. It returns nil if a restore is already
+// in progress.
+//
+// Example:
+//
+//	err := client.RestoreObject(ctx, "exports/2024.csv", s3.RestoreOptions{Tier: s3types.TierBulk, Days: 7})
+func (s *Client) RestoreObject(ctx context.Context, key string, options ...RestoreOptions) error {
+	var opts RestoreOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	_, err := s.s3Client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
+		RestoreRequest: &s3types.RestoreRequest{
+			Days: aws.Int32(int32(utils.IntOrDefault(opts.Days, 1))),
+			GlacierJobParameters: &s3types.GlacierJobParameters{
+				Tier: s3types.Tier(utils.StringOrDefault(string(opts.Tier), string(s3types.TierStandard))),
+			},
+		},
+	})
+	if err != nil && !strings.Contains(err.Error(), "RestoreAlreadyInProgress") {
+		return fmt.Errorf("failed to restore object from S3: %w", err)
+	}
+
+	return nil
+}
+
+// archivedError builds the error for a download of an archived object, starting a restore
+// when opts asks for one
+func (s *Client) archivedError(ctx context.Context, key string, opts DownloadOptions) error {
+	head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get restore state from S3: %w", err)
+	}
+
+	archived := &ArchivedError{Key: key, StorageClass: string(head.StorageClass)}
+	archived.RestoreInProgress, _ = parseRestore(aws.ToString(head.Restore))
+
+	tier := s3types.Tier(utils.StringOrDefault(string(opts.RestoreOptions.Tier), string(s3types.TierStandard)))
+
+	if !archived.RestoreInProgress && opts.Restore {
+		if err := s.RestoreObject(ctx, key, opts.RestoreOptions); err != nil {
+			return err
+		}
+		archived.RestoreInProgress = true
+	}
+
+	if archived.RestoreInProgress {
+		archived.RetryAfter = restoreTimes[head.StorageClass][tier]
+	}
+
+	return archived
+}
@@ -0,0 +1,86 @@
+package s3
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrChecksumMismatch is returned when an upload doesn't match UploadOptions.ChecksumSHA256
+// or downloaded content doesn't match the checksum stored with the object
+var ErrChecksumMismatch = utils.ErrChecksumMismatch
+
+// checksumMetadataKey is the object metadata holding the hex SHA-256 of uploaded content
+const checksumMetadataKey = "sha256"
+
+var md5ETag = regexp.MustCompile(`^"?([0-9a-f]{32})"?$`)
+
+// checkChecksum fails with ErrChecksumMismatch if want is set and isn't the hex of sum
+func checkChecksum(key string, sum []byte, want string) error {
+	if want != "" && !strings.EqualFold(want, hex.EncodeToString(sum)) {
+		return fmt.Errorf("content of %s doesn't match the expected checksum %s: %w", key, want, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// setChecksum stores the SHA-256 of an object with it: S3 verifies it on receipt and
+// downloads verify against the copy kept in the metadata
+func setChecksum(input *s3.PutObjectInput, sum []byte) {
+	metadata := make(map[string]string, len(input.Metadata)+1)
+	for k, v := range input.Metadata {
+		metadata[k] = v
+	}
+	metadata[checksumMetadataKey] = hex.EncodeToString(sum)
+
+	input.Metadata = metadata
+	input.ChecksumSHA256 = aws.String(base64.StdEncoding.EncodeToString(sum))
+}
+
+// objectChecksum returns the hash to verify an object's content with and the sum it must
+// have, or a nil hash if the object has nothing to verify against. The SHA-256 stored by
+// Upload is preferred; objects uploaded without one fall back to their ETag, which is the
+// MD5 of the content for single part uploads that aren't encrypted with KMS or customer keys.
+func objectChecksum(metadata map[string]string, etag *string, sse s3types.ServerSideEncryption, customerKey *string) (hash.Hash, []byte) {
+	if sum, err := hex.DecodeString(metadata[checksumMetadataKey]); err == nil && len(sum) == sha256.Size {
+		return sha256.New(), sum
+	}
+
+	if sse == s3types.ServerSideEncryptionAwsKms || sse == s3types.ServerSideEncryptionAwsKmsDsse || customerKey != nil {
+		return nil, nil
+	}
+
+	match := md5ETag.FindStringSubmatch(strings.ToLower(aws.ToString(etag)))
+	if match == nil {
+		return nil, nil
+	}
+
+	sum, _ := hex.DecodeString(match[1])
+	return md5.New(), sum
+}
+
+// verifyingReader wraps body to fail with ErrChecksumMismatch if the content read doesn't
+// match the checksum of the object
+func verifyingReader(body io.ReadCloser, output *s3.GetObjectOutput) io.ReadCloser {
+	h, want := objectChecksum(output.Metadata, output.ETag, output.ServerSideEncryption, output.SSECustomerAlgorithm)
+	if h == nil || output.ContentRange != nil {
+		return body
+	}
+
+	return utils.NewChecksumReader(body, h, want)
+}
+
+// checksumError reports a checksum mismatch of key
+func checksumError(key string) error {
+	return fmt.Errorf("content of %s doesn't match its checksum: %w", key, ErrChecksumMismatch)
+}
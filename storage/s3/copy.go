@@ -0,0 +1,173 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// maxCopyObjectSize is the largest object CopyObject copies in one request
+	maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+	// copyPartSize is the part size of multipart copies of larger objects
+	copyPartSize = 512 * 1024 * 1024
+)
+
+// CopyOptions configures Copy and Move
+type CopyOptions struct {
+	SourceBucket string // Bucket to copy from (default the client's bucket)
+	DestBucket   string // Bucket to copy to (default the client's bucket)
+}
+
+// bucketKey resolves a key in bucket, applying the key prefix only in the client's own bucket
+// as GetS3FileInfo does
+func (s *Client) bucketKey(bucket, key string) (string, string) {
+	if bucket == "" || bucket == s.Bucket {
+		return s.Bucket, s.fullKey(key)
+	}
+	return bucket, key
+}
+
+// Copy copies srcKey to dstKey inside S3, without downloading the object. Metadata, content
+// type and tags are copied with it. Keys in the client's bucket get the key prefix; keys in
+// the other buckets of options are used as they are.
+//
+// Example:
+//
+//	err := client.Copy(ctx, "uploads/report.pdf", "reports/2024/report.pdf")
+//
+//	// Into another bucket
+//	err := client.Copy(ctx, "uploads/report.pdf", "report.pdf", s3.CopyOptions{DestBucket: "archive"})
+func (s *Client) Copy(ctx context.Context, srcKey, dstKey string, options ...CopyOptions) error {
+	var opts CopyOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	srcBucket, srcKey := s.bucketKey(opts.SourceBucket, srcKey)
+	dstBucket, dstKey := s.bucketKey(opts.DestBucket, dstKey)
+
+	head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get %s from S3: %w", srcKey, err)
+	}
+
+	if aws.ToInt64(head.ContentLength) > maxCopyObjectSize {
+		return s.multipartCopy(ctx, srcBucket, srcKey, dstBucket, dstKey, head)
+	}
+
+	_, err = s.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(dstBucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(copySource(srcBucket, srcKey)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s in S3: %w", srcKey, dstKey, err)
+	}
+
+	return nil
+}
+
+// Move copies srcKey to dstKey as Copy does, then deletes srcKey
+//
+// Example:
+//
+//	err := client.Move(ctx, "incoming/batch.csv", "processed/batch.csv")
+func (s *Client) Move(ctx context.Context, srcKey, dstKey string, options ...CopyOptions) error {
+	if err := s.Copy(ctx, srcKey, dstKey, options...); err != nil {
+		return err
+	}
+
+	var opts CopyOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	srcBucket, fullSrcKey := s.bucketKey(opts.SourceBucket, srcKey)
+
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(fullSrcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s after copying it: %w", fullSrcKey, err)
+	}
+
+	return nil
+}
+
+// multipartCopy copies objects above the CopyObject limit part by part. Tags aren't copied
+// by UploadPartCopy.
+func (s *Client) multipartCopy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, head *s3.HeadObjectOutput) error {
+	upload, err := s.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(dstBucket),
+		Key:          aws.String(dstKey),
+		ContentType:  head.ContentType,
+		Metadata:     head.Metadata,
+		StorageClass: s3types.StorageClass(head.StorageClass),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start copy of %s in S3: %w", srcKey, err)
+	}
+
+	abort := func(err error) error {
+		_, _ = s.s3Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(dstBucket),
+			Key:      aws.String(dstKey),
+			UploadId: upload.UploadId,
+		})
+		return err
+	}
+
+	var completed []s3types.CompletedPart
+
+	for i, part := range partRanges(aws.ToInt64(head.ContentLength), copyPartSize) {
+		result, err := s.s3Client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(dstBucket),
+			Key:               aws.String(dstKey),
+			UploadId:          upload.UploadId,
+			PartNumber:        aws.Int32(int32(i + 1)),
+			CopySource:        aws.String(copySource(srcBucket, srcKey)),
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", part.start, part.end)),
+			CopySourceIfMatch: head.ETag,
+		})
+		if err != nil {
+			return abort(fmt.Errorf("failed to copy part %d of %s in S3: %w", i+1, srcKey, err))
+		}
+
+		completed = append(completed, s3types.CompletedPart{
+			ETag:       result.CopyPartResult.ETag,
+			PartNumber: aws.Int32(int32(i + 1)),
+		})
+	}
+
+	_, err = s.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(dstBucket),
+		Key:             aws.String(dstKey),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to complete copy of %s in S3: %w", srcKey, err))
+	}
+
+	return nil
+}
+
+// copySource builds the URL-encoded source of a copy request. S3 decodes "+" in it as a
+// space, so it is escaped too.
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
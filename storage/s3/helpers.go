@@ -2,15 +2,25 @@ package s3
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/finch-technologies/go-utils/naming"
 	"github.com/finch-technologies/go-utils/utils"
 )
 
 func getConfig(config ...Config) (*Config, error) {
 	defaultConfig := Config{
-		Region: utils.StringOrDefault(os.Getenv("S3_REGION"), "af-south-1"),
+		Region:         utils.StringOrDefault(os.Getenv("S3_REGION"), "af-south-1"),
+		MaxRetries:     retry.DefaultMaxAttempts - 1,
+		RetryBaseDelay: time.Second,
+		MaxBackoff:     retry.DefaultMaxBackoff,
 	}
 
 	if len(config) == 0 {
@@ -22,6 +32,10 @@ func getConfig(config ...Config) (*Config, error) {
 	if len(config) > 0 {
 		cfg = config[0]
 
+		if cfg.Bucket == "" && cfg.Resource != "" {
+			cfg.Bucket = naming.Bucket(cfg.Resource)
+		}
+
 		if cfg.Bucket == "" {
 			return nil, errors.New("bucket is required")
 		}
@@ -37,6 +51,7 @@ func getUploadOptions(options ...UploadOptions) UploadOptions {
 		ReturnType:      S3ReturnTypeKey,
 		PresignedUrlTTL: 30 * time.Minute,
 		Metadata:        map[string]string{},
+		ContentPrefix:   "content",
 	}
 
 	if len(options) == 0 {
@@ -55,6 +70,49 @@ func getUploadOptions(options ...UploadOptions) UploadOptions {
 	if opts.Metadata == nil {
 		opts.Metadata = defaultOptions.Metadata
 	}
+	if opts.ContentPrefix == "" {
+		opts.ContentPrefix = "content"
+	}
+	if opts.KmsKeyId != "" && opts.ServerSideEncryption == "" {
+		opts.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+	}
 
 	return opts
 }
+
+// applyObjectOptions sets the metadata, expiry tag, encryption, storage class and header
+// options of an upload
+func applyObjectOptions(input *s3.PutObjectInput, opts UploadOptions) error {
+	if opts.KmsKeyId != "" && opts.ServerSideEncryption != s3types.ServerSideEncryptionAwsKms {
+		return fmt.Errorf("a KMS key needs %s encryption, got %s", s3types.ServerSideEncryptionAwsKms, opts.ServerSideEncryption)
+	}
+
+	if opts.Metadata != nil {
+		input.Metadata = opts.Metadata
+	}
+
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+
+	if !opts.ExpiresAt.IsZero() {
+		input.Tagging = aws.String(url.Values{ExpiresAtTag: {expiryDate(opts.ExpiresAt)}}.Encode())
+	}
+
+	input.ServerSideEncryption = opts.ServerSideEncryption
+	input.StorageClass = opts.StorageClass
+
+	if opts.KmsKeyId != "" {
+		input.SSEKMSKeyId = aws.String(opts.KmsKeyId)
+	}
+
+	if opts.CacheControl != "" {
+		input.CacheControl = aws.String(opts.CacheControl)
+	}
+
+	if opts.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(opts.ContentDisposition)
+	}
+
+	return nil
+}
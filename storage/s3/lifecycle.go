@@ -0,0 +1,294 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+const (
+	// ExpiringPrefix is the key prefix under which ExpiringKey groups objects by expiry date
+	ExpiringPrefix = "expiring"
+	// ExpiresAtTag is the object tag holding an upload's expiry date
+	ExpiresAtTag = "expires-at"
+
+	expiryDateLayout = "2006-01-02"
+
+	// maxDeleteObjects is the most keys a DeleteObjects request accepts
+	maxDeleteObjects = 1000
+)
+
+// CleanupOptions configures the expired prefix cleanup job
+type CleanupOptions struct {
+	Interval time.Duration // How often expired prefixes are deleted (default 1h)
+	IsLeader func() bool   // Only runs the cleanup while it returns true, e.g. elector.IsLeader (default every instance runs it)
+}
+
+// ExpiringKey places key under a prefix for its expiry date, e.g.
+// "expiring/2026-10-20/statements/123.pdf", so DeleteExpiredPrefixes can remove a whole day
+// of uploads at once. Expiry is rounded up to the next whole day.
+func ExpiringKey(key string, expiresAt time.Time) string {
+	return ExpiringPrefix + "/" + expiryDate(expiresAt) + "/" + strings.TrimPrefix(key, "/")
+}
+
+// expiryDate returns the first UTC day on which an object expiring at t may be deleted
+func expiryDate(t time.Time) string {
+	t = t.UTC()
+
+	day := t.Truncate(24 * time.Hour)
+	if day.Before(t) {
+		day = day.Add(24 * time.Hour)
+	}
+
+	return day.Format(expiryDateLayout)
+}
+
+// DeleteExpiredPrefixes deletes every object under expiry date prefixes that have passed
+// and returns the number of objects deleted
+func (s *Client) DeleteExpiredPrefixes(ctx context.Context) (int, error) {
+	root := s.fullKey(ExpiringPrefix) + "/"
+	today := time.Now().UTC().Format(expiryDateLayout)
+
+	deleted := 0
+
+	paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.Bucket),
+		Prefix:    aws.String(root),
+		Delimiter: aws.String("/"),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list expiring prefixes: %w", err)
+		}
+
+		for _, prefix := range page.CommonPrefixes {
+			date := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(prefix.Prefix), root), "/")
+
+			if _, err := time.Parse(expiryDateLayout, date); err != nil {
+				continue
+			}
+
+			// Dates compare correctly as strings in this layout
+			if date > today {
+				continue
+			}
+
+			count, err := s.deletePrefix(ctx, aws.ToString(prefix.Prefix))
+			deleted += count
+			if err != nil {
+				return deleted, err
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// DeletePrefix deletes every object under prefix (relative to the configured key prefix)
+// and returns the number of objects deleted
+func (s *Client) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	if strings.Trim(prefix, "/") == "" && strings.Trim(s.KeyPrefix, "/") == "" {
+		return 0, fmt.Errorf("refusing to delete every object in bucket %s", s.Bucket)
+	}
+
+	return s.deletePrefix(ctx, s.fullKey(prefix))
+}
+
+// deletePrefix deletes every object under a full key prefix, 1000 objects per request
+func (s *Client) deletePrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+
+	paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+
+		keys := make([]string, len(page.Contents))
+		for i, obj := range page.Contents {
+			keys[i] = aws.ToString(obj.Key)
+		}
+
+		count, err := s.deleteKeys(ctx, keys)
+		deleted += count
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete objects under %s: %w", prefix, err)
+		}
+	}
+
+	return deleted, nil
+}
+
+// deleteKeys deletes full keys with one DeleteObjects request per 1000 keys and returns the
+// number of objects deleted
+func (s *Client) deleteKeys(ctx context.Context, keys []string) (int, error) {
+	deleted := 0
+
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		batch := keys[start:min(start+maxDeleteObjects, len(keys))]
+
+		objects := make([]s3types.ObjectIdentifier, len(batch))
+		for i, key := range batch {
+			objects[i] = s3types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		result, err := s.s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.Bucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += len(objects) - len(result.Errors)
+
+		if len(result.Errors) > 0 {
+			first := result.Errors[0]
+			return deleted, fmt.Errorf("%d objects not deleted, %s: %s", len(result.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+	}
+
+	return deleted, nil
+}
+
+// StartCleanupJob runs DeleteExpiredPrefixes periodically until ctx is done, on the leader
+// only when IsLeader is set. It is meant for buckets without lifecycle rules; see
+// ExpirePrefixRule for the lifecycle-based alternative.
+//
+// Example:
+//
+//	elector.Start(elector.ElectorConfig{TableName: "locks"})
+//	client.StartCleanupJob(ctx, s3.CleanupOptions{Interval: 6 * time.Hour, IsLeader: elector.IsLeader})
+func (s *Client) StartCleanupJob(ctx context.Context, options ...CleanupOptions) {
+	opts := CleanupOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Interval = utils.DurationOrDefault(opts.Interval, time.Hour)
+
+	if opts.IsLeader == nil {
+		opts.IsLeader = func() bool { return true }
+	}
+
+	go func() {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if !opts.IsLeader() {
+				continue
+			}
+
+			deleted, err := s.DeleteExpiredPrefixes(ctx)
+			if err != nil {
+				log.Errorf("Failed to clean up expired prefixes in bucket %s: %v", s.Bucket, err)
+				continue
+			}
+
+			if deleted > 0 {
+				log.Infof("Deleted %d expired objects from bucket %s", deleted, s.Bucket)
+			}
+		}
+	}()
+}
+
+// GetLifecycleRules returns the bucket's lifecycle rules, or nil if it has none
+func (s *Client) GetLifecycleRules(ctx context.Context) ([]s3types.LifecycleRule, error) {
+	result, err := s.s3Client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(s.Bucket),
+	})
+	if err != nil {
+		if noLifecycleConfiguration(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket lifecycle configuration: %w", err)
+	}
+
+	return result.Rules, nil
+}
+
+// noLifecycleConfiguration reports whether err is S3's error for a bucket without lifecycle rules
+func noLifecycleConfiguration(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchLifecycleConfiguration"
+}
+
+// PutLifecycleRules replaces the bucket's lifecycle configuration with rules. Passing no
+// rules removes the configuration.
+func (s *Client) PutLifecycleRules(ctx context.Context, rules ...s3types.LifecycleRule) error {
+	if len(rules) == 0 {
+		_, err := s.s3Client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{
+			Bucket: aws.String(s.Bucket),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete bucket lifecycle configuration: %w", err)
+		}
+		return nil
+	}
+
+	_, err := s.s3Client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(s.Bucket),
+		LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{
+			Rules: rules,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put bucket lifecycle configuration: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyLifecycleRule adds rule to the bucket's lifecycle configuration, replacing an
+// existing rule with the same ID
+func (s *Client) ApplyLifecycleRule(ctx context.Context, rule s3types.LifecycleRule) error {
+	rules, err := s.GetLifecycleRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules = utils.Filter(rules, func(existing s3types.LifecycleRule, _ int) bool {
+		return aws.ToString(existing.ID) != aws.ToString(rule.ID)
+	})
+
+	return s.PutLifecycleRules(ctx, append(rules, rule)...)
+}
+
+// ExpirePrefixRule returns an enabled lifecycle rule expiring objects under prefix after
+// the given number of days
+func ExpirePrefixRule(id, prefix string, days int32) s3types.LifecycleRule {
+	return s3types.LifecycleRule{
+		ID:     aws.String(id),
+		Status: s3types.ExpirationStatusEnabled,
+		Filter: &s3types.LifecycleRuleFilter{
+			Prefix: aws.String(prefix),
+		},
+		Expiration: &s3types.LifecycleExpiration{
+			Days: aws.Int32(days),
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ListOptions configures List
+type ListOptions struct {
+	// Delimiter groups keys that contain it after the prefix into ListResult.Prefixes, e.g.
+	// "/" to list a "directory" without descending into its subdirectories
+	Delimiter         string
+	MaxKeys           int    // Most files and prefixes returned (0 = all)
+	ContinuationToken string // ListResult.NextToken of a previous call to continue from
+}
+
+// ListResult is the result of List. Names and prefixes are relative to the client's key
+// prefix.
+type ListResult struct {
+	Files     []FileInfo
+	Prefixes  []string // Common prefixes when ListOptions.Delimiter is set, ending in the delimiter
+	NextToken string   // Set when MaxKeys stopped the listing early; pass as ContinuationToken to continue
+}
+
+// List returns the objects under prefix with their sizes and last modified times, following
+// continuation tokens until every object is listed or MaxKeys is reached
+//
+// Example:
+//
+//	// The files and subdirectories of reports/2024/
+//	result, err := client.List(ctx, "reports/2024/", s3.ListOptions{Delimiter: "/"})
+//	for _, dir := range result.Prefixes {
+//	    fmt.Println(dir) // reports/2024/01/, reports/2024/02/, ...
+//	}
+func (s *Client) List(ctx context.Context, prefix string, options ...ListOptions) (*ListResult, error) {
+	var opts ListOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(s.fullKey(prefix)),
+	}
+
+	if opts.Delimiter != "" {
+		input.Delimiter = aws.String(opts.Delimiter)
+	}
+
+	if opts.ContinuationToken != "" {
+		input.ContinuationToken = aws.String(opts.ContinuationToken)
+	}
+
+	result := &ListResult{}
+
+	for {
+		if opts.MaxKeys > 0 {
+			input.MaxKeys = aws.Int32(int32(opts.MaxKeys - len(result.Files) - len(result.Prefixes)))
+		}
+
+		page, err := s.s3Client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+
+			result.Files = append(result.Files, FileInfo{
+				Name:         s.relativeKey(key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: obj.LastModified,
+				S3Key:        key,
+				StorageClass: string(obj.StorageClass),
+			})
+		}
+
+		for _, commonPrefix := range page.CommonPrefixes {
+			result.Prefixes = append(result.Prefixes, s.relativeKey(aws.ToString(commonPrefix.Prefix)))
+		}
+
+		if !aws.ToBool(page.IsTruncated) {
+			return result, nil
+		}
+
+		if opts.MaxKeys > 0 && len(result.Files)+len(result.Prefixes) >= opts.MaxKeys {
+			result.NextToken = aws.ToString(page.NextContinuationToken)
+			return result, nil
+		}
+
+		input.ContinuationToken = page.NextContinuationToken
+	}
+}
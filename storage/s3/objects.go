@@ -0,0 +1,36 @@
+package s3
+
+import (
+	"context"
+	"time"
+)
+
+// PutObject stores data under key, tagged to expire at expiresAt unless it is zero. Along
+// with GetObject and DeleteObject it lets the client hold oversized dynamo values.
+//
+// Example:
+//
+//	bucket, err := s3.New(s3.Config{Bucket: "sessions-overflow"})
+//	db, err := dynamo.New(dynamo.DbOptions{
+//	    TableName: "sessions",
+//	    Overflow:  &dynamo.OverflowOptions{Store: bucket},
+//	})
+func (s *Client) PutObject(ctx context.Context, key string, data []byte, expiresAt time.Time) error {
+	_, err := s.Upload(ctx, data, key, UploadOptions{
+		ReturnType:  S3ReturnTypeKey,
+		ContentType: "application/octet-stream",
+		ExpiresAt:   expiresAt,
+	})
+
+	return err
+}
+
+// GetObject returns the content of the object stored under key
+func (s *Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+	return s.Download(ctx, key)
+}
+
+// DeleteObject deletes the object stored under key
+func (s *Client) DeleteObject(ctx context.Context, key string) error {
+	return s.DeleteFile(ctx, key)
+}
@@ -0,0 +1,88 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ErrThrottled is returned when S3 still throttles a request (e.g. with SlowDown) after every
+// retry. Use errors.As with *ThrottledError for the S3 error.
+var ErrThrottled = errors.New("request throttled by S3")
+
+// ThrottledError wraps the S3 error of a throttled request
+type ThrottledError struct {
+	Err error
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrThrottled, e.Err)
+}
+
+func (e *ThrottledError) Unwrap() []error {
+	return []error{ErrThrottled, e.Err}
+}
+
+// exponentialBackoff delays retry attempt n by a random duration up to base * 2^(n-1),
+// capped at max
+type exponentialBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+func (b exponentialBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	delay := b.max
+	// Past 30 doublings any base is beyond a sensible max
+	if attempt <= 30 && b.base<<(attempt-1) < b.max {
+		delay = b.base << (attempt - 1)
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1)), nil
+}
+
+// retryOptions applies the retry, backoff and timeout settings of cfg to the S3 client
+func retryOptions(cfg *Config) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
+			so.MaxAttempts = max(cfg.MaxRetries, 0) + 1
+			so.MaxBackoff = cfg.MaxBackoff
+			so.Backoff = exponentialBackoff{base: cfg.RetryBaseDelay, max: cfg.MaxBackoff}
+		})
+
+		if cfg.Timeout > 0 {
+			o.HTTPClient = awshttp.NewBuildableClient().WithTimeout(cfg.Timeout)
+		}
+
+		o.APIOptions = append(o.APIOptions, addThrottledError)
+	}
+}
+
+// addThrottledError wraps the errors of throttled requests in a *ThrottledError once the
+// retryer gives up
+func addThrottledError(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ThrottledError", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		return out, metadata, throttledError(err)
+	}), middleware.Before)
+}
+
+// throttledError wraps err in a *ThrottledError if it is a throttling error
+func throttledError(err error) error {
+	if err == nil || errors.Is(err, ErrThrottled) {
+		return err
+	}
+
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		return &ThrottledError{Err: err}
+	}
+
+	return err
+}
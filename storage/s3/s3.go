@@ -3,6 +3,7 @@ package s3
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
@@ -32,8 +34,22 @@ type Client struct {
 
 type Config struct {
 	Bucket    string
+	Resource  string // Logical bucket name resolved with naming.Bucket when Bucket is empty
 	Region    string
 	KeyPrefix string
+
+	MaxRetries     int           // Retries of a failed request, e.g. on SlowDown (default 2, -1 disables retries)
+	RetryBaseDelay time.Duration // Upper bound of the first retry's random delay, doubling per retry (default 1s)
+	MaxBackoff     time.Duration // Longest delay between retries (default 20s)
+	Timeout        time.Duration // Limit per request attempt, including reading a download's body (default none)
+
+	// Endpoint points the client at an S3 compatible service such as MinIO or Google Cloud
+	// Storage. Checksums are then only sent when an operation requires them, as such
+	// services tend to reject the SDK's default trailing checksums.
+	Endpoint        string
+	PathStyle       bool   // Address buckets as endpoint/bucket rather than bucket.endpoint
+	AccessKeyId     string // Static credentials, e.g. HMAC keys of a compatible service (default the AWS credential chain)
+	SecretAccessKey string
 }
 
 func New(config ...Config) (*Client, error) {
@@ -43,26 +59,40 @@ func New(config ...Config) (*Client, error) {
 		return nil, err
 	}
 
-	awsCfg, err := awsconfig.LoadDefaultConfig(
-		context.Background(),
-		awsconfig.WithRegion(cfg.Region),
-	)
+	loadOptions := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(cfg.Region)}
+
+	if cfg.AccessKeyId != "" {
+		loadOptions = append(loadOptions, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyId, cfg.SecretAccessKey, ""),
+		))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), loadOptions...)
 
 	if err != nil {
 		//errors.ThrowError(err, "", "", nil)
 		return nil, fmt.Errorf("unable to load SDK config, %v", err)
 	}
 
-	var client *s3.Client
+	optFns := []func(*s3.Options){retryOptions(cfg)}
+
+	if cfg.Endpoint != "" {
+		optFns = append(optFns, func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = cfg.PathStyle
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		})
+	}
 
 	if os.Getenv("S3_DEBUG") == "true" {
-		client = s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		optFns = append(optFns, func(o *s3.Options) {
 			o.ClientLogMode = aws.LogSigning | aws.LogRequest | aws.LogResponseWithBody
 		})
-	} else {
-		client = s3.NewFromConfig(awsCfg)
 	}
 
+	client := s3.NewFromConfig(awsCfg, optFns...)
+
 	return &Client{
 		s3Client:  client,
 		Bucket:    cfg.Bucket,
@@ -71,39 +101,113 @@ func New(config ...Config) (*Client, error) {
 	}, nil
 }
 
+// ResolveKey returns the object key that key is stored under: key below the configured key
+// prefix. Every method taking a key resolves it this way.
+//
+// Example:
+//
+//	client, err := s3.New(s3.Config{Bucket: "documents", KeyPrefix: "tenant-1"})
+//	client.ResolveKey("invoices/42.pdf") // "tenant-1/invoices/42.pdf"
+func (s *Client) ResolveKey(key string) string {
+	return s.fullKey(key)
+}
+
+// WithoutPrefix returns a client for the same bucket that uses keys as they are, for calls
+// that need objects outside the key prefix. The underlying S3 client is shared.
+//
+// Example:
+//
+//	data, err := client.WithoutPrefix().Download(ctx, "shared/logo.png")
+func (s *Client) WithoutPrefix() *Client {
+	clone := *s
+	clone.KeyPrefix = ""
+	return &clone
+}
+
+// fullKey prepends the configured key prefix to a key
+func (s *Client) fullKey(key string) string {
+	prefix := strings.Trim(s.KeyPrefix, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + strings.TrimPrefix(key, "/")
+}
+
+// relativeKey strips the configured key prefix from a full object key
+func (s *Client) relativeKey(fullKey string) string {
+	prefix := strings.Trim(s.KeyPrefix, "/")
+	if prefix == "" {
+		return fullKey
+	}
+	return strings.TrimPrefix(fullKey, prefix+"/")
+}
+
+// Upload stores file under key. With Deduplicate set, the file is stored under a
+// content-addressed key instead and an existing object with the same content is reused
+// rather than uploaded again; the returned key (or URL) then points at that object. The
+// SHA-256 of the content is sent along for S3 to verify and kept in the object's metadata,
+// so downloads can detect corruption.
 func (s *Client) Upload(ctx context.Context, file []byte, key string, options ...UploadOptions) (string, error) {
 	opts := getUploadOptions(options...)
 
-	// Add prefix to key if configured
-	if s.KeyPrefix != "" {
-		key = fmt.Sprintf("%s/%s", s.KeyPrefix, key)
+	sum := sha256.Sum256(file)
+	if err := checkChecksum(key, sum[:], opts.ChecksumSHA256); err != nil {
+		return "", err
 	}
 
-	putObjectInput := &s3.PutObjectInput{
-		Bucket: &s.Bucket,
-		Key:    &key,
-		Body:   bytes.NewReader(file),
-	}
+	if opts.Deduplicate {
+		name := filepath.Base(key)
+		key = utils.ContentKey(opts.ContentPrefix, file, name)
 
-	if opts.Metadata != nil {
-		putObjectInput.Metadata = opts.Metadata
+		exists, err := s.FileExists(ctx, key)
+		if err != nil {
+			return "", err
+		}
+
+		metadata := make(map[string]string, len(opts.Metadata)+1)
+		for k, v := range opts.Metadata {
+			metadata[k] = v
+		}
+		if metadata["original-name"] == "" {
+			metadata["original-name"] = name
+		}
+		opts.Metadata = metadata
+
+		if exists {
+			return s.uploadResult(ctx, key, opts)
+		}
 	}
 
-	if opts.ContentType != "" {
-		putObjectInput.ContentType = &opts.ContentType
+	putObjectInput := &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
+		Body:   bytes.NewReader(file),
 	}
 
 	if opts.FileSize != 0 {
 		putObjectInput.ContentLength = &opts.FileSize
 	}
 
+	if err := applyObjectOptions(putObjectInput, opts); err != nil {
+		return "", err
+	}
+
+	setChecksum(putObjectInput, sum[:])
+
 	_, err := s.s3Client.PutObject(ctx, putObjectInput)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to S3: %v", err)
 	}
 
+	return s.uploadResult(ctx, key, opts)
+}
+
+// uploadResult builds Upload's return value for key. Returned keys are relative to the key
+// prefix like the keys the other methods take; URLs point at the full key.
+func (s *Client) uploadResult(ctx context.Context, key string, opts UploadOptions) (string, error) {
 	var result string
+	var err error
 
 	switch opts.ReturnType {
 	case S3ReturnTypePresignedUrl:
@@ -112,7 +216,7 @@ func (s *Client) Upload(ctx context.Context, file []byte, key string, options ..
 			return "", fmt.Errorf("failed to generate presigned URL: %w", err)
 		}
 	case S3ReturnTypeUrl:
-		result = fmt.Sprintf("https	://%s.console.aws.amazon.com/s3/buckets/%s/%s", s.Region, s.Bucket, key)
+		result = fmt.Sprintf("https://%s.console.aws.amazon.com/s3/buckets/%s/%s", s.Region, s.Bucket, s.fullKey(key))
 	case S3ReturnTypeKey:
 		result = key
 	}
@@ -124,7 +228,7 @@ func (s *Client) Upload(ctx context.Context, file []byte, key string, options ..
 func (s *Client) ListFiles(ctx context.Context, maxKeys int32) ([]FileInfo, error) {
 	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(s.Bucket),
-		Prefix: aws.String(s.KeyPrefix),
+		Prefix: aws.String(s.fullKey("")),
 	}
 	if maxKeys > 0 {
 		input.MaxKeys = &maxKeys
@@ -137,21 +241,13 @@ func (s *Client) ListFiles(ctx context.Context, maxKeys int32) ([]FileInfo, erro
 
 	var files []FileInfo
 	for _, obj := range result.Contents {
-		// Extract original filename from key (remove prefix)
-		name := strings.TrimPrefix(*obj.Key, s.KeyPrefix)
-
-		// If prefix was removed and there's a leading slash, remove it
-		if name != *obj.Key && strings.HasPrefix(name, "/") {
-			name = strings.TrimPrefix(name, "/")
-		}
-
 		var size int64
 		if obj.Size != nil {
 			size = *obj.Size
 		}
 
 		files = append(files, FileInfo{
-			Name:         name,
+			Name:         s.relativeKey(*obj.Key),
 			Size:         size,
 			LastModified: obj.LastModified,
 			S3Key:        *obj.Key,
@@ -167,7 +263,7 @@ func (s *Client) GeneratePresignedURL(ctx context.Context, key string, expiratio
 
 	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.Bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.fullKey(key)),
 	}, func(opts *s3.PresignOptions) {
 		opts.Expires = time.Duration(expirationMinutes) * time.Minute
 	})
@@ -178,17 +274,34 @@ func (s *Client) GeneratePresignedURL(ctx context.Context, key string, expiratio
 	return request.URL, nil
 }
 
-func (s *Client) Download(ctx context.Context, key string) ([]byte, error) {
-	// Add prefix to key if configured
-	if s.KeyPrefix != "" {
-		key = fmt.Sprintf("%s/%s", s.KeyPrefix, key)
+// Download returns the content of key. Objects archived in Glacier or Deep Archive fail with
+// an *ArchivedError wrapping ErrObjectArchived; with DownloadOptions.Restore set, a restore
+// is started and the error says when to retry. Content that doesn't match the checksum
+// stored with the object fails with ErrChecksumMismatch.
+//
+// Example:
+//
+//	data, err := client.Download(ctx, key, s3.DownloadOptions{Restore: true})
+//	var archived *s3.ArchivedError
+//	if errors.As(err, &archived) {
+//	    retryIn(archived.RetryAfter)
+//	}
+func (s *Client) Download(ctx context.Context, key string, options ...DownloadOptions) ([]byte, error) {
+	var opts DownloadOptions
+
+	if len(options) > 0 {
+		opts = options[0]
 	}
 
 	output, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: &s.Bucket,
-		Key:    &key,
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
 	})
 	if err != nil {
+		var invalidState *s3types.InvalidObjectState
+		if errors.As(err, &invalidState) {
+			return nil, s.archivedError(ctx, key, opts)
+		}
 		return nil, fmt.Errorf("failed to download file from S3, %v", err)
 	}
 	defer func(Body io.ReadCloser) {
@@ -198,19 +311,19 @@ func (s *Client) Download(ctx context.Context, key string) ([]byte, error) {
 		}
 	}(output.Body)
 
-	return io.ReadAll(output.Body)
+	data, err := io.ReadAll(verifyingReader(output.Body, output))
+	if errors.Is(err, ErrChecksumMismatch) {
+		return nil, checksumError(key)
+	}
+
+	return data, err
 }
 
 // DeleteFile deletes a file from S3
 func (s *Client) DeleteFile(ctx context.Context, key string) error {
-	// Add prefix to key if configured
-	if s.KeyPrefix != "" {
-		key = fmt.Sprintf("%s/%s", s.KeyPrefix, key)
-	}
-
 	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.Bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.fullKey(key)),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete file from S3: %w", err)
@@ -219,16 +332,31 @@ func (s *Client) DeleteFile(ctx context.Context, key string) error {
 	return nil
 }
 
-// FileExists checks if a file exists in S3
-func (s *Client) FileExists(ctx context.Context, key string) (bool, error) {
-	// Add prefix to key if configured
-	if s.KeyPrefix != "" {
-		key = fmt.Sprintf("%s/%s", s.KeyPrefix, key)
+// DeleteFiles deletes many files from S3, 1000 per request, and returns the number of files
+// deleted. Keys that don't exist count as deleted, as with DeleteFile.
+//
+// Example:
+//
+//	deleted, err := client.DeleteFiles(ctx, []string{"tmp/a.csv", "tmp/b.csv"})
+func (s *Client) DeleteFiles(ctx context.Context, keys []string) (int, error) {
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = s.fullKey(key)
+	}
+
+	deleted, err := s.deleteKeys(ctx, fullKeys)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to delete files from S3: %w", err)
 	}
 
+	return deleted, nil
+}
+
+// FileExists checks if a file exists in S3
+func (s *Client) FileExists(ctx context.Context, key string) (bool, error) {
 	_, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(s.Bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.fullKey(key)),
 	})
 	if err != nil {
 		var noSuchKey *s3types.NoSuchKey
@@ -248,10 +376,8 @@ func (s *Client) FileExists(ctx context.Context, key string) (bool, error) {
 
 // getFileInfoFromAWS gets file info using AWS SDK (for private URLs)
 func (s *Client) GetS3FileInfo(ctx context.Context, bucket, key string) (*FileInfo, error) {
-	// Add prefix to key if configured and bucket matches client bucket
-	if s.KeyPrefix != "" && bucket == s.Bucket {
-		key = fmt.Sprintf("%s/%s", s.KeyPrefix, key)
-	}
+	// The key prefix only applies in the client's own bucket
+	bucket, key = s.bucketKey(bucket, key)
 
 	result, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(bucket),
@@ -280,8 +406,12 @@ func (s *Client) GetS3FileInfo(ctx context.Context, bucket, key string) (*FileIn
 		ContentType:  aws.ToString(result.ContentType),
 		LastModified: result.LastModified,
 		S3Key:        key,
+		StorageClass: string(result.StorageClass),
 	}
 
+	info.RestoreInProgress, info.RestoredUntil = parseRestore(aws.ToString(result.Restore))
+	info.Archived = isArchived(info.StorageClass) && info.RestoredUntil == nil
+
 	return info, nil
 }
 
@@ -2,11 +2,25 @@ package s3
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/finch-technologies/go-utils/utils"
+	"github.com/finch-technologies/go-utils/utils/fuzz"
 )
 
 var testBucket = "go-utils.test"
@@ -363,6 +377,35 @@ func TestParseS3URL(t *testing.T) {
 	}
 }
 
+func TestParseS3URLProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		url, expectedBucket, expectedKey := fuzz.RandomS3URL(r)
+
+		bucket, key, err := ParseS3URL(url)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", url, err)
+			continue
+		}
+
+		if bucket != expectedBucket || key != expectedKey {
+			t.Errorf("ParseS3URL(%s) = (%s, %s), want (%s, %s)", url, bucket, key, expectedBucket, expectedKey)
+		}
+	}
+}
+
+func FuzzParseS3URL(f *testing.F) {
+	fuzz.AddSeeds(f, fuzz.S3URLSeeds())
+
+	f.Fuzz(func(t *testing.T, input string) {
+		bucket, key, err := ParseS3URL(input)
+		if err == nil && (bucket == "" || key == "") {
+			t.Errorf("ParseS3URL(%q) returned empty bucket or key without error", input)
+		}
+	})
+}
+
 // Integration tests that require actual S3 access
 func TestS3Integration(t *testing.T) {
 	client, err := New(Config{
@@ -388,8 +431,9 @@ func TestS3Integration(t *testing.T) {
 			t.Fatalf("failed to upload file: %v", err)
 		}
 
-		if !strings.Contains(result, testKey) {
-			t.Errorf("expected result to contain key %s, got %s", testKey, result)
+		// Returned keys are relative to the key prefix, like the keys the client takes
+		if result != testKey {
+			t.Errorf("expected result to be key %s, got %s", testKey, result)
 		}
 
 		// Check if file exists
@@ -602,6 +646,205 @@ func TestS3Integration(t *testing.T) {
 			t.Errorf("expected at most 2 files with limit, got %d", len(limitedFiles))
 		}
 	})
+
+	t.Run("list with delimiter and paging", func(t *testing.T) {
+		keys := []string{"list-test/a.txt", "list-test/b.txt", "list-test/c.txt", "list-test/sub/d.txt"}
+		for _, key := range keys {
+			if _, err := client.Upload(ctx, []byte(key), key); err != nil {
+				t.Fatalf("failed to upload %s: %v", key, err)
+			}
+			defer client.DeleteFile(ctx, key)
+		}
+
+		tests := []struct {
+			name         string
+			options      ListOptions
+			wantFiles    int
+			wantPrefixes []string
+		}{
+			{"recursive", ListOptions{}, 4, nil},
+			{"directory", ListOptions{Delimiter: "/"}, 3, []string{"list-test/sub/"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := client.List(ctx, "list-test/", tt.options)
+				if err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+
+				if len(result.Files) != tt.wantFiles || fmt.Sprint(result.Prefixes) != fmt.Sprint(tt.wantPrefixes) {
+					t.Errorf("List() = %d files, prefixes %v, want %d files, prefixes %v", len(result.Files), result.Prefixes, tt.wantFiles, tt.wantPrefixes)
+				}
+
+				for _, file := range result.Files {
+					if !strings.HasPrefix(file.Name, "list-test/") || file.LastModified == nil {
+						t.Errorf("unexpected file %+v", file)
+					}
+				}
+			})
+		}
+
+		var listed []string
+		token := ""
+
+		for {
+			page, err := client.List(ctx, "list-test/", ListOptions{MaxKeys: 3, ContinuationToken: token})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			for _, file := range page.Files {
+				listed = append(listed, file.Name)
+			}
+
+			if token = page.NextToken; token == "" {
+				break
+			}
+		}
+
+		if fmt.Sprint(listed) != fmt.Sprint(keys) {
+			t.Errorf("paged List() = %v, want %v", listed, keys)
+		}
+	})
+
+	t.Run("copy and move", func(t *testing.T) {
+		content := []byte("copy me")
+
+		if _, err := client.Upload(ctx, content, "copy-test/source.txt"); err != nil {
+			t.Fatalf("failed to upload: %v", err)
+		}
+		defer client.DeleteFile(ctx, "copy-test/copy.txt")
+		defer client.DeleteFile(ctx, "copy-test/moved.txt")
+
+		if err := client.Copy(ctx, "copy-test/source.txt", "copy-test/copy.txt"); err != nil {
+			t.Fatalf("Copy() error = %v", err)
+		}
+
+		if err := client.Move(ctx, "copy-test/source.txt", "copy-test/moved.txt"); err != nil {
+			t.Fatalf("Move() error = %v", err)
+		}
+
+		tests := []struct {
+			key        string
+			wantExists bool
+		}{
+			{"copy-test/source.txt", false},
+			{"copy-test/copy.txt", true},
+			{"copy-test/moved.txt", true},
+		}
+
+		for _, tt := range tests {
+			exists, err := client.FileExists(ctx, tt.key)
+			if err != nil || exists != tt.wantExists {
+				t.Errorf("FileExists(%s) = %v, %v, want %v", tt.key, exists, err, tt.wantExists)
+			}
+		}
+
+		if data, err := client.Download(ctx, "copy-test/moved.txt"); err != nil || string(data) != string(content) {
+			t.Errorf("Download() of moved file = %q, %v", data, err)
+		}
+	})
+
+	t.Run("delete files and prefix", func(t *testing.T) {
+		var keys []string
+		for i := range 5 {
+			key := fmt.Sprintf("delete-test/file-%d.txt", i)
+			if _, err := client.Upload(ctx, []byte("delete me"), key); err != nil {
+				t.Fatalf("failed to upload %s: %v", key, err)
+			}
+			keys = append(keys, key)
+		}
+
+		deleted, err := client.DeleteFiles(ctx, keys[:2])
+		if err != nil || deleted != 2 {
+			t.Fatalf("DeleteFiles() = %d, %v, want 2", deleted, err)
+		}
+
+		deleted, err = client.DeletePrefix(ctx, "delete-test/")
+		if err != nil || deleted != 3 {
+			t.Fatalf("DeletePrefix() = %d, %v, want 3", deleted, err)
+		}
+
+		for _, key := range keys {
+			if exists, err := client.FileExists(ctx, key); err != nil || exists {
+				t.Errorf("FileExists(%s) = %v, %v, want false", key, exists, err)
+			}
+		}
+	})
+
+	t.Run("stream with reader and writer", func(t *testing.T) {
+		tests := []struct {
+			name string
+			size int
+		}{
+			{"single request", 1024},
+			{"multipart", defaultPartSize + 1024},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				key := "stream-test/" + strings.ReplaceAll(tt.name, " ", "-") + ".bin"
+				content := []byte(strings.Repeat("x", tt.size))
+
+				w, err := client.Writer(ctx, key, UploadOptions{ContentType: "application/octet-stream"})
+				if err != nil {
+					t.Fatalf("Writer() error = %v", err)
+				}
+				defer client.DeleteFile(ctx, key)
+
+				if _, err := w.Write(content); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatalf("Close() error = %v", err)
+				}
+
+				r, err := client.Reader(ctx, key)
+				if err != nil {
+					t.Fatalf("Reader() error = %v", err)
+				}
+				defer r.Close()
+
+				data, err := io.ReadAll(r)
+				if err != nil || len(data) != tt.size {
+					t.Errorf("read %d bytes, %v, want %d", len(data), err, tt.size)
+				}
+			})
+		}
+	})
+
+	t.Run("checksum verification", func(t *testing.T) {
+		key := "checksum-test/document.txt"
+		defer client.DeleteFile(ctx, key)
+
+		_, err := client.Upload(ctx, testContent, key, UploadOptions{ChecksumSHA256: utils.ChecksumSHA256([]byte("other"))})
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("Upload() with a wrong checksum error = %v, want ErrChecksumMismatch", err)
+		}
+
+		if _, err := client.Upload(ctx, testContent, key, UploadOptions{ChecksumSHA256: utils.ChecksumSHA256(testContent)}); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+
+		// Replace the content behind the stored checksum, as silent corruption would
+		_, err = client.s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:   aws.String(client.Bucket),
+			Key:      aws.String(client.fullKey(key)),
+			Body:     strings.NewReader("corrupted"),
+			Metadata: map[string]string{checksumMetadataKey: utils.ChecksumSHA256(testContent)},
+		})
+		if err != nil {
+			t.Fatalf("failed to overwrite object: %v", err)
+		}
+
+		if _, err := client.Download(ctx, key); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Download() error = %v, want ErrChecksumMismatch", err)
+		}
+		if _, err := client.DownloadStream(ctx, key, io.Discard); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("DownloadStream() error = %v, want ErrChecksumMismatch", err)
+		}
+	})
 }
 
 func TestEdgeCases(t *testing.T) {
@@ -734,3 +977,480 @@ func TestEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestExpiringKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		expiresAt time.Time
+		expected  string
+	}{
+		{
+			name:      "mid-day expiry rounds up",
+			key:       "statements/123.pdf",
+			expiresAt: time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC),
+			expected:  "expiring/2026-10-20/statements/123.pdf",
+		},
+		{
+			name:      "midnight expiry stays on the day",
+			key:       "/statements/123.pdf",
+			expiresAt: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+			expected:  "expiring/2026-10-20/statements/123.pdf",
+		},
+		{
+			name:      "non-UTC expiry uses the UTC day",
+			key:       "a.txt",
+			expiresAt: time.Date(2026, 10, 20, 1, 0, 0, 0, time.FixedZone("SAST", 2*60*60)),
+			expected:  "expiring/2026-10-20/a.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExpiringKey(tt.key, tt.expiresAt)
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		expected  string
+		expectErr bool
+	}{
+		{name: "nested key", key: "a/b/c.pdf", expected: "/tmp/dest/a/b/c.pdf"},
+		{name: "dot segments inside dir", key: "a/../b.pdf", expected: "/tmp/dest/b.pdf"},
+		{name: "escaping key", key: "../../etc/passwd", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := safeJoin("/tmp/dest", tt.key)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", result)
+				}
+				return
+			}
+
+			if err != nil || result != tt.expected {
+				t.Errorf("safeJoin() = %s, %v, want %s", result, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunTransfer(t *testing.T) {
+	files := make([]transferFile, 20)
+	for i := range files {
+		files[i] = transferFile{key: fmt.Sprintf("file-%d", i)}
+	}
+
+	var last TransferProgress
+
+	count, err := runTransfer(context.Background(), files, 4, TransferOptions{
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+		OnProgress: func(progress TransferProgress) { last = progress },
+	}, func(ctx context.Context, file transferFile) (int64, error) {
+		if file.key == "file-3" {
+			return 0, errors.New("access denied")
+		}
+		return 10, nil
+	})
+
+	if count != 19 {
+		t.Errorf("expected 19 files transferred, got %d", count)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "file-3: access denied") {
+		t.Errorf("expected error for file-3, got %v", err)
+	}
+
+	if last.Total != 20 || last.Completed != 19 || last.Failed != 1 || last.Bytes != 190 {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+}
+
+func TestParseRestore(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		wantInProgress bool
+		wantUntil      string
+	}{
+		{name: "no restore", header: ""},
+		{name: "in progress", header: `ongoing-request="true"`, wantInProgress: true},
+		{name: "restored", header: `ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"`, wantUntil: "2012-12-23T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inProgress, until := parseRestore(tt.header)
+
+			if inProgress != tt.wantInProgress {
+				t.Errorf("inProgress = %v, want %v", inProgress, tt.wantInProgress)
+			}
+
+			got := ""
+			if until != nil {
+				got = until.UTC().Format(time.RFC3339)
+			}
+
+			if got != tt.wantUntil {
+				t.Errorf("restoredUntil = %s, want %s", got, tt.wantUntil)
+			}
+		})
+	}
+
+	err := error(&ArchivedError{Key: "a.csv", StorageClass: "GLACIER"})
+	if !errors.Is(err, ErrObjectArchived) {
+		t.Errorf("ArchivedError does not match ErrObjectArchived")
+	}
+}
+
+func TestPartRanges(t *testing.T) {
+	tests := []struct {
+		size, partSize int64
+		want           []byteRange
+	}{
+		{0, 10, nil},
+		{10, 10, []byteRange{{0, 9}}},
+		{25, 10, []byteRange{{0, 9}, {10, 19}, {20, 24}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%d", tt.size, tt.partSize), func(t *testing.T) {
+			got := partRanges(tt.size, tt.partSize)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("partRanges(%d, %d) = %v, want %v", tt.size, tt.partSize, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamParts(t *testing.T) {
+	content := "the quick brown fox jumps over the lazy dog"
+	parts := partRanges(int64(len(content)), 5)
+
+	fetch := func(ctx context.Context, part byteRange) ([]byte, error) {
+		// Later parts finish first, so writes must wait for earlier ones
+		time.Sleep(time.Duration(len(content)-int(part.start)) * 100 * time.Microsecond)
+		return []byte(content[part.start : part.end+1]), nil
+	}
+
+	var out strings.Builder
+
+	n, err := streamParts(context.Background(), &out, parts, 3, fetch)
+	if err != nil {
+		t.Fatalf("streamParts() error = %v", err)
+	}
+
+	if out.String() != content || n != int64(len(content)) {
+		t.Errorf("streamParts() wrote %q (%d bytes), want %q", out.String(), n, content)
+	}
+
+	out.Reset()
+
+	_, err = streamParts(context.Background(), &out, parts, 3, func(ctx context.Context, part byteRange) ([]byte, error) {
+		if part.start == 10 {
+			return nil, errors.New("connection reset")
+		}
+		return fetch(ctx, part)
+	})
+
+	if err == nil || out.String() != content[:10] {
+		t.Errorf("expected the first two parts then an error, got %q, %v", out.String(), err)
+	}
+}
+
+func TestCopySource(t *testing.T) {
+	tests := []struct {
+		bucket, key string
+		want        string
+	}{
+		{"bucket", "file.txt", "bucket/file.txt"},
+		{"bucket", "dir/sub/file.txt", "bucket/dir/sub/file.txt"},
+		{"bucket", "dir/my file+1.txt", "bucket/dir/my%20file%2B1.txt"},
+		{"bucket", "dir/100%.txt", "bucket/dir/100%25.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := copySource(tt.bucket, tt.key); got != tt.want {
+				t.Errorf("copySource(%q, %q) = %q, want %q", tt.bucket, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyObjectOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options UploadOptions
+		want    s3.PutObjectInput
+		wantErr bool
+	}{
+		{
+			name:    "none",
+			options: UploadOptions{},
+			want:    s3.PutObjectInput{},
+		},
+		{
+			name:    "kms key defaults to kms encryption",
+			options: UploadOptions{KmsKeyId: "alias/uploads"},
+			want: s3.PutObjectInput{
+				ServerSideEncryption: s3types.ServerSideEncryptionAwsKms,
+				SSEKMSKeyId:          aws.String("alias/uploads"),
+			},
+		},
+		{
+			name: "headers and storage class",
+			options: UploadOptions{
+				ServerSideEncryption: s3types.ServerSideEncryptionAes256,
+				StorageClass:         s3types.StorageClassGlacierIr,
+				CacheControl:         "max-age=3600",
+				ContentDisposition:   `attachment; filename="report.pdf"`,
+			},
+			want: s3.PutObjectInput{
+				ServerSideEncryption: s3types.ServerSideEncryptionAes256,
+				StorageClass:         s3types.StorageClassGlacierIr,
+				CacheControl:         aws.String("max-age=3600"),
+				ContentDisposition:   aws.String(`attachment; filename="report.pdf"`),
+			},
+		},
+		{
+			name:    "kms key with S3 managed encryption",
+			options: UploadOptions{ServerSideEncryption: s3types.ServerSideEncryptionAes256, KmsKeyId: "alias/uploads"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input s3.PutObjectInput
+
+			err := applyObjectOptions(&input, getUploadOptions(tt.options))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyObjectOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if input.ServerSideEncryption != tt.want.ServerSideEncryption ||
+				input.StorageClass != tt.want.StorageClass ||
+				aws.ToString(input.SSEKMSKeyId) != aws.ToString(tt.want.SSEKMSKeyId) ||
+				aws.ToString(input.CacheControl) != aws.ToString(tt.want.CacheControl) ||
+				aws.ToString(input.ContentDisposition) != aws.ToString(tt.want.ContentDisposition) {
+				t.Errorf("applyObjectOptions() = %+v, want %+v", input, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveKey(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   string
+	}{
+		{"", "file.txt", "file.txt"},
+		{"uploads", "file.txt", "uploads/file.txt"},
+		{"uploads/", "file.txt", "uploads/file.txt"},
+		{"uploads", "/dir/file.txt", "uploads/dir/file.txt"},
+		{"tenant/1", "dir/", "tenant/1/dir/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix+"|"+tt.key, func(t *testing.T) {
+			client := &Client{Bucket: testBucket, KeyPrefix: tt.prefix}
+
+			got := client.ResolveKey(tt.key)
+			if got != tt.want {
+				t.Errorf("ResolveKey(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+
+			if rel, want := client.relativeKey(got), strings.TrimPrefix(tt.key, "/"); rel != want {
+				t.Errorf("relativeKey(%q) = %q, want %q", got, rel, want)
+			}
+
+			if raw := client.WithoutPrefix().ResolveKey(tt.key); raw != tt.key {
+				t.Errorf("WithoutPrefix().ResolveKey(%q) = %q, want the key unchanged", tt.key, raw)
+			}
+		})
+	}
+}
+
+func TestUploadResult(t *testing.T) {
+	client := &Client{Bucket: "media", Region: "af-south-1", KeyPrefix: "uploads"}
+
+	tests := []struct {
+		returnType S3ReturnType
+		want       string
+	}{
+		{S3ReturnTypeUrl, "https://af-south-1.console.aws.amazon.com/s3/buckets/media/uploads/file.txt"},
+		{S3ReturnTypeKey, "file.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.returnType), func(t *testing.T) {
+			got, err := client.uploadResult(context.Background(), "file.txt", UploadOptions{ReturnType: tt.returnType})
+			if err != nil {
+				t.Fatalf("uploadResult() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("uploadResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := exponentialBackoff{base: time.Second, max: 20 * time.Second}
+
+	tests := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{6, 20 * time.Second},
+		{100, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for range 100 {
+				delay, err := backoff.BackoffDelay(tt.attempt, nil)
+				if err != nil || delay < 0 || delay > tt.limit {
+					t.Fatalf("BackoffDelay(%d) = %s, %v, want at most %s", tt.attempt, delay, err, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestThrottledError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantThrottled bool
+	}{
+		{"nil", nil, false},
+		{"slow down", &smithy.GenericAPIError{Code: "SlowDown"}, true},
+		{"wrapped by the retryer", &retry.MaxAttemptsError{Attempt: 3, Err: &smithy.GenericAPIError{Code: "SlowDown"}}, true},
+		{"not found", &smithy.GenericAPIError{Code: "NoSuchKey"}, false},
+		{"other", errors.New("connection reset"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := throttledError(tt.err)
+
+			if got := errors.Is(err, ErrThrottled); got != tt.wantThrottled {
+				t.Fatalf("errors.Is(%v, ErrThrottled) = %v, want %v", err, got, tt.wantThrottled)
+			}
+
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v to wrap %v", err, tt.err)
+			}
+
+			if tt.wantThrottled && throttledError(err) != err {
+				t.Error("expected a throttled error not to be wrapped twice")
+			}
+		})
+	}
+}
+
+func TestNoLifecycleConfiguration(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no configuration", &smithy.GenericAPIError{Code: "NoSuchLifecycleConfiguration"}, true},
+		{"wrapped", fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "NoSuchLifecycleConfiguration"}), true},
+		{"other api error", &smithy.GenericAPIError{Code: "AccessDenied", Message: "NoSuchLifecycleConfiguration"}, false},
+		{"other", errors.New("NoSuchLifecycleConfiguration"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := noLifecycleConfiguration(tt.err); got != tt.want {
+				t.Errorf("noLifecycleConfiguration(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectChecksum(t *testing.T) {
+	content := []byte("archived document")
+	sha := utils.ChecksumSHA256(content)
+	md5ETag := `"0c8a40af61ad0fd3ff4fd0a8a2da7d4d"`
+
+	tests := []struct {
+		name        string
+		metadata    map[string]string
+		etag        string
+		sse         s3types.ServerSideEncryption
+		customerKey *string
+		want        string // Hex sum to verify against, empty if nothing is verified
+	}{
+		{"stored sha256", map[string]string{checksumMetadataKey: sha}, md5ETag, "", nil, sha},
+		{"sha256 preferred with kms", map[string]string{checksumMetadataKey: sha}, `"abc"`, s3types.ServerSideEncryptionAwsKms, nil, sha},
+		{"md5 etag", nil, md5ETag, s3types.ServerSideEncryptionAes256, nil, "0c8a40af61ad0fd3ff4fd0a8a2da7d4d"},
+		{"kms etag", nil, md5ETag, s3types.ServerSideEncryptionAwsKms, nil, ""},
+		{"customer key etag", nil, md5ETag, "", aws.String("AES256"), ""},
+		{"multipart etag", nil, `"0c8a40af61ad0fd3ff4fd0a8a2da7d4d-3"`, "", nil, ""},
+		{"malformed sha256", map[string]string{checksumMetadataKey: "xyz"}, `"abc"`, "", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, sum := objectChecksum(tt.metadata, aws.String(tt.etag), tt.sse, tt.customerKey)
+
+			if tt.want == "" {
+				if h != nil {
+					t.Fatalf("objectChecksum() = %x, want nothing to verify", sum)
+				}
+				return
+			}
+
+			if h == nil || hex.EncodeToString(sum) != tt.want {
+				t.Fatalf("objectChecksum() = %x, want %s", sum, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetChecksum(t *testing.T) {
+	metadata := map[string]string{"owner": "billing"}
+	input := &s3.PutObjectInput{Metadata: metadata}
+	sum := sha256.Sum256([]byte("archived document"))
+
+	setChecksum(input, sum[:])
+
+	if input.Metadata[checksumMetadataKey] != hex.EncodeToString(sum[:]) || input.Metadata["owner"] != "billing" {
+		t.Errorf("Metadata = %v", input.Metadata)
+	}
+	if _, ok := metadata[checksumMetadataKey]; ok {
+		t.Error("expected the caller's metadata not to be modified")
+	}
+	if aws.ToString(input.ChecksumSHA256) != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Errorf("ChecksumSHA256 = %s", aws.ToString(input.ChecksumSHA256))
+	}
+
+	if err := checkChecksum("a.txt", sum[:], strings.ToUpper(hex.EncodeToString(sum[:]))); err != nil {
+		t.Errorf("checkChecksum() error = %v for a matching checksum", err)
+	}
+	if err := checkChecksum("a.txt", sum[:], utils.ChecksumSHA256(nil)); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("checkChecksum() error = %v, want ErrChecksumMismatch", err)
+	}
+}
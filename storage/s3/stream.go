@@ -0,0 +1,250 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+const defaultPartSize = 8 * 1024 * 1024
+
+// StreamOptions configures DownloadStream and DownloadToFile. At most PartSize * Concurrency
+// bytes are held in memory.
+type StreamOptions struct {
+	PartSize    int64 // Bytes fetched per ranged GET (default 8MB)
+	Concurrency int   // Parts fetched at once (default 4)
+	DownloadOptions
+}
+
+func getStreamOptions(options ...StreamOptions) StreamOptions {
+	var opts StreamOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.PartSize <= 0 {
+		opts.PartSize = defaultPartSize
+	}
+	opts.Concurrency = utils.IntOrDefault(opts.Concurrency, 4)
+
+	return opts
+}
+
+// byteRange is an inclusive range of bytes of an object
+type byteRange struct {
+	start, end int64
+}
+
+// partRanges splits an object of size bytes into parts of partSize
+func partRanges(size, partSize int64) []byteRange {
+	var parts []byteRange
+	for start := int64(0); start < size; start += partSize {
+		parts = append(parts, byteRange{start: start, end: min(start+partSize, size) - 1})
+	}
+	return parts
+}
+
+// DownloadStream writes the content of key to w, fetching parts of the object with
+// concurrent ranged GETs so large objects never have to fit in memory. It returns the
+// number of bytes written. Archived objects and checksum mismatches fail as with Download,
+// though the mismatch is only known once everything was written.
+//
+// Example:
+//
+//	f, err := os.Create("export.csv")
+//	n, err := client.DownloadStream(ctx, "exports/2024.csv", f, s3.StreamOptions{Concurrency: 8})
+func (s *Client) DownloadStream(ctx context.Context, key string, w io.Writer, options ...StreamOptions) (int64, error) {
+	opts := getStreamOptions(options...)
+
+	head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get size of %s from S3: %w", key, err)
+	}
+
+	parts := partRanges(aws.ToInt64(head.ContentLength), opts.PartSize)
+
+	h, want := objectChecksum(head.Metadata, head.ETag, head.ServerSideEncryption, head.SSECustomerAlgorithm)
+	if h != nil {
+		w = io.MultiWriter(w, h)
+	}
+
+	written, err := streamParts(ctx, w, parts, opts.Concurrency, func(ctx context.Context, part byteRange) ([]byte, error) {
+		output, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.Bucket),
+			Key:    aws.String(s.fullKey(key)),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-%d", part.start, part.end)),
+			// Fail rather than mix parts of two versions if the object is replaced meanwhile
+			IfMatch: head.ETag,
+		})
+		if err != nil {
+			var invalidState *s3types.InvalidObjectState
+			if errors.As(err, &invalidState) {
+				return nil, s.archivedError(ctx, key, opts.DownloadOptions)
+			}
+			return nil, fmt.Errorf("failed to download %s from S3: %w", key, err)
+		}
+		defer output.Body.Close()
+
+		var buf bytes.Buffer
+		buf.Grow(int(part.end - part.start + 1))
+
+		if _, err := buf.ReadFrom(output.Body); err != nil {
+			return nil, fmt.Errorf("failed to download %s from S3: %w", key, err)
+		}
+
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return written, err
+	}
+
+	if h != nil && !bytes.Equal(h.Sum(nil), want) {
+		return written, checksumError(key)
+	}
+
+	return written, nil
+}
+
+// Reader returns the content of key as a stream, for objects too large to Download into
+// memory in one piece. Archived objects fail as with Download. If the content doesn't match
+// the object's checksum, the final read fails with ErrChecksumMismatch instead of io.EOF.
+// Close the reader when done.
+//
+// Example:
+//
+//	r, err := client.Reader(ctx, "exports/2024.csv")
+//	defer r.Close()
+//	rows := csv.NewReader(r)
+func (s *Client) Reader(ctx context.Context, key string, options ...DownloadOptions) (io.ReadCloser, error) {
+	var opts DownloadOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	output, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
+	})
+	if err != nil {
+		var invalidState *s3types.InvalidObjectState
+		if errors.As(err, &invalidState) {
+			return nil, s.archivedError(ctx, key, opts)
+		}
+		return nil, fmt.Errorf("failed to download %s from S3: %w", key, err)
+	}
+
+	return verifyingReader(output.Body, output), nil
+}
+
+// DownloadToFile downloads key to path as DownloadStream does, through a temporary file so
+// a failed download never leaves a partial file behind. It returns the size of the file.
+//
+// Example:
+//
+//	size, err := client.DownloadToFile(ctx, "backups/db.tar.gz", "/tmp/db.tar.gz")
+func (s *Client) DownloadToFile(ctx context.Context, key, path string, options ...StreamOptions) (int64, error) {
+	return writeFileAtomic(path, func(w io.Writer) (int64, error) {
+		return s.DownloadStream(ctx, key, w, options...)
+	})
+}
+
+// streamParts fetches parts with up to concurrency fetches at once and writes them to w in
+// order. A fetched part holds its slot until it is written, bounding memory use.
+func streamParts(ctx context.Context, w io.Writer, parts []byteRange, concurrency int, fetch func(ctx context.Context, part byteRange) ([]byte, error)) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	slots := make(chan struct{}, max(concurrency, 1))
+	results := make([]chan result, len(parts))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	go func() {
+		for i, part := range parts {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func() {
+				data, err := fetch(ctx, part)
+				results[i] <- result{data, err}
+			}()
+		}
+	}()
+
+	var written int64
+
+	for i := range parts {
+		var part result
+
+		select {
+		case part = <-results[i]:
+		case <-ctx.Done():
+			return written, ctx.Err()
+		}
+
+		<-slots
+
+		if part.err != nil {
+			return written, part.err
+		}
+
+		n, err := w.Write(part.data)
+		written += int64(n)
+		if err != nil {
+			return written, fmt.Errorf("failed to write download: %w", err)
+		}
+	}
+
+	return written, nil
+}
+
+// writeFileAtomic writes path through a temporary file in the same directory that is renamed
+// into place once write succeeds
+func writeFileAtomic(path string, write func(w io.Writer) (int64, error)) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to move download to %s: %w", path, err)
+	}
+
+	return size, nil
+}
@@ -0,0 +1,291 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// TransferOptions configures DownloadPrefix and UploadDir
+type TransferOptions struct {
+	Retries    int                             // Attempts per file (default 3)
+	RetryDelay time.Duration                   // Delay between attempts (default 1s)
+	OnProgress func(progress TransferProgress) // Called after each file completes or fails (optional)
+}
+
+// TransferProgress reports the state of a bulk transfer after a file completes or fails
+type TransferProgress struct {
+	Key       string // Key of the file that just finished
+	Err       error  // Error for the file, nil if it succeeded
+	Completed int    // Files transferred so far
+	Failed    int    // Files that failed after all retries
+	Total     int    // Files in the transfer
+	Bytes     int64  // Bytes transferred so far
+}
+
+func getTransferOptions(options ...TransferOptions) TransferOptions {
+	opts := TransferOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Retries = utils.IntOrDefault(opts.Retries, 3)
+	opts.RetryDelay = utils.DurationOrDefault(opts.RetryDelay, time.Second)
+
+	return opts
+}
+
+// transferFile is a single file in a bulk transfer
+type transferFile struct {
+	key  string // Key relative to the client's key prefix
+	path string // Local file path
+}
+
+// DownloadPrefix downloads every object under prefix into destDir in parallel, keeping the
+// key structure below prefix as directories. Failed files are retried and don't stop the
+// rest of the transfer; the returned error joins the errors of all files that failed.
+// Returns the number of files downloaded.
+//
+// Example:
+//
+//	count, err := client.DownloadPrefix(ctx, "batches/2026-10-16", "/tmp/batch", 16, s3.TransferOptions{
+//	    OnProgress: func(p s3.TransferProgress) { log.Infof("%d/%d files", p.Completed, p.Total) },
+//	})
+func (s *Client) DownloadPrefix(ctx context.Context, prefix, destDir string, concurrency int, options ...TransferOptions) (int, error) {
+	opts := getTransferOptions(options...)
+
+	root := s.fullKey(strings.TrimPrefix(prefix, "/"))
+
+	var files []transferFile
+
+	paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(root),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list objects under %s: %w", root, err)
+		}
+
+		for _, obj := range page.Contents {
+			fullKey := aws.ToString(obj.Key)
+
+			// Skip folder placeholder objects
+			if strings.HasSuffix(fullKey, "/") {
+				continue
+			}
+
+			rel := strings.TrimPrefix(strings.TrimPrefix(fullKey, root), "/")
+			if rel == "" {
+				// prefix is the key of a single object
+				rel = filepath.Base(fullKey)
+			}
+
+			path, err := safeJoin(destDir, rel)
+			if err != nil {
+				return 0, err
+			}
+
+			files = append(files, transferFile{key: s.relativeKey(fullKey), path: path})
+		}
+	}
+
+	return runTransfer(ctx, files, concurrency, opts, func(ctx context.Context, file transferFile) (int64, error) {
+		return s.downloadToFile(ctx, file.key, file.path)
+	})
+}
+
+// UploadDir uploads every file under srcDir to prefix in parallel, keeping the directory
+// structure in the keys. Content types are derived from file extensions. Failed files are
+// retried and don't stop the rest of the transfer; the returned error joins the errors of
+// all files that failed. Returns the number of files uploaded.
+func (s *Client) UploadDir(ctx context.Context, srcDir, prefix string, concurrency int, options ...TransferOptions) (int, error) {
+	opts := getTransferOptions(options...)
+
+	prefix = strings.Trim(prefix, "/")
+
+	var files []transferFile
+
+	err := filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if prefix != "" {
+			key = prefix + "/" + key
+		}
+
+		files = append(files, transferFile{key: key, path: path})
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read directory %s: %w", srcDir, err)
+	}
+
+	return runTransfer(ctx, files, concurrency, opts, func(ctx context.Context, file transferFile) (int64, error) {
+		return s.uploadFromFile(ctx, file.path, file.key)
+	})
+}
+
+// runTransfer runs transfer for each file with up to concurrency files in flight
+func runTransfer(ctx context.Context, files []transferFile, concurrency int, opts TransferOptions, transfer func(ctx context.Context, file transferFile) (int64, error)) (int, error) {
+	concurrency = utils.IntOrDefault(concurrency, 8)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		errs     []error
+		progress = TransferProgress{Total: len(files)}
+	)
+
+	work := make(chan transferFile)
+
+	for i := 0; i < concurrency && i < len(files); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for file := range work {
+				size, err := utils.Retry(ctx, opts.Retries, opts.RetryDelay, func(int) (int64, error) {
+					return transfer(ctx, file)
+				})
+
+				mu.Lock()
+
+				progress.Key = file.key
+				progress.Err = err
+
+				if err != nil {
+					progress.Failed++
+					errs = append(errs, fmt.Errorf("%s: %w", file.key, err))
+				} else {
+					progress.Completed++
+					progress.Bytes += size
+				}
+
+				if opts.OnProgress != nil {
+					opts.OnProgress(progress)
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case work <- file:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	return progress.Completed, errors.Join(errs...)
+}
+
+// downloadToFile streams an object to path through a temporary file, so a failed download
+// never leaves a partial file behind
+func (s *Client) downloadToFile(ctx context.Context, key, path string) (int64, error) {
+	output, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.fullKey(key)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download file from S3: %w", err)
+	}
+	defer output.Body.Close()
+
+	return writeFileAtomic(path, func(w io.Writer) (int64, error) {
+		n, err := io.Copy(w, verifyingReader(output.Body, output))
+		if errors.Is(err, ErrChecksumMismatch) {
+			return n, checksumError(key)
+		}
+		return n, err
+	})
+}
+
+// uploadFromFile streams a local file to key
+func (s *Client) uploadFromFile(ctx context.Context, path, key string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	// Hash the file up front so S3 verifies what it receives, then send it from the start
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.Bucket),
+		Key:           aws.String(s.fullKey(key)),
+		Body:          file,
+		ContentLength: aws.Int64(info.Size()),
+		ContentType:   aws.String(utils.GetContentTypeFromURL(path)),
+	}
+	setChecksum(input, h.Sum(nil))
+
+	_, err = s.s3Client.PutObject(ctx, input)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// safeJoin joins a key below dir, rejecting keys that would escape it
+func safeJoin(dir, key string) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(key))
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("object key %q escapes destination directory", key)
+	}
+
+	return path, nil
+}
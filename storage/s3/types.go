@@ -1,6 +1,10 @@
 package s3
 
-import "time"
+import (
+	"time"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
 
 type S3ReturnType string
 
@@ -16,6 +20,18 @@ type UploadOptions struct {
 	FileSize        int64
 	Metadata        map[string]string
 	PresignedUrlTTL time.Duration
+	ExpiresAt       time.Time // Tags the object with its expiry date for lifecycle rules or the cleanup job (optional)
+	Deduplicate     bool      // Store under a SHA-256 content key and reuse an existing object with the same content
+	ContentPrefix   string    // Key prefix for deduplicated uploads (default "content")
+	ChecksumSHA256  string    // Hex SHA-256 the content must have, failing with ErrChecksumMismatch otherwise (optional)
+
+	// ServerSideEncryption encrypts the object with S3 managed keys (AES256) or KMS
+	// (aws:kms); it defaults to aws:kms when KmsKeyId is set, else to the bucket default
+	ServerSideEncryption s3types.ServerSideEncryption
+	KmsKeyId             string               // KMS key for aws:kms encryption (default the account's aws/s3 key)
+	StorageClass         s3types.StorageClass // e.g. STANDARD_IA or GLACIER_IR (default STANDARD)
+	CacheControl         string               // Cache-Control header served with the object
+	ContentDisposition   string               // Content-Disposition header, e.g. `attachment; filename="report.pdf"`
 }
 
 // FileInfo contains information about a stored file
@@ -25,4 +41,9 @@ type FileInfo struct {
 	ContentType  string     `json:"content_type,omitempty"`
 	LastModified *time.Time `json:"last_modified,omitempty"`
 	S3Key        string     `json:"s3_key,omitempty"`
+
+	StorageClass      string     `json:"storage_class,omitempty"`
+	Archived          bool       `json:"archived,omitempty"`            // In Glacier or Deep Archive without a restored copy
+	RestoreInProgress bool       `json:"restore_in_progress,omitempty"` // A restore request is running
+	RestoredUntil     *time.Time `json:"restored_until,omitempty"`      // When the restored copy expires
 }
@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectWriter uploads what is written to it as an object. Data is buffered in parts of 8MB
+// and sent with a multipart upload, so objects of any size can be written without holding
+// them in memory. Objects smaller than a part are sent with a single PutObject on Close.
+type ObjectWriter struct {
+	ctx      context.Context
+	client   *Client
+	key      string // Full object key
+	input    *s3.PutObjectInput
+	buf      []byte
+	uploadId *string
+	parts    []s3types.CompletedPart
+	checksum string    // Expected hex SHA-256 of the content
+	hash     hash.Hash // SHA-256 of the parts uploaded so far
+	err      error
+	closed   bool
+}
+
+// Writer returns a writer that uploads to key. The object only appears once Close succeeds;
+// call Abort instead to discard a write that failed midway. Deduplicate isn't supported, as
+// the content key needs the whole content up front. With ChecksumSHA256 set, Close fails
+// with ErrChecksumMismatch and discards the object if the content doesn't match. S3 checks
+// every part it receives, but objects larger than a part keep no checksum for downloads to
+// verify against.
+//
+// Example:
+//
+//	w, err := client.Writer(ctx, "exports/2024.csv", s3.UploadOptions{ContentType: "text/csv"})
+//	if _, err := io.Copy(w, rows); err != nil {
+//	    w.Abort()
+//	    return err
+//	}
+//	err = w.Close()
+func (s *Client) Writer(ctx context.Context, key string, options ...UploadOptions) (*ObjectWriter, error) {
+	opts := getUploadOptions(options...)
+
+	if opts.Deduplicate {
+		return nil, errors.New("deduplicated uploads aren't supported by Writer")
+	}
+
+	input := &s3.PutObjectInput{}
+	if err := applyObjectOptions(input, opts); err != nil {
+		return nil, err
+	}
+
+	return &ObjectWriter{
+		ctx:      ctx,
+		client:   s,
+		key:      s.fullKey(key),
+		input:    input,
+		checksum: opts.ChecksumSHA256,
+		hash:     sha256.New(),
+	}, nil
+}
+
+// Write buffers p, uploading every full part
+func (w *ObjectWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed object writer")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf = append(w.buf, p...)
+
+	for len(w.buf) >= defaultPartSize {
+		if err := w.uploadPart(w.buf[:defaultPartSize]); err != nil {
+			w.err = err
+			return 0, err
+		}
+		w.buf = append(w.buf[:0], w.buf[defaultPartSize:]...)
+	}
+
+	return len(p), nil
+}
+
+// Close uploads the remaining data and completes the object. A failed write is aborted.
+func (w *ObjectWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+
+	if w.err != nil {
+		w.Abort()
+		return w.err
+	}
+	w.closed = true
+
+	if w.uploadId == nil {
+		sum := sha256.Sum256(w.buf)
+		if err := checkChecksum(w.key, sum[:], w.checksum); err != nil {
+			w.err = err
+			return err
+		}
+
+		input := *w.input
+		input.Bucket = aws.String(w.client.Bucket)
+		input.Key = aws.String(w.key)
+		input.Body = bytes.NewReader(w.buf)
+		input.ContentLength = aws.Int64(int64(len(w.buf)))
+		setChecksum(&input, sum[:])
+
+		if _, err := w.client.s3Client.PutObject(w.ctx, &input); err != nil {
+			w.err = fmt.Errorf("failed to upload %s to S3: %w", w.key, err)
+		}
+		return w.err
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.uploadPart(w.buf); err != nil {
+			w.err = err
+			w.abort()
+			return err
+		}
+	}
+
+	if err := checkChecksum(w.key, w.hash.Sum(nil), w.checksum); err != nil {
+		w.err = err
+		w.abort()
+		return err
+	}
+
+	_, err := w.client.s3Client.CompleteMultipartUpload(w.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(w.client.Bucket),
+		Key:             aws.String(w.key),
+		UploadId:        w.uploadId,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: w.parts},
+	})
+	if err != nil {
+		w.err = fmt.Errorf("failed to complete upload of %s to S3: %w", w.key, err)
+		w.abort()
+	}
+
+	return w.err
+}
+
+// Abort discards the write, removing the parts uploaded so far
+func (w *ObjectWriter) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if w.err == nil {
+		w.err = errors.New("object write aborted")
+	}
+
+	return w.abort()
+}
+
+func (w *ObjectWriter) abort() error {
+	w.buf = nil
+
+	if w.uploadId == nil {
+		return nil
+	}
+
+	_, err := w.client.s3Client.AbortMultipartUpload(context.WithoutCancel(w.ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(w.client.Bucket),
+		Key:      aws.String(w.key),
+		UploadId: w.uploadId,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort upload of %s to S3: %w", w.key, err)
+	}
+
+	return nil
+}
+
+// uploadPart sends data as the next part, starting the multipart upload on the first part
+func (w *ObjectWriter) uploadPart(data []byte) error {
+	if w.uploadId == nil {
+		upload, err := w.client.s3Client.CreateMultipartUpload(w.ctx, &s3.CreateMultipartUploadInput{
+			Bucket:               aws.String(w.client.Bucket),
+			Key:                  aws.String(w.key),
+			ContentType:          w.input.ContentType,
+			Metadata:             w.input.Metadata,
+			Tagging:              w.input.Tagging,
+			ServerSideEncryption: w.input.ServerSideEncryption,
+			SSEKMSKeyId:          w.input.SSEKMSKeyId,
+			StorageClass:         w.input.StorageClass,
+			CacheControl:         w.input.CacheControl,
+			ContentDisposition:   w.input.ContentDisposition,
+			ChecksumAlgorithm:    s3types.ChecksumAlgorithmSha256,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start upload of %s to S3: %w", w.key, err)
+		}
+		w.uploadId = upload.UploadId
+	}
+
+	partNumber := aws.Int32(int32(len(w.parts) + 1))
+	sum := sha256.Sum256(data)
+
+	result, err := w.client.s3Client.UploadPart(w.ctx, &s3.UploadPartInput{
+		Bucket:         aws.String(w.client.Bucket),
+		Key:            aws.String(w.key),
+		UploadId:       w.uploadId,
+		PartNumber:     partNumber,
+		Body:           bytes.NewReader(data),
+		ContentLength:  aws.Int64(int64(len(data))),
+		ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d of %s to S3: %w", *partNumber, w.key, err)
+	}
+
+	w.hash.Write(data)
+	w.parts = append(w.parts, s3types.CompletedPart{
+		ETag:           result.ETag,
+		PartNumber:     partNumber,
+		ChecksumSHA256: result.ChecksumSHA256,
+	})
+
+	return nil
+}
@@ -0,0 +1,198 @@
+// Package signedurl mints short-lived HMAC-signed tokens for storage keys so services can
+// hand out download links through their own API instead of raw S3 presigned URLs.
+package signedurl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed or have a bad signature
+	ErrInvalidToken = errors.New("invalid file token")
+	// ErrTokenExpired is returned for correctly signed tokens past their expiry
+	ErrTokenExpired = errors.New("file token expired")
+)
+
+// Options configures a signer
+type Options struct {
+	Secret     []byte        // HMAC secret, defaults to the FILE_TOKEN_SECRET env variable
+	Ttl        time.Duration // Default token lifetime (default 15m)
+	QueryParam string        // Query parameter carrying the token in URLs (default "token")
+}
+
+// MintOptions configures a single token
+type MintOptions struct {
+	Ttl      time.Duration // Overrides the signer's default lifetime
+	Filename string        // Download filename for the Content-Disposition header (optional)
+}
+
+// Claims are the contents of a verified token
+type Claims struct {
+	Key       string    `json:"k"`
+	Filename  string    `json:"f,omitempty"`
+	Expiry    int64     `json:"e"` // Unix expiry as signed in the token
+	ExpiresAt time.Time `json:"-"`
+}
+
+// Signer mints and verifies file tokens
+type Signer struct {
+	opts Options
+	now  func() time.Time
+}
+
+// New creates a signer
+//
+// Example:
+//
+//	signer, err := signedurl.New(signedurl.Options{Ttl: 5 * time.Minute})
+//	link, err := signer.URL("https://api.example.com/files", "statements/123.pdf")
+func New(options ...Options) (*Signer, error) {
+	opts := Options{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if len(opts.Secret) == 0 {
+		opts.Secret = []byte(os.Getenv("FILE_TOKEN_SECRET"))
+	}
+
+	if len(opts.Secret) < 16 {
+		return nil, fmt.Errorf("file token secret must be at least 16 bytes")
+	}
+
+	opts.Ttl = utils.DurationOrDefault(opts.Ttl, 15*time.Minute)
+	opts.QueryParam = utils.StringOrDefault(opts.QueryParam, "token")
+
+	return &Signer{opts: opts, now: time.Now}, nil
+}
+
+// Mint returns a token granting access to key until it expires. The token is URL safe.
+func (s *Signer) Mint(key string, options ...MintOptions) (string, error) {
+	var opts MintOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	ttl := utils.DurationOrDefault(opts.Ttl, s.opts.Ttl)
+
+	payload, err := json.Marshal(Claims{
+		Key:      key,
+		Filename: opts.Filename,
+		Expiry:   s.now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token claims: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + s.sign(encoded), nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims
+func (s *Signer) Verify(token string) (Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	claims.ExpiresAt = time.Unix(claims.Expiry, 0)
+
+	if !s.now().Before(claims.ExpiresAt) {
+		return claims, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// URL mints a token for key and appends it to baseURL as a query parameter
+func (s *Signer) URL(baseURL, key string, options ...MintOptions) (string, error) {
+	token, err := s.Mint(key, options...)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	query := u.Query()
+	query.Set(s.opts.QueryParam, token)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Handler serves files for valid tokens, reading them with fetch (e.g. an s3 Client's
+// Download method). Invalid tokens get 403 and expired tokens 410.
+//
+// Example:
+//
+//	mux.Handle("/files", signer.Handler(s3Client.Download))
+func (s *Signer) Handler(fetch func(ctx context.Context, key string) ([]byte, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.Verify(r.URL.Query().Get(s.opts.QueryParam))
+
+		if errors.Is(err, ErrTokenExpired) {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		file, err := fetch(r.Context(), claims.Key)
+		if err != nil {
+			log.Errorf("Failed to fetch file %s for signed URL: %v", claims.Key, err)
+			http.Error(w, "file not available", http.StatusNotFound)
+			return
+		}
+
+		filename := utils.StringOrDefault(claims.Filename, filepath.Base(claims.Key))
+
+		w.Header().Set("Content-Type", utils.GetContentTypeFromURL(filename))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Write(file)
+	})
+}
+
+func (s *Signer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.opts.Secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
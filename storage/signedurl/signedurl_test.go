@@ -0,0 +1,97 @@
+package signedurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	signer, err := New(Options{Secret: []byte("0123456789abcdef0123456789abcdef"), Ttl: time.Minute})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return signer
+}
+
+func TestMintVerify(t *testing.T) {
+	signer := newTestSigner(t)
+
+	token, err := signer.Mint("statements/123.pdf", MintOptions{Filename: "statement.pdf"})
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+
+	claims, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if claims.Key != "statements/123.pdf" || claims.Filename != "statement.pdf" {
+		t.Errorf("Verify() claims = %+v", claims)
+	}
+
+	tampered := "x" + token[1:]
+	if _, err := signer.Verify(tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify(tampered) error = %v, want ErrInvalidToken", err)
+	}
+
+	other, _ := New(Options{Secret: []byte("another-secret-of-32-bytes-long!")})
+	if _, err := other.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() with another secret error = %v, want ErrInvalidToken", err)
+	}
+
+	signer.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := signer.Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Verify(expired) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	t.Setenv("FILE_TOKEN_SECRET", "")
+
+	if _, err := New(); err == nil {
+		t.Error("New() without a secret succeeded, want error")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	signer := newTestSigner(t)
+
+	server := httptest.NewServer(signer.Handler(func(ctx context.Context, key string) ([]byte, error) {
+		if key != "statements/123.pdf" {
+			return nil, errors.New("not found")
+		}
+		return []byte("pdf"), nil
+	}))
+	defer server.Close()
+
+	link, err := signer.URL(server.URL+"/files", "statements/123.pdf")
+	if err != nil {
+		t.Fatalf("URL() error = %v", err)
+	}
+
+	resp, err := http.Get(link)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Errorf("expected 200 application/pdf, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(server.URL + "/files?" + url.Values{"token": {"bogus"}}.Encode())
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for an invalid token, got %d", resp.StatusCode)
+	}
+}
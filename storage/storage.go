@@ -0,0 +1,380 @@
+// Package storage puts the S3, Cloud Storage, Azure Blob, local filesystem and in-memory
+// backends behind one Storage interface, so code can store files without knowing where they
+// end up.
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/finch-technologies/go-utils/storage/azblob"
+	"github.com/finch-technologies/go-utils/storage/filesystem"
+	"github.com/finch-technologies/go-utils/storage/gcs"
+	"github.com/finch-technologies/go-utils/storage/memory"
+	"github.com/finch-technologies/go-utils/storage/s3"
+	"github.com/finch-technologies/go-utils/utils"
+)
+
+// ErrChecksumMismatch is returned when stored content no longer matches its checksum, by
+// the S3 and GCS backends and by the local backend with Checksums set
+var ErrChecksumMismatch = utils.ErrChecksumMismatch
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Storage stores files by key. Keys are slash separated paths, relative to the backend's
+// key prefix or base path.
+type Storage interface {
+	// Upload stores data under key, replacing any existing file
+	Upload(ctx context.Context, data []byte, key string) error
+	// Download returns the content of key
+	Download(ctx context.Context, key string) ([]byte, error)
+	// Exists reports whether a file is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the file under key
+	Delete(ctx context.Context, key string) error
+	// List returns the files whose keys start with prefix
+	List(ctx context.Context, prefix string) ([]FileInfo, error)
+	// GetInfo returns the size, content type and modification time of key
+	GetInfo(ctx context.Context, key string) (*FileInfo, error)
+	// Reader streams the content of key; the caller closes it
+	Reader(ctx context.Context, key string) (io.ReadCloser, error)
+	// Writer streams a new file to key, which appears once the writer is closed
+	Writer(ctx context.Context, key string) (io.WriteCloser, error)
+	// Copy copies the file under src to dst
+	Copy(ctx context.Context, src, dst string) error
+	// Move moves the file under src to dst
+	Move(ctx context.Context, src, dst string) error
+}
+
+// NewS3 returns client as a Storage
+//
+// Example:
+//
+//	client, err := s3.New(s3.Config{Bucket: "documents"})
+//	store := storage.NewS3(client)
+func NewS3(client *s3.Client) Storage {
+	return &s3Storage{client: client}
+}
+
+// NewGCS returns client as a Storage
+//
+// Example:
+//
+//	client, err := gcs.New(gcs.Config{Bucket: "documents"})
+//	store := storage.NewGCS(client)
+func NewGCS(client *gcs.Client) Storage {
+	return &s3Storage{client: client.Client}
+}
+
+// NewAzure returns client as a Storage
+//
+// Example:
+//
+//	client, err := azblob.New(azblob.Config{Container: "documents"})
+//	store := storage.NewAzure(client)
+func NewAzure(client *azblob.Client) Storage {
+	return &azureStorage{client: client}
+}
+
+// NewLocal returns local as a Storage
+//
+// Example:
+//
+//	local, err := filesystem.Init(filesystem.LocalStorageOptions{BasePath: "/var/data"})
+//	store := storage.NewLocal(local)
+func NewLocal(local *filesystem.LocalStorage) Storage {
+	return &localStorage{local: local}
+}
+
+// NewMemory returns files as a Storage
+//
+// Example:
+//
+//	store := storage.NewMemory(memory.New())
+func NewMemory(files *memory.Storage) Storage {
+	return &memoryStorage{files: files}
+}
+
+type s3Storage struct {
+	client *s3.Client
+}
+
+func (s *s3Storage) Upload(ctx context.Context, data []byte, key string) error {
+	_, err := s.client.Upload(ctx, data, key)
+	return err
+}
+
+func (s *s3Storage) Download(ctx context.Context, key string) ([]byte, error) {
+	return s.client.Download(ctx, key)
+}
+
+func (s *s3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	return s.client.FileExists(ctx, key)
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.DeleteFile(ctx, key)
+}
+
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	result, err := s.client.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileInfo, len(result.Files))
+	for i, file := range result.Files {
+		files[i] = FileInfo{Key: file.Name, Size: file.Size, LastModified: timeOrZero(file.LastModified)}
+	}
+
+	return files, nil
+}
+
+func (s *s3Storage) GetInfo(ctx context.Context, key string) (*FileInfo, error) {
+	info, err := s.client.GetS3FileInfo(ctx, s.client.Bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileInfo{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: timeOrZero(info.LastModified),
+	}, nil
+}
+
+func (s *s3Storage) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.Reader(ctx, key)
+}
+
+func (s *s3Storage) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return s.client.Writer(ctx, key)
+}
+
+func (s *s3Storage) Copy(ctx context.Context, src, dst string) error {
+	return s.client.Copy(ctx, src, dst)
+}
+
+func (s *s3Storage) Move(ctx context.Context, src, dst string) error {
+	return s.client.Move(ctx, src, dst)
+}
+
+type localStorage struct {
+	local *filesystem.LocalStorage
+}
+
+func (s *localStorage) Upload(ctx context.Context, data []byte, key string) error {
+	_, err := s.local.Write(ctx, data, key)
+	return err
+}
+
+func (s *localStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	return s.local.Read(ctx, key)
+}
+
+func (s *localStorage) Exists(_ context.Context, key string) (bool, error) {
+	return s.local.FileExists(key), nil
+}
+
+func (s *localStorage) Delete(_ context.Context, key string) error {
+	return s.local.Delete(key)
+}
+
+func (s *localStorage) List(_ context.Context, prefix string) ([]FileInfo, error) {
+	files, err := s.local.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]FileInfo, len(files))
+	for i, file := range files {
+		result[i] = localFileInfo(file)
+	}
+
+	return result, nil
+}
+
+func (s *localStorage) GetInfo(_ context.Context, key string) (*FileInfo, error) {
+	file, err := s.local.GetFileInfo(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info := localFileInfo(*file)
+	return &info, nil
+}
+
+func (s *localStorage) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.local.Reader(ctx, key)
+}
+
+func (s *localStorage) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return s.local.Writer(ctx, key)
+}
+
+func (s *localStorage) Copy(ctx context.Context, src, dst string) error {
+	return s.local.Copy(ctx, src, dst)
+}
+
+func (s *localStorage) Move(ctx context.Context, src, dst string) error {
+	return s.local.Move(ctx, src, dst)
+}
+
+type azureStorage struct {
+	client *azblob.Client
+}
+
+func (s *azureStorage) Upload(ctx context.Context, data []byte, key string) error {
+	return s.client.Upload(ctx, data, key)
+}
+
+func (s *azureStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	return s.client.Download(ctx, key)
+}
+
+func (s *azureStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return s.client.FileExists(ctx, key)
+}
+
+func (s *azureStorage) Delete(ctx context.Context, key string) error {
+	return s.client.DeleteFile(ctx, key)
+}
+
+func (s *azureStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	files, err := s.client.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]FileInfo, len(files))
+	for i, file := range files {
+		result[i] = azureFileInfo(file)
+	}
+
+	return result, nil
+}
+
+func (s *azureStorage) GetInfo(ctx context.Context, key string) (*FileInfo, error) {
+	file, err := s.client.GetFileInfo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	info := azureFileInfo(*file)
+	return &info, nil
+}
+
+func (s *azureStorage) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.Reader(ctx, key)
+}
+
+func (s *azureStorage) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return s.client.Writer(ctx, key)
+}
+
+func (s *azureStorage) Copy(ctx context.Context, src, dst string) error {
+	return s.client.Copy(ctx, src, dst)
+}
+
+func (s *azureStorage) Move(ctx context.Context, src, dst string) error {
+	return s.client.Move(ctx, src, dst)
+}
+
+type memoryStorage struct {
+	files *memory.Storage
+}
+
+func (s *memoryStorage) Upload(ctx context.Context, data []byte, key string) error {
+	return s.files.Write(ctx, data, key)
+}
+
+func (s *memoryStorage) Download(ctx context.Context, key string) ([]byte, error) {
+	return s.files.Read(ctx, key)
+}
+
+func (s *memoryStorage) Exists(_ context.Context, key string) (bool, error) {
+	return s.files.FileExists(key), nil
+}
+
+func (s *memoryStorage) Delete(_ context.Context, key string) error {
+	return s.files.Delete(key)
+}
+
+func (s *memoryStorage) List(_ context.Context, prefix string) ([]FileInfo, error) {
+	files := s.files.List(prefix)
+
+	result := make([]FileInfo, len(files))
+	for i, file := range files {
+		result[i] = memoryFileInfo(file)
+	}
+
+	return result, nil
+}
+
+func (s *memoryStorage) GetInfo(_ context.Context, key string) (*FileInfo, error) {
+	file, err := s.files.GetFileInfo(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info := memoryFileInfo(*file)
+	return &info, nil
+}
+
+func (s *memoryStorage) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.files.Reader(ctx, key)
+}
+
+func (s *memoryStorage) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return s.files.Writer(ctx, key)
+}
+
+func (s *memoryStorage) Copy(ctx context.Context, src, dst string) error {
+	return s.files.Copy(ctx, src, dst)
+}
+
+func (s *memoryStorage) Move(ctx context.Context, src, dst string) error {
+	return s.files.Move(ctx, src, dst)
+}
+
+func azureFileInfo(file azblob.FileInfo) FileInfo {
+	return FileInfo{
+		Key:          file.Name,
+		Size:         file.Size,
+		ContentType:  file.ContentType,
+		LastModified: file.LastModified,
+	}
+}
+
+func memoryFileInfo(file memory.FileInfo) FileInfo {
+	return FileInfo{
+		Key:          file.Path,
+		Size:         file.Size,
+		ContentType:  file.ContentType,
+		LastModified: file.ModTime,
+	}
+}
+
+func localFileInfo(file filesystem.FileInfo) FileInfo {
+	return FileInfo{
+		Key:          file.Path,
+		Size:         file.Size,
+		ContentType:  file.ContentType,
+		LastModified: file.ModTime,
+	}
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
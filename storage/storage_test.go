@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/finch-technologies/go-utils/storage/azblob"
+	"github.com/finch-technologies/go-utils/storage/filesystem"
+)
+
+func TestLocalStorage(t *testing.T) {
+	local, err := filesystem.Init(filesystem.LocalStorageOptions{BasePath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to initialize storage: %v", err)
+	}
+
+	var store Storage = NewLocal(local)
+	ctx := context.Background()
+
+	if err := store.Upload(ctx, []byte("a,b"), "exports/a.csv"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	w, err := store.Writer(ctx, "exports/b.json")
+	if err != nil {
+		t.Fatalf("Writer() error = %v", err)
+	}
+	io.WriteString(w, `{"b":1}`)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	files, err := store.List(ctx, "exports/")
+	if err != nil || len(files) != 2 {
+		t.Fatalf("List() = %v, %v, want 2 files", files, err)
+	}
+	if files[1].Key != "exports/b.json" || files[1].Size != 7 || files[1].ContentType != "application/json" {
+		t.Errorf("List()[1] = %+v", files[1])
+	}
+
+	info, err := store.GetInfo(ctx, "exports/a.csv")
+	if err != nil || info.Size != 3 || info.LastModified.IsZero() {
+		t.Errorf("GetInfo() = %+v, %v", info, err)
+	}
+
+	r, err := store.Reader(ctx, "exports/b.json")
+	if err != nil {
+		t.Fatalf("Reader() error = %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != `{"b":1}` {
+		t.Errorf("Reader() read %q", data)
+	}
+
+	if err := store.Copy(ctx, "exports/a.csv", "archive/a.csv"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if err := store.Move(ctx, "archive/a.csv", "archive/moved.csv"); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	if err := store.Delete(ctx, "exports/a.csv"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"exports/a.csv", false},
+		{"exports/b.json", true},
+		{"archive/a.csv", false},
+		{"archive/moved.csv", true},
+	}
+
+	for _, tt := range tests {
+		if exists, err := store.Exists(ctx, tt.key); err != nil || exists != tt.want {
+			t.Errorf("Exists(%s) = %v, %v, want %v", tt.key, exists, err, tt.want)
+		}
+	}
+
+	if data, err := store.Download(ctx, "exports/b.json"); err != nil || string(data) != `{"b":1}` {
+		t.Errorf("Download() = %q, %v", data, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StorageConfig
+		wantErr bool
+	}{
+		{"memory", StorageConfig{Type: TypeMemory}, false},
+		{"local", StorageConfig{Type: TypeLocal, Local: filesystem.LocalStorageOptions{BasePath: t.TempDir()}}, false},
+		{"azure without container", StorageConfig{Type: TypeAzure, Azure: azblob.Config{Account: "acct", Key: "a2V5"}}, true},
+		{"unsupported", StorageConfig{Type: "ftp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := New(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			ctx := context.Background()
+
+			if err := store.Upload(ctx, []byte("data"), "dir/file.txt"); err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			if data, err := store.Download(ctx, "dir/file.txt"); err != nil || string(data) != "data" {
+				t.Errorf("Download() = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestGetStorage(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", string(TypeMemory))
+
+	store, err := GetStorage()
+	if err != nil {
+		t.Fatalf("GetStorage() error = %v", err)
+	}
+
+	if _, ok := store.(*memoryStorage); !ok {
+		t.Fatalf("GetStorage() = %T, want the memory backend", store)
+	}
+
+	if again, _ := GetStorage(); again != store {
+		t.Error("expected GetStorage to return the shared storage")
+	}
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/finch-technologies/go-utils/log"
+)
+
+// ErrBatcherClosed is returned when adding to a batcher that has been closed
+var ErrBatcherClosed = errors.New("batcher is closed")
+
+// BatcherOptions configures a Batcher
+type BatcherOptions[T any] struct {
+	MaxSize      int                        // Maximum items per batch (default 100)
+	MaxLatency   time.Duration              // Maximum time an item waits before its batch is flushed (default 1s)
+	QueueSize    int                        // Items buffered before Add blocks (default 10 * MaxSize)
+	FlushTimeout time.Duration              // Timeout for a single flush call (default 30s)
+	OnError      func(batch []T, err error) // Called when a flush fails, defaults to logging
+}
+
+// Batcher collects items and hands them to a flush function in batches, flushing when a
+// batch is full or its oldest item has waited MaxLatency. Items are buffered in a bounded
+// queue, so Add blocks when the flush function can't keep up instead of growing memory.
+//
+// Example:
+//
+//	batcher := utils.NewBatcher(func(ctx context.Context, batch []Event) error {
+//	    return store.WriteAll(ctx, batch)
+//	}, utils.BatcherOptions[Event]{MaxSize: 25, MaxLatency: time.Second})
+//	defer batcher.Close(ctx)
+//
+//	err := batcher.Add(ctx, event)
+type Batcher[T any] struct {
+	opts  BatcherOptions[T]
+	flush func(ctx context.Context, batch []T) error
+
+	items    chan T
+	flushReq chan chan error
+	done     chan struct{}
+	stopped  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewBatcher creates a batcher and starts its flush loop
+func NewBatcher[T any](flush func(ctx context.Context, batch []T) error, options ...BatcherOptions[T]) *Batcher[T] {
+	opts := BatcherOptions[T]{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.MaxSize = IntOrDefault(opts.MaxSize, 100)
+	opts.MaxLatency = DurationOrDefault(opts.MaxLatency, time.Second)
+	opts.QueueSize = IntOrDefault(opts.QueueSize, 10*opts.MaxSize)
+	opts.FlushTimeout = DurationOrDefault(opts.FlushTimeout, 30*time.Second)
+
+	if opts.OnError == nil {
+		opts.OnError = func(batch []T, err error) {
+			log.Errorf("Failed to flush batch of %d items: %v", len(batch), err)
+		}
+	}
+
+	b := &Batcher[T]{
+		opts:     opts,
+		flush:    flush,
+		items:    make(chan T, opts.QueueSize),
+		flushReq: make(chan chan error),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	go b.run()
+
+	return b
+}
+
+// Add queues an item, blocking while the queue is full until there is room or ctx is done
+func (b *Batcher[T]) Add(ctx context.Context, item T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrBatcherClosed
+	}
+
+	select {
+	case b.items <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAdd queues an item without blocking, returning false if the queue is full or the
+// batcher is closed
+func (b *Batcher[T]) TryAdd(item T) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return false
+	}
+
+	select {
+	case b.items <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pending returns the number of items waiting in the queue
+func (b *Batcher[T]) Pending() int {
+	return len(b.items)
+}
+
+// Flush flushes everything queued so far and waits for it to complete, returning the
+// error of the last failed flush call
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	result := make(chan error, 1)
+
+	select {
+	case b.flushReq <- result:
+	case <-b.stopped:
+		return ErrBatcherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting items, flushes what is queued and waits for the flush loop to
+// exit or ctx to be done
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Batcher[T]) run() {
+	defer close(b.stopped)
+
+	batch := make([]T, 0, b.opts.MaxSize)
+
+	timer := time.NewTimer(b.opts.MaxLatency)
+	timer.Stop()
+
+	var lastErr error
+
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := b.send(batch); err != nil {
+			lastErr = err
+		}
+
+		batch = make([]T, 0, b.opts.MaxSize)
+		timer.Stop()
+	}
+
+	add := func(item T) {
+		if len(batch) == 0 {
+			timer.Reset(b.opts.MaxLatency)
+		}
+
+		batch = append(batch, item)
+
+		if len(batch) >= b.opts.MaxSize {
+			send()
+		}
+	}
+
+	// drain moves everything currently queued into batches
+	drain := func() {
+		for {
+			select {
+			case item := <-b.items:
+				add(item)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case item := <-b.items:
+			add(item)
+
+		case <-timer.C:
+			send()
+
+		case result := <-b.flushReq:
+			lastErr = nil
+			drain()
+			send()
+			result <- lastErr
+
+		case <-b.done:
+			drain()
+			send()
+			return
+		}
+	}
+}
+
+func (b *Batcher[T]) send(batch []T) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.opts.FlushTimeout)
+	defer cancel()
+
+	_, err := TryReturn(func() (struct{}, error) {
+		return struct{}{}, b.flush(ctx, batch)
+	})
+
+	if err != nil {
+		b.opts.OnError(batch, err)
+	}
+
+	return err
+}
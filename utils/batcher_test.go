@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher_FlushesFullBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+
+	batcher := NewBatcher(func(ctx context.Context, batch []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, batch)
+		return nil
+	}, BatcherOptions[int]{MaxSize: 3, MaxLatency: time.Hour})
+
+	for i := 0; i < 7; i++ {
+		if err := batcher.Add(context.Background(), i); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	if err := batcher.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(batches) != 3 || len(batches[0]) != 3 || len(batches[1]) != 3 || len(batches[2]) != 1 {
+		t.Errorf("batches = %v, want sizes 3, 3, 1", batches)
+	}
+
+	if err := batcher.Add(context.Background(), 8); !errors.Is(err, ErrBatcherClosed) {
+		t.Errorf("Add() after Close error = %v, want ErrBatcherClosed", err)
+	}
+}
+
+func TestBatcher_FlushesAfterMaxLatency(t *testing.T) {
+	flushed := make(chan []string, 1)
+
+	batcher := NewBatcher(func(ctx context.Context, batch []string) error {
+		flushed <- batch
+		return nil
+	}, BatcherOptions[string]{MaxSize: 100, MaxLatency: 20 * time.Millisecond})
+	defer batcher.Close(context.Background())
+
+	batcher.Add(context.Background(), "a")
+
+	select {
+	case batch := <-flushed:
+		if len(batch) != 1 || batch[0] != "a" {
+			t.Errorf("batch = %v, want [a]", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("batch was not flushed after MaxLatency")
+	}
+}
+
+func TestBatcher_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+
+	batcher := NewBatcher(func(ctx context.Context, batch []int) error {
+		<-release
+		return nil
+	}, BatcherOptions[int]{MaxSize: 1, QueueSize: 1})
+
+	// The first item is taken by the blocked flush, the second fills the queue
+	batcher.Add(context.Background(), 1)
+	time.Sleep(10 * time.Millisecond)
+	batcher.Add(context.Background(), 2)
+
+	if batcher.TryAdd(3) {
+		t.Error("TryAdd() on a full queue = true, want false")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := batcher.Add(ctx, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Add() on a full queue error = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	batcher.Close(context.Background())
+}
+
+func TestBatcher_FlushReportsErrors(t *testing.T) {
+	var failed []int
+
+	batcher := NewBatcher(func(ctx context.Context, batch []int) error {
+		return errors.New("write failed")
+	}, BatcherOptions[int]{
+		MaxLatency: time.Hour,
+		OnError: func(batch []int, err error) {
+			failed = append(failed, batch...)
+		},
+	})
+	defer batcher.Close(context.Background())
+
+	batcher.Add(context.Background(), 1)
+	batcher.Add(context.Background(), 2)
+
+	if err := batcher.Flush(context.Background()); err == nil {
+		t.Error("Flush() error = nil, want flush error")
+	}
+
+	if len(failed) != 2 {
+		t.Errorf("OnError received %v, want 2 items", failed)
+	}
+}
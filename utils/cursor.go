@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/finch-technologies/go-utils/encryption/token"
+)
+
+var (
+	// ErrInvalidCursor is returned for cursors that are malformed, were tampered with or
+	// were encoded with a different secret
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCursorExpired is returned for valid cursors older than the codec's Ttl
+	ErrCursorExpired = errors.New("cursor expired")
+)
+
+// CursorOptions configures a CursorCodec
+type CursorOptions struct {
+	Secret []byte        // Encryption secret, defaults to the CURSOR_SECRET env variable
+	Ttl    time.Duration // How long cursors stay valid (default no expiry)
+}
+
+// CursorCodec turns pagination state into opaque cursors that clients can pass back but
+// can't read or alter. Cursors are tokens of the token package with the purpose "cursor",
+// so other tokens sealed with the same secret aren't accepted as cursors.
+//
+// Example:
+//
+//	codec, err := utils.NewCursorCodec(utils.CursorOptions{Ttl: time.Hour})
+//	cursor, err := codec.Encode(map[string]string{"id": lastID})
+//
+//	var state map[string]string
+//	err = codec.Decode(cursor, &state)
+type CursorCodec struct {
+	codec *token.Codec
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+type cursorEnvelope struct {
+	Value    json.RawMessage `json:"v"`
+	IssuedAt int64           `json:"t"`
+}
+
+// NewCursorCodec creates a cursor codec
+func NewCursorCodec(options ...CursorOptions) (*CursorCodec, error) {
+	opts := CursorOptions{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if len(opts.Secret) == 0 {
+		opts.Secret = []byte(os.Getenv("CURSOR_SECRET"))
+	}
+
+	if len(opts.Secret) < 16 {
+		return nil, fmt.Errorf("cursor secret must be at least 16 bytes")
+	}
+
+	codec, err := token.NewCodec(token.Options{Secrets: [][]byte{opts.Secret}, Purpose: "cursor"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CursorCodec{codec: codec, ttl: opts.Ttl, now: time.Now}, nil
+}
+
+// Encode returns an opaque cursor holding value
+func (c *CursorCodec) Encode(value any) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cursor value: %w", err)
+	}
+
+	return c.codec.Encode(cursorEnvelope{Value: raw, IssuedAt: c.now().Unix()})
+}
+
+// Decode reads the value held by cursor into value, returning ErrInvalidCursor if the
+// cursor was not produced by this codec and ErrCursorExpired if it is older than Ttl
+func (c *CursorCodec) Decode(cursor string, value any) error {
+	var envelope cursorEnvelope
+	if err := c.codec.Decode(cursor, &envelope); err != nil {
+		return ErrInvalidCursor
+	}
+
+	if c.ttl > 0 && c.now().Sub(time.Unix(envelope.IssuedAt, 0)) > c.ttl {
+		return ErrCursorExpired
+	}
+
+	if err := json.Unmarshal(envelope.Value, value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return nil
+}
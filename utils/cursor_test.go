@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/finch-technologies/go-utils/encryption/token"
+)
+
+type pageState struct {
+	ID    string `json:"id"`
+	Score int    `json:"score"`
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec, err := NewCursorCodec(CursorOptions{Secret: []byte("0123456789abcdef"), Ttl: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCursorCodec() error = %v", err)
+	}
+
+	other, err := NewCursorCodec(CursorOptions{Secret: []byte("fedcba9876543210")})
+	if err != nil {
+		t.Fatalf("NewCursorCodec() error = %v", err)
+	}
+
+	cursor, err := codec.Encode(pageState{ID: "user#42", Score: 7})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	// A token for another purpose sealed with the same secret
+	session, err := token.NewCodec(token.Options{Secrets: [][]byte{[]byte("0123456789abcdef")}, Purpose: "session"})
+	if err != nil {
+		t.Fatalf("NewCodec() error = %v", err)
+	}
+
+	sessionToken, err := session.Encode(pageState{ID: "user#42", Score: 7})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tampered := []byte(cursor)
+	tampered[len(tampered)/2] ^= 1
+
+	tests := []struct {
+		name    string
+		codec   *CursorCodec
+		cursor  string
+		wantErr error
+	}{
+		{name: "valid", codec: codec, cursor: cursor},
+		{name: "tampered", codec: codec, cursor: string(tampered), wantErr: ErrInvalidCursor},
+		{name: "other secret", codec: other, cursor: cursor, wantErr: ErrInvalidCursor},
+		{name: "other purpose", codec: codec, cursor: sessionToken, wantErr: ErrInvalidCursor},
+		{name: "garbage", codec: codec, cursor: "not-a-cursor", wantErr: ErrInvalidCursor},
+		{name: "empty", codec: codec, cursor: "", wantErr: ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state pageState
+			err := tt.codec.Decode(tt.cursor, &state)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && (state.ID != "user#42" || state.Score != 7) {
+				t.Errorf("Decode() = %+v", state)
+			}
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		codec.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		defer func() { codec.now = time.Now }()
+
+		var state pageState
+		if err := codec.Decode(cursor, &state); !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("Decode() error = %v, want ErrCursorExpired", err)
+		}
+	})
+}
+
+func TestNewCursorCodec_RequiresSecret(t *testing.T) {
+	t.Setenv("CURSOR_SECRET", "")
+
+	if _, err := NewCursorCodec(); err == nil {
+		t.Error("expected error without a secret")
+	}
+}
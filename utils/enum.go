@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidEnum is returned when parsing a value that isn't one of an enum's values
+var ErrInvalidEnum = errors.New("invalid enum value")
+
+// Enum is the set of values of a string-backed enum type. It parses and validates values,
+// and its DecodeJSON and DecodeText back the enum type's unmarshalling methods.
+//
+// Example:
+//
+//	type Color string
+//
+//	const (
+//	    ColorRed  Color = "red"
+//	    ColorBlue Color = "blue"
+//	)
+//
+//	var Colors = utils.NewEnum(ColorRed, ColorBlue)
+//
+//	func (c *Color) UnmarshalJSON(data []byte) error { return Colors.DecodeJSON(data, c) }
+//	func (c *Color) UnmarshalText(text []byte) error { return Colors.DecodeText(text, c) }
+//
+//	color, err := Colors.Parse("Red") // ColorRed
+type Enum[T ~string] struct {
+	name   string
+	values []T
+}
+
+// NewEnum creates an enum of values
+func NewEnum[T ~string](values ...T) *Enum[T] {
+	var zero T
+
+	return &Enum[T]{
+		name:   reflect.TypeOf(zero).Name(),
+		values: append([]T{}, values...),
+	}
+}
+
+// Values returns the values of the enum in declaration order
+func (e *Enum[T]) Values() []T {
+	return append([]T{}, e.values...)
+}
+
+// Strings returns the values of the enum as strings
+func (e *Enum[T]) Strings() []string {
+	values := make([]string, len(e.values))
+	for i, value := range e.values {
+		values[i] = string(value)
+	}
+	return values
+}
+
+// Valid reports whether value is one of the enum's values
+func (e *Enum[T]) Valid(value T) bool {
+	for _, v := range e.values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse returns the value matching s, ignoring case and surrounding whitespace. It returns
+// an error wrapping ErrInvalidEnum and listing the valid values otherwise.
+func (e *Enum[T]) Parse(s string) (T, error) {
+	if value := T(s); e.Valid(value) {
+		return value, nil
+	}
+
+	trimmed := strings.TrimSpace(s)
+
+	for _, value := range e.values {
+		if strings.EqualFold(string(value), trimmed) {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q is not a %s, expected one of %s", ErrInvalidEnum, s, e.name, strings.Join(e.Strings(), ", "))
+}
+
+// MustParse works like Parse but panics on invalid values, for constants and tests
+func (e *Enum[T]) MustParse(s string) T {
+	value, err := e.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// DecodeJSON decodes a JSON string into target, validating it. Null and the empty string
+// leave target unchanged, so optional fields can be omitted.
+func (e *Enum[T]) DecodeJSON(data []byte, target *T) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %s must be a string", ErrInvalidEnum, e.name)
+	}
+
+	return e.DecodeText([]byte(s), target)
+}
+
+// DecodeText decodes text into target, validating it. Empty text leaves target unchanged.
+func (e *Enum[T]) DecodeText(text []byte, target *T) error {
+	if len(text) == 0 {
+		return nil
+	}
+
+	value, err := e.Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*target = value
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type testColor string
+
+const (
+	testColorRed  testColor = "red"
+	testColorBlue testColor = "blue"
+)
+
+var testColors = NewEnum(testColorRed, testColorBlue)
+
+func (c *testColor) UnmarshalJSON(data []byte) error { return testColors.DecodeJSON(data, c) }
+
+func TestEnum_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    testColor
+		wantErr bool
+	}{
+		{name: "exact", input: "red", want: testColorRed},
+		{name: "case insensitive", input: "BLUE", want: testColorBlue},
+		{name: "whitespace", input: " Red ", want: testColorRed},
+		{name: "unknown", input: "green", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testColors.Parse(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEnum) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInvalidEnum", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnum_Values(t *testing.T) {
+	values := testColors.Values()
+	if len(values) != 2 || values[0] != testColorRed || values[1] != testColorBlue {
+		t.Fatalf("Values() = %v", values)
+	}
+
+	// Callers can't modify the enum through the returned slice
+	values[0] = "green"
+	if !testColors.Valid(testColorRed) || testColors.Valid("green") {
+		t.Error("Values() returned the enum's own slice")
+	}
+}
+
+func TestEnum_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    testColor
+		wantErr bool
+	}{
+		{name: "valid", input: `{"color":"Blue"}`, want: testColorBlue},
+		{name: "null", input: `{"color":null}`, want: testColorRed},
+		{name: "empty", input: `{"color":""}`, want: testColorRed},
+		{name: "invalid", input: `{"color":"green"}`, wantErr: true},
+		{name: "not a string", input: `{"color":1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := struct {
+				Color testColor `json:"color"`
+			}{Color: testColorRed}
+
+			err := json.Unmarshal([]byte(tt.input), &value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEnum) {
+					t.Fatalf("Unmarshal(%s) error = %v, want ErrInvalidEnum", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.input, err)
+			}
+			if value.Color != tt.want {
+				t.Errorf("Unmarshal(%s) = %q, want %q", tt.input, value.Color, tt.want)
+			}
+		})
+	}
+}
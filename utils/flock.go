@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrLockTimeout is returned when a file lock isn't acquired within its timeout
+	ErrLockTimeout = errors.New("timed out waiting for file lock")
+	// ErrLockHeld is returned with NoWait when the file lock is held elsewhere
+	ErrLockHeld = errors.New("file lock is held elsewhere")
+)
+
+// FileLockOptions configures LockFile
+type FileLockOptions struct {
+	Shared        bool          // Take a shared (read) lock instead of an exclusive one
+	Timeout       time.Duration // How long to wait for the lock; 0 waits until ctx is done
+	RetryInterval time.Duration // How often to retry while the lock is held elsewhere (default 50ms)
+	NoWait        bool          // Return ErrLockHeld straight away instead of waiting for the lock
+}
+
+// FileLock is an advisory lock held on a lock file: a flock on unix and a LockFileEx
+// lock on windows. Other platforms return errors.ErrUnsupported.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile takes an advisory lock on path, creating the file and its directory if needed,
+// and waits until the lock is free, the timeout passes or ctx is done. Locks are held per
+// call, so they exclude other goroutines as well as other processes, and are released by
+// the kernel if the process exits without calling Unlock.
+//
+// Example:
+//
+//	lock, err := utils.LockFile(ctx, ".storage/state.json.lock", utils.FileLockOptions{Timeout: 5 * time.Second})
+//	if err != nil {
+//	    return err
+//	}
+//	defer lock.Unlock()
+func LockFile(ctx context.Context, path string, options ...FileLockOptions) (*FileLock, error) {
+	var opts FileLockOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.RetryInterval = DurationOrDefault(opts.RetryInterval, 50*time.Millisecond)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	// Blocking locks can't be interrupted, so poll without blocking to honour ctx
+	for {
+		locked, err := tryLock(file, opts.Shared)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock file: %w", err)
+		}
+
+		if locked {
+			return &FileLock{file: file}, nil
+		}
+
+		if opts.NoWait {
+			file.Close()
+			return nil, fmt.Errorf("%w: %s", ErrLockHeld, path)
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w: %s", ErrLockTimeout, path)
+			}
+			return nil, ctx.Err()
+		case <-time.After(opts.RetryInterval):
+		}
+	}
+}
+
+// Unlock releases the lock and closes the lock file. The lock file is left in place, since
+// removing it would race with processes waiting on it.
+func (l *FileLock) Unlock() error {
+	if l.file == nil {
+		return nil
+	}
+
+	err := unlock(l.file)
+	closeErr := l.file.Close()
+	l.file = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to unlock file: %w", err)
+	}
+
+	return closeErr
+}
+
+// File returns the open lock file, e.g. to record the holder in it
+func (l *FileLock) File() *os.File {
+	return l.file
+}
+
+// WithFileLock calls fn while holding the lock on path
+func WithFileLock(ctx context.Context, path string, fn func() error, options ...FileLockOptions) error {
+	lock, err := LockFile(ctx, path, options...)
+	if err != nil {
+		return err
+	}
+
+	defer lock.Unlock()
+
+	return fn()
+}
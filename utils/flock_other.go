@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLock(file *os.File, shared bool) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func unlock(file *os.File) error {
+	return errors.ErrUnsupported
+}
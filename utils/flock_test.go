@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "state.lock")
+	ctx := context.Background()
+
+	lock, err := LockFile(ctx, path)
+	if err != nil {
+		t.Fatalf("LockFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		options FileLockOptions
+		wantErr error
+	}{
+		{
+			name:    "timeout",
+			ctx:     func() (context.Context, context.CancelFunc) { return ctx, func() {} },
+			options: FileLockOptions{Timeout: 100 * time.Millisecond, RetryInterval: 10 * time.Millisecond},
+			wantErr: ErrLockTimeout,
+		},
+		{
+			name:    "shared lock while exclusive is held",
+			ctx:     func() (context.Context, context.CancelFunc) { return ctx, func() {} },
+			options: FileLockOptions{Shared: true, Timeout: 100 * time.Millisecond},
+			wantErr: ErrLockTimeout,
+		},
+		{
+			name: "cancelled context",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(ctx)
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			_, err := LockFile(ctx, path, tt.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LockFile() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	acquired := make(chan error, 1)
+
+	go func() {
+		waiter, err := LockFile(ctx, path, FileLockOptions{Timeout: 2 * time.Second})
+		if err == nil {
+			waiter.Unlock()
+		}
+		acquired <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if err := <-acquired; err != nil {
+		t.Errorf("LockFile() after unlock error = %v", err)
+	}
+}
+
+func TestLockFileShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+	opts := FileLockOptions{Shared: true, Timeout: 100 * time.Millisecond}
+
+	first, err := LockFile(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("LockFile() error = %v", err)
+	}
+	defer first.Unlock()
+
+	second, err := LockFile(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("second shared LockFile() error = %v", err)
+	}
+	defer second.Unlock()
+
+	if _, err := LockFile(context.Background(), path, FileLockOptions{Timeout: 100 * time.Millisecond}); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("exclusive LockFile() error = %v, want %v", err, ErrLockTimeout)
+	}
+}
+
+func TestLockFileNoWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+
+	lock, err := LockFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("LockFile() error = %v", err)
+	}
+
+	start := time.Now()
+	if _, err := LockFile(context.Background(), path, FileLockOptions{NoWait: true}); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("LockFile() error = %v, want %v", err, ErrLockHeld)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("LockFile() waited %v with NoWait", elapsed)
+	}
+
+	lock.Unlock()
+
+	lock, err = LockFile(context.Background(), path, FileLockOptions{NoWait: true})
+	if err != nil {
+		t.Fatalf("LockFile() after Unlock error = %v", err)
+	}
+	lock.Unlock()
+}
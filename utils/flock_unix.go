@@ -0,0 +1,28 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock takes a flock on file without blocking. Returns false if it is held elsewhere.
+func tryLock(file *os.File, shared bool) (bool, error) {
+	how := syscall.LOCK_EX
+	if shared {
+		how = syscall.LOCK_SH
+	}
+
+	err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) || errors.Is(err, syscall.EINTR) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,187 @@
+// Package fuzz provides seed corpora and random input generators for fuzzing and
+// property testing parsers built on the go-utils parsing helpers.
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// AddSeeds adds string seeds to the corpus of a fuzz target
+func AddSeeds(f *testing.F, seeds ...[]string) {
+	for _, set := range seeds {
+		for _, seed := range set {
+			f.Add(seed)
+		}
+	}
+}
+
+// JSONSeeds returns valid and malformed JSON documents, including the tab and newline
+// characters stripped by utils.ParseJson
+func JSONSeeds() []string {
+	return []string{
+		`{}`,
+		`[]`,
+		`null`,
+		`"string"`,
+		`123`,
+		`{"name": "John", "age": 30}`,
+		"{\n\t\"name\": \"John\",\r\n\t\"tags\": [\"a\", \"b\"]\n}",
+		`{"nested": {"list": [1, 2.5, true, null]}}`,
+		`{"unterminated": "value`,
+		`{"trailing": 1,}`,
+		`{"escaped": "line\nbreak é"}`,
+	}
+}
+
+// DurationSeeds returns valid and invalid duration strings as accepted by time.ParseDuration
+func DurationSeeds() []string {
+	return []string{
+		"",
+		"0",
+		"5s",
+		"100ms",
+		"1h30m",
+		"-2m",
+		"1.5h",
+		"300us",
+		"10",
+		"s",
+		"9999999999999h",
+		"invalid",
+	}
+}
+
+// RegexSeeds returns input strings for named sub-match patterns
+func RegexSeeds() []string {
+	return []string{
+		"",
+		"john@example.com",
+		"no match here",
+		"a@b.c d@e.f",
+		"@@@",
+		"unicode ü@é.com",
+	}
+}
+
+// S3URLSeeds returns S3 URLs in all supported formats along with malformed variants
+func S3URLSeeds() []string {
+	return []string{
+		"s3://bucket/key.txt",
+		"s3://bucket/path/to/key.txt",
+		"s3://bucket",
+		"https://bucket.s3.af-south-1.amazonaws.com/path/to/file.txt",
+		"https://s3.af-south-1.amazonaws.com/bucket/path/to/file.txt",
+		"https://s3-af-south-1.amazonaws.com/bucket/file.txt",
+		"https://my.dotted.bucket.s3.amazonaws.com/key",
+		"https://amazonaws.com/",
+		"https://example.com/file.txt",
+		"not-a-url",
+		"://missing-scheme",
+		"",
+	}
+}
+
+const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_."
+
+// RandomString returns a random string of up to maxLength characters from a URL and key safe alphabet
+func RandomString(r *rand.Rand, maxLength int) string {
+	if maxLength <= 0 {
+		return ""
+	}
+
+	length := r.Intn(maxLength) + 1
+	sb := strings.Builder{}
+	sb.Grow(length)
+
+	for i := 0; i < length; i++ {
+		sb.WriteByte(alphabet[r.Intn(len(alphabet))])
+	}
+
+	return sb.String()
+}
+
+// RandomJSON returns a random valid JSON document nested up to depth levels
+func RandomJSON(r *rand.Rand, depth int) string {
+	kind := r.Intn(6)
+
+	if depth <= 0 && kind >= 4 {
+		kind = r.Intn(4)
+	}
+
+	switch kind {
+	case 0:
+		return "null"
+	case 1:
+		return strconv.FormatBool(r.Intn(2) == 0)
+	case 2:
+		return strconv.Itoa(r.Intn(2000) - 1000)
+	case 3:
+		return strconv.Quote(RandomString(r, 12))
+	case 4:
+		items := make([]string, r.Intn(4))
+		for i := range items {
+			items[i] = RandomJSON(r, depth-1)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		fields := make([]string, r.Intn(4))
+		for i := range fields {
+			fields[i] = fmt.Sprintf("%q: %s", RandomString(r, 8), RandomJSON(r, depth-1))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+}
+
+// RandomDuration returns a random valid duration string such as "1h20m5s"
+func RandomDuration(r *rand.Rand) string {
+	units := []string{"h", "m", "s", "ms", "us", "ns"}
+
+	sb := strings.Builder{}
+
+	if r.Intn(4) == 0 {
+		sb.WriteString("-")
+	}
+
+	parts := r.Intn(3) + 1
+	start := r.Intn(len(units) - parts + 1)
+
+	for i := 0; i < parts; i++ {
+		sb.WriteString(strconv.Itoa(r.Intn(100)))
+		sb.WriteString(units[start+i])
+	}
+
+	return sb.String()
+}
+
+// RandomS3URL returns a random S3 URL in one of the supported formats along with the
+// bucket and key it encodes
+func RandomS3URL(r *rand.Rand) (url, bucket, key string) {
+	bucket = strings.ToLower(strings.Trim(RandomString(r, 20), "-_."))
+	if bucket == "" {
+		bucket = "bucket"
+	}
+	bucket = strings.ReplaceAll(bucket, "s3", "x3")
+
+	segments := make([]string, r.Intn(3)+1)
+	for i := range segments {
+		segments[i] = RandomString(r, 10)
+	}
+	key = strings.Join(segments, "/")
+
+	region := []string{"af-south-1", "us-east-1", "eu-west-2"}[r.Intn(3)]
+
+	switch r.Intn(3) {
+	case 0:
+		url = fmt.Sprintf("s3://%s/%s", bucket, key)
+	case 1:
+		url = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+	default:
+		url = fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", region, bucket, key)
+	}
+
+	return url, bucket, key
+}
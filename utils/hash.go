@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// ErrChecksumMismatch is returned when content doesn't match its expected checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// HashFNV returns the 64-bit FNV-1a hash of s. Like HashXX it is stable across processes,
+// machines and Go versions, unlike maphash, so it can pick shards that other services agree on.
+func HashFNV(s string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(s))
+	return hasher.Sum64()
+}
+
+// HashXX returns the 64-bit xxHash of s, which is faster than HashFNV for long keys
+func HashXX(s string) uint64 {
+	return xxhash.Sum64String(s)
+}
+
+// Shard returns the shard of key among n shards, in [0, n). It uses jump consistent hashing,
+// so when n grows only about 1/n of the keys move to a new shard.
+//
+// Example:
+//
+//	table := fmt.Sprintf("events-%d", utils.Shard(userID, 8))
+func Shard(key string, n int) int {
+	if n <= 1 {
+		return 0
+	}
+
+	// Jump consistent hash, Lamping and Veach 2014
+	h := HashXX(key)
+	b, j := int64(-1), int64(0)
+
+	for j < int64(n) {
+		b = j
+		h = h*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((h>>33)+1)))
+	}
+
+	return int(b)
+}
+
+// CanonicalJSON encodes v as JSON with object keys sorted and without HTML escaping, so
+// equal values always encode to the same bytes whether they are structs or maps
+func CanonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	// Decoding into any and encoding again sorts the keys of struct fields as well as maps,
+	// and numbers keep their exact text
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(decoded); err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// HashStructCanonical returns the hex SHA-256 of the canonical JSON of v, e.g. to
+// deduplicate messages or cache entries by content
+//
+// Example:
+//
+//	id, err := utils.HashStructCanonical(order)
+func HashStructCanonical(v any) (string, error) {
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ConsistentHashRingOptions configures a ConsistentHashRing
+type ConsistentHashRingOptions[T comparable] struct {
+	Replicas int            // Points per member on the ring; more spread keys more evenly (default 100)
+	Name     func(T) string // Identifies a member on the ring (default fmt.Sprint)
+}
+
+// ConsistentHashRing distributes keys across members so that adding or removing a member
+// only moves the keys of that member. It is safe for concurrent use.
+//
+// Example:
+//
+//	ring := utils.NewConsistentHashRing[string]()
+//	ring.Add("worker-1", "worker-2", "worker-3")
+//	worker, ok := ring.Get(jobID)
+type ConsistentHashRing[T comparable] struct {
+	opts ConsistentHashRingOptions[T]
+
+	mu      sync.RWMutex
+	points  []uint64
+	owners  map[uint64]T
+	members map[T]struct{}
+}
+
+// NewConsistentHashRing creates an empty ring
+func NewConsistentHashRing[T comparable](options ...ConsistentHashRingOptions[T]) *ConsistentHashRing[T] {
+	opts := ConsistentHashRingOptions[T]{}
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	opts.Replicas = IntOrDefault(opts.Replicas, 100)
+
+	if opts.Name == nil {
+		opts.Name = func(member T) string { return fmt.Sprint(member) }
+	}
+
+	return &ConsistentHashRing[T]{
+		opts:    opts,
+		owners:  make(map[uint64]T),
+		members: make(map[T]struct{}),
+	}
+}
+
+// Add adds members to the ring, ignoring members it already has
+func (r *ConsistentHashRing[T]) Add(members ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, member := range members {
+		if _, ok := r.members[member]; ok {
+			continue
+		}
+
+		r.members[member] = struct{}{}
+
+		for _, point := range r.memberPoints(member) {
+			r.owners[point] = member
+		}
+	}
+
+	r.sortPoints()
+}
+
+// Remove removes members from the ring
+func (r *ConsistentHashRing[T]) Remove(members ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, member := range members {
+		if _, ok := r.members[member]; !ok {
+			continue
+		}
+
+		delete(r.members, member)
+
+		for _, point := range r.memberPoints(member) {
+			if r.owners[point] == member {
+				delete(r.owners, point)
+			}
+		}
+	}
+
+	r.sortPoints()
+}
+
+// Get returns the member key belongs to, or false if the ring is empty
+func (r *ConsistentHashRing[T]) Get(key string) (T, bool) {
+	members := r.GetN(key, 1)
+	if len(members) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	return members[0], true
+}
+
+// GetN returns up to n distinct members for key in ring order, e.g. to replicate a key or
+// fail over to the next member. The first is the member Get returns.
+func (r *ConsistentHashRing[T]) GetN(key string, n int) []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 || n <= 0 {
+		return nil
+	}
+
+	n = min(n, len(r.members))
+
+	hash := HashXX(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+
+	result := make([]T, 0, n)
+	seen := make(map[T]struct{}, n)
+
+	for i := 0; i < len(r.points) && len(result) < n; i++ {
+		member := r.owners[r.points[(start+i)%len(r.points)]]
+
+		if _, ok := seen[member]; ok {
+			continue
+		}
+
+		seen[member] = struct{}{}
+		result = append(result, member)
+	}
+
+	return result
+}
+
+// Members returns the members of the ring in no particular order
+func (r *ConsistentHashRing[T]) Members() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]T, 0, len(r.members))
+	for member := range r.members {
+		members = append(members, member)
+	}
+
+	return members
+}
+
+// Len returns the number of members
+func (r *ConsistentHashRing[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.members)
+}
+
+func (r *ConsistentHashRing[T]) memberPoints(member T) []uint64 {
+	name := r.opts.Name(member)
+	points := make([]uint64, r.opts.Replicas)
+
+	for i := range points {
+		points[i] = HashXX(name + "#" + strconv.Itoa(i))
+	}
+
+	return points
+}
+
+func (r *ConsistentHashRing[T]) sortPoints() {
+	r.points = r.points[:0]
+
+	for point := range r.owners {
+		r.points = append(r.points, point)
+	}
+
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// ChecksumSHA256 returns the hex SHA-256 of data
+func ChecksumSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewChecksumReader returns a reader over r that hashes what is read with h and, once r is
+// exhausted, fails with ErrChecksumMismatch instead of io.EOF unless the sum equals want.
+// Closing it closes r.
+//
+// Example:
+//
+//	want, _ := hex.DecodeString(expectedSHA256)
+//	r = utils.NewChecksumReader(r, sha256.New(), want)
+//	_, err := io.Copy(dst, r) // err matches utils.ErrChecksumMismatch on corruption
+func NewChecksumReader(r io.ReadCloser, h hash.Hash, want []byte) io.ReadCloser {
+	return &checksumReader{r: r, h: h, want: want}
+}
+
+type checksumReader struct {
+	r    io.ReadCloser
+	h    hash.Hash
+	want []byte
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.h.Write(p[:n])
+
+	if err == io.EOF && !bytes.Equal(c.h.Sum(nil), c.want) {
+		return n, ErrChecksumMismatch
+	}
+
+	return n, err
+}
+
+func (c *checksumReader) Close() error {
+	return c.r.Close()
+}
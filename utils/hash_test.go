@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestHashFNV(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint64
+	}{
+		{"", 0xcbf29ce484222325},
+		{"a", 0xaf63dc4c8601ec8c},
+		{"foobar", 0x85944171f73967e8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := HashFNV(tt.input); got != tt.want {
+				t.Errorf("HashFNV(%q) = %#x, want %#x", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShard(t *testing.T) {
+	counts := make([]int, 8)
+	moved := 0
+
+	for i := 0; i < 8000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+
+		shard := Shard(key, 8)
+		if shard < 0 || shard >= 8 {
+			t.Fatalf("Shard(%q, 8) = %d, out of range", key, shard)
+		}
+
+		if Shard(key, 8) != shard {
+			t.Fatalf("Shard(%q, 8) is not stable", key)
+		}
+
+		counts[shard]++
+
+		if Shard(key, 9) != shard {
+			moved++
+		}
+	}
+
+	for shard, count := range counts {
+		if count < 800 || count > 1200 {
+			t.Errorf("shard %d has %d of 8000 keys, expected about 1000", shard, count)
+		}
+	}
+
+	// Growing to 9 shards should move about 1/9 of the keys
+	if moved > 1200 {
+		t.Errorf("growing to 9 shards moved %d of 8000 keys", moved)
+	}
+
+	if got := Shard("key", 0); got != 0 {
+		t.Errorf("Shard(key, 0) = %d, want 0", got)
+	}
+}
+
+func TestHashStructCanonical(t *testing.T) {
+	type order struct {
+		ID    string  `json:"id"`
+		Total float64 `json:"total"`
+		Note  string  `json:"note"`
+	}
+
+	structHash, err := HashStructCanonical(order{ID: "o1", Total: 9.5, Note: "<gift>"})
+	if err != nil {
+		t.Fatalf("HashStructCanonical() error = %v", err)
+	}
+
+	mapHash, err := HashStructCanonical(map[string]any{"total": 9.5, "note": "<gift>", "id": "o1"})
+	if err != nil {
+		t.Fatalf("HashStructCanonical() error = %v", err)
+	}
+
+	if structHash != mapHash {
+		t.Errorf("expected a struct and an equal map to hash the same, got %s and %s", structHash, mapHash)
+	}
+
+	otherHash, _ := HashStructCanonical(order{ID: "o2", Total: 9.5, Note: "<gift>"})
+	if otherHash == structHash {
+		t.Error("expected different values to hash differently")
+	}
+
+	data, _ := CanonicalJSON(order{ID: "o1", Total: 9.5, Note: "<gift>"})
+	if want := `{"id":"o1","note":"<gift>","total":9.5}`; string(data) != want {
+		t.Errorf("CanonicalJSON() = %s, want %s", data, want)
+	}
+}
+
+func TestConsistentHashRing(t *testing.T) {
+	ring := NewConsistentHashRing[string]()
+
+	if _, ok := ring.Get("key"); ok {
+		t.Fatal("expected an empty ring to have no member")
+	}
+
+	ring.Add("worker-1", "worker-2", "worker-3", "worker-1")
+
+	if ring.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", ring.Len())
+	}
+
+	before := make(map[string]string)
+	counts := make(map[string]int)
+
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("job-%d", i)
+		member, _ := ring.Get(key)
+		before[key] = member
+		counts[member]++
+	}
+
+	for member, count := range counts {
+		if count < 600 {
+			t.Errorf("%s got only %d of 3000 keys", member, count)
+		}
+	}
+
+	ring.Remove("worker-2")
+
+	for key, member := range before {
+		after, _ := ring.Get(key)
+
+		if member != "worker-2" && after != member {
+			t.Fatalf("key %s moved from %s to %s though its member wasn't removed", key, member, after)
+		}
+
+		if after == "worker-2" {
+			t.Fatalf("key %s still maps to the removed member", key)
+		}
+	}
+
+	members := ring.GetN("job-1", 5)
+	if len(members) != 2 || members[0] == members[1] {
+		t.Errorf("GetN() = %v, want 2 distinct members", members)
+	}
+}
+
+func TestChecksumReader(t *testing.T) {
+	data := []byte("archived document")
+	sum := sha256.Sum256(data)
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr error
+	}{
+		{"match", data, nil},
+		{"corrupted", []byte("archived d0cument"), ErrChecksumMismatch},
+		{"truncated", data[:8], ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewChecksumReader(io.NopCloser(bytes.NewReader(tt.content)), sha256.New(), sum[:])
+
+			got, err := io.ReadAll(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.content) {
+				t.Errorf("ReadAll() = %q, want %q", got, tt.content)
+			}
+		})
+	}
+
+	if got := ChecksumSHA256(data); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("ChecksumSHA256() = %s", got)
+	}
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRUCache is a size-bounded in-memory cache that evicts the least recently used entry when
+// full. Entries also expire after the TTL given to NewLRUCache, if any. It is safe for
+// concurrent use.
+//
+// Example:
+//
+//	cache := utils.NewLRUCache[string, Config](1000, 30*time.Second)
+//	if config, ok := cache.Get(name); ok {
+//	    return config
+//	}
+type LRUCache[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List // Most recently used first
+}
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// NewLRUCache creates a cache holding up to size entries (at least 1), each for up to ttl,
+// or until evicted if ttl is 0
+func NewLRUCache[K comparable, V any](size int, ttl time.Duration) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
+		size:    max(size, 1),
+		ttl:     ttl,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value cached for key, or false if there is none or it has expired
+func (c *LRUCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	entry := element.Value.(*lruEntry[K, V])
+
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(element)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// Set caches value for key, evicting the least recently used entry if the cache is full
+func (c *LRUCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete removes key from the cache
+func (c *LRUCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Purge removes every entry
+func (c *LRUCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of cached entries, including expired ones not yet removed
+func (c *LRUCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRUCache[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache[string, int](2, 0)
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	// Reading a makes b the least recently used
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Fatalf("Get(a) = %d, %v, want 1, true", value, ok)
+	}
+
+	cache.Set("c", 3)
+
+	tests := []struct {
+		key    string
+		want   int
+		wantOk bool
+	}{
+		{"a", 1, true},
+		{"b", 0, false},
+		{"c", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, ok := cache.Get(tt.key)
+			if value != tt.want || ok != tt.wantOk {
+				t.Errorf("Get(%s) = %d, %v, want %d, %v", tt.key, value, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	cache.Set("a", 10)
+	if value, _ := cache.Get("a"); value != 10 {
+		t.Errorf("Get(a) after update = %d, want 10", value)
+	}
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected a to be deleted")
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("Len() after Purge = %d, want 0", cache.Len())
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	cache := NewLRUCache[string, string](10, 50*time.Millisecond)
+
+	cache.Set("key", "value")
+
+	if value, ok := cache.Get("key"); !ok || value != "value" {
+		t.Fatalf("Get() = %q, %v, want value, true", value, ok)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected the entry to expire")
+	}
+
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d, want expired entry removed", cache.Len())
+	}
+}
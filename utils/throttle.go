@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Debounce returns a function that delays calling fn until delay has passed without
+// another call. Bursts of calls result in a single call to fn after the burst settles.
+// The returned cancel function discards any pending call.
+func Debounce(fn func(), delay time.Duration) (debounced func(), cancel func()) {
+	var mu sync.Mutex
+	var timer *time.Timer
+
+	debounced = func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		timer = time.AfterFunc(delay, fn)
+	}
+
+	cancel = func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+	}
+
+	return debounced, cancel
+}
+
+// Throttle returns a function that calls fn at most once per interval. Calls made
+// before the interval has passed are dropped; the returned function reports whether
+// fn was called.
+func Throttle(fn func(), interval time.Duration) func() bool {
+	var mu sync.Mutex
+	var last time.Time
+
+	return func() bool {
+		mu.Lock()
+		now := time.Now()
+
+		if !last.IsZero() && now.Sub(last) < interval {
+			mu.Unlock()
+			return false
+		}
+
+		last = now
+		mu.Unlock()
+
+		fn()
+
+		return true
+	}
+}
+
+// SlidingWindow counts events over a rolling time window. The window is split into
+// buckets so old events expire gradually instead of all at once.
+//
+// Example:
+//
+//	window := utils.NewSlidingWindow(time.Minute, 60)
+//	window.Inc()
+//	perSecond := window.Rate()
+type SlidingWindow struct {
+	mu         sync.Mutex
+	window     time.Duration
+	bucketSize time.Duration
+	buckets    []int64
+	head       int       // index of the bucket for the current time slot
+	headStart  time.Time // start of the current time slot
+	now        func() time.Time
+}
+
+// NewSlidingWindow creates a sliding window of the given length split into the given
+// number of buckets (default 10)
+func NewSlidingWindow(window time.Duration, buckets int) *SlidingWindow {
+	if buckets <= 0 {
+		buckets = 10
+	}
+
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	bucketSize := window / time.Duration(buckets)
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+
+	return &SlidingWindow{
+		window:     window,
+		bucketSize: bucketSize,
+		buckets:    make([]int64, buckets),
+		now:        time.Now,
+	}
+}
+
+// Inc records a single event
+func (w *SlidingWindow) Inc() {
+	w.Add(1)
+}
+
+// Add records n events
+func (w *SlidingWindow) Add(n int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance()
+	w.buckets[w.head] += n
+}
+
+// Count returns the number of events in the window
+func (w *SlidingWindow) Count() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance()
+
+	var total int64
+	for _, count := range w.buckets {
+		total += count
+	}
+
+	return total
+}
+
+// Rate returns the average number of events per second over the window
+func (w *SlidingWindow) Rate() float64 {
+	return float64(w.Count()) / w.window.Seconds()
+}
+
+// Reset clears all counts
+func (w *SlidingWindow) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.buckets {
+		w.buckets[i] = 0
+	}
+}
+
+// advance rotates the buckets forward to the current time, clearing expired slots
+func (w *SlidingWindow) advance() {
+	now := w.now().Truncate(w.bucketSize)
+
+	if w.headStart.IsZero() {
+		w.headStart = now
+		return
+	}
+
+	steps := int(now.Sub(w.headStart) / w.bucketSize)
+	if steps <= 0 {
+		return
+	}
+
+	if steps > len(w.buckets) {
+		steps = len(w.buckets)
+	}
+
+	for i := 0; i < steps; i++ {
+		w.head = (w.head + 1) % len(w.buckets)
+		w.buckets[w.head] = 0
+	}
+
+	w.headStart = now
+}
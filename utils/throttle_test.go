@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	var calls atomic.Int32
+
+	debounced, _ := Debounce(func() { calls.Add(1) }, 20*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		debounced()
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Debounce() called fn %d times, want 1", got)
+	}
+}
+
+func TestDebounceCancel(t *testing.T) {
+	var calls atomic.Int32
+
+	debounced, cancel := Debounce(func() { calls.Add(1) }, 20*time.Millisecond)
+
+	debounced()
+	cancel()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := calls.Load(); got != 0 {
+		t.Errorf("Debounce() called fn %d times after cancel, want 0", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	calls := 0
+
+	throttled := Throttle(func() { calls++ }, 50*time.Millisecond)
+
+	if !throttled() {
+		t.Error("Throttle() first call should run")
+	}
+	if throttled() {
+		t.Error("Throttle() call within interval should be dropped")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if !throttled() {
+		t.Error("Throttle() call after interval should run")
+	}
+	if calls != 2 {
+		t.Errorf("Throttle() called fn %d times, want 2", calls)
+	}
+}
+
+func TestSlidingWindowDefaults(t *testing.T) {
+	window := NewSlidingWindow(-time.Second, -1)
+
+	if len(window.buckets) != 10 || window.window != time.Minute {
+		t.Errorf("NewSlidingWindow(-1s, -1) = %d buckets over %v, want 10 over 1m", len(window.buckets), window.window)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	window := NewSlidingWindow(10*time.Second, 10)
+	window.now = func() time.Time { return now }
+
+	window.Add(5)
+	now = now.Add(3 * time.Second)
+	window.Inc()
+
+	if got := window.Count(); got != 6 {
+		t.Errorf("Count() = %d, want 6", got)
+	}
+	if got := window.Rate(); got != 0.6 {
+		t.Errorf("Rate() = %v, want 0.6", got)
+	}
+
+	// The first 5 events fall out of the window
+	now = now.Add(8 * time.Second)
+	if got := window.Count(); got != 1 {
+		t.Errorf("Count() after partial expiry = %d, want 1", got)
+	}
+
+	now = now.Add(time.Hour)
+	if got := window.Count(); got != 0 {
+		t.Errorf("Count() after full expiry = %d, want 0", got)
+	}
+
+	window.Add(3)
+	window.Reset()
+	if got := window.Count(); got != 0 {
+		t.Errorf("Count() after Reset = %d, want 0", got)
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Byte     int64 = 1
+	Kilobyte       = 1024 * Byte
+	Megabyte       = 1024 * Kilobyte
+	Gigabyte       = 1024 * Megabyte
+	Terabyte       = 1024 * Gigabyte
+	Petabyte       = 1024 * Terabyte
+)
+
+var (
+	byteUnits = map[string]int64{
+		"":    Byte,
+		"b":   Byte,
+		"k":   Kilobyte,
+		"kb":  Kilobyte,
+		"kib": Kilobyte,
+		"m":   Megabyte,
+		"mb":  Megabyte,
+		"mib": Megabyte,
+		"g":   Gigabyte,
+		"gb":  Gigabyte,
+		"gib": Gigabyte,
+		"t":   Terabyte,
+		"tb":  Terabyte,
+		"tib": Terabyte,
+		"p":   Petabyte,
+		"pb":  Petabyte,
+		"pib": Petabyte,
+	}
+
+	bytesPattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z]*)$`)
+	daysPattern  = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+)
+
+// ParseBytes parses a human readable size such as "10MB", "1.5 GiB" or "512" (bytes).
+// Units are case insensitive and always binary, so "1KB" and "1KiB" are both 1024 bytes,
+// matching what AWS means by part and message size limits.
+func ParseBytes(s string) (int64, error) {
+	matches := bytesPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	unit, ok := byteUnits[strings.ToLower(matches[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit %q in %q", matches[2], s)
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+
+	bytes := value * float64(unit)
+	// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+
+	return int64(bytes), nil
+}
+
+// FormatBytes formats a byte count using the largest unit that keeps the value at least 1,
+// e.g. 1536 becomes "1.5KB". The result can be parsed with ParseBytes.
+func FormatBytes(bytes int64) string {
+	units := []struct {
+		size int64
+		name string
+	}{
+		{Petabyte, "PB"},
+		{Terabyte, "TB"},
+		{Gigabyte, "GB"},
+		{Megabyte, "MB"},
+		{Kilobyte, "KB"},
+	}
+
+	sign := ""
+	if bytes < 0 {
+		sign = "-"
+		bytes = -bytes
+	}
+
+	for _, unit := range units {
+		if bytes >= unit.size {
+			value := strconv.FormatFloat(float64(bytes)/float64(unit.size), 'f', 2, 64)
+			value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+			return sign + value + unit.name
+		}
+	}
+
+	return fmt.Sprintf("%s%dB", sign, bytes)
+}
+
+// ParseDurationWithDays parses a duration like time.ParseDuration but also accepts days
+// ("d") and weeks ("w"), e.g. "2d4h" or "1w12h30m".
+func ParseDurationWithDays(s string) (time.Duration, error) {
+	input := strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(input, "-")
+	input = strings.TrimLeft(input, "+-")
+
+	var total time.Duration
+
+	for _, match := range daysPattern.FindAllStringSubmatch(input, -1) {
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+
+		unit := 24 * time.Hour
+		if match[2] == "w" {
+			unit *= 7
+		}
+
+		total += time.Duration(value * float64(unit))
+	}
+
+	rest := daysPattern.ReplaceAllString(input, "")
+
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		total += d
+	} else if input == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	if negative {
+		total = -total
+	}
+
+	return total, nil
+}
+
+// FormatDurationWithDays formats a duration using days for whole days and omitting zero
+// units, e.g. 52h becomes "2d4h". The result can be parsed with ParseDurationWithDays.
+func FormatDurationWithDays(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(sign)
+
+	days := d / (24 * time.Hour)
+	if days > 0 {
+		sb.WriteString(strconv.FormatInt(int64(days), 10) + "d")
+		d -= days * 24 * time.Hour
+	}
+
+	hours := d / time.Hour
+	if hours > 0 {
+		sb.WriteString(strconv.FormatInt(int64(hours), 10) + "h")
+		d -= hours * time.Hour
+	}
+
+	minutes := d / time.Minute
+	if minutes > 0 {
+		sb.WriteString(strconv.FormatInt(int64(minutes), 10) + "m")
+		d -= minutes * time.Minute
+	}
+
+	if d > 0 {
+		sb.WriteString(d.String())
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"1KB", 1024, false},
+		{"1kib", 1024, false},
+		{"10MB", 10 * Megabyte, false},
+		{"1.5 GB", 3 * Gigabyte / 2, false},
+		{"5m", 5 * Megabyte, false},
+		{"2TB", 2 * Terabyte, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"10XB", 0, true},
+		{"-5MB", 0, true},
+		{"99999999PB", 0, true},
+		{"8192PB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBytes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBytes(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBytes(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input int64
+		want  string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{1024, "1KB"},
+		{1536, "1.5KB"},
+		{10 * Megabyte, "10MB"},
+		{-2 * Gigabyte, "-2GB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.input); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.input, got, tt.want)
+		}
+
+		if tt.input >= 0 {
+			parsed, err := ParseBytes(FormatBytes(tt.input))
+			if err != nil || parsed != tt.input {
+				t.Errorf("ParseBytes(FormatBytes(%d)) = %d, %v", tt.input, parsed, err)
+			}
+		}
+	}
+}
+
+func TestParseDurationWithDays(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"2d4h", 52 * time.Hour, false},
+		{"1w", 7 * 24 * time.Hour, false},
+		{"1w2d30m", 9*24*time.Hour + 30*time.Minute, false},
+		{"0.5d", 12 * time.Hour, false},
+		{"90s", 90 * time.Second, false},
+		{"-1d", -24 * time.Hour, false},
+		{"", 0, true},
+		{"2x", 0, true},
+		{"d", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDurationWithDays(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDurationWithDays(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDurationWithDays(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDurationWithDays(t *testing.T) {
+	tests := []struct {
+		input time.Duration
+		want  string
+	}{
+		{0, "0s"},
+		{52 * time.Hour, "2d4h"},
+		{24*time.Hour + 90*time.Second, "1d1m30s"},
+		{1500 * time.Millisecond, "1.5s"},
+		{-26 * time.Hour, "-1d2h"},
+	}
+
+	for _, tt := range tests {
+		got := FormatDurationWithDays(tt.input)
+		if got != tt.want {
+			t.Errorf("FormatDurationWithDays(%v) = %q, want %q", tt.input, got, tt.want)
+		}
+
+		parsed, err := ParseDurationWithDays(got)
+		if err != nil || parsed != tt.input {
+			t.Errorf("ParseDurationWithDays(%q) = %v, %v, want %v", got, parsed, err, tt.input)
+		}
+	}
+}
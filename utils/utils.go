@@ -4,16 +4,17 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/rand"
-	rand2 "math/rand/v2"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -106,9 +107,26 @@ const (
 	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
 )
 
-var src = rand.NewSource(time.Now().UnixNano())
+var (
+	randMu sync.Mutex
+	src    = rand.NewSource(time.Now().UnixNano())
+	rng    = rand.New(src)
+)
+
+// SetRandomSource replaces the random source used by RandomString, RandomInt and SleepRandom.
+// Pass a seeded source (e.g. rand.NewSource(42)) to make retry and jitter behaviour reproducible in tests.
+func SetRandomSource(source rand.Source) {
+	randMu.Lock()
+	defer randMu.Unlock()
+
+	src = source
+	rng = rand.New(source)
+}
 
 func RandomString(length int) string {
+	randMu.Lock()
+	defer randMu.Unlock()
+
 	sb := strings.Builder{}
 	sb.Grow(length)
 	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
@@ -128,8 +146,11 @@ func RandomString(length int) string {
 }
 
 func RandomInt(min, max int) int {
+	randMu.Lock()
+	defer randMu.Unlock()
+
 	max += 1
-	return rand2.IntN(max-min) + min
+	return rng.Intn(max-min) + min
 }
 
 func SleepRandom(ctx context.Context, min, max int) {
@@ -148,6 +169,19 @@ func Hash(s string, l int) string {
 	return sha[:l]
 }
 
+// ContentKey returns a content-addressed key for content: the hex SHA-256 of the content
+// under prefix, keeping the extension of name, e.g. "content/9f86d0...0a08.pdf"
+func ContentKey(prefix string, content []byte, name string) string {
+	sum := sha256.Sum256(content)
+	key := hex.EncodeToString(sum[:]) + filepath.Ext(name)
+
+	if prefix == "" {
+		return key
+	}
+
+	return strings.TrimSuffix(prefix, "/") + "/" + key
+}
+
 func EncodeURLParams(q any) string {
 	v, _ := query.Values(q)
 	return fmt.Sprint("?", v)
@@ -253,6 +287,71 @@ func Sleep(ctx context.Context, delay time.Duration) {
 	}
 }
 
+// WaitUntil sleeps until t or until ctx is done, returning the context error in the latter case
+func WaitUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SleepWithHeartbeat sleeps for delay, calling heartbeat every interval with the remaining
+// sleep time, e.g. to extend a message's visibility timeout while backing off. Returns the
+// context error if ctx is done before the delay has passed.
+func SleepWithHeartbeat(ctx context.Context, delay, every time.Duration, heartbeat func(remaining time.Duration)) error {
+	deadline := time.Now().Add(delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var tick <-chan time.Time
+	if every > 0 && heartbeat != nil {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case <-tick:
+			heartbeat(time.Until(deadline))
+		}
+	}
+}
+
+// RemainingTime returns the time left until the context deadline. The second value is false
+// if the context has no deadline.
+func RemainingTime(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	return time.Until(deadline), true
+}
+
+// HasTimeFor reports whether the context has at least d left before its deadline and is not
+// done. Contexts without a deadline always have time. Use it to decide whether to start
+// another unit of work in a long-running consumer.
+func HasTimeFor(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	remaining, ok := RemainingTime(ctx)
+
+	return !ok || remaining >= d
+}
+
 func ParseJson[T interface{}](jsonStr string) (T, error) {
 	var data T
 	re := regexp.MustCompile(`[\t\n\r]`)
@@ -350,8 +449,8 @@ func MergeObjects[T any](objA *T, objB T) {
 	objBValue := reflect.ValueOf(objB)
 
 	for i := 0; i < fields.NumField(); i++ {
-		field := fields.Field(i)
-		if objAValue.Field(i).Interface() == reflect.Zero(field.Type).Interface() {
+		// IsZero instead of == so map and slice fields don't panic
+		if objAValue.Field(i).IsZero() {
 			objAValue.Field(i).Set(objBValue.Field(i))
 		}
 	}
@@ -2,11 +2,15 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"math/rand"
 	"regexp"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/finch-technologies/go-utils/utils/fuzz"
 )
 
 type TestStruct struct {
@@ -259,6 +263,25 @@ func TestRandomInt(t *testing.T) {
 	}
 }
 
+func TestSetRandomSource(t *testing.T) {
+	defer SetRandomSource(rand.NewSource(time.Now().UnixNano()))
+
+	SetRandomSource(rand.NewSource(42))
+	firstString := RandomString(16)
+	firstInt := RandomInt(0, 1000)
+
+	SetRandomSource(rand.NewSource(42))
+	secondString := RandomString(16)
+	secondInt := RandomInt(0, 1000)
+
+	if firstString != secondString {
+		t.Errorf("RandomString() with same seed = %s, want %s", secondString, firstString)
+	}
+	if firstInt != secondInt {
+		t.Errorf("RandomInt() with same seed = %d, want %d", secondInt, firstInt)
+	}
+}
+
 func TestSleepRandom(t *testing.T) {
 	ctx := context.Background()
 	start := time.Now()
@@ -472,6 +495,77 @@ func TestRegexSubMatch(t *testing.T) {
 	}
 }
 
+func TestParseJsonProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		doc := fuzz.RandomJSON(r, 3)
+
+		var expected any
+		if err := json.Unmarshal([]byte(doc), &expected); err != nil {
+			t.Fatalf("generator produced invalid JSON %q: %v", doc, err)
+		}
+
+		if _, err := ParseJson[any](doc); err != nil {
+			t.Errorf("ParseJson(%q) returned error: %v", doc, err)
+		}
+	}
+}
+
+func FuzzParseJson(f *testing.F) {
+	fuzz.AddSeeds(f, fuzz.JSONSeeds())
+
+	f.Fuzz(func(t *testing.T, input string) {
+		result, err := ParseJson[map[string]any](input)
+		if err == nil && result == nil && strings.TrimSpace(input) != "null" {
+			t.Errorf("ParseJson(%q) returned nil map without error", input)
+		}
+	})
+}
+
+func FuzzRegexSubMatch(f *testing.F) {
+	fuzz.AddSeeds(f, fuzz.RegexSeeds())
+
+	r := regexp.MustCompile(`(?P<name>\w+)@(?P<domain>\w+\.\w+)`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		result := RegexSubMatch(r, input)
+		if len(result) != 0 && len(result) != 2 {
+			t.Errorf("RegexSubMatch(%q) returned %d groups, want 0 or 2", input, len(result))
+		}
+	})
+}
+
+func TestParseTimeoutProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		input := fuzz.RandomDuration(r)
+
+		expected, err := time.ParseDuration(input)
+		if err != nil {
+			t.Fatalf("generator produced invalid duration %q: %v", input, err)
+		}
+
+		if result := ParseTimeout(input); result != expected {
+			t.Errorf("ParseTimeout(%q) = %v, want %v", input, result, expected)
+		}
+	}
+}
+
+func FuzzParseTimeout(f *testing.F) {
+	fuzz.AddSeeds(f, fuzz.DurationSeeds())
+
+	f.Fuzz(func(t *testing.T, input string) {
+		result := ParseTimeout(input)
+		if expected, err := time.ParseDuration(input); err == nil && result != expected {
+			t.Errorf("ParseTimeout(%q) = %v, want %v", input, result, expected)
+		} else if err != nil && result != 30*time.Second {
+			t.Errorf("ParseTimeout(%q) = %v, want default 30s", input, result)
+		}
+	})
+}
+
 func TestParseInt(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -555,6 +649,20 @@ func TestMergeObjects(t *testing.T) {
 	}
 }
 
+func TestMergeObjects_MapFields(t *testing.T) {
+	type withMap struct {
+		Name   string
+		Labels map[string]string
+	}
+
+	objA := withMap{Labels: map[string]string{"a": "1"}}
+	MergeObjects(&objA, withMap{Name: "default", Labels: map[string]string{"b": "2"}})
+
+	if objA.Name != "default" || objA.Labels["a"] != "1" || len(objA.Labels) != 1 {
+		t.Errorf("MergeObjects() = %+v", objA)
+	}
+}
+
 func TestMergeObjects_InvalidTypes(t *testing.T) {
 	// Test with nil pointer
 	var nilPtr *TestStruct
@@ -588,3 +696,65 @@ func TestTryCatch(t *testing.T) {
 		t.Errorf("TryCatch() caught error = %s, want 'test panic'", caughtError.Error())
 	}
 }
+
+func TestWaitUntil(t *testing.T) {
+	start := time.Now()
+
+	if err := WaitUntil(context.Background(), start.Add(20*time.Millisecond)); err != nil {
+		t.Fatalf("WaitUntil() error = %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("WaitUntil() returned early")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := WaitUntil(ctx, time.Now().Add(time.Hour)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitUntil() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSleepWithHeartbeat(t *testing.T) {
+	beats := 0
+
+	err := SleepWithHeartbeat(context.Background(), 55*time.Millisecond, 10*time.Millisecond, func(remaining time.Duration) {
+		beats++
+		if remaining > 55*time.Millisecond {
+			t.Errorf("heartbeat remaining = %v, want <= 55ms", remaining)
+		}
+	})
+
+	if err != nil {
+		t.Fatalf("SleepWithHeartbeat() error = %v", err)
+	}
+	if beats < 3 {
+		t.Errorf("SleepWithHeartbeat() heartbeats = %d, want at least 3", beats)
+	}
+}
+
+func TestHasTimeFor(t *testing.T) {
+	if !HasTimeFor(context.Background(), time.Hour) {
+		t.Error("HasTimeFor() should be true without a deadline")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	remaining, ok := RemainingTime(ctx)
+	if !ok || remaining <= 0 || remaining > time.Second {
+		t.Errorf("RemainingTime() = %v, %v", remaining, ok)
+	}
+
+	if !HasTimeFor(ctx, 100*time.Millisecond) {
+		t.Error("HasTimeFor(100ms) should be true with 1s left")
+	}
+	if HasTimeFor(ctx, time.Minute) {
+		t.Error("HasTimeFor(1m) should be false with 1s left")
+	}
+
+	cancel()
+	if HasTimeFor(ctx, 0) {
+		t.Error("HasTimeFor() should be false for a cancelled context")
+	}
+}